@@ -22,6 +22,8 @@ type WorkerDaemonOption struct {
 	TaskWorkspaceGUID string
 	TLSEnabled        bool
 	DefaultConfigFile string
+	MetricsAddr       string
+	Labels            string
 }
 
 func parseDaemonWorkerOption() *WorkerDaemonOption {
@@ -39,6 +41,8 @@ func parseDaemonWorkerOption() *WorkerDaemonOption {
 	flag.BoolVar(&option.NoFilesync, "nf", option.NoFilesync, "disable file sync")
 	flag.BoolVar(&option.TLSEnabled, "tls", false, "use TLS for RPC and filesync")
 	flag.StringVar(&option.DefaultConfigFile, "f", conf.WorkerDefaultConfigFile, "worker default config file")
+	flag.StringVar(&option.MetricsAddr, "metrics", "", "worker metrics listen address,such as 0.0.0.0:9100; disabled if empty")
+	flag.StringVar(&option.Labels, "labels", "", "worker labels for task scheduling,such as region=cn,egress=1.2.3.4; multiple labels separated by \",\"")
 	flag.Parse()
 
 	return option
@@ -64,6 +68,8 @@ func main() {
 	conf.WorkerDefaultConfigFile = option.DefaultConfigFile
 	comm.TLSEnabled = option.TLSEnabled
 	filesync.TLSEnabled = option.TLSEnabled
+	comm.WorkerMetricsAddr = option.MetricsAddr
+	comm.WorkerLabels = option.Labels
 
 	if option.ManualSyncHost != "" && option.ManualSyncPort != "" && option.ManualSyncAuth != "" {
 		logging.RuntimeLog.Info("start onetime file sync...")
@@ -5,14 +5,22 @@ import (
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/beego/beego/v2/server/web"
 	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/hanc00l/nemo_go/pkg/apikeypool"
 	"github.com/hanc00l/nemo_go/pkg/cert"
 	"github.com/hanc00l/nemo_go/pkg/comm"
 	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/db"
 	"github.com/hanc00l/nemo_go/pkg/filesync"
+	"github.com/hanc00l/nemo_go/pkg/fulltext"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
+	"github.com/hanc00l/nemo_go/pkg/oob"
+	"github.com/hanc00l/nemo_go/pkg/storage"
 	"github.com/hanc00l/nemo_go/pkg/task/ampq"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/runner"
+	"github.com/hanc00l/nemo_go/pkg/task/serverapi"
+	"github.com/hanc00l/nemo_go/pkg/tracing"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	_ "github.com/hanc00l/nemo_go/pkg/web/routers"
 	"net/http"
@@ -26,6 +34,10 @@ type ServerOption struct {
 	TLSEnabled  bool
 	TLSCertFile string
 	TLSKeyFile  string
+	// MigrateStorage 将本地的截图及icon文件迁移到配置的存储后退出
+	MigrateStorage bool
+	// RebuildFulltext 重建全部（all）或指定workspace的全文索引后退出
+	RebuildFulltext string
 }
 
 var UrlFilterWhiteList = []string{"/"}
@@ -40,6 +52,8 @@ func parseServerOption() *ServerOption {
 	flag.BoolVar(&option.TLSEnabled, "tls", false, "use TLS for web、RPC and filesync")
 	flag.StringVar(&option.TLSKeyFile, "key", "server.key", "TLS private key file")
 	flag.StringVar(&option.TLSCertFile, "cert", "server.crt", "TLS cert file")
+	flag.BoolVar(&option.MigrateStorage, "migrate-storage", false, "migrate local screenshot and iconimage files to configured storage and exit")
+	flag.StringVar(&option.RebuildFulltext, "rebuild-fulltext", "", "rebuild fulltext index of all workspaces(all) or specified workspace guid(split by ',') and exit")
 	flag.Parse()
 
 	return option
@@ -68,6 +82,20 @@ func StartWebServer(option *ServerOption) {
 	if conf.RunMode == conf.Release {
 		web.InsertFilter("/*", web.BeforeRouter, filterLoginCheck)
 	}
+	if conf.GlobalServerConfig().Metrics.Enabled {
+		// /metrics不经过登录检查，未配置token时不注册以免指标被公开访问
+		if conf.GlobalServerConfig().Metrics.Token == "" {
+			logging.RuntimeLog.Warning("metrics enabled but token is empty, /metrics disabled")
+			logging.CLILog.Warning("metrics enabled but token is empty, /metrics disabled")
+		} else {
+			UrlFilterWhiteList = append(UrlFilterWhiteList, "/metrics")
+			web.Handler("/metrics", metrics.Handler(conf.GlobalServerConfig().Metrics.Token))
+		}
+	}
+	// 非本地存储时，webfiles由StorageController读取
+	if !storage.IsLocal() {
+		delete(web.BConfig.WebConfig.StaticDir, "/webfiles")
+	}
 
 	logging.RuntimeLog.Info("nemo server started...")
 	logging.CLILog.Info("nemo server started...")
@@ -87,6 +115,19 @@ func StartWebServer(option *ServerOption) {
 	web.Run()
 }
 
+// MigrateStorage 迁移本地的截图及icon文件到配置的存储
+func MigrateStorage() {
+	if storage.IsLocal() {
+		logging.CLILog.Error("storage type is local,no need to migrate")
+		return
+	}
+	count, err := storage.Migrate(conf.GlobalServerConfig().Web.WebFiles, storage.GetStorage())
+	if err != nil {
+		logging.CLILog.Errorf("migrate storage fail:%v", err)
+	}
+	logging.CLILog.Infof("migrate storage files total:%d", count)
+}
+
 // filterLoginCheck 全局的登录验证
 func filterLoginCheck(ctx *beegoContext.Context) {
 	for _, url := range UrlFilterWhiteList {
@@ -107,6 +148,29 @@ func filterLoginCheck(ctx *beegoContext.Context) {
 	}
 }
 
+// StartTracing 启用链路追踪
+func StartTracing() {
+	if _, err := tracing.Init("nemo-server", conf.GlobalServerConfig().Tracing); err != nil {
+		logging.CLILog.Error(err)
+		logging.RuntimeLog.Error(err)
+	}
+}
+
+// StartMetrics 注册数据库连接池的指标并启动队列任务数量的监控
+func StartMetrics() {
+	if !conf.GlobalServerConfig().Metrics.Enabled {
+		return
+	}
+	if gormDB := db.GetDB(); gormDB != nil {
+		if sqlDB, err := gormDB.DB(); err == nil {
+			if err = metrics.RegisterDB(sqlDB, conf.GlobalServerConfig().Database.Dbname); err != nil {
+				logging.RuntimeLog.Error(err)
+			}
+		}
+	}
+	go ampq.StartQueueDepthMonitor()
+}
+
 func loadCustomTaskWorkspace() {
 	ampq.CustomTaskWorkspaceMap = custom.LoadCustomTaskWorkspace()
 }
@@ -116,6 +180,14 @@ func main() {
 	if option == nil {
 		return
 	}
+	if option.MigrateStorage {
+		MigrateStorage()
+		return
+	}
+	if option.RebuildFulltext != "" {
+		fulltext.RebuildAll(option.RebuildFulltext)
+		return
+	}
 
 	if option.TLSEnabled {
 		if !utils.CheckFileExist(filepath.Join(conf.GetRootPath(), option.TLSCertFile)) || !utils.CheckFileExist(filepath.Join(conf.GetRootPath(), option.TLSKeyFile)) {
@@ -139,10 +211,17 @@ func main() {
 		comm.TLSCertFile = option.TLSCertFile
 		comm.TLSKeyFile = option.TLSKeyFile
 		go comm.StartRPCServer()
+		// worker通过RPC心跳上报能力，任务根据要求分配到满足要求的worker
+		serverapi.SelectWorkerTopic = comm.SelectWorkerTopic
+		go comm.StartWorkerTopicMonitor()
 		time.Sleep(time.Second * 1)
 	}
 	go comm.StartSaveRuntimeLog("server@nemo")
+	oob.StartServer()
+	apikeypool.StartQuotaCheck()
 	loadCustomTaskWorkspace()
+	StartTracing()
+	StartMetrics()
 	StartCronTask()
 	StartMainTaskDemon()
 	time.Sleep(time.Second * 1)
@@ -8,8 +8,12 @@ import (
 	beegoContext "github.com/beego/beego/v2/server/web/context"
 	"github.com/hanc00l/nemo_go/pkg/comm"
 	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/db"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
+	"github.com/hanc00l/nemo_go/pkg/storage"
 	"github.com/hanc00l/nemo_go/pkg/task/runner"
+	"github.com/hanc00l/nemo_go/pkg/tracing"
 	ctrl "github.com/hanc00l/nemo_go/pkg/web/controllers"
 	_ "github.com/hanc00l/nemo_go/pkg/webapi/routers"
 	"net/http"
@@ -48,6 +52,21 @@ func StartWebAPIServer() {
 	if conf.RunMode == conf.Release {
 		web.InsertFilter("/*", web.BeforeRouter, filterLoginCheck)
 	}
+	if conf.GlobalServerConfig().Metrics.Enabled {
+		// /metrics不经过登录检查，未配置token时不注册以免指标被公开访问
+		if conf.GlobalServerConfig().Metrics.Token == "" {
+			logging.RuntimeLog.Warning("metrics enabled but token is empty, /metrics disabled")
+			logging.CLILog.Warning("metrics enabled but token is empty, /metrics disabled")
+		} else {
+			UrlFilterWhiteList = append(UrlFilterWhiteList, "/metrics")
+			web.Handler("/metrics", metrics.Handler(conf.GlobalServerConfig().Metrics.Token))
+		}
+	}
+	// 非本地存储时，webfiles由StorageController读取
+	if !storage.IsLocal() {
+		delete(web.BConfig.WebConfig.StaticDir, "/webfiles")
+		web.CtrlGet("/webfiles/*", (*ctrl.StorageController).FileAction)
+	}
 	logging.RuntimeLog.Info("nemo API server started...")
 	logging.CLILog.Info("nemo API server started...")
 	addr := fmt.Sprintf("%s:%d", conf.GlobalServerConfig().WebAPI.Host, conf.GlobalServerConfig().WebAPI.Port)
@@ -71,6 +90,28 @@ func filterLoginCheck(ctx *beegoContext.Context) {
 	}
 }
 
+// StartTracing 启用链路追踪
+func StartTracing() {
+	if _, err := tracing.Init("nemo-serverapi", conf.GlobalServerConfig().Tracing); err != nil {
+		logging.CLILog.Error(err)
+		logging.RuntimeLog.Error(err)
+	}
+}
+
+// StartMetrics 注册数据库连接池的指标；队列任务数量的监控只在server中启动
+func StartMetrics() {
+	if !conf.GlobalServerConfig().Metrics.Enabled {
+		return
+	}
+	if gormDB := db.GetDB(); gormDB != nil {
+		if sqlDB, err := gormDB.DB(); err == nil {
+			if err = metrics.RegisterDB(sqlDB, conf.GlobalServerConfig().Database.Dbname); err != nil {
+				logging.RuntimeLog.Error(err)
+			}
+		}
+	}
+}
+
 func main() {
 	var noFilesync, noRPC bool
 	flag.BoolVar(&noFilesync, "nf", false, "disable file sync")
@@ -87,6 +128,8 @@ func main() {
 		go comm.StartRPCServer()
 		time.Sleep(time.Second * 1)
 	}
+	StartTracing()
+	StartMetrics()
 	StartCronTask()
 	StartMainTaskDemon()
 
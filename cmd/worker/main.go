@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/comm"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
 	"github.com/hanc00l/nemo_go/pkg/task/ampq"
+	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
+	"github.com/hanc00l/nemo_go/pkg/task/pocscan"
 	"github.com/hanc00l/nemo_go/pkg/task/workerapi"
+	"github.com/hanc00l/nemo_go/pkg/tracing"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/mem"
@@ -21,12 +27,17 @@ import (
 	"time"
 )
 
+// tracingShutdown 退出时导出剩余的span
+var tracingShutdown func(context.Context) error
+
 type WorkerOption struct {
 	Concurrency       int
 	WorkerPerformance int
 	WorkerTopic       map[string]struct{}
 	TLSEnabled        bool
 	DefaultConfigFile string
+	MetricsAddr       string
+	Labels            string
 }
 
 func parseWorkerOptions() *WorkerOption {
@@ -42,6 +53,8 @@ func parseWorkerOptions() *WorkerOption {
 	flag.StringVar(&taskWorkspaceGUID, "w", "", "workspace guid for custom task; multiple workspace separated by \",\"")
 	flag.BoolVar(&option.TLSEnabled, "tls", false, "use TLS for RPC and filesync")
 	flag.StringVar(&option.DefaultConfigFile, "f", conf.WorkerDefaultConfigFile, "worker default config file")
+	flag.StringVar(&option.MetricsAddr, "metrics", "", "worker metrics listen address,such as 0.0.0.0:9100; disabled if empty")
+	flag.StringVar(&option.Labels, "labels", "", "worker labels for task scheduling,such as region=cn,egress=1.2.3.4; multiple labels separated by \",\"")
 
 	flag.Parse()
 
@@ -99,7 +112,10 @@ func parseWorkerOptions() *WorkerOption {
 func keepAlive() {
 	time.Sleep(10 * time.Second)
 	for {
+		gobyPoolStatus := getGobyPoolStatus()
+		proxypool.GetPool().CheckHealth(false)
 		workerapi.WStatus.Lock()
+		workerapi.WStatus.GobyPool = gobyPoolStatus
 		if !comm.DoKeepAlive(&workerapi.WStatus) {
 			logging.RuntimeLog.Errorf("keep alive fail")
 			logging.CLILog.Error("keep alive fail")
@@ -109,6 +125,18 @@ func keepAlive() {
 	}
 }
 
+// getGobyPoolStatus 执行漏洞验证任务的worker，检查goby实例的健康状态并随心跳上报
+func getGobyPoolStatus() []ampq.GobyInstanceStatus {
+	workerapi.WStatus.Lock()
+	topics := workerapi.WStatus.WorkerTopics
+	workerapi.WStatus.Unlock()
+	if !strings.Contains(topics, ampq.TopicPocscan) && !strings.Contains(topics, ampq.TopicCustom) {
+		return nil
+	}
+	pocscan.GetGobyPool().CheckHealth(false)
+	return pocscan.GobyPoolStatus()
+}
+
 func checkWorkerPerformance(workerPerformance int) {
 	switch workerPerformance {
 	case 0:
@@ -132,6 +160,10 @@ func setupCloseHandler() {
 	<-quitSignal
 	logging.CLILog.Info("Ctrl+C pressed in Terminal,waiting for worker exit...")
 	logging.RuntimeLog.Info("Ctrl+C pressed in Terminal,waiting for worker exit...")
+	fingerprint.CloseBrowserPool()
+	if tracingShutdown != nil {
+		tracingShutdown(context.Background())
+	}
 	os.Exit(0)
 }
 
@@ -140,10 +172,17 @@ func initWorkerStatus(option *WorkerOption) {
 	workerapi.WStatus.CreateTime = time.Now()
 	workerapi.WStatus.UpdateTime = time.Now()
 	workerapi.WStatus.WorkerTopics = utils.SetToString(option.WorkerTopic)
+	workerapi.WStatus.Capability = workerapi.DetectCapability(ampq.ParseLabels(option.Labels))
+	workerapi.WStatus.DedicatedTopic = ampq.GetWorkerTopic(workerapi.WStatus.WorkerName)
 }
 
 func startWorker(option *WorkerOption) {
+	// 除任务模式对应的队列外，同时执行分配到worker专属队列的任务
+	topics := []string{workerapi.WStatus.DedicatedTopic}
 	for mode := range option.WorkerTopic {
+		topics = append(topics, mode)
+	}
+	for _, mode := range topics {
 		go func(topicName string, concurrency int) {
 			err := workerapi.StartWorker(topicName, concurrency)
 			if err != nil {
@@ -155,6 +194,20 @@ func startWorker(option *WorkerOption) {
 	}
 }
 
+// startMetricsServer 启动worker的指标http服务，未配置token时不启动以免指标被公开访问
+func startMetricsServer(addr string) {
+	token := conf.GlobalWorkerConfig().Metrics.Token
+	if token == "" {
+		logging.RuntimeLog.Warning("metrics token is empty, worker metrics server disabled")
+		logging.CLILog.Warning("metrics token is empty, worker metrics server disabled")
+		return
+	}
+	if err := metrics.StartServer(addr, token); err != nil {
+		logging.CLILog.Errorf("start metrics server fail:%v", err)
+		logging.RuntimeLog.Errorf("start metrics server fail:%v", err)
+	}
+}
+
 func main() {
 	//pprof
 	//if conf.RunMode == conf.Debug {
@@ -168,10 +221,19 @@ func main() {
 	}
 
 	comm.TLSEnabled = option.TLSEnabled
+	var err error
+	if tracingShutdown, err = tracing.Init("nemo-worker", conf.GlobalWorkerConfig().Tracing); err != nil {
+		logging.CLILog.Error(err)
+		logging.RuntimeLog.Error(err)
+	}
+	if option.MetricsAddr != "" {
+		go startMetricsServer(option.MetricsAddr)
+	}
 	go keepAlive()
 	go comm.StartSaveRuntimeLog(comm.GetWorkerNameBySelf())
 	checkWorkerPerformance(option.WorkerPerformance)
 	initWorkerStatus(option)
+	workerapi.InitOnlineAPIKeyProvider()
 	startWorker(option)
 	setupCloseHandler()
 }
@@ -401,9 +401,14 @@ CREATE TABLE `runtimelog` (
   `level` varchar(20) NOT NULL,
   `level_int` int(11) NOT NULL,
   `message` varchar(1000) NOT NULL,
+  `task_id` varchar(40) NOT NULL DEFAULT '',
+  `main_task_id` varchar(40) NOT NULL DEFAULT '',
+  `workspace_id` int(11) NOT NULL DEFAULT '0',
   `create_datetime` datetime NOT NULL,
   `update_datetime` datetime NOT NULL,
-  PRIMARY KEY (`id`)
+  PRIMARY KEY (`id`),
+  KEY `index_runtimelog_task_id` (`task_id`),
+  KEY `index_runtimelog_main_task_id` (`main_task_id`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1208 DEFAULT CHARSET=utf8mb4;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
@@ -695,6 +700,176 @@ CREATE TABLE `domain_http` (
   CONSTRAINT `fk_domain_http_rid` FOREIGN KEY (`r_id`) REFERENCES `domain` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB AUTO_INCREMENT=9 DEFAULT CHARSET=utf8mb4;
 /*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Table structure for table `ip_path`
+--
+
+DROP TABLE IF EXISTS `ip_path`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `ip_path` (
+  `id` int(11) unsigned NOT NULL AUTO_INCREMENT,
+  `r_id` int(11) unsigned NOT NULL,
+  `path` varchar(500) NOT NULL,
+  `url` varchar(1000) NOT NULL,
+  `status` int(11) NOT NULL,
+  `content_length` int(11) NOT NULL,
+  `location` varchar(1000) NOT NULL,
+  `source` varchar(40) NOT NULL,
+  `create_datetime` datetime NOT NULL,
+  `update_datetime` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `ip_path_id_uindex` (`id`),
+  KEY `fk_ip_path_rid` (`r_id`),
+  CONSTRAINT `fk_ip_path_rid` FOREIGN KEY (`r_id`) REFERENCES `port` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Table structure for table `domain_path`
+--
+
+DROP TABLE IF EXISTS `domain_path`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `domain_path` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `r_id` int(10) unsigned NOT NULL,
+  `port` int(11) NOT NULL,
+  `path` varchar(500) NOT NULL,
+  `url` varchar(1000) NOT NULL,
+  `status` int(11) NOT NULL,
+  `content_length` int(11) NOT NULL,
+  `location` varchar(1000) NOT NULL,
+  `source` varchar(40) NOT NULL,
+  `create_datetime` datetime NOT NULL,
+  `update_datetime` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `domain_path_id_uindex` (`id`),
+  KEY `fk_domain_path_rid` (`r_id`),
+  CONSTRAINT `fk_domain_path_rid` FOREIGN KEY (`r_id`) REFERENCES `domain` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+--
+-- Table structure for table `url`
+--
+
+DROP TABLE IF EXISTS `url`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `url` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `url` varchar(1000) NOT NULL,
+  `host` varchar(255) NOT NULL,
+  `port` int(11) NOT NULL,
+  `method` varchar(10) NOT NULL,
+  `path` varchar(1000) NOT NULL,
+  `params` varchar(1000) NOT NULL,
+  `content_type` varchar(100) NOT NULL,
+  `status` int(11) NOT NULL,
+  `source` varchar(100) NOT NULL,
+  `hash` char(32) NOT NULL,
+  `workspace_id` int(11) NOT NULL,
+  `create_datetime` datetime NOT NULL,
+  `update_datetime` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `url_workspace_hash_uindex` (`workspace_id`,`hash`),
+  KEY `index_url_host` (`host`),
+  CONSTRAINT `fk_url_workspace_id` FOREIGN KEY (`workspace_id`) REFERENCES `workspace` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+--
+-- Table structure for table `screenshot`
+--
+
+DROP TABLE IF EXISTS `screenshot`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `screenshot` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `domain` varchar(255) NOT NULL,
+  `port` int(11) NOT NULL,
+  `protocol` varchar(10) NOT NULL,
+  `final_url` varchar(1000) NOT NULL,
+  `title` varchar(500) NOT NULL,
+  `content` mediumtext NOT NULL,
+  `phash` char(16) NOT NULL,
+  `workspace_id` int(11) NOT NULL,
+  `create_datetime` datetime NOT NULL,
+  `update_datetime` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `screenshot_workspace_target_uindex` (`workspace_id`,`domain`,`port`,`protocol`),
+  KEY `index_screenshot_phash` (`phash`),
+  CONSTRAINT `fk_screenshot_workspace_id` FOREIGN KEY (`workspace_id`) REFERENCES `workspace` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+--
+-- Table structure for table `saved_query`
+--
+
+DROP TABLE IF EXISTS `saved_query`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `saved_query` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL,
+  `category` varchar(20) NOT NULL,
+  `query` varchar(4096) NOT NULL,
+  `workspace_id` int(11) NOT NULL,
+  `create_datetime` datetime NOT NULL,
+  `update_datetime` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `saved_query_workspace_name_uindex` (`workspace_id`,`category`,`name`),
+  CONSTRAINT `fk_saved_query_workspace_id` FOREIGN KEY (`workspace_id`) REFERENCES `workspace` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+--
+-- Table structure for table `api_key`
+--
+
+DROP TABLE IF EXISTS `api_key`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `api_key` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `engine` varchar(20) NOT NULL,
+  `api_key` varchar(200) NOT NULL,
+  `enabled` tinyint(1) NOT NULL DEFAULT '1',
+  `status` varchar(20) NOT NULL,
+  `quota` int(11) NOT NULL DEFAULT '-1',
+  `last_error` varchar(500) NOT NULL DEFAULT '',
+  `cooldown_datetime` datetime DEFAULT NULL,
+  `check_datetime` datetime DEFAULT NULL,
+  `lease_datetime` datetime DEFAULT NULL,
+  `create_datetime` datetime NOT NULL,
+  `update_datetime` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `api_key_engine_key_uindex` (`engine`,`api_key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+--
+-- Table structure for table `api_key_usage`
+--
+
+DROP TABLE IF EXISTS `api_key_usage`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `api_key_usage` (
+  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+  `api_key_id` int(10) unsigned NOT NULL,
+  `workspace_id` int(11) NOT NULL,
+  `query_count` int(11) NOT NULL DEFAULT '0',
+  `result_count` int(11) NOT NULL DEFAULT '0',
+  `error_count` int(11) NOT NULL DEFAULT '0',
+  `create_datetime` datetime NOT NULL,
+  `update_datetime` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `api_key_usage_key_workspace_uindex` (`api_key_id`,`workspace_id`),
+  CONSTRAINT `fk_api_key_usage_api_key_id` FOREIGN KEY (`api_key_id`) REFERENCES `api_key` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_api_key_usage_workspace_id` FOREIGN KEY (`workspace_id`) REFERENCES `workspace` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
 /*!40103 SET TIME_ZONE=@OLD_TIME_ZONE */;
 
 /*!40101 SET SQL_MODE=@OLD_SQL_MODE */;
@@ -0,0 +1,275 @@
+package apikeypool
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/onlineapi"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCheckIntervalSecond 查询账号剩余额度的间隔
+	DefaultCheckIntervalSecond = 3600
+	// DefaultCooldownSecond key被限流后暂停租用的时间
+	DefaultCooldownSecond = 60
+	// lastErrorMaxLength 记录的错误信息最大长度
+	lastErrorMaxLength = 500
+)
+
+// Engines 使用key池的在线API
+var Engines = onlineapi.SearchEngines
+
+var (
+	pool = &Pool{}
+	// poolConfig 获取key池的配置，可在测试中替换
+	poolConfig = func() conf.APIKeyPool {
+		return conf.GlobalServerConfig().APIKey
+	}
+)
+
+// Pool server的在线API key池：按engine租用可用的key，记录key的状态、额度及各workspace的使用量
+type Pool struct {
+	sync.Mutex
+}
+
+// LocalProvider server本地使用key池（如在线测试API），worker通过RPC使用
+type LocalProvider struct{}
+
+// GetPool 获取全局的key池
+func GetPool() *Pool {
+	return pool
+}
+
+func (LocalProvider) Lease(engine string, workspaceId int, excludeIds []int) (*onlineapi.APIKeyLease, error) {
+	return pool.Lease(engine, workspaceId, excludeIds)
+}
+
+func (LocalProvider) Report(report onlineapi.APIKeyReport) {
+	pool.Report(report)
+}
+
+// checkInterval 查询账号剩余额度的间隔
+func checkInterval() time.Duration {
+	interval := poolConfig().CheckInterval
+	if interval <= 0 {
+		interval = DefaultCheckIntervalSecond
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// cooldown key被限流后暂停租用的时间
+func cooldown() time.Duration {
+	seconds := poolConfig().Cooldown
+	if seconds <= 0 {
+		seconds = DefaultCooldownSecond
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Lease 租用一个engine的key：跳过禁用、无效、冷却中及调用者已排除的key，优先选择最久未租用的key
+func (p *Pool) Lease(engine string, workspaceId int, excludeIds []int) (*onlineapi.APIKeyLease, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	keyDb := db.APIKey{Engine: engine}
+	now := time.Now()
+	k := selectKey(keyDb.Gets(), excludeIds, now)
+	if k == nil {
+		logging.RuntimeLog.Warningf("no %s api key available for workspace %d", engine, workspaceId)
+		return nil, onlineapi.ErrNoKeyAvailable
+	}
+	k.Update(map[string]interface{}{"lease_datetime": now})
+
+	return &onlineapi.APIKeyLease{Id: k.Id, Engine: k.Engine, Key: k.APIKey}, nil
+}
+
+// selectKey 选择一个可租用的key
+func selectKey(keys []db.APIKey, excludeIds []int, now time.Time) (selected *db.APIKey) {
+	exclude := make(map[int]struct{})
+	for _, id := range excludeIds {
+		exclude[id] = struct{}{}
+	}
+	for i := range keys {
+		k := &keys[i]
+		if _, ok := exclude[k.Id]; ok || !isAvailable(k, now) {
+			continue
+		}
+		if selected == nil || leaseBefore(k, selected) {
+			selected = k
+		}
+	}
+	return
+}
+
+// isAvailable key是否可以租用，限流及额度耗尽的key在冷却结束后恢复租用
+func isAvailable(k *db.APIKey, now time.Time) bool {
+	if !k.Enabled || k.Status == onlineapi.KeyStatusInvalid {
+		return false
+	}
+	if k.CooldownDatetime != nil && now.Before(*k.CooldownDatetime) {
+		return false
+	}
+	return true
+}
+
+// leaseBefore a是否比b更早被租用（从未租用的key最优先）
+func leaseBefore(a, b *db.APIKey) bool {
+	if a.LeaseDatetime == nil {
+		return b.LeaseDatetime != nil
+	}
+	if b.LeaseDatetime == nil {
+		return false
+	}
+	return a.LeaseDatetime.Before(*b.LeaseDatetime)
+}
+
+// Report 记录一次查询后key的状态、剩余额度及workspace的使用量
+func (p *Pool) Report(report onlineapi.APIKeyReport) {
+	p.Lock()
+	defer p.Unlock()
+
+	k := db.APIKey{Id: report.Id}
+	if !k.Get() || k.Engine != report.Engine {
+		logging.RuntimeLog.Warningf("invalid api key report:%s %d", report.Engine, report.Id)
+		return
+	}
+	updateMap := statusUpdateMap(report.Status, report.Error, report.Quota, time.Now())
+	if len(updateMap) > 0 {
+		k.Update(updateMap)
+	}
+	if report.Status != onlineapi.KeyStatusOK && report.Status != "" {
+		logging.RuntimeLog.Warningf("%s api key %s is %s:%s", k.Engine, onlineapi.DesensitizeKey(k.APIKey), report.Status, report.Error)
+	}
+	if report.WorkspaceId > 0 {
+		usage := db.APIKeyUsage{APIKeyId: k.Id, WorkspaceId: report.WorkspaceId, QueryCount: 1, ResultCount: report.ResultCount}
+		if report.Error != "" {
+			usage.ErrorCount = 1
+		}
+		usage.AddUsage()
+	}
+}
+
+// statusUpdateMap 根据key的状态生成更新的字段：限流后冷却一段时间，额度耗尽后在下次查询额度前不再租用
+func statusUpdateMap(status string, lastError string, quota int, now time.Time) (updateMap map[string]interface{}) {
+	updateMap = make(map[string]interface{})
+	if len(lastError) > lastErrorMaxLength {
+		lastError = lastError[:lastErrorMaxLength]
+	}
+	if quota >= 0 {
+		updateMap["quota"] = quota
+		if quota == 0 && status == onlineapi.KeyStatusOK {
+			status = onlineapi.KeyStatusExhausted
+		}
+	}
+	switch status {
+	case onlineapi.KeyStatusOK:
+		updateMap["status"] = status
+		updateMap["last_error"] = ""
+		updateMap["cooldown_datetime"] = nil
+	case onlineapi.KeyStatusRateLimited:
+		updateMap["status"] = status
+		updateMap["last_error"] = lastError
+		updateMap["cooldown_datetime"] = now.Add(cooldown())
+	case onlineapi.KeyStatusExhausted:
+		updateMap["status"] = status
+		updateMap["quota"] = 0
+		updateMap["last_error"] = lastError
+		updateMap["cooldown_datetime"] = now.Add(checkInterval())
+	case onlineapi.KeyStatusInvalid:
+		updateMap["status"] = status
+		updateMap["last_error"] = lastError
+	default:
+		// 与key无关的错误只记录错误信息
+		if lastError != "" {
+			updateMap["last_error"] = lastError
+		}
+	}
+	return
+}
+
+// CheckQuota 查询超过检查间隔（force为true时全部）的key的账号剩余额度；
+// 没有账号接口的engine（hunter、zoomeye、netlas）在冷却结束后将限流、额度耗尽的状态恢复为unknown，由下一次查询更新
+func (p *Pool) CheckQuota(force bool) {
+	keyDb := db.APIKey{}
+	now := time.Now()
+	interval := checkInterval()
+	for _, k := range keyDb.Gets() {
+		if !k.Enabled || (!force && k.CheckDatetime != nil && now.Sub(*k.CheckDatetime) < interval) {
+			continue
+		}
+		p.CheckKey(k)
+	}
+}
+
+// CheckKey 查询一个key的账号剩余额度并更新状态
+func (p *Pool) CheckKey(k db.APIKey) {
+	now := time.Now()
+	updateMap := make(map[string]interface{})
+	if checker, ok := onlineapi.NewEngine(k.Engine).(onlineapi.AccountChecker); ok {
+		quota, err := checker.CheckAccount(k.APIKey, onlineapi.OnlineAPIConfig{})
+		if err != nil {
+			updateMap = statusUpdateMap(onlineapi.KeyStatus(err), err.Error(), -1, now)
+			logging.RuntimeLog.Warningf("check %s api key %s fail:%v", k.Engine, onlineapi.DesensitizeKey(k.APIKey), err)
+		} else {
+			updateMap = statusUpdateMap(onlineapi.KeyStatusOK, "", quota, now)
+		}
+	} else if (k.Status == onlineapi.KeyStatusRateLimited || k.Status == onlineapi.KeyStatusExhausted) && (k.CooldownDatetime == nil || now.After(*k.CooldownDatetime)) {
+		updateMap["status"] = onlineapi.KeyStatusUnknown
+		updateMap["cooldown_datetime"] = nil
+	}
+	updateMap["check_datetime"] = now
+
+	p.Lock()
+	defer p.Unlock()
+	k.Update(updateMap)
+}
+
+// Import 导入以,分隔的多个key，已存在的key不重复导入，返回导入的数量
+func (p *Pool) Import(engine string, keys string) (count int) {
+	p.Lock()
+	defer p.Unlock()
+
+	for _, key := range onlineapi.SplitKeys(keys) {
+		k := db.APIKey{Engine: engine, APIKey: key}
+		if k.GetByKey() {
+			continue
+		}
+		k.Enabled = true
+		k.Status = onlineapi.KeyStatusUnknown
+		k.Quota = -1
+		if k.Add() {
+			count++
+		}
+	}
+	return
+}
+
+// ImportFromConfig 将worker.yml中配置的key导入到key池中还没有key的engine（升级时迁移原有的配置）
+func ImportFromConfig() {
+	keys := onlineapi.ConfigKeys(conf.GlobalWorkerConfig().API)
+	for _, engine := range Engines {
+		keyDb := db.APIKey{Engine: engine}
+		if keyDb.Count() > 0 {
+			continue
+		}
+		if count := pool.Import(engine, keys[engine]); count > 0 {
+			logging.RuntimeLog.Infof("import %d %s api key to key pool", count, engine)
+		}
+	}
+}
+
+// StartQuotaCheck 启动key池：导入配置文件中的key并定时查询key的剩余额度
+func StartQuotaCheck() {
+	onlineapi.SetKeyProvider(LocalProvider{})
+	ImportFromConfig()
+	go func() {
+		pool.CheckQuota(false)
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			pool.CheckQuota(false)
+		}
+	}()
+}
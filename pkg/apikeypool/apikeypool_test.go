@@ -0,0 +1,58 @@
+package apikeypool
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/task/onlineapi"
+	"testing"
+	"time"
+)
+
+func TestSelectKey(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	later := now.Add(time.Minute)
+	keys := []db.APIKey{
+		{Id: 1, Enabled: true, Status: onlineapi.KeyStatusOK, LeaseDatetime: &now},
+		{Id: 2, Enabled: true, Status: onlineapi.KeyStatusOK, LeaseDatetime: &earlier},
+		{Id: 3, Enabled: false, Status: onlineapi.KeyStatusOK},
+		{Id: 4, Enabled: true, Status: onlineapi.KeyStatusInvalid},
+		{Id: 5, Enabled: true, Status: onlineapi.KeyStatusRateLimited, CooldownDatetime: &later},
+	}
+	if k := selectKey(keys, nil, now); k == nil || k.Id != 2 {
+		t.Errorf("least recently leased key:%+v", k)
+	}
+	if k := selectKey(keys, []int{2}, now); k == nil || k.Id != 1 {
+		t.Errorf("excluded key:%+v", k)
+	}
+	// 冷却结束后恢复租用，从未租用的key优先
+	if k := selectKey(keys, nil, later.Add(time.Second)); k == nil || k.Id != 5 {
+		t.Errorf("cooldown expired key:%+v", k)
+	}
+	if k := selectKey(keys, []int{1, 2}, now); k != nil {
+		t.Errorf("no key available:%+v", k)
+	}
+}
+
+func TestStatusUpdateMap(t *testing.T) {
+	poolConfig = func() conf.APIKeyPool {
+		return conf.APIKeyPool{CheckInterval: 600, Cooldown: 30}
+	}
+	now := time.Now()
+	m := statusUpdateMap(onlineapi.KeyStatusRateLimited, "429", -1, now)
+	if m["status"] != onlineapi.KeyStatusRateLimited || m["cooldown_datetime"] != now.Add(30*time.Second) {
+		t.Errorf("rate limited:%v", m)
+	}
+	m = statusUpdateMap(onlineapi.KeyStatusOK, "", 0, now)
+	if m["status"] != onlineapi.KeyStatusExhausted || m["quota"] != 0 || m["cooldown_datetime"] != now.Add(600*time.Second) {
+		t.Errorf("zero quota:%v", m)
+	}
+	m = statusUpdateMap(onlineapi.KeyStatusOK, "", 100, now)
+	if m["status"] != onlineapi.KeyStatusOK || m["quota"] != 100 || m["cooldown_datetime"] != nil {
+		t.Errorf("ok:%v", m)
+	}
+	m = statusUpdateMap("", "i/o timeout", -1, now)
+	if _, ok := m["status"]; ok || m["last_error"] != "i/o timeout" {
+		t.Errorf("network error:%v", m)
+	}
+}
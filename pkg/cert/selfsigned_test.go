@@ -1,9 +1,11 @@
 package cert
 
 import (
+	"path/filepath"
 	"testing"
 )
 
 func TestSelfSignedCertGenerator_Generate(t *testing.T) {
-	t.Log(GenerateSelfSignedCert("server.crt", "server.key"))
+	dir := t.TempDir()
+	t.Log(GenerateSelfSignedCert(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")))
 }
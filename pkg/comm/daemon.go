@@ -16,6 +16,12 @@ import (
 var cmd *exec.Cmd
 var WorkerName string
 
+// WorkerMetricsAddr worker指标http服务的监听地址，为空时不启动
+var WorkerMetricsAddr string
+
+// WorkerLabels worker的标签，用于任务分配
+var WorkerLabels string
+
 // WatchWorkerProcess worker进程状态监控
 func WatchWorkerProcess(workerRunTaskMode, taskWorkspaceGUID string, concurrency, workerPerformance int) {
 	if cmd == nil {
@@ -136,6 +142,12 @@ func StartWorker(workerRunTaskMode, taskWorkspaceGUID string, concurrency, worke
 	if TLSEnabled {
 		cmdArgs = append(cmdArgs, "-tls")
 	}
+	if WorkerMetricsAddr != "" {
+		cmdArgs = append(cmdArgs, "-metrics", WorkerMetricsAddr)
+	}
+	if WorkerLabels != "" {
+		cmdArgs = append(cmdArgs, "-labels", WorkerLabels)
+	}
 	cmd = exec.Command(workerPathName, cmdArgs...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -3,6 +3,7 @@ package comm
 import (
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/ampq"
+	"strings"
 	"sync"
 	"time"
 )
@@ -21,6 +22,9 @@ var (
 	WorkerStatus      = make(map[string]*ampq.WorkerStatus)
 )
 
+// workerTopicCheckInterval 检查离线worker专属队列的时间间隔
+const workerTopicCheckInterval = time.Minute
+
 // DoKeepAlive worker请求keepAlive
 func DoKeepAlive(ws *ampq.WorkerStatus) bool {
 	kari := newKeepAliveRequestInfo(ws)
@@ -52,3 +56,84 @@ func newKeepAliveRequestInfo(ws *ampq.WorkerStatus) *KeepAliveInfo {
 	kai.WorkerStatus.UpdateTime = time.Now()
 	return &kai
 }
+
+// SelectWorkerTopic 根据任务要求，从在线的worker中选择负载最低的满足要求的worker，返回其专属队列；
+// 任务队列的全部worker都满足要求或没有满足要求的worker时返回空，任务仍使用原始的队列
+func SelectWorkerTopic(topicName string, requirement ampq.TaskRequirement) string {
+	WorkerStatusMutex.Lock()
+	defer WorkerStatusMutex.Unlock()
+
+	var selected *ampq.WorkerStatus
+	var selectedLoad float64
+	var allSatisfied = true
+	for _, ws := range WorkerStatus {
+		if !isWorkerOnline(ws) {
+			continue
+		}
+		if !strings.Contains(","+ws.WorkerTopics+",", ","+topicName+",") {
+			continue
+		}
+		if ok, _ := ws.Capability.Satisfy(requirement); !ok || ws.DedicatedTopic == "" {
+			allSatisfied = false
+			continue
+		}
+		cpuNumber := ws.Capability.CPU
+		if cpuNumber <= 0 {
+			cpuNumber = 1
+		}
+		load := float64(ws.TaskStartedNumber) / float64(cpuNumber)
+		if selected == nil || load < selectedLoad {
+			selected = ws
+			selectedLoad = load
+		}
+	}
+	if selected == nil {
+		logging.RuntimeLog.Warningf("no online worker satisfies the requirement of topic:%s", topicName)
+		return ""
+	}
+	if allSatisfied {
+		return ""
+	}
+	return selected.DedicatedTopic
+}
+
+// isWorkerOnline worker在3分钟内有心跳时认为在线
+func isWorkerOnline(ws *ampq.WorkerStatus) bool {
+	return time.Now().Sub(ws.UpdateTime).Minutes() < 3
+}
+
+// StartWorkerTopicMonitor 定时将离线worker的专属队列中的任务退回到原始队列，由其它满足要求的worker执行
+func StartWorkerTopicMonitor() {
+	for {
+		time.Sleep(workerTopicCheckInterval)
+		for _, topic := range offlineWorkerTopics() {
+			count, err := ampq.RequeueWorkerTopic(topic)
+			if err != nil {
+				logging.RuntimeLog.Errorf("requeue tasks of offline worker topic:%s fail:%v", topic, err)
+			}
+			if count > 0 {
+				logging.RuntimeLog.Warningf("requeue %d tasks of offline worker topic:%s", count, topic)
+			}
+		}
+	}
+}
+
+// offlineWorkerTopics 获取离线worker的专属队列；同一主机上仍有在线的worker时，专属队列由在线的worker继续执行
+func offlineWorkerTopics() (topics []string) {
+	WorkerStatusMutex.Lock()
+	defer WorkerStatusMutex.Unlock()
+
+	online := make(map[string]bool)
+	for _, ws := range WorkerStatus {
+		if ws.DedicatedTopic == "" {
+			continue
+		}
+		online[ws.DedicatedTopic] = online[ws.DedicatedTopic] || isWorkerOnline(ws)
+	}
+	for topic, ok := range online {
+		if !ok {
+			topics = append(topics, topic)
+		}
+	}
+	return
+}
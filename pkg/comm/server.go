@@ -15,6 +15,14 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"time"
+)
+
+const (
+	// runtimeLogBatchSize 批量发送RuntimeLog的最大数量
+	runtimeLogBatchSize = 50
+	// runtimeLogFlushInterval 发送RuntimeLog的时间间隔
+	runtimeLogFlushInterval = 3 * time.Second
 )
 
 var (
@@ -120,20 +128,39 @@ func GenerateRSAKey() (err error) {
 	return
 }
 
+// StartSaveRuntimeLog 将RuntimeLog批量发送到server保存：缓存的日志达到runtimeLogBatchSize条或每隔runtimeLogFlushInterval发送一次
 func StartSaveRuntimeLog(source string) {
+	logging.SetWorkerName(source)
 	logging.RuntimeLogChan = make(chan []byte, logging.RuntimeLogChanMax)
+	ticker := time.NewTicker(runtimeLogFlushInterval)
+	defer ticker.Stop()
+
+	var messages [][]byte
 	for {
 		select {
 		case msg := <-logging.RuntimeLogChan:
-			resultArgs := RuntimeLogArgs{
-				Source:     source,
-				LogMessage: msg,
+			messages = append(messages, msg)
+			if len(messages) >= runtimeLogBatchSize {
+				saveRuntimeLogs(source, messages)
+				messages = nil
 			}
-			var result string
-			err := CallXClient("SaveRuntimeLog", &resultArgs, &result)
-			if err != nil {
-				logging.CLILog.Error(err)
+		case <-ticker.C:
+			if len(messages) > 0 {
+				saveRuntimeLogs(source, messages)
+				messages = nil
 			}
 		}
 	}
 }
+
+// saveRuntimeLogs 通过RPC批量保存RuntimeLog
+func saveRuntimeLogs(source string, messages [][]byte) {
+	args := RuntimeLogBatchArgs{
+		Source:      source,
+		LogMessages: messages,
+	}
+	var result string
+	if err := CallXClient("SaveRuntimeLogs", &args, &result); err != nil {
+		logging.CLILog.Error(err)
+	}
+}
@@ -7,9 +7,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/apikeypool"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/db"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
+	"github.com/hanc00l/nemo_go/pkg/oob"
 	"github.com/hanc00l/nemo_go/pkg/task/ampq"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
@@ -17,10 +20,16 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/task/pocscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
 	"github.com/hanc00l/nemo_go/pkg/task/serverapi"
+	"github.com/hanc00l/nemo_go/pkg/task/urlscan"
+	"github.com/hanc00l/nemo_go/pkg/tracing"
 	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/hanc00l/nemo_go/pkg/weakpass"
 	whoisparser "github.com/likexian/whois-parser"
 	"github.com/smallnest/rpcx/client"
+	"github.com/smallnest/rpcx/share"
 	"github.com/tidwall/pretty"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"os"
 	"path"
 	"path/filepath"
@@ -42,6 +51,8 @@ type ScanResultArgs struct {
 	IPResult            map[string]*portscan.IPResult
 	DomainResult        map[string]*domainscan.DomainResult
 	VulnerabilityResult []pocscan.Result
+	PathResult          []pocscan.PathResult
+	UrlResult           []urlscan.UrlResult
 }
 
 // ScreenshotResultArgs screenshot结果请求参数
@@ -86,6 +97,11 @@ type LoadDomainOpenedPortArgs struct {
 	Target      map[string]struct{}
 }
 
+type LoadKnownSubdomainArgs struct {
+	WorkspaceId int
+	Target      []string
+}
+
 type MainTaskResultMap struct {
 	IPResult         map[string]map[int]interface{}
 	DomainResult     map[string]interface{}
@@ -102,6 +118,20 @@ type RuntimeLogArgs struct {
 	LogMessage []byte
 }
 
+type RuntimeLogBatchArgs struct {
+	Source      string
+	LogMessages [][]byte
+}
+
+// LeaseAPIKeyArgs 租用在线API key的请求参数，ExcludeIds为本次任务中已不可用的key
+type LeaseAPIKeyArgs struct {
+	Engine      string
+	WorkspaceId int
+	ExcludeIds  []int
+}
+
+const knownSubdomainMaxNumber = 10000
+
 var (
 	// globalXClient 全局的RPC连接（长连接方式）
 	globalXClient      client.XClient
@@ -120,6 +150,18 @@ var (
 
 // CallXClient RPC远程调用
 func CallXClient(serviceMethod string, args interface{}, reply interface{}) error {
+	return CallXClientWithContext(context.Background(), serviceMethod, args, reply)
+}
+
+// CallXClientWithContext RPC远程调用，ctx中有trace上下文时通过metadata传递到server
+func CallXClientWithContext(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) (err error) {
+	if tracing.HasSpan(ctx) {
+		var span trace.Span
+		ctx, span = tracing.StartWithKind(ctx, "rpc."+serviceMethod, trace.SpanKindClient)
+		defer func() { tracing.End(span, err) }()
+		ctx = context.WithValue(ctx, share.ReqMetaDataKey, tracing.InjectMap(ctx))
+	}
+
 	globalXClientMutex.Lock()
 	defer globalXClientMutex.Unlock()
 
@@ -137,20 +179,47 @@ func CallXClient(serviceMethod string, args interface{}, reply interface{}) erro
 		globalXClient.Auth(conf.GlobalWorkerConfig().Rpc.AuthKey)
 	}
 
-	return globalXClient.Call(context.Background(), serviceMethod, args, reply)
+	start := time.Now()
+	err = globalXClient.Call(ctx, serviceMethod, args, reply)
+	metrics.ObserveRPC(serviceMethod, start, err)
+	return err
+}
+
+// startServiceSpan 从RPC的metadata中读取trace上下文并创建server端的span；调用方没有trace上下文时返回空的span
+func startServiceSpan(ctx context.Context, serviceMethod string) (context.Context, trace.Span) {
+	metadata, _ := ctx.Value(share.ReqMetaDataKey).(map[string]string)
+	ctx = tracing.ExtractMap(ctx, metadata)
+	if !tracing.HasSpan(ctx) {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return tracing.StartWithKind(ctx, "rpc."+serviceMethod, trace.SpanKindServer)
+}
+
+// startSaveSpan 创建保存结果到数据库的span
+func startSaveSpan(ctx context.Context, name string, count int) trace.Span {
+	if !tracing.HasSpan(ctx) {
+		return trace.SpanFromContext(ctx)
+	}
+	_, span := tracing.Start(ctx, "db."+name, attribute.Int("count", count))
+	return span
 }
 
 // SaveScanResult 保存IP与域名的扫描结果
 func (s *Service) SaveScanResult(ctx context.Context, args *ScanResultArgs, replay *string) error {
+	ctx, span := startServiceSpan(ctx, "SaveScanResult")
+	defer span.End()
+
 	var msg []string
 	if args.IPConfig != nil && args.IPResult != nil {
 		r := portscan.Result{
 			IPResult: args.IPResult,
 		}
 
+		saveSpan := startSaveSpan(ctx, "SaveIPResult", len(args.IPResult))
 		saveIPMutex.Lock()
 		msg = append(msg, r.SaveResult(*args.IPConfig))
 		saveIPMutex.Unlock()
+		saveSpan.End()
 
 		if len(args.IPResult) > 0 {
 			saveTaskResult(args.TaskID, args.IPResult)
@@ -161,14 +230,29 @@ func (s *Service) SaveScanResult(ctx context.Context, args *ScanResultArgs, repl
 			DomainResult: args.DomainResult,
 		}
 
+		saveSpan := startSaveSpan(ctx, "SaveDomainResult", len(args.DomainResult))
 		saveDomainMutex.Lock()
 		msg = append(msg, r.SaveResult(*args.DomainConfig))
 		saveDomainMutex.Unlock()
+		saveSpan.End()
 
 		if len(args.DomainResult) > 0 {
 			saveTaskResult(args.TaskID, args.DomainResult)
 		}
 	}
+	// httpx获取的URL与爬虫等发现的URL作为URL资产保存
+	urlResult := args.UrlResult
+	if args.IPConfig != nil && args.IPResult != nil {
+		urlResult = append(urlResult, fingerprint.NewHttpx().ParseUrlResult(args.IPResult, nil, args.IPConfig.WorkspaceId)...)
+	}
+	if args.DomainConfig != nil && args.DomainResult != nil {
+		urlResult = append(urlResult, fingerprint.NewHttpx().ParseUrlResult(nil, args.DomainResult, args.DomainConfig.WorkspaceId)...)
+	}
+	if len(urlResult) > 0 {
+		saveSpan := startSaveSpan(ctx, "SaveUrlResult", len(urlResult))
+		msg = append(msg, urlscan.SaveResult(urlResult))
+		saveSpan.End()
+	}
 	saveMainTaskResult(args.MainTaskId, args.IPResult, args.DomainResult, args.VulnerabilityResult, 0)
 	*replay = strings.Join(msg, ",")
 	saveMainTaskNewResult(args.MainTaskId, *replay)
@@ -178,18 +262,17 @@ func (s *Service) SaveScanResult(ctx context.Context, args *ScanResultArgs, repl
 
 // SaveScreenshotResult 保存Screenshot的结果到Server
 func (s *Service) SaveScreenshotResult(ctx context.Context, args *ScreenshotResultArgs, replay *string) error {
+	_, span := startServiceSpan(ctx, "SaveScreenshotResult")
+	defer span.End()
+
 	ss := fingerprint.NewScreenShot()
-	//检查保存结果的路径
+	//检查保存结果的workspace
 	workspace := db.Workspace{Id: args.WorkspaceId}
-	if workspace.Get() == false {
+	if workspace.Get() == false || workspace.WorkspaceGUID == "" {
 		logging.RuntimeLog.Error("workspace error")
+		return errors.New("workspace error")
 	}
-	screenshotPath := path.Join(conf.GlobalServerConfig().Web.WebFiles, workspace.WorkspaceGUID, "screenshot")
-	if !utils.MakePath(screenshotPath) {
-		logging.RuntimeLog.Error("创建保存screenshot的目录失败！")
-		return errors.New("创建保存screenshot的目录失败！")
-	}
-	count := ss.SaveFile(screenshotPath, args.FileInfo)
+	count := ss.SaveFile(workspace.WorkspaceGUID, args.WorkspaceId, args.FileInfo)
 	saveMainTaskResult(args.MainTaskId, nil, nil, nil, count)
 	*replay = fmt.Sprintf("screenshot:%d", count)
 	return nil
@@ -197,25 +280,34 @@ func (s *Service) SaveScreenshotResult(ctx context.Context, args *ScreenshotResu
 
 // SaveIconImageResult 保存IconImage结果到Server
 func (s *Service) SaveIconImageResult(ctx context.Context, args *IconHashResultArgs, replay *string) error {
+	_, span := startServiceSpan(ctx, "SaveIconImageResult")
+	defer span.End()
+
 	workspace := db.Workspace{Id: args.WorkspaceId}
-	if workspace.Get() == false {
+	if workspace.Get() == false || workspace.WorkspaceGUID == "" {
+		*replay = "workspace error"
 		logging.RuntimeLog.Error("workspace error")
-	}
-	iconImagePath := path.Join(conf.GlobalServerConfig().Web.WebFiles, workspace.WorkspaceGUID, "iconimage")
-	if !utils.MakePath(iconImagePath) {
-		*replay = "fail to mkdir"
-		logging.RuntimeLog.Error("创建任务保存Image的目录失败！")
-		return errors.New("创建任务保存Image的目录失败！")
+		return errors.New("workspace error")
 	}
 	hash := fingerprint.NewIconHash()
-	*replay = hash.SaveFile(iconImagePath, args.IconHashInfo)
+	*replay = hash.SaveFile(workspace.WorkspaceGUID, args.IconHashInfo)
 
 	return nil
 }
 
 // SaveVulnerabilityResult 保存漏洞结果
 func (s *Service) SaveVulnerabilityResult(ctx context.Context, args *ScanResultArgs, replay *string) error {
+	ctx, span := startServiceSpan(ctx, "SaveVulnerabilityResult")
+	defer span.End()
+
+	saveSpan := startSaveSpan(ctx, "SaveVulnerabilityResult", len(args.VulnerabilityResult))
 	*replay = pocscan.SaveResult(args.VulnerabilityResult)
+	saveSpan.End()
+	if len(args.PathResult) > 0 {
+		saveSpan = startSaveSpan(ctx, "SavePathResult", len(args.PathResult))
+		*replay = fmt.Sprintf("%s,%s", *replay, pocscan.SavePathResult(args.PathResult))
+		saveSpan.End()
+	}
 	if len(args.VulnerabilityResult) > 0 {
 		saveTaskResult(args.TaskID, args.VulnerabilityResult)
 		saveMainTaskResult(args.MainTaskId, nil, nil, args.VulnerabilityResult, 0)
@@ -291,6 +383,9 @@ func (s *Service) CheckTask(ctx context.Context, args *string, replay *TaskStatu
 
 // UpdateTask 更新任务状态到数据库中
 func (s *Service) UpdateTask(ctx context.Context, args *TaskStatusArgs, replay *bool) error {
+	_, span := startServiceSpan(ctx, "UpdateTask")
+	defer span.End()
+
 	taskCheck := &db.TaskRun{TaskId: args.TaskID}
 	if !taskCheck.GetByTaskId() {
 		return nil
@@ -318,6 +413,7 @@ func (s *Service) UpdateTask(ctx context.Context, args *TaskStatusArgs, replay *
 	}
 	if task.SaveOrUpdate() {
 		*replay = true
+		metrics.TaskStateTotal.WithLabelValues(taskCheck.TaskName, args.State).Inc()
 	} else {
 		logging.RuntimeLog.Errorf("update task:%s,state:%s fail !", args.TaskID, args.State)
 	}
@@ -370,7 +466,10 @@ func (s *Service) NewTask(ctx context.Context, args *NewTaskArgs, replay *string
 		replay = &msg
 		return errors.New(msg)
 	}
-	taskId, err := serverapi.NewRunTask(args.TaskName, args.ConfigJSON, args.MainTaskID, args.LastRunTaskId)
+	ctx, span := startServiceSpan(ctx, "NewTask")
+	defer span.End()
+
+	taskId, err := serverapi.NewRunTaskWithContext(ctx, args.TaskName, args.ConfigJSON, args.MainTaskID, args.LastRunTaskId)
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 		return err
@@ -416,6 +515,48 @@ func (s *Service) LoadOpenedPort(ctx context.Context, args *LoadIPOpenedPortArgs
 	return nil
 }
 
+// LoadServicePort 读取指定IP已开放端口中支持弱口令验证的服务（service://ip:port），只验证工作空间中已有的资产
+func (s *Service) LoadServicePort(ctx context.Context, args *LoadIPOpenedPortArgs, replay *string) error {
+	var resultServices []string
+
+	for _, ip := range strings.Split(args.Target, ",") {
+		var ipAllByParse []string
+		if utils.CheckIPV4(ip) || utils.CheckIPV4Subnet(ip) {
+			ipAllByParse = utils.ParseIP(ip)
+		} else if utils.CheckIPV6(ip) || utils.CheckIPV6Subnet(ip) {
+			ipAllByParse = append(ipAllByParse, ip)
+		}
+		for _, ipOneByOne := range ipAllByParse {
+			ipDb := db.Ip{IpName: ipOneByOne, WorkspaceId: args.WorkspaceId}
+			if ipDb.GetByIp() == false {
+				continue
+			}
+			portDb := db.Port{IpId: ipDb.Id}
+			for _, port := range portDb.GetsByIPId() {
+				// 优先使用端口属性中识别的服务，没有时根据默认端口判断
+				var service string
+				portAttrDb := db.PortAttr{RelatedId: port.Id}
+				for _, attr := range portAttrDb.GetsByRelatedId() {
+					if attr.Tag == "service" {
+						if service = weakpass.ServiceName(attr.Content, port.PortNum); service != "" {
+							break
+						}
+					}
+				}
+				if service == "" {
+					service = weakpass.ServiceName("", port.PortNum)
+				}
+				if service != "" {
+					resultServices = append(resultServices, fmt.Sprintf("%s://%s", service, utils.FormatHostUrl("", ipOneByOne, port.PortNum)))
+				}
+			}
+		}
+	}
+
+	*replay = strings.Join(resultServices, ",")
+	return nil
+}
+
 // LoadIpByOrgId 根据组织ID读取IP资产
 func (s *Service) LoadIpByOrgId(ctx context.Context, args *int, replay *map[string]*portscan.IPResult) error {
 	if args == nil || *args == 0 {
@@ -497,6 +638,74 @@ func (s *Service) LoadDomainOpenedPort(ctx context.Context, args *LoadDomainOpen
 	return nil
 }
 
+// LoadKnownSubdomain 获取根域名在工作空间中已知的子域名，用于子域名变换
+func (s *Service) LoadKnownSubdomain(ctx context.Context, args *LoadKnownSubdomainArgs, replay *map[string][]string) error {
+	if args == nil || args.WorkspaceId == 0 {
+		return errors.New("null workspaceId")
+	}
+	result := make(map[string][]string)
+	for _, domain := range args.Target {
+		searchMap := make(map[string]interface{})
+		searchMap["domain"] = domain
+		searchMap["workspace_id"] = args.WorkspaceId
+		domainDb := db.Domain{}
+		domainResults, _ := domainDb.Gets(searchMap, 1, knownSubdomainMaxNumber, false)
+		for _, domainRow := range domainResults {
+			if strings.HasSuffix(domainRow.DomainName, "."+domain) {
+				result[domain] = append(result[domain], domainRow.DomainName)
+			}
+		}
+	}
+	*replay = result
+	return nil
+}
+
+// NewOOBSubdomain 从反连平台分配一个唯一的子域名
+func (s *Service) NewOOBSubdomain(ctx context.Context, args *string, replay *string) error {
+	server := oob.DefaultServer()
+	if server == nil {
+		return errors.New("oob server not enabled")
+	}
+	_, *replay = server.NewSubdomain()
+	return nil
+}
+
+// LookupOOBInteraction 查询反连平台中子域名的交互记录
+func (s *Service) LookupOOBInteraction(ctx context.Context, args *string, replay *[]oob.Interaction) error {
+	server := oob.DefaultServer()
+	if server == nil {
+		return errors.New("oob server not enabled")
+	}
+	if args == nil || *args == "" {
+		return errors.New("null domain")
+	}
+	*replay = server.Lookup(*args)
+	return nil
+}
+
+// LeaseOnlineAPIKey 从server的key池租用一个在线API的key
+func (s *Service) LeaseOnlineAPIKey(ctx context.Context, args *LeaseAPIKeyArgs, replay *onlineapi.APIKeyLease) error {
+	if args == nil || args.Engine == "" {
+		return errors.New("null engine")
+	}
+	lease, err := apikeypool.GetPool().Lease(args.Engine, args.WorkspaceId, args.ExcludeIds)
+	if err != nil {
+		return err
+	}
+	*replay = *lease
+	return nil
+}
+
+// ReportOnlineAPIKey 报告在线API key的使用结果
+func (s *Service) ReportOnlineAPIKey(ctx context.Context, args *onlineapi.APIKeyReport, replay *string) error {
+	if args == nil || args.Id <= 0 {
+		return errors.New("null api key")
+	}
+	apikeypool.GetPool().Report(*args)
+	*replay = "report success"
+	return nil
+}
+
 // SaveRuntimeLog 保存RuntimeLog
 func (s *Service) SaveRuntimeLog(ctx context.Context, args *RuntimeLogArgs, replay *string) error {
 	if len(args.Source) == 0 || len(args.LogMessage) == 0 {
@@ -504,24 +713,12 @@ func (s *Service) SaveRuntimeLog(ctx context.Context, args *RuntimeLogArgs, repl
 		replay = &msg
 		return errors.New(msg)
 	}
-	logMessage := logging.RuntimeLogMessage{}
-	err := json.Unmarshal(args.LogMessage, &logMessage)
+	rtlog, err := newRuntimeLog(args.Source, args.LogMessage)
 	if err != nil {
 		msg := "runtimelog message error"
 		replay = &msg
 		return err
 	}
-	rtlog := db.RuntimeLog{
-		Source: args.Source,
-		File:   logMessage.File,
-		Func:   logMessage.Func,
-		Level:  logMessage.Level,
-	}
-	if len(logMessage.Message) > 500 {
-		rtlog.Message = logMessage.Message[:500]
-	} else {
-		rtlog.Message = logMessage.Message
-	}
 	if rtlog.Add() {
 		msg := "save success"
 		replay = &msg
@@ -533,6 +730,62 @@ func (s *Service) SaveRuntimeLog(ctx context.Context, args *RuntimeLogArgs, repl
 	return nil
 }
 
+// SaveRuntimeLogs 批量保存RuntimeLog
+func (s *Service) SaveRuntimeLogs(ctx context.Context, args *RuntimeLogBatchArgs, replay *string) error {
+	if len(args.Source) == 0 || len(args.LogMessages) == 0 {
+		return errors.New("null source or message")
+	}
+	var logs []db.RuntimeLog
+	// 日志中未记录workspace时，根据任务补充
+	taskWorkspace := make(map[string]int)
+	for _, message := range args.LogMessages {
+		rtlog, err := newRuntimeLog(args.Source, message)
+		if err != nil {
+			logging.CLILog.Errorf("runtimelog message error:%v", err)
+			continue
+		}
+		if rtlog.TaskId != "" && rtlog.WorkspaceId == 0 {
+			workspaceId, ok := taskWorkspace[rtlog.TaskId]
+			if !ok {
+				runTask := db.TaskRun{TaskId: rtlog.TaskId}
+				if runTask.GetByTaskId() {
+					workspaceId = runTask.WorkspaceId
+				}
+				taskWorkspace[rtlog.TaskId] = workspaceId
+			}
+			rtlog.WorkspaceId = workspaceId
+		}
+		logs = append(logs, rtlog)
+	}
+	rtlog := db.RuntimeLog{}
+	*replay = fmt.Sprintf("runtimelog:%d", rtlog.AddLogs(logs))
+
+	return nil
+}
+
+// newRuntimeLog 解析日志内容，生成RuntimeLog记录
+func newRuntimeLog(source string, message []byte) (rtlog db.RuntimeLog, err error) {
+	logMessage := logging.RuntimeLogMessage{}
+	if err = json.Unmarshal(message, &logMessage); err != nil {
+		return
+	}
+	rtlog = db.RuntimeLog{
+		Source:      source,
+		File:        logMessage.File,
+		Func:        logMessage.Func,
+		Level:       logMessage.Level,
+		TaskId:      logMessage.TaskId,
+		MainTaskId:  logMessage.MainTaskId,
+		WorkspaceId: logMessage.WorkspaceId,
+	}
+	if len(logMessage.Message) > 500 {
+		rtlog.Message = logMessage.Message[:500]
+	} else {
+		rtlog.Message = logMessage.Message
+	}
+	return
+}
+
 // getWorkspaceGUIDByRunTaskId 根据runtask获取workspace的GUID
 func getWorkspaceGUIDByRunTaskId(taskId string) string {
 	runTask := db.TaskRun{TaskId: taskId}
@@ -45,38 +45,57 @@ func GlobalServerConfig() *Server {
 }
 
 func GlobalWorkerConfig() *Worker {
+	config, err := LoadGlobalWorkerConfig()
+	if err != nil {
+		fmt.Println("Load Worker config fail!")
+		os.Exit(0)
+	}
+	return config
+}
+
+// LoadGlobalWorkerConfig 读取worker的全局配置，读取失败时返回错误（不退出），用于配置为可选的库调用
+func LoadGlobalWorkerConfig() (*Worker, error) {
 	if workerConfig == nil {
-		workerConfig = new(Worker)
-		err := workerConfig.ReloadConfig()
-		if err != nil {
-			fmt.Println("Load Worker config fail!")
-			os.Exit(0)
+		config := new(Worker)
+		if err := config.ReloadConfig(); err != nil {
+			return nil, err
 		}
+		workerConfig = config
 	}
-	return workerConfig
+	return workerConfig, nil
 }
 
 type Server struct {
-	Web      Web               `yaml:"web"`
-	Rpc      RPC               `yaml:"rpc"`
-	FileSync RPC               `yaml:"fileSync"`
-	WebAPI   WebAPI            `yaml:"api"`
-	Database Database          `yaml:"database"`
-	Rabbitmq Rabbitmq          `yaml:"rabbitmq"`
-	Task     Task              `yaml:"task"`
-	Notify   map[string]Notify `yaml:"notify"`
+	Web       Web               `yaml:"web"`
+	Rpc       RPC               `yaml:"rpc"`
+	FileSync  RPC               `yaml:"fileSync"`
+	WebAPI    WebAPI            `yaml:"api"`
+	Database  Database          `yaml:"database"`
+	Rabbitmq  Rabbitmq          `yaml:"rabbitmq"`
+	Task      Task              `yaml:"task"`
+	Notify    map[string]Notify `yaml:"notify"`
+	OOB       OOB               `yaml:"oob"`
+	Storage   Storage           `yaml:"storage"`
+	Fulltext  Fulltext          `yaml:"fulltext"`
+	Metrics   Metrics           `yaml:"metrics"`
+	Tracing   Tracing           `yaml:"tracing"`
+	APIKey    APIKeyPool        `yaml:"apiKeyPool"`
+	Reconcile Reconcile         `yaml:"reconcile"`
 }
 
 type Worker struct {
-	Rpc         RPC         `yaml:"rpc"`
-	FileSync    RPC         `yaml:"fileSync"`
-	Rabbitmq    Rabbitmq    `yaml:"rabbitmq"`
-	API         API         `yaml:"api"`
-	Portscan    Portscan    `yaml:"portscan"`
-	Fingerprint Fingerprint `yaml:"fingerprint"`
-	Domainscan  Domainscan  `yaml:"domainscan"`
-	OnlineAPI   OnlineAPI   `yaml:"onlineapi"`
-	Pocscan     Pocscan     `yaml:"pocscan"`
+	Rpc         RPC           `yaml:"rpc"`
+	FileSync    RPC           `yaml:"fileSync"`
+	Rabbitmq    Rabbitmq      `yaml:"rabbitmq"`
+	API         API           `yaml:"api"`
+	Portscan    Portscan      `yaml:"portscan"`
+	Fingerprint Fingerprint   `yaml:"fingerprint"`
+	Domainscan  Domainscan    `yaml:"domainscan"`
+	OnlineAPI   OnlineAPI     `yaml:"onlineapi"`
+	Pocscan     Pocscan       `yaml:"pocscan"`
+	Tracing     Tracing       `yaml:"tracing"`
+	Proxy       Proxy         `yaml:"proxy"`
+	Metrics     WorkerMetrics `yaml:"metrics"`
 }
 
 type Web struct {
@@ -110,6 +129,86 @@ type Rabbitmq struct {
 	Password string `yaml:"password"`
 }
 
+// OOB 反连平台配置，Domain需要将NS记录指向server，端口为0时不启动对应协议的监听
+type OOB struct {
+	Enabled  bool   `yaml:"enabled"`
+	Domain   string `yaml:"domain"`
+	IP       string `yaml:"ip"`
+	Host     string `yaml:"host"`
+	DNSPort  int    `yaml:"dnsPort"`
+	HTTPPort int    `yaml:"httpPort"`
+	LDAPPort int    `yaml:"ldapPort"`
+	SMTPPort int    `yaml:"smtpPort"`
+}
+
+// Storage 截图、icon等文件的存储方式：local为保存在webfiles目录，s3为S3兼容的对象存储（如MinIO）
+type Storage struct {
+	Type string `yaml:"type"`
+	S3   struct {
+		Endpoint  string `yaml:"endpoint"`
+		Region    string `yaml:"region"`
+		Bucket    string `yaml:"bucket"`
+		AccessKey string `yaml:"accessKey"`
+		SecretKey string `yaml:"secretKey"`
+		UseSSL    bool   `yaml:"useSSL"`
+		Prefix    string `yaml:"prefix"`
+	} `yaml:"s3"`
+}
+
+// Fulltext HTTP内容、Banner及属性的全文索引，每个workspace的索引保存在Path下以workspaceGUID命名的目录（相对路径为nemo的根目录）
+type Fulltext struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"`
+}
+
+// Metrics server及serverapi的Prometheus指标接口（/metrics），Token不为空时需以Bearer token的方式访问
+type Metrics struct {
+	Enabled bool   `yaml:"enabled"`
+	Token   string `yaml:"token"`
+}
+
+// WorkerMetrics worker的Prometheus指标接口，通过-metrics参数指定监听地址时Token不能为空
+type WorkerMetrics struct {
+	Token string `yaml:"token"`
+}
+
+// Tracing OpenTelemetry链路追踪，Endpoint为OTLP/HTTP的collector地址（如127.0.0.1:4318），SampleRatio为采样率（0-1，为0时全部采样）
+type Tracing struct {
+	Enabled     bool    `yaml:"enabled"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	SampleRatio float64 `yaml:"sampleRatio"`
+}
+
+// APIKeyPool 在线API的key池，CheckInterval为查询账号剩余额度的间隔，Cooldown为key被限流后暂停租用的时间（秒）
+type APIKeyPool struct {
+	CheckInterval int `yaml:"checkInterval"`
+	Cooldown      int `yaml:"cooldown"`
+}
+
+// Reconcile 端口属性的多来源合并，Confidence为需要调整默认值的来源的置信度（0-1，default为未知来源），HalfLife为置信度随更新时间衰减的半衰期（天）
+type Reconcile struct {
+	HalfLife   int                `yaml:"halfLife"`
+	Confidence map[string]float64 `yaml:"confidence"`
+}
+
+// Proxy worker的代理池，Tasks指定各类任务使用的代理分组，未指定分组的任务直接访问目标
+// Rotation为同一分组内代理的轮换方式（roundrobin、random），HealthCheckURL用于检查代理是否可用并获取代理的出口IP
+type Proxy struct {
+	Enabled             bool              `yaml:"enabled"`
+	Rotation            string            `yaml:"rotation"`
+	HealthCheckInterval int               `yaml:"healthCheckInterval"`
+	HealthCheckURL      string            `yaml:"healthCheckURL"`
+	Pool                []ProxyServer     `yaml:"pool"`
+	Tasks               map[string]string `yaml:"tasks"`
+}
+
+// ProxyServer 代理地址，支持http://、https://及socks5://，Group为空时属于default分组
+type ProxyServer struct {
+	URL   string `yaml:"url"`
+	Group string `yaml:"group"`
+}
+
 type Task struct {
 	IpSliceNumber   int `yaml:"ipSliceNumber"`
 	PortSliceNumber int `yaml:"portSliceNumber"`
@@ -122,6 +221,11 @@ type API struct {
 	ICP              APIKey `yaml:"icp"`
 	Quake            APIKey `yaml:"quake"`
 	Hunter           APIKey `yaml:"hunter"`
+	Shodan           APIKey `yaml:"shodan"`
+	Censys           APIKey `yaml:"censys"`
+	ZoomEye          APIKey `yaml:"zoomeye"`
+	Netlas           APIKey `yaml:"netlas"`
+	BinaryEdge       APIKey `yaml:"binaryedge"`
 }
 
 type APIKey struct {
@@ -141,6 +245,11 @@ type Fingerprint struct {
 	IsScreenshot     bool `yaml:"screenshot"`
 	IsFingerprintHub bool `yaml:"fingerprinthub"`
 	IsIconHash       bool `yaml:"iconhash"`
+	Screenshot       struct {
+		MaxTabs int `yaml:"maxTabs"`
+		Timeout int `yaml:"timeout"`
+		Delay   int `yaml:"delay"`
+	} `yaml:"screenshotBrowser"`
 }
 
 type Pocscan struct {
@@ -150,31 +259,98 @@ type Pocscan struct {
 	Nuclei struct {
 		PocPath string `yaml:"pocPath"`
 	} `yaml:"nuclei"`
+	XrayPocV1 struct {
+		PocPath string `yaml:"pocPath"`
+		Threads int    `yaml:"threads"`
+		Timeout int    `yaml:"timeout"`
+		Proxy   string `yaml:"proxy"`
+	} `yaml:"xraypocv1"`
+	Dirsearch struct {
+		Wordlist  string   `yaml:"wordlist"`
+		Threads   int      `yaml:"threads"`
+		MaxDepth  int      `yaml:"maxDepth"`
+		RateLimit int      `yaml:"rateLimit"`
+		Timeout   int      `yaml:"timeout"`
+		Headers   []string `yaml:"headers"`
+	} `yaml:"dirsearch"`
 	Goby struct {
-		AuthUser string   `yaml:"authUser"`
-		AuthPass string   `yaml:"authPass"`
-		API      []string `yaml:"api"`
+		AuthUser            string   `yaml:"authUser"`
+		AuthPass            string   `yaml:"authPass"`
+		API                 []string `yaml:"api"`
+		MaxConcurrency      int      `yaml:"maxConcurrency"`
+		HealthCheckInterval int      `yaml:"healthCheckInterval"`
 	} `yaml:"goby"`
+	WeakPass struct {
+		Threads       int  `yaml:"threads"`
+		Timeout       int  `yaml:"timeout"`
+		Interval      int  `yaml:"interval"`
+		MaxAttempts   int  `yaml:"maxAttempts"`
+		StopOnSuccess bool `yaml:"stopOnSuccess"`
+	} `yaml:"weakpass"`
 }
 
 type Domainscan struct {
-	Resolver           string `yaml:"resolver"`
-	Wordlist           string `yaml:"wordlist"`
-	ProviderConfig     string `yaml:"providerConfig"`
-	IsSubDomainFinder  bool   `yaml:"subfinder"`
-	IsSubDomainBrute   bool   `yaml:"subdomainBrute"`
-	IsSubdomainCrawler bool   `yaml:"subdomainCrawler"`
-	IsIgnoreCDN        bool   `yaml:"ignoreCDN"`
-	IsIgnoreOutofChina bool   `yaml:"ignoreOutofChina"`
-	IsPortScan         bool   `yaml:"portscan"`
-	IsWhois            bool   `yaml:"whois"`
-	IsICP              bool   `yaml:"icp"`
+	Resolver               string `yaml:"resolver"`
+	Wordlist               string `yaml:"wordlist"`
+	ProviderConfig         string `yaml:"providerConfig"`
+	IsSubDomainFinder      bool   `yaml:"subfinder"`
+	IsSubDomainBrute       bool   `yaml:"subdomainBrute"`
+	IsSubdomainCrawler     bool   `yaml:"subdomainCrawler"`
+	IsIgnoreCDN            bool   `yaml:"ignoreCDN"`
+	IsIgnoreOutofChina     bool   `yaml:"ignoreOutofChina"`
+	IsPortScan             bool   `yaml:"portscan"`
+	IsWhois                bool   `yaml:"whois"`
+	IsICP                  bool   `yaml:"icp"`
+	IsDNSRecord            bool   `yaml:"dnsRecord"`
+	IsSubDomainPassive     bool   `yaml:"subdomainPassive"`
+	IsSubDomainAlteration  bool   `yaml:"subdomainAlteration"`
+	AlterationMaxCandidate int    `yaml:"alterationMaxCandidate"`
+	Passive                struct {
+		CrtSh          PassiveSource `yaml:"crtsh"`
+		SecurityTrails PassiveSource `yaml:"securitytrails"`
+		Wayback        PassiveSource `yaml:"wayback"`
+	} `yaml:"passive"`
+}
+
+// PassiveSource 内置被动子域名数据源的配置，RateLimit为每分钟最大请求数
+type PassiveSource struct {
+	Enable    bool   `yaml:"enable"`
+	Key       string `yaml:"key"`
+	RateLimit int    `yaml:"rateLimit"`
 }
 
 type OnlineAPI struct {
-	IsFofa   bool `yaml:"fofa"`
-	IsQuake  bool `yaml:"quake"`
-	IsHunter bool `yaml:"hunter"`
+	IsFofa       bool `yaml:"fofa"`
+	IsQuake      bool `yaml:"quake"`
+	IsHunter     bool `yaml:"hunter"`
+	IsShodan     bool `yaml:"shodan"`
+	IsCensys     bool `yaml:"censys"`
+	IsZoomEye    bool `yaml:"zoomeye"`
+	IsNetlas     bool `yaml:"netlas"`
+	IsBinaryEdge bool `yaml:"binaryedge"`
+}
+
+// IsEnabled 是否启用在线资产搜索引擎
+func (o OnlineAPI) IsEnabled(apiName string) bool {
+	switch apiName {
+	case "fofa":
+		return o.IsFofa
+	case "quake":
+		return o.IsQuake
+	case "hunter":
+		return o.IsHunter
+	case "shodan":
+		return o.IsShodan
+	case "censys":
+		return o.IsCensys
+	case "zoomeye":
+		return o.IsZoomEye
+	case "netlas":
+		return o.IsNetlas
+	case "binaryedge":
+		return o.IsBinaryEdge
+	}
+	return false
 }
 
 type Notify struct {
@@ -0,0 +1,176 @@
+package db
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// APIKey 在线资产平台API的key，由server的key池统一管理并租用给worker
+type APIKey struct {
+	Id     int    `gorm:"primaryKey"`
+	Engine string `gorm:"column:engine"`
+	APIKey string `gorm:"column:api_key"`
+	// Enabled 为false时不再租用该key
+	Enabled bool `gorm:"column:enabled"`
+	// Status key的状态：unknown、ok、ratelimited、exhausted、invalid
+	Status string `gorm:"column:status"`
+	// Quota 剩余的额度，-1表示未知
+	Quota     int    `gorm:"column:quota"`
+	LastError string `gorm:"column:last_error"`
+	// CooldownDatetime 限流或额度耗尽后，在该时间之前不再租用
+	CooldownDatetime *time.Time `gorm:"column:cooldown_datetime"`
+	CheckDatetime    *time.Time `gorm:"column:check_datetime"`
+	LeaseDatetime    *time.Time `gorm:"column:lease_datetime"`
+	CreateDatetime   time.Time  `gorm:"column:create_datetime"`
+	UpdateDatetime   time.Time  `gorm:"column:update_datetime"`
+}
+
+// APIKeyUsage 各workspace使用key的查询次数及结果数量
+type APIKeyUsage struct {
+	Id             int       `gorm:"primaryKey"`
+	APIKeyId       int       `gorm:"column:api_key_id"`
+	WorkspaceId    int       `gorm:"column:workspace_id"`
+	QueryCount     int       `gorm:"column:query_count"`
+	ResultCount    int       `gorm:"column:result_count"`
+	ErrorCount     int       `gorm:"column:error_count"`
+	CreateDatetime time.Time `gorm:"column:create_datetime"`
+	UpdateDatetime time.Time `gorm:"column:update_datetime"`
+}
+
+// APIKeyUsageSum key的使用量汇总
+type APIKeyUsageSum struct {
+	APIKeyId    int
+	QueryCount  int
+	ResultCount int
+	ErrorCount  int
+}
+
+func (*APIKey) TableName() string {
+	return "api_key"
+}
+
+func (*APIKeyUsage) TableName() string {
+	return "api_key_usage"
+}
+
+// Get 根据ID查询记录
+func (k *APIKey) Get() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.First(k, k.Id); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// GetByKey 根据engine及key查询记录
+func (k *APIKey) GetByKey() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Where("engine", k.Engine).Where("api_key", k.APIKey).First(k); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Add 插入一条新的记录
+func (k *APIKey) Add() (success bool) {
+	k.CreateDatetime = time.Now()
+	k.UpdateDatetime = time.Now()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Create(k); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Update 更新指定ID的一条记录，列名和内容位于map中
+func (k *APIKey) Update(updateMap map[string]interface{}) (success bool) {
+	updateMap["update_datetime"] = time.Now()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Model(k).Updates(updateMap); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Delete 删除指定ID的一条记录
+func (k *APIKey) Delete() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Delete(k, k.Id); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Gets 查询指定engine（为空时查询全部）的key
+func (k *APIKey) Gets() (results []APIKey) {
+	db := GetDB()
+	defer CloseDB(db)
+	if k.Engine != "" {
+		db = db.Where("engine", k.Engine)
+	}
+	db.Order("engine").Order("id").Find(&results)
+
+	return
+}
+
+// Count 统计指定engine的key数量
+func (k *APIKey) Count() (count int) {
+	var total int64
+	db := GetDB()
+	defer CloseDB(db)
+	db.Model(k).Where("engine", k.Engine).Count(&total)
+
+	return int(total)
+}
+
+// AddUsage 累加key在workspace中的使用量，记录不存在时新建
+func (u *APIKeyUsage) AddUsage() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	old := APIKeyUsage{}
+	if result := db.Where("api_key_id", u.APIKeyId).Where("workspace_id", u.WorkspaceId).First(&old); result.RowsAffected > 0 {
+		result = db.Model(&old).Updates(map[string]interface{}{
+			"query_count":     gorm.Expr("query_count + ?", u.QueryCount),
+			"result_count":    gorm.Expr("result_count + ?", u.ResultCount),
+			"error_count":     gorm.Expr("error_count + ?", u.ErrorCount),
+			"update_datetime": time.Now(),
+		})
+		return result.RowsAffected > 0
+	}
+	u.CreateDatetime = time.Now()
+	u.UpdateDatetime = time.Now()
+	if result := db.Create(u); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// SumByKey 按key汇总使用量，workspaceId大于0时只统计该workspace
+func (u *APIKeyUsage) SumByKey(workspaceId int) (results map[int]APIKeyUsageSum) {
+	results = make(map[int]APIKeyUsageSum)
+	var rows []APIKeyUsageSum
+	db := GetDB()
+	defer CloseDB(db)
+	db = db.Model(u).Select("api_key_id, sum(query_count) as query_count, sum(result_count) as result_count, sum(error_count) as error_count")
+	if workspaceId > 0 {
+		db = db.Where("workspace_id", workspaceId)
+	}
+	db.Group("api_key_id").Scan(&rows)
+	for _, row := range rows {
+		results[row.APIKeyId] = row
+	}
+	return
+}
@@ -123,6 +123,8 @@ func (domain *Domain) makeWhere(searchMap map[string]interface{}) *gorm.DB {
 			domainAttr := GetDB().Model(&DomainAttr{}).Select("r_id").Where("content like ?", fmt.Sprintf("%%%s%%", value))
 			db = db.Where("id in (?)", domainAttr)
 			CloseDB(domainAttr)
+		case "query":
+			db = makeQueryWhere(value, QueryCategoryDomain, db)
 		case "domain_http":
 			http := GetDB().Model(&DomainHttp{}).Select("r_id").Where("content like ?", fmt.Sprintf("%%%s%%", value))
 			db = db.Where("id in (?)", http)
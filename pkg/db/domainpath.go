@@ -0,0 +1,104 @@
+package db
+
+import (
+	"time"
+)
+
+type DomainPath struct {
+	Id             int       `gorm:"primaryKey"`
+	RelatedId      int       `gorm:"column:r_id"`
+	Port           int       `gorm:"column:port"`
+	Path           string    `gorm:"column:path"`
+	Url            string    `gorm:"column:url"`
+	Status         int       `gorm:"column:status"`
+	ContentLength  int       `gorm:"column:content_length"`
+	Location       string    `gorm:"column:location"`
+	Source         string    `gorm:"column:source"`
+	CreateDatetime time.Time `gorm:"column:create_datetime"`
+	UpdateDatetime time.Time `gorm:"column:update_datetime"`
+}
+
+func (*DomainPath) TableName() string {
+	return "domain_path"
+}
+
+// Add 插入一条新的记录，返回主键ID及成功标志
+func (d *DomainPath) Add() (success bool) {
+	d.CreateDatetime = time.Now()
+	d.UpdateDatetime = time.Now()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Create(d); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// GetsByRelatedId 根据域名查询全部路径记录
+func (d *DomainPath) GetsByRelatedId() (results []DomainPath) {
+	orderBy := "port,path"
+
+	db := GetDB()
+	defer CloseDB(db)
+	db.Where("r_id", d.RelatedId).Order(orderBy).Find(&results)
+	return
+}
+
+// GetByRelatedIdAndPortAndPath 根据域名、port、path查询一条记录
+func (d *DomainPath) GetByRelatedIdAndPortAndPath() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+
+	if result := db.Where("r_id", d.RelatedId).Where("port", d.Port).Where("path", d.Path).First(d); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Update 更新指定ID的一条记录，列名和内容位于map中
+func (d *DomainPath) Update(updateMap map[string]interface{}) (success bool) {
+	updateMap["update_datetime"] = time.Now()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Model(d).Updates(updateMap); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Delete 删除指定主键ID的一条记录
+func (d *DomainPath) Delete() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Delete(d, d.Id); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// SaveOrUpdate 保存、更新一条记录
+func (d *DomainPath) SaveOrUpdate() (success bool, isNew bool) {
+	oldRecord := &DomainPath{RelatedId: d.RelatedId, Port: d.Port, Path: d.Path}
+	if oldRecord.GetByRelatedIdAndPortAndPath() {
+		updateMap := map[string]interface{}{
+			"url":            d.Url,
+			"status":         d.Status,
+			"content_length": d.ContentLength,
+			"location":       d.Location,
+		}
+		if d.Source != "" {
+			updateMap["source"] = d.Source
+		}
+		//更新记录
+		d.Id = oldRecord.Id
+		return d.Update(updateMap), false
+	} else {
+		return d.Add(), true
+	}
+}
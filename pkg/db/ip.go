@@ -216,6 +216,8 @@ func (ip *Ip) makeWhere(searchMap map[string]interface{}) *gorm.DB {
 				db = db.Where("id in (?)", dbPorts)
 				CloseDB(dbPorts)
 			}
+		case "query":
+			db = makeQueryWhere(value, QueryCategoryIP, db)
 		case "ip_http":
 			http := GetDB().Model(&IpHttp{}).Select("r_id").Where("content like ?", fmt.Sprintf("%%%s%%", value))
 			port := GetDB().Model(&Port{}).Select("ip_id").Where("id in (?)", http)
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"time"
+)
+
+type IpPath struct {
+	Id             int       `gorm:"primaryKey"`
+	RelatedId      int       `gorm:"column:r_id"`
+	Path           string    `gorm:"column:path"`
+	Url            string    `gorm:"column:url"`
+	Status         int       `gorm:"column:status"`
+	ContentLength  int       `gorm:"column:content_length"`
+	Location       string    `gorm:"column:location"`
+	Source         string    `gorm:"column:source"`
+	CreateDatetime time.Time `gorm:"column:create_datetime"`
+	UpdateDatetime time.Time `gorm:"column:update_datetime"`
+}
+
+func (*IpPath) TableName() string {
+	return "ip_path"
+}
+
+// Add 插入一条新的记录，返回主键ID及成功标志
+func (i *IpPath) Add() (success bool) {
+	i.CreateDatetime = time.Now()
+	i.UpdateDatetime = time.Now()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Create(i); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// GetsByRelatedId 根据端口查询全部路径记录
+func (i *IpPath) GetsByRelatedId() (results []IpPath) {
+	orderBy := "path"
+
+	db := GetDB()
+	defer CloseDB(db)
+	db.Where("r_id", i.RelatedId).Order(orderBy).Find(&results)
+	return
+}
+
+// GetByRelatedIdAndPath 根据端口、path查询一条记录
+func (i *IpPath) GetByRelatedIdAndPath() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+
+	if result := db.Where("r_id", i.RelatedId).Where("path", i.Path).First(i); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Update 更新指定ID的一条记录，列名和内容位于map中
+func (i *IpPath) Update(updateMap map[string]interface{}) (success bool) {
+	updateMap["update_datetime"] = time.Now()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Model(i).Updates(updateMap); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Delete 删除指定主键ID的一条记录
+func (i *IpPath) Delete() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Delete(i, i.Id); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// SaveOrUpdate 保存、更新一条记录
+func (i *IpPath) SaveOrUpdate() (success bool, isNew bool) {
+	oldRecord := &IpPath{RelatedId: i.RelatedId, Path: i.Path}
+	if oldRecord.GetByRelatedIdAndPath() {
+		updateMap := map[string]interface{}{
+			"url":            i.Url,
+			"status":         i.Status,
+			"content_length": i.ContentLength,
+			"location":       i.Location,
+		}
+		if i.Source != "" {
+			updateMap["source"] = i.Source
+		}
+		//更新记录
+		i.Id = oldRecord.Id
+		return i.Update(updateMap), false
+	} else {
+		return i.Add(), true
+	}
+}
@@ -0,0 +1,577 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"gorm.io/gorm"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+资产查询语句，语法类似于FOFA：
+	port="443" && title="login" && !location="CN"
+	(app="Shiro" || header="rememberMe") && port="8080"
+匹配方式：
+	=	包含（端口等数值类型为等于）
+	==	等于
+	!=	不包含（不等于）
+	~=	正则匹配（MySQL REGEXP）
+逻辑运算：&&、||、!及()，优先级为! > && > ||
+*/
+
+const (
+	QueryCategoryIP            = "ip"
+	QueryCategoryDomain        = "domain"
+	QueryCategoryVulnerability = "vulnerability"
+
+	queryMaxLength     = 4096
+	queryMaxDepth      = 32
+	queryMaxConditions = 64
+)
+
+// Query 解析后的查询语句
+type Query struct {
+	root *queryNode
+}
+
+// queryNode 查询语句的语法树节点
+type queryNode struct {
+	// and、or、not或cond
+	nodeType string
+	children []*queryNode
+	// cond节点的字段、匹配方式及值
+	field    string
+	operator string
+	value    string
+}
+
+// queryField 查询字段的定义
+type queryField struct {
+	// column 匹配的列名，subquery不为空时为子查询表中的列
+	column string
+	// numeric 数值类型，只支持等于匹配
+	numeric bool
+	// ipv4 支持IPv4地址及掩码的匹配
+	ipv4 bool
+	// subquery 根据子查询表中的匹配条件生成主表的查询条件
+	subquery func(db *gorm.DB, condition string, args []interface{}) (string, []interface{})
+}
+
+type queryToken struct {
+	kind  string
+	value string
+	pos   int
+}
+
+const (
+	tokenLParen = "("
+	tokenRParen = ")"
+	tokenAnd    = "&&"
+	tokenOr     = "||"
+	tokenNot    = "!"
+	tokenOp     = "op"
+	tokenString = "string"
+	tokenWord   = "word"
+	tokenEOF    = "eof"
+)
+
+// ParseQuery 解析查询语句
+func ParseQuery(query string) (*Query, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("empty query")
+	}
+	if len(query) > queryMaxLength {
+		return nil, fmt.Errorf("query too long:%d", len(query))
+	}
+	tokens, err := tokenizeQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	p := queryParser{tokens: tokens}
+	root, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at %d", t.value, t.pos)
+	}
+	if p.conditions > queryMaxConditions {
+		return nil, fmt.Errorf("too many conditions:%d", p.conditions)
+	}
+	return &Query{root: root}, nil
+}
+
+// ValidateQuery 检查查询语句的语法及字段是否适用于指定的类别
+func ValidateQuery(category, query string) error {
+	fields, err := getQueryFields(category)
+	if err != nil {
+		return err
+	}
+	q, err := ParseQuery(query)
+	if err != nil {
+		return err
+	}
+	return q.check(q.root, fields)
+}
+
+// check 检查语法树中的字段及匹配的值
+func (q *Query) check(node *queryNode, fields map[string]queryField) error {
+	if node.nodeType != "cond" {
+		for _, child := range node.children {
+			if err := q.check(child, fields); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	field, ok := fields[node.field]
+	if !ok {
+		return fmt.Errorf("unknown field:%s", node.field)
+	}
+	operator := node.operator
+	if operator == "!=" {
+		operator = "="
+	}
+	if _, _, err := matchCondition(field, operator, node.value); err != nil {
+		return fmt.Errorf("%s:%v", node.field, err)
+	}
+	return nil
+}
+
+// makeQueryWhere 将查询语句转换为查询条件；查询语句错误时不返回任何记录
+func makeQueryWhere(value interface{}, category string, db *gorm.DB) *gorm.DB {
+	fields, err := getQueryFields(category)
+	if err == nil {
+		var q *Query
+		if q, err = ParseQuery(fmt.Sprintf("%v", value)); err == nil {
+			var clause string
+			var args []interface{}
+			if clause, args, err = q.compile(db, q.root, fields); err == nil {
+				return db.Where(clause, args...)
+			}
+		}
+	}
+	logging.RuntimeLog.Warningf("invalid query:%v,%v", value, err)
+	return db.Where("1 = 0")
+}
+
+// compile 将语法树转换为SQL条件，字段名只来源于预定义的字段，值均使用参数传递
+func (q *Query) compile(db *gorm.DB, node *queryNode, fields map[string]queryField) (string, []interface{}, error) {
+	switch node.nodeType {
+	case "and", "or":
+		var clauses []string
+		var args []interface{}
+		for _, child := range node.children {
+			clause, childArgs, err := q.compile(db, child, fields)
+			if err != nil {
+				return "", nil, err
+			}
+			clauses = append(clauses, clause)
+			args = append(args, childArgs...)
+		}
+		return "(" + strings.Join(clauses, " "+strings.ToUpper(node.nodeType)+" ") + ")", args, nil
+	case "not":
+		clause, args, err := q.compile(db, node.children[0], fields)
+		if err != nil {
+			return "", nil, err
+		}
+		return "NOT " + clause, args, nil
+	default:
+		return q.compileCondition(db, node, fields)
+	}
+}
+
+// compileCondition 生成一个字段的匹配条件
+func (q *Query) compileCondition(db *gorm.DB, node *queryNode, fields map[string]queryField) (string, []interface{}, error) {
+	field, ok := fields[node.field]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown field:%s", node.field)
+	}
+	operator := node.operator
+	negative := false
+	if operator == "!=" {
+		operator = "="
+		negative = true
+	}
+	condition, args, err := matchCondition(field, operator, node.value)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s:%v", node.field, err)
+	}
+	if field.subquery != nil {
+		condition, args = field.subquery(db.Session(&gorm.Session{NewDB: true}), condition, args)
+	}
+	if negative {
+		return "NOT (" + condition + ")", args, nil
+	}
+	return "(" + condition + ")", args, nil
+}
+
+// matchCondition 根据匹配方式生成列的条件
+func matchCondition(field queryField, operator, value string) (string, []interface{}, error) {
+	column := field.column
+	if field.numeric {
+		if operator == "~=" {
+			return "", nil, errors.New("regexp not supported")
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid number:%s", value)
+		}
+		return column + " = ?", []interface{}{n}, nil
+	}
+	switch operator {
+	case "=", "==":
+		if field.ipv4 && utils.CheckIPV4Subnet(value) {
+			_ip, _ipNet, err := net.ParseCIDR(value)
+			if err == nil {
+				ones, bits := _ipNet.Mask.Size()
+				_ipStart := utils.IPV4ToUInt32(_ip.Mask(_ipNet.Mask).String())
+				_ipEnd := _ipStart + (1 << (bits - ones)) - 1
+				return "ip_int BETWEEN ? AND ?", []interface{}{_ipStart, _ipEnd}, nil
+			}
+		}
+		if operator == "==" || (field.ipv4 && utils.CheckIPV4(value)) {
+			return column + " = ?", []interface{}{value}, nil
+		}
+		return column + " LIKE ?", []interface{}{"%" + escapeLike(value) + "%"}, nil
+	case "~=":
+		if _, err := regexp.Compile(value); err != nil {
+			return "", nil, fmt.Errorf("invalid regexp:%s", value)
+		}
+		return column + " REGEXP ?", []interface{}{value}, nil
+	}
+	return "", nil, fmt.Errorf("unsupported operator:%s", operator)
+}
+
+// escapeLike 转义LIKE中的通配符
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// tokenizeQuery 对查询语句进行词法分析
+func tokenizeQuery(query string) (tokens []queryToken, err error) {
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, queryToken{kind: string(c), value: string(c), pos: i})
+			i++
+		case c == '&' || c == '|':
+			if i+1 >= len(runes) || runes[i+1] != c {
+				return nil, fmt.Errorf("unexpected %c at %d", c, i)
+			}
+			tokens = append(tokens, queryToken{kind: string([]rune{c, c}), value: string([]rune{c, c}), pos: i})
+			i += 2
+		case c == '!':
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, queryToken{kind: tokenOp, value: "!=", pos: i})
+				i += 2
+			} else {
+				tokens = append(tokens, queryToken{kind: tokenNot, value: "!", pos: i})
+				i++
+			}
+		case c == '=':
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, queryToken{kind: tokenOp, value: "==", pos: i})
+				i += 2
+			} else {
+				tokens = append(tokens, queryToken{kind: tokenOp, value: "=", pos: i})
+				i++
+			}
+		case c == '~':
+			if i+1 >= len(runes) || runes[i+1] != '=' {
+				return nil, fmt.Errorf("unexpected %c at %d", c, i)
+			}
+			tokens = append(tokens, queryToken{kind: tokenOp, value: "~=", pos: i})
+			i += 2
+		case c == '"' || c == '\'':
+			var sb strings.Builder
+			start := i
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == c {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			tokens = append(tokens, queryToken{kind: tokenString, value: sb.String(), pos: start})
+		default:
+			start := i
+			for i < len(runes) && isQueryWordRune(runes[i]) {
+				i++
+			}
+			if start == i {
+				return nil, fmt.Errorf("unexpected %c at %d", c, i)
+			}
+			tokens = append(tokens, queryToken{kind: tokenWord, value: string(runes[start:i]), pos: start})
+		}
+	}
+	tokens = append(tokens, queryToken{kind: tokenEOF, value: "end of query", pos: len(runes)})
+	return
+}
+
+// isQueryWordRune 不加引号的字段名及值允许的字符
+func isQueryWordRune(c rune) bool {
+	return c == '_' || c == '-' || c == '.' || c == ':' || c == '/' || c == '*' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c > 127
+}
+
+// queryParser 递归下降的语法分析
+type queryParser struct {
+	tokens     []queryToken
+	pos        int
+	conditions int
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// parseOr or := and ("||" and)*
+func (p *queryParser) parseOr(depth int) (*queryNode, error) {
+	if depth > queryMaxDepth {
+		return nil, errors.New("query nested too deep")
+	}
+	node, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenOr {
+		return node, nil
+	}
+	or := &queryNode{nodeType: "or", children: []*queryNode{node}}
+	for p.peek().kind == tokenOr {
+		p.next()
+		if node, err = p.parseAnd(depth); err != nil {
+			return nil, err
+		}
+		or.children = append(or.children, node)
+	}
+	return or, nil
+}
+
+// parseAnd and := unary ("&&" unary)*
+func (p *queryParser) parseAnd(depth int) (*queryNode, error) {
+	node, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenAnd {
+		return node, nil
+	}
+	and := &queryNode{nodeType: "and", children: []*queryNode{node}}
+	for p.peek().kind == tokenAnd {
+		p.next()
+		if node, err = p.parseUnary(depth); err != nil {
+			return nil, err
+		}
+		and.children = append(and.children, node)
+	}
+	return and, nil
+}
+
+// parseUnary unary := "!" unary | "(" or ")" | field op value
+func (p *queryParser) parseUnary(depth int) (*queryNode, error) {
+	if depth > queryMaxDepth {
+		return nil, errors.New("query nested too deep")
+	}
+	t := p.next()
+	switch t.kind {
+	case tokenNot:
+		node, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &queryNode{nodeType: "not", children: []*queryNode{node}}, nil
+	case tokenLParen:
+		node, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r.kind != tokenRParen {
+			return nil, fmt.Errorf("expect ) but got %s at %d", r.value, r.pos)
+		}
+		return node, nil
+	case tokenWord:
+		op := p.next()
+		if op.kind != tokenOp {
+			return nil, fmt.Errorf("expect operator after %s at %d", t.value, op.pos)
+		}
+		value := p.next()
+		if value.kind != tokenString && value.kind != tokenWord {
+			return nil, fmt.Errorf("expect value after %s at %d", op.value, value.pos)
+		}
+		p.conditions++
+		return &queryNode{nodeType: "cond", field: strings.ToLower(t.value), operator: op.value, value: value.value}, nil
+	}
+	return nil, fmt.Errorf("unexpected %s at %d", t.value, t.pos)
+}
+
+// getQueryFields 获取类别可用的查询字段
+func getQueryFields(category string) (map[string]queryField, error) {
+	switch category {
+	case QueryCategoryIP:
+		return ipQueryFields, nil
+	case QueryCategoryDomain:
+		return domainQueryFields, nil
+	case QueryCategoryVulnerability:
+		return vulnerabilityQueryFields, nil
+	}
+	return nil, fmt.Errorf("unknown query category:%s", category)
+}
+
+// GetQueryFields 获取类别可用的查询字段名
+func GetQueryFields(category string) (names []string) {
+	fields, _ := getQueryFields(category)
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// ipPortAttrField IP端口属性（title、banner等）的查询字段，tags为空时匹配全部属性
+func ipPortAttrField(tags ...string) queryField {
+	return queryField{column: "content", subquery: func(db *gorm.DB, condition string, args []interface{}) (string, []interface{}) {
+		portAttr := db.Model(&PortAttr{}).Select("r_id").Where(condition, args...)
+		if len(tags) > 0 {
+			portAttr = portAttr.Where("tag in ?", tags)
+		}
+		port := db.Model(&Port{}).Select("ip_id").Where("id in (?)", portAttr)
+		return "id IN (?)", []interface{}{port}
+	}}
+}
+
+// ipHttpField IP端口http信息（header、body）的查询字段
+func ipHttpField(tags ...string) queryField {
+	return queryField{column: "content", subquery: func(db *gorm.DB, condition string, args []interface{}) (string, []interface{}) {
+		http := db.Model(&IpHttp{}).Select("r_id").Where(condition, args...)
+		if len(tags) > 0 {
+			http = http.Where("tag in ?", tags)
+		}
+		port := db.Model(&Port{}).Select("ip_id").Where("id in (?)", http)
+		return "id IN (?)", []interface{}{port}
+	}}
+}
+
+// domainAttrField 域名属性的查询字段
+func domainAttrField(tags ...string) queryField {
+	return queryField{column: "content", subquery: func(db *gorm.DB, condition string, args []interface{}) (string, []interface{}) {
+		domainAttr := db.Model(&DomainAttr{}).Select("r_id").Where(condition, args...)
+		if len(tags) > 0 {
+			domainAttr = domainAttr.Where("tag in ?", tags)
+		}
+		return "id IN (?)", []interface{}{domainAttr}
+	}}
+}
+
+// domainHttpField 域名http信息的查询字段
+func domainHttpField(column string, numeric bool, tags ...string) queryField {
+	return queryField{column: column, numeric: numeric, subquery: func(db *gorm.DB, condition string, args []interface{}) (string, []interface{}) {
+		http := db.Model(&DomainHttp{}).Select("r_id").Where(condition, args...)
+		if len(tags) > 0 {
+			http = http.Where("tag in ?", tags)
+		}
+		return "id IN (?)", []interface{}{http}
+	}}
+}
+
+// relatedField 颜色标记、备忘录等通过r_id关联的查询字段
+func relatedField(model interface{}, column string) queryField {
+	return queryField{column: column, subquery: func(db *gorm.DB, condition string, args []interface{}) (string, []interface{}) {
+		related := db.Model(model).Select("r_id").Where(condition, args...)
+		return "id IN (?)", []interface{}{related}
+	}}
+}
+
+var ipQueryFields = map[string]queryField{
+	"ip":       {column: "ip", ipv4: true},
+	"location": {column: "location"},
+	"port": {column: "port", numeric: true, subquery: func(db *gorm.DB, condition string, args []interface{}) (string, []interface{}) {
+		port := db.Model(&Port{}).Select("ip_id").Where(condition, args...)
+		return "id IN (?)", []interface{}{port}
+	}},
+	"port_status": {column: "status", subquery: func(db *gorm.DB, condition string, args []interface{}) (string, []interface{}) {
+		port := db.Model(&Port{}).Select("ip_id").Where(condition, args...)
+		return "id IN (?)", []interface{}{port}
+	}},
+	"domain": {column: "domain", subquery: func(db *gorm.DB, condition string, args []interface{}) (string, []interface{}) {
+		domains := db.Model(&Domain{}).Select("id").Where(condition, args...)
+		content := db.Model(&DomainAttr{}).Select("content").Where("tag in ?", []string{"A", "AAAA"}).Where("r_id in (?)", domains)
+		return "ip IN (?)", []interface{}{content}
+	}},
+	"title":       ipPortAttrField("title"),
+	"banner":      ipPortAttrField("banner"),
+	"server":      ipPortAttrField("server"),
+	"service":     ipPortAttrField("service"),
+	"app":         ipPortAttrField("fingerprint"),
+	"fingerprint": ipPortAttrField("fingerprint"),
+	"favicon":     ipPortAttrField("favicon"),
+	"cert":        ipPortAttrField("tlsdata"),
+	"content":     ipPortAttrField(),
+	"header":      ipHttpField("header"),
+	"body":        ipHttpField("body"),
+	"http":        ipHttpField(),
+	"color_tag":   relatedField(&IpColorTag{}, "color"),
+	"memo":        relatedField(&IpMemo{}, "content"),
+}
+
+var domainQueryFields = map[string]queryField{
+	"domain":      {column: "domain"},
+	"ip":          domainAttrField("A", "AAAA"),
+	"cname":       domainAttrField("CNAME"),
+	"title":       domainAttrField("title"),
+	"banner":      domainAttrField("banner"),
+	"server":      domainAttrField("server"),
+	"service":     domainAttrField("service"),
+	"app":         domainAttrField("fingerprint"),
+	"fingerprint": domainAttrField("fingerprint"),
+	"favicon":     domainAttrField("favicon"),
+	"cert":        domainAttrField("tlsdata"),
+	"content":     domainAttrField(),
+	"port":        domainHttpField("port", true),
+	"header":      domainHttpField("content", false, "header"),
+	"body":        domainHttpField("content", false, "body"),
+	"http":        domainHttpField("content", false),
+	"color_tag":   relatedField(&DomainColorTag{}, "color"),
+	"memo":        relatedField(&DomainMemo{}, "content"),
+}
+
+var vulnerabilityQueryFields = map[string]queryField{
+	"target":   {column: "target"},
+	"url":      {column: "url"},
+	"poc":      {column: "poc_file"},
+	"poc_file": {column: "poc_file"},
+	"source":   {column: "source"},
+	"extra":    {column: "extra"},
+}
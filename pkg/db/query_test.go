@@ -0,0 +1,119 @@
+package db
+
+import (
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"strings"
+	"testing"
+)
+
+// dryRunDB 不连接数据库，只生成SQL语句
+func dryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "nemo:nemo@tcp(127.0.0.1:3306)/nemo", SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// querySQL 生成查询语句对应的SQL
+func querySQL(t *testing.T, db *gorm.DB, model interface{}, category, query string) (string, []interface{}) {
+	fields, err := getQueryFields(category)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clause, args, err := q.compile(db, q.root, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := db.Model(model).Where(clause, args...).Find(&[]map[string]interface{}{}).Statement
+	return stmt.SQL.String(), stmt.Vars
+}
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(`port="443" && title="login" || !(location=="CN" && memo~="^test")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ||优先级低于&&
+	if q.root.nodeType != "or" || len(q.root.children) != 2 || q.root.children[0].nodeType != "and" || q.root.children[1].nodeType != "not" {
+		t.Errorf("unexpected tree:%+v", q.root)
+	}
+	cond := q.root.children[1].children[0].children[1]
+	if cond.field != "memo" || cond.operator != "~=" || cond.value != "^test" {
+		t.Errorf("unexpected condition:%+v", cond)
+	}
+	// 不加引号的值及转义
+	q, err = ParseQuery(`ip=192.168.1.0/24 && title!="say \"hi\""`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.root.children[0].value != "192.168.1.0/24" || q.root.children[1].operator != "!=" || q.root.children[1].value != `say "hi"` {
+		t.Errorf("unexpected tree:%+v", q.root.children)
+	}
+
+	for _, query := range []string{
+		``,
+		`port=`,
+		`port="443" &&`,
+		`(port="443"`,
+		`port="443")`,
+		`port "443"`,
+		`port="443" & title="a"`,
+		`title="unterminated`,
+		strings.Repeat("(", queryMaxDepth+2) + `port="443"` + strings.Repeat(")", queryMaxDepth+2),
+		strings.Repeat(`port="1" || `, queryMaxConditions) + `port="1"`,
+	} {
+		if _, err = ParseQuery(query); err == nil {
+			t.Errorf("query should fail:%s", query)
+		}
+	}
+}
+
+func TestValidateQuery(t *testing.T) {
+	if err := ValidateQuery(QueryCategoryIP, `app="Shiro" || header="rememberMe"`); err != nil {
+		t.Error(err)
+	}
+	for category, query := range map[string]string{
+		QueryCategoryIP:            `unknown="a"`,
+		QueryCategoryDomain:        `port="abc"`,
+		QueryCategoryVulnerability: `target~="("`,
+		"unknown":                  `target="a"`,
+	} {
+		if err := ValidateQuery(category, query); err == nil {
+			t.Errorf("%s query should fail:%s", category, query)
+		}
+	}
+}
+
+func TestQuery_Compile(t *testing.T) {
+	db := dryRunDB(t)
+
+	sql, vars := querySQL(t, db, &Ip{}, QueryCategoryIP, `ip="192.168.1.0/24" && !location="C_N" && port=="443"`)
+	expected := "SELECT * FROM `ip` WHERE ((ip_int BETWEEN ? AND ?) AND NOT (location LIKE ?) AND (id IN (SELECT `ip_id` FROM `port` WHERE port = ?)))"
+	if sql != expected {
+		t.Errorf("unexpected sql:%s", sql)
+	}
+	if len(vars) != 4 || vars[0] != uint32(3232235776) || vars[1] != uint32(3232236031) || vars[2] != `%C\_N%` || vars[3] != 443 {
+		t.Errorf("unexpected vars:%v", vars)
+	}
+
+	sql, vars = querySQL(t, db, &Domain{}, QueryCategoryDomain, `app="Shiro" || header!="rememberMe"`)
+	expected = "SELECT * FROM `domain` WHERE ((id IN (SELECT `r_id` FROM `domain_attr` WHERE content LIKE ? AND tag in (?))) OR NOT (id IN (SELECT `r_id` FROM `domain_http` WHERE content LIKE ? AND tag in (?))))"
+	if sql != expected {
+		t.Errorf("unexpected sql:%s", sql)
+	}
+	if len(vars) != 4 || vars[0] != "%Shiro%" || vars[2] != "%rememberMe%" {
+		t.Errorf("unexpected vars:%v", vars)
+	}
+
+	sql, vars = querySQL(t, db, &Vulnerability{}, QueryCategoryVulnerability, `poc~="^CVE-2023" && source=="nuclei"`)
+	expected = "SELECT * FROM `vulnerability` WHERE ((poc_file REGEXP ?) AND (source = ?))"
+	if sql != expected || len(vars) != 2 {
+		t.Errorf("unexpected sql:%s %v", sql, vars)
+	}
+}
@@ -15,6 +15,9 @@ type RuntimeLog struct {
 	Level          string    `gorm:"column:level"`
 	LevelInt       int       `gorm:"level_int"`
 	Message        string    `gorm:"column:message"`
+	TaskId         string    `gorm:"column:task_id"`
+	MainTaskId     string    `gorm:"column:main_task_id"`
+	WorkspaceId    int       `gorm:"column:workspace_id"`
 	CreateDatetime time.Time `gorm:"column:create_datetime"`
 	UpdateDatetime time.Time `gorm:"column:update_datetime"`
 }
@@ -25,6 +28,33 @@ func (*RuntimeLog) TableName() string {
 
 // Add 插入一条新的记录，返回主键ID及成功标志
 func (l *RuntimeLog) Add() (success bool) {
+	l.setLevelInt()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Create(l); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// AddLogs 批量插入记录，返回插入的记录数量
+func (l *RuntimeLog) AddLogs(logs []RuntimeLog) (count int) {
+	if len(logs) == 0 {
+		return 0
+	}
+	for i := range logs {
+		logs[i].setLevelInt()
+	}
+	db := GetDB()
+	defer CloseDB(db)
+	result := db.CreateInBatches(logs, 100)
+	return int(result.RowsAffected)
+}
+
+// setLevelInt 设置记录的时间，并根据level设置level_int
+func (l *RuntimeLog) setLevelInt() {
 	l.CreateDatetime = time.Now()
 	l.UpdateDatetime = time.Now()
 	/*
@@ -72,14 +102,6 @@ func (l *RuntimeLog) Add() (success bool) {
 	default:
 		l.LevelInt = 10
 	}
-
-	db := GetDB()
-	defer CloseDB(db)
-	if result := db.Create(l); result.RowsAffected > 0 {
-		return true
-	} else {
-		return false
-	}
 }
 
 // Get 根据Id查询记录
@@ -142,6 +164,9 @@ func (l *RuntimeLog) makeWhere(searchMap map[string]interface{}) *gorm.DB {
 			db = db.Where("level_int <= ?", value)
 		case "message":
 			db = makeLike(value, column, db)
+		case "task_id":
+			// 任务的日志，包括主任务下所有子任务的日志
+			db = db.Where("task_id = ? OR main_task_id = ?", value, value)
 		case "date_delta":
 			// 筛选指定日期之前的日志（注意：与其它查询时间之类的有所区别）
 			daysToHour := 24 * value.(int)
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"time"
+)
+
+// SavedQuery 保存的资产查询语句
+type SavedQuery struct {
+	Id             int       `gorm:"primaryKey"`
+	Name           string    `gorm:"column:name"`
+	Category       string    `gorm:"column:category"`
+	Query          string    `gorm:"column:query"`
+	WorkspaceId    int       `gorm:"column:workspace_id"`
+	CreateDatetime time.Time `gorm:"column:create_datetime"`
+	UpdateDatetime time.Time `gorm:"column:update_datetime"`
+}
+
+func (*SavedQuery) TableName() string {
+	return "saved_query"
+}
+
+// Get 根据ID查询记录
+func (s *SavedQuery) Get() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.First(s, s.Id); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// GetByName 根据名称及类别查询记录
+func (s *SavedQuery) GetByName() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Where("workspace_id", s.WorkspaceId).Where("category", s.Category).Where("name", s.Name).First(s); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Add 插入一条新的记录
+func (s *SavedQuery) Add() (success bool) {
+	s.CreateDatetime = time.Now()
+	s.UpdateDatetime = time.Now()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Create(s); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Update 更新指定ID的一条记录，列名和内容位于map中
+func (s *SavedQuery) Update(updateMap map[string]interface{}) (success bool) {
+	updateMap["update_datetime"] = time.Now()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Model(s).Updates(updateMap); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Delete 删除指定ID的一条记录
+func (s *SavedQuery) Delete() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Delete(s, s.Id); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Gets 查询workspace中指定类别的所有记录
+func (s *SavedQuery) Gets() (results []SavedQuery) {
+	db := GetDB()
+	defer CloseDB(db)
+	if s.WorkspaceId > 0 {
+		db = db.Where("workspace_id", s.WorkspaceId)
+	}
+	if s.Category != "" {
+		db = db.Where("category", s.Category)
+	}
+	db.Order("name").Find(&results)
+
+	return
+}
+
+// SaveOrUpdate 保存、更新一条记录，同名的查询语句将被更新
+func (s *SavedQuery) SaveOrUpdate() (success bool, isNew bool) {
+	oldRecord := &SavedQuery{WorkspaceId: s.WorkspaceId, Category: s.Category, Name: s.Name}
+	if oldRecord.GetByName() {
+		s.Id = oldRecord.Id
+		return s.Update(map[string]interface{}{"query": s.Query}), false
+	} else {
+		return s.Add(), true
+	}
+}
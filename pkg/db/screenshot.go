@@ -0,0 +1,126 @@
+package db
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Screenshot 截图的页面信息及感知哈希，截图文件保存在webfiles中
+type Screenshot struct {
+	Id             int       `gorm:"primaryKey"`
+	Domain         string    `gorm:"column:domain"`
+	Port           int       `gorm:"column:port"`
+	Protocol       string    `gorm:"column:protocol"`
+	FinalUrl       string    `gorm:"column:final_url"`
+	Title          string    `gorm:"column:title"`
+	Content        string    `gorm:"column:content"`
+	PHash          string    `gorm:"column:phash"`
+	WorkspaceId    int       `gorm:"column:workspace_id"`
+	CreateDatetime time.Time `gorm:"column:create_datetime"`
+	UpdateDatetime time.Time `gorm:"column:update_datetime"`
+}
+
+func (*Screenshot) TableName() string {
+	return "screenshot"
+}
+
+// Get 根据ID查询记录
+func (s *Screenshot) Get() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.First(s, s.Id); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// GetByTarget 根据域名（IP）、端口及协议精确查询一条记录
+func (s *Screenshot) GetByTarget() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Where("workspace_id", s.WorkspaceId).Where("domain", s.Domain).Where("port", s.Port).Where("protocol", s.Protocol).First(s); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Add 插入一条新的记录
+func (s *Screenshot) Add() (success bool) {
+	s.CreateDatetime = time.Now()
+	s.UpdateDatetime = time.Now()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Create(s); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Update 更新指定ID的一条记录，列名和内容位于map中
+func (s *Screenshot) Update(updateMap map[string]interface{}) (success bool) {
+	updateMap["update_datetime"] = time.Now()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Model(s).Updates(updateMap); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// DeleteByDomain 删除指定域名（IP）的所有记录
+func (s *Screenshot) DeleteByDomain() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Where("workspace_id", s.WorkspaceId).Where("domain", s.Domain).Delete(&Screenshot{}); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// makeWhere 根据查询条件的不同的字段，组合生成查询条件
+func (s *Screenshot) makeWhere(searchMap map[string]interface{}) *gorm.DB {
+	db := GetDB()
+	for column, value := range searchMap {
+		switch column {
+		case "domain", "final_url", "title", "content":
+			db = makeLike(value, column, db)
+		case "date_delta":
+			db = makeDateDelta(value.(int), "update_datetime", db)
+		default:
+			db = db.Where(column, value)
+		}
+	}
+	return db
+}
+
+// Gets 根据指定的条件查询所有满足要求的记录，不包括页面文本内容
+func (s *Screenshot) Gets(searchMap map[string]interface{}) (results []Screenshot) {
+	db := s.makeWhere(searchMap).Model(s)
+	defer CloseDB(db)
+	db.Omit("content").Order("update_datetime desc").Find(&results)
+
+	return
+}
+
+// SaveOrUpdate 保存、更新一条记录
+func (s *Screenshot) SaveOrUpdate() (success bool, isNew bool) {
+	oldRecord := &Screenshot{WorkspaceId: s.WorkspaceId, Domain: s.Domain, Port: s.Port, Protocol: s.Protocol}
+	if oldRecord.GetByTarget() {
+		s.Id = oldRecord.Id
+		return s.Update(map[string]interface{}{
+			"final_url": s.FinalUrl,
+			"title":     s.Title,
+			"content":   s.Content,
+			"phash":     s.PHash,
+		}), false
+	} else {
+		return s.Add(), true
+	}
+}
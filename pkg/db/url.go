@@ -0,0 +1,163 @@
+package db
+
+import (
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Url URL（endpoint）资产，来源于爬虫、httpx及dirsearch
+type Url struct {
+	Id             int       `gorm:"primaryKey"`
+	Url            string    `gorm:"column:url"`
+	Host           string    `gorm:"column:host"`
+	Port           int       `gorm:"column:port"`
+	Method         string    `gorm:"column:method"`
+	Path           string    `gorm:"column:path"`
+	Params         string    `gorm:"column:params"`
+	ContentType    string    `gorm:"column:content_type"`
+	Status         int       `gorm:"column:status"`
+	Source         string    `gorm:"column:source"`
+	Hash           string    `gorm:"column:hash"`
+	WorkspaceId    int       `gorm:"column:workspace_id"`
+	CreateDatetime time.Time `gorm:"column:create_datetime"`
+	UpdateDatetime time.Time `gorm:"column:update_datetime"`
+}
+
+func (*Url) TableName() string {
+	return "url"
+}
+
+// makeHash 同一工作空间中method与url唯一确定一条记录
+func (u *Url) makeHash() string {
+	return utils.MD5(fmt.Sprintf("%s%s", u.Method, u.Url))
+}
+
+// Get 根据ID查询记录
+func (u *Url) Get() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.First(u, u.Id); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Add 插入一条新的记录
+func (u *Url) Add() (success bool) {
+	u.CreateDatetime = time.Now()
+	u.UpdateDatetime = time.Now()
+	u.Hash = u.makeHash()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Create(u); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// GetByUrl 根据method与url精确查询一条记录
+func (u *Url) GetByUrl() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Where("workspace_id", u.WorkspaceId).Where("hash", u.makeHash()).First(u); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Update 更新指定ID的一条记录，列名和内容位于map中
+func (u *Url) Update(updateMap map[string]interface{}) (success bool) {
+	updateMap["update_datetime"] = time.Now()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Model(u).Updates(updateMap); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Delete 删除指定主键ID的一条记录
+func (u *Url) Delete() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Delete(u, u.Id); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Count 统计指定查询条件的记录数量
+func (u *Url) Count(searchMap map[string]interface{}) (count int) {
+	db := u.makeWhere(searchMap).Model(u)
+	defer CloseDB(db)
+	var result int64
+	db.Count(&result)
+	return int(result)
+}
+
+// makeWhere 根据查询条件的不同的字段，组合生成count和search的查询条件
+func (u *Url) makeWhere(searchMap map[string]interface{}) *gorm.DB {
+	db := GetDB()
+	//根据查询条件的不同的字段，组合生成查询条件
+	for column, value := range searchMap {
+		switch column {
+		case "url", "host", "path", "params", "content_type", "source":
+			db = makeLike(value, column, db)
+		case "date_delta":
+			db = makeDateDelta(value.(int), "update_datetime", db)
+		case "create_date_delta":
+			db = makeDateDelta(value.(int), "create_datetime", db)
+		default:
+			db = db.Where(column, value)
+		}
+	}
+	return db
+}
+
+// Gets 根据指定的条件，查询满足要求的记录
+func (u *Url) Gets(searchMap map[string]interface{}, page, rowsPerPage int) (results []Url, count int) {
+	orderBy := "update_datetime desc"
+
+	db := u.makeWhere(searchMap).Model(u)
+	defer CloseDB(db)
+	//统计满足条件的总记录数
+	var total int64
+	db.Count(&total)
+	//获取分页查询结果
+	if rowsPerPage > 0 && page > 0 {
+		db = db.Offset((page - 1) * rowsPerPage).Limit(rowsPerPage)
+	}
+	db.Order(orderBy).Find(&results)
+
+	return results, int(total)
+}
+
+// SaveOrUpdate 保存、更新一条记录，已存在的记录更新最后发现时间
+func (u *Url) SaveOrUpdate() (success bool, isNew bool) {
+	oldRecord := &Url{Url: u.Url, Method: u.Method, WorkspaceId: u.WorkspaceId}
+	if oldRecord.GetByUrl() {
+		updateMap := map[string]interface{}{
+			"params": utils.MergeCommaString(oldRecord.Params, u.Params),
+			"source": utils.MergeCommaString(oldRecord.Source, u.Source),
+		}
+		if u.ContentType != "" {
+			updateMap["content_type"] = u.ContentType
+		}
+		if u.Status > 0 {
+			updateMap["status"] = u.Status
+		}
+		u.Id = oldRecord.Id
+		return u.Update(updateMap), false
+	} else {
+		return u.Add(), true
+	}
+}
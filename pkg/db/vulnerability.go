@@ -117,6 +117,8 @@ func (vul *Vulnerability) makeWhere(searchMap map[string]interface{}) *gorm.DB {
 			db = makeLike(value, column, db)
 		case "poc_file":
 			db = makeLike(value, column, db)
+		case "query":
+			db = makeQueryWhere(value, QueryCategoryVulnerability, db)
 		case "date_delta":
 			db = makeDateDelta(value.(int), "update_datetime", db)
 		default:
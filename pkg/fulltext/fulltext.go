@@ -0,0 +1,329 @@
+package fulltext
+
+import (
+	"errors"
+	"fmt"
+	"github.com/blevesearch/bleve/v2"
+	_ "github.com/blevesearch/bleve/v2/analysis/lang/cjk"
+	"github.com/blevesearch/bleve/v2/index/scorch"
+	"github.com/blevesearch/bleve/v2/mapping"
+	htmlFormatter "github.com/blevesearch/bleve/v2/search/highlight/format/html"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	CategoryIP     = "ip"
+	CategoryDomain = "domain"
+	TypeAttr       = "attr"
+	TypeHttp       = "http"
+	// MaxSearchSize 单次查询最多返回的结果数
+	MaxSearchSize = 100
+	// indexBatchSize 每批次索引的文档数
+	indexBatchSize = 500
+	// boltTimeout 等待其它进程（server与serverapi）释放索引的时间
+	boltTimeout = "30s"
+	// fragmentSize 高亮片段的长度
+	fragmentSize = 200
+)
+
+var (
+	defaultIndexer     *Indexer
+	defaultIndexerOnce sync.Once
+	guidPattern        = regexp.MustCompile(`^[0-9a-zA-Z\-]+$`)
+)
+
+// Document 全文索引的文档，ID为<数据表>:<记录ID>，如port_attr:1
+type Document struct {
+	ID         string
+	Category   string
+	Target     string
+	Port       int
+	Type       string
+	Source     string
+	Tag        string
+	Content    string
+	UpdateTime time.Time
+}
+
+// SearchRequest 全文检索的请求，Query为bleve的query string语法，如：rememberMe tag:header
+type SearchRequest struct {
+	Query    string
+	Category string
+	From     int
+	Size     int
+}
+
+// SearchHit 一条检索结果，Highlights为内容中匹配的片段（已进行html转义，匹配内容由<mark>标记）
+type SearchHit struct {
+	ID         string
+	Category   string
+	Target     string
+	Port       int
+	Type       string
+	Source     string
+	Tag        string
+	Score      float64
+	Highlights []string
+	UpdateTime time.Time
+}
+
+// SearchResult 全文检索的结果
+type SearchResult struct {
+	Total uint64
+	Hits  []SearchHit
+	Took  time.Duration
+}
+
+// Indexer 管理各workspace的全文索引，每个workspace的索引位于<root>/<workspaceGUID>；
+// server与serverapi可能同时读写索引，因此每次操作时打开索引、完成后关闭
+type Indexer struct {
+	sync.Mutex
+	root string
+}
+
+// NewIndexer 创建全文索引管理对象
+func NewIndexer(root string) *Indexer {
+	return &Indexer{root: root}
+}
+
+// GetIndexer 获取server配置的全文索引管理对象
+func GetIndexer() *Indexer {
+	defaultIndexerOnce.Do(func() {
+		root := conf.GlobalServerConfig().Fulltext.Path
+		if root == "" {
+			root = "fulltext"
+		}
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(conf.GetRootPath(), root)
+		}
+		defaultIndexer = NewIndexer(root)
+	})
+	return defaultIndexer
+}
+
+// IsEnabled 是否启用全文索引
+func IsEnabled() bool {
+	return conf.GlobalServerConfig().Fulltext.Enabled
+}
+
+// newIndexMapping 索引的字段映射：content使用cjk分词并保存词向量用于高亮，其它字段不分词
+func newIndexMapping() mapping.IndexMapping {
+	keywordField := bleve.NewKeywordFieldMapping()
+	keywordField.IncludeInAll = false
+	numericField := bleve.NewNumericFieldMapping()
+	numericField.IncludeInAll = false
+	datetimeField := bleve.NewDateTimeFieldMapping()
+	datetimeField.IncludeInAll = false
+	contentField := bleve.NewTextFieldMapping()
+	contentField.Analyzer = "cjk"
+	contentField.IncludeTermVectors = true
+
+	docMapping := bleve.NewDocumentMapping()
+	for _, field := range []string{"category", "target", "type", "source", "tag"} {
+		docMapping.AddFieldMappingsAt(field, keywordField)
+	}
+	docMapping.AddFieldMappingsAt("port", numericField)
+	docMapping.AddFieldMappingsAt("update_time", datetimeField)
+	docMapping.AddFieldMappingsAt("content", contentField)
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = docMapping
+	indexMapping.DefaultAnalyzer = "cjk"
+	return indexMapping
+}
+
+// indexPath workspace的索引目录
+func (i *Indexer) indexPath(workspaceGUID string) (string, error) {
+	if !guidPattern.MatchString(workspaceGUID) {
+		return "", fmt.Errorf("invalid workspace guid:%s", workspaceGUID)
+	}
+	return filepath.Join(i.root, workspaceGUID), nil
+}
+
+// open 打开workspace的索引，不存在且create为true时创建索引
+func (i *Indexer) open(workspaceGUID string, create bool) (bleve.Index, error) {
+	path, err := i.indexPath(workspaceGUID)
+	if err != nil {
+		return nil, err
+	}
+	config := map[string]interface{}{"bolt_timeout": boltTimeout}
+	if utils.CheckFileExist(path) {
+		return bleve.OpenUsing(path, config)
+	}
+	if !create {
+		return nil, nil
+	}
+	if err = os.MkdirAll(i.root, 0755); err != nil {
+		return nil, err
+	}
+	return bleve.NewUsing(path, newIndexMapping(), scorch.Name, scorch.Name, config)
+}
+
+// Index 增量索引文档，相同ID的文档将被更新
+func (i *Indexer) Index(workspaceGUID string, docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	i.Lock()
+	defer i.Unlock()
+
+	index, err := i.open(workspaceGUID, true)
+	if err != nil {
+		return err
+	}
+	defer index.Close()
+
+	batch := index.NewBatch()
+	for _, doc := range docs {
+		if doc.ID == "" || doc.Content == "" {
+			continue
+		}
+		if err = batch.Index(doc.ID, doc.fields()); err != nil {
+			return err
+		}
+		if batch.Size() >= indexBatchSize {
+			if err = index.Batch(batch); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if batch.Size() > 0 {
+		return index.Batch(batch)
+	}
+	return nil
+}
+
+// Delete 从索引中删除指定ID的文档
+func (i *Indexer) Delete(workspaceGUID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	i.Lock()
+	defer i.Unlock()
+
+	index, err := i.open(workspaceGUID, false)
+	if err != nil || index == nil {
+		return err
+	}
+	defer index.Close()
+
+	batch := index.NewBatch()
+	for _, id := range ids {
+		batch.Delete(id)
+	}
+	return index.Batch(batch)
+}
+
+// DeleteIndex 删除workspace的索引
+func (i *Indexer) DeleteIndex(workspaceGUID string) error {
+	path, err := i.indexPath(workspaceGUID)
+	if err != nil {
+		return err
+	}
+	i.Lock()
+	defer i.Unlock()
+
+	return os.RemoveAll(path)
+}
+
+// Search 在workspace的索引中进行全文检索，结果按相关度排序并返回高亮片段
+func (i *Indexer) Search(workspaceGUID string, req SearchRequest) (result *SearchResult, err error) {
+	if req.Query == "" {
+		return nil, errors.New("empty query")
+	}
+	if req.Size <= 0 || req.Size > MaxSearchSize {
+		req.Size = MaxSearchSize
+	}
+	if req.From < 0 {
+		req.From = 0
+	}
+	// 提前校验语法，无索引时也返回查询语句的错误
+	var q query.Query = bleve.NewQueryStringQuery(req.Query)
+	if _, err = q.(*query.QueryStringQuery).Parse(); err != nil {
+		return nil, err
+	}
+	if req.Category != "" {
+		categoryQuery := bleve.NewTermQuery(req.Category)
+		categoryQuery.SetField("category")
+		q = bleve.NewConjunctionQuery(q, categoryQuery)
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	result = &SearchResult{}
+	index, err := i.open(workspaceGUID, false)
+	if err != nil || index == nil {
+		return result, err
+	}
+	defer index.Close()
+
+	searchRequest := bleve.NewSearchRequestOptions(q, req.Size, req.From, false)
+	searchRequest.Fields = []string{"*"}
+	searchRequest.Highlight = bleve.NewHighlightWithStyle(htmlFormatter.Name)
+	searchRequest.Highlight.AddField("content")
+	searchResult, err := index.Search(searchRequest)
+	if err != nil {
+		return nil, err
+	}
+	result.Total = searchResult.Total
+	result.Took = searchResult.Took
+	for _, hit := range searchResult.Hits {
+		result.Hits = append(result.Hits, newSearchHit(hit.ID, hit.Score, hit.Fields, hit.Fragments["content"]))
+	}
+	return
+}
+
+// fields 索引的字段内容
+func (doc Document) fields() map[string]interface{} {
+	if doc.UpdateTime.IsZero() {
+		doc.UpdateTime = time.Now()
+	}
+	return map[string]interface{}{
+		"category":    doc.Category,
+		"target":      doc.Target,
+		"port":        doc.Port,
+		"type":        doc.Type,
+		"source":      doc.Source,
+		"tag":         doc.Tag,
+		"content":     doc.Content,
+		"update_time": doc.UpdateTime,
+	}
+}
+
+// newSearchHit 由检索结果保存的字段生成SearchHit，没有匹配内容的片段时（如只匹配了tag）使用内容的开始部分
+func newSearchHit(id string, score float64, fields map[string]interface{}, fragments []string) SearchHit {
+	hit := SearchHit{ID: id, Score: score, Highlights: fragments}
+	hit.Category, _ = fields["category"].(string)
+	hit.Target, _ = fields["target"].(string)
+	hit.Type, _ = fields["type"].(string)
+	hit.Source, _ = fields["source"].(string)
+	hit.Tag, _ = fields["tag"].(string)
+	if port, ok := fields["port"].(float64); ok {
+		hit.Port = int(port)
+	}
+	if updateTime, ok := fields["update_time"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, updateTime); err == nil {
+			hit.UpdateTime = t.Local()
+		}
+	}
+	if len(hit.Highlights) == 0 {
+		if content, ok := fields["content"].(string); ok && content != "" {
+			runes := []rune(content)
+			if len(runes) > fragmentSize {
+				runes = runes[:fragmentSize]
+			}
+			hit.Highlights = []string{html.EscapeString(string(runes))}
+		}
+	}
+	return hit
+}
@@ -0,0 +1,107 @@
+package fulltext
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/db"
+	"strings"
+	"testing"
+)
+
+func TestIndexer_Search(t *testing.T) {
+	indexer := NewIndexer(t.TempDir())
+	guid := "0f3c3b5e-1d2a-4d7b-9c1e-2b7a3c4d5e6f"
+	docs := []Document{
+		{ID: "ip_http:1", Category: CategoryIP, Target: "192.168.1.1", Port: 8080, Type: TypeHttp, Source: "httpx", Tag: "header", Content: "HTTP/1.1 302 Found\r\nSet-Cookie: rememberMe=deleteMe; Path=/"},
+		{ID: "ip_http:2", Category: CategoryIP, Target: "192.168.1.1", Port: 8080, Type: TypeHttp, Source: "httpx", Tag: "body", Content: "<html><title>后台管理系统</title><script>alert(1)</script></html>"},
+		{ID: "port_attr:1", Category: CategoryIP, Target: "192.168.1.2", Port: 22, Type: TypeAttr, Source: "nmap", Tag: "banner", Content: "OpenSSH 7.4"},
+		{ID: "domain_attr:1", Category: CategoryDomain, Target: "www.example.com", Type: TypeAttr, Source: "httpx", Tag: "title", Content: "Example Domain login"},
+		{ID: "", Category: CategoryIP, Target: "192.168.1.3", Content: "no id"},
+	}
+	if err := indexer.Index(guid, docs); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := indexer.Search(guid, SearchRequest{Query: "rememberMe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || result.Hits[0].ID != "ip_http:1" || result.Hits[0].Target != "192.168.1.1" || result.Hits[0].Port != 8080 || result.Hits[0].Tag != "header" || result.Hits[0].UpdateTime.IsZero() {
+		t.Fatalf("unexpected result:%+v", result)
+	}
+	if len(result.Hits[0].Highlights) == 0 || !strings.Contains(result.Hits[0].Highlights[0], "<mark>rememberMe</mark>") {
+		t.Errorf("unexpected highlights:%v", result.Hits[0].Highlights)
+	}
+	// 中文分词及html转义
+	result, err = indexer.Search(guid, SearchRequest{Query: "管理"})
+	if err != nil || result.Total != 1 || result.Hits[0].ID != "ip_http:2" {
+		t.Fatalf("unexpected result:%+v %v", result, err)
+	}
+	if highlight := strings.Join(result.Hits[0].Highlights, ""); !strings.Contains(highlight, "<mark>管理</mark>") || strings.Contains(highlight, "<script>") {
+		t.Errorf("unexpected highlights:%s", highlight)
+	}
+	// 字段查询及类别过滤
+	if result, err = indexer.Search(guid, SearchRequest{Query: "tag:banner openssh"}); err != nil || result.Total != 1 || result.Hits[0].ID != "port_attr:1" {
+		t.Errorf("unexpected result:%+v %v", result, err)
+	}
+	if result, err = indexer.Search(guid, SearchRequest{Query: "port:>=8000 target:192.168.1.1"}); err != nil || result.Total != 2 {
+		t.Errorf("unexpected result:%+v %v", result, err)
+	}
+	if result, err = indexer.Search(guid, SearchRequest{Query: "login", Category: CategoryIP}); err != nil || result.Total != 0 {
+		t.Errorf("unexpected result:%+v %v", result, err)
+	}
+	if result, err = indexer.Search(guid, SearchRequest{Query: "login", Category: CategoryDomain}); err != nil || result.Total != 1 {
+		t.Errorf("unexpected result:%+v %v", result, err)
+	}
+	// 增量更新及删除
+	docs[2].Content = "OpenSSH 8.0 Ubuntu"
+	if err = indexer.Index(guid, docs[2:3]); err != nil {
+		t.Fatal(err)
+	}
+	if result, err = indexer.Search(guid, SearchRequest{Query: "ubuntu"}); err != nil || result.Total != 1 || result.Hits[0].ID != "port_attr:1" {
+		t.Errorf("unexpected result:%+v %v", result, err)
+	}
+	if err = indexer.Delete(guid, []string{"port_attr:1"}); err != nil {
+		t.Fatal(err)
+	}
+	if result, err = indexer.Search(guid, SearchRequest{Query: "openssh"}); err != nil || result.Total != 0 {
+		t.Errorf("unexpected result:%+v %v", result, err)
+	}
+	// workspace之间的索引隔离
+	otherGUID := "5a6b7c8d-0000-4d7b-9c1e-2b7a3c4d5e6f"
+	if result, err = indexer.Search(otherGUID, SearchRequest{Query: "rememberMe"}); err != nil || result.Total != 0 {
+		t.Errorf("unexpected result:%+v %v", result, err)
+	}
+	if err = indexer.DeleteIndex(guid); err != nil {
+		t.Fatal(err)
+	}
+	if result, err = indexer.Search(guid, SearchRequest{Query: "rememberMe"}); err != nil || result.Total != 0 {
+		t.Errorf("unexpected result:%+v %v", result, err)
+	}
+}
+
+func TestIndexer_Invalid(t *testing.T) {
+	indexer := NewIndexer(t.TempDir())
+	for _, guid := range []string{"", "../guid", "guid/a"} {
+		if err := indexer.Index(guid, []Document{{ID: "ip_http:1", Content: "a"}}); err == nil {
+			t.Errorf("invalid guid should fail:%s", guid)
+		}
+	}
+	for _, q := range []string{"", "tag:", `"unterminated`} {
+		if _, err := indexer.Search("guid", SearchRequest{Query: q}); err == nil {
+			t.Errorf("invalid query should fail:%s", q)
+		}
+	}
+}
+
+func TestDocumentID(t *testing.T) {
+	pa := db.PortAttr{Id: 3, Source: "httpxfinger", Tag: "fingerprint", Content: "Shiro"}
+	if id := PortAttrDocumentID(&pa); id != "port_attr:3" || id != NewPortAttrDocument("192.168.1.1", 80, &pa).ID {
+		t.Errorf("unexpected port attr id:%s", id)
+	}
+	da := db.DomainAttr{Id: 5}
+	if id := DomainAttrDocumentID(&da); id != NewDomainAttrDocument("www.example.com", &da).ID {
+		t.Errorf("unexpected domain attr id:%s", id)
+	}
+	if PortAttrDocumentID(&db.PortAttr{}) != "" {
+		t.Error("unsaved attr should have no id")
+	}
+}
@@ -0,0 +1,274 @@
+package fulltext
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"strings"
+)
+
+// rebuildPageSize 重建索引时每次从数据库读取的IP、域名数量
+const rebuildPageSize = 500
+
+// NewPortAttrDocument 由端口属性生成文档
+func NewPortAttrDocument(ipName string, portNum int, attr *db.PortAttr) Document {
+	return Document{
+		ID:         makeID(attr.TableName(), attr.Id),
+		Category:   CategoryIP,
+		Target:     ipName,
+		Port:       portNum,
+		Type:       TypeAttr,
+		Source:     attr.Source,
+		Tag:        attr.Tag,
+		Content:    attr.Content,
+		UpdateTime: attr.UpdateDatetime,
+	}
+}
+
+// NewIpHttpDocument 由IP的HTTP信息生成文档
+func NewIpHttpDocument(ipName string, portNum int, http *db.IpHttp) Document {
+	return Document{
+		ID:         makeID(http.TableName(), http.Id),
+		Category:   CategoryIP,
+		Target:     ipName,
+		Port:       portNum,
+		Type:       TypeHttp,
+		Source:     http.Source,
+		Tag:        http.Tag,
+		Content:    http.Content,
+		UpdateTime: http.UpdateDatetime,
+	}
+}
+
+// NewDomainAttrDocument 由域名属性生成文档
+func NewDomainAttrDocument(domainName string, attr *db.DomainAttr) Document {
+	return Document{
+		ID:         makeID(attr.TableName(), attr.Id),
+		Category:   CategoryDomain,
+		Target:     domainName,
+		Type:       TypeAttr,
+		Source:     attr.Source,
+		Tag:        attr.Tag,
+		Content:    attr.Content,
+		UpdateTime: attr.UpdateDatetime,
+	}
+}
+
+// NewDomainHttpDocument 由域名的HTTP信息生成文档
+func NewDomainHttpDocument(domainName string, http *db.DomainHttp) Document {
+	return Document{
+		ID:         makeID(http.TableName(), http.Id),
+		Category:   CategoryDomain,
+		Target:     domainName,
+		Port:       http.Port,
+		Type:       TypeHttp,
+		Source:     http.Source,
+		Tag:        http.Tag,
+		Content:    http.Content,
+		UpdateTime: http.UpdateDatetime,
+	}
+}
+
+// PortAttrDocumentID 端口属性的文档ID
+func PortAttrDocumentID(attr *db.PortAttr) string {
+	return makeID(attr.TableName(), attr.Id)
+}
+
+// DomainAttrDocumentID 域名属性的文档ID
+func DomainAttrDocumentID(attr *db.DomainAttr) string {
+	return makeID(attr.TableName(), attr.Id)
+}
+
+// makeID 文档的ID
+func makeID(table string, id int) string {
+	if id <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", table, id)
+}
+
+// getWorkspaceGUID 获取workspace的GUID
+func getWorkspaceGUID(workspaceId int) (string, error) {
+	workspace := db.Workspace{Id: workspaceId}
+	if !workspace.Get() || workspace.WorkspaceGUID == "" {
+		return "", fmt.Errorf("workspace %d not exist", workspaceId)
+	}
+	return workspace.WorkspaceGUID, nil
+}
+
+// IndexWorkspace 保存扫描结果后增量索引文档，未启用全文索引时不处理
+func IndexWorkspace(workspaceId int, docs []Document) {
+	if !IsEnabled() || len(docs) == 0 {
+		return
+	}
+	guid, err := getWorkspaceGUID(workspaceId)
+	if err == nil {
+		err = GetIndexer().Index(guid, docs)
+	}
+	if err != nil {
+		logging.RuntimeLog.Errorf("fulltext index fail:%v", err)
+	}
+}
+
+// DeleteDocuments 删除属性或HTTP信息后从索引中删除对应的文档，未启用全文索引时不处理
+func DeleteDocuments(workspaceId int, ids []string) {
+	if !IsEnabled() || len(ids) == 0 {
+		return
+	}
+	guid, err := getWorkspaceGUID(workspaceId)
+	if err == nil {
+		err = GetIndexer().Delete(guid, ids)
+	}
+	if err != nil {
+		logging.RuntimeLog.Errorf("fulltext delete fail:%v", err)
+	}
+}
+
+// DeleteWorkspace 删除workspace时删除对应的索引
+func DeleteWorkspace(workspaceGUID string) {
+	if workspaceGUID == "" {
+		return
+	}
+	if err := GetIndexer().DeleteIndex(workspaceGUID); err != nil {
+		logging.RuntimeLog.Errorf("delete fulltext index fail:%v", err)
+	}
+}
+
+// SearchWorkspace 在workspace中进行全文检索；IP或域名已被删除的文档从结果及索引中移除
+func SearchWorkspace(workspaceId int, req SearchRequest) (*SearchResult, error) {
+	if !IsEnabled() {
+		return nil, errors.New("未启用全文索引")
+	}
+	guid, err := getWorkspaceGUID(workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	result, err := GetIndexer().Search(guid, req)
+	if err != nil {
+		return nil, err
+	}
+	var hits []SearchHit
+	var staleIds []string
+	targetExist := make(map[string]bool)
+	for _, hit := range result.Hits {
+		key := hit.Category + ":" + hit.Target
+		exist, ok := targetExist[key]
+		if !ok {
+			exist = checkTargetExist(workspaceId, hit.Category, hit.Target)
+			targetExist[key] = exist
+		}
+		if exist {
+			hits = append(hits, hit)
+		} else {
+			staleIds = append(staleIds, hit.ID)
+		}
+	}
+	if len(staleIds) > 0 {
+		if err = GetIndexer().Delete(guid, staleIds); err != nil {
+			logging.RuntimeLog.Errorf("delete fulltext stale document fail:%v", err)
+		}
+		result.Total -= uint64(len(staleIds))
+	}
+	result.Hits = hits
+	return result, nil
+}
+
+// checkTargetExist 检查workspace中IP或域名是否存在
+func checkTargetExist(workspaceId int, category, target string) bool {
+	switch category {
+	case CategoryIP:
+		ip := db.Ip{IpName: target, WorkspaceId: workspaceId}
+		return ip.GetByIp()
+	case CategoryDomain:
+		domain := db.Domain{DomainName: target, WorkspaceId: workspaceId}
+		return domain.GetByDomain()
+	}
+	return false
+}
+
+// Rebuild 删除workspace的索引，并根据数据库中的IP、域名的属性和HTTP信息重新建立索引
+func Rebuild(workspaceId int) (count int, err error) {
+	guid, err := getWorkspaceGUID(workspaceId)
+	if err != nil {
+		return
+	}
+	indexer := GetIndexer()
+	if err = indexer.DeleteIndex(guid); err != nil {
+		return
+	}
+	for page := 1; ; page++ {
+		ip := db.Ip{}
+		ips, _ := ip.Gets(map[string]interface{}{"workspace_id": workspaceId}, page, rebuildPageSize, false)
+		var docs []Document
+		for _, ipRow := range ips {
+			port := db.Port{IpId: ipRow.Id}
+			for _, portRow := range port.GetsByIPId() {
+				portAttr := db.PortAttr{RelatedId: portRow.Id}
+				for _, attr := range portAttr.GetsByRelatedId() {
+					docs = append(docs, NewPortAttrDocument(ipRow.IpName, portRow.PortNum, &attr))
+				}
+				ipHttp := db.IpHttp{RelatedId: portRow.Id}
+				for _, http := range ipHttp.GetsByRelatedId() {
+					docs = append(docs, NewIpHttpDocument(ipRow.IpName, portRow.PortNum, &http))
+				}
+			}
+		}
+		if err = indexer.Index(guid, docs); err != nil {
+			return
+		}
+		count += len(docs)
+		if len(ips) < rebuildPageSize {
+			break
+		}
+	}
+	for page := 1; ; page++ {
+		domain := db.Domain{}
+		domains, _ := domain.Gets(map[string]interface{}{"workspace_id": workspaceId}, page, rebuildPageSize, false)
+		var docs []Document
+		for _, domainRow := range domains {
+			domainAttr := db.DomainAttr{RelatedId: domainRow.Id}
+			for _, attr := range domainAttr.GetsByRelatedId() {
+				docs = append(docs, NewDomainAttrDocument(domainRow.DomainName, &attr))
+			}
+			domainHttp := db.DomainHttp{RelatedId: domainRow.Id}
+			for _, http := range domainHttp.GetsByRelatedId() {
+				docs = append(docs, NewDomainHttpDocument(domainRow.DomainName, &http))
+			}
+		}
+		if err = indexer.Index(guid, docs); err != nil {
+			return
+		}
+		count += len(docs)
+		if len(domains) < rebuildPageSize {
+			break
+		}
+	}
+	return
+}
+
+// RebuildAll 重建全部或指定workspace（多个以逗号分隔的workspace GUID）的索引
+func RebuildAll(workspaceGUIDs string) {
+	var workspaces []db.Workspace
+	if workspaceGUIDs == "" || workspaceGUIDs == "all" {
+		w := db.Workspace{}
+		workspaces, _ = w.Gets(map[string]interface{}{}, -1, -1)
+	} else {
+		for _, guid := range strings.Split(workspaceGUIDs, ",") {
+			w := db.Workspace{WorkspaceGUID: strings.TrimSpace(guid)}
+			if !w.GetByGUID() {
+				logging.CLILog.Errorf("workspace %s not exist", guid)
+				continue
+			}
+			workspaces = append(workspaces, w)
+		}
+	}
+	for _, w := range workspaces {
+		count, err := Rebuild(w.Id)
+		if err != nil {
+			logging.CLILog.Errorf("rebuild fulltext index for workspace %s fail:%v", w.WorkspaceName, err)
+			continue
+		}
+		logging.CLILog.Infof("rebuild fulltext index for workspace %s total:%d", w.WorkspaceName, count)
+	}
+}
@@ -1,6 +1,7 @@
 package logging
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -40,3 +41,28 @@ func outputLog(c chan []byte, quit chan int) {
 		}
 	}
 }
+
+func TestNewTaskLog(t *testing.T) {
+	RuntimeLogChan = make(chan []byte, RuntimeLogChanMax)
+	defer func() { RuntimeLogChan = nil }()
+	SetWorkerName("worker@test")
+	defer SetWorkerName("")
+
+	NewTaskLog("task-1", "main-1", 2).Error("task error")
+	var msg RuntimeLogMessage
+	if err := json.Unmarshal(<-RuntimeLogChan, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.TaskId != "task-1" || msg.MainTaskId != "main-1" || msg.WorkspaceId != 2 || msg.Worker != "worker@test" || msg.Message != "task error" || msg.Level != "error" {
+		t.Errorf("unexpected message:%+v", msg)
+	}
+	// 没有任务日志时不包含任务字段
+	NewTaskLog("", "", 0).Warning("no task")
+	msg = RuntimeLogMessage{}
+	if err := json.Unmarshal(<-RuntimeLogChan, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.TaskId != "" || msg.MainTaskId != "" || msg.WorkspaceId != 0 || msg.Message != "no task" {
+		t.Errorf("unexpected message:%+v", msg)
+	}
+}
@@ -6,11 +6,15 @@ import (
 )
 
 type RuntimeLogMessage struct {
-	Source  string `json:"source"`
-	File    string `json:"file"`
-	Func    string `json:"func"`
-	Level   string `json:"level"`
-	Message string `json:"msg"`
+	Source      string `json:"source"`
+	File        string `json:"file"`
+	Func        string `json:"func"`
+	Level       string `json:"level"`
+	Message     string `json:"msg"`
+	TaskId      string `json:"task_id,omitempty"`
+	MainTaskId  string `json:"main_task_id,omitempty"`
+	WorkspaceId int    `json:"workspace,omitempty"`
+	Worker      string `json:"worker,omitempty"`
 }
 
 type RuntimeLogWriter struct {
@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// 任务日志的关联字段，由JSONFormatter输出到日志的顶层
+const (
+	FieldTaskId     = "task_id"
+	FieldMainTaskId = "main_task_id"
+	FieldWorkspace  = "workspace"
+	FieldWorker     = "worker"
+)
+
+// workerName 当前进程的worker名称，由SetWorkerName设置
+var workerName string
+
+// SetWorkerName 设置当前进程的worker名称，任务日志中将记录该名称
+func SetWorkerName(name string) {
+	workerName = name
+}
+
+// NewTaskLog 生成带有任务关联字段的RuntimeLog日志
+func NewTaskLog(taskId, mainTaskId string, workspaceId int) *logrus.Entry {
+	fields := logrus.Fields{}
+	if taskId != "" {
+		fields[FieldTaskId] = taskId
+	}
+	if mainTaskId != "" {
+		fields[FieldMainTaskId] = mainTaskId
+	}
+	if workspaceId > 0 {
+		fields[FieldWorkspace] = workspaceId
+	}
+	if workerName != "" {
+		fields[FieldWorker] = workerName
+	}
+	return RuntimeLog.WithFields(fields)
+}
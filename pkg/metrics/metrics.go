@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const namespace = "nemo"
+
+var (
+	// Registry server、serverapi及worker的指标注册表
+	Registry = prometheus.NewRegistry()
+
+	// TaskStateTotal 任务状态变化的数量
+	TaskStateTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "task_state_total",
+		Help:      "Number of task state changes by task name and state.",
+	}, []string{"name", "state"})
+	// TaskDuration 任务的执行时长
+	TaskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "task_duration_seconds",
+		Help:      "Task execution duration in seconds by task name and state.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+	}, []string{"name", "state"})
+	// QueueDepth 消息队列中等待执行的任务数量
+	QueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "task_queue_depth",
+		Help:      "Number of tasks waiting in the queue by topic.",
+	}, []string{"topic"})
+	// RPCDuration RPC调用的时长
+	RPCDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "RPC call latency in seconds by service method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+	// RPCErrors RPC调用失败的数量
+	RPCErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_errors_total",
+		Help:      "Number of failed RPC calls by service method.",
+	}, []string{"method"})
+	// ToolExitTotal 外部工具执行结束的数量，code为进程退出码，-1表示进程未能启动
+	ToolExitTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_exit_total",
+		Help:      "Number of external tool executions by tool and exit code.",
+	}, []string{"tool", "code"})
+	// OnlineAPIRequests 在线API查询的数量
+	OnlineAPIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "onlineapi_requests_total",
+		Help:      "Number of online API requests by api and result.",
+	}, []string{"api", "result"})
+	// OnlineAPIQuotaConsumed 在线API消耗的积分
+	OnlineAPIQuotaConsumed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "onlineapi_quota_consumed_total",
+		Help:      "Quota consumed by online API queries.",
+	}, []string{"api"})
+	// OnlineAPIQuotaRemaining 在线API剩余的积分
+	OnlineAPIQuotaRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "onlineapi_quota_remaining",
+		Help:      "Remaining quota of online API reported by the last query.",
+	}, []string{"api"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		TaskStateTotal,
+		TaskDuration,
+		QueueDepth,
+		RPCDuration,
+		RPCErrors,
+		ToolExitTotal,
+		OnlineAPIRequests,
+		OnlineAPIQuotaConsumed,
+		OnlineAPIQuotaRemaining,
+	)
+}
+
+// RegisterDB 注册数据库连接池的指标
+func RegisterDB(db *sql.DB, dbName string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// ObserveRPC 记录一次RPC调用的时长及结果
+func ObserveRPC(method string, start time.Time, err error) {
+	RPCDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		RPCErrors.WithLabelValues(method).Inc()
+	}
+}
+
+// ObserveToolExit 记录外部工具的退出码
+func ObserveToolExit(tool string, err error) {
+	code := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		} else {
+			code = -1
+		}
+	}
+	ToolExitTotal.WithLabelValues(tool, strconv.Itoa(code)).Inc()
+}
+
+// ObserveOnlineAPI 记录一次在线API的查询结果
+func ObserveOnlineAPI(api string, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	OnlineAPIRequests.WithLabelValues(api, result).Inc()
+}
+
+// Handler 指标的http接口；token不为空时需在Authorization中提供Bearer token
+func Handler(token string) http.Handler {
+	h := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+	if token == "" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// StartServer 启动独立的指标http服务（用于worker）
+func StartServer(addr string, token string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler(token))
+	return http.ListenAndServe(addr, mux)
+}
+
+// Values 获取指标各个label组合的当前值，key为按label名称排序后的label值以","连接；histogram取观测的次数
+func Values(name string) map[string]float64 {
+	values := make(map[string]float64)
+	families, err := Registry.Gather()
+	if err != nil {
+		return values
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			var labels []string
+			for _, l := range m.GetLabel() {
+				labels = append(labels, l.GetValue())
+			}
+			key := strings.Join(labels, ",")
+			switch {
+			case m.Counter != nil:
+				values[key] = m.Counter.GetValue()
+			case m.Gauge != nil:
+				values[key] = m.Gauge.GetValue()
+			case m.Histogram != nil:
+				values[key] = float64(m.Histogram.GetSampleCount())
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+)
+
+func TestObserveToolExit(t *testing.T) {
+	ObserveToolExit("test-ok", nil)
+	ObserveToolExit("test-notfound", exec.Command("/nonexistent/nemo-tool").Run())
+	ObserveToolExit("test-exit", exec.Command("sh", "-c", "exit 3").Run())
+	ObserveToolExit("test-error", errors.New("timeout"))
+
+	values := Values("nemo_tool_exit_total")
+	t.Log(values)
+	for key, expected := range map[string]float64{
+		"0,test-ok":        1,
+		"-1,test-notfound": 1,
+		"3,test-exit":      1,
+		"-1,test-error":    1,
+	} {
+		if values[key] != expected {
+			t.Errorf("%s: expected %v, got %v", key, expected, values[key])
+		}
+	}
+}
+
+func TestValues(t *testing.T) {
+	QueueDepth.WithLabelValues("active").Set(5)
+	TaskStateTotal.WithLabelValues("portscan", "SUCCESS").Add(2)
+
+	if v := Values("nemo_task_queue_depth")["active"]; v != 5 {
+		t.Errorf("queue depth: expected 5, got %v", v)
+	}
+	if v := Values("nemo_task_state_total")["portscan,SUCCESS"]; v != 2 {
+		t.Errorf("task state: expected 2, got %v", v)
+	}
+	if len(Values("nemo_not_exist")) != 0 {
+		t.Error("expected empty values")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	h := Handler("secret")
+	for _, c := range []struct {
+		auth string
+		code int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Bearer secret", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if c.auth != "" {
+			req.Header.Set("Authorization", c.auth)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.code {
+			t.Errorf("authorization %q: expected %d, got %d", c.auth, c.code, w.Code)
+		}
+	}
+}
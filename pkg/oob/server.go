@@ -0,0 +1,390 @@
+package oob
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/miekg/dns"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// idLength 分配给每次POC执行的子域名标识长度
+	idLength = 12
+	// interactionTTL 子域名及交互记录的保存时间
+	interactionTTL = 30 * time.Minute
+	// interactionMaxNumber 每个子域名最多保存的交互记录
+	interactionMaxNumber = 20
+	// rawDataMaxSize 交互记录中保存的原始数据最大长度
+	rawDataMaxSize  = 2048
+	connReadTimeout = 10 * time.Second
+	dnsAnswerTTL    = 60
+)
+
+const (
+	ProtocolDNS  = "dns"
+	ProtocolHTTP = "http"
+	ProtocolLDAP = "ldap"
+	ProtocolSMTP = "smtp"
+)
+
+// ldapBindResponse LDAP bind成功的响应（messageID为1），JNDI客户端收到后才会发送search请求
+var ldapBindResponse = []byte{0x30, 0x0c, 0x02, 0x01, 0x01, 0x61, 0x07, 0x0a, 0x01, 0x00, 0x04, 0x00, 0x04, 0x00}
+
+// Interaction 一次反连的交互记录
+type Interaction struct {
+	Protocol   string
+	Id         string
+	RemoteAddr string
+	RawData    string
+	Time       time.Time
+}
+
+// Server 反连平台：监听DNS、HTTP及可选的LDAP、SMTP，为每次POC执行分配唯一的子域名并记录交互
+type Server struct {
+	Config conf.OOB
+
+	mutex        sync.Mutex
+	ids          map[string]time.Time
+	interactions map[string][]Interaction
+	dnsServers   []*dns.Server
+	httpServer   *http.Server
+	listeners    []net.Listener
+	done         chan struct{}
+}
+
+var defaultServer *Server
+
+// NewServer 创建反连平台
+func NewServer(config conf.OOB) *Server {
+	return &Server{
+		Config:       config,
+		ids:          make(map[string]time.Time),
+		interactions: make(map[string][]Interaction),
+		done:         make(chan struct{}),
+	}
+}
+
+// StartServer 按server的配置启动反连平台
+func StartServer() {
+	config := conf.GlobalServerConfig().OOB
+	if !config.Enabled {
+		return
+	}
+	s := NewServer(config)
+	if err := s.Start(); err != nil {
+		logging.RuntimeLog.Errorf("start oob server fail:%v", err)
+		logging.CLILog.Errorf("start oob server fail:%v", err)
+		s.Shutdown()
+		return
+	}
+	defaultServer = s
+	logging.RuntimeLog.Infof("start oob server for %s...", config.Domain)
+	logging.CLILog.Infof("start oob server for %s...", config.Domain)
+}
+
+// DefaultServer 返回已启动的反连平台，未启用时返回nil
+func DefaultServer() *Server {
+	return defaultServer
+}
+
+// Start 启动各协议的监听
+func (s *Server) Start() (err error) {
+	s.Config.Domain = strings.ToLower(strings.Trim(s.Config.Domain, "."))
+	if s.Config.Domain == "" {
+		return errors.New("oob domain is empty")
+	}
+	if s.Config.DNSPort > 0 {
+		addr := net.JoinHostPort(s.Config.Host, fmt.Sprintf("%d", s.Config.DNSPort))
+		pc, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return err
+		}
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			pc.Close()
+			return err
+		}
+		handler := dns.HandlerFunc(s.handleDNS)
+		s.dnsServers = append(s.dnsServers, &dns.Server{PacketConn: pc, Handler: handler}, &dns.Server{Listener: l, Handler: handler})
+		for _, server := range s.dnsServers {
+			go server.ActivateAndServe()
+		}
+	}
+	if s.Config.HTTPPort > 0 {
+		l, err := s.listen(s.Config.HTTPPort)
+		if err != nil {
+			return err
+		}
+		s.httpServer = &http.Server{Handler: http.HandlerFunc(s.handleHTTP), ReadTimeout: connReadTimeout}
+		go s.httpServer.Serve(l)
+	}
+	if s.Config.LDAPPort > 0 {
+		l, err := s.listen(s.Config.LDAPPort)
+		if err != nil {
+			return err
+		}
+		go s.serveTCP(l, s.handleLDAP)
+	}
+	if s.Config.SMTPPort > 0 {
+		l, err := s.listen(s.Config.SMTPPort)
+		if err != nil {
+			return err
+		}
+		go s.serveTCP(l, s.handleSMTP)
+	}
+	go s.cleanup()
+	return nil
+}
+
+// Shutdown 关闭全部监听
+func (s *Server) Shutdown() {
+	select {
+	case <-s.done:
+		return
+	default:
+		close(s.done)
+	}
+	for _, server := range s.dnsServers {
+		server.Shutdown()
+	}
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+	for _, l := range s.listeners {
+		l.Close()
+	}
+}
+
+// NewSubdomain 分配一个唯一的子域名
+func (s *Server) NewSubdomain() (id string, domain string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for {
+		id = randomId()
+		if _, ok := s.ids[id]; !ok {
+			break
+		}
+	}
+	s.ids[id] = time.Now()
+	return id, fmt.Sprintf("%s.%s", id, s.Config.Domain)
+}
+
+// Lookup 查询子域名（或其标识）的交互记录
+func (s *Server) Lookup(domain string) []Interaction {
+	id := s.matchId(domain)
+	if id == "" {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Interaction(nil), s.interactions[id]...)
+}
+
+// matchId 从域名中获取分配的标识，支持多级子域名（如xxx.id.domain）
+func (s *Server) matchId(name string) string {
+	name = strings.ToLower(strings.Trim(name, "."))
+	if host, _, err := net.SplitHostPort(name); err == nil {
+		name = host
+	}
+	if strings.HasSuffix(name, "."+s.Config.Domain) {
+		name = strings.TrimSuffix(name, "."+s.Config.Domain)
+	} else if strings.Contains(name, ".") {
+		return ""
+	}
+	labels := strings.Split(name, ".")
+	id := labels[len(labels)-1]
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.ids[id]; ok {
+		return id
+	}
+	return ""
+}
+
+// matchIdInData 在原始数据中查找已分配的标识
+func (s *Server) matchIdInData(data []byte) string {
+	data = bytes.ToLower(data)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for id := range s.ids {
+		if bytes.Contains(data, []byte(id)) {
+			return id
+		}
+	}
+	return ""
+}
+
+func (s *Server) record(protocol, id, remoteAddr string, rawData []byte) {
+	if len(rawData) > rawDataMaxSize {
+		rawData = rawData[:rawDataMaxSize]
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.interactions[id]) >= interactionMaxNumber {
+		return
+	}
+	s.interactions[id] = append(s.interactions[id], Interaction{
+		Protocol:   protocol,
+		Id:         id,
+		RemoteAddr: remoteAddr,
+		RawData:    string(rawData),
+		Time:       time.Now(),
+	})
+}
+
+func (s *Server) handleDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+	for _, q := range r.Question {
+		if id := s.matchId(q.Name); id != "" {
+			s.record(ProtocolDNS, id, w.RemoteAddr().String(), []byte(q.String()))
+		}
+		name := strings.ToLower(q.Name)
+		if name != dns.Fqdn(s.Config.Domain) && !strings.HasSuffix(name, "."+dns.Fqdn(s.Config.Domain)) {
+			m.Rcode = dns.RcodeRefused
+			continue
+		}
+		ip := net.ParseIP(s.Config.IP)
+		if q.Qtype == dns.TypeA && ip != nil && ip.To4() != nil {
+			m.Answer = append(m.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: dnsAnswerTTL},
+				A:   ip.To4(),
+			})
+		}
+	}
+	w.WriteMsg(m)
+}
+
+func (s *Server) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	id := s.matchId(r.Host)
+	if id == "" {
+		id = s.matchIdInData([]byte(r.RequestURI))
+	}
+	if id != "" {
+		var buf bytes.Buffer
+		r.Write(&buf)
+		s.record(ProtocolHTTP, id, r.RemoteAddr, buf.Bytes())
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleLDAP 响应bind请求后读取search请求，在请求的DN中查找标识
+func (s *Server) handleLDAP(conn net.Conn) {
+	var data []byte
+	buf := make([]byte, rawDataMaxSize)
+	for i := 0; i < 2; i++ {
+		n, err := conn.Read(buf)
+		if n > 0 {
+			data = append(data, buf[:n]...)
+			if id := s.matchIdInData(data); id != "" {
+				s.record(ProtocolLDAP, id, conn.RemoteAddr().String(), data)
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+		if i == 0 {
+			conn.Write(ldapBindResponse)
+		}
+	}
+}
+
+// handleSMTP 模拟SMTP会话，在命令及邮件内容中查找标识
+func (s *Server) handleSMTP(conn net.Conn) {
+	fmt.Fprintf(conn, "220 %s ESMTP\r\n", s.Config.Domain)
+	var data []byte
+	var inData bool
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := scanner.Text()
+		data = append(data, []byte(line+"\n")...)
+		if inData {
+			if line == "." {
+				inData = false
+				fmt.Fprint(conn, "250 OK\r\n")
+			}
+			continue
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		if strings.HasPrefix(cmd, "QUIT") {
+			fmt.Fprint(conn, "221 Bye\r\n")
+			break
+		} else if strings.HasPrefix(cmd, "DATA") {
+			inData = true
+			fmt.Fprint(conn, "354 End data with <CR><LF>.<CR><LF>\r\n")
+		} else {
+			fmt.Fprint(conn, "250 OK\r\n")
+		}
+		if len(data) > rawDataMaxSize {
+			break
+		}
+	}
+	if id := s.matchIdInData(data); id != "" {
+		s.record(ProtocolSMTP, id, conn.RemoteAddr().String(), data)
+	}
+}
+
+func (s *Server) listen(port int) (net.Listener, error) {
+	l, err := net.Listen("tcp", net.JoinHostPort(s.Config.Host, fmt.Sprintf("%d", port)))
+	if err != nil {
+		return nil, err
+	}
+	s.listeners = append(s.listeners, l)
+	return l, nil
+}
+
+func (s *Server) serveTCP(l net.Listener, handler func(conn net.Conn)) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(connReadTimeout))
+			handler(conn)
+		}()
+	}
+}
+
+// cleanup 定期清除过期的子域名及交互记录
+func (s *Server) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+		s.mutex.Lock()
+		for id, t := range s.ids {
+			if time.Since(t) > interactionTTL {
+				delete(s.ids, id)
+				delete(s.interactions, id)
+			}
+		}
+		s.mutex.Unlock()
+	}
+}
+
+func randomId() string {
+	letters := "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, idLength)
+	for i := range b {
+		b[i] = letters[rand.Intn(len(letters))]
+	}
+	return string(b)
+}
@@ -0,0 +1,142 @@
+package oob
+
+import (
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/miekg/dns"
+	"net"
+	"net/http"
+	"net/smtp"
+	"testing"
+	"time"
+)
+
+// freePort 获取一个本地可用的端口
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func newTestServer(t *testing.T) *Server {
+	s := NewServer(conf.OOB{
+		Enabled:  true,
+		Domain:   "oob.example.com.",
+		IP:       "127.0.0.1",
+		Host:     "127.0.0.1",
+		DNSPort:  freePort(t),
+		HTTPPort: freePort(t),
+		LDAPPort: freePort(t),
+		SMTPPort: freePort(t),
+	})
+	if err := s.Start(); err != nil {
+		s.Shutdown()
+		t.Skip(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	return s
+}
+
+// waitInteraction 等待交互记录出现
+func waitInteraction(s *Server, domain string, protocol string) bool {
+	for i := 0; i < 20; i++ {
+		for _, r := range s.Lookup(domain) {
+			if r.Protocol == protocol {
+				return true
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return false
+}
+
+func TestServer_DNS(t *testing.T) {
+	s := newTestServer(t)
+	defer s.Shutdown()
+
+	_, domain := s.NewSubdomain()
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn("data."+domain), dns.TypeA)
+	r, err := dns.Exchange(m, fmt.Sprintf("127.0.0.1:%d", s.Config.DNSPort))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Answer) != 1 || r.Answer[0].(*dns.A).A.String() != "127.0.0.1" {
+		t.Errorf("invalid answer:%v", r.Answer)
+	}
+	if !waitInteraction(s, domain, ProtocolDNS) {
+		t.Error("dns interaction not found")
+	}
+
+	m.SetQuestion("www.other.com.", dns.TypeA)
+	if r, err = dns.Exchange(m, fmt.Sprintf("127.0.0.1:%d", s.Config.DNSPort)); err != nil || r.Rcode != dns.RcodeRefused {
+		t.Errorf("other domain should be refused:%v", err)
+	}
+	_, unused := s.NewSubdomain()
+	if len(s.Lookup(unused)) != 0 || len(s.Lookup("www.other.com")) != 0 {
+		t.Error("should not have interaction")
+	}
+}
+
+func TestServer_HTTP(t *testing.T) {
+	s := newTestServer(t)
+	defer s.Shutdown()
+
+	_, byHost := s.NewSubdomain()
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d/x", s.Config.HTTPPort), nil)
+	req.Host = byHost
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if !waitInteraction(s, byHost, ProtocolHTTP) {
+		t.Error("http interaction by host not found")
+	}
+
+	id, byPath := s.NewSubdomain()
+	resp, err = http.Get(fmt.Sprintf("http://127.0.0.1:%d/%s", s.Config.HTTPPort, id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if !waitInteraction(s, byPath, ProtocolHTTP) {
+		t.Error("http interaction by path not found")
+	}
+}
+
+func TestServer_LDAPAndSMTP(t *testing.T) {
+	s := newTestServer(t)
+	defer s.Shutdown()
+
+	id, domain := s.NewSubdomain()
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", s.Config.LDAPPort))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 匿名bind请求
+	conn.Write([]byte{0x30, 0x0c, 0x02, 0x01, 0x01, 0x60, 0x07, 0x02, 0x01, 0x03, 0x04, 0x00, 0x80, 0x00})
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	if err != nil || buf[5] != 0x61 {
+		t.Fatalf("invalid bind response:%x %v", buf[:n], err)
+	}
+	// search请求的baseObject中携带标识
+	conn.Write(append([]byte{0x30, 0x20, 0x02, 0x01, 0x02, 0x63, 0x1b, 0x04, byte(len(id))}, []byte(id)...))
+	conn.Close()
+	if !waitInteraction(s, domain, ProtocolLDAP) {
+		t.Error("ldap interaction not found")
+	}
+
+	_, domain = s.NewSubdomain()
+	err = smtp.SendMail(fmt.Sprintf("127.0.0.1:%d", s.Config.SMTPPort), nil, "test@example.com", []string{"admin@" + domain}, []byte("Subject: test\r\n\r\nhello\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !waitInteraction(s, domain, ProtocolSMTP) {
+		t.Error("smtp interaction not found")
+	}
+}
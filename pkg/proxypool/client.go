@@ -0,0 +1,164 @@
+package proxypool
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"golang.org/x/net/proxy"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SetTransport 设置http.Transport通过代理访问（支持http、https及socks5代理），p为nil时不修改；
+// 代理无效时所有请求都返回错误，不会直接连接目标
+func SetTransport(tr *http.Transport, p *Proxy) {
+	if p == nil {
+		return
+	}
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		logging.RuntimeLog.Errorf("invalid proxy %s:%v", p.Redacted(), err)
+		err = fmt.Errorf("invalid proxy %s", p.Redacted())
+		tr.Proxy = func(*http.Request) (*url.URL, error) {
+			return nil, err
+		}
+		tr.DialContext = failedDialer{err: err}.DialContext
+		return
+	}
+	tr.Proxy = http.ProxyURL(u)
+}
+
+// NewHTTPClient 创建通过代理访问的http客户端，p为nil时直接访问
+func NewHTTPClient(p *Proxy, timeout time.Duration) *http.Client {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	SetTransport(tr, p)
+	return &http.Client{Transport: tr, Timeout: timeout}
+}
+
+// Dialer 获取通过代理建立TCP连接的dialer，用于whois等非http协议及协议检测；p为nil时直接连接
+func Dialer(p *Proxy) (proxy.Dialer, error) {
+	if p == nil {
+		return proxy.Direct, nil
+	}
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "socks5", "socks5h":
+		return proxy.FromURL(u, proxy.Direct)
+	case "http", "https":
+		return &httpConnectDialer{proxyURL: u}, nil
+	}
+	return nil, errors.New(fmt.Sprintf("unsupported proxy scheme:%s", u.Scheme))
+}
+
+// httpConnectDialer 通过http代理的CONNECT方法建立TCP连接
+type httpConnectDialer struct {
+	proxyURL *url.URL
+}
+
+// Dial 建立连接
+func (d *httpConnectDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialContext 建立连接，ctx的超时同时用于CONNECT的请求与响应
+func (d *httpConnectDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	proxyAddress := d.proxyURL.Host
+	if d.proxyURL.Port() == "" {
+		port := "80"
+		if strings.ToLower(d.proxyURL.Scheme) == "https" {
+			port = "443"
+		}
+		proxyAddress = net.JoinHostPort(d.proxyURL.Hostname(), port)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddress)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(d.proxyURL.Scheme) == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: d.proxyURL.Hostname(), InsecureSkipVerify: true})
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if d.proxyURL.User != nil {
+		password, _ := d.proxyURL.User.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(d.proxyURL.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err = req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// CONNECT成功的响应没有正文，不能调用Body.Close（会一直读取到连接关闭）
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, errors.New(fmt.Sprintf("proxy connect %s:%s", address, resp.Status))
+	}
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: br}, nil
+	}
+	return conn, nil
+}
+
+// failedDialer 代理无效时使用，所有连接都返回错误
+type failedDialer struct {
+	err error
+}
+
+// Dial 建立连接
+func (d failedDialer) Dial(network, address string) (net.Conn, error) {
+	return nil, d.err
+}
+
+// DialContext 建立连接
+func (d failedDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return nil, d.err
+}
+
+// bufferedConn 代理响应后已读取到缓冲区中的数据需先返回
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// GetProtocol 通过代理检测URL协议，p为nil时直接连接；代理无效时不连接目标，返回缺省的协议
+func GetProtocol(host string, timeout int64, p *Proxy) string {
+	if p == nil {
+		return utils.GetProtocol(host, timeout)
+	}
+	dialer, err := Dialer(p)
+	if err != nil {
+		logging.RuntimeLog.Errorf("invalid proxy %s:%v", p.Redacted(), err)
+		dialer = failedDialer{err: err}
+	}
+	return utils.GetProtocolByDialer(host, timeout, dialer)
+}
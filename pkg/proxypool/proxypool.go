@@ -0,0 +1,245 @@
+package proxypool
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 使用代理池的任务类型，在配置文件的proxy.tasks中指定各类任务使用的代理分组
+const (
+	TaskHttpx      = "httpx"
+	TaskScreenshot = "screenshot"
+	TaskIconHash   = "iconhash"
+	TaskCrawler    = "crawler"
+	TaskDirsearch  = "dirsearch"
+	TaskXrayPocV1  = "xraypocv1"
+	TaskNuclei     = "nuclei"
+	TaskOnlineAPI  = "onlineapi"
+)
+
+const (
+	// RotationRoundRobin 同一分组内的代理依次轮换
+	RotationRoundRobin = "roundrobin"
+	// RotationRandom 同一分组内随机选择代理
+	RotationRandom = "random"
+	// DefaultGroup 未指定分组的代理所属的分组
+	DefaultGroup = "default"
+	// DefaultHealthCheckIntervalSecond 健康检查的间隔
+	DefaultHealthCheckIntervalSecond = 300
+	// DefaultHealthCheckURL 健康检查访问的地址，返回内容为出口IP
+	DefaultHealthCheckURL = "https://api.ipify.org"
+	// EgressTag 结果中记录出口IP的属性名称
+	EgressTag = "egress"
+
+	healthCheckTimeout = 10 * time.Second
+)
+
+var (
+	pool = &Pool{next: make(map[string]int)}
+	// proxyConfig 获取代理池的配置，worker的配置可能被server同步更新，每次使用时读取；没有worker配置时不启用代理池；可在测试中替换
+	proxyConfig = func() conf.Proxy {
+		config, err := conf.LoadGlobalWorkerConfig()
+		if err != nil {
+			return conf.Proxy{}
+		}
+		return config.Proxy
+	}
+)
+
+// Proxy 任务使用的代理，Egress为代理的出口IP（健康检查前为代理的地址）
+type Proxy struct {
+	URL    string
+	Group  string
+	Egress string
+}
+
+// proxyInstance 代理池中的一个代理及其健康状态
+type proxyInstance struct {
+	Proxy
+	healthy       bool
+	lastCheckTime time.Time
+	lastError     string
+}
+
+// Pool worker的代理池：按任务类型选择分组、分组内轮换及健康检查
+type Pool struct {
+	sync.Mutex
+	instances []*proxyInstance
+	// next 各分组轮换的位置
+	next map[string]int
+}
+
+// GetPool 获取worker全局的代理池
+func GetPool() *Pool {
+	return pool
+}
+
+// Get 为任务选择一个代理，返回nil表示直接访问
+func Get(taskType string) *Proxy {
+	return pool.Get(taskType)
+}
+
+// Redacted 代理地址，隐藏认证的密码，用于日志
+func (p *Proxy) Redacted() string {
+	if p == nil {
+		return ""
+	}
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return p.URL
+	}
+	return u.Redacted()
+}
+
+// Get 为任务选择一个代理：未启用代理池或任务未指定分组时返回nil；
+// 优先选择健康（或尚未检查）的代理，分组内全部代理不可用时仍在全部代理中轮换，避免任务改为直接访问
+func (p *Pool) Get(taskType string) *Proxy {
+	config := proxyConfig()
+	if !config.Enabled {
+		return nil
+	}
+	group := config.Tasks[taskType]
+	if group == "" {
+		return nil
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	p.syncConfig()
+	var all, candidates []*proxyInstance
+	for _, inst := range p.instances {
+		if inst.Group != group {
+			continue
+		}
+		all = append(all, inst)
+		if inst.healthy || inst.lastCheckTime.IsZero() {
+			candidates = append(candidates, inst)
+		}
+	}
+	if len(all) == 0 {
+		logging.RuntimeLog.Warningf("no proxy in group %s for %s,use direct connection", group, taskType)
+		return nil
+	}
+	if len(candidates) == 0 {
+		candidates = all
+	}
+	var inst *proxyInstance
+	if config.Rotation == RotationRandom {
+		inst = candidates[rand.Intn(len(candidates))]
+	} else {
+		inst = candidates[p.next[group]%len(candidates)]
+		p.next[group]++
+	}
+	proxy := inst.Proxy
+	return &proxy
+}
+
+// syncConfig 根据配置文件同步代理列表，保留已有代理的健康状态，调用者需持有锁
+func (p *Pool) syncConfig() {
+	var instances []*proxyInstance
+	for _, server := range proxyConfig().Pool {
+		proxyURL := strings.TrimSpace(server.URL)
+		u, err := url.Parse(proxyURL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		group := strings.TrimSpace(server.Group)
+		if group == "" {
+			group = DefaultGroup
+		}
+		var inst *proxyInstance
+		for _, v := range p.instances {
+			if v.URL == proxyURL {
+				inst = v
+				break
+			}
+		}
+		if inst == nil {
+			inst = &proxyInstance{Proxy: Proxy{URL: proxyURL, Egress: u.Hostname()}}
+		}
+		inst.Group = group
+		instances = append(instances, inst)
+	}
+	p.instances = instances
+}
+
+// healthCheckInterval 健康检查的间隔
+func (p *Pool) healthCheckInterval() time.Duration {
+	interval := proxyConfig().HealthCheckInterval
+	if interval <= 0 {
+		interval = DefaultHealthCheckIntervalSecond
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// CheckHealth 对超过检查间隔的代理执行一次健康检查，并记录代理的出口IP
+func (p *Pool) CheckHealth(force bool) {
+	config := proxyConfig()
+	if !config.Enabled {
+		return
+	}
+	checkURL := config.HealthCheckURL
+	if checkURL == "" {
+		checkURL = DefaultHealthCheckURL
+	}
+	p.Lock()
+	p.syncConfig()
+	interval := p.healthCheckInterval()
+	var checkList []*proxyInstance
+	for _, inst := range p.instances {
+		if force || time.Now().Sub(inst.lastCheckTime) >= interval {
+			checkList = append(checkList, inst)
+		}
+	}
+	p.Unlock()
+
+	for _, inst := range checkList {
+		p.Lock()
+		proxy := inst.Proxy
+		p.Unlock()
+		egress, err := checkProxy(&proxy, checkURL)
+		p.Lock()
+		inst.lastCheckTime = time.Now()
+		inst.healthy = err == nil
+		if err != nil {
+			inst.lastError = err.Error()
+			logging.RuntimeLog.Warningf("proxy %s is unavailable:%v", proxy.Redacted(), err)
+		} else {
+			inst.lastError = ""
+			if egress != "" {
+				inst.Egress = egress
+			}
+		}
+		p.Unlock()
+	}
+}
+
+// checkProxy 通过代理访问健康检查地址，返回内容为IP时作为代理的出口IP
+func checkProxy(proxy *Proxy, checkURL string) (egress string, err error) {
+	resp, err := NewHTTPClient(proxy, healthCheckTimeout).Get(checkURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New(fmt.Sprintf("health check status code:%d", resp.StatusCode))
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", err
+	}
+	if ip := net.ParseIP(strings.TrimSpace(string(body))); ip != nil {
+		egress = ip.String()
+	}
+	return
+}
@@ -0,0 +1,158 @@
+package proxypool
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newFakeProxy 模拟http代理：普通请求返回出口IP，CONNECT请求转发到目标地址
+func newFakeProxy(egress string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.Write([]byte(egress))
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			target.Close()
+			return
+		}
+		go func() {
+			io.Copy(target, conn)
+			target.Close()
+		}()
+		io.Copy(conn, target)
+		conn.Close()
+	}))
+}
+
+func setProxyConfig(config *conf.Proxy) {
+	proxyConfig = func() conf.Proxy {
+		return *config
+	}
+	pool = &Pool{next: make(map[string]int)}
+}
+
+func TestPool_Get(t *testing.T) {
+	config := &conf.Proxy{
+		Enabled: true,
+		Pool: []conf.ProxyServer{
+			{URL: "http://10.0.0.1:8080"},
+			{URL: "socks5://10.0.0.2:1080"},
+			{URL: "http://10.0.0.3:8080", Group: "api"},
+		},
+		Tasks: map[string]string{TaskHttpx: DefaultGroup, TaskOnlineAPI: "api"},
+	}
+	setProxyConfig(config)
+	if p := Get(TaskNuclei); p != nil {
+		t.Errorf("task without group should use direct connection:%v", p.URL)
+	}
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, Get(TaskHttpx).URL)
+	}
+	if got[0] == got[1] || got[0] != got[2] || got[1] != got[3] {
+		t.Errorf("round robin:%v", got)
+	}
+	if p := Get(TaskOnlineAPI); p == nil || p.URL != "http://10.0.0.3:8080" || p.Egress != "10.0.0.3" {
+		t.Errorf("api group proxy:%+v", p)
+	}
+	// 不健康的代理不再分配
+	pool.instances[0].healthy = false
+	pool.instances[0].lastCheckTime = pool.instances[0].lastCheckTime.AddDate(1, 0, 0)
+	for i := 0; i < 3; i++ {
+		if p := Get(TaskHttpx); p.URL != "socks5://10.0.0.2:1080" {
+			t.Errorf("unhealthy proxy should be skipped:%s", p.URL)
+		}
+	}
+	config.Enabled = false
+	if p := Get(TaskHttpx); p != nil {
+		t.Errorf("disabled pool should use direct connection:%v", p.URL)
+	}
+}
+
+func TestPool_CheckHealth(t *testing.T) {
+	fakeProxy := newFakeProxy("1.2.3.4")
+	defer fakeProxy.Close()
+	config := &conf.Proxy{
+		Enabled:        true,
+		HealthCheckURL: "http://egress.nemo.test/ip",
+		Pool: []conf.ProxyServer{
+			{URL: fakeProxy.URL},
+			{URL: "http://127.0.0.1:1"},
+		},
+		Tasks: map[string]string{TaskDirsearch: DefaultGroup},
+	}
+	setProxyConfig(config)
+	pool.CheckHealth(true)
+	for i := 0; i < 3; i++ {
+		p := Get(TaskDirsearch)
+		if p.URL != fakeProxy.URL || p.Egress != "1.2.3.4" {
+			t.Errorf("healthy proxy and egress:%+v", p)
+		}
+	}
+}
+
+func TestDialer(t *testing.T) {
+	fakeProxy := newFakeProxy("")
+	defer fakeProxy.Close()
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer target.Close()
+
+	dialer, err := Dialer(&Proxy{URL: fakeProxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &http.Transport{Dial: dialer.Dial}}
+	defer client.CloseIdleConnections()
+	resp, err := client.Get(target.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "ok" {
+		t.Errorf("response through connect proxy:%s", body)
+	}
+	if _, err = Dialer(&Proxy{URL: "ftp://127.0.0.1:21"}); err == nil {
+		t.Error("unsupported proxy scheme should fail")
+	}
+	// 代理无效时不能直接连接目标
+	for _, u := range []string{"http://[::1", "ftp://127.0.0.1:21"} {
+		if resp, err = NewHTTPClient(&Proxy{URL: u}, time.Second).Get(target.URL); err == nil {
+			resp.Body.Close()
+			t.Errorf("invalid proxy %s should not connect directly", u)
+		}
+	}
+}
+
+// defaultProxyConfig 读取worker配置的proxyConfig，其它测试会替换proxyConfig
+var defaultProxyConfig = proxyConfig
+
+func TestPool_GetWithoutWorkerConfig(t *testing.T) {
+	configFile := conf.WorkerDefaultConfigFile
+	conf.WorkerDefaultConfigFile = "conf/not_exist_worker.yml"
+	defer func() {
+		conf.WorkerDefaultConfigFile = configFile
+		setProxyConfig(&conf.Proxy{})
+	}()
+	proxyConfig = defaultProxyConfig
+	pool = &Pool{next: make(map[string]int)}
+	// 没有worker配置时不启用代理池，不退出
+	if p := Get(TaskDirsearch); p != nil {
+		t.Errorf("proxy without worker config:%v", p.URL)
+	}
+}
@@ -0,0 +1,211 @@
+package reconcile
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/task/onlineapi"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultHalfLife 置信度衰减的默认半衰期（天）
+	defaultHalfLife = 30
+	// defaultSource 未设置置信度的来源使用的key
+	defaultSource = "default"
+)
+
+// Fields 需要合并的端口属性，每个属性只有一个真实值；fingerprint、tag等同时存在多个值的属性不合并
+var Fields = []string{"title", "server", "banner", "service"}
+
+// defaultConfidence 各来源的默认置信度，server.yml中只需配置需要调整的来源：主动探测的指纹高于在线资产平台，masscan的service由端口号推测
+var defaultConfidence = map[string]float64{
+	defaultSource: 0.5,
+	"httpx":       0.95,
+	"httpxfinger": 0.9,
+	"nmap":        0.9,
+	"portscan":    0.8,
+	"gogo":        0.8,
+	"fscan":       0.8,
+	"goby":        0.8,
+	"masscan":     0.4,
+	"fofa":        0.6,
+	"hunter":      0.6,
+	"quake":       0.6,
+	"shodan":      0.6,
+	"censys":      0.6,
+	"zoomeye":     0.5,
+	"netlas":      0.5,
+	"binaryedge":  0.5,
+	"0zone":       0.5,
+}
+
+// Record 一个来源的端口属性
+type Record struct {
+	Source     string
+	Tag        string
+	Content    string
+	UpdateTime time.Time
+}
+
+// SourceValue 一个来源提供的属性值，Freshness为按更新时间衰减的系数，Score为来源置信度与Freshness的乘积
+type SourceValue struct {
+	Source     string    `json:"source"`
+	Content    string    `json:"content"`
+	UpdateTime time.Time `json:"update_time"`
+	Freshness  float64   `json:"freshness"`
+	Score      float64   `json:"score"`
+}
+
+// Field 合并后的属性：Value为得分最高的值，Confidence为Value的可信程度（0-1），Sources为全部来源的原始值（按得分排序）
+type Field struct {
+	Tag        string        `json:"tag"`
+	Value      string        `json:"value"`
+	Confidence float64       `json:"confidence"`
+	Sources    []SourceValue `json:"sources"`
+}
+
+// Port 一个端口合并后的属性，Verified为是否经过端口扫描、指纹获取等主动探测；只由在线资产平台获取的端口为false
+type Port struct {
+	Fields   map[string]*Field `json:"fields"`
+	Sources  []string          `json:"sources"`
+	Verified bool              `json:"verified"`
+}
+
+// Reconciler 按来源的置信度及更新时间合并端口属性
+type Reconciler struct {
+	halfLife   float64
+	confidence map[string]float64
+	now        time.Time
+}
+
+// NewReconciler 创建Reconciler，config中未设置的项使用默认值
+func NewReconciler(config conf.Reconcile) *Reconciler {
+	r := &Reconciler{
+		halfLife:   defaultHalfLife,
+		confidence: make(map[string]float64),
+		now:        time.Now(),
+	}
+	if config.HalfLife > 0 {
+		r.halfLife = float64(config.HalfLife)
+	}
+	for source, c := range defaultConfidence {
+		r.confidence[source] = c
+	}
+	for source, c := range config.Confidence {
+		r.confidence[strings.ToLower(source)] = math.Max(0, math.Min(1, c))
+	}
+	return r
+}
+
+// Confidence 来源的置信度
+func (r *Reconciler) Confidence(source string) float64 {
+	if c, ok := r.confidence[strings.ToLower(source)]; ok {
+		return c
+	}
+	return r.confidence[defaultSource]
+}
+
+// Freshness 更新时间的衰减系数，每经过一个半衰期减半
+func (r *Reconciler) Freshness(updateTime time.Time) float64 {
+	days := r.now.Sub(updateTime).Hours() / 24
+	if days <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, days/r.halfLife)
+}
+
+// Reconcile 合并一个端口的全部属性
+func (r *Reconciler) Reconcile(records []Record) (p Port) {
+	p.Fields = make(map[string]*Field)
+	sourceSet := make(map[string]struct{})
+	fieldRecords := make(map[string][]Record)
+	for _, record := range records {
+		if record.Source == "" {
+			continue
+		}
+		if _, ok := sourceSet[record.Source]; !ok {
+			sourceSet[record.Source] = struct{}{}
+			p.Sources = append(p.Sources, record.Source)
+		}
+		if !onlineapi.IsSource(record.Source) {
+			p.Verified = true
+		}
+		if isField(record.Tag) && strings.TrimSpace(record.Content) != "" {
+			fieldRecords[record.Tag] = append(fieldRecords[record.Tag], record)
+		}
+	}
+	sort.Strings(p.Sources)
+	for tag, fr := range fieldRecords {
+		p.Fields[tag] = r.reconcileField(tag, fr)
+	}
+	return
+}
+
+// reconcileField 合并一个属性：相同的值（忽略大小写及空白）累加各来源的得分，取得分最高的值
+// Confidence为各来源对该值的联合置信度（1-∏(1-Score)）与该值得分占全部得分比例的乘积
+func (r *Reconciler) reconcileField(tag string, records []Record) *Field {
+	type valueGroup struct {
+		best      SourceValue
+		score     float64
+		disbelief float64
+	}
+	field := &Field{Tag: tag}
+	groups := make(map[string]*valueGroup)
+	var keys []string
+	var total float64
+	for _, record := range records {
+		sv := SourceValue{
+			Source:     record.Source,
+			Content:    record.Content,
+			UpdateTime: record.UpdateTime,
+			Freshness:  r.Freshness(record.UpdateTime),
+		}
+		sv.Score = r.Confidence(record.Source) * sv.Freshness
+		field.Sources = append(field.Sources, sv)
+		total += sv.Score
+
+		key := normalize(record.Content)
+		g, ok := groups[key]
+		if !ok {
+			g = &valueGroup{best: sv, disbelief: 1}
+			groups[key] = g
+			keys = append(keys, key)
+		} else if sv.Score > g.best.Score {
+			g.best = sv
+		}
+		g.score += sv.Score
+		g.disbelief *= 1 - sv.Score
+	}
+	var winner *valueGroup
+	for _, key := range keys {
+		g := groups[key]
+		if winner == nil || g.score > winner.score || (g.score == winner.score && g.best.UpdateTime.After(winner.best.UpdateTime)) {
+			winner = g
+		}
+	}
+	field.Value = winner.best.Content
+	if total > 0 {
+		field.Confidence = math.Round((1-winner.disbelief)*winner.score/total*100) / 100
+	}
+	sort.SliceStable(field.Sources, func(i, j int) bool {
+		return field.Sources[i].Score > field.Sources[j].Score
+	})
+	return field
+}
+
+// isField 是否为需要合并的属性
+func isField(tag string) bool {
+	for _, f := range Fields {
+		if f == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize 比较属性值时忽略大小写及多余的空白
+func normalize(content string) string {
+	return strings.ToLower(strings.Join(strings.Fields(content), " "))
+}
@@ -0,0 +1,71 @@
+package reconcile
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/task/onlineapi"
+	"testing"
+)
+
+func TestReconciler_Reconcile(t *testing.T) {
+	r := NewReconciler(conf.Reconcile{HalfLife: 30})
+	now := r.now
+	records := []Record{
+		{Source: "fofa", Tag: "title", Content: "Welcome to nginx", UpdateTime: now.AddDate(0, 0, -60)},
+		{Source: "hunter", Tag: "title", Content: "welcome  to nginx", UpdateTime: now.AddDate(0, 0, -1)},
+		{Source: "httpx", Tag: "title", Content: "Login", UpdateTime: now},
+		{Source: "quake", Tag: "server", Content: "nginx", UpdateTime: now},
+		{Source: "nmap", Tag: "banner", Content: "OpenResty 1.21", UpdateTime: now},
+		{Source: "fofa", Tag: "banner", Content: "nginx", UpdateTime: now},
+		{Source: "fofa", Tag: "tag", Content: "cdn", UpdateTime: now},
+	}
+	p := r.Reconcile(records)
+	if !p.Verified || len(p.Sources) != 5 {
+		t.Errorf("verified:%v sources:%v", p.Verified, p.Sources)
+	}
+	if _, ok := p.Fields["tag"]; ok {
+		t.Error("tag should not be reconciled")
+	}
+	// httpx的得分0.95高于fofa（衰减两个半衰期）与hunter之和
+	title := p.Fields["title"]
+	if title.Value != "Login" || len(title.Sources) != 3 || title.Sources[0].Source != "httpx" {
+		t.Errorf("title:%+v", title)
+	}
+	if title.Confidence <= 0 || title.Confidence >= 0.95 {
+		t.Errorf("title confidence:%v", title.Confidence)
+	}
+	if banner := p.Fields["banner"]; banner.Value != "OpenResty 1.21" {
+		t.Errorf("banner:%+v", banner)
+	}
+	if server := p.Fields["server"]; server.Value != "nginx" || server.Confidence != 0.6 {
+		t.Errorf("server:%+v", server)
+	}
+}
+
+func TestReconciler_EngineOnly(t *testing.T) {
+	r := NewReconciler(conf.Reconcile{Confidence: map[string]float64{"fofa": 0.9, "default": 0.2}})
+	now := r.now
+	p := r.Reconcile([]Record{
+		{Source: "fofa", Tag: "title", Content: "A", UpdateTime: now},
+		{Source: "hunter", Tag: "title", Content: "A", UpdateTime: now},
+		{Source: "netlas", Tag: "title", Content: "B", UpdateTime: now},
+	})
+	if p.Verified {
+		t.Error("engine only port should not be verified")
+	}
+	// 同一值的多个来源提高置信度：1-(1-0.9)*(1-0.6)=0.96，占全部得分的1.5/2.0
+	title := p.Fields["title"]
+	if title.Value != "A" || title.Confidence != 0.72 {
+		t.Errorf("title:%+v", title)
+	}
+	if r.Confidence("unknown") != 0.2 || r.Confidence("hunter") != 0.6 {
+		t.Errorf("confidence:%v %v", r.Confidence("unknown"), r.Confidence("hunter"))
+	}
+}
+
+func TestDefaultConfidence(t *testing.T) {
+	for _, source := range append(onlineapi.SearchEngines, "0zone") {
+		if _, ok := defaultConfidence[source]; !ok {
+			t.Errorf("no default confidence for %s", source)
+		}
+	}
+}
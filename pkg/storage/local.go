@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage 本地文件存储
+type LocalStorage struct {
+	Root string
+}
+
+// NewLocalStorage 创建本地存储对象
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{Root: root}
+}
+
+// Put 保存文件，自动创建目录
+func (l *LocalStorage) Put(key string, content []byte) error {
+	fileName, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(fileName), 0777); err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, content, 0666)
+}
+
+// Get 读取文件
+func (l *LocalStorage) Get(key string) ([]byte, error) {
+	fileName, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(fileName)
+}
+
+// Exists 文件是否存在
+func (l *LocalStorage) Exists(key string) bool {
+	fileName, err := l.path(key)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(fileName)
+	return err == nil && !info.IsDir()
+}
+
+// List 列出目录下的所有文件（包括子目录）
+func (l *LocalStorage) List(prefix string) (keys []string, err error) {
+	dir, err := l.path(prefix)
+	if err != nil {
+		return nil, err
+	}
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(l.Root, p)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, filepath.ToSlash(rel))
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return
+}
+
+// Delete 删除文件
+func (l *LocalStorage) Delete(key string) error {
+	fileName, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(fileName); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// DeletePrefix 删除整个目录
+func (l *LocalStorage) DeletePrefix(prefix string) error {
+	dir, err := l.path(prefix)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// path 将key转换为本地文件路径
+func (l *LocalStorage) path(key string) (string, error) {
+	key = strings.TrimSuffix(key, "/")
+	if err := CheckKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(l.Root, filepath.FromSlash(key)), nil
+}
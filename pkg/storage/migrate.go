@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"os"
+)
+
+// MigrateDirs 需要迁移到存储的webfiles子目录，任务结果等文件仍保存在本地
+var MigrateDirs = []string{"screenshot", "iconimage"}
+
+// Migrate 将本地webfiles目录下已有的截图及icon文件复制到目标存储
+func Migrate(localRoot string, dst Storage) (count int, err error) {
+	src := NewLocalStorage(localRoot)
+	entries, err := os.ReadDir(localRoot)
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		for _, dir := range MigrateDirs {
+			keys, err := src.List(Key(entry.Name(), dir))
+			if err != nil {
+				return count, err
+			}
+			for _, key := range keys {
+				content, err := src.Get(key)
+				if err != nil {
+					return count, err
+				}
+				if err = dst.Put(key, content); err != nil {
+					return count, err
+				}
+				count++
+				if count%1000 == 0 {
+					logging.CLILog.Infof("migrate storage files:%d", count)
+				}
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultS3Region = "us-east-1"
+	// s3DeleteBatchSize DeleteObjects每次最多删除的文件数
+	s3DeleteBatchSize = 1000
+)
+
+// S3Storage S3兼容的对象存储（MinIO等），使用path-style访问
+type S3Storage struct {
+	Bucket string
+	// Prefix 所有文件key的公共前缀
+	Prefix string
+
+	client *s3.S3
+}
+
+// NewS3Storage 创建S3存储对象
+func NewS3Storage(config conf.Storage) (*S3Storage, error) {
+	c := config.S3
+	if c.Endpoint == "" || c.Bucket == "" {
+		return nil, errors.New("s3 endpoint and bucket required")
+	}
+	region := c.Region
+	if region == "" {
+		region = defaultS3Region
+	}
+	endpoint := c.Endpoint
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		if c.UseSSL {
+			endpoint = "https://" + endpoint
+		} else {
+			endpoint = "http://" + endpoint
+		}
+	}
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:         aws.String(endpoint),
+		Region:           aws.String(region),
+		Credentials:      credentials.NewStaticCredentials(c.AccessKey, c.SecretKey, ""),
+		S3ForcePathStyle: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &S3Storage{
+		Bucket: c.Bucket,
+		Prefix: strings.Trim(c.Prefix, "/"),
+		client: s3.New(sess),
+	}, nil
+}
+
+// Put 上传文件
+func (s *S3Storage) Put(key string, content []byte) error {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(objectKey),
+		Body:        bytes.NewReader(content),
+		ContentType: aws.String(ContentType(key)),
+	})
+	return err
+}
+
+// Get 下载文件
+func (s *S3Storage) Get(key string) ([]byte, error) {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return nil, err
+	}
+	output, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(objectKey),
+	})
+	if err != nil {
+		return nil, s.convertError(key, err)
+	}
+	defer output.Body.Close()
+	return io.ReadAll(output.Body)
+}
+
+// Exists 文件是否存在
+func (s *S3Storage) Exists(key string) bool {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return false
+	}
+	_, err = s.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(objectKey),
+	})
+	return err == nil
+}
+
+// List 列出前缀下的所有文件
+func (s *S3Storage) List(prefix string) (keys []string, err error) {
+	objectPrefix, err := s.objectKey(strings.TrimSuffix(prefix, "/"))
+	if err != nil {
+		return nil, err
+	}
+	err = s.client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(objectPrefix + "/"),
+	}, func(output *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range output.Contents {
+			keys = append(keys, s.trimPrefix(aws.StringValue(object.Key)))
+		}
+		return true
+	})
+	return
+}
+
+// Delete 删除文件
+func (s *S3Storage) Delete(key string) error {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(objectKey),
+	})
+	return err
+}
+
+// DeletePrefix 删除前缀下的所有文件
+func (s *S3Storage) DeletePrefix(prefix string) error {
+	keys, err := s.List(prefix)
+	if err != nil {
+		return err
+	}
+	for start := 0; start < len(keys); start += s3DeleteBatchSize {
+		end := start + s3DeleteBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		var objects []*s3.ObjectIdentifier
+		for _, key := range keys[start:end] {
+			objectKey, _ := s.objectKey(key)
+			objects = append(objects, &s3.ObjectIdentifier{Key: aws.String(objectKey)})
+		}
+		output, err := s.client.DeleteObjects(&s3.DeleteObjectsInput{
+			Bucket: aws.String(s.Bucket),
+			Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return err
+		}
+		if len(output.Errors) > 0 {
+			return fmt.Errorf("delete %s fail:%s", aws.StringValue(output.Errors[0].Key), aws.StringValue(output.Errors[0].Message))
+		}
+	}
+	return nil
+}
+
+// objectKey 检查key并加上公共前缀
+func (s *S3Storage) objectKey(key string) (string, error) {
+	if err := CheckKey(key); err != nil {
+		return "", err
+	}
+	if s.Prefix == "" {
+		return key, nil
+	}
+	return s.Prefix + "/" + key, nil
+}
+
+// trimPrefix 去掉对象key的公共前缀
+func (s *S3Storage) trimPrefix(objectKey string) string {
+	if s.Prefix == "" {
+		return objectKey
+	}
+	return strings.TrimPrefix(objectKey, s.Prefix+"/")
+}
+
+// convertError 将对象不存在的错误转换为fs.ErrNotExist
+func (s *S3Storage) convertError(key string, err error) error {
+	var requestFailure awserr.RequestFailure
+	if errors.As(err, &requestFailure) && requestFailure.StatusCode() == http.StatusNotFound {
+		return &fs.PathError{Op: "get", Path: key, Err: fs.ErrNotExist}
+	}
+	return err
+}
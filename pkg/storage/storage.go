@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"io/fs"
+	"mime"
+	"path"
+	"strings"
+	"sync"
+)
+
+const (
+	LocalStorageType = "local"
+	S3StorageType    = "s3"
+)
+
+var (
+	defaultStorage     Storage
+	defaultStorageOnce sync.Once
+)
+
+// Storage 截图、icon等文件的存储，key为相对webfiles的路径，如<workspaceGUID>/screenshot/<domain>/80_http.png
+type Storage interface {
+	// Put 保存文件，已存在的文件将被覆盖
+	Put(key string, content []byte) error
+	// Get 读取文件，文件不存在时返回fs.ErrNotExist
+	Get(key string) ([]byte, error)
+	// Exists 文件是否存在
+	Exists(key string) bool
+	// List 列出指定前缀（目录）下的所有文件
+	List(prefix string) ([]string, error)
+	// Delete 删除文件
+	Delete(key string) error
+	// DeletePrefix 删除指定前缀（目录）下的所有文件
+	DeletePrefix(prefix string) error
+}
+
+// New 根据配置创建存储对象，localRoot为本地存储的根目录
+func New(config conf.Storage, localRoot string) (Storage, error) {
+	switch config.Type {
+	case "", LocalStorageType:
+		return NewLocalStorage(localRoot), nil
+	case S3StorageType:
+		return NewS3Storage(config)
+	default:
+		return nil, fmt.Errorf("unsupported storage type:%s", config.Type)
+	}
+}
+
+// GetStorage 获取server配置的存储对象，配置错误时使用本地存储
+func GetStorage() Storage {
+	defaultStorageOnce.Do(func() {
+		var err error
+		defaultStorage, err = New(conf.GlobalServerConfig().Storage, conf.GlobalServerConfig().Web.WebFiles)
+		if err != nil {
+			logging.RuntimeLog.Errorf("create storage fail:%v,use local storage", err)
+			logging.CLILog.Errorf("create storage fail:%v,use local storage", err)
+			defaultStorage = NewLocalStorage(conf.GlobalServerConfig().Web.WebFiles)
+		}
+	})
+	return defaultStorage
+}
+
+// IsLocal 是否为本地存储，本地存储的文件由web静态目录直接访问
+func IsLocal() bool {
+	_, ok := GetStorage().(*LocalStorage)
+	return ok
+}
+
+// Key 由各级路径生成存储的key
+func Key(elem ...string) string {
+	return path.Join(elem...)
+}
+
+// CheckKey 检查key的合法性，防止路径穿越
+func CheckKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid key:%s", key)
+	}
+	for _, p := range strings.Split(key, "/") {
+		if p == "" || p == "." || p == ".." {
+			return fmt.Errorf("invalid key:%s", key)
+		}
+	}
+	return nil
+}
+
+// ContentType 根据文件后缀得到Content-Type
+func ContentType(key string) string {
+	if t := mime.TypeByExtension(path.Ext(key)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// IsNotExist 是否为文件不存在的错误
+func IsNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
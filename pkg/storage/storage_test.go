@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 简单模拟S3的PutObject、GetObject、HeadObject、ListObjectsV2、DeleteObject及DeleteObjects
+type fakeS3 struct {
+	sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	// path-style: /bucket/key
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	key := ""
+	if len(parts) == 2 {
+		key = parts[1]
+	}
+	switch {
+	case r.Method == http.MethodPut:
+		content, _ := io.ReadAll(r.Body)
+		f.objects[key] = content
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		prefix := r.URL.Query().Get("prefix")
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><ListBucketResult><IsTruncated>false</IsTruncated>`)
+		for _, k := range keys {
+			fmt.Fprint(w, "<Contents><Key>")
+			xml.EscapeText(w, []byte(k))
+			fmt.Fprint(w, "</Key></Contents>")
+		}
+		fmt.Fprint(w, "</ListBucketResult>")
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		content, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+			}
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
+		if r.Method == http.MethodGet {
+			w.Write(content)
+		}
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && r.URL.Query().Has("delete"):
+		var req struct {
+			Objects []struct {
+				Key string `xml:"Key"`
+			} `xml:"Object"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		xml.Unmarshal(body, &req)
+		for _, o := range req.Objects {
+			delete(f.objects, o.Key)
+		}
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><DeleteResult></DeleteResult>`)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// testStorage 对存储进行通用的读写测试
+func testStorage(t *testing.T, s Storage) {
+	files := map[string]string{
+		"guid1/screenshot/127.0.0.1/80_http.png":           "png",
+		"guid1/screenshot/127.0.0.1/80_http_thumbnail.png": "thumbnail",
+		"guid1/iconimage/abc.ico":                          "ico",
+		"guid2/screenshot/127.0.0.1/80_http.png":           "png2",
+	}
+	for k, v := range files {
+		if err := s.Put(k, []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	content, err := s.Get("guid1/iconimage/abc.ico")
+	if err != nil || string(content) != "ico" {
+		t.Errorf("unexpected content:%s %v", content, err)
+	}
+	if _, err = s.Get("guid1/iconimage/none.ico"); !IsNotExist(err) {
+		t.Errorf("expected not exist error:%v", err)
+	}
+	if !s.Exists("guid1/iconimage/abc.ico") || s.Exists("guid1/iconimage/none.ico") || s.Exists("guid1/iconimage") {
+		t.Errorf("unexpected exists result")
+	}
+	keys, err := s.List("guid1/screenshot/127.0.0.1")
+	sort.Strings(keys)
+	if err != nil || strings.Join(keys, ",") != "guid1/screenshot/127.0.0.1/80_http.png,guid1/screenshot/127.0.0.1/80_http_thumbnail.png" {
+		t.Errorf("unexpected keys:%v %v", keys, err)
+	}
+	// 前缀按目录匹配
+	if keys, err = s.List("guid1/screen"); err != nil || len(keys) != 0 {
+		t.Errorf("unexpected keys:%v %v", keys, err)
+	}
+	if keys, err = s.List("guid3"); err != nil || len(keys) != 0 {
+		t.Errorf("unexpected keys:%v %v", keys, err)
+	}
+	if err = s.Delete("guid1/iconimage/abc.ico"); err != nil || s.Exists("guid1/iconimage/abc.ico") {
+		t.Errorf("delete fail:%v", err)
+	}
+	if err = s.DeletePrefix("guid1"); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ = s.List("guid1"); len(keys) != 0 {
+		t.Errorf("delete prefix fail:%v", keys)
+	}
+	if !s.Exists("guid2/screenshot/127.0.0.1/80_http.png") {
+		t.Errorf("other prefix should not be deleted")
+	}
+	for _, key := range []string{"", "/etc/passwd", "../a.png", "guid1/../../a.png", "guid1\\a.png", "guid1//a.png"} {
+		if err = s.Put(key, []byte("x")); err == nil {
+			t.Errorf("invalid key should fail:%s", key)
+		}
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	testStorage(t, NewLocalStorage(t.TempDir()))
+}
+
+func TestS3Storage(t *testing.T) {
+	server := httptest.NewServer(&fakeS3{objects: make(map[string][]byte)})
+	defer server.Close()
+
+	config := conf.Storage{Type: S3StorageType}
+	config.S3.Endpoint = server.URL
+	config.S3.Bucket = "nemo"
+	config.S3.AccessKey = "minio"
+	config.S3.SecretKey = "minio123"
+	config.S3.Prefix = "/webfiles/"
+	s, err := New(config, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, s)
+}
+
+func TestMigrate(t *testing.T) {
+	root := t.TempDir()
+	src := NewLocalStorage(root)
+	src.Put("guid1/screenshot/127.0.0.1/80_http.png", []byte("png"))
+	src.Put("guid1/iconimage/abc.ico", []byte("ico"))
+	src.Put("guid1/taskresult/task.json", []byte("{}"))
+
+	dst := NewLocalStorage(t.TempDir())
+	count, err := Migrate(root, dst)
+	if err != nil || count != 2 {
+		t.Errorf("unexpected migrate result:%d %v", count, err)
+	}
+	if !dst.Exists("guid1/screenshot/127.0.0.1/80_http.png") || !dst.Exists("guid1/iconimage/abc.ico") || dst.Exists("guid1/taskresult/task.json") {
+		t.Errorf("unexpected migrated files")
+	}
+}
@@ -22,7 +22,7 @@ const (
 	FAILURE  string = tasks.StateFailure  //任务执行完成，结果为FAILURE
 	RECEIVED string = tasks.StateReceived //未使用
 	PENDING  string = tasks.StatePending  //未使用
-	RETRY    string = tasks.StateRetry    //未使用
+	RETRY    string = tasks.StateRetry    //worker不满足任务要求时拒绝执行，任务重新进入队列等待执行
 
 	TopicActive  = "active"
 	TopicFinger  = "finger"
@@ -40,15 +40,29 @@ type TaskResult struct {
 
 type WorkerStatus struct {
 	sync.Mutex             `json:"-"`
-	WorkerName             string    `json:"worker_name"`
-	WorkerTopics           string    `json:"worker_topic"`
-	CreateTime             time.Time `json:"create_time"`
-	UpdateTime             time.Time `json:"update_time"`
-	TaskExecutedNumber     int       `json:"task_number"`
-	TaskStartedNumber      int       `json:"started_number"`
-	ManualReloadFlag       bool      `json:"manual_reload_flag"`
-	ManualFileSyncFlag     bool      `json:"manual_file_sync_flag"`
-	WorkerDaemonUpdateTime time.Time `json:"worker_daemon_update_time"`
+	WorkerName             string               `json:"worker_name"`
+	WorkerTopics           string               `json:"worker_topic"`
+	CreateTime             time.Time            `json:"create_time"`
+	UpdateTime             time.Time            `json:"update_time"`
+	TaskExecutedNumber     int                  `json:"task_number"`
+	TaskStartedNumber      int                  `json:"started_number"`
+	ManualReloadFlag       bool                 `json:"manual_reload_flag"`
+	ManualFileSyncFlag     bool                 `json:"manual_file_sync_flag"`
+	WorkerDaemonUpdateTime time.Time            `json:"worker_daemon_update_time"`
+	GobyPool               []GobyInstanceStatus `json:"goby_pool,omitempty"`
+	Capability             WorkerCapability     `json:"capability"`
+	DedicatedTopic         string               `json:"dedicated_topic,omitempty"`
+}
+
+// GobyInstanceStatus worker中goby服务端实例的状态
+type GobyInstanceStatus struct {
+	API            string    `json:"api"`
+	Healthy        bool      `json:"healthy"`
+	Running        int       `json:"running"`
+	MaxConcurrency int       `json:"max_concurrency"`
+	TaskNumber     int       `json:"task_number"`
+	LastCheckTime  time.Time `json:"last_check_time"`
+	LastError      string    `json:"last_error,omitempty"`
 }
 
 type WorkerRunTaskMode int
@@ -70,14 +84,22 @@ var taskTopicDefineMap = map[string]string{
 	"subfinder":         TopicPassive,
 	"subdomainbrute":    TopicPassive,
 	"subdomaincrawler":  TopicActive,
+	"subdomainpassive":  TopicPassive,
 	"iplocation":        TopicPassive,
 	"fofa":              TopicPassive,
 	"quake":             TopicPassive,
 	"hunter":            TopicPassive,
+	"shodan":            TopicPassive,
+	"censys":            TopicPassive,
+	"zoomeye":           TopicPassive,
+	"netlas":            TopicPassive,
+	"binaryedge":        TopicPassive,
 	"xray":              TopicPocscan,
 	"dirsearch":         TopicPocscan,
 	"nuclei":            TopicPocscan,
 	"goby":              TopicPocscan,
+	"xraypocv1":         TopicPocscan,
+	"weakpass":          TopicPocscan,
 	"icpquery":          TopicPassive,
 	"whoisquery":        TopicPassive,
 	"fingerprint":       TopicFinger,
@@ -86,6 +108,11 @@ var taskTopicDefineMap = map[string]string{
 	"xfofa":             TopicPassive,
 	"xquake":            TopicPassive,
 	"xhunter":           TopicPassive,
+	"xshodan":           TopicPassive,
+	"xcensys":           TopicPassive,
+	"xzoomeye":          TopicPassive,
+	"xnetlas":           TopicPassive,
+	"xbinaryedge":       TopicPassive,
 	"xdomainscan":       TopicPassive,
 	"xsubfinder":        TopicPassive,
 	"xsubdomainbrute":   TopicPassive,
@@ -94,11 +121,15 @@ var taskTopicDefineMap = map[string]string{
 	"xxray":             TopicPocscan,
 	"xnuclei":           TopicPocscan,
 	"xgoby":             TopicPocscan,
+	"xxraypocv1":        TopicPocscan,
 	"xorgscan":          TopicActive,
 	//test:
 	"test": TopicCustom,
 }
 
+// amqpExchange 任务消息的exchange
+const amqpExchange = "nemo_mq_exchange"
+
 // taskServer 复用的全局AMQP连接
 var taskServerConn = make(map[string]*machinery.Server)
 
@@ -133,7 +164,7 @@ func startAMQPServer(username, password, host string, port int, topicName string
 		ResultBackend:   amqpConfig,
 		ResultsExpireIn: 300,
 		AMQP: &config.AMQPConfig{
-			Exchange:      "nemo_mq_exchange",
+			Exchange:      amqpExchange,
 			ExchangeType:  "topic",
 			BindingKey:    routingKey,
 			PrefetchCount: prefetchCount,
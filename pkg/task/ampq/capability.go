@@ -0,0 +1,205 @@
+package ampq
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"github.com/RichardKnop/machinery/v2/tasks"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// TopicWorker worker的专属队列，用于将任务分配到满足任务要求的指定worker
+	TopicWorker = "worker"
+
+	// HeaderTaskRequirement 任务消息头中任务要求的字段
+	HeaderTaskRequirement = "nemo-requirement"
+	// HeaderTaskTopic 任务消息头中任务原始队列的字段，worker拒绝执行任务时将任务退回到该队列
+	HeaderTaskTopic = "nemo-topic"
+	// HeaderTaskRejected 任务消息头中任务被worker拒绝执行的次数
+	HeaderTaskRejected = "nemo-rejected"
+)
+
+// 任务要求的外部工具
+const (
+	ToolNmap         = "nmap"
+	ToolMasscan      = "masscan"
+	ToolHttpx        = "httpx"
+	ToolSubfinder    = "subfinder"
+	ToolMassdns      = "massdns"
+	ToolXray         = "xray"
+	ToolNuclei       = "nuclei"
+	ToolObserverWard = "observer_ward"
+)
+
+// WorkerCapability worker的能力：已安装的工具、运行环境及自定义的标签，随心跳上报到server
+type WorkerCapability struct {
+	Tools     []string          `json:"tools,omitempty"`
+	Chrome    bool              `json:"chrome"`
+	RawSocket bool              `json:"raw_socket"`
+	CPU       int               `json:"cpu"`
+	Memory    uint64            `json:"memory"` //MB
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// TaskRequirement 任务对worker的要求
+type TaskRequirement struct {
+	Tools     []string          `json:"tools,omitempty"`
+	Chrome    bool              `json:"chrome,omitempty"`
+	RawSocket bool              `json:"raw_socket,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// taskRequirementConfig 任务参数中与worker要求相关的字段，兼容各任务的参数格式
+type taskRequirementConfig struct {
+	CmdBin           string `json:"cmdBin"`
+	Tech             string `json:"tech"`
+	IsHttpx          bool   `json:"IsHttpx"`
+	IsScreenshot     bool   `json:"IsScreenshot"`
+	IsFingerprintHub bool   `json:"IsFingerprintHub"`
+}
+
+// IsEmpty 任务是否没有要求
+func (r TaskRequirement) IsEmpty() bool {
+	return len(r.Tools) == 0 && !r.Chrome && !r.RawSocket && len(r.Labels) == 0
+}
+
+// Satisfy 检查worker是否满足任务的要求，不满足时返回原因
+func (c WorkerCapability) Satisfy(r TaskRequirement) (ok bool, reason string) {
+	tools := make(map[string]struct{})
+	for _, t := range c.Tools {
+		tools[t] = struct{}{}
+	}
+	var missing []string
+	for _, t := range r.Tools {
+		if _, existed := tools[t]; !existed {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) > 0 {
+		return false, fmt.Sprintf("tool not installed: %s", strings.Join(missing, ","))
+	}
+	if r.Chrome && !c.Chrome {
+		return false, "chrome not available"
+	}
+	if r.RawSocket && !c.RawSocket {
+		return false, "raw socket not permitted"
+	}
+	for k, v := range r.Labels {
+		if c.Labels[k] != v {
+			return false, fmt.Sprintf("label not match: %s=%s", k, v)
+		}
+	}
+	return true, ""
+}
+
+// GetTaskRequirement 根据任务名称、任务参数及主任务指定的worker标签，生成任务对worker的要求
+func GetTaskRequirement(taskName, configJSON, workerLabels string) (r TaskRequirement) {
+	r.Labels = ParseLabels(workerLabels)
+	var config taskRequirementConfig
+	json.Unmarshal([]byte(configJSON), &config)
+	switch taskName {
+	case "portscan", "batchscan":
+		switch config.CmdBin {
+		case "masscan":
+			r.Tools = []string{ToolMasscan}
+			r.RawSocket = true
+		case "masnmap":
+			r.Tools = []string{ToolMasscan, ToolNmap}
+			r.RawSocket = true
+		case "nmap":
+			r.Tools = []string{ToolNmap}
+			// SYN扫描需要原始套接字
+			if strings.Contains(config.Tech, "-sS") {
+				r.RawSocket = true
+			}
+		}
+	case "subfinder", "xsubfinder":
+		r.Tools = []string{ToolSubfinder}
+	case "subdomainbrute", "xsubdomainbrute":
+		r.Tools = []string{ToolMassdns}
+	case "subdomaincrawler", "xsubdomaincralwer":
+		r.Chrome = true
+	case "xray", "xxray":
+		r.Tools = []string{ToolXray}
+	case "nuclei", "xnuclei":
+		r.Tools = []string{ToolNuclei}
+	case "fingerprint":
+		if config.IsHttpx {
+			r.Tools = append(r.Tools, ToolHttpx)
+		}
+		if config.IsFingerprintHub {
+			r.Tools = append(r.Tools, ToolObserverWard)
+		}
+		r.Chrome = config.IsScreenshot
+	}
+	return
+}
+
+// ParseLabels 解析worker标签，格式为"key1=value1,key2=value2"
+func ParseLabels(labels string) map[string]string {
+	result := make(map[string]string)
+	for _, label := range strings.Split(labels, ",") {
+		k, v, _ := strings.Cut(label, "=")
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		result[k] = strings.TrimSpace(v)
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// LabelsToString 将worker标签按名称排序后转换为"key1=value1,key2=value2"的格式
+func LabelsToString(labels map[string]string) string {
+	var list []string
+	for k, v := range labels {
+		list = append(list, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+// GetWorkerTopic 获取worker的专属队列；使用不包含进程号的worker名称，以便worker重启后仍使用相同的队列
+func GetWorkerTopic(workerName string) string {
+	host, _, _ := strings.Cut(workerName, "#")
+	hash := fmt.Sprintf("%x", md5.Sum([]byte(host)))
+	return fmt.Sprintf("%s.%s", TopicWorker, hash[:12])
+}
+
+// SetTaskRequirementHeaders 将任务要求及任务的原始队列写入到任务的消息头中
+func SetTaskRequirementHeaders(headers tasks.Headers, r TaskRequirement, topicName string) {
+	if r.IsEmpty() {
+		return
+	}
+	content, _ := json.Marshal(r)
+	headers[HeaderTaskRequirement] = string(content)
+	headers[HeaderTaskTopic] = topicName
+}
+
+// GetTaskRequirementFromHeaders 从任务的消息头中读取任务要求及任务的原始队列
+func GetTaskRequirementFromHeaders(headers tasks.Headers) (r TaskRequirement, topicName string, ok bool) {
+	content, _ := headers[HeaderTaskRequirement].(string)
+	if content == "" {
+		return
+	}
+	if err := json.Unmarshal([]byte(content), &r); err != nil {
+		return
+	}
+	topicName, _ = headers[HeaderTaskTopic].(string)
+	return r, topicName, true
+}
+
+// IncTaskRejectedTimes 任务被拒绝执行的次数加1，返回累计的次数；消息头经过JSON序列化，次数以字符串保存
+func IncTaskRejectedTimes(headers tasks.Headers) int {
+	content, _ := headers[HeaderTaskRejected].(string)
+	times, _ := strconv.Atoi(content)
+	times++
+	headers[HeaderTaskRejected] = strconv.Itoa(times)
+	return times
+}
@@ -0,0 +1,78 @@
+package ampq
+
+import (
+	"github.com/RichardKnop/machinery/v2/tasks"
+	"strings"
+	"testing"
+)
+
+func TestGetTaskRequirement(t *testing.T) {
+	r := GetTaskRequirement("portscan", `{"cmdBin":"masnmap","tech":"-sS"}`, "region=cn, egress=1.2.3.4")
+	if len(r.Tools) != 2 || !r.RawSocket {
+		t.Errorf("portscan requirement: %+v", r)
+	}
+	if r.Labels["region"] != "cn" || r.Labels["egress"] != "1.2.3.4" {
+		t.Errorf("labels: %v", r.Labels)
+	}
+	r = GetTaskRequirement("portscan", `{"cmdBin":"nmap","tech":"-sT"}`, "")
+	if r.RawSocket || len(r.Tools) != 1 || r.Tools[0] != ToolNmap {
+		t.Errorf("nmap -sT requirement: %+v", r)
+	}
+	r = GetTaskRequirement("fingerprint", `{"IsHttpx":true,"IsScreenshot":true}`, "")
+	if !r.Chrome || len(r.Tools) != 1 || r.Tools[0] != ToolHttpx {
+		t.Errorf("fingerprint requirement: %+v", r)
+	}
+	if r = GetTaskRequirement("iplocation", `{}`, ""); !r.IsEmpty() {
+		t.Errorf("iplocation requirement should be empty: %+v", r)
+	}
+}
+
+func TestSatisfy(t *testing.T) {
+	c := WorkerCapability{
+		Tools:  []string{ToolNmap, ToolHttpx},
+		Chrome: true,
+		Labels: map[string]string{"region": "cn"},
+	}
+	for _, test := range []struct {
+		requirement TaskRequirement
+		ok          bool
+		reason      string
+	}{
+		{TaskRequirement{Tools: []string{ToolNmap}, Chrome: true}, true, ""},
+		{TaskRequirement{Tools: []string{ToolMasscan, ToolNmap}}, false, "masscan"},
+		{TaskRequirement{RawSocket: true}, false, "raw socket"},
+		{TaskRequirement{Labels: map[string]string{"region": "cn"}}, true, ""},
+		{TaskRequirement{Labels: map[string]string{"region": "us"}}, false, "region=us"},
+	} {
+		ok, reason := c.Satisfy(test.requirement)
+		if ok != test.ok || !strings.Contains(reason, test.reason) {
+			t.Errorf("%+v: got %v %q", test.requirement, ok, reason)
+		}
+	}
+}
+
+func TestTaskRequirementHeaders(t *testing.T) {
+	headers := tasks.Headers{}
+	SetTaskRequirementHeaders(headers, TaskRequirement{}, TopicActive)
+	if len(headers) != 0 {
+		t.Errorf("empty requirement should not be set: %v", headers)
+	}
+	SetTaskRequirementHeaders(headers, TaskRequirement{Tools: []string{ToolMasscan}, RawSocket: true}, TopicActive)
+	r, topicName, ok := GetTaskRequirementFromHeaders(headers)
+	if !ok || topicName != TopicActive || !r.RawSocket || r.Tools[0] != ToolMasscan {
+		t.Errorf("requirement from headers: %+v %s %v", r, topicName, ok)
+	}
+	if IncTaskRejectedTimes(headers) != 1 || IncTaskRejectedTimes(headers) != 2 {
+		t.Errorf("rejected times: %v", headers[HeaderTaskRejected])
+	}
+}
+
+func TestGetWorkerTopic(t *testing.T) {
+	topic := GetWorkerTopic("host@192.168.1.2#1234")
+	if topic != GetWorkerTopic("host@192.168.1.2#5678") {
+		t.Error("worker topic should not depend on pid")
+	}
+	if GetTopicByMQRoutingKey(GetRoutingKeyByTopic(topic)) != topic {
+		t.Errorf("worker topic %s should be parsed from routing key", topic)
+	}
+}
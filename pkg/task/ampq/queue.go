@@ -0,0 +1,117 @@
+package ampq
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/RichardKnop/machinery/v2/tasks"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
+	"github.com/streadway/amqp"
+	"time"
+)
+
+// queueDepthInterval 获取队列任务数量的时间间隔
+const queueDepthInterval = 30 * time.Second
+
+// GetServerTopics 获取server分发任务的全部topic，包括自定义任务的topic
+func GetServerTopics() (topics []string) {
+	topics = []string{TopicActive, TopicFinger, TopicPassive, TopicPocscan}
+	for workspaceGUID := range CustomTaskWorkspaceMap {
+		topics = append(topics, fmt.Sprintf("%s.%s", TopicCustom, workspaceGUID))
+	}
+	return
+}
+
+// GetQueueDepth 获取各个topic的队列中等待执行的任务数量，队列不存在时不返回该topic
+func GetQueueDepth(topics []string) (depth map[string]int, err error) {
+	rabbitmq := conf.GlobalServerConfig().Rabbitmq
+	conn, err := amqp.Dial(fmt.Sprintf("amqp://%s:%s@%s:%d/", rabbitmq.Username, rabbitmq.Password, rabbitmq.Host, rabbitmq.Port))
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	depth = make(map[string]int)
+	var channel *amqp.Channel
+	for _, topic := range topics {
+		// 检查不存在的队列会导致channel被关闭，需要重新打开
+		if channel == nil {
+			if channel, err = conn.Channel(); err != nil {
+				return
+			}
+		}
+		queue, errInspect := channel.QueueInspect(GetRoutingKeyByTopic(topic))
+		if errInspect != nil {
+			channel = nil
+			continue
+		}
+		depth[topic] = queue.Messages
+	}
+	if channel != nil {
+		channel.Close()
+	}
+	return
+}
+
+// StartQueueDepthMonitor 定时获取队列中等待执行的任务数量，更新到指标中
+func StartQueueDepthMonitor() {
+	for {
+		depth, err := GetQueueDepth(GetServerTopics())
+		if err != nil {
+			logging.CLILog.Errorf("get queue depth fail:%v", err)
+		}
+		for topic, n := range depth {
+			metrics.QueueDepth.WithLabelValues(topic).Set(float64(n))
+		}
+		time.Sleep(queueDepthInterval)
+	}
+}
+
+// RequeueWorkerTopic 将worker专属队列中等待执行的任务退回到任务的原始队列，避免worker离线后任务一直滞留在专属队列中
+func RequeueWorkerTopic(workerTopic string) (count int, err error) {
+	rabbitmq := conf.GlobalServerConfig().Rabbitmq
+	conn, err := amqp.Dial(fmt.Sprintf("amqp://%s:%s@%s:%d/", rabbitmq.Username, rabbitmq.Password, rabbitmq.Host, rabbitmq.Port))
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	channel, err := conn.Channel()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+
+	queueName := GetRoutingKeyByTopic(workerTopic)
+	for {
+		delivery, ok, errGet := channel.Get(queueName, false)
+		if errGet != nil || !ok {
+			return
+		}
+		var signature tasks.Signature
+		if err = json.Unmarshal(delivery.Body, &signature); err != nil {
+			delivery.Nack(false, true)
+			return
+		}
+		_, topicName, _ := GetTaskRequirementFromHeaders(signature.Headers)
+		if topicName == "" {
+			delivery.Nack(false, true)
+			return count, fmt.Errorf("task %s has no original topic", signature.UUID)
+		}
+		signature.RoutingKey = GetRoutingKeyByTopic(topicName)
+		body, _ := json.Marshal(signature)
+		err = channel.Publish(amqpExchange, signature.RoutingKey, false, false, amqp.Publishing{
+			Headers:      delivery.Headers,
+			ContentType:  delivery.ContentType,
+			Body:         body,
+			Priority:     delivery.Priority,
+			DeliveryMode: amqp.Persistent,
+		})
+		if err != nil {
+			delivery.Nack(false, true)
+			return
+		}
+		delivery.Ack(false)
+		count++
+	}
+}
@@ -0,0 +1,187 @@
+package domainscan
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// BruteModeWordlist 子域名爆破模式：使用字典爆破（默认）
+	BruteModeWordlist = ""
+	// BruteModeAlteration 子域名爆破模式：根据已知子域名生成变换组合
+	BruteModeAlteration = "alteration"
+	// defaultAlterationMaxCandidate 未配置时变换生成的最大候选子域名数量
+	defaultAlterationMaxCandidate = 50000
+	// alterationNumberDelta 数字递增、递减的范围
+	alterationNumberDelta = 2
+)
+
+// alterationWords 变换时用于插入、替换的常见环境及功能单词
+var alterationWords = []string{
+	"dev", "test", "uat", "qa", "stage", "staging", "pre", "prod", "beta", "demo",
+	"api", "admin", "internal", "backup", "old", "new", "m", "app", "web", "vpn",
+}
+
+var alterationNumberRegex = regexp.MustCompile(`\d+`)
+
+// Alteration 根据已知子域名生成变换（permutation）的候选子域名
+type Alteration struct {
+	Words        []string
+	MaxCandidate int
+
+	known      map[string]struct{}
+	candidates map[string]struct{}
+	result     []string
+}
+
+// NewAlteration 创建子域名变换对象，maxCandidate<=0时使用默认的数量上限
+func NewAlteration(maxCandidate int) *Alteration {
+	if maxCandidate <= 0 {
+		maxCandidate = defaultAlterationMaxCandidate
+	}
+	return &Alteration{Words: alterationWords, MaxCandidate: maxCandidate}
+}
+
+// Generate 根据根域名及已知的子域名，生成不包含已知子域名的候选子域名列表
+func (a *Alteration) Generate(domain string, knownSubdomains []string) []string {
+	a.known = make(map[string]struct{})
+	a.candidates = make(map[string]struct{})
+	a.result = nil
+
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	known := normalizeSubdomains(domain, knownSubdomains)
+	sort.Strings(known)
+	for _, subdomain := range known {
+		a.known[subdomain] = struct{}{}
+	}
+	for _, subdomain := range known {
+		if subdomain == domain {
+			continue
+		}
+		labels := strings.Split(strings.TrimSuffix(subdomain, "."+domain), ".")
+		if a.alterLabels(labels, domain) {
+			break
+		}
+	}
+	return a.result
+}
+
+// alterLabels 对一个子域名的各级标签进行变换，达到数量上限时返回true
+func (a *Alteration) alterLabels(labels []string, domain string) bool {
+	first, rest := labels[0], strings.Join(labels[1:], ".")
+	for _, word := range a.Words {
+		if word == first {
+			continue
+		}
+		// dash插入：dev-api、api-dev
+		if a.add(domain, word+"-"+first, rest) || a.add(domain, first+"-"+word, rest) {
+			return true
+		}
+		// dot插入：dev.api
+		if a.add(domain, word+"."+first, rest) {
+			return true
+		}
+	}
+	// 数字递增递减：api2 -> api1、api3
+	for _, loc := range alterationNumberRegex.FindAllStringIndex(first, -1) {
+		for _, number := range alterNumber(first[loc[0]:loc[1]]) {
+			if a.add(domain, first[:loc[0]]+number+first[loc[1]:], rest) {
+				return true
+			}
+		}
+	}
+	// 单词替换：dev-api -> test-api
+	tokens := strings.Split(first, "-")
+	for i, token := range tokens {
+		if !a.isWord(token) {
+			continue
+		}
+		for _, word := range a.Words {
+			if word == token {
+				continue
+			}
+			swapped := make([]string, len(tokens))
+			copy(swapped, tokens)
+			swapped[i] = word
+			if a.add(domain, strings.Join(swapped, "-"), rest) {
+				return true
+			}
+		}
+	}
+	// dash与dot的互换：dev-api.example.com <-> dev.api.example.com
+	if strings.Contains(first, "-") {
+		if a.add(domain, strings.ReplaceAll(first, "-", "."), rest) {
+			return true
+		}
+	}
+	if len(labels) >= 2 {
+		if a.add(domain, labels[0]+"-"+labels[1], strings.Join(labels[2:], ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// add 增加一个候选子域名，达到数量上限时返回true
+func (a *Alteration) add(domain, first, rest string) bool {
+	if len(a.result) >= a.MaxCandidate {
+		return true
+	}
+	candidate := first
+	if rest != "" {
+		candidate = fmt.Sprintf("%s.%s", candidate, rest)
+	}
+	candidate = fmt.Sprintf("%s.%s", candidate, domain)
+	if !isValidAlteration(candidate) {
+		return false
+	}
+	if _, ok := a.known[candidate]; ok {
+		return false
+	}
+	if _, ok := a.candidates[candidate]; ok {
+		return false
+	}
+	a.candidates[candidate] = struct{}{}
+	a.result = append(a.result, candidate)
+	return len(a.result) >= a.MaxCandidate
+}
+
+func (a *Alteration) isWord(token string) bool {
+	for _, word := range a.Words {
+		if word == token {
+			return true
+		}
+	}
+	return false
+}
+
+// alterNumber 生成数字前后的递增递减值，保留原有的位数（如01 -> 02）
+func alterNumber(s string) (result []string) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return
+	}
+	for delta := -alterationNumberDelta; delta <= alterationNumberDelta; delta++ {
+		if delta == 0 || n+delta < 0 {
+			continue
+		}
+		result = append(result, fmt.Sprintf("%0*d", len(s), n+delta))
+	}
+	return
+}
+
+// isValidAlteration 检查候选子域名的每一级标签是否合法
+func isValidAlteration(domain string) bool {
+	if len(domain) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+	}
+	return true
+}
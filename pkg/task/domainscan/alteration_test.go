@@ -0,0 +1,55 @@
+package domainscan
+
+import "testing"
+
+func TestAlteration_Generate(t *testing.T) {
+	known := []string{"api.example.com", "dev-web.example.com", "app01.example.com", "mail.internal.example.com", "other.com"}
+	a := NewAlteration(0)
+	candidates := a.Generate("example.com", known)
+
+	result := make(map[string]struct{})
+	for _, c := range candidates {
+		if _, ok := result[c]; ok {
+			t.Errorf("duplicate candidate:%s", c)
+		}
+		result[c] = struct{}{}
+	}
+	for _, expected := range []string{
+		"dev-api.example.com", "api-test.example.com", "dev.api.example.com", // dash/dot插入
+		"app00.example.com", "app02.example.com", "app03.example.com", // 数字递增递减
+		"test-web.example.com", "uat-web.example.com", // 单词替换
+		"dev.web.example.com",            // dash转换为dot
+		"mail-internal.example.com",      // dot转换为dash
+		"test-mail.internal.example.com", // 多级子域名
+	} {
+		if _, ok := result[expected]; !ok {
+			t.Errorf("%s not generated", expected)
+		}
+	}
+	for _, unexpected := range []string{"api.example.com", "dev-web.example.com", "-api.example.com"} {
+		if _, ok := result[unexpected]; ok {
+			t.Errorf("%s should not be generated", unexpected)
+		}
+	}
+	for c := range result {
+		if !isValidAlteration(c) || c == "other.com" {
+			t.Errorf("invalid candidate:%s", c)
+		}
+	}
+}
+
+func TestAlteration_MaxCandidate(t *testing.T) {
+	a := NewAlteration(10)
+	candidates := a.Generate("example.com", []string{"api.example.com", "www.example.com", "app1.example.com"})
+	if len(candidates) != 10 {
+		t.Errorf("got %d candidates,expected 10", len(candidates))
+	}
+	if len(NewAlteration(10).Generate("example.com", nil)) != 0 {
+		t.Error("expected no candidate without known subdomain")
+	}
+}
+
+func TestAlterNumber(t *testing.T) {
+	assertSubdomains(t, "alterNumber", alterNumber("01"), []string{"00", "02", "03"})
+	assertSubdomains(t, "alterNumber", alterNumber("10"), []string{"08", "09", "11", "12"})
+}
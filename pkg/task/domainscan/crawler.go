@@ -9,7 +9,9 @@ import (
 	model2 "github.com/Qianlitp/crawlergo/pkg/model"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
+	"github.com/hanc00l/nemo_go/pkg/task/urlscan"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"github.com/remeh/sizedwaitgroup"
 	"github.com/sirupsen/logrus"
@@ -26,16 +28,6 @@ type Crawler struct {
 	Result Result
 }
 
-type UrlResponse struct {
-	URL              string
-	Method           string
-	Headers          map[string]interface{}
-	PostData         string
-	StatusCode       int
-	ContentLength    int64
-	RedirectLocation string
-}
-
 // NewCrawler 创建Crawler对象
 func NewCrawler(config Config) *Crawler {
 	return &Crawler{Config: config}
@@ -53,25 +45,34 @@ func (c *Crawler) Do() {
 			continue
 		}
 		if blackDomain.CheckBlack(domain) {
-			logging.RuntimeLog.Warningf("%s is in blacklist,skip...", domain)
+			c.Config.log().Warningf("%s is in blacklist,skip...", domain)
 			continue
 		}
-		protocol := utils.GetProtocol(domain, 5)
 		swg.Add()
 		go func(d string) {
 			defer swg.Done()
-			c.RunCrawler(d)
-		}(fmt.Sprintf("%s://%s", protocol, domain))
+			proxy := proxypool.Get(proxypool.TaskCrawler)
+			protocol := proxypool.GetProtocol(d, 5, proxy)
+			c.RunCrawlerByProxy(fmt.Sprintf("%s://%s", protocol, d), proxy)
+		}(domain)
 	}
 	swg.Wait()
 }
 
 // RunCrawler 爬取一个网站
 func (c *Crawler) RunCrawler(domainUrl string) {
+	c.RunCrawlerByProxy(domainUrl, nil)
+}
+
+// RunCrawlerByProxy 通过代理爬取一个网站，proxy为nil时直接访问
+func (c *Crawler) RunCrawlerByProxy(domainUrl string, proxy *proxypool.Proxy) {
 	taskConfig := initTaskConfig()
+	if proxy != nil {
+		taskConfig.Proxy = proxy.URL
+	}
 	option := getOption(&taskConfig)
 	if taskConfig.ChromiumPath == "" {
-		logging.RuntimeLog.Error("no chrome or chromium-browser found in default path")
+		c.Config.log().Error("no chrome or chromium-browser found in default path")
 		logging.CLILog.Error("no chrome or chromium-browser found in default path")
 		return
 	}
@@ -84,7 +85,7 @@ func (c *Crawler) RunCrawler(domainUrl string) {
 	var targets []*model2.Request
 	url, err := model2.GetUrl(domainUrl)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		c.Config.log().Error(err)
 		logging.CLILog.Error(err)
 		return
 	}
@@ -93,7 +94,7 @@ func (c *Crawler) RunCrawler(domainUrl string) {
 	// 开始爬虫任务
 	task, err := pkg.NewCrawlerTask(targets, taskConfig)
 	if err != nil {
-		logging.RuntimeLog.Error(fmt.Sprintf("create crawler task failed:%s.", domainUrl))
+		c.Config.log().Error(fmt.Sprintf("create crawler task failed:%s.", domainUrl))
 		logging.CLILog.Error(err)
 		return
 	}
@@ -104,11 +105,12 @@ func (c *Crawler) RunCrawler(domainUrl string) {
 	logging.CLILog.Info(fmt.Sprintf("task finished, %d results, %d requests, %d subdomains, %d domains found.",
 		len(result.ReqList), len(result.AllReqList), len(result.SubDomainList), len(result.AllDomainList)))
 	// 结果解析
-	c.parseResult(result.SubDomainList)
+	c.parseResult(result.SubDomainList, proxy)
+	c.parseReqList(result.ReqList)
 }
 
-// parseResult 解析子域名枚举结果文件
-func (c *Crawler) parseResult(result []string) {
+// parseResult 解析子域名枚举结果文件，使用代理时记录代理的出口IP
+func (c *Crawler) parseResult(result []string, proxy *proxypool.Proxy) {
 	blackDomain := custom.NewBlackTargetCheck(custom.CheckDomain)
 	for _, line := range result {
 		domain := strings.TrimSpace(line)
@@ -116,14 +118,45 @@ func (c *Crawler) parseResult(result []string) {
 			continue
 		}
 		if blackDomain.CheckBlack(domain) {
-			logging.RuntimeLog.Warningf("%s is in blacklist,skip...", domain)
+			c.Config.log().Warningf("%s is in blacklist,skip...", domain)
 			continue
 		}
 		logging.CLILog.Info(domain)
 		if !c.Result.HasDomain(domain) {
 			c.Result.SetDomain(domain)
 		}
+		if proxy != nil {
+			c.Result.SetDomainAttr(domain, DomainAttrResult{
+				Source:  "crawler",
+				Tag:     proxypool.EgressTag,
+				Content: proxy.Egress,
+			})
+		}
+	}
+}
+
+// parseReqList 解析爬虫获取的同域名请求，作为URL资产
+func (c *Crawler) parseReqList(reqList []*model2.Request) {
+	var urlResult []urlscan.UrlResult
+	for _, req := range reqList {
+		if req == nil || req.URL == nil {
+			continue
+		}
+		r := urlscan.UrlResult{
+			Url:         req.URL.String(),
+			Method:      req.Method,
+			PostData:    req.PostData,
+			Source:      urlscan.SourceCrawler,
+			WorkspaceId: c.Config.WorkspaceId,
+		}
+		if contentType, ok := req.Headers["Content-Type"]; ok {
+			r.ContentType = fmt.Sprintf("%v", contentType)
+		}
+		urlResult = append(urlResult, r)
 	}
+	c.Result.Lock()
+	c.Result.UrlResult = append(c.Result.UrlResult, urlResult...)
+	c.Result.Unlock()
 }
 
 func getOption(taskConfig *pkg.TaskConfig) model2.Options {
@@ -165,7 +198,7 @@ func initTaskConfig() pkg.TaskConfig {
 		CustomFormKeywordValues map[string]string // 自定义表单关键词填充内容
 	}*/
 	taskConfig := pkg.TaskConfig{}
-	taskConfig.ChromiumPath = findExecPath()
+	taskConfig.ChromiumPath = FindExecPath()
 	taskConfig.ExtraHeadersString = fmt.Sprintf(`{"User-Agent": "%s"}`, config.DefaultUA)
 	taskConfig.MaxTabsCount = config.MaxCrawlCount
 	taskConfig.FilterMode = config.SmartFilterMode
@@ -195,12 +228,12 @@ func handleExit(t *pkg.CrawlerTask, signalChan chan os.Signal) {
 	}
 }
 
-// findExecPath tries to find the Chrome browser somewhere in the current
+// FindExecPath tries to find the Chrome browser somewhere in the current
 // system. It finds in different locations on different OS systems.
 // It could perform a rather aggressive search. That may make it a bit slow,
 // but it will only be run when creating a new ExecAllocator.
 // forked from https://github.com/chromedp/chromedp/blob/master/allocate.go
-func findExecPath() string {
+func FindExecPath() string {
 	var locations []string
 	switch runtime.GOOS {
 	case "darwin":
@@ -0,0 +1,310 @@
+package domainscan
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/task/custom"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/miekg/dns"
+	"github.com/remeh/sizedwaitgroup"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// DNSRecordSource DNS记录及检查结果在属性中的Source，记录的Tag为记录类型（MX、NS等）
+	DNSRecordSource = "dnsrecord"
+	// DNSCheckAttrTag DNS安全检查（域传送、SPF/DMARC、CNAME悬挂）结果在属性中的Tag
+	DNSCheckAttrTag = "dnscheck"
+
+	dnsQueryTimeout       = 5 * time.Second
+	defaultDNSNameserver  = "223.5.5.5:53"
+	defaultDNSServicePort = "53"
+)
+
+// dnsRecordTypes 根域名需要枚举的记录类型
+var dnsRecordTypes = []uint16{dns.TypeMX, dns.TypeNS, dns.TypeTXT, dns.TypeSOA, dns.TypeCAA}
+
+// dnsSRVServices 根域名需要枚举的常见SRV服务
+var dnsSRVServices = []string{
+	"_sip._tcp", "_sip._udp", "_sips._tcp", "_xmpp-client._tcp", "_xmpp-server._tcp",
+	"_ldap._tcp", "_kerberos._tcp", "_kerberos._udp", "_autodiscover._tcp", "_submission._tcp",
+	"_imaps._tcp", "_pop3s._tcp", "_caldavs._tcp", "_carddavs._tcp",
+}
+
+// DNSRecord 枚举根域名的DNS记录，并检查域传送、SPF/DMARC及悬挂的CNAME
+type DNSRecord struct {
+	Config Config
+	Result Result
+	// Nameserver 递归查询使用的DNS服务器（ip:port）
+	Nameserver string
+	// axfrPort 域传送检查时NS服务器的端口
+	axfrPort string
+}
+
+// NewDNSRecord 创建DNS记录枚举对象
+func NewDNSRecord(config Config) *DNSRecord {
+	return &DNSRecord{Config: config, Nameserver: getDefaultNameserver(), axfrPort: defaultDNSServicePort}
+}
+
+// Do 执行DNS记录枚举及检查：Config中的根域名枚举全部记录，Result中所有存在CNAME的域名检查是否悬挂
+func (d *DNSRecord) Do() {
+	if d.Result.DomainResult == nil {
+		d.Result.DomainResult = make(map[string]*DomainResult)
+	}
+	swg := sizedwaitgroup.New(dnsRecordThreadNumber[conf.WorkerPerformanceMode])
+	blackDomain := custom.NewBlackTargetCheck(custom.CheckDomain)
+
+	for _, line := range strings.Split(d.Config.Target, ",") {
+		domain := strings.TrimSpace(line)
+		if domain == "" || utils.CheckIPOrSubnet(domain) {
+			continue
+		}
+		if blackDomain.CheckBlack(domain) {
+			d.Config.log().Warningf("%s is in blacklist,skip...", domain)
+			continue
+		}
+		if !d.Result.HasDomain(domain) {
+			d.Result.SetDomain(domain)
+		}
+		swg.Add()
+		go func(domain string) {
+			defer swg.Done()
+			d.RunRecord(domain)
+		}(domain)
+	}
+	swg.Wait()
+
+	cnames := make(map[string]string)
+	for domain, domainResult := range d.Result.DomainResult {
+		for _, dar := range domainResult.DomainAttrs {
+			if dar.Tag == "CNAME" {
+				cnames[domain] = dar.Content
+				break
+			}
+		}
+	}
+	for domain, cname := range cnames {
+		swg.Add()
+		go func(domain, cname string) {
+			defer swg.Done()
+			d.CheckDanglingCNAME(domain, cname)
+		}(domain, cname)
+	}
+	swg.Wait()
+}
+
+// RunRecord 枚举一个根域名的DNS记录，并检查域传送及SPF/DMARC
+func (d *DNSRecord) RunRecord(domain string) {
+	var nsHosts, txts []string
+	for _, qtype := range dnsRecordTypes {
+		for _, rr := range d.queryRecords(domain, qtype) {
+			d.setRecordAttr(domain, rr)
+			switch r := rr.(type) {
+			case *dns.NS:
+				nsHosts = append(nsHosts, strings.TrimSuffix(r.Ns, "."))
+			case *dns.TXT:
+				txts = append(txts, strings.Join(r.Txt, ""))
+			}
+		}
+	}
+	for _, service := range dnsSRVServices {
+		for _, rr := range d.queryRecords(fmt.Sprintf("%s.%s", service, domain), dns.TypeSRV) {
+			d.setRecordAttr(domain, rr)
+		}
+	}
+	for _, result := range checkSPF(txts) {
+		d.setCheckAttr(domain, result)
+	}
+	var dmarcTxts []string
+	for _, rr := range d.queryRecords("_dmarc."+domain, dns.TypeTXT) {
+		if r, ok := rr.(*dns.TXT); ok {
+			dmarcTxts = append(dmarcTxts, strings.Join(r.Txt, ""))
+		}
+	}
+	for _, result := range checkDMARC(dmarcTxts) {
+		d.setCheckAttr(domain, result)
+	}
+	for _, ns := range nsHosts {
+		d.checkAXFR(domain, ns)
+	}
+}
+
+// CheckDanglingCNAME 检查域名的CNAME指向的目标是否已不存在（NXDOMAIN），可能导致子域名接管
+func (d *DNSRecord) CheckDanglingCNAME(domain, cname string) {
+	cname = strings.TrimSuffix(cname, ".")
+	if cname == "" || cname == domain {
+		return
+	}
+	msg, err := d.query(cname, dns.TypeA)
+	if err != nil {
+		return
+	}
+	if msg.Rcode == dns.RcodeNameError {
+		d.setCheckAttr(domain, fmt.Sprintf("dangling CNAME: %s (NXDOMAIN)", cname))
+	}
+}
+
+// checkAXFR 检查NS服务器是否允许域传送
+func (d *DNSRecord) checkAXFR(domain, ns string) {
+	var ips []string
+	for _, rr := range d.queryRecords(ns, dns.TypeA) {
+		if r, ok := rr.(*dns.A); ok {
+			ips = append(ips, r.A.String())
+		}
+	}
+	for _, ip := range ips {
+		m := new(dns.Msg)
+		m.SetAxfr(dns.Fqdn(domain))
+		t := &dns.Transfer{DialTimeout: dnsQueryTimeout, ReadTimeout: dnsQueryTimeout}
+		env, err := t.In(m, net.JoinHostPort(ip, d.axfrPort))
+		if err != nil {
+			continue
+		}
+		var count int
+		for e := range env {
+			if e.Error != nil {
+				break
+			}
+			count += len(e.RR)
+		}
+		if count > 0 {
+			d.setCheckAttr(domain, fmt.Sprintf("zone transfer allowed: %s(%s) %d records", ns, ip, count))
+			return
+		}
+	}
+}
+
+// query 向DNS服务器查询一个记录，UDP响应被截断时使用TCP重试
+func (d *DNSRecord) query(name string, qtype uint16) (*dns.Msg, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	m.RecursionDesired = true
+	c := &dns.Client{Timeout: dnsQueryTimeout}
+	msg, _, err := c.Exchange(m, d.Nameserver)
+	if err == nil && msg.Truncated {
+		c.Net = "tcp"
+		msg, _, err = c.Exchange(m, d.Nameserver)
+	}
+	return msg, err
+}
+
+// queryRecords 查询记录并返回与查询类型一致的结果（忽略CNAME等其它类型的应答）
+func (d *DNSRecord) queryRecords(name string, qtype uint16) (rrs []dns.RR) {
+	msg, err := d.query(name, qtype)
+	if err != nil {
+		d.Config.log().Debugf("query %s %s fail:%v", name, dns.TypeToString[qtype], err)
+		return
+	}
+	if msg.Rcode != dns.RcodeSuccess {
+		return
+	}
+	for _, rr := range msg.Answer {
+		if rr.Header().Rrtype == qtype {
+			rrs = append(rrs, rr)
+		}
+	}
+	return
+}
+
+func (d *DNSRecord) setRecordAttr(domain string, rr dns.RR) {
+	content := strings.TrimSpace(strings.TrimPrefix(rr.String(), rr.Header().String()))
+	if rr.Header().Rrtype == dns.TypeSRV {
+		content = fmt.Sprintf("%s %s", strings.TrimSuffix(rr.Header().Name, "."), content)
+	}
+	d.Result.SetDomainAttr(domain, DomainAttrResult{
+		Source:  DNSRecordSource,
+		Tag:     dns.TypeToString[rr.Header().Rrtype],
+		Content: content,
+	})
+}
+
+func (d *DNSRecord) setCheckAttr(domain string, content string) {
+	d.Result.SetDomainAttr(domain, DomainAttrResult{
+		Source:  DNSRecordSource,
+		Tag:     DNSCheckAttrTag,
+		Content: content,
+	})
+}
+
+// checkSPF 检查SPF记录是否缺失或过于宽松
+func checkSPF(txts []string) (results []string) {
+	var spfs []string
+	for _, txt := range txts {
+		if strings.HasPrefix(strings.ToLower(txt), "v=spf1") {
+			spfs = append(spfs, txt)
+		}
+	}
+	if len(spfs) == 0 {
+		return []string{"missing SPF record"}
+	}
+	if len(spfs) > 1 {
+		results = append(results, fmt.Sprintf("multiple SPF records: %s", strings.Join(spfs, " | ")))
+	}
+	for _, spf := range spfs {
+		var hasAll, isWeak bool
+		for _, term := range strings.Fields(strings.ToLower(spf))[1:] {
+			switch term {
+			case "all", "+all", "?all":
+				hasAll, isWeak = true, true
+			case "-all", "~all":
+				hasAll = true
+			}
+			if strings.HasPrefix(term, "redirect=") {
+				hasAll = true
+			}
+		}
+		if isWeak || !hasAll {
+			results = append(results, fmt.Sprintf("weak SPF: %s", spf))
+		}
+	}
+	return
+}
+
+// checkDMARC 检查DMARC记录是否缺失或策略为none
+func checkDMARC(txts []string) (results []string) {
+	var dmarc string
+	for _, txt := range txts {
+		if strings.HasPrefix(strings.ToUpper(strings.ReplaceAll(txt, " ", "")), "V=DMARC1") {
+			dmarc = txt
+			break
+		}
+	}
+	if dmarc == "" {
+		return []string{"missing DMARC record"}
+	}
+	var policy string
+	for _, tag := range strings.Split(dmarc, ";") {
+		kv := strings.SplitN(strings.TrimSpace(tag), "=", 2)
+		if len(kv) == 2 && strings.ToLower(strings.TrimSpace(kv[0])) == "p" {
+			policy = strings.ToLower(strings.TrimSpace(kv[1]))
+		}
+	}
+	if policy == "" || policy == "none" {
+		results = append(results, fmt.Sprintf("weak DMARC policy: %s", dmarc))
+	}
+	return
+}
+
+// getDefaultNameserver 使用resolver字典中的第一个DNS服务器
+func getDefaultNameserver() string {
+	inputFile, err := os.Open(filepath.Join(conf.GetRootPath(), "thirdparty/dict", conf.GlobalWorkerConfig().Domainscan.Resolver))
+	if err != nil {
+		return defaultDNSNameserver
+	}
+	defer inputFile.Close()
+	scanner := bufio.NewScanner(inputFile)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			if _, _, err = net.SplitHostPort(line); err == nil {
+				return line
+			}
+			return net.JoinHostPort(line, defaultDNSServicePort)
+		}
+	}
+	return defaultDNSNameserver
+}
@@ -0,0 +1,159 @@
+package domainscan
+
+import (
+	"github.com/miekg/dns"
+	"net"
+	"strings"
+	"testing"
+)
+
+// testZone 本地权威DNS模拟的example.com区域
+var testZone = []string{
+	"example.com. 3600 IN SOA ns1.example.com. admin.example.com. 2023100101 7200 3600 1209600 3600",
+	"example.com. 3600 IN NS ns1.example.com.",
+	"example.com. 3600 IN MX 10 mail.example.com.",
+	`example.com. 3600 IN TXT "v=spf1 include:_spf.example.com +all"`,
+	`example.com. 3600 IN CAA 0 issue "letsencrypt.org"`,
+	"_sip._tcp.example.com. 3600 IN SRV 10 60 5060 sip.example.com.",
+	"ns1.example.com. 3600 IN A 127.0.0.1",
+	"mail.example.com. 3600 IN A 127.0.0.2",
+	"www.example.com. 3600 IN CNAME www.example.net.",
+	"old.example.com. 3600 IN CNAME gone.example.net.",
+	"www.example.net. 3600 IN A 127.0.0.3",
+}
+
+// newTestDNSServer 启动本地UDP及TCP的权威DNS模拟服务，返回监听的端口
+func newTestDNSServer(t *testing.T) (port string, shutdown func()) {
+	var rrs []dns.RR
+	for _, line := range testZone {
+		rr, err := dns.NewRR(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rrs = append(rrs, rr)
+	}
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		q := r.Question[0]
+		if q.Qtype == dns.TypeAXFR {
+			ch := make(chan *dns.Envelope)
+			tr := new(dns.Transfer)
+			go func() {
+				// 域传送以SOA记录开始及结束
+				ch <- &dns.Envelope{RR: append(rrs, rrs[0])}
+				close(ch)
+			}()
+			tr.Out(w, r, ch)
+			w.Hijack()
+			return
+		}
+		m := new(dns.Msg)
+		m.SetReply(r)
+		m.Authoritative = true
+		var exist bool
+		for _, rr := range rrs {
+			if !strings.EqualFold(rr.Header().Name, q.Name) {
+				continue
+			}
+			exist = true
+			if rr.Header().Rrtype == q.Qtype || rr.Header().Rrtype == dns.TypeCNAME {
+				m.Answer = append(m.Answer, rr)
+			}
+		}
+		if !exist {
+			m.Rcode = dns.RcodeNameError
+		}
+		w.WriteMsg(m)
+	})
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ = net.SplitHostPort(pc.LocalAddr().String())
+	l, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", port))
+	if err != nil {
+		pc.Close()
+		t.Skip(err)
+	}
+	udpServer := &dns.Server{PacketConn: pc, Handler: handler}
+	tcpServer := &dns.Server{Listener: l, Handler: handler}
+	go udpServer.ActivateAndServe()
+	go tcpServer.ActivateAndServe()
+
+	return port, func() {
+		udpServer.Shutdown()
+		tcpServer.Shutdown()
+	}
+}
+
+func TestDNSRecord_Do(t *testing.T) {
+	port, shutdown := newTestDNSServer(t)
+	defer shutdown()
+
+	d := &DNSRecord{Config: Config{Target: "example.com"}, Nameserver: net.JoinHostPort("127.0.0.1", port), axfrPort: port}
+	d.Result.DomainResult = map[string]*DomainResult{
+		"www.example.com": {DomainAttrs: []DomainAttrResult{{Tag: "CNAME", Content: "www.example.net"}}},
+		"old.example.com": {DomainAttrs: []DomainAttrResult{{Tag: "CNAME", Content: "gone.example.net"}}},
+	}
+	d.Do()
+
+	attrs := make(map[string][]string)
+	for _, dar := range d.Result.DomainResult["example.com"].DomainAttrs {
+		if dar.Source != DNSRecordSource {
+			t.Errorf("invalid source:%v", dar)
+		}
+		attrs[dar.Tag] = append(attrs[dar.Tag], dar.Content)
+	}
+	for _, tag := range []string{"SOA", "NS", "MX", "TXT", "CAA", "SRV"} {
+		if len(attrs[tag]) == 0 {
+			t.Errorf("%s record not found", tag)
+		}
+	}
+	if len(attrs["MX"]) > 0 && attrs["MX"][0] != "10 mail.example.com." {
+		t.Errorf("invalid MX record:%v", attrs["MX"])
+	}
+	checks := strings.Join(attrs[DNSCheckAttrTag], "\n")
+	for _, expected := range []string{"weak SPF", "missing DMARC record", "zone transfer allowed: ns1.example.com(127.0.0.1)"} {
+		if !strings.Contains(checks, expected) {
+			t.Errorf("check %s not found in:%s", expected, checks)
+		}
+	}
+
+	for domain, dangling := range map[string]bool{"www.example.com": false, "old.example.com": true} {
+		var found bool
+		for _, dar := range d.Result.DomainResult[domain].DomainAttrs {
+			if dar.Tag == DNSCheckAttrTag && strings.HasPrefix(dar.Content, "dangling CNAME") {
+				found = true
+			}
+		}
+		if found != dangling {
+			t.Errorf("%s dangling CNAME: got %v,expected %v", domain, found, dangling)
+		}
+	}
+}
+
+func TestCheckSPFAndDMARC(t *testing.T) {
+	for spf, weak := range map[string]bool{
+		"v=spf1 include:_spf.example.com -all": false,
+		"v=spf1 mx ~all":                       false,
+		"v=spf1 redirect=_spf.example.com":     false,
+		"v=spf1 mx ?all":                       true,
+		"v=spf1 a mx":                          true,
+	} {
+		if got := len(checkSPF([]string{spf})) > 0; got != weak {
+			t.Errorf("%s: got %v,expected %v", spf, got, weak)
+		}
+	}
+	if r := checkSPF([]string{"google-site-verification=xxx"}); len(r) != 1 || r[0] != "missing SPF record" {
+		t.Errorf("invalid spf check:%v", r)
+	}
+	for dmarc, weak := range map[string]bool{
+		"v=DMARC1; p=reject; rua=mailto:dmarc@example.com": false,
+		"v=DMARC1; p=quarantine":                           false,
+		"v=DMARC1; p=none":                                 true,
+	} {
+		if got := len(checkDMARC([]string{dmarc})) > 0; got != weak {
+			t.Errorf("%s: got %v,expected %v", dmarc, got, weak)
+		}
+	}
+}
@@ -16,11 +16,14 @@ import (
 type Massdns struct {
 	Config Config
 	Result Result
+	// KnownSubdomains 变换模式下，每个根域名在工作空间中已知的子域名
+	KnownSubdomains map[string][]string
+	wildcard        *WildcardDetector
 }
 
 // NewMassdns 创建Massdns对象
 func NewMassdns(config Config) *Massdns {
-	return &Massdns{Config: config}
+	return &Massdns{Config: config, wildcard: NewWildcardDetector()}
 }
 
 // Do 执行Massdns任务
@@ -28,26 +31,38 @@ func (m *Massdns) Do() {
 	m.Result.DomainResult = make(map[string]*DomainResult)
 	swg := sizedwaitgroup.New(massdnsThreadNumber[conf.WorkerPerformanceMode])
 	blackDomain := custom.NewBlackTargetCheck(custom.CheckDomain)
+	var roots []string
 	for _, line := range strings.Split(m.Config.Target, ",") {
 		domain := strings.TrimSpace(line)
 		if domain == "" || utils.CheckIPOrSubnet(domain) {
 			continue
 		}
 		if blackDomain.CheckBlack(domain) {
-			logging.RuntimeLog.Warningf("%s is in blacklist,skip...", domain)
+			m.Config.log().Warningf("%s is in blacklist,skip...", domain)
 			continue
 		}
+		roots = append(roots, domain)
 		swg.Add()
 		go func(d string) {
 			defer swg.Done()
-			m.RunMassdns(domain)
+			if m.Config.SubDomainBruteMode == BruteModeAlteration {
+				m.RunAlteration(d)
+			} else {
+				m.RunMassdns(d)
+			}
 		}(domain)
 	}
 	swg.Wait()
+	// 在根域名上标记泛解析
+	for _, root := range roots {
+		if zones := m.wildcard.WildcardZones(root); len(zones) > 0 {
+			m.Result.SetWildcard(root, zones)
+		}
+	}
 }
 
 // parseResult 解析子域名枚举结果文件
-func (m *Massdns) parseResult(outputTempFile string) {
+func (m *Massdns) parseResult(root string, outputTempFile string) {
 	content, err := os.ReadFile(outputTempFile)
 	if err != nil {
 		return
@@ -59,7 +74,12 @@ func (m *Massdns) parseResult(outputTempFile string) {
 			continue
 		}
 		if blackDomain.CheckBlack(domain) {
-			logging.RuntimeLog.Warningf("%s is in blacklist,skip...", domain)
+			m.Config.log().Warningf("%s is in blacklist,skip...", domain)
+			continue
+		}
+		// 丢弃与泛解析指纹一致的域名
+		if m.wildcard != nil && m.wildcard.IsWildcardDomain(domain, root) {
+			m.Config.log().Debugf("%s matches wildcard dns,skip...", domain)
 			continue
 		}
 		if !m.Result.HasDomain(domain) {
@@ -70,24 +90,50 @@ func (m *Massdns) parseResult(outputTempFile string) {
 
 // RunMassdns runs the massdns tool on the list of inputs
 func (m *Massdns) RunMassdns(domain string) {
+	conf.GlobalWorkerConfig().ReloadConfig()
+	m.runMassdns(domain, filepath.Join(conf.GetRootPath(), "thirdparty/dict", conf.GlobalWorkerConfig().Domainscan.Wordlist), "")
+}
+
+// RunAlteration 根据已知子域名生成变换的候选子域名，并通过massdns进行解析及泛解析过滤
+func (m *Massdns) RunAlteration(domain string) {
+	conf.GlobalWorkerConfig().ReloadConfig()
+	alteration := NewAlteration(conf.GlobalWorkerConfig().Domainscan.AlterationMaxCandidate)
+	candidates := alteration.Generate(domain, m.KnownSubdomains[domain])
+	if len(candidates) == 0 {
+		m.Config.log().Warningf("%s has no known subdomain for alteration,skip...", domain)
+		return
+	}
+	logging.CLILog.Infof("%s generate %d alteration subdomains", domain, len(candidates))
+
+	tempSubdomainsFile := utils.GetTempPathFileName()
+	defer os.Remove(tempSubdomainsFile)
+	if err := os.WriteFile(tempSubdomainsFile, []byte(strings.Join(candidates, "\n")), 0666); err != nil {
+		m.Config.log().Error(err)
+		logging.CLILog.Error(err)
+		return
+	}
+	m.runMassdns(domain, "", tempSubdomainsFile)
+}
+
+// runMassdns 调用massdns进行字典爆破（wordlist）或解析指定的子域名列表（subdomainsList）
+func (m *Massdns) runMassdns(domain string, wordlist string, subdomainsList string) {
 	tempOutputFile := utils.GetTempPathFileName()
 	defer os.Remove(tempOutputFile)
 
 	tempDir, err := os.MkdirTemp("", utils.GetRandomString2(8))
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		m.Config.log().Error(err)
 		logging.CLILog.Error(err)
 		return
 	}
 	defer os.RemoveAll(tempDir)
 
-	conf.GlobalWorkerConfig().ReloadConfig()
 	options := &runner.Options{
 		Directory:          tempDir,
 		Domain:             domain,
-		SubdomainsList:     "",
+		SubdomainsList:     subdomainsList,
 		ResolversFile:      filepath.Join(conf.GetRootPath(), "thirdparty/dict", conf.GlobalWorkerConfig().Domainscan.Resolver),
-		Wordlist:           filepath.Join(conf.GetRootPath(), "thirdparty/dict", conf.GlobalWorkerConfig().Domainscan.Wordlist),
+		Wordlist:           wordlist,
 		MassdnsPath:        filepath.Join(conf.GetRootPath(), "thirdparty/massdns", utils.GetThirdpartyBinNameByPlatform(utils.MassDns)),
 		Output:             tempOutputFile,
 		Json:               false,
@@ -106,10 +152,11 @@ func (m *Massdns) RunMassdns(domain string) {
 	massdnsRunner, err := runner.New(options)
 	if err != nil {
 		msg := fmt.Sprintf("Could not create runner: %s", err)
-		logging.RuntimeLog.Errorf(msg)
+		m.Config.log().Errorf(msg)
 		logging.CLILog.Errorf(msg)
+		return
 	}
 
 	massdnsRunner.RunEnumeration()
-	m.parseResult(tempOutputFile)
+	m.parseResult(domain, tempOutputFile)
 }
@@ -0,0 +1,209 @@
+package domainscan
+
+import (
+	"crypto/tls"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/custom"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/remeh/sizedwaitgroup"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	passiveHttpTimeout     = 60 * time.Second
+	passiveResponseMaxSize = 64 * 1024 * 1024
+	// PassiveAttrTag 被动数据源获取的子域名在属性中的Tag，Source为数据源名称，Content为查询的根域名
+	PassiveAttrTag = "subdomain"
+)
+
+// PassiveProvider 进程内的被动子域名数据源接口（证书透明度日志、被动DNS、历史URL归档等）
+type PassiveProvider interface {
+	// Name 数据源名称，用于结果中的Source
+	Name() string
+	// Query 查询根域名的子域名，返回的结果可能包含非该根域名的子域名，由调用方进行过滤
+	Query(domain string) (subdomains []string, err error)
+}
+
+// Passive 使用内置的被动数据源进行子域名收集
+type Passive struct {
+	Config    Config
+	Result    Result
+	Providers []PassiveProvider
+}
+
+// passiveRateLimiter 每个数据源独立的请求速率限制，同一worker的多个任务共享
+type passiveRateLimiter struct {
+	sync.Mutex
+	interval time.Duration
+	last     time.Time
+}
+
+var (
+	passiveRateLimiterMutex sync.Mutex
+	passiveRateLimiters     = make(map[string]*passiveRateLimiter)
+)
+
+// NewPassive 创建被动子域名收集对象，根据worker配置加载启用的数据源
+func NewPassive(config Config) *Passive {
+	p := &Passive{Config: config}
+	passiveConfig := conf.GlobalWorkerConfig().Domainscan.Passive
+	if passiveConfig.CrtSh.Enable {
+		p.Providers = append(p.Providers, NewCrtSh(passiveConfig.CrtSh))
+	}
+	if passiveConfig.SecurityTrails.Enable && passiveConfig.SecurityTrails.Key != "" {
+		p.Providers = append(p.Providers, NewSecurityTrails(passiveConfig.SecurityTrails))
+	}
+	if passiveConfig.Wayback.Enable {
+		p.Providers = append(p.Providers, NewWayback(passiveConfig.Wayback))
+	}
+	return p
+}
+
+// Do 执行被动子域名收集
+func (p *Passive) Do() {
+	p.Result.DomainResult = make(map[string]*DomainResult)
+	if len(p.Providers) == 0 {
+		p.Config.log().Warning("no passive subdomain provider enabled")
+		return
+	}
+	swg := sizedwaitgroup.New(subfinderThreadNumber[conf.WorkerPerformanceMode])
+	blackDomain := custom.NewBlackTargetCheck(custom.CheckDomain)
+
+	for _, line := range strings.Split(p.Config.Target, ",") {
+		domain := strings.TrimSpace(line)
+		if domain == "" || utils.CheckIPOrSubnet(domain) {
+			continue
+		}
+		if blackDomain.CheckBlack(domain) {
+			p.Config.log().Warningf("%s is in blacklist,skip...", domain)
+			continue
+		}
+		for _, provider := range p.Providers {
+			swg.Add()
+			go func(d string, pp PassiveProvider) {
+				defer swg.Done()
+				p.RunProvider(d, pp)
+			}(domain, provider)
+		}
+	}
+	swg.Wait()
+}
+
+// RunProvider 调用一个数据源查询根域名，并保存结果
+func (p *Passive) RunProvider(domain string, provider PassiveProvider) {
+	subdomains, err := provider.Query(domain)
+	if err != nil {
+		p.Config.log().Errorf("passive provider %s query %s fail:%v", provider.Name(), domain, err)
+		logging.CLILog.Errorf("passive provider %s query %s fail:%v", provider.Name(), domain, err)
+		return
+	}
+	blackDomain := custom.NewBlackTargetCheck(custom.CheckDomain)
+	for _, subdomain := range normalizeSubdomains(domain, subdomains) {
+		if blackDomain.CheckBlack(subdomain) {
+			p.Config.log().Warningf("%s is in blacklist,skip...", subdomain)
+			continue
+		}
+		p.Result.Lock()
+		if _, ok := p.Result.DomainResult[subdomain]; !ok {
+			p.Result.DomainResult[subdomain] = &DomainResult{DomainAttrs: []DomainAttrResult{}}
+		}
+		p.Result.DomainResult[subdomain].DomainAttrs = append(p.Result.DomainResult[subdomain].DomainAttrs, DomainAttrResult{
+			Source:  provider.Name(),
+			Tag:     PassiveAttrTag,
+			Content: domain,
+		})
+		p.Result.Unlock()
+	}
+}
+
+// normalizeSubdomains 规范化数据源返回的子域名：转为小写、去除通配符及非该根域名的结果并去重
+func normalizeSubdomains(domain string, subdomains []string) (result []string) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	suffix := "." + domain
+	exist := make(map[string]struct{})
+	for _, s := range subdomains {
+		s = strings.ToLower(strings.TrimSpace(s))
+		s = strings.TrimPrefix(s, "*.")
+		s = strings.TrimSuffix(s, ".")
+		if s == "" || strings.ContainsAny(s, " */:@") {
+			continue
+		}
+		if s != domain && !strings.HasSuffix(s, suffix) {
+			continue
+		}
+		if _, ok := exist[s]; ok {
+			continue
+		}
+		exist[s] = struct{}{}
+		result = append(result, s)
+	}
+	return
+}
+
+// getPassiveRateLimiter 获取数据源的速率限制，rateLimit为每分钟最大请求数，0表示不限制
+func getPassiveRateLimiter(name string, rateLimit int) *passiveRateLimiter {
+	passiveRateLimiterMutex.Lock()
+	defer passiveRateLimiterMutex.Unlock()
+
+	if _, ok := passiveRateLimiters[name]; !ok {
+		l := &passiveRateLimiter{}
+		if rateLimit > 0 {
+			l.interval = time.Minute / time.Duration(rateLimit)
+		}
+		passiveRateLimiters[name] = l
+	}
+	return passiveRateLimiters[name]
+}
+
+// Wait 等待直到允许下一次请求
+func (l *passiveRateLimiter) Wait() {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.interval <= 0 {
+		return
+	}
+	if d := time.Until(l.last.Add(l.interval)); d > 0 {
+		time.Sleep(d)
+	}
+	l.last = time.Now()
+}
+
+// passiveHttpGet 数据源的HTTP GET请求
+func passiveHttpGet(limiter *passiveRateLimiter, url string, headers map[string]string) ([]byte, error) {
+	limiter.Wait()
+
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36")
+	for k, v := range headers {
+		request.Header.Set(k, v)
+	}
+	client := &http.Client{
+		Timeout: passiveHttpTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	resp, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(io.LimitReader(resp.Body, passiveResponseMaxSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http status code:%d", resp.StatusCode)
+	}
+	return content, nil
+}
@@ -0,0 +1,127 @@
+package domainscan
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// newPassiveFixtureServer 使用录制的数据源响应创建本地的HTTP测试服务
+func newPassiveFixtureServer(t *testing.T) *httptest.Server {
+	fixtures := map[string]string{
+		"/":                                 "passive_crtsh.json",
+		"/v1/domain/example.com/subdomains": "passive_securitytrails.json",
+		"/cdx/search/cdx":                   "passive_wayback.txt",
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fixture, ok := fixtures[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/v1/") && r.Header.Get("APIKEY") != "test-key" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"You've exceeded the usage limits for your account."}`))
+			return
+		}
+		content, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(content)
+	}))
+}
+
+func assertSubdomains(t *testing.T, name string, got []string, expected []string) {
+	sort.Strings(got)
+	sort.Strings(expected)
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("%s: got %v,expected %v", name, got, expected)
+	}
+}
+
+func TestCrtSh_Query(t *testing.T) {
+	server := newPassiveFixtureServer(t)
+	defer server.Close()
+
+	c := NewCrtSh(conf.PassiveSource{})
+	c.BaseURL = server.URL
+	subdomains, err := c.Query("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSubdomains(t, c.Name(), normalizeSubdomains("example.com", subdomains),
+		[]string{"example.com", "www.example.com", "dev.example.com", "api.example.com"})
+}
+
+func TestSecurityTrails_Query(t *testing.T) {
+	server := newPassiveFixtureServer(t)
+	defer server.Close()
+
+	s := NewSecurityTrails(conf.PassiveSource{Key: "test-key"})
+	s.BaseURL = server.URL
+	subdomains, err := s.Query("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSubdomains(t, s.Name(), normalizeSubdomains("example.com", subdomains),
+		[]string{"www.example.com", "vpn.example.com", "mail.internal.example.com"})
+
+	s.apiKey = "invalid-key"
+	if _, err = s.Query("example.com"); err == nil {
+		t.Error("expected error for invalid key")
+	}
+}
+
+func TestWayback_Query(t *testing.T) {
+	server := newPassiveFixtureServer(t)
+	defer server.Close()
+
+	w := NewWayback(conf.PassiveSource{})
+	w.BaseURL = server.URL
+	subdomains, err := w.Query("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSubdomains(t, w.Name(), normalizeSubdomains("example.com", subdomains),
+		[]string{"www.example.com", "shop.example.com"})
+}
+
+func TestPassive_RunProvider(t *testing.T) {
+	server := newPassiveFixtureServer(t)
+	defer server.Close()
+
+	c := NewCrtSh(conf.PassiveSource{})
+	c.BaseURL = server.URL
+	w := NewWayback(conf.PassiveSource{})
+	w.BaseURL = server.URL
+	p := &Passive{Config: Config{Target: "example.com"}, Providers: []PassiveProvider{c, w}}
+	p.Result.DomainResult = make(map[string]*DomainResult)
+	for _, provider := range p.Providers {
+		p.RunProvider("example.com", provider)
+	}
+	r, ok := p.Result.DomainResult["www.example.com"]
+	if !ok {
+		t.Fatal("www.example.com not found")
+	}
+	sources := make(map[string]struct{})
+	for _, dar := range r.DomainAttrs {
+		if dar.Tag != PassiveAttrTag || dar.Content != "example.com" {
+			t.Errorf("invalid attr:%v", dar)
+		}
+		sources[dar.Source] = struct{}{}
+	}
+	if _, ok = sources[crtShName]; !ok {
+		t.Error("crtsh source not tagged")
+	}
+	if _, ok = sources[waybackName]; !ok {
+		t.Error("wayback source not tagged")
+	}
+}
@@ -0,0 +1,53 @@
+package domainscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"net/url"
+	"strings"
+)
+
+const crtShName = "crtsh"
+
+// CrtSh 基于crt.sh证书透明度日志的子域名数据源
+type CrtSh struct {
+	BaseURL string
+	limiter *passiveRateLimiter
+}
+
+type crtShResult struct {
+	CommonName string `json:"common_name"`
+	NameValue  string `json:"name_value"`
+}
+
+// NewCrtSh 创建crt.sh数据源
+func NewCrtSh(config conf.PassiveSource) *CrtSh {
+	return &CrtSh{
+		BaseURL: "https://crt.sh",
+		limiter: getPassiveRateLimiter(crtShName, config.RateLimit),
+	}
+}
+
+func (c *CrtSh) Name() string {
+	return crtShName
+}
+
+// Query 查询证书透明度日志中包含该根域名的证书
+func (c *CrtSh) Query(domain string) (subdomains []string, err error) {
+	queryUrl := fmt.Sprintf("%s/?q=%s&output=json", strings.TrimSuffix(c.BaseURL, "/"), url.QueryEscape("%."+domain))
+	content, err := passiveHttpGet(c.limiter, queryUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []crtShResult
+	if err = json.Unmarshal(content, &results); err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		subdomains = append(subdomains, r.CommonName)
+		// name_value为证书的SAN，多个值以换行分隔
+		subdomains = append(subdomains, strings.Split(r.NameValue, "\n")...)
+	}
+	return
+}
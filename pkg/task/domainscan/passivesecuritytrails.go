@@ -0,0 +1,55 @@
+package domainscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"strings"
+)
+
+const securityTrailsName = "securitytrails"
+
+// SecurityTrails 基于SecurityTrails被动DNS的子域名数据源
+type SecurityTrails struct {
+	BaseURL string
+	apiKey  string
+	limiter *passiveRateLimiter
+}
+
+type securityTrailsResult struct {
+	Subdomains []string `json:"subdomains"`
+	Message    string   `json:"message"`
+}
+
+// NewSecurityTrails 创建SecurityTrails数据源
+func NewSecurityTrails(config conf.PassiveSource) *SecurityTrails {
+	return &SecurityTrails{
+		BaseURL: "https://api.securitytrails.com",
+		apiKey:  config.Key,
+		limiter: getPassiveRateLimiter(securityTrailsName, config.RateLimit),
+	}
+}
+
+func (s *SecurityTrails) Name() string {
+	return securityTrailsName
+}
+
+// Query 查询被动DNS记录的子域名，返回的子域名为前缀，需要拼接根域名
+func (s *SecurityTrails) Query(domain string) (subdomains []string, err error) {
+	queryUrl := fmt.Sprintf("%s/v1/domain/%s/subdomains?children_only=false", strings.TrimSuffix(s.BaseURL, "/"), domain)
+	content, err := passiveHttpGet(s.limiter, queryUrl, map[string]string{"APIKEY": s.apiKey, "Accept": "application/json"})
+	if err != nil {
+		return nil, err
+	}
+	var result securityTrailsResult
+	if err = json.Unmarshal(content, &result); err != nil {
+		return nil, err
+	}
+	if result.Message != "" && len(result.Subdomains) == 0 {
+		return nil, fmt.Errorf("securitytrails:%s", result.Message)
+	}
+	for _, prefix := range result.Subdomains {
+		subdomains = append(subdomains, fmt.Sprintf("%s.%s", prefix, domain))
+	}
+	return
+}
@@ -0,0 +1,52 @@
+package domainscan
+
+import (
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"net/url"
+	"strings"
+)
+
+const waybackName = "wayback"
+
+// Wayback 基于web.archive.org历史URL归档的子域名数据源
+type Wayback struct {
+	BaseURL string
+	limiter *passiveRateLimiter
+}
+
+// NewWayback 创建Wayback数据源
+func NewWayback(config conf.PassiveSource) *Wayback {
+	return &Wayback{
+		BaseURL: "https://web.archive.org",
+		limiter: getPassiveRateLimiter(waybackName, config.RateLimit),
+	}
+}
+
+func (w *Wayback) Name() string {
+	return waybackName
+}
+
+// Query 查询归档的URL，从中提取出主机名
+func (w *Wayback) Query(domain string) (subdomains []string, err error) {
+	queryUrl := fmt.Sprintf("%s/cdx/search/cdx?url=*.%s/*&output=txt&fl=original&collapse=urlkey", strings.TrimSuffix(w.BaseURL, "/"), domain)
+	content, err := passiveHttpGet(w.limiter, queryUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.Contains(line, "://") {
+			line = "http://" + line
+		}
+		u, err1 := url.Parse(line)
+		if err1 != nil {
+			continue
+		}
+		subdomains = append(subdomains, u.Hostname())
+	}
+	return
+}
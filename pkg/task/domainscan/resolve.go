@@ -2,7 +2,6 @@ package domainscan
 
 import (
 	"github.com/hanc00l/nemo_go/pkg/conf"
-	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"github.com/remeh/sizedwaitgroup"
@@ -11,24 +10,36 @@ import (
 )
 
 type Resolve struct {
-	Config Config
-	Result Result
+	Config   Config
+	Result   Result
+	wildcard *WildcardDetector
+	roots    []string
 }
 
 // NewResolve 创建resolve对象
 func NewResolve(config Config) *Resolve {
-	return &Resolve{Config: config}
+	return &Resolve{Config: config, wildcard: NewWildcardDetector()}
 }
 
 // Do 执行域名解析
 func (r *Resolve) Do() {
 	swg := sizedwaitgroup.New(resolveThreadNumber[conf.WorkerPerformanceMode])
 	blackDomain := custom.NewBlackTargetCheck(custom.CheckDomain)
+	for _, line := range strings.Split(r.Config.Target, ",") {
+		if domain := strings.TrimSpace(line); domain != "" && !utils.CheckIPOrSubnet(domain) {
+			r.roots = append(r.roots, domain)
+		}
+	}
 	// 如果Result中已有map[domain]*DomainResult，则遍历并解析域名
 	if r.Result.DomainResult != nil {
-		for domain, _ := range r.Result.DomainResult {
+		// 解析过程中会删除泛解析的域名，先获取待解析的域名列表
+		var domains []string
+		for domain := range r.Result.DomainResult {
+			domains = append(domains, domain)
+		}
+		for _, domain := range domains {
 			if blackDomain.CheckBlack(domain) {
-				logging.RuntimeLog.Warningf("%s is in blacklist,skip...", domain)
+				r.Config.log().Warningf("%s is in blacklist,skip...", domain)
 				continue
 			}
 			swg.Add()
@@ -46,7 +57,7 @@ func (r *Resolve) Do() {
 				continue
 			}
 			if blackDomain.CheckBlack(domain) {
-				logging.RuntimeLog.Warningf("%s is in blacklist,skip...", domain)
+				r.Config.log().Warningf("%s is in blacklist,skip...", domain)
 				continue
 			}
 			swg.Add()
@@ -57,14 +68,28 @@ func (r *Resolve) Do() {
 		}
 	}
 	swg.Wait()
+	// 在根域名上标记泛解析
+	for _, root := range r.roots {
+		if zones := r.wildcard.WildcardZones(root); len(zones) > 0 {
+			r.Result.SetWildcard(root, zones)
+		}
+	}
 }
 
 // RunResolve 解析并保存一个域名结果
 func (r *Resolve) RunResolve(domain string) {
+	cname, host := ResolveDomain(domain)
+	// 丢弃与泛解析指纹一致的域名
+	if r.wildcard != nil && r.wildcard.Match(domain, r.wildcard.RootDomain(domain, r.roots), cname, host) {
+		r.Config.log().Debugf("%s matches wildcard dns,skip...", domain)
+		r.Result.Lock()
+		delete(r.Result.DomainResult, domain)
+		r.Result.Unlock()
+		return
+	}
 	if !r.Result.HasDomain(domain) {
 		r.Result.SetDomain(domain)
 	}
-	_, host := ResolveDomain(domain)
 	if len(host) > 0 {
 		for _, h := range host {
 			dar := DomainAttrResult{
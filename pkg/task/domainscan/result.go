@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/fulltext"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
+	"github.com/hanc00l/nemo_go/pkg/task/urlscan"
 	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/sirupsen/logrus"
 	"strings"
 	"sync"
 )
@@ -21,6 +24,7 @@ var (
 	massdnsThreadNumber   = make(map[string]int)
 	massdnsRunnerThreads  = make(map[string]int)
 	crawlerThreadNumber   = make(map[string]int)
+	dnsRecordThreadNumber = make(map[string]int)
 )
 
 // Config 端口扫描的参数配置
@@ -29,7 +33,10 @@ type Config struct {
 	OrgId              *int   `json:"orgId"`
 	IsSubDomainFinder  bool   `json:"subfinder"`
 	IsSubDomainBrute   bool   `json:"subdomainBrute"`
+	SubDomainBruteMode string `json:"subdomainBruteMode"`
 	IsCrawler          bool   `json:"crawler"`
+	IsSubDomainPassive bool   `json:"subdomainPassive"`
+	IsDNSRecord        bool   `json:"dnsrecord"`
 	IsHttpx            bool   `json:"httpx"`
 	IsIPPortScan       bool   `json:"portscan"`
 	IsIPSubnetPortScan bool   `json:"subnetPortscan"`
@@ -40,6 +47,14 @@ type Config struct {
 	IsIgnoreCDN        bool   `json:"ignorecdn"`
 	IsIgnoreOutofChina bool   `json:"ignoreoutofchina"`
 	WorkspaceId        int    `json:"workspaceId"`
+	// 任务ID，用于关联任务日志
+	TaskId     string `json:"-"`
+	MainTaskId string `json:"-"`
+}
+
+// log 任务的日志
+func (c *Config) log() *logrus.Entry {
+	return logging.NewTaskLog(c.TaskId, c.MainTaskId, c.WorkspaceId)
 }
 
 // DomainAttrResult 域名属性结果
@@ -68,8 +83,8 @@ type DomainResult struct {
 // Result 域名结果
 type Result struct {
 	sync.RWMutex
-	DomainResult    map[string]*DomainResult
-	ReqResponseList []UrlResponse
+	DomainResult map[string]*DomainResult
+	UrlResult    []urlscan.UrlResult
 }
 
 func init() {
@@ -87,6 +102,9 @@ func init() {
 	//
 	crawlerThreadNumber[conf.HighPerformance] = 2
 	crawlerThreadNumber[conf.NormalPerformance] = 1
+	//
+	dnsRecordThreadNumber[conf.HighPerformance] = 20
+	dnsRecordThreadNumber[conf.NormalPerformance] = 10
 
 }
 
@@ -123,6 +141,7 @@ func (r *Result) SetHttpInfo(domain string, result HttpResult) {
 func (r *Result) SaveResult(config Config) string {
 	var resultDomainCount int
 	var newDomain int
+	var docs []fulltext.Document
 	blackDomain := custom.NewBlackTargetCheck(custom.CheckDomain)
 	for domainName, domainResult := range r.DomainResult {
 		if blackDomain.CheckBlack(domainName) {
@@ -155,7 +174,9 @@ func (r *Result) SaveResult(config Config) string {
 			} else {
 				domainAttr.Content = domainAttrResult.Content
 			}
-			domainAttr.SaveOrUpdate()
+			if domainAttr.SaveOrUpdate() {
+				docs = append(docs, fulltext.NewDomainAttrDocument(domainName, domainAttr))
+			}
 		}
 		//save http info
 		for _, httpInfoResult := range domainResult.HttpInfo {
@@ -170,9 +191,12 @@ func (r *Result) SaveResult(config Config) string {
 			} else {
 				httpInfo.Content = httpInfoResult.Content
 			}
-			httpInfo.SaveOrUpdate()
+			if httpInfo.SaveOrUpdate() {
+				docs = append(docs, fulltext.NewDomainHttpDocument(domainName, httpInfo))
+			}
 		}
 	}
+	fulltext.IndexWorkspace(config.WorkspaceId, docs)
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("domain:%d", resultDomainCount))
 	if newDomain > 0 {
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"github.com/remeh/sizedwaitgroup"
@@ -35,7 +36,7 @@ func (s *SubFinder) Do() {
 			continue
 		}
 		if blackDomain.CheckBlack(domain) {
-			logging.RuntimeLog.Warningf("%s is in blacklist,skip...", domain)
+			s.Config.log().Warningf("%s is in blacklist,skip...", domain)
 			continue
 		}
 		swg.Add()
@@ -66,15 +67,16 @@ func (s *SubFinder) RunSubFinder(domain string) {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = &stderr
 	err := cmd.Run()
+	metrics.ObserveToolExit("subfinder", err)
 	if err != nil {
-		logging.RuntimeLog.Error(err, stderr)
+		s.Config.log().Error(err, stderr)
 		logging.CLILog.Error(err, stderr)
 		return
 	}
 	//读取结果
 	data, err := os.ReadFile(resultTempFile)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		s.Config.log().Error(err)
 		logging.CLILog.Error(err)
 		return
 	}
@@ -85,7 +87,7 @@ func (s *SubFinder) RunSubFinder(domain string) {
 func (s *SubFinder) parseResult(outputTempFile string) {
 	content, err := os.ReadFile(outputTempFile)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		s.Config.log().Error(err)
 		logging.CLILog.Error(err)
 		return
 	}
@@ -102,7 +104,7 @@ func (s *SubFinder) parseResultContent(content []byte) {
 			continue
 		}
 		if blackDomain.CheckBlack(domain) {
-			logging.RuntimeLog.Warningf("%s is in blacklist,skip...", domain)
+			s.Config.log().Warningf("%s is in blacklist,skip...", domain)
 			continue
 		}
 		if !s.Result.HasDomain(domain) {
@@ -0,0 +1,227 @@
+package domainscan
+
+import (
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// WildcardAttrTag 根域名存在泛解析时在属性中的Tag，Content为泛解析的区域（如*.example.com）
+	WildcardAttrTag = "wildcard"
+	// wildcardProbeNumber 每个区域使用随机标签探测的次数
+	wildcardProbeNumber = 3
+)
+
+// wildcardFingerprint 一个区域的泛解析指纹
+type wildcardFingerprint struct {
+	IsWildcard bool
+	IPs        map[string]struct{}
+	CNames     map[string]struct{}
+}
+
+// WildcardDetector 按区域（每一级子域）使用随机标签探测泛解析，并过滤与泛解析指纹一致的解析结果
+type WildcardDetector struct {
+	sync.Mutex
+	// lookup 域名解析函数，返回CNAME及A/AAAA记录
+	lookup func(domain string) (CName string, Host []string)
+	zones  map[string]*wildcardFingerprint
+	// zoneLocks 同一区域只探测一次
+	zoneLocks map[string]*sync.Mutex
+	tldOnce   sync.Once
+	tld       TldExtract
+}
+
+// NewWildcardDetector 创建泛解析检测对象
+func NewWildcardDetector() *WildcardDetector {
+	return &WildcardDetector{
+		lookup:    ResolveDomain,
+		zones:     make(map[string]*wildcardFingerprint),
+		zoneLocks: make(map[string]*sync.Mutex),
+	}
+}
+
+// HasWildcard 检查域名在根域名下的任意一级区域是否存在泛解析
+func (w *WildcardDetector) HasWildcard(domain, root string) bool {
+	for _, zone := range wildcardZones(domain, root) {
+		if w.probe(zone).IsWildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWildcardDomain 解析域名并检查其结果是否与泛解析指纹一致；区域不存在泛解析时不进行解析
+func (w *WildcardDetector) IsWildcardDomain(domain, root string) bool {
+	if !w.HasWildcard(domain, root) {
+		return false
+	}
+	cname, hosts := w.lookup(domain)
+	return w.Match(domain, root, cname, hosts)
+}
+
+// Match 检查域名的解析结果是否与所在区域的泛解析指纹一致
+func (w *WildcardDetector) Match(domain, root string, cname string, hosts []string) bool {
+	domain = strings.ToLower(domain)
+	cname = normalizeCName(domain, cname)
+	for _, zone := range wildcardZones(domain, root) {
+		fp := w.probe(zone)
+		if !fp.IsWildcard {
+			continue
+		}
+		if cname != "" {
+			if _, ok := fp.CNames[cname]; ok {
+				return true
+			}
+		}
+		if len(hosts) == 0 {
+			continue
+		}
+		matched := true
+		for _, h := range hosts {
+			if _, ok := fp.IPs[normalizeHost(h)]; !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// WildcardZones 返回已探测到泛解析的根域名及其子区域（*.zone格式）
+func (w *WildcardDetector) WildcardZones(root string) (zones []string) {
+	w.Lock()
+	defer w.Unlock()
+
+	root = strings.ToLower(root)
+	for zone, fp := range w.zones {
+		if fp.IsWildcard && (zone == root || strings.HasSuffix(zone, "."+root)) {
+			zones = append(zones, fmt.Sprintf("*.%s", zone))
+		}
+	}
+	sort.Strings(zones)
+	return
+}
+
+// probe 使用随机标签探测一个区域的泛解析指纹，结果缓存
+func (w *WildcardDetector) probe(zone string) *wildcardFingerprint {
+	w.Lock()
+	if fp, ok := w.zones[zone]; ok {
+		w.Unlock()
+		return fp
+	}
+	if _, ok := w.zoneLocks[zone]; !ok {
+		w.zoneLocks[zone] = &sync.Mutex{}
+	}
+	zoneLock := w.zoneLocks[zone]
+	w.Unlock()
+
+	zoneLock.Lock()
+	defer zoneLock.Unlock()
+	// 等待锁期间可能已完成探测
+	w.Lock()
+	fp, ok := w.zones[zone]
+	w.Unlock()
+	if ok {
+		return fp
+	}
+
+	fp = &wildcardFingerprint{IPs: make(map[string]struct{}), CNames: make(map[string]struct{})}
+	for i := 0; i < wildcardProbeNumber; i++ {
+		randomDomain := fmt.Sprintf("%s.%s", utils.GetRandomString2(16), zone)
+		cname, hosts := w.lookup(randomDomain)
+		if cname = normalizeCName(randomDomain, cname); cname != "" {
+			fp.CNames[cname] = struct{}{}
+			fp.IsWildcard = true
+		}
+		for _, h := range hosts {
+			fp.IPs[normalizeHost(h)] = struct{}{}
+			fp.IsWildcard = true
+		}
+	}
+	w.Lock()
+	w.zones[zone] = fp
+	w.Unlock()
+
+	return fp
+}
+
+// wildcardZones 返回域名在根域名下需要探测的各级区域，如a.b.example.com返回example.com、b.example.com
+func wildcardZones(domain, root string) (zones []string) {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	root = strings.ToLower(strings.TrimSuffix(root, "."))
+	if root == "" || domain == root || !strings.HasSuffix(domain, "."+root) {
+		return
+	}
+	labels := strings.Split(strings.TrimSuffix(domain, "."+root), ".")
+	zone := root
+	zones = append(zones, zone)
+	for i := len(labels) - 1; i > 0; i-- {
+		zone = fmt.Sprintf("%s.%s", labels[i], zone)
+		zones = append(zones, zone)
+	}
+	return
+}
+
+// normalizeCName 规范化CNAME，无CNAME记录时（返回域名本身）返回空
+func normalizeCName(domain, cname string) string {
+	cname = strings.ToLower(strings.TrimSuffix(cname, "."))
+	if cname == strings.ToLower(strings.TrimSuffix(domain, ".")) {
+		return ""
+	}
+	return cname
+}
+
+func normalizeHost(host string) string {
+	if utils.CheckIPV6(host) {
+		return utils.GetIPV6ParsedFormat(host)
+	}
+	return host
+}
+
+// RootDomain 从任务的目标中查找域名所属的根域名，未找到时返回域名的FLD
+func (w *WildcardDetector) RootDomain(domain string, roots []string) (root string) {
+	for _, r := range roots {
+		if (domain == r || strings.HasSuffix(domain, "."+r)) && len(r) > len(root) {
+			root = r
+		}
+	}
+	if root == "" {
+		w.tldOnce.Do(func() {
+			w.tld = NewTldExtract()
+		})
+		root = w.tld.ExtractFLD(domain)
+	}
+	return
+}
+
+// SetWildcard 在根域名的结果中标记泛解析
+func (r *Result) SetWildcard(root string, zones []string) {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, ok := r.DomainResult[root]; !ok {
+		r.DomainResult[root] = &DomainResult{DomainAttrs: []DomainAttrResult{}}
+	}
+	for _, zone := range zones {
+		var exist bool
+		for _, dar := range r.DomainResult[root].DomainAttrs {
+			if dar.Tag == WildcardAttrTag && dar.Content == zone {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			r.DomainResult[root].DomainAttrs = append(r.DomainResult[root].DomainAttrs, DomainAttrResult{
+				Source:  "domainscan",
+				Tag:     WildcardAttrTag,
+				Content: zone,
+			})
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package domainscan
+
+import (
+	"strings"
+	"testing"
+)
+
+// newTestWildcardDetector 模拟*.example.com解析到1.1.1.1、*.cdn.example.com CNAME到wildcard.cdn.net的解析
+func newTestWildcardDetector() *WildcardDetector {
+	w := NewWildcardDetector()
+	w.lookup = func(domain string) (string, []string) {
+		switch domain {
+		case "www.example.com":
+			return domain + ".", []string{"2.2.2.2"}
+		case "api.example.com":
+			return domain + ".", []string{"1.1.1.1", "2.2.2.2"}
+		case "static.cdn.example.com":
+			return "static.cdn.net.", []string{"3.3.3.3"}
+		case "www.other.com":
+			return domain + ".", []string{"4.4.4.4"}
+		}
+		if strings.HasSuffix(domain, ".cdn.example.com") {
+			return "wildcard.cdn.net.", []string{"5.5.5.5"}
+		}
+		if strings.HasSuffix(domain, ".example.com") {
+			return domain + ".", []string{"1.1.1.1"}
+		}
+		return domain + ".", nil
+	}
+	return w
+}
+
+func TestWildcardDetector_IsWildcardDomain(t *testing.T) {
+	w := newTestWildcardDetector()
+	for domain, expected := range map[string]bool{
+		"www.example.com":         false,
+		"api.example.com":         false,
+		"bogus.example.com":       true,
+		"a.b.example.com":         true,
+		"static.cdn.example.com":  false,
+		"bogus.cdn.example.com":   true,
+		"www.other.com":           false,
+		"example.com":             false,
+		"notasubdomain.other.com": false,
+	} {
+		if got := w.IsWildcardDomain(domain, "example.com"); got != expected {
+			t.Errorf("%s: got %v,expected %v", domain, got, expected)
+		}
+	}
+	zones := w.WildcardZones("example.com")
+	if strings.Join(zones, ",") != "*.cdn.example.com,*.example.com" {
+		t.Errorf("invalid wildcard zones:%v", zones)
+	}
+	if len(w.WildcardZones("other.com")) != 0 {
+		t.Error("other.com should not be wildcard")
+	}
+}
+
+func TestWildcardZones(t *testing.T) {
+	if zones := wildcardZones("a.b.example.com", "example.com"); strings.Join(zones, ",") != "example.com,b.example.com" {
+		t.Errorf("invalid zones:%v", zones)
+	}
+	if zones := wildcardZones("example.com", "example.com"); len(zones) != 0 {
+		t.Errorf("invalid zones:%v", zones)
+	}
+}
+
+func TestResult_SetWildcard(t *testing.T) {
+	r := Result{DomainResult: make(map[string]*DomainResult)}
+	r.SetWildcard("example.com", []string{"*.example.com"})
+	r.SetWildcard("example.com", []string{"*.example.com", "*.cdn.example.com"})
+	if len(r.DomainResult["example.com"].DomainAttrs) != 2 {
+		t.Errorf("invalid wildcard attrs:%v", r.DomainResult["example.com"].DomainAttrs)
+	}
+}
@@ -0,0 +1,277 @@
+package fingerprint
+
+import (
+	"context"
+	"errors"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"log"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultBrowserTimeout = 20 * time.Second
+	defaultBrowserDelay   = 5 * time.Second
+	// maxPageTextLength 保存的页面文本的最大长度
+	maxPageTextLength = 16 * 1024
+)
+
+var (
+	browserPool     *BrowserPool
+	browserPoolOnce sync.Once
+	// proxyBrowserPools 使用代理的浏览器池，chrome的代理在启动时指定，每个代理使用一个浏览器进程
+	proxyBrowserPools      = make(map[string]*BrowserPool)
+	proxyBrowserPoolsMutex sync.Mutex
+)
+
+// PageCapture 页面截图及跳转后的URL、标题与文本内容
+type PageCapture struct {
+	FinalURL string
+	Title    string
+	Text     string
+	Image    []byte
+}
+
+// BrowserPool 常驻的headless chrome：所有截图共用一个浏览器进程，每个目标打开一个标签页；
+// 标签页的数量受MaxTabs限制，浏览器崩溃或断开连接后在下次使用时重新启动
+type BrowserPool struct {
+	MaxTabs int
+	// Timeout 每个标签页（从打开页面到截图完成）的超时时间
+	Timeout time.Duration
+	// Delay 页面加载完成后的等待时间，等待js跳转及动态内容
+	Delay time.Duration
+	// Proxy 浏览器使用的代理，为空时直接访问
+	Proxy string
+
+	tabs          chan struct{}
+	mutex         sync.Mutex
+	allocCancel   context.CancelFunc
+	browserCtx    context.Context
+	browserCancel context.CancelFunc
+}
+
+// NewBrowserPool 创建浏览器池，浏览器在第一次截图时启动
+func NewBrowserPool(maxTabs int, timeout, delay time.Duration) *BrowserPool {
+	if maxTabs <= 0 {
+		maxTabs = fpScreenshotThreadNum[conf.NormalPerformance]
+	}
+	if timeout <= 0 {
+		timeout = defaultBrowserTimeout
+	}
+	if delay < 0 {
+		delay = defaultBrowserDelay
+	}
+	return &BrowserPool{
+		MaxTabs: maxTabs,
+		Timeout: timeout,
+		Delay:   delay,
+		tabs:    make(chan struct{}, maxTabs),
+	}
+}
+
+// GetBrowserPool 获取worker共用的浏览器池
+func GetBrowserPool() *BrowserPool {
+	browserPoolOnce.Do(func() {
+		browserPool = newWorkerBrowserPool()
+	})
+	return browserPool
+}
+
+// GetBrowserPoolByProxy 获取使用指定代理的浏览器池，proxy为空时为worker共用的浏览器池
+func GetBrowserPoolByProxy(proxy string) *BrowserPool {
+	if proxy == "" {
+		return GetBrowserPool()
+	}
+	proxyBrowserPoolsMutex.Lock()
+	defer proxyBrowserPoolsMutex.Unlock()
+
+	p, ok := proxyBrowserPools[proxy]
+	if !ok {
+		p = newWorkerBrowserPool()
+		p.Proxy = proxy
+		proxyBrowserPools[proxy] = p
+	}
+	return p
+}
+
+// newWorkerBrowserPool 根据worker的配置创建浏览器池
+func newWorkerBrowserPool() *BrowserPool {
+	c := conf.GlobalWorkerConfig().Fingerprint.Screenshot
+	maxTabs := c.MaxTabs
+	if maxTabs <= 0 {
+		maxTabs = fpScreenshotThreadNum[conf.WorkerPerformanceMode]
+	}
+	delay := defaultBrowserDelay
+	if c.Delay > 0 {
+		delay = time.Duration(c.Delay) * time.Second
+	}
+	return NewBrowserPool(maxTabs, time.Duration(c.Timeout)*time.Second, delay)
+}
+
+// CloseBrowserPool 关闭worker共用的浏览器及使用代理的浏览器
+func CloseBrowserPool() {
+	if browserPool != nil {
+		browserPool.Close()
+	}
+	proxyBrowserPoolsMutex.Lock()
+	defer proxyBrowserPoolsMutex.Unlock()
+	for _, p := range proxyBrowserPools {
+		p.Close()
+	}
+}
+
+// Capture 打开一个标签页访问url，截图并获取页面信息
+func (p *BrowserPool) Capture(url string) (capture *PageCapture, err error) {
+	p.tabs <- struct{}{}
+	defer func() { <-p.tabs }()
+
+	browserCtx, err := p.browser()
+	if err != nil {
+		return nil, err
+	}
+	tabCtx, cancel := chromedp.NewContext(browserCtx)
+	defer cancel()
+	ctx, cancelTimeout := context.WithTimeout(tabCtx, p.Timeout)
+	defer cancelTimeout()
+
+	capture = &PageCapture{}
+	if err = chromedp.Run(ctx, p.captureTasks(url, capture)); err != nil {
+		p.checkCrash(browserCtx)
+		return nil, err
+	}
+	if len(capture.Image) == 0 {
+		return nil, errors.New("empty screenshot")
+	}
+	return capture, nil
+}
+
+// Close 关闭浏览器进程
+func (p *BrowserPool) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.close()
+}
+
+// browser 获取浏览器的context，浏览器未启动或已崩溃时（重新）启动
+func (p *BrowserPool) browser() (context.Context, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.alive() {
+		return p.browserCtx, nil
+	}
+	if p.browserCtx != nil {
+		logging.RuntimeLog.Warning("headless-chrome lost connection,restarting...")
+	}
+	p.close()
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), browserOptions(p.Proxy)...)
+	ctx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
+	// 启动浏览器进程
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		allocCancel()
+		logging.RuntimeLog.Errorf("start headless-chrome fail:%v", err)
+		return nil, err
+	}
+	p.allocCancel, p.browserCtx, p.browserCancel = allocCancel, ctx, cancel
+	return p.browserCtx, nil
+}
+
+// alive 浏览器是否正常运行
+func (p *BrowserPool) alive() bool {
+	if p.browserCtx == nil || p.browserCtx.Err() != nil {
+		return false
+	}
+	c := chromedp.FromContext(p.browserCtx)
+	if c == nil || c.Browser == nil {
+		return false
+	}
+	select {
+	case <-c.Browser.LostConnection:
+		return false
+	default:
+		return true
+	}
+}
+
+// checkCrash 截图失败后检查浏览器是否已崩溃，崩溃时关闭以便下次重新启动
+func (p *BrowserPool) checkCrash(browserCtx context.Context) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.browserCtx == browserCtx && !p.alive() {
+		logging.RuntimeLog.Warning("headless-chrome crashed")
+		p.close()
+	}
+}
+
+// close 关闭浏览器，调用者需持有锁
+func (p *BrowserPool) close() {
+	if p.browserCancel != nil {
+		p.browserCancel()
+	}
+	if p.allocCancel != nil {
+		p.allocCancel()
+	}
+	p.allocCancel, p.browserCtx, p.browserCancel = nil, nil, nil
+}
+
+// captureTasks 访问页面，等待跳转后获取最终的URL、标题、文本并截图
+func (p *BrowserPool) captureTasks(url string, capture *PageCapture) chromedp.Tasks {
+	return chromedp.Tasks{
+		chromedp.Navigate(url),
+		//延时：等待有些页面有js自动跳转，待js跳转后再执行截图操作
+		chromedp.Sleep(p.Delay),
+		chromedp.Location(&capture.FinalURL),
+		chromedp.Title(&capture.Title),
+		chromedp.Evaluate(`document.body ? document.body.innerText : ""`, &capture.Text),
+		chromedp.ActionFunc(func(ctx context.Context) (err error) {
+			capture.Text = truncateText(capture.Text, maxPageTextLength)
+			capture.Image, err = page.CaptureScreenshot().WithQuality(100).WithClip(&page.Viewport{
+				X:      0,
+				Y:      0,
+				Width:  MaxWidth,
+				Height: MinHeight,
+				Scale:  1,
+			}).Do(ctx)
+			return err
+		}),
+	}
+}
+
+// browserOptions headless chrome的启动参数，proxy不为空时通过代理访问
+func browserOptions(proxy string) []chromedp.ExecAllocatorOption {
+	options := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", true),
+		chromedp.Flag("ignore-certificate-errors", true),
+		chromedp.Flag("disable-gpu", true),
+		chromedp.Flag("disable-crash-reporter", true),
+		chromedp.Flag("disable-notifications", true),
+		chromedp.Flag("hide-scrollbars", true),
+		chromedp.Flag("mute-audio", true),
+		chromedp.Flag("incognito", true),
+		chromedp.Flag("enable-features", "NetworkService"),
+		chromedp.UserAgent(`Mozilla/5.0 (Windows NT 6.3; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/73.0.3683.103 Safari/537.36`),
+		chromedp.WindowSize(MaxWidth, MinHeight),
+	)
+	if proxy != "" {
+		options = append(options, chromedp.ProxyServer(proxy))
+	}
+	return options
+}
+
+// truncateText 按字节截断文本，不截断多字节字符
+func truncateText(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+	for maxLength > 0 && !utf8.RuneStart(s[maxLength]) {
+		maxLength--
+	}
+	return s[:maxLength]
+}
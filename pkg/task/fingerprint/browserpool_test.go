@@ -0,0 +1,49 @@
+package fingerprint
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTruncateText(t *testing.T) {
+	if s := truncateText("nemo", 10); s != "nemo" {
+		t.Errorf("unexpected text:%s", s)
+	}
+	// 不截断多字节字符
+	if s := truncateText("登录页面", 7); s != "登录" {
+		t.Errorf("unexpected text:%s", s)
+	}
+}
+
+func TestBrowserPool_Capture(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/login" {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		io.WriteString(w, "<html><head><title>Nemo Login</title></head><body><h1>Welcome admin</h1></body></html>")
+	}))
+	defer server.Close()
+
+	p := NewBrowserPool(2, 10*time.Second, 0)
+	defer p.Close()
+	if _, err := p.browser(); err != nil {
+		t.Skip(err)
+	}
+	capture, err := p.Capture(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capture.FinalURL != server.URL+"/login" || capture.Title != "Nemo Login" || !strings.Contains(capture.Text, "Welcome admin") || len(capture.Image) == 0 {
+		t.Errorf("unexpected capture:%s %s %s", capture.FinalURL, capture.Title, capture.Text)
+	}
+	// 浏览器退出后重新启动
+	p.browserCancel()
+	if _, err = p.Capture(server.URL); err != nil {
+		t.Errorf("browser should be restarted:%v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
@@ -16,6 +17,7 @@ import (
 )
 
 type FingerprintHub struct {
+	TaskInfo
 	ResultPortScan   *portscan.Result
 	ResultDomainScan *domainscan.Result
 	DomainTargetPort map[string]map[int]struct{}
@@ -44,7 +46,7 @@ func (f *FingerprintHub) Do() {
 	if f.ResultPortScan != nil && f.ResultPortScan.IPResult != nil {
 		for ipName, ipResult := range f.ResultPortScan.IPResult {
 			if btc.CheckBlack(ipName) {
-				logging.RuntimeLog.Warningf("%s is in blacklist,skip...", ipName)
+				f.log().Warningf("%s is in blacklist,skip...", ipName)
 				continue
 			}
 			for portNumber := range ipResult.Ports {
@@ -83,7 +85,7 @@ func (f *FingerprintHub) Do() {
 		}
 		for domain := range f.ResultDomainScan.DomainResult {
 			if btc.CheckBlack(domain) {
-				logging.RuntimeLog.Warningf("%s is in blacklist,skip...", domain)
+				f.log().Warningf("%s is in blacklist,skip...", domain)
 				continue
 			}
 			//如果无域名对应的端口，默认80和443
@@ -142,8 +144,9 @@ func (f *FingerprintHub) RunObserverWard(url string) []FingerprintHubReult {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = &stderr
 	err := cmd.Run()
+	metrics.ObserveToolExit("observerward", err)
 	if err != nil {
-		logging.RuntimeLog.Error(err, stderr)
+		f.log().Error(err, stderr)
 		logging.CLILog.Error(err, stderr)
 		return nil
 	}
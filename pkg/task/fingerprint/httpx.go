@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/task/urlscan"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"github.com/remeh/sizedwaitgroup"
 	"os"
@@ -19,6 +22,7 @@ import (
 )
 
 type Httpx struct {
+	TaskInfo
 	ResultPortScan   *portscan.Result
 	ResultDomainScan *domainscan.Result
 	DomainTargetPort map[string]map[int]struct{}
@@ -66,6 +70,8 @@ type HttpxResult struct {
 	Port               string   `json:"port,omitempty"`
 	Title              string   `json:"title,omitempty"`
 	WebServer          string   `json:"webserver,omitempty"`
+	Path               string   `json:"path,omitempty"`
+	Method             string   `json:"method,omitempty"`
 	ContentType        string   `json:"content_type,omitempty"`
 	StatusCode         int      `json:"status_code,omitempty"`
 	TLSData            *TLS     `json:"tls,omitempty"`
@@ -93,7 +99,7 @@ func (x *Httpx) Do() {
 	if x.ResultPortScan != nil && x.ResultPortScan.IPResult != nil {
 		for ipName, ipResult := range x.ResultPortScan.IPResult {
 			if btc.CheckBlack(ipName) {
-				logging.RuntimeLog.Warningf("%s is in blacklist,skip...", ipName)
+				x.log().Warningf("%s is in blacklist,skip...", ipName)
 				continue
 			}
 			for portNumber, _ := range ipResult.Ports {
@@ -145,7 +151,7 @@ func (x *Httpx) Do() {
 		}
 		for domain := range x.ResultDomainScan.DomainResult {
 			if btc.CheckBlack(domain) {
-				logging.RuntimeLog.Warningf("%s is in blacklist,skip...", domain)
+				x.log().Warningf("%s is in blacklist,skip...", domain)
 				continue
 			}
 			//如果无域名对应的端口，默认80和443
@@ -205,7 +211,7 @@ func (x *Httpx) RunHttpx(domain string) (result []FingerAttrResult, storedRespon
 	defer os.Remove(inputTempFile)
 	err := os.WriteFile(inputTempFile, []byte(domain), 0666)
 	if err != nil {
-		logging.RuntimeLog.Error(err.Error())
+		x.log().Error(err.Error())
 		logging.CLILog.Error(err)
 		return nil, ""
 	}
@@ -220,18 +226,26 @@ func (x *Httpx) RunHttpx(domain string) (result []FingerAttrResult, storedRespon
 			"-store-response",
 			"-store-response-dir", x.StoreResponseDirectory)
 	}
+	proxy := proxypool.Get(proxypool.TaskHttpx)
+	if proxy != nil {
+		cmdArgs = append(cmdArgs, "-http-proxy", proxy.URL)
+	}
 	binPath := filepath.Join(conf.GetRootPath(), "thirdparty/httpx", utils.GetThirdpartyBinNameByPlatform(utils.Httpx))
 	cmd := exec.Command(binPath, cmdArgs...)
 	var stderr bytes.Buffer
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = &stderr
 	err = cmd.Run()
+	metrics.ObserveToolExit("httpx", err)
 	if err != nil {
-		logging.RuntimeLog.Error(err, stderr)
+		x.log().Error(err, stderr)
 		logging.CLILog.Error(err, stderr)
 		return nil, ""
 	}
 	result, storedResponsePathFile = x.parseHttpxResult(resultTempFile)
+	if proxy != nil && len(result) > 0 {
+		result = append(result, FingerAttrResult{Tag: proxypool.EgressTag, Content: proxy.Egress})
+	}
 	return
 }
 
@@ -240,7 +254,7 @@ func (x *Httpx) ParseHttpxJson(content []byte) (host string, port int, result []
 	resultJSON := HttpxResult{}
 	err := json.Unmarshal(content, &resultJSON)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		x.log().Error(err)
 		logging.CLILog.Error(err)
 		return
 	}
@@ -248,7 +262,7 @@ func (x *Httpx) ParseHttpxJson(content []byte) (host string, port int, result []
 	host = resultJSON.Host
 	port, err = strconv.Atoi(resultJSON.Port)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		x.log().Error(err)
 		logging.CLILog.Error(err)
 		return
 	}
@@ -299,7 +313,7 @@ func (x *Httpx) parseHttpxResult(outputTempFile string) (result []FingerAttrResu
 	content, err := os.ReadFile(outputTempFile)
 	if err != nil || len(content) == 0 {
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			x.log().Error(err)
 			logging.CLILog.Error(err)
 		}
 		return
@@ -348,3 +362,39 @@ func (x *Httpx) ParseContentResult(content []byte) (result portscan.Result) {
 	}
 	return
 }
+
+// ParseUrlResult 从IP与域名结果中httpx的JSON记录获取URL资产
+func (x *Httpx) ParseUrlResult(ipResult map[string]*portscan.IPResult, domainResult map[string]*domainscan.DomainResult, workspaceId int) (result []urlscan.UrlResult) {
+	var contents []string
+	for _, ip := range ipResult {
+		for _, port := range ip.Ports {
+			for _, par := range port.PortAttrs {
+				if par.Source == "httpx" && par.Tag == "httpx" {
+					contents = append(contents, par.Content)
+				}
+			}
+		}
+	}
+	for _, domain := range domainResult {
+		for _, dar := range domain.DomainAttrs {
+			if dar.Source == "httpx" && dar.Tag == "httpx" {
+				contents = append(contents, dar.Content)
+			}
+		}
+	}
+	for _, content := range contents {
+		resultJSON := HttpxResult{}
+		if err := json.Unmarshal([]byte(content), &resultJSON); err != nil || resultJSON.Url == "" {
+			continue
+		}
+		result = append(result, urlscan.UrlResult{
+			Url:         resultJSON.Url,
+			Method:      resultJSON.Method,
+			ContentType: resultJSON.ContentType,
+			Status:      resultJSON.StatusCode,
+			Source:      urlscan.SourceHttpx,
+			WorkspaceId: workspaceId,
+		})
+	}
+	return
+}
@@ -71,18 +71,13 @@ func (h *HttpxFinger) loadFingerprintHub() {
 	if len(fpWebFingerprintHub) > 0 {
 		return
 	}
-	fingerprintJsonPathFile := path.Join(conf.GetRootPath(), "thirdparty/fingerprinthub", "web_fingerprint_v3.json")
-	fingerContent, err := os.ReadFile(fingerprintJsonPathFile)
+	rules, err := readFingerprintHubRules()
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 		logging.CLILog.Error(err)
 		return
 	}
-	if err = json.Unmarshal(fingerContent, &fpWebFingerprintHub); err != nil {
-		logging.RuntimeLog.Error(err)
-		logging.CLILog.Error(err)
-		return
-	}
+	fpWebFingerprintHub = rules
 	if len(fpWebFingerprintHub) > 0 {
 		h.FingerPrintFunc = append(h.FingerPrintFunc, h.fingerPrintFuncForFingerprintHub)
 		logging.CLILog.Infof("Load fingerprinthub total:%d", len(fpWebFingerprintHub))
@@ -94,26 +89,56 @@ func (h *HttpxFinger) loadCustomFingerprint() {
 	if len(fpCustom) > 0 {
 		return
 	}
-	fingerprintJsonPathFile := path.Join(conf.GetRootPath(), "thirdparty/custom", "web_fingerprint.json")
-	fingerContent, err := os.ReadFile(fingerprintJsonPathFile)
+	rules, err := readCustomFingerprintRules()
 	if err != nil {
-		logging.CLILog.Warning(err)
-		return
-	}
-	if err = json.Unmarshal(fingerContent, &fpCustom); err != nil {
-		logging.RuntimeLog.Error(err)
-		logging.CLILog.Error(err)
+		if os.IsNotExist(err) {
+			logging.CLILog.Warning(err)
+		} else {
+			logging.RuntimeLog.Error(err)
+			logging.CLILog.Error(err)
+		}
 		return
 	}
+	fpCustom = rules
 	if len(fpCustom) > 0 {
 		h.FingerPrintFunc = append(h.FingerPrintFunc, h.fingerPrintFuncForCustom)
 		logging.CLILog.Infof("Load custom web finger total:%d", len(fpCustom))
 	}
 }
 
+// readFingerprintHubRules 读取fingerprinthub的指纹规则
+func readFingerprintHubRules() (rules []WebFingerPrint, err error) {
+	fingerprintJsonPathFile := path.Join(conf.GetRootPath(), "thirdparty/fingerprinthub", "web_fingerprint_v3.json")
+	fingerContent, err := os.ReadFile(fingerprintJsonPathFile)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(fingerContent, &rules)
+	return
+}
+
+// readCustomFingerprintRules 读取自定义的指纹规则
+func readCustomFingerprintRules() (rules []CustomFingerPrint, err error) {
+	fingerprintJsonPathFile := path.Join(conf.GetRootPath(), "thirdparty/custom", "web_fingerprint.json")
+	fingerContent, err := os.ReadFile(fingerprintJsonPathFile)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(fingerContent, &rules)
+	return
+}
+
+// fingerprintRules 获取当前使用的指纹规则；重新加载规则时整体替换，已获取的规则不受影响
+func fingerprintRules() ([]WebFingerPrint, []CustomFingerPrint) {
+	fpMutex.Lock()
+	defer fpMutex.Unlock()
+
+	return fpWebFingerprintHub, fpCustom
+}
+
 // DoHttpxAndFingerPrint 执行指纹识别
 func (h *HttpxFinger) DoHttpxAndFingerPrint() {
-	// 保存响应结果，用于自定义的指纹分析
+	// 保存响应结果，用于自定义的指纹分析；只在worker本地临时使用，不写入storage
 	h.StoreResponseDirectory = utils.GetTempPathDirName()
 	defer os.RemoveAll(h.StoreResponseDirectory)
 	//调用httpx识别指纹
@@ -124,8 +149,19 @@ func (h *HttpxFinger) DoHttpxAndFingerPrint() {
 func (h *HttpxFinger) fingerPrintFuncForFingerprintHub(domain string, ip string, port int, url string, result []FingerAttrResult, storedResponsePathFile string) (fingers []string) {
 	// 读取httpx保存的response内容，并解析为body和headers
 	body, _, headers := h.parseHttpHeaderAndBody(h.getStoredResponseContent(storedResponsePathFile))
-	// 指纹匹配
-	for _, v := range fpWebFingerprintHub {
+	return matchFingerprintHub(body, headers)
+}
+
+// fingerPrintFuncForIceMoon 回调函数，用于处理自己的指纹识别
+func (h *HttpxFinger) fingerPrintFuncForCustom(domain string, ip string, port int, url string, result []FingerAttrResult, storedResponsePathFile string) (fingers []string) {
+	body, header, _ := h.parseHttpHeaderAndBody(h.getStoredResponseContent(storedResponsePathFile))
+	return matchCustomFingerprint(port, body, header, result)
+}
+
+// matchFingerprintHub 对http响应的body与headers进行fingerprinthub的指纹匹配
+func matchFingerprintHub(body string, headers map[string][]string) (fingers []string) {
+	hubRules, _ := fingerprintRules()
+	for _, v := range hubRules {
 		flag := false
 
 		hflag := true
@@ -169,14 +205,11 @@ func (h *HttpxFinger) fingerPrintFuncForFingerprintHub(domain string, ip string,
 			//break
 		}
 	}
-	//fmt.Println(fingers)
 	return
 }
 
-// fingerPrintFuncForIceMoon 回调函数，用于处理自己的指纹识别
-func (h *HttpxFinger) fingerPrintFuncForCustom(domain string, ip string, port int, url string, result []FingerAttrResult, storedResponsePathFile string) (fingers []string) {
-	body, header, _ := h.parseHttpHeaderAndBody(h.getStoredResponseContent(storedResponsePathFile))
-
+// matchCustomFingerprint 对http响应及httpx获取的title、server、tls等属性进行自定义指纹匹配
+func matchCustomFingerprint(port int, body string, header string, result []FingerAttrResult) (fingers []string) {
 	content := xraypocv1.Content{
 		Port:   fmt.Sprintf("%d", port),
 		Body:   body,
@@ -191,11 +224,10 @@ func (h *HttpxFinger) fingerPrintFuncForCustom(domain string, ip string, port in
 			content.Cert = fa.Content
 		}
 	}
-	//fmt.Println(content)
-	for _, v := range fpCustom {
+	_, customRules := fingerprintRules()
+	for _, v := range customRules {
 		rule := xraypocv1.ParseRules(v.Rule)
 		if xraypocv1.MatchRules(*rule, content) {
-			//fmt.Println(v)
 			fingers = append(fingers, v.App)
 		}
 	}
@@ -247,7 +279,7 @@ func (h *HttpxFinger) getStoredResponseContent(storedResponsePathFile string) st
 	content, err := os.ReadFile(storedResponsePathFile)
 	if err != nil || len(content) == 0 {
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			h.log().Error(err)
 			logging.CLILog.Error(err)
 		}
 		return ""
@@ -271,23 +303,30 @@ func (h *HttpxFinger) parseHttpHeaderAndBody(content string) (body string, heade
 	headerAndBodyArrays := strings.Split(content, "\r\n\r\n")
 	if len(headerAndBodyArrays) >= 2 {
 		header = headerAndBodyArrays[1]
-		respHeaderSlice := strings.Split(header, "\r\n")
-		for _, hh := range respHeaderSlice {
-			hslice := strings.SplitN(hh, ":", 2)
-			if len(hslice) != 2 {
-				continue
-			}
-			k := strings.ToLower(hslice[0])
-			v := strings.TrimLeft(hslice[1], " ")
-			if len(headerMap[k]) > 0 {
-				headerMap[k] = append(headerMap[k], v)
-			} else {
-				headerMap[k] = []string{v}
-			}
-		}
+		headerMap = parseHttpHeaderMap(header)
 	}
 	if len(headerAndBodyArrays) >= 3 {
 		body = strings.Join(headerAndBodyArrays[2:], "\r\n\r\n")
 	}
 	return
 }
+
+// parseHttpHeaderMap 将http的header解析为map，key为小写的header名称
+func parseHttpHeaderMap(header string) (headerMap map[string][]string) {
+	headerMap = make(map[string][]string)
+	respHeaderSlice := strings.Split(header, "\r\n")
+	for _, hh := range respHeaderSlice {
+		hslice := strings.SplitN(hh, ":", 2)
+		if len(hslice) != 2 {
+			continue
+		}
+		k := strings.ToLower(hslice[0])
+		v := strings.TrimLeft(hslice[1], " ")
+		if len(headerMap[k]) > 0 {
+			headerMap[k] = append(headerMap[k], v)
+		} else {
+			headerMap[k] = []string{v}
+		}
+	}
+	return
+}
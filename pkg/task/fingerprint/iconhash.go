@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
+	"github.com/hanc00l/nemo_go/pkg/storage"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
@@ -16,13 +18,13 @@ import (
 	"github.com/twmb/murmur3"
 	"hash"
 	"image"
-	"os"
-	"path/filepath"
+	"net/http"
 	"strings"
 	"sync"
 )
 
 type IconHash struct {
+	TaskInfo
 	ResultPortScan     *portscan.Result
 	ResultDomainScan   *domainscan.Result
 	IconHashInfoResult *IconHashInfoResult
@@ -53,6 +55,8 @@ var (
 	// used by github.com/Becivells/iconhash
 	isUint32 = false
 	debug    = false
+	// iconUserAgent besticon缺省的User-Agent
+	iconUserAgent = "Mozilla/5.0 (iPhone; CPU iPhone OS 10_0 like Mac OS X) AppleWebKit/602.1.38 (KHTML, like Gecko) Version/10.0 Mobile/14A5297c Safari/602.1"
 )
 
 func NewIconHash() *IconHash {
@@ -67,7 +71,7 @@ func (i *IconHash) Do() {
 	if i.ResultPortScan != nil && i.ResultPortScan.IPResult != nil {
 		for ipName, ipResult := range i.ResultPortScan.IPResult {
 			if btc.CheckBlack(ipName) {
-				logging.RuntimeLog.Warningf("%s is in blacklist,skip...", ipName)
+				i.log().Warningf("%s is in blacklist,skip...", ipName)
 				continue
 			}
 			for portNumber := range ipResult.Ports {
@@ -83,8 +87,16 @@ func (i *IconHash) Do() {
 				swg.Add()
 				go func(ip string, port int, u string) {
 					defer swg.Done()
-					iconHashes := i.RunFetchIconHashes(u)
+					proxy := proxypool.Get(proxypool.TaskIconHash)
+					iconHashes := i.fetchIconHashes(u, proxy)
 					if len(iconHashes) > 0 {
+						if proxy != nil {
+							i.ResultPortScan.SetPortAttr(ip, port, portscan.PortAttrResult{
+								Source:  "iconhash",
+								Tag:     proxypool.EgressTag,
+								Content: proxy.Egress,
+							})
+						}
 						for _, r := range iconHashes {
 							par := portscan.PortAttrResult{
 								Source:  "iconhash",
@@ -113,7 +125,7 @@ func (i *IconHash) Do() {
 		}
 		for domain := range i.ResultDomainScan.DomainResult {
 			if btc.CheckBlack(domain) {
-				logging.RuntimeLog.Warningf("%s is in blacklist,skip...", domain)
+				i.log().Warningf("%s is in blacklist,skip...", domain)
 				continue
 			}
 			//如果无域名对应的端口，默认80和443
@@ -135,8 +147,16 @@ func (i *IconHash) Do() {
 				swg.Add()
 				go func(d string, u string) {
 					defer swg.Done()
-					iconHashes := i.RunFetchIconHashes(u)
+					proxy := proxypool.Get(proxypool.TaskIconHash)
+					iconHashes := i.fetchIconHashes(u, proxy)
 					if len(iconHashes) > 0 {
+						if proxy != nil {
+							i.ResultDomainScan.SetDomainAttr(d, domainscan.DomainAttrResult{
+								Source:  "iconhash",
+								Tag:     proxypool.EgressTag,
+								Content: proxy.Egress,
+							})
+						}
 						for _, r := range iconHashes {
 							dar := domainscan.DomainAttrResult{
 								Source:  "iconhash",
@@ -163,12 +183,19 @@ func (i *IconHash) Do() {
 	swg.Wait()
 }
 
+// RunFetchIconHashes 直接访问获取icon及其哈希值
 func (i *IconHash) RunFetchIconHashes(url string) (hashResult []IconHashResult) {
+	return i.fetchIconHashes(url, nil)
+}
+
+// fetchIconHashes 获取icon及其哈希值，proxy不为nil时通过代理访问
+func (i *IconHash) fetchIconHashes(url string, proxy *proxypool.Proxy) (hashResult []IconHashResult) {
 	var icons []besticon.Icon
-	protocoll := utils.GetProtocol(url, 5)
+	bi := newBesticon(proxy)
+	protocoll := proxypool.GetProtocol(url, 5, proxy)
 	// 获取缺省的icon:
 	u1 := fmt.Sprintf("%s://%s/favicon.ico", protocoll, url)
-	icon1 := fetchIconDetails(u1)
+	icon1 := fetchIconDetails(bi, u1)
 	if icon1.Error == nil {
 		icons = append(icons, icon1)
 	}
@@ -177,7 +204,7 @@ func (i *IconHash) RunFetchIconHashes(url string) (hashResult []IconHashResult)
 		// 获取网页中定义的全部icon
 		u2 := fmt.Sprintf("%s://%s", protocoll, url)
 		//finder := besticon.IconFinder{}
-		finder := besticon.New().NewIconFinder()
+		finder := bi.NewIconFinder()
 		icons2, err := finder.FetchIcons(u2)
 		if err == nil {
 			icons = append(icons, icons2...)
@@ -193,8 +220,9 @@ func (i *IconHash) RunFetchIconHashes(url string) (hashResult []IconHashResult)
 	return
 }
 
-// SaveFile 保存icon Image文件
-func (i *IconHash) SaveFile(localSavePath string, result []IconHashInfo) string {
+// SaveFile 保存icon Image文件到存储
+func (i *IconHash) SaveFile(workspaceGUID string, result []IconHashInfo) string {
+	store := storage.GetStorage()
 	count := 0
 	for _, ihf := range result {
 		if ihf.Url == "" || ihf.Hash == "" || len(ihf.ImageData) <= 0 {
@@ -205,10 +233,10 @@ func (i *IconHash) SaveFile(localSavePath string, result []IconHashInfo) string
 			continue
 		}
 		//文件名为md5(iconHash).后缀
-		filePathName := filepath.Join(localSavePath, fmt.Sprintf("%s.%s", utils.MD5(ihf.Hash), fileSuffix))
-		err := os.WriteFile(filePathName, ihf.ImageData, 0666)
+		key := storage.Key(workspaceGUID, "iconimage", fmt.Sprintf("%s.%s", utils.MD5(ihf.Hash), fileSuffix))
+		err := store.Put(key, ihf.ImageData)
 		if err != nil {
-			msg := fmt.Sprintf("write icon file %s fail:%v", filePathName, err)
+			msg := fmt.Sprintf("save icon file %s fail:%v", key, err)
 			logging.RuntimeLog.Error(msg)
 			logging.CLILog.Error(msg)
 			continue
@@ -218,12 +246,36 @@ func (i *IconHash) SaveFile(localSavePath string, result []IconHashInfo) string
 	return fmt.Sprintf("iconimage:%d", count)
 }
 
+// newBesticon 创建besticon对象，proxy不为nil时使用通过代理访问的http客户端
+func newBesticon(proxy *proxypool.Proxy) *besticon.Besticon {
+	if proxy == nil {
+		return besticon.New()
+	}
+	client := besticon.NewDefaultHTTPClient()
+	client.Transport = &iconTransport{
+		transport: proxypool.NewHTTPClient(proxy, 0).Transport,
+		userAgent: iconUserAgent,
+	}
+	return besticon.New(besticon.WithHTTPClient(client))
+}
+
+// iconTransport 与besticon缺省的http客户端一致，为请求设置User-Agent
+type iconTransport struct {
+	transport http.RoundTripper
+	userAgent string
+}
+
+func (t *iconTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Accept", "*/*")
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.transport.RoundTrip(req)
+}
+
 // fetchIconDetails 根据URL获取并校验icon文件
 // forked from github.com/mat/besticon/besticon/besticon.go
-func fetchIconDetails(url string) besticon.Icon {
+func fetchIconDetails(bi *besticon.Besticon, url string) besticon.Icon {
 	i := besticon.Icon{URL: url}
 
-	bi := besticon.New()
 	response, e := bi.Get(url)
 	//response, e := besticon.Get(url)
 	if e != nil {
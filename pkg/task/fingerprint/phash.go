@@ -0,0 +1,129 @@
+package fingerprint
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/disintegration/imaging"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+)
+
+const (
+	phashSampleSize = 32
+	phashHashSize   = 8
+	phashTolerance  = 0.005
+	// PHashSimilarDistance 感知哈希的汉明距离不超过该值时认为页面视觉上相同
+	PHashSimilarDistance = 8
+)
+
+// dctCosTable DCT变换的余弦系数，dctCosTable[u][x]
+var dctCosTable = func() (table [phashSampleSize][phashSampleSize]float64) {
+	for u := 0; u < phashSampleSize; u++ {
+		for x := 0; x < phashSampleSize; x++ {
+			table[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / float64(2*phashSampleSize))
+		}
+	}
+	return
+}()
+
+// PerceptualHash 计算图片的感知哈希（pHash）：缩放为32x32灰度图，取DCT的低频8x8系数与中位数比较
+func PerceptualHash(img image.Image) uint64 {
+	gray := imaging.Grayscale(imaging.Resize(img, phashSampleSize, phashSampleSize, imaging.Box))
+	var pixels [phashSampleSize][phashSampleSize]float64
+	for y := 0; y < phashSampleSize; y++ {
+		for x := 0; x < phashSampleSize; x++ {
+			pixels[y][x] = float64(gray.Pix[y*gray.Stride+x*4])
+		}
+	}
+	// 二维DCT分解为行、列两次一维变换，只计算需要的低频部分
+	var rows [phashSampleSize][phashHashSize]float64
+	for y := 0; y < phashSampleSize; y++ {
+		for u := 0; u < phashHashSize; u++ {
+			for x := 0; x < phashSampleSize; x++ {
+				rows[y][u] += pixels[y][x] * dctCosTable[u][x]
+			}
+		}
+	}
+	coefficients := make([]float64, 0, phashHashSize*phashHashSize)
+	for v := 0; v < phashHashSize; v++ {
+		for u := 0; u < phashHashSize; u++ {
+			var sum float64
+			for y := 0; y < phashSampleSize; y++ {
+				sum += rows[y][u] * dctCosTable[v][y]
+			}
+			coefficients = append(coefficients, sum)
+		}
+	}
+	// 直流分量不参与中位数计算
+	sorted := append([]float64{}, coefficients[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	// 大面积空白的页面有很多接近中位数的系数，设置容差避免缩放误差导致结果不稳定
+	tolerance := math.Max(math.Abs(sorted[0]), math.Abs(sorted[len(sorted)-1])) * phashTolerance
+
+	var hash uint64
+	for i, c := range coefficients {
+		if c > median+tolerance {
+			hash |= 1 << uint(len(coefficients)-1-i)
+		}
+	}
+	return hash
+}
+
+// PerceptualHashString 计算图片内容的感知哈希，返回16位十六进制字符串
+func PerceptualHashString(content []byte) (string, error) {
+	img, err := imaging.Decode(bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	return FormatPHash(PerceptualHash(img)), nil
+}
+
+// FormatPHash 将感知哈希格式化为十六进制字符串
+func FormatPHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// ParsePHash 解析十六进制格式的感知哈希
+func ParsePHash(s string) (uint64, error) {
+	return strconv.ParseUint(s, 16, 64)
+}
+
+// HammingDistance 两个感知哈希的汉明距离
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// GroupBySimilarity 将感知哈希按相似度分组，返回每组成员的下标；
+// 以每组第一个成员为代表，距离不超过distance的归入该组，无法解析的哈希单独成组
+func GroupBySimilarity(hashes []string, distance int) (groups [][]int) {
+	type leader struct {
+		hash  uint64
+		valid bool
+	}
+	var leaders []leader
+	for i, s := range hashes {
+		hash, err := ParsePHash(s)
+		if err != nil {
+			groups = append(groups, []int{i})
+			leaders = append(leaders, leader{})
+			continue
+		}
+		found := false
+		for g, l := range leaders {
+			if l.valid && HammingDistance(hash, l.hash) <= distance {
+				groups[g] = append(groups[g], i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			groups = append(groups, []int{i})
+			leaders = append(leaders, leader{hash: hash, valid: true})
+		}
+	}
+	return
+}
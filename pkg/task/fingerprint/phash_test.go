@@ -0,0 +1,105 @@
+package fingerprint
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// makePage 生成一个模拟页面的图片：白色背景，顶部导航栏及居中的登录框
+func makePage(width, height int, bar, box color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			switch {
+			case y < height/10:
+				img.Set(x, y, bar)
+			case x > width/3 && x < width*2/3 && y > height/3 && y < height*2/3:
+				img.Set(x, y, box)
+			default:
+				img.Set(x, y, color.White)
+			}
+		}
+	}
+	return img
+}
+
+// makeSidebar 生成一个左侧为深色菜单栏、右侧上方为内容块的页面图片
+func makeSidebar(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			switch {
+			case x < width/4:
+				img.Set(x, y, color.RGBA{R: 40, G: 40, B: 40, A: 255})
+			case x > width/2 && y < height/2:
+				img.Set(x, y, color.RGBA{R: 120, G: 160, B: 120, A: 255})
+			default:
+				img.Set(x, y, color.White)
+			}
+		}
+	}
+	return img
+}
+
+func TestPerceptualHash(t *testing.T) {
+	blue := color.RGBA{R: 30, G: 60, B: 200, A: 255}
+	gray := color.RGBA{R: 200, G: 200, B: 200, A: 255}
+	page := PerceptualHash(makePage(400, 300, blue, gray))
+	// 尺寸不同、颜色略有差异的相同页面
+	similar := PerceptualHash(makePage(800, 600, color.RGBA{R: 40, G: 70, B: 210, A: 255}, color.RGBA{R: 190, G: 190, B: 190, A: 255}))
+	different := PerceptualHash(makeSidebar(400, 300))
+
+	if d := HammingDistance(page, similar); d > PHashSimilarDistance {
+		t.Errorf("similar pages distance too large:%d", d)
+	}
+	if d := HammingDistance(page, different); d <= PHashSimilarDistance {
+		t.Errorf("different pages distance too small:%d", d)
+	}
+}
+
+func TestPerceptualHashString(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, makeSidebar(200, 100)); err != nil {
+		t.Fatal(err)
+	}
+	s, err := PerceptualHashString(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := ParsePHash(s)
+	if err != nil || len(s) != 16 || FormatPHash(hash) != s {
+		t.Errorf("unexpected phash:%s", s)
+	}
+	if _, err = PerceptualHashString([]byte("not a picture")); err == nil {
+		t.Errorf("invalid picture should fail")
+	}
+}
+
+func TestGroupBySimilarity(t *testing.T) {
+	hashes := []string{
+		FormatPHash(0xffff0000ffff0000),
+		FormatPHash(0x0f0f0f0f0f0f0f0f),
+		FormatPHash(0xffff0000ffff0003),
+		"",
+		FormatPHash(0x0f0f0f0f0f0f0f0e),
+		FormatPHash(0xffff0000ffff0000),
+	}
+	groups := GroupBySimilarity(hashes, 2)
+	expected := [][]int{{0, 2, 5}, {1, 4}, {3}}
+	if len(groups) != len(expected) {
+		t.Fatalf("unexpected groups:%v", groups)
+	}
+	for i := range groups {
+		if len(groups[i]) != len(expected[i]) {
+			t.Fatalf("unexpected groups:%v", groups)
+		}
+		for j := range groups[i] {
+			if groups[i][j] != expected[i][j] {
+				t.Errorf("unexpected groups:%v", groups)
+			}
+		}
+	}
+}
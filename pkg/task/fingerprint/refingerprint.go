@@ -0,0 +1,300 @@
+package fingerprint
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/fulltext"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	reFingerprintSource = "httpxfinger"
+	reFingerprintTag    = "fingerprint"
+)
+
+// ReFingerprint 对数据库中已保存的http响应（ip_http、domain_http）重新进行指纹匹配
+// 用于新增指纹规则后对已有资产进行回溯识别，整个过程只读取数据库，不会向目标发送任何请求
+type ReFingerprint struct {
+	WorkspaceId int
+	Result      ReFingerprintResult
+	// 新增及删除的指纹，完成后更新全文索引
+	indexDocs  []fulltext.Document
+	deletedIds []string
+}
+
+// ReFingerprintResult 回溯指纹识别的统计结果
+type ReFingerprintResult struct {
+	PortTotal     int
+	PortChanged   int
+	DomainTotal   int
+	DomainChanged int
+	AttrAdded     int
+	AttrRemoved   int
+}
+
+// ReFingerprintStatus 工作空间在后台执行回溯指纹识别的状态
+type ReFingerprintStatus struct {
+	Running   bool                `json:"running"`
+	StartTime time.Time           `json:"start_time"`
+	EndTime   time.Time           `json:"end_time"`
+	Result    ReFingerprintResult `json:"result"`
+	Error     string              `json:"error,omitempty"`
+}
+
+var (
+	reFingerprintMutex  sync.Mutex
+	reFingerprintStatus = make(map[int]*ReFingerprintStatus)
+)
+
+// NewReFingerprint 创建回溯指纹识别对象
+func NewReFingerprint(workspaceId int) *ReFingerprint {
+	return &ReFingerprint{WorkspaceId: workspaceId}
+}
+
+// ReloadFingerprintRules 重新加载fingerprinthub及自定义的指纹规则；全部读取成功后再替换正在使用的规则，读取失败时保留原有的规则并返回0
+func ReloadFingerprintRules() (total int) {
+	hubRules, err := readFingerprintHubRules()
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		return 0
+	}
+	customRules, err := readCustomFingerprintRules()
+	if err != nil && !os.IsNotExist(err) {
+		logging.RuntimeLog.Error(err)
+		return 0
+	}
+	if len(hubRules)+len(customRules) == 0 {
+		return 0
+	}
+	fpMutex.Lock()
+	fpWebFingerprintHub, fpCustom = hubRules, customRules
+	fpMutex.Unlock()
+
+	return len(hubRules) + len(customRules)
+}
+
+// StartReFingerprint 在后台执行工作空间的回溯指纹识别，同一工作空间同时只能执行一个
+func StartReFingerprint(workspaceId int) error {
+	if workspaceId <= 0 {
+		return errors.New("invalid workspace")
+	}
+	reFingerprintMutex.Lock()
+	defer reFingerprintMutex.Unlock()
+
+	if status, ok := reFingerprintStatus[workspaceId]; ok && status.Running {
+		return errors.New("refingerprint is running")
+	}
+	reFingerprintStatus[workspaceId] = &ReFingerprintStatus{Running: true, StartTime: time.Now()}
+	go func() {
+		r := NewReFingerprint(workspaceId)
+		err := r.Do()
+		if err != nil {
+			logging.RuntimeLog.Errorf("refingerprint workspace:%d fail:%v", workspaceId, err)
+		}
+		reFingerprintMutex.Lock()
+		defer reFingerprintMutex.Unlock()
+		status := reFingerprintStatus[workspaceId]
+		status.Running = false
+		status.EndTime = time.Now()
+		status.Result = r.Result
+		if err != nil {
+			status.Error = err.Error()
+		}
+	}()
+	return nil
+}
+
+// GetReFingerprintStatus 获取工作空间最近一次回溯指纹识别的状态
+func GetReFingerprintStatus(workspaceId int) (status ReFingerprintStatus, ok bool) {
+	reFingerprintMutex.Lock()
+	defer reFingerprintMutex.Unlock()
+
+	if s, exist := reFingerprintStatus[workspaceId]; exist {
+		return *s, true
+	}
+	return
+}
+
+// Do 执行回溯指纹识别
+func (r *ReFingerprint) Do() error {
+	if r.WorkspaceId <= 0 {
+		return errors.New("invalid workspace")
+	}
+	// 指纹规则加载失败时不能执行，否则会删除全部已有的指纹
+	if ReloadFingerprintRules() == 0 {
+		return errors.New("no fingerprint rule loaded")
+	}
+	r.doIP()
+	r.doDomain()
+	fulltext.IndexWorkspace(r.WorkspaceId, r.indexDocs)
+	fulltext.DeleteDocuments(r.WorkspaceId, r.deletedIds)
+	logging.RuntimeLog.Infof("refingerprint workspace:%d finished,%s", r.WorkspaceId, r.Result.String())
+
+	return nil
+}
+
+// doIP 对IP的端口保存的http响应进行指纹匹配
+func (r *ReFingerprint) doIP() {
+	ipDb := db.Ip{}
+	ips, _ := ipDb.Gets(map[string]interface{}{"workspace_id": r.WorkspaceId}, -1, -1, false)
+	for _, ip := range ips {
+		portDb := db.Port{IpId: ip.Id}
+		for _, port := range portDb.GetsByIPId() {
+			httpDb := db.IpHttp{RelatedId: port.Id}
+			var header, body string
+			for _, h := range httpDb.GetsByRelatedId() {
+				if h.Tag == "header" {
+					header = h.Content
+				} else if h.Tag == "body" {
+					body = h.Content
+				}
+			}
+			if header == "" && body == "" {
+				continue
+			}
+			r.Result.PortTotal++
+
+			portAttrDb := db.PortAttr{RelatedId: port.Id}
+			portAttrs := portAttrDb.GetsByRelatedId()
+			var attrs []FingerAttrResult
+			oldFingers := make(map[string]db.PortAttr)
+			for _, pa := range portAttrs {
+				if pa.Source == reFingerprintSource && pa.Tag == reFingerprintTag {
+					oldFingers[pa.Content] = pa
+				} else if pa.Source == "httpx" {
+					attrs = append(attrs, FingerAttrResult{Tag: pa.Tag, Content: pa.Content})
+				}
+			}
+			newFingers := matchStoredResponse(port.PortNum, header, body, attrs)
+			var oldFingerNames []string
+			for k := range oldFingers {
+				oldFingerNames = append(oldFingerNames, k)
+			}
+			added, removed := diffFingerprint(oldFingerNames, newFingers)
+			for _, finger := range added {
+				pa := db.PortAttr{RelatedId: port.Id, Source: reFingerprintSource, Tag: reFingerprintTag, Content: finger}
+				if pa.SaveOrUpdate() {
+					r.Result.AttrAdded++
+					r.indexDocs = append(r.indexDocs, fulltext.NewPortAttrDocument(ip.IpName, port.PortNum, &pa))
+				}
+			}
+			for _, finger := range removed {
+				pa := oldFingers[finger]
+				if pa.Delete() {
+					r.Result.AttrRemoved++
+					r.deletedIds = append(r.deletedIds, fulltext.PortAttrDocumentID(&pa))
+				}
+			}
+			if len(added) > 0 || len(removed) > 0 {
+				r.Result.PortChanged++
+			}
+		}
+	}
+}
+
+// doDomain 对域名保存的http响应进行指纹匹配，域名的多个端口的指纹合并为域名的属性
+func (r *ReFingerprint) doDomain() {
+	domainDb := db.Domain{}
+	domains, _ := domainDb.Gets(map[string]interface{}{"workspace_id": r.WorkspaceId}, -1, -1, false)
+	for _, domain := range domains {
+		httpDb := db.DomainHttp{RelatedId: domain.Id}
+		headers := make(map[int]string)
+		bodies := make(map[int]string)
+		for _, h := range httpDb.GetsByRelatedId() {
+			if h.Tag == "header" {
+				headers[h.Port] = h.Content
+			} else if h.Tag == "body" {
+				bodies[h.Port] = h.Content
+			}
+		}
+		if len(headers) == 0 && len(bodies) == 0 {
+			continue
+		}
+		r.Result.DomainTotal++
+
+		domainAttrDb := db.DomainAttr{RelatedId: domain.Id}
+		var attrs []FingerAttrResult
+		oldFingers := make(map[string]db.DomainAttr)
+		for _, da := range domainAttrDb.GetsByRelatedId() {
+			if da.Source == reFingerprintSource && da.Tag == reFingerprintTag {
+				oldFingers[da.Content] = da
+			} else if da.Source == "httpx" {
+				attrs = append(attrs, FingerAttrResult{Tag: da.Tag, Content: da.Content})
+			}
+		}
+		ports := make(map[int]struct{})
+		for p := range headers {
+			ports[p] = struct{}{}
+		}
+		for p := range bodies {
+			ports[p] = struct{}{}
+		}
+		var newFingers []string
+		for p := range ports {
+			newFingers = append(newFingers, matchStoredResponse(p, headers[p], bodies[p], attrs)...)
+		}
+		var oldFingerNames []string
+		for k := range oldFingers {
+			oldFingerNames = append(oldFingerNames, k)
+		}
+		added, removed := diffFingerprint(oldFingerNames, newFingers)
+		for _, finger := range added {
+			da := db.DomainAttr{RelatedId: domain.Id, Source: reFingerprintSource, Tag: reFingerprintTag, Content: finger}
+			if da.SaveOrUpdate() {
+				r.Result.AttrAdded++
+				r.indexDocs = append(r.indexDocs, fulltext.NewDomainAttrDocument(domain.DomainName, &da))
+			}
+		}
+		for _, finger := range removed {
+			da := oldFingers[finger]
+			if da.Delete() {
+				r.Result.AttrRemoved++
+				r.deletedIds = append(r.deletedIds, fulltext.DomainAttrDocumentID(&da))
+			}
+		}
+		if len(added) > 0 || len(removed) > 0 {
+			r.Result.DomainChanged++
+		}
+	}
+}
+
+// String 返回统计结果的描述
+func (result ReFingerprintResult) String() string {
+	return fmt.Sprintf("port:%d,portChanged:%d,domain:%d,domainChanged:%d,attrAdded:%d,attrRemoved:%d",
+		result.PortTotal, result.PortChanged, result.DomainTotal, result.DomainChanged, result.AttrAdded, result.AttrRemoved)
+}
+
+// matchStoredResponse 对保存的header与body执行fingerprinthub与自定义指纹匹配
+func matchStoredResponse(port int, header string, body string, attrs []FingerAttrResult) (fingers []string) {
+	fingers = append(fingers, matchFingerprintHub(body, parseHttpHeaderMap(header))...)
+	fingers = append(fingers, matchCustomFingerprint(port, body, header, attrs)...)
+	return
+}
+
+// diffFingerprint 比较新旧指纹，返回需要新增及删除的指纹
+func diffFingerprint(oldFingers []string, newFingers []string) (added []string, removed []string) {
+	oldSet := make(map[string]struct{})
+	for _, f := range oldFingers {
+		oldSet[f] = struct{}{}
+	}
+	newSet := make(map[string]struct{})
+	for _, f := range newFingers {
+		if _, ok := newSet[f]; ok {
+			continue
+		}
+		newSet[f] = struct{}{}
+		if _, ok := oldSet[f]; !ok {
+			added = append(added, f)
+		}
+	}
+	for _, f := range oldFingers {
+		if _, ok := newSet[f]; !ok {
+			removed = append(removed, f)
+		}
+	}
+	return
+}
@@ -0,0 +1,58 @@
+package fingerprint
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestDiffFingerprint(t *testing.T) {
+	added, removed := diffFingerprint([]string{"nginx", "tomcat"}, []string{"nginx", "shiro", "shiro"})
+	if len(added) != 1 || added[0] != "shiro" {
+		t.Errorf("added:%v", added)
+	}
+	if len(removed) != 1 || removed[0] != "tomcat" {
+		t.Errorf("removed:%v", removed)
+	}
+	added, removed = diffFingerprint(nil, nil)
+	if len(added) != 0 || len(removed) != 0 {
+		t.Errorf("added:%v,removed:%v", added, removed)
+	}
+}
+
+func TestMatchStoredResponse(t *testing.T) {
+	fpMutex.Lock()
+	oldHub, oldCustom := fpWebFingerprintHub, fpCustom
+	fpWebFingerprintHub = []WebFingerPrint{
+		{Name: "shiro", Headers: map[string]string{"Set-Cookie": "rememberMe=deleteMe"}},
+		{Name: "nginx-default", Keyword: []string{"Welcome to nginx!"}},
+	}
+	fpCustom = []CustomFingerPrint{
+		{Id: 1, App: "custom-title", Rule: `title="Admin Console"`},
+	}
+	fpMutex.Unlock()
+	defer func() {
+		fpMutex.Lock()
+		fpWebFingerprintHub, fpCustom = oldHub, oldCustom
+		fpMutex.Unlock()
+	}()
+
+	header := "HTTP/1.1 200 OK\r\nServer: nginx\r\nSet-Cookie: rememberMe=deleteMe; Path=/"
+	body := "<html><title>Admin Console</title><h1>Welcome to nginx!</h1></html>"
+	attrs := []FingerAttrResult{{Tag: "title", Content: "Admin Console"}}
+	fingers := matchStoredResponse(8080, header, body, attrs)
+	sort.Strings(fingers)
+	expected := []string{"custom-title", "nginx-default", "shiro"}
+	if len(fingers) != len(expected) {
+		t.Fatalf("fingers:%v", fingers)
+	}
+	for i := range expected {
+		if fingers[i] != expected[i] {
+			t.Errorf("fingers:%v", fingers)
+		}
+	}
+
+	fingers = matchStoredResponse(80, "HTTP/1.1 404 Not Found\r\nServer: apache", "not found", nil)
+	if len(fingers) != 0 {
+		t.Errorf("unexpected fingers:%v", fingers)
+	}
+}
@@ -3,8 +3,10 @@ package fingerprint
 import (
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/sirupsen/logrus"
 	"strings"
 	"sync"
 )
@@ -44,6 +46,18 @@ type Config struct {
 	//OrgId  *int
 }
 
+// TaskInfo 执行指纹识别的任务，用于关联任务日志
+type TaskInfo struct {
+	TaskId      string
+	MainTaskId  string
+	WorkspaceId int
+}
+
+// log 任务的日志
+func (t TaskInfo) log() *logrus.Entry {
+	return logging.NewTaskLog(t.TaskId, t.MainTaskId, t.WorkspaceId)
+}
+
 type FingerAttrResult struct {
 	Tag     string
 	Content string
@@ -53,6 +67,11 @@ type ScreenshotInfo struct {
 	Port         int
 	Protocol     string
 	FilePathName string
+	FinalURL     string
+	Title        string
+	Text         string
+	PHash        string
+	Egress       string
 }
 
 type ScreenshotResult struct {
@@ -1,22 +1,20 @@
 package fingerprint
 
 import (
-	"context"
 	"fmt"
-	"github.com/chromedp/cdproto/page"
-	"github.com/chromedp/chromedp"
 	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/db"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
+	"github.com/hanc00l/nemo_go/pkg/storage"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"github.com/remeh/sizedwaitgroup"
-	"log"
 	"os"
-	"path/filepath"
+	"path"
 	"strings"
-	"time"
 )
 
 const (
@@ -28,6 +26,7 @@ const (
 )
 
 type ScreenShot struct {
+	TaskInfo
 	ResultPortScan   *portscan.Result
 	ResultDomainScan *domainscan.Result
 	ResultScreenShot ScreenshotResult
@@ -40,6 +39,11 @@ type ScreenshotFileInfo struct {
 	Port     int    `json:"port"`
 	Protocol string `json:"protocol"`
 	Content  []byte `json:"content"`
+	FinalURL string `json:"final_url"`
+	Title    string `json:"title"`
+	Text     string `json:"text"`
+	PHash    string `json:"phash"`
+	Egress   string `json:"egress,omitempty"`
 }
 
 // NewScreenShot 创建ScreenShot对象
@@ -58,7 +62,7 @@ func (s *ScreenShot) Do() {
 	if s.ResultPortScan != nil && s.ResultPortScan.IPResult != nil {
 		for ipName, ipResult := range s.ResultPortScan.IPResult {
 			if btc.CheckBlack(ipName) {
-				logging.RuntimeLog.Warningf("%s is in blacklist,skip...", ipName)
+				s.log().Warningf("%s is in blacklist,skip...", ipName)
 				continue
 			}
 			for portNumber := range ipResult.Ports {
@@ -70,9 +74,8 @@ func (s *ScreenShot) Do() {
 						continue
 					}
 				}
-				protocol := utils.GetProtocol(utils.FormatHostUrl("", ipName, portNumber), 5)
 				swg.Add()
-				go s.doScreenshotAndResize(&swg, ipName, portNumber, protocol)
+				go s.doScreenshotAndResize(&swg, ipName, portNumber)
 
 			}
 		}
@@ -83,7 +86,7 @@ func (s *ScreenShot) Do() {
 		}
 		for domain := range s.ResultDomainScan.DomainResult {
 			if btc.CheckBlack(domain) {
-				logging.RuntimeLog.Warningf("%s is in blacklist,skip...", domain)
+				s.log().Warningf("%s is in blacklist,skip...", domain)
 				continue
 			}
 			//如果无域名对应的端口，默认80和443
@@ -101,9 +104,8 @@ func (s *ScreenShot) Do() {
 						continue
 					}
 				}
-				protocol := utils.GetProtocol(utils.FormatHostUrl("", domain, port), 5)
 				swg.Add()
-				go s.doScreenshotAndResize(&swg, domain, port, protocol)
+				go s.doScreenshotAndResize(&swg, domain, port)
 			}
 		}
 	}
@@ -118,6 +120,11 @@ func (s *ScreenShot) LoadResult() (result []ScreenshotFileInfo) {
 				Domain:   domain,
 				Port:     si.Port,
 				Protocol: si.Protocol,
+				FinalURL: si.FinalURL,
+				Title:    si.Title,
+				Text:     si.Text,
+				PHash:    si.PHash,
+				Egress:   si.Egress,
 			}
 			var err error
 			sfi.Content, err = os.ReadFile(si.FilePathName)
@@ -133,8 +140,9 @@ func (s *ScreenShot) LoadResult() (result []ScreenshotFileInfo) {
 	return
 }
 
-// SaveFile 保存screenshot文件到本地
-func (s *ScreenShot) SaveFile(localSavePath string, result []ScreenshotFileInfo) (count int) {
+// SaveFile 保存screenshot文件到存储，页面信息及感知哈希保存到数据库
+func (s *ScreenShot) SaveFile(workspaceGUID string, workspaceId int, result []ScreenshotFileInfo) (count int) {
+	store := storage.GetStorage()
 	for _, sfi := range result {
 		// check
 		if sfi.Port == 0 || sfi.Domain == "" || sfi.Protocol == "" || len(sfi.Content) == 0 {
@@ -149,38 +157,66 @@ func (s *ScreenShot) SaveFile(localSavePath string, result []ScreenshotFileInfo)
 			logging.RuntimeLog.Errorf("invalid domain:%s", sfi.Domain)
 			continue
 		}
-		domainPath := filepath.Join(localSavePath, sfi.Domain)
-		if !utils.MakePath(localSavePath) || !utils.MakePath(domainPath) {
-			logging.RuntimeLog.Errorf("check upload path fail:%s", domainPath)
-			continue
-		}
 		//保存文件
-		fileName := filepath.Join(domainPath, fmt.Sprintf("%d_%s.png", sfi.Port, sfi.Protocol))
-		err := os.WriteFile(fileName, sfi.Content, 0666)
-		if err != nil {
-			logging.RuntimeLog.Errorf("write file %s fail:%v", fileName, err)
+		key := storage.Key(workspaceGUID, "screenshot", sfi.Domain, fmt.Sprintf("%d_%s.png", sfi.Port, sfi.Protocol))
+		if err := store.Put(key, sfi.Content); err != nil {
+			logging.RuntimeLog.Errorf("save file %s fail:%v", key, err)
 			continue
 		}
 		//生成缩略图
-		fileNameThumbnail := filepath.Join(domainPath, fmt.Sprintf("%d_%s_thumbnail.png", sfi.Port, sfi.Protocol))
-		if utils.ReSizePicture(fileName, fileNameThumbnail, thumbnailWidth, 0) {
-			count++
+		thumbnail, err := utils.ReSizePictureContent(sfi.Content, thumbnailWidth, 0)
+		if err != nil {
+			logging.RuntimeLog.Errorf("generate thumbnail picature fail:%v", err)
 		} else {
-			logging.RuntimeLog.Error("generate thumbnail picature fail")
+			keyThumbnail := storage.Key(workspaceGUID, "screenshot", sfi.Domain, fmt.Sprintf("%d_%s_thumbnail.png", sfi.Port, sfi.Protocol))
+			if err = store.Put(keyThumbnail, thumbnail); err != nil {
+				logging.RuntimeLog.Errorf("save file %s fail:%v", keyThumbnail, err)
+			} else {
+				count++
+			}
+		}
+		s.saveScreenshotInfo(workspaceId, sfi)
+		if sfi.Egress != "" {
+			s.saveEgressAttr(workspaceId, sfi)
 		}
 	}
 	return
 }
 
+// saveScreenshotInfo 保存截图的页面信息；worker未提供感知哈希时由截图内容计算
+func (s *ScreenShot) saveScreenshotInfo(workspaceId int, sfi ScreenshotFileInfo) {
+	if sfi.PHash == "" {
+		var err error
+		if sfi.PHash, err = PerceptualHashString(sfi.Content); err != nil {
+			logging.RuntimeLog.Errorf("phash %s:%d fail:%v", sfi.Domain, sfi.Port, err)
+		}
+	}
+	screenshot := db.Screenshot{
+		Domain:      sfi.Domain,
+		Port:        sfi.Port,
+		Protocol:    sfi.Protocol,
+		FinalUrl:    sfi.FinalURL,
+		Title:       sfi.Title,
+		Content:     sfi.Text,
+		PHash:       sfi.PHash,
+		WorkspaceId: workspaceId,
+	}
+	screenshot.SaveOrUpdate()
+}
+
 // LoadScreenshotFile 获取screenshot文件
 func (s *ScreenShot) LoadScreenshotFile(workspaceGUID, domain string) (r []string) {
 	if !utils.CheckDomain(domain) && !utils.CheckIP(domain) {
 		return
 	}
-	files, _ := filepath.Glob(filepath.Join(conf.GlobalServerConfig().Web.WebFiles, workspaceGUID, "screenshot", domain, "*.png"))
-	for _, file := range files {
-		_, f := filepath.Split(file)
-		if !strings.HasSuffix(f, "_thumbnail.png") {
+	keys, err := storage.GetStorage().List(storage.Key(workspaceGUID, "screenshot", domain))
+	if err != nil {
+		logging.RuntimeLog.Errorf("list screenshot of %s fail:%v", domain, err)
+		return
+	}
+	for _, key := range keys {
+		f := path.Base(key)
+		if path.Dir(key) == storage.Key(workspaceGUID, "screenshot", domain) && strings.HasSuffix(f, ".png") && !strings.HasSuffix(f, "_thumbnail.png") {
 			r = append(r, f)
 		}
 	}
@@ -188,24 +224,70 @@ func (s *ScreenShot) LoadScreenshotFile(workspaceGUID, domain string) (r []strin
 }
 
 // doScreenshotAndResize 屏幕截图并进行缩放
-func (s *ScreenShot) doScreenshotAndResize(swg *sizedwaitgroup.SizedWaitGroup, domain string, port int, protocol string) {
+func (s *ScreenShot) doScreenshotAndResize(swg *sizedwaitgroup.SizedWaitGroup, domain string, port int) {
 	defer swg.Done()
 
+	proxy := proxypool.Get(proxypool.TaskScreenshot)
+	protocol := proxypool.GetProtocol(utils.FormatHostUrl("", domain, port), 5, proxy)
 	u := utils.FormatHostUrl(protocol, domain, port)
+	logging.CLILog.Infof("headless-chrome screenshot -> %s", u)
+	var proxyURL string
+	if proxy != nil {
+		proxyURL = proxy.URL
+	}
+	capture, err := GetBrowserPoolByProxy(proxyURL).Capture(u)
+	if err != nil {
+		logging.CLILog.Warningf("screenshot %s fail:%v", u, err)
+		return
+	}
 	file1 := utils.GetTempPNGPathFileName()
 	defer os.Remove(file1)
-	logging.CLILog.Infof("headless-chrome screenshot -> %s", u)
-	if DoFullScreenshot(u, file1) {
-		fileResized := utils.GetTempPNGPathFileName()
-		if utils.ReSizePicture(file1, fileResized, SavedWidth, SavedHeight) {
-			si := ScreenshotInfo{
-				Port:         port,
-				Protocol:     protocol,
-				FilePathName: fileResized,
-			}
-			s.ResultScreenShot.SetScreenshotInfo(domain, si)
+	if err = os.WriteFile(file1, capture.Image, 0644); err != nil {
+		s.log().Error(err)
+		logging.CLILog.Error(err)
+		return
+	}
+	fileResized := utils.GetTempPNGPathFileName()
+	if utils.ReSizePicture(file1, fileResized, SavedWidth, SavedHeight) {
+		si := ScreenshotInfo{
+			Port:         port,
+			Protocol:     protocol,
+			FilePathName: fileResized,
+			FinalURL:     capture.FinalURL,
+			Title:        capture.Title,
+			Text:         capture.Text,
+		}
+		if proxy != nil {
+			si.Egress = proxy.Egress
 		}
+		if si.PHash, err = PerceptualHashString(capture.Image); err != nil {
+			s.log().Warningf("phash %s fail:%v", u, err)
+		}
+		s.ResultScreenShot.SetScreenshotInfo(domain, si)
+	}
+}
+
+// saveEgressAttr 将截图使用的代理出口IP保存为端口或域名的属性
+func (s *ScreenShot) saveEgressAttr(workspaceId int, sfi ScreenshotFileInfo) {
+	if utils.CheckIP(sfi.Domain) {
+		ip := db.Ip{IpName: sfi.Domain, WorkspaceId: workspaceId}
+		if !ip.GetByIp() {
+			return
+		}
+		port := db.Port{IpId: ip.Id, PortNum: sfi.Port}
+		if !port.GetByIPPort() {
+			return
+		}
+		portAttr := db.PortAttr{RelatedId: port.Id, Source: "screenshot", Tag: proxypool.EgressTag, Content: sfi.Egress}
+		portAttr.SaveOrUpdate()
+		return
+	}
+	domain := db.Domain{DomainName: sfi.Domain, WorkspaceId: workspaceId}
+	if !domain.GetByDomain() {
+		return
 	}
+	domainAttr := db.DomainAttr{RelatedId: domain.Id, Source: "screenshot", Tag: proxypool.EgressTag, Content: sfi.Egress}
+	domainAttr.SaveOrUpdate()
 }
 
 // Delete 删除指定domain、IP下保存的screenshot文件
@@ -214,74 +296,14 @@ func (s *ScreenShot) Delete(workspaceGUID, domain string) bool {
 		logging.RuntimeLog.Errorf("invalid domain:%s", domain)
 		return false
 	}
-	domainPath := filepath.Join(conf.GlobalServerConfig().Web.WebFiles, workspaceGUID, "screenshot", domain)
-	if err := os.RemoveAll(domainPath); err != nil {
-		return false
+	workspace := db.Workspace{WorkspaceGUID: workspaceGUID}
+	if workspace.GetByGUID() {
+		screenshot := db.Screenshot{WorkspaceId: workspace.Id, Domain: domain}
+		screenshot.DeleteByDomain()
 	}
-	return true
-}
-
-// DoFullScreenshot 调用chromedp执行截图
-func DoFullScreenshot(url, path string) bool {
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("headless", true),
-		chromedp.Flag("ignore-certificate-errors", true),
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("disable-crash-reporter", true),
-		chromedp.Flag("disable-notifications", true),
-		chromedp.Flag("hide-scrollbars", true),
-		chromedp.Flag("mute-audio", true),
-		chromedp.Flag("incognito", true),
-		chromedp.Flag("enable-features", "NetworkService"),
-		chromedp.UserAgent(`Mozilla/5.0 (Windows NT 6.3; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/73.0.3683.103 Safari/537.36`),
-		chromedp.WindowSize(MaxWidth, MinHeight),
-	)
-	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	defer cancel()
-	// 创建chrome实例
-	ctx, cancel := chromedp.NewContext(
-		allocCtx,
-		chromedp.WithLogf(log.Printf),
-	)
-	defer cancel()
-	// 创建超时时间
-	ctx, cancel = context.WithTimeout(ctx, 15*time.Second)
-	defer cancel()
-	// 缓存对象
-	var buf []byte
-	// 运行截屏
-	if err := chromedp.Run(ctx, fullScreenshot(url, 100, &buf)); err != nil {
-		return false
-	}
-	// 保存文件
-	if err := os.WriteFile(path, buf, 0644); err != nil {
-		logging.RuntimeLog.Error(err)
-		logging.CLILog.Error(err)
+	if err := storage.GetStorage().DeletePrefix(storage.Key(workspaceGUID, "screenshot", domain)); err != nil {
+		logging.RuntimeLog.Errorf("delete screenshot of %s fail:%v", domain, err)
 		return false
 	}
-
 	return true
 }
-
-// fullScreenshot 全屏截图
-func fullScreenshot(url string, quality int64, res *[]byte) chromedp.Tasks {
-	return chromedp.Tasks{
-		chromedp.Navigate(url),
-		////延时：等待有些页面有js自动跳转，待js跳转后再执行截图操作
-		chromedp.Sleep(5 * time.Second),
-		chromedp.ActionFunc(func(ctx context.Context) (err error) {
-			*res, err = page.CaptureScreenshot().WithQuality(quality).WithClip(&page.Viewport{
-				X:      0,
-				Y:      0,
-				Width:  MaxWidth,
-				Height: MinHeight,
-				Scale:  1,
-			}).Do(ctx)
-			if err != nil {
-				logging.CLILog.Error(err)
-				return err
-			}
-			return nil
-		}),
-	}
-}
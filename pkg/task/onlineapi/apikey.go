@@ -0,0 +1,197 @@
+package onlineapi
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"math/rand"
+	"strings"
+)
+
+// key的状态，由server的key池记录
+const (
+	KeyStatusUnknown     = "unknown"
+	KeyStatusOK          = "ok"
+	KeyStatusRateLimited = "ratelimited"
+	KeyStatusExhausted   = "exhausted"
+	KeyStatusInvalid     = "invalid"
+)
+
+var (
+	ErrKeyRateLimited = errors.New("api key rate limited")
+	ErrKeyExhausted   = errors.New("api key quota exhausted")
+	ErrKeyInvalid     = errors.New("api key invalid")
+	ErrNoKeyAvailable = errors.New("no api key available")
+)
+
+// keyErrorKeywords 各API返回的错误信息中表示key不可用的关键词
+var keyErrorKeywords = map[string][]string{
+	KeyStatusRateLimited: {"429", "频繁", "请求太多", "too many requests", "rate limit"},
+	KeyStatusExhausted:   {"余额不足", "积分不足", "额度不足", "查询上限", "820031"},
+	KeyStatusInvalid:     {"账号无效", "令牌无效", "令牌过期", "[-700]", "token invalid", "invalid fofa key", "unauthorized"},
+}
+
+// APIKeyLease 从key池租用的一个key
+type APIKeyLease struct {
+	Id     int
+	Engine string
+	Key    string
+}
+
+// APIKeyReport 一次查询后报告key的使用结果，Status为空表示与key无关的错误（如网络错误），Quota为-1表示未知
+type APIKeyReport struct {
+	Id          int
+	Engine      string
+	WorkspaceId int
+	Status      string
+	Error       string
+	Quota       int
+	ResultCount int
+}
+
+// KeyProvider 在线API的key池：worker通过RPC从server租用key并报告使用结果
+type KeyProvider interface {
+	Lease(engine string, workspaceId int, excludeIds []int) (*APIKeyLease, error)
+	Report(report APIKeyReport)
+}
+
+// AccountChecker 可查询账号剩余额度的搜索引擎，用于key池检查key的状态
+type AccountChecker interface {
+	CheckAccount(apiKey string, config OnlineAPIConfig) (quota int, err error)
+}
+
+// QuotaReporter 在查询结果中返回剩余额度的搜索引擎
+type QuotaReporter interface {
+	RemainingQuota() (quota int, ok bool)
+}
+
+var keyProvider KeyProvider = configKeyProvider{}
+
+// SetKeyProvider 设置在线API查询使用的key池，为nil时使用配置文件中的key
+func SetKeyProvider(p KeyProvider) {
+	if p == nil {
+		p = configKeyProvider{}
+	}
+	keyProvider = p
+}
+
+// NewEngine 根据API名称获取搜索引擎
+func NewEngine(apiName string) Engine {
+	switch apiName {
+	case "fofa":
+		return new(FOFA)
+	case "hunter":
+		return new(Hunter)
+	case "quake":
+		return new(Quake)
+	case "shodan":
+		return new(Shodan)
+	case "censys":
+		return new(Censys)
+	case "zoomeye":
+		return new(ZoomEye)
+	case "netlas":
+		return new(Netlas)
+	case "binaryedge":
+		return new(BinaryEdge)
+	case "0zone":
+		return new(ZeroZone)
+	}
+	return nil
+}
+
+// KeyStatus 根据查询的错误判断key的状态，与key无关的错误返回空
+func KeyStatus(err error) string {
+	if err == nil {
+		return KeyStatusOK
+	}
+	switch {
+	case errors.Is(err, ErrKeyRateLimited):
+		return KeyStatusRateLimited
+	case errors.Is(err, ErrKeyExhausted):
+		return KeyStatusExhausted
+	case errors.Is(err, ErrKeyInvalid):
+		return KeyStatusInvalid
+	}
+	msg := strings.ToLower(err.Error())
+	for _, status := range []string{KeyStatusRateLimited, KeyStatusExhausted, KeyStatusInvalid} {
+		for _, keyword := range keyErrorKeywords[status] {
+			if strings.Contains(msg, keyword) {
+				return status
+			}
+		}
+	}
+	return ""
+}
+
+// DesensitizeKey 脱敏APIKey，格式为xxxx****xxxx或者xxxx****
+func DesensitizeKey(key string) string {
+	l := len(key)
+	if l == 0 {
+		return ""
+	}
+	if l >= 4 {
+		keyPre := key[:4]
+		if l >= 8 {
+			var keyMid, keyEnd string
+			if l >= 12 {
+				keyMid = "****"
+				keyEnd = key[l-4:]
+			} else {
+				keyMid = ""
+				keyEnd = "****"
+			}
+			return fmt.Sprintf("%s%s%s", keyPre, keyMid, keyEnd)
+		}
+		return fmt.Sprintf("%s****", keyPre)
+	}
+	return "****"
+}
+
+// SplitKeys 分割以,分隔的多个key
+func SplitKeys(keys string) (results []string) {
+	for _, key := range strings.Split(keys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			results = append(results, key)
+		}
+	}
+	return
+}
+
+// ConfigKeys 配置文件中各在线API的key
+func ConfigKeys(api conf.API) map[string]string {
+	return map[string]string{
+		"fofa":       api.Fofa.Key,
+		"hunter":     api.Hunter.Key,
+		"quake":      api.Quake.Key,
+		"shodan":     api.Shodan.Key,
+		"censys":     api.Censys.Key,
+		"zoomeye":    api.ZoomEye.Key,
+		"netlas":     api.Netlas.Key,
+		"binaryedge": api.BinaryEdge.Key,
+	}
+}
+
+// configKeyProvider 使用配置文件中的key，用于未连接server的命令行工具及测试，不记录key的状态
+type configKeyProvider struct{}
+
+func (configKeyProvider) Lease(engine string, workspaceId int, excludeIds []int) (*APIKeyLease, error) {
+	keys := ConfigKeys(conf.GlobalWorkerConfig().API)[engine]
+	exclude := make(map[int]struct{})
+	for _, id := range excludeIds {
+		exclude[id] = struct{}{}
+	}
+	var leases []*APIKeyLease
+	for i, key := range SplitKeys(keys) {
+		if _, ok := exclude[i+1]; !ok {
+			leases = append(leases, &APIKeyLease{Id: i + 1, Engine: engine, Key: key})
+		}
+	}
+	if len(leases) == 0 {
+		return nil, ErrNoKeyAvailable
+	}
+	return leases[rand.Intn(len(leases))], nil
+}
+
+func (configKeyProvider) Report(report APIKeyReport) {
+}
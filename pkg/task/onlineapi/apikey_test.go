@@ -0,0 +1,97 @@
+package onlineapi
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"testing"
+)
+
+// testKeyEngine 模拟搜索引擎：key为limited时限流，其它key返回一条结果
+type testKeyEngine struct {
+	Quake
+	keys []string
+}
+
+func (e *testKeyEngine) Run(query string, apiKey string, pageIndex int, pageSize int, config OnlineAPIConfig) (pageResult []onlineSearchResult, sizeTotal int, err error) {
+	e.keys = append(e.keys, apiKey)
+	if apiKey == "limited" {
+		return nil, 0, fmt.Errorf("%w:too many requests", ErrKeyRateLimited)
+	}
+	return []onlineSearchResult{{IP: "127.0.0.1", Port: "80"}}, 1, nil
+}
+
+func (e *testKeyEngine) ParseContentResult(content []byte) (ipResult portscan.Result, domainResult domainscan.Result) {
+	return
+}
+
+// testKeyProvider 按顺序租用未排除的key，记录报告的结果
+type testKeyProvider struct {
+	keys    []string
+	reports []APIKeyReport
+}
+
+func (p *testKeyProvider) Lease(engine string, workspaceId int, excludeIds []int) (*APIKeyLease, error) {
+	exclude := make(map[int]bool)
+	for _, id := range excludeIds {
+		exclude[id] = true
+	}
+	for i, key := range p.keys {
+		if !exclude[i+1] {
+			return &APIKeyLease{Id: i + 1, Engine: engine, Key: key}, nil
+		}
+	}
+	return nil, ErrNoKeyAvailable
+}
+
+func (p *testKeyProvider) Report(report APIKeyReport) {
+	p.reports = append(p.reports, report)
+}
+
+func TestKeyStatus(t *testing.T) {
+	tests := map[string]string{
+		"":                KeyStatusOK,
+		"[820031] F点余额不足": KeyStatusExhausted,
+		"[-700] 账号无效":     KeyStatusInvalid,
+		"Hunter Search Error:请求太多，请稍后再试": KeyStatusRateLimited,
+		"dial tcp: i/o timeout":          "",
+	}
+	for msg, status := range tests {
+		var err error
+		if msg != "" {
+			err = errors.New(msg)
+		}
+		if got := KeyStatus(err); got != status {
+			t.Errorf("%s:%s,expected %s", msg, got, status)
+		}
+	}
+	if got := KeyStatus(fmt.Errorf("%w:quake token invalid", ErrKeyInvalid)); got != KeyStatusInvalid {
+		t.Errorf("wrapped error:%s", got)
+	}
+}
+
+func TestOnlineSearch_RotateKey(t *testing.T) {
+	provider := &testKeyProvider{keys: []string{"limited", "available"}}
+	SetKeyProvider(provider)
+	defer SetKeyProvider(nil)
+
+	engine := &testKeyEngine{}
+	s := &OnlineSearch{apiName: "quake", searchEngine: engine, Config: OnlineAPIConfig{WorkspaceId: 1, SearchPageSize: 10}}
+	pageResult, _, err := s.retriedQuery("ip=\"127.0.0.1\"", 1, 10)
+	if err != nil || len(pageResult) != 1 {
+		t.Fatalf("query with rotated key:%v %d", err, len(pageResult))
+	}
+	if len(engine.keys) != 2 || engine.keys[1] != "available" {
+		t.Errorf("used keys:%v", engine.keys)
+	}
+	if len(provider.reports) != 2 || provider.reports[0].Status != KeyStatusRateLimited || provider.reports[1].Status != KeyStatusOK || provider.reports[1].ResultCount != 1 {
+		t.Errorf("reports:%+v", provider.reports)
+	}
+	// 全部key不可用
+	provider.keys = []string{"limited"}
+	s = &OnlineSearch{apiName: "quake", searchEngine: engine, Config: OnlineAPIConfig{SearchPageSize: 10}}
+	if _, _, err = s.retriedQuery("ip=\"127.0.0.1\"", 1, 10); err == nil {
+		t.Error("no key available should fail")
+	}
+}
@@ -0,0 +1,214 @@
+package onlineapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// binaryEdgePageSize BinaryEdge每页固定返回20条结果
+const binaryEdgePageSize = 20
+
+type BinaryEdge struct {
+}
+
+// binaryEdgeEvent BinaryEdge查询结果及导出文件中的一条事件
+type binaryEdgeEvent struct {
+	Target struct {
+		IP       string `json:"ip"`
+		Port     int    `json:"port"`
+		Protocol string `json:"protocol"`
+	} `json:"target"`
+	Result struct {
+		Data struct {
+			Service struct {
+				Name    string `json:"name"`
+				Product string `json:"product"`
+				Banner  string `json:"banner"`
+			} `json:"service"`
+			Response struct {
+				URL   string `json:"url"`
+				Title string `json:"title"`
+			} `json:"response"`
+		} `json:"data"`
+	} `json:"result"`
+}
+
+// BinaryEdgeSearchResult BinaryEdge查询返回的数据
+type BinaryEdgeSearchResult struct {
+	Status   int               `json:"status"`
+	Message  string            `json:"message"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"pagesize"`
+	Total    int               `json:"total"`
+	Events   []binaryEdgeEvent `json:"events"`
+}
+
+// binaryEdgeSubscription BinaryEdge账号订阅信息，RequestsLeft为本月剩余的查询次数
+type binaryEdgeSubscription struct {
+	Message      string `json:"message"`
+	RequestsLeft int    `json:"requests_left"`
+	RequestsPlan int    `json:"requests_plan"`
+}
+
+func (b *BinaryEdge) MakeSearchSyntax(syntax map[SyntaxType]string, condition SyntaxType, checkMod SyntaxType, value string) string {
+	if condition == Not {
+		// NOT web.title:"百度"
+		return fmt.Sprintf("%s %s:\"%s\"", syntax[condition], syntax[checkMod], value)
+	}
+	// web.title:"百度"
+	return fmt.Sprintf("%s%s\"%s\"", syntax[checkMod], syntax[condition], value)
+}
+
+func (b *BinaryEdge) GetSyntaxMap() (syntax map[SyntaxType]string) {
+	syntax = make(map[SyntaxType]string)
+	syntax[And] = "AND"
+	syntax[Or] = "OR"
+	syntax[Equal] = ":"
+	syntax[Not] = "NOT"
+	syntax[After] = "(NOT SUPPORT YET)"
+	syntax[Title] = "web.title"
+	syntax[Body] = "web.body.content"
+
+	return
+}
+
+func (b *BinaryEdge) GetQueryString(domain string, config OnlineAPIConfig, filterKeyword map[string]struct{}) (query string) {
+	if config.SearchByKeyWord {
+		query = config.Target
+	} else {
+		if utils.CheckIPOrSubnet(domain) {
+			query = fmt.Sprintf("ip:\"%s\"", domain)
+		} else {
+			// BinaryEdge的host查询没有域名字段，以域名为关键字搜索
+			query = fmt.Sprintf("\"%s\"", domain)
+		}
+	}
+	if words := b.getFilterTitleKeyword(filterKeyword); len(words) > 0 {
+		query = fmt.Sprintf("(%s) AND %s", query, words)
+	}
+	if config.IsIgnoreOutofChina {
+		query = fmt.Sprintf("(%s) AND country:\"CN\"", query)
+	}
+	return
+}
+
+func (b *BinaryEdge) getFilterTitleKeyword(filterKeyword map[string]struct{}) string {
+	var words []string
+	for k := range filterKeyword {
+		words = append(words, fmt.Sprintf("NOT web.body.content:\"%s\"", k))
+	}
+
+	return strings.Join(words, " AND ")
+}
+
+// PageSize BinaryEdge每页固定返回20条结果
+func (b *BinaryEdge) PageSize(pageSize int) int {
+	return binaryEdgePageSize
+}
+
+func (b *BinaryEdge) Run(query string, apiKey string, pageIndex int, pageSize int, config OnlineAPIConfig) (pageResult []onlineSearchResult, sizeTotal int, err error) {
+	request, err := http.NewRequest(http.MethodGet, "https://api.binaryedge.io/v2/query/search", nil)
+	if err != nil {
+		return
+	}
+	params := make(url.Values)
+	params.Add("query", query)
+	params.Add("page", strconv.Itoa(pageIndex))
+	request.URL.RawQuery = params.Encode()
+	request.Header.Set("X-Key", apiKey)
+	statusCode, content, err := doAPIRequest(request, config)
+	if err != nil {
+		return
+	}
+	return b.parseBinaryEdgeSearchResult(statusCode, content)
+}
+
+// CheckAccount 查询账号订阅信息，返回本月剩余的查询次数
+func (b *BinaryEdge) CheckAccount(apiKey string, config OnlineAPIConfig) (quota int, err error) {
+	request, err := http.NewRequest(http.MethodGet, "https://api.binaryedge.io/v2/user/subscription", nil)
+	if err != nil {
+		return
+	}
+	request.Header.Set("X-Key", apiKey)
+	statusCode, content, err := doAPIRequest(request, config)
+	if err != nil {
+		return
+	}
+	var info binaryEdgeSubscription
+	json.Unmarshal(content, &info)
+	if err = checkStatusCode("BinaryEdge", statusCode, info.Message); err != nil {
+		return
+	}
+	return info.RequestsLeft, nil
+}
+
+// parseBinaryEdgeSearchResult 解析查询返回的数据，查询次数用完时返回额度耗尽
+func (b *BinaryEdge) parseBinaryEdgeSearchResult(statusCode int, content []byte) (result []onlineSearchResult, sizeTotal int, err error) {
+	var r BinaryEdgeSearchResult
+	if err = json.Unmarshal(content, &r); err != nil && statusCode == http.StatusOK {
+		return
+	}
+	if statusCode == http.StatusForbidden && strings.Contains(strings.ToLower(r.Message), "limit") {
+		return nil, 0, fmt.Errorf("%w:BinaryEdge Search Error:%s", ErrKeyExhausted, r.Message)
+	}
+	if err = checkStatusCode("BinaryEdge", statusCode, r.Message); err != nil {
+		return
+	}
+	if r.Status != 0 && r.Status != http.StatusOK {
+		return nil, 0, errors.New(fmt.Sprintf("BinaryEdge Search Error:%d %s", r.Status, r.Message))
+	}
+	sizeTotal = r.Total
+	for _, event := range r.Events {
+		result = append(result, b.eventResult(event))
+	}
+	return
+}
+
+// eventResult 将一条事件转换为查询结果
+func (b *BinaryEdge) eventResult(event binaryEdgeEvent) onlineSearchResult {
+	service := event.Result.Data.Service
+	fsr := onlineSearchResult{
+		IP:     event.Target.IP,
+		Port:   strconv.Itoa(event.Target.Port),
+		Title:  event.Result.Data.Response.Title,
+		Server: service.Product,
+	}
+	if u, err := url.Parse(event.Result.Data.Response.URL); err == nil && u.Hostname() != "" && !utils.CheckIP(u.Hostname()) {
+		fsr.Host = u.Hostname()
+	}
+	// 过滤HTTP响应的banner
+	if !strings.HasPrefix(service.Banner, "HTTP/") {
+		fsr.Banner = strings.TrimSpace(service.Banner)
+	}
+	return fsr
+}
+
+// ParseContentResult 导入查询返回的JSON数据或导出的JSON Lines格式文件
+func (b *BinaryEdge) ParseContentResult(content []byte) (ipResult portscan.Result, domainResult domainscan.Result) {
+	var results []onlineSearchResult
+	var r BinaryEdgeSearchResult
+	if err := json.Unmarshal(content, &r); err == nil && len(r.Events) > 0 {
+		for _, event := range r.Events {
+			results = append(results, b.eventResult(event))
+		}
+	} else {
+		results = parseJSONLines(content, func(line []byte) []onlineSearchResult {
+			var event binaryEdgeEvent
+			if err := json.Unmarshal(line, &event); err != nil {
+				logging.RuntimeLog.Error(err)
+				return nil
+			}
+			return []onlineSearchResult{b.eventResult(event)}
+		})
+	}
+	return makeContentResult(results, "binaryedge")
+}
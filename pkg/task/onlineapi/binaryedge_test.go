@@ -0,0 +1,47 @@
+package onlineapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+const binaryEdgeSearchContent = `{"query":"web.title:\"Example\"","page":1,"pagesize":20,"total":42,"events":[
+{"target":{"ip":"1.2.3.4","port":443,"protocol":"tcp"},"result":{"data":{"service":{"name":"https","product":"nginx","banner":"HTTP/1.1 200 OK\r\nServer: nginx"},"response":{"url":"https://www.example.com/","title":"Example"}}}},
+{"target":{"ip":"1.2.3.5","port":22,"protocol":"tcp"},"result":{"data":{"service":{"name":"ssh","product":"OpenSSH","banner":"SSH-2.0-OpenSSH_8.2p1\r\n"},"response":{}}}}]}`
+
+func TestBinaryEdge_ParseSearchResult(t *testing.T) {
+	b := &BinaryEdge{}
+	result, sizeTotal, err := b.parseBinaryEdgeSearchResult(http.StatusOK, []byte(binaryEdgeSearchContent))
+	if err != nil || sizeTotal != 42 || len(result) != 2 {
+		t.Fatalf("parse result:%v %d %d", err, sizeTotal, len(result))
+	}
+	if result[0].Host != "www.example.com" || result[0].Banner != "" || result[1].Banner != "SSH-2.0-OpenSSH_8.2p1" {
+		t.Errorf("result:%+v", result)
+	}
+	_, _, err = b.parseBinaryEdgeSearchResult(http.StatusUnauthorized, []byte(`{"status":401,"message":"Unauthorized"}`))
+	if KeyStatus(err) != KeyStatusInvalid {
+		t.Errorf("invalid key:%v", err)
+	}
+	_, _, err = b.parseBinaryEdgeSearchResult(http.StatusForbidden, []byte(`{"status":403,"message":"Request limit reached"}`))
+	if KeyStatus(err) != KeyStatusExhausted {
+		t.Errorf("exhausted key:%v", err)
+	}
+	if b.PageSize(100) != binaryEdgePageSize {
+		t.Errorf("page size:%d", b.PageSize(100))
+	}
+}
+
+func TestBinaryEdge_ParseContentResult(t *testing.T) {
+	b := &BinaryEdge{}
+	ipResult, domainResult := b.ParseContentResult([]byte(binaryEdgeSearchContent))
+	if len(ipResult.IPResult) != 2 || len(domainResult.DomainResult) != 1 {
+		t.Errorf("json:%d %d", len(ipResult.IPResult), len(domainResult.DomainResult))
+	}
+	jsonLines := `{"target":{"ip":"1.2.3.4","port":443},"result":{"data":{"response":{"url":"https://www.example.com/"}}}}
+{"target":{"ip":"1.2.3.4","port":8080},"result":{"data":{}}}
+`
+	ipResult, domainResult = b.ParseContentResult([]byte(jsonLines))
+	if len(ipResult.IPResult) != 1 || len(ipResult.IPResult["1.2.3.4"].Ports) != 2 || len(domainResult.DomainResult) != 1 {
+		t.Errorf("json lines:%d %d", len(ipResult.IPResult), len(domainResult.DomainResult))
+	}
+}
@@ -0,0 +1,274 @@
+package onlineapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// censysMaxPageSize Censys每页最多返回100个host
+const censysMaxPageSize = 100
+
+type Censys struct {
+	// cursors 分页查询的游标，key为查询语句及页码
+	cursors sync.Map
+}
+
+// censysHit Censys查询结果及导出文件中的一个host
+type censysHit struct {
+	IP       string `json:"ip"`
+	Services []struct {
+		Port              int    `json:"port"`
+		ServiceName       string `json:"service_name"`
+		TransportProtocol string `json:"transport_protocol"`
+	} `json:"services"`
+	Location struct {
+		CountryCode string `json:"country_code"`
+		City        string `json:"city"`
+	} `json:"location"`
+	DNS struct {
+		Names []string `json:"names"`
+	} `json:"dns"`
+}
+
+// CensysSearchResult Censys v2查询返回的数据
+type CensysSearchResult struct {
+	Code   int    `json:"code"`
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Result struct {
+		Total int         `json:"total"`
+		Hits  []censysHit `json:"hits"`
+		Links struct {
+			Prev string `json:"prev"`
+			Next string `json:"next"`
+		} `json:"links"`
+	} `json:"result"`
+}
+
+// censysAccountInfo Censys账号信息，剩余额度为Allowance-Used
+type censysAccountInfo struct {
+	Error string `json:"error"`
+	Quota struct {
+		Used      int `json:"used"`
+		Allowance int `json:"allowance"`
+	} `json:"quota"`
+}
+
+func (c *Censys) MakeSearchSyntax(syntax map[SyntaxType]string, condition SyntaxType, checkMod SyntaxType, value string) string {
+	if condition == Not {
+		// not services.http.response.html_title:"百度"
+		return fmt.Sprintf("%s %s:\"%s\"", syntax[condition], syntax[checkMod], value)
+	}
+	// services.http.response.html_title:"百度"
+	return fmt.Sprintf("%s%s\"%s\"", syntax[checkMod], syntax[condition], value)
+}
+
+func (c *Censys) GetSyntaxMap() (syntax map[SyntaxType]string) {
+	syntax = make(map[SyntaxType]string)
+	syntax[And] = "and"
+	syntax[Or] = "or"
+	syntax[Equal] = ":"
+	syntax[Not] = "not"
+	syntax[After] = "last_updated_at>"
+	syntax[Title] = "services.http.response.html_title"
+	syntax[Body] = "services.http.response.body"
+
+	return
+}
+
+func (c *Censys) GetQueryString(domain string, config OnlineAPIConfig, filterKeyword map[string]struct{}) (query string) {
+	if config.SearchByKeyWord {
+		query = config.Target
+	} else {
+		if utils.CheckIPOrSubnet(domain) {
+			query = fmt.Sprintf("ip:%s", domain)
+		} else {
+			query = fmt.Sprintf("dns.names:\"%s\" or dns.names:*.%s", domain, domain)
+		}
+	}
+	if words := c.getFilterTitleKeyword(filterKeyword); len(words) > 0 {
+		query = fmt.Sprintf("(%s) and %s", query, words)
+	}
+	if len(config.SearchStartTime) > 0 {
+		query = fmt.Sprintf("(%s) and last_updated_at>=\"%s\"", query, config.SearchStartTime)
+	}
+	if config.IsIgnoreOutofChina {
+		query = fmt.Sprintf("(%s) and location.country_code:\"CN\"", query)
+	}
+	return
+}
+
+func (c *Censys) getFilterTitleKeyword(filterKeyword map[string]struct{}) string {
+	var words []string
+	for k := range filterKeyword {
+		words = append(words, fmt.Sprintf("not services.http.response.body:\"%s\"", k))
+	}
+
+	return strings.Join(words, " and ")
+}
+
+// PageSize Censys每页最多返回100个host
+func (c *Censys) PageSize(pageSize int) int {
+	if pageSize <= 0 || pageSize > censysMaxPageSize {
+		return censysMaxPageSize
+	}
+	return pageSize
+}
+
+// Run 执行查询，apiKey的格式为API_ID:Secret；Censys使用游标分页，第N页使用第N-1页返回的游标
+func (c *Censys) Run(query string, apiKey string, pageIndex int, pageSize int, config OnlineAPIConfig) (pageResult []onlineSearchResult, sizeTotal int, err error) {
+	arr := strings.SplitN(apiKey, ":", 2)
+	if len(arr) != 2 {
+		err = fmt.Errorf("%w:invalid censys key %s", ErrKeyInvalid, DesensitizeKey(apiKey))
+		return
+	}
+	var cursor string
+	if pageIndex > 1 {
+		v, ok := c.cursors.Load(censysCursorKey(query, pageIndex))
+		if !ok {
+			// 上一页已是最后一页
+			return
+		}
+		cursor = v.(string)
+	}
+	request, err := http.NewRequest(http.MethodGet, "https://search.censys.io/api/v2/hosts/search", nil)
+	if err != nil {
+		return
+	}
+	params := make(url.Values)
+	params.Add("q", query)
+	params.Add("per_page", strconv.Itoa(c.PageSize(pageSize)))
+	if cursor != "" {
+		params.Add("cursor", cursor)
+	}
+	request.URL.RawQuery = params.Encode()
+	request.SetBasicAuth(arr[0], arr[1])
+	statusCode, content, err := doAPIRequest(request, config)
+	if err != nil {
+		return
+	}
+	pageResult, sizeTotal, cursor, err = c.parseCensysSearchResult(statusCode, content)
+	if err == nil && cursor != "" {
+		c.cursors.Store(censysCursorKey(query, pageIndex+1), cursor)
+	}
+	return
+}
+
+// censysCursorKey 游标的key
+func censysCursorKey(query string, pageIndex int) string {
+	return fmt.Sprintf("%d:%s", pageIndex, query)
+}
+
+// CheckAccount 查询账号信息，返回本月剩余的查询次数
+func (c *Censys) CheckAccount(apiKey string, config OnlineAPIConfig) (quota int, err error) {
+	arr := strings.SplitN(apiKey, ":", 2)
+	if len(arr) != 2 {
+		err = fmt.Errorf("%w:invalid censys key %s", ErrKeyInvalid, DesensitizeKey(apiKey))
+		return
+	}
+	request, err := http.NewRequest(http.MethodGet, "https://search.censys.io/api/v1/account", nil)
+	if err != nil {
+		return
+	}
+	request.SetBasicAuth(arr[0], arr[1])
+	statusCode, content, err := doAPIRequest(request, config)
+	if err != nil {
+		return
+	}
+	var info censysAccountInfo
+	json.Unmarshal(content, &info)
+	if err = checkStatusCode("Censys", statusCode, info.Error); err != nil {
+		return
+	}
+	if quota = info.Quota.Allowance - info.Quota.Used; quota < 0 {
+		quota = 0
+	}
+	return
+}
+
+// parseCensysSearchResult 解析查询返回的数据及下一页的游标
+func (c *Censys) parseCensysSearchResult(statusCode int, content []byte) (result []onlineSearchResult, sizeTotal int, nextCursor string, err error) {
+	var r CensysSearchResult
+	if err = json.Unmarshal(content, &r); err != nil && statusCode == http.StatusOK {
+		return
+	}
+	message := r.Error
+	if message == "" {
+		message = r.Status
+	}
+	if statusCode == http.StatusForbidden && strings.Contains(strings.ToLower(message), "quota") {
+		err = fmt.Errorf("%w:Censys Search Error:%s", ErrKeyExhausted, message)
+		return
+	}
+	if err = checkStatusCode("Censys", statusCode, message); err != nil {
+		return
+	}
+	if r.Error != "" {
+		err = errors.New(fmt.Sprintf("Censys Search Error:%s", r.Error))
+		return
+	}
+	sizeTotal = r.Result.Total
+	nextCursor = r.Result.Links.Next
+	for _, hit := range r.Result.Hits {
+		result = append(result, c.hitResult(hit)...)
+	}
+	return
+}
+
+// hitResult 将一个host转换为查询结果：每个服务一条结果，域名关联到第一个服务
+func (c *Censys) hitResult(hit censysHit) (result []onlineSearchResult) {
+	fsr := onlineSearchResult{
+		IP:      hit.IP,
+		Country: hit.Location.CountryCode,
+		City:    hit.Location.City,
+	}
+	for _, service := range hit.Services {
+		r := fsr
+		r.Port = strconv.Itoa(service.Port)
+		result = append(result, r)
+	}
+	if len(result) == 0 {
+		return
+	}
+	for i, name := range hit.DNS.Names {
+		if i == 0 {
+			result[0].Host = name
+			continue
+		}
+		r := result[0]
+		r.Host = name
+		result = append(result, r)
+	}
+	return
+}
+
+// ParseContentResult 导入查询返回的JSON数据或导出的JSON Lines格式文件（每行一个host）
+func (c *Censys) ParseContentResult(content []byte) (ipResult portscan.Result, domainResult domainscan.Result) {
+	var results []onlineSearchResult
+	var r CensysSearchResult
+	if err := json.Unmarshal(content, &r); err == nil && len(r.Result.Hits) > 0 {
+		for _, hit := range r.Result.Hits {
+			results = append(results, c.hitResult(hit)...)
+		}
+	} else {
+		results = parseJSONLines(content, func(line []byte) []onlineSearchResult {
+			var hit censysHit
+			if err := json.Unmarshal(line, &hit); err != nil {
+				logging.RuntimeLog.Error(err)
+				return nil
+			}
+			return c.hitResult(hit)
+		})
+	}
+	return makeContentResult(results, "censys")
+}
@@ -0,0 +1,64 @@
+package onlineapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+const censysSearchContent = `{"code":200,"status":"OK","result":{"query":"services.port:443","total":25,"hits":[
+{"ip":"1.2.3.4","services":[{"port":80,"service_name":"HTTP","transport_protocol":"TCP"},{"port":443,"service_name":"HTTP","transport_protocol":"TCP"}],"location":{"country_code":"US","city":"Ashburn"},"dns":{"names":["a.example.com","b.example.com"]}},
+{"ip":"1.2.3.5","services":[{"port":22,"service_name":"SSH","transport_protocol":"TCP"}],"location":{"country_code":"US","city":"Ashburn"}}],
+"links":{"prev":"","next":"eyJhZnRlciI6WzFdfQ=="}}}`
+
+func TestCensys_ParseSearchResult(t *testing.T) {
+	c := &Censys{}
+	result, sizeTotal, cursor, err := c.parseCensysSearchResult(http.StatusOK, []byte(censysSearchContent))
+	if err != nil || sizeTotal != 25 || cursor != "eyJhZnRlciI6WzFdfQ==" {
+		t.Fatalf("parse result:%v %d %s", err, sizeTotal, cursor)
+	}
+	// 3个服务及第二个域名
+	if len(result) != 4 || result[0].Host != "a.example.com" || result[2].Host != "b.example.com" || result[2].Port != "80" {
+		t.Errorf("result:%+v", result)
+	}
+	_, _, _, err = c.parseCensysSearchResult(http.StatusUnauthorized, []byte(`{"code":401,"status":"Unauthorized","error":"You must authenticate with a valid API ID and secret."}`))
+	if KeyStatus(err) != KeyStatusInvalid {
+		t.Errorf("invalid key:%v", err)
+	}
+	_, _, _, err = c.parseCensysSearchResult(http.StatusForbidden, []byte(`{"code":403,"status":"Forbidden","error":"You have used your full quota for this billing period."}`))
+	if KeyStatus(err) != KeyStatusExhausted {
+		t.Errorf("exhausted key:%v", err)
+	}
+	_, _, _, err = c.parseCensysSearchResult(http.StatusTooManyRequests, []byte(`{"code":429,"status":"Too Many Requests","error":"rate limit exceeded"}`))
+	if KeyStatus(err) != KeyStatusRateLimited {
+		t.Errorf("rate limited key:%v", err)
+	}
+	if c.PageSize(1000) != censysMaxPageSize || c.PageSize(50) != 50 {
+		t.Errorf("page size:%d %d", c.PageSize(1000), c.PageSize(50))
+	}
+}
+
+func TestCensys_RunInvalidKey(t *testing.T) {
+	c := &Censys{}
+	if _, _, err := c.Run("ip:1.2.3.4", "apiid-without-secret", 1, 100, OnlineAPIConfig{}); KeyStatus(err) != KeyStatusInvalid {
+		t.Errorf("invalid key format:%v", err)
+	}
+	// 没有上一页的游标时不再查询
+	if result, _, err := c.Run("ip:1.2.3.4", "id:secret", 2, 100, OnlineAPIConfig{}); err != nil || len(result) != 0 {
+		t.Errorf("page without cursor:%v %d", err, len(result))
+	}
+}
+
+func TestCensys_ParseContentResult(t *testing.T) {
+	c := &Censys{}
+	ipResult, domainResult := c.ParseContentResult([]byte(censysSearchContent))
+	if len(ipResult.IPResult) != 2 || len(ipResult.IPResult["1.2.3.4"].Ports) != 2 || len(domainResult.DomainResult) != 2 {
+		t.Errorf("json:%d %d", len(ipResult.IPResult), len(domainResult.DomainResult))
+	}
+	jsonLines := `{"ip":"1.2.3.4","services":[{"port":443}],"dns":{"names":["a.example.com"]}}
+{"ip":"1.2.3.6","services":[{"port":8080}]}
+`
+	ipResult, domainResult = c.ParseContentResult([]byte(jsonLines))
+	if len(ipResult.IPResult) != 2 || len(domainResult.DomainResult) != 1 {
+		t.Errorf("json lines:%d %d", len(ipResult.IPResult), len(domainResult.DomainResult))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
@@ -17,6 +18,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type FOFA struct {
@@ -76,7 +78,7 @@ func (f *FOFA) Run(query string, apiKey string, pageIndex int, pageSize int, con
 	fields := "domain,host,ip,port,title,country,city,server,banner"
 	arr := strings.Split(apiKey, ":")
 	if len(arr) != 2 {
-		err = errors.Newf("invalid fofa key:%s", apiKey)
+		err = fmt.Errorf("%w:invalid fofa key %s", ErrKeyInvalid, DesensitizeKey(apiKey))
 		return
 	}
 	request, err := http.NewRequest(http.MethodGet, "https://fofa.info/api/v1/search/all", nil)
@@ -91,17 +93,60 @@ func (f *FOFA) Run(query string, apiKey string, pageIndex int, pageSize int, con
 	params.Add("page", strconv.Itoa(pageIndex))
 	params.Add("size", strconv.Itoa(pageSize))
 	request.URL.RawQuery = params.Encode()
-	resp, err := http.DefaultClient.Do(request)
+	resp, err := proxypool.NewHTTPClient(config.Proxy, 0).Do(request)
 	if err != nil {
 		return
 	}
 	content, err := io.ReadAll(resp.Body)
 	resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		err = ErrKeyRateLimited
+		return
+	}
 	pageResult, sizeTotal, err = f.parseFofaSearchResult(content)
 
 	return
 }
 
+// CheckAccount 查询账号信息，返回剩余的API查询次数
+func (f *FOFA) CheckAccount(apiKey string, config OnlineAPIConfig) (quota int, err error) {
+	arr := strings.Split(apiKey, ":")
+	if len(arr) != 2 {
+		err = fmt.Errorf("%w:invalid fofa key %s", ErrKeyInvalid, DesensitizeKey(apiKey))
+		return
+	}
+	request, err := http.NewRequest(http.MethodGet, "https://fofa.info/api/v1/info/my", nil)
+	if err != nil {
+		return
+	}
+	params := make(url.Values)
+	params.Add("email", arr[0])
+	params.Add("key", arr[1])
+	request.URL.RawQuery = params.Encode()
+	resp, err := proxypool.NewHTTPClient(config.Proxy, 30*time.Second).Do(request)
+	if err != nil {
+		return
+	}
+	content, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		err = ErrKeyRateLimited
+		return
+	}
+	r := fofaAccountInfo{}
+	if err = json.Unmarshal(content, &r); err != nil {
+		return
+	}
+	if r.IsError {
+		err = errors.New(r.ErrorMessage)
+		return
+	}
+	return r.RemainAPIQuery, nil
+}
+
 func (f *FOFA) parseFofaSearchResult(queryResult []byte) (result []onlineSearchResult, sizeTotal int, err error) {
 	r := fofaQueryResult{}
 	err = json.Unmarshal(queryResult, &r)
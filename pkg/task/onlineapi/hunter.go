@@ -6,6 +6,8 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
@@ -14,14 +16,20 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 )
 
 type Hunter struct {
+	// restQuota 最近一次查询返回的剩余积分，hasRestQuota为false时未知
+	restQuota    int
+	hasRestQuota bool
 }
 
+var hunterQuotaRegex = regexp.MustCompile(`\d+`)
+
 // HunterServiceInfo 查询结果的返回数据
 type HunterServiceInfo struct {
 	Code    int    `json:"code"`
@@ -117,7 +125,7 @@ func (h *Hunter) Run(query string, apiKey string, pageIndex int, pageSize int, c
 	params.Add("start_time", startTime)
 	params.Add("end_time", endTime)
 	request.URL.RawQuery = params.Encode()
-	resp, err := http.DefaultClient.Do(request)
+	resp, err := proxypool.NewHTTPClient(config.Proxy, 0).Do(request)
 	if err != nil {
 		return
 	}
@@ -131,10 +139,18 @@ func (h *Hunter) Run(query string, apiKey string, pageIndex int, pageSize int, c
 		return
 	}
 	if serviceInfo.Code != 200 {
-		err = errors.Newf("Hunter Search Error:%s", serviceInfo.Message)
+		switch serviceInfo.Code {
+		case http.StatusUnauthorized:
+			err = fmt.Errorf("%w:Hunter Search Error:%s", ErrKeyInvalid, serviceInfo.Message)
+		case http.StatusTooManyRequests:
+			err = fmt.Errorf("%w:Hunter Search Error:%s", ErrKeyRateLimited, serviceInfo.Message)
+		default:
+			err = errors.Newf("Hunter Search Error:%s", serviceInfo.Message)
+		}
 		return
 	}
 	sizeTotal = serviceInfo.Data.Total
+	h.observeQuota(serviceInfo.Data.ConsumeQuota, serviceInfo.Data.RestQuota)
 	for _, data := range serviceInfo.Data.Arr {
 		qsr := onlineSearchResult{
 			IP:     data.IP,
@@ -149,6 +165,26 @@ func (h *Hunter) Run(query string, apiKey string, pageIndex int, pageSize int, c
 	return
 }
 
+// observeQuota 记录消耗及剩余的积分，积分的格式如“消耗积分：10”、“今日剩余积分：490”
+func (h *Hunter) observeQuota(consumeQuota, restQuota string) {
+	if v := hunterQuotaRegex.FindString(consumeQuota); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			metrics.OnlineAPIQuotaConsumed.WithLabelValues("hunter").Add(float64(n))
+		}
+	}
+	if v := hunterQuotaRegex.FindString(restQuota); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			metrics.OnlineAPIQuotaRemaining.WithLabelValues("hunter").Set(float64(n))
+			h.restQuota, h.hasRestQuota = n, true
+		}
+	}
+}
+
+// RemainingQuota 最近一次查询返回的今日剩余积分（hunter没有查询账号额度的接口）
+func (h *Hunter) RemainingQuota() (quota int, ok bool) {
+	return h.restQuota, h.hasRestQuota
+}
+
 func (h *Hunter) ParseContentResult(content []byte) (ipResult portscan.Result, domainResult domainscan.Result) {
 	s := custom.NewService()
 	ipResult.IPResult = make(map[string]*portscan.IPResult)
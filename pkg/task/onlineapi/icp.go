@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"io"
 	"math/rand"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -92,7 +92,15 @@ func (i *ICPQuery) RunICPQuery(domain string) *ICPInfo {
 		return nil
 	}
 	url := fmt.Sprintf("https://apidatav2.chinaz.com/single/icp?key=%s&domain=%s", i.selectOneAPIKey(), domain)
-	resp, err := http.Get(url)
+	proxy := proxypool.Get(proxypool.TaskOnlineAPI)
+	if proxy != nil {
+		logging.RuntimeLog.Infof("icp query %s via proxy egress:%s", domain, proxy.Egress)
+	}
+	resp, err := proxypool.NewHTTPClient(proxy, 0).Get(url)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		return nil
+	}
 	if resp.StatusCode != 200 {
 		logging.RuntimeLog.Errorf("get api status code:%v", resp.Status)
 		return nil
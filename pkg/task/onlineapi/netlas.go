@@ -0,0 +1,212 @@
+package onlineapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// netlasPageSize Netlas每页固定返回20条结果
+const netlasPageSize = 20
+
+type Netlas struct {
+}
+
+// netlasResponse Netlas查询结果及导出文件中的一条响应
+type netlasResponse struct {
+	Data struct {
+		IP       string `json:"ip"`
+		Port     int    `json:"port"`
+		Protocol string `json:"protocol"`
+		Host     string `json:"host"`
+		HTTP     *struct {
+			Title   string              `json:"title"`
+			Headers map[string][]string `json:"headers"`
+		} `json:"http"`
+		Geo struct {
+			Country string `json:"country"`
+			City    string `json:"city"`
+		} `json:"geo"`
+	} `json:"data"`
+}
+
+// NetlasSearchResult Netlas查询返回的数据
+type NetlasSearchResult struct {
+	Detail string           `json:"detail"`
+	Items  []netlasResponse `json:"items"`
+}
+
+// netlasCountResult Netlas查询结果数量
+type netlasCountResult struct {
+	Detail string `json:"detail"`
+	Count  int    `json:"count"`
+}
+
+func (n *Netlas) MakeSearchSyntax(syntax map[SyntaxType]string, condition SyntaxType, checkMod SyntaxType, value string) string {
+	if condition == Not {
+		// NOT http.title:"百度"
+		return fmt.Sprintf("%s %s:\"%s\"", syntax[condition], syntax[checkMod], value)
+	}
+	// http.title:"百度"
+	return fmt.Sprintf("%s%s\"%s\"", syntax[checkMod], syntax[condition], value)
+}
+
+func (n *Netlas) GetSyntaxMap() (syntax map[SyntaxType]string) {
+	syntax = make(map[SyntaxType]string)
+	syntax[And] = "AND"
+	syntax[Or] = "OR"
+	syntax[Equal] = ":"
+	syntax[Not] = "NOT"
+	syntax[After] = "last_updated:>="
+	syntax[Title] = "http.title"
+	syntax[Body] = "http.body"
+
+	return
+}
+
+func (n *Netlas) GetQueryString(domain string, config OnlineAPIConfig, filterKeyword map[string]struct{}) (query string) {
+	if config.SearchByKeyWord {
+		query = config.Target
+	} else {
+		if utils.CheckIPOrSubnet(domain) {
+			query = fmt.Sprintf("ip:\"%s\"", domain)
+		} else {
+			query = fmt.Sprintf("host:\"%s\" OR host:*.%s", domain, domain)
+		}
+	}
+	if words := n.getFilterTitleKeyword(filterKeyword); len(words) > 0 {
+		query = fmt.Sprintf("(%s) AND %s", query, words)
+	}
+	if len(config.SearchStartTime) > 0 {
+		query = fmt.Sprintf("(%s) AND last_updated:>=%s", query, config.SearchStartTime)
+	}
+	if config.IsIgnoreOutofChina {
+		query = fmt.Sprintf("(%s) AND geo.country:\"CN\"", query)
+	}
+	return
+}
+
+func (n *Netlas) getFilterTitleKeyword(filterKeyword map[string]struct{}) string {
+	var words []string
+	for k := range filterKeyword {
+		words = append(words, fmt.Sprintf("NOT http.body:\"%s\"", k))
+	}
+
+	return strings.Join(words, " AND ")
+}
+
+// PageSize Netlas每页固定返回20条结果
+func (n *Netlas) PageSize(pageSize int) int {
+	return netlasPageSize
+}
+
+// Run 执行查询，第一页时另外查询结果的数量
+func (n *Netlas) Run(query string, apiKey string, pageIndex int, pageSize int, config OnlineAPIConfig) (pageResult []onlineSearchResult, sizeTotal int, err error) {
+	if pageIndex == 1 {
+		if sizeTotal, err = n.count(query, apiKey, config); err != nil || sizeTotal == 0 {
+			return
+		}
+	}
+	request, err := http.NewRequest(http.MethodGet, "https://app.netlas.io/api/responses/", nil)
+	if err != nil {
+		return
+	}
+	params := make(url.Values)
+	params.Add("q", query)
+	params.Add("start", strconv.Itoa((pageIndex-1)*netlasPageSize))
+	request.URL.RawQuery = params.Encode()
+	request.Header.Set("X-API-Key", apiKey)
+	statusCode, content, err := doAPIRequest(request, config)
+	if err != nil {
+		return
+	}
+	pageResult, err = n.parseNetlasSearchResult(statusCode, content)
+	return
+}
+
+// count 查询结果的数量
+func (n *Netlas) count(query string, apiKey string, config OnlineAPIConfig) (count int, err error) {
+	request, err := http.NewRequest(http.MethodGet, "https://app.netlas.io/api/responses_count/", nil)
+	if err != nil {
+		return
+	}
+	params := make(url.Values)
+	params.Add("q", query)
+	request.URL.RawQuery = params.Encode()
+	request.Header.Set("X-API-Key", apiKey)
+	statusCode, content, err := doAPIRequest(request, config)
+	if err != nil {
+		return
+	}
+	var r netlasCountResult
+	if err = json.Unmarshal(content, &r); err != nil && statusCode == http.StatusOK {
+		return
+	}
+	if err = checkStatusCode("Netlas", statusCode, r.Detail); err != nil {
+		return
+	}
+	return r.Count, nil
+}
+
+// parseNetlasSearchResult 解析查询返回的数据
+func (n *Netlas) parseNetlasSearchResult(statusCode int, content []byte) (result []onlineSearchResult, err error) {
+	var r NetlasSearchResult
+	if err = json.Unmarshal(content, &r); err != nil && statusCode == http.StatusOK {
+		return
+	}
+	if err = checkStatusCode("Netlas", statusCode, r.Detail); err != nil {
+		return
+	}
+	for _, item := range r.Items {
+		result = append(result, n.responseResult(item))
+	}
+	return
+}
+
+// responseResult 将一条响应转换为查询结果
+func (n *Netlas) responseResult(item netlasResponse) onlineSearchResult {
+	fsr := onlineSearchResult{
+		IP:      item.Data.IP,
+		Port:    strconv.Itoa(item.Data.Port),
+		Country: item.Data.Geo.Country,
+		City:    item.Data.Geo.City,
+	}
+	if !utils.CheckIP(item.Data.Host) {
+		fsr.Host = item.Data.Host
+	}
+	if item.Data.HTTP != nil {
+		fsr.Title = item.Data.HTTP.Title
+		if servers := item.Data.HTTP.Headers["server"]; len(servers) > 0 {
+			fsr.Server = servers[0]
+		}
+	}
+	return fsr
+}
+
+// ParseContentResult 导入查询返回的JSON数据或下载的JSON Lines格式文件
+func (n *Netlas) ParseContentResult(content []byte) (ipResult portscan.Result, domainResult domainscan.Result) {
+	var results []onlineSearchResult
+	var r NetlasSearchResult
+	if err := json.Unmarshal(content, &r); err == nil && len(r.Items) > 0 {
+		for _, item := range r.Items {
+			results = append(results, n.responseResult(item))
+		}
+	} else {
+		results = parseJSONLines(content, func(line []byte) []onlineSearchResult {
+			var item netlasResponse
+			if err := json.Unmarshal(line, &item); err != nil {
+				logging.RuntimeLog.Error(err)
+				return nil
+			}
+			return []onlineSearchResult{n.responseResult(item)}
+		})
+	}
+	return makeContentResult(results, "netlas")
+}
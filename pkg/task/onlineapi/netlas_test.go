@@ -0,0 +1,47 @@
+package onlineapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+const netlasSearchContent = `{"items":[
+{"data":{"ip":"1.2.3.4","port":443,"protocol":"https","host":"www.example.com","http":{"title":"Example","headers":{"server":["nginx"]}},"geo":{"country":"US","city":"Ashburn"}}},
+{"data":{"ip":"1.2.3.5","port":80,"protocol":"http","host":"1.2.3.5","http":{"title":"","headers":{}},"geo":{"country":"US","city":"Ashburn"}}}]}`
+
+func TestNetlas_ParseSearchResult(t *testing.T) {
+	n := &Netlas{}
+	result, err := n.parseNetlasSearchResult(http.StatusOK, []byte(netlasSearchContent))
+	if err != nil || len(result) != 2 {
+		t.Fatalf("parse result:%v %d", err, len(result))
+	}
+	if result[0].Host != "www.example.com" || result[0].Server != "nginx" || result[1].Host != "" {
+		t.Errorf("result:%+v", result)
+	}
+	_, err = n.parseNetlasSearchResult(http.StatusUnauthorized, []byte(`{"detail":"Invalid API key."}`))
+	if KeyStatus(err) != KeyStatusInvalid {
+		t.Errorf("invalid key:%v", err)
+	}
+	_, err = n.parseNetlasSearchResult(http.StatusTooManyRequests, []byte(`{"detail":"Request was throttled."}`))
+	if KeyStatus(err) != KeyStatusRateLimited {
+		t.Errorf("rate limited key:%v", err)
+	}
+	if n.PageSize(100) != netlasPageSize {
+		t.Errorf("page size:%d", n.PageSize(100))
+	}
+}
+
+func TestNetlas_ParseContentResult(t *testing.T) {
+	n := &Netlas{}
+	ipResult, domainResult := n.ParseContentResult([]byte(netlasSearchContent))
+	if len(ipResult.IPResult) != 2 || len(domainResult.DomainResult) != 1 {
+		t.Errorf("json:%d %d", len(ipResult.IPResult), len(domainResult.DomainResult))
+	}
+	jsonLines := `{"data":{"ip":"1.2.3.4","port":443,"host":"www.example.com"}}
+{"data":{"ip":"1.2.3.6","port":22}}
+`
+	ipResult, domainResult = n.ParseContentResult([]byte(jsonLines))
+	if len(ipResult.IPResult) != 2 || len(domainResult.DomainResult) != 1 {
+		t.Errorf("json lines:%d %d", len(ipResult.IPResult), len(domainResult.DomainResult))
+	}
+}
@@ -14,6 +14,27 @@ type Engine interface {
 	ParseContentResult(content []byte) (ipResult portscan.Result, domainResult domainscan.Result)
 }
 
+// SearchEngines 支持API查询的在线资产搜索引擎
+var SearchEngines = []string{"fofa", "hunter", "quake", "shodan", "censys", "zoomeye", "netlas", "binaryedge"}
+
+// IsSource 结果来源是否为在线资产搜索引擎（包括导入的0zone结果）
+func IsSource(source string) bool {
+	if source == "0zone" {
+		return true
+	}
+	for _, s := range SearchEngines {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// PageSizeLimiter 每页结果数量固定或有上限的搜索引擎，返回实际使用的每页数量
+type PageSizeLimiter interface {
+	PageSize(pageSize int) int
+}
+
 type SyntaxType int
 
 const (
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
 	"github.com/hanc00l/nemo_go/pkg/utils"
@@ -30,6 +31,16 @@ type quakePostData struct {
 	StartTime   string   `json:"start_time,omitempty"`
 }
 
+// quakeUserInfo Quake账号信息，Credit为当前可用的积分
+type quakeUserInfo struct {
+	Message string `json:"message"`
+	Data    struct {
+		Credit               int `json:"credit"`
+		MonthRemainingCredit int `json:"month_remaining_credit"`
+		ConstantCredit       int `json:"constant_credit"`
+	} `json:"data"`
+}
+
 // QuakeServiceInfo Quake查询返回数据 from https://github.com/YetClass/QuakeAPI
 type QuakeServiceInfo struct {
 	//Code:在查询成功时为0（整形），在失败时为p00XX为字符串，因此无法保证正确unmarshal，因此取消code，用Message来判断
@@ -158,11 +169,14 @@ func (q *Quake) getFilterTitleKeyword(filterKeyword map[string]struct{}) string
 }
 
 func (q *Quake) Run(query string, apiKey string, pageIndex int, pageSize int, config OnlineAPIConfig) (pageResult []onlineSearchResult, sizeTotal int, err error) {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	proxypool.SetTransport(transport, config.Proxy)
 	client := &http.Client{
-		Timeout: time.Duration(30) * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}}
+		Timeout:   time.Duration(30) * time.Second,
+		Transport: transport,
+	}
 	data := quakePostData{
 		Query:       query,
 		Latest:      true,
@@ -194,8 +208,14 @@ func (q *Quake) Run(query string, apiKey string, pageIndex int, pageSize int, co
 		if err != nil {
 			return
 		}
+		if response.StatusCode == http.StatusTooManyRequests {
+			return nil, 0, ErrKeyRateLimited
+		}
 		if strings.Contains(string(body), "/quake/login") {
-			return nil, 0, errors.New("quake token invalid")
+			return nil, 0, fmt.Errorf("%w:quake token invalid", ErrKeyInvalid)
+		}
+		if strings.Contains(string(body), "积分不足") {
+			return nil, 0, fmt.Errorf("%w:quake credit is not enough", ErrKeyExhausted)
 		}
 		if strings.Contains(string(body), "暂不支持搜索该内容") {
 			return nil, 0, errors.New("暂不支持搜索该内容")
@@ -206,6 +226,47 @@ func (q *Quake) Run(query string, apiKey string, pageIndex int, pageSize int, co
 	return
 }
 
+// CheckAccount 查询账号信息，返回当前可用的积分
+func (q *Quake) CheckAccount(apiKey string, config OnlineAPIConfig) (quota int, err error) {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	proxypool.SetTransport(transport, config.Proxy)
+	client := &http.Client{
+		Timeout:   time.Duration(30) * time.Second,
+		Transport: transport,
+	}
+	request, err := http.NewRequest(http.MethodGet, "https://quake.360.net/api/v3/user/info", nil)
+	if err != nil {
+		return
+	}
+	request.Header.Set("X-QuakeToken", apiKey)
+	request.Header.Add("User-Agent", userAgent)
+	response, err := client.Do(request)
+	if err != nil {
+		return
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return
+	}
+	if response.StatusCode == http.StatusTooManyRequests {
+		return 0, ErrKeyRateLimited
+	}
+	if response.StatusCode == http.StatusUnauthorized || strings.Contains(string(body), "/quake/login") {
+		return 0, fmt.Errorf("%w:quake token invalid", ErrKeyInvalid)
+	}
+	var info quakeUserInfo
+	if err = json.Unmarshal(body, &info); err != nil {
+		return
+	}
+	if strings.HasPrefix(info.Message, "Successful") == false {
+		return 0, errors.New(fmt.Sprintf("Quake Account Error:%s", info.Message))
+	}
+	return info.Data.Credit, nil
+}
+
 func (q *Quake) parseQuakeSearchResult(queryResult []byte) (result []onlineSearchResult, finish bool, sizeTotal int) {
 	var serviceInfo QuakeServiceInfo
 	err := json.Unmarshal(queryResult, &serviceInfo)
@@ -1,13 +1,22 @@
 package onlineapi
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
 	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type OnlineAPIConfig struct {
@@ -25,6 +34,16 @@ type OnlineAPIConfig struct {
 	SearchLimitCount   int    `json:"searchlimitcount"`
 	SearchPageSize     int    `json:"searchpagesize"`
 	WorkspaceId        int    `json:"workspaceId"`
+	// Proxy 查询API使用的代理，由worker的代理池分配
+	Proxy *proxypool.Proxy `json:"-"`
+	// 任务ID，用于关联任务日志
+	TaskId     string `json:"-"`
+	MainTaskId string `json:"-"`
+}
+
+// log 任务的日志
+func (c *OnlineAPIConfig) log() *logrus.Entry {
+	return logging.NewTaskLog(c.TaskId, c.MainTaskId, c.WorkspaceId)
 }
 
 type ICPQueryConfig struct {
@@ -45,6 +64,7 @@ type onlineSearchResult struct {
 	City    string
 	Server  string
 	Banner  string
+	Egress  string
 }
 
 type fofaQueryResult struct {
@@ -56,6 +76,16 @@ type fofaQueryResult struct {
 	ErrorMessage string     `json:"errmsg"`
 }
 
+// fofaAccountInfo 账号信息，RemainAPIQuery为剩余的API查询次数
+type fofaAccountInfo struct {
+	IsError        bool   `json:"error"`
+	ErrorMessage   string `json:"errmsg"`
+	Email          string `json:"email"`
+	IsVIP          bool   `json:"isvip"`
+	RemainAPIQuery int    `json:"remain_api_query"`
+	RemainAPIData  int    `json:"remain_api_data"`
+}
+
 type icpQueryResult struct {
 	StateCode int     `json:"StateCode"`
 	Reason    string  `json:"Reason"`
@@ -120,6 +150,13 @@ func parseIpPort(ipResult *portscan.Result, fsr onlineSearchResult, source strin
 			Content: fsr.Banner,
 		})
 	}
+	if fsr.Egress != "" {
+		ipResult.SetPortAttr(fsr.IP, portNumber, portscan.PortAttrResult{
+			Source:  source,
+			Tag:     proxypool.EgressTag,
+			Content: fsr.Egress,
+		})
+	}
 }
 
 // parseDomainIP 解析搜索结果中的域名记录
@@ -164,4 +201,64 @@ func parseDomainIP(domainResult *domainscan.Result, fsr onlineSearchResult, sour
 			Content: fsr.Banner,
 		})
 	}
+	if fsr.Egress != "" {
+		domainResult.SetDomainAttr(domain, domainscan.DomainAttrResult{
+			Source:  source,
+			Tag:     proxypool.EgressTag,
+			Content: fsr.Egress,
+		})
+	}
+}
+
+// doAPIRequest 通过代理发送在线API的请求，返回响应的状态码及内容
+func doAPIRequest(request *http.Request, config OnlineAPIConfig) (statusCode int, body []byte, err error) {
+	request.Header.Set("User-Agent", userAgent)
+	resp, err := proxypool.NewHTTPClient(config.Proxy, 30*time.Second).Do(request)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	body, err = io.ReadAll(resp.Body)
+	return resp.StatusCode, body, err
+}
+
+// checkStatusCode 根据在线API响应的状态码判断key是否可用：401无效，402额度耗尽，429限流
+func checkStatusCode(apiName string, statusCode int, message string) error {
+	switch statusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%w:%s Search Error:%s", ErrKeyInvalid, apiName, message)
+	case http.StatusPaymentRequired:
+		return fmt.Errorf("%w:%s Search Error:%s", ErrKeyExhausted, apiName, message)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w:%s Search Error:%s", ErrKeyRateLimited, apiName, message)
+	}
+	return errors.New(fmt.Sprintf("%s Search Error:%d %s", apiName, statusCode, message))
+}
+
+// parseJSONLines 逐行解析JSON Lines格式的导出文件
+func parseJSONLines(content []byte, parseLine func(line []byte) []onlineSearchResult) (results []onlineSearchResult) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		results = append(results, parseLine(line)...)
+	}
+	return
+}
+
+// makeContentResult 将导入的结果转换为IP和域名结果
+func makeContentResult(results []onlineSearchResult, source string) (ipResult portscan.Result, domainResult domainscan.Result) {
+	ipResult.IPResult = make(map[string]*portscan.IPResult)
+	domainResult.DomainResult = make(map[string]*domainscan.DomainResult)
+	btc := custom.NewBlackTargetCheck(custom.CheckAll)
+	for _, fsr := range results {
+		parseIpPort(&ipResult, fsr, source, btc)
+		parseDomainIP(&domainResult, fsr, source, btc)
+	}
+	return
 }
@@ -6,10 +6,11 @@ import (
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
-	"math/rand"
 	"os"
 	"path/filepath"
 	"strings"
@@ -17,10 +18,11 @@ import (
 )
 
 type OnlineSearch struct {
-	apiName      string
-	apiKey       string
-	apiKeyInUse  string
-	searchEngine Engine
+	apiName string
+	// lease 当前使用的key，excludeKeyIds为已不可用的key
+	lease         *APIKeyLease
+	excludeKeyIds []int
+	searchEngine  Engine
 	//Config 配置参数：查询的目标、关联的组织
 	Config OnlineAPIConfig
 	//Result quake api查询后的结果
@@ -32,24 +34,12 @@ type OnlineSearch struct {
 }
 
 func NewOnlineAPISearch(config OnlineAPIConfig, apiName string) *OnlineSearch {
-	s := &OnlineSearch{Config: config, apiName: apiName}
-	switch apiName {
-	case "fofa":
-		s.searchEngine = new(FOFA)
-		s.apiKey = conf.GlobalWorkerConfig().API.Fofa.Key
-	case "hunter":
-		s.searchEngine = new(Hunter)
-		s.apiKey = conf.GlobalWorkerConfig().API.Hunter.Key
-	case "quake":
-		s.searchEngine = new(Quake)
-		s.apiKey = conf.GlobalWorkerConfig().API.Quake.Key
-	case "0zone":
-		s.searchEngine = new(ZeroZone)
-	}
+	s := &OnlineSearch{Config: config, apiName: apiName, searchEngine: NewEngine(apiName)}
 	s.Config.SearchLimitCount = conf.GlobalWorkerConfig().API.SearchLimitCount
 	if s.Config.SearchPageSize = conf.GlobalWorkerConfig().API.SearchPageSize; s.Config.SearchPageSize <= 0 {
 		s.Config.SearchPageSize = pageSizeDefault
 	}
+	s.Config.Proxy = proxypool.Get(proxypool.TaskOnlineAPI)
 
 	return s
 }
@@ -57,13 +47,13 @@ func NewOnlineAPISearch(config OnlineAPIConfig, apiName string) *OnlineSearch {
 // Do 执行查询
 func (s *OnlineSearch) Do() {
 	if s.searchEngine == nil {
-		logging.RuntimeLog.Errorf("invalid api:%s,exit search", s.apiName)
+		s.Config.log().Errorf("invalid api:%s,exit search", s.apiName)
 		logging.CLILog.Errorf("invalid api:%s,exit search", s.apiName)
 		return
 	}
-	if len(s.apiKey) == 0 {
-		logging.RuntimeLog.Warningf("no %s api key,exit search", s.apiName)
-		logging.CLILog.Warningf("no %s api key,exit search", s.apiName)
+	if err := s.leaseKey(); err != nil {
+		s.Config.log().Warningf("no %s api key,exit search:%v", s.apiName, err)
+		logging.CLILog.Warningf("no %s api key,exit search:%v", s.apiName, err)
 		return
 	}
 	filterKeyword := s.loadFilterKeyword()
@@ -74,7 +64,7 @@ func (s *OnlineSearch) Do() {
 			continue
 		}
 		if btc.CheckBlack(domain) {
-			logging.RuntimeLog.Warningf("%s is in blacklist,skip...", domain)
+			s.Config.log().Warningf("%s is in blacklist,skip...", domain)
 			continue
 		}
 		s.Query(domain, filterKeyword)
@@ -85,27 +75,28 @@ func (s *OnlineSearch) Do() {
 // Query 查询一个domain
 func (s *OnlineSearch) Query(domain string, filterKeyword map[string]struct{}) {
 	query := s.searchEngine.GetQueryString(domain, s.Config, filterKeyword)
-	pageResult, sizeTotal, err := s.retriedQuery(query, 1, s.Config.SearchPageSize)
+	pageSize := s.pageSize()
+	pageResult, sizeTotal, err := s.retriedQuery(query, 1, pageSize)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		s.Config.log().Error(err)
 		logging.CLILog.Error(err)
 		return
 	}
 	if s.Config.SearchLimitCount > 0 && sizeTotal > s.Config.SearchLimitCount {
 		msg := fmt.Sprintf("%s search %s result total:%d, limited to:%d", s.apiName, domain, sizeTotal, s.Config.SearchLimitCount)
-		logging.RuntimeLog.Warning(msg)
+		s.Config.log().Warning(msg)
 		logging.CLILog.Warning(msg)
 		sizeTotal = s.Config.SearchLimitCount
 	}
 	s.Result = append(s.Result, pageResult...)
-	pageTotalNum := sizeTotal / s.Config.SearchPageSize
-	if sizeTotal%s.Config.SearchPageSize > 0 {
+	pageTotalNum := sizeTotal / pageSize
+	if sizeTotal%pageSize > 0 {
 		pageTotalNum++
 	}
 	for i := 2; i <= pageTotalNum; i++ {
-		pageResult, _, err = s.retriedQuery(query, i, s.Config.SearchPageSize)
+		pageResult, _, err = s.retriedQuery(query, i, pageSize)
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			s.Config.log().Error(err)
 			logging.CLILog.Error(err)
 			return
 		}
@@ -114,84 +105,82 @@ func (s *OnlineSearch) Query(domain string, filterKeyword map[string]struct{}) {
 	}
 }
 
+// pageSize 每页的查询数量，部份搜索引擎每页的数量固定或有上限
+func (s *OnlineSearch) pageSize() int {
+	if l, ok := s.searchEngine.(PageSizeLimiter); ok {
+		if pageSize := l.PageSize(s.Config.SearchPageSize); pageSize > 0 {
+			return pageSize
+		}
+	}
+	return s.Config.SearchPageSize
+}
+
 // ParseContentResult 从文件内容中导入结果
 func (s *OnlineSearch) ParseContentResult(content []byte) {
 	s.IpResult, s.DomainResult = s.searchEngine.ParseContentResult(content)
 }
 
-// retriedQuery 执行一次查询，允许重试N次
+// retriedQuery 执行一次查询，允许重试N次；key限流、额度耗尽或无效时从key池更换key，不计入重试次数
 func (s *OnlineSearch) retriedQuery(query string, pageIndex int, pageSize int) (pageResult []onlineSearchResult, sizeTotal int, err error) {
-	RETRIED := 3
-	allKeys := strings.Split(s.apiKey, ",")
-	if len(allKeys) > 1 {
-		RETRIED = 2 * len(allKeys)
-	}
-	if s.apiKeyInUse == "" {
-		if s.apiKeyInUse = s.getOneAPIKey(); s.apiKeyInUse == "" {
-			return nil, 0, errors.New(fmt.Sprintf("%s no  key to available", s.apiName))
-		}
-	}
+	const RETRIED = 3
 	var retriedCount int
-	for ; retriedCount < RETRIED; retriedCount++ {
-		pageResult, sizeTotal, err = s.searchEngine.Run(query, s.apiKeyInUse, pageIndex, pageSize, s.Config)
+	for retriedCount < RETRIED {
+		if s.lease == nil {
+			if err = s.leaseKey(); err != nil {
+				return nil, 0, errors.New(fmt.Sprintf("%s no key to available:%v", s.apiName, err))
+			}
+		}
+		pageResult, sizeTotal, err = s.searchEngine.Run(query, s.lease.Key, pageIndex, pageSize, s.Config)
+		metrics.ObserveOnlineAPI(s.apiName, err)
+		status := KeyStatus(err)
+		s.reportKey(status, err, len(pageResult))
 		if err == nil {
 			return
 		}
-		msg := fmt.Sprintf("api %s with key %s has error:%v", s.apiName, s.desensitizationAPIKey(), err)
-		logging.RuntimeLog.Error(msg)
+		msg := fmt.Sprintf("api %s with key %s has error:%v", s.apiName, DesensitizeKey(s.lease.Key), err)
+		s.Config.log().Error(msg)
 		logging.CLILog.Error(msg)
-
-		if s.apiKeyInUse = s.getOneAPIKey(); s.apiKeyInUse == "" {
-			return nil, 0, errors.New(fmt.Sprintf("%s no  key to available", s.apiName))
+		if status != "" {
+			s.excludeKeyIds = append(s.excludeKeyIds, s.lease.Id)
+			s.lease = nil
+			continue
 		}
+		retriedCount++
 	}
-	if retriedCount >= RETRIED {
-		return nil, 0, errors.New(fmt.Sprintf("%s search retried failed to over max", s.apiName))
-	}
-	return
+	return nil, 0, errors.New(fmt.Sprintf("%s search retried failed to over max", s.apiName))
 }
 
-// desensitizationAPIKey 脱敏APIKey，格式为xxxx****xxxx或者xxxx****
-func (s *OnlineSearch) desensitizationAPIKey() string {
-	l := len(s.apiKeyInUse)
-	if l == 0 {
-		return ""
-	}
-	if l >= 4 {
-		keyPre := s.apiKeyInUse[:4]
-		if l >= 8 {
-			var keyMid, keyEnd string
-			if l >= 12 {
-				keyMid = "****"
-				keyEnd = s.apiKeyInUse[l-4:]
-			} else {
-				keyMid = ""
-				keyEnd = "****"
-			}
-			return fmt.Sprintf("%s%s%s", keyPre, keyMid, keyEnd)
-		}
-		return fmt.Sprintf("%s****", keyPre)
+// leaseKey 从key池租用一个key
+func (s *OnlineSearch) leaseKey() (err error) {
+	if s.lease != nil {
+		return nil
 	}
-	return "****"
+	s.lease, err = keyProvider.Lease(s.apiName, s.Config.WorkspaceId, s.excludeKeyIds)
+	if err == nil && s.lease == nil {
+		err = ErrNoKeyAvailable
+	}
+	return
 }
 
-// getOneAPIKey 选择一个查询的key
-func (s *OnlineSearch) getOneAPIKey() string {
-	allKeys := strings.Split(s.apiKey, ",")
-
-	if len(allKeys) == 0 {
-		return ""
+// reportKey 向key池报告key的使用结果
+func (s *OnlineSearch) reportKey(status string, err error, resultCount int) {
+	report := APIKeyReport{
+		Id:          s.lease.Id,
+		Engine:      s.apiName,
+		WorkspaceId: s.Config.WorkspaceId,
+		Status:      status,
+		Quota:       -1,
+		ResultCount: resultCount,
 	}
-	if len(allKeys) == 1 {
-		return allKeys[0]
+	if err != nil {
+		report.Error = err.Error()
 	}
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	for {
-		n := r.Intn(len(allKeys))
-		if allKeys[n] != s.apiKeyInUse {
-			return allKeys[n]
+	if qr, ok := s.searchEngine.(QuotaReporter); ok {
+		if quota, ok := qr.RemainingQuota(); ok {
+			report.Quota = quota
 		}
 	}
+	keyProvider.Report(report)
 }
 
 // processResult 转换查询的IP和域名结果保存
@@ -201,6 +190,9 @@ func (s *OnlineSearch) processResult() {
 
 	btc := custom.NewBlackTargetCheck(custom.CheckAll)
 	for _, fsr := range s.Result {
+		if s.Config.Proxy != nil {
+			fsr.Egress = s.Config.Proxy.Egress
+		}
 		parseIpPort(&s.IpResult, fsr, s.apiName, btc)
 		parseDomainIP(&s.DomainResult, fsr, s.apiName, btc)
 	}
@@ -213,7 +205,7 @@ func (s *OnlineSearch) loadFilterKeyword() (filterKeyword map[string]struct{}) {
 	filterKeyword = make(map[string]struct{})
 	inputFile, err := os.Open(filepath.Join(conf.GetRootPath(), "thirdparty/custom/onlineapi_filter_keyword.txt"))
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		s.Config.log().Error(err)
 		logging.CLILog.Error(err)
 		return
 	}
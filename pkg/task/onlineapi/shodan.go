@@ -0,0 +1,231 @@
+package onlineapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// shodanPageSize Shodan每页固定返回100条结果
+const shodanPageSize = 100
+
+type Shodan struct {
+}
+
+// shodanBanner Shodan查询结果及导出文件中的一条banner
+type shodanBanner struct {
+	IPStr     string   `json:"ip_str"`
+	Port      int      `json:"port"`
+	Transport string   `json:"transport"`
+	Product   string   `json:"product"`
+	Hostnames []string `json:"hostnames"`
+	Domains   []string `json:"domains"`
+	HTTP      *struct {
+		Host   string `json:"host"`
+		Title  string `json:"title"`
+		Server string `json:"server"`
+	} `json:"http"`
+	Location struct {
+		CountryCode string `json:"country_code"`
+		City        string `json:"city"`
+	} `json:"location"`
+}
+
+// ShodanSearchResult Shodan查询返回的数据
+type ShodanSearchResult struct {
+	Error   string         `json:"error"`
+	Total   int            `json:"total"`
+	Matches []shodanBanner `json:"matches"`
+}
+
+// shodanAPIInfo Shodan账号信息，QueryCredits为剩余的查询积分
+type shodanAPIInfo struct {
+	Error        string `json:"error"`
+	QueryCredits int    `json:"query_credits"`
+	ScanCredits  int    `json:"scan_credits"`
+	Plan         string `json:"plan"`
+}
+
+func (s *Shodan) MakeSearchSyntax(syntax map[SyntaxType]string, condition SyntaxType, checkMod SyntaxType, value string) string {
+	if condition == Not {
+		// -http.title:"百度"
+		return fmt.Sprintf("%s%s:\"%s\"", syntax[condition], syntax[checkMod], value)
+	}
+	// http.title:"百度"
+	return fmt.Sprintf("%s%s\"%s\"", syntax[checkMod], syntax[condition], value)
+}
+
+func (s *Shodan) GetSyntaxMap() (syntax map[SyntaxType]string) {
+	syntax = make(map[SyntaxType]string)
+	syntax[And] = ""
+	syntax[Or] = "OR"
+	syntax[Equal] = ":"
+	syntax[Not] = "-"
+	syntax[After] = "after:"
+	syntax[Title] = "http.title"
+	syntax[Body] = "http.html"
+
+	return
+}
+
+func (s *Shodan) GetQueryString(domain string, config OnlineAPIConfig, filterKeyword map[string]struct{}) (query string) {
+	if config.SearchByKeyWord {
+		query = config.Target
+	} else {
+		if utils.CheckIPOrSubnet(domain) {
+			query = fmt.Sprintf("net:%s", domain)
+		} else {
+			query = fmt.Sprintf("hostname:\"%s\"", domain)
+		}
+	}
+	if words := s.getFilterTitleKeyword(filterKeyword); len(words) > 0 {
+		query = fmt.Sprintf("%s %s", query, words)
+	}
+	if len(config.SearchStartTime) > 0 {
+		// after的日期格式为dd/mm/yyyy
+		if st, err := time.Parse("2006-01-02", config.SearchStartTime); err == nil {
+			query = fmt.Sprintf("%s after:\"%s\"", query, st.Format("02/01/2006"))
+		}
+	}
+	if config.IsIgnoreOutofChina {
+		query = fmt.Sprintf("%s country:\"CN\"", query)
+	}
+	return
+}
+
+func (s *Shodan) getFilterTitleKeyword(filterKeyword map[string]struct{}) string {
+	var words []string
+	for k := range filterKeyword {
+		words = append(words, fmt.Sprintf("-http.html:\"%s\"", k))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// PageSize Shodan每页固定返回100条结果
+func (s *Shodan) PageSize(pageSize int) int {
+	return shodanPageSize
+}
+
+func (s *Shodan) Run(query string, apiKey string, pageIndex int, pageSize int, config OnlineAPIConfig) (pageResult []onlineSearchResult, sizeTotal int, err error) {
+	request, err := http.NewRequest(http.MethodGet, "https://api.shodan.io/shodan/host/search", nil)
+	if err != nil {
+		return
+	}
+	params := make(url.Values)
+	params.Add("key", apiKey)
+	params.Add("query", query)
+	params.Add("page", strconv.Itoa(pageIndex))
+	params.Add("minify", "true")
+	request.URL.RawQuery = params.Encode()
+	statusCode, content, err := doAPIRequest(request, config)
+	if err != nil {
+		return
+	}
+	return s.parseShodanSearchResult(statusCode, content)
+}
+
+// CheckAccount 查询账号信息，返回剩余的查询积分
+func (s *Shodan) CheckAccount(apiKey string, config OnlineAPIConfig) (quota int, err error) {
+	request, err := http.NewRequest(http.MethodGet, "https://api.shodan.io/api-info", nil)
+	if err != nil {
+		return
+	}
+	params := make(url.Values)
+	params.Add("key", apiKey)
+	request.URL.RawQuery = params.Encode()
+	statusCode, content, err := doAPIRequest(request, config)
+	if err != nil {
+		return
+	}
+	var info shodanAPIInfo
+	json.Unmarshal(content, &info)
+	if err = checkStatusCode("Shodan", statusCode, info.Error); err != nil {
+		return
+	}
+	return info.QueryCredits, nil
+}
+
+// parseShodanSearchResult 解析查询返回的数据，查询积分不足时返回额度耗尽
+func (s *Shodan) parseShodanSearchResult(statusCode int, content []byte) (result []onlineSearchResult, sizeTotal int, err error) {
+	var r ShodanSearchResult
+	if err = json.Unmarshal(content, &r); err != nil && statusCode == http.StatusOK {
+		return
+	}
+	if strings.Contains(strings.ToLower(r.Error), "insufficient query credits") {
+		return nil, 0, fmt.Errorf("%w:Shodan Search Error:%s", ErrKeyExhausted, r.Error)
+	}
+	if err = checkStatusCode("Shodan", statusCode, r.Error); err != nil {
+		return
+	}
+	if r.Error != "" {
+		return nil, 0, errors.New(fmt.Sprintf("Shodan Search Error:%s", r.Error))
+	}
+	sizeTotal = r.Total
+	for _, banner := range r.Matches {
+		result = append(result, s.bannerResult(banner)...)
+	}
+	return
+}
+
+// bannerResult 将一条banner转换为查询结果，每个hostname对应一条结果
+func (s *Shodan) bannerResult(banner shodanBanner) (result []onlineSearchResult) {
+	fsr := onlineSearchResult{
+		IP:      banner.IPStr,
+		Port:    strconv.Itoa(banner.Port),
+		Server:  banner.Product,
+		Country: banner.Location.CountryCode,
+		City:    banner.Location.City,
+	}
+	if len(banner.Domains) > 0 {
+		fsr.Domain = banner.Domains[0]
+	}
+	if banner.HTTP != nil {
+		fsr.Title = banner.HTTP.Title
+		if banner.HTTP.Server != "" {
+			fsr.Server = banner.HTTP.Server
+		}
+		if banner.HTTP.Host != "" && !utils.CheckIP(banner.HTTP.Host) {
+			fsr.Host = banner.HTTP.Host
+		}
+	}
+	if fsr.Host != "" || len(banner.Hostnames) == 0 {
+		return []onlineSearchResult{fsr}
+	}
+	for _, hostname := range banner.Hostnames {
+		r := fsr
+		r.Host = hostname
+		result = append(result, r)
+	}
+	return
+}
+
+// ParseContentResult 导入查询返回的JSON数据或下载的JSON Lines格式文件
+func (s *Shodan) ParseContentResult(content []byte) (ipResult portscan.Result, domainResult domainscan.Result) {
+	var results []onlineSearchResult
+	var r ShodanSearchResult
+	if err := json.Unmarshal(content, &r); err == nil && len(r.Matches) > 0 {
+		for _, banner := range r.Matches {
+			results = append(results, s.bannerResult(banner)...)
+		}
+	} else {
+		results = parseJSONLines(content, func(line []byte) []onlineSearchResult {
+			var banner shodanBanner
+			if err := json.Unmarshal(line, &banner); err != nil {
+				logging.RuntimeLog.Error(err)
+				return nil
+			}
+			return s.bannerResult(banner)
+		})
+	}
+	return makeContentResult(results, "shodan")
+}
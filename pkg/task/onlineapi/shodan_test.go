@@ -0,0 +1,47 @@
+package onlineapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+const shodanSearchContent = `{"total":2,"matches":[
+{"ip_str":"1.2.3.4","port":443,"transport":"tcp","product":"nginx","hostnames":["a.example.com","b.example.com"],"domains":["example.com"],"http":{"host":"","title":"Example","server":"nginx/1.20"},"location":{"country_code":"US","city":"Ashburn"}},
+{"ip_str":"1.2.3.5","port":22,"transport":"tcp","product":"OpenSSH","hostnames":[],"domains":[],"location":{"country_code":"US","city":"Ashburn"}}]}`
+
+func TestShodan_ParseSearchResult(t *testing.T) {
+	s := &Shodan{}
+	result, sizeTotal, err := s.parseShodanSearchResult(http.StatusOK, []byte(shodanSearchContent))
+	if err != nil || sizeTotal != 2 || len(result) != 3 {
+		t.Fatalf("parse result:%v %d %d", err, sizeTotal, len(result))
+	}
+	if result[0].Host != "a.example.com" || result[0].Server != "nginx/1.20" || result[0].Title != "Example" {
+		t.Errorf("result:%+v", result[0])
+	}
+	_, _, err = s.parseShodanSearchResult(http.StatusUnauthorized, []byte(`{"error":"Invalid API key"}`))
+	if KeyStatus(err) != KeyStatusInvalid {
+		t.Errorf("invalid key:%v", err)
+	}
+	_, _, err = s.parseShodanSearchResult(http.StatusForbidden, []byte(`{"error":"Insufficient query credits, please upgrade your API plan or wait for the monthly limit to reset"}`))
+	if KeyStatus(err) != KeyStatusExhausted {
+		t.Errorf("exhausted key:%v", err)
+	}
+	if s.PageSize(1000) != shodanPageSize {
+		t.Errorf("page size:%d", s.PageSize(1000))
+	}
+}
+
+func TestShodan_ParseContentResult(t *testing.T) {
+	s := &Shodan{}
+	ipResult, domainResult := s.ParseContentResult([]byte(shodanSearchContent))
+	if len(ipResult.IPResult) != 2 || len(domainResult.DomainResult) != 2 {
+		t.Errorf("json:%d %d", len(ipResult.IPResult), len(domainResult.DomainResult))
+	}
+	jsonLines := `{"ip_str":"1.2.3.4","port":443,"hostnames":["a.example.com"]}
+{"ip_str":"1.2.3.4","port":80,"hostnames":[]}
+`
+	ipResult, domainResult = s.ParseContentResult([]byte(jsonLines))
+	if len(ipResult.IPResult) != 1 || len(ipResult.IPResult["1.2.3.4"].Ports) != 2 || len(domainResult.DomainResult) != 1 {
+		t.Errorf("json lines:%d %d", len(ipResult.IPResult), len(domainResult.DomainResult))
+	}
+}
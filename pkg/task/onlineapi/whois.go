@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/likexian/whois"
 	whoisparser "github.com/likexian/whois-parser"
@@ -59,7 +60,17 @@ func (w *Whois) Do() {
 // runWhoisQuery 执行查询一个whois
 func (w *Whois) runWhoisQuery(domain string) (whoisInfoResult *whoisparser.WhoisInfo) {
 	c := whois.NewClient()
-	c.SetDialer(proxy.FromEnvironment())
+	if p := proxypool.Get(proxypool.TaskOnlineAPI); p != nil {
+		dialer, err := proxypool.Dialer(p)
+		if err != nil {
+			logging.RuntimeLog.Error(err)
+			return
+		}
+		logging.RuntimeLog.Infof("whois query %s via proxy egress:%s", domain, p.Egress)
+		c.SetDialer(dialer)
+	} else {
+		c.SetDialer(proxy.FromEnvironment())
+	}
 	text, err := c.Whois(domain, "")
 	if err != nil {
 		return
@@ -0,0 +1,203 @@
+package onlineapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// zoomeyeSuccessCode ZoomEye查询成功的返回码
+const zoomeyeSuccessCode = 60000
+
+type ZoomEye struct {
+}
+
+type zoomeyePostData struct {
+	QBase64  string `json:"qbase64"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"pagesize"`
+	Fields   string `json:"fields"`
+}
+
+// zoomeyeStrings ZoomEye返回的字段可能为字符串或字符串数组（如title）
+type zoomeyeStrings []string
+
+func (z *zoomeyeStrings) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		if s != "" {
+			*z = zoomeyeStrings{s}
+		}
+		return nil
+	}
+	var arr []string
+	if err := json.Unmarshal(data, &arr); err != nil {
+		return err
+	}
+	*z = arr
+	return nil
+}
+
+// zoomeyeAsset ZoomEye查询结果及导出文件中的一条资产
+type zoomeyeAsset struct {
+	IP          string         `json:"ip"`
+	Port        int            `json:"port"`
+	Domain      string         `json:"domain"`
+	Hostname    string         `json:"hostname"`
+	Title       zoomeyeStrings `json:"title"`
+	Product     string         `json:"product"`
+	Service     string         `json:"service"`
+	CountryName string         `json:"country.name"`
+	CityName    string         `json:"city.name"`
+}
+
+// ZoomEyeSearchResult ZoomEye v2查询返回的数据
+type ZoomEyeSearchResult struct {
+	Code    int            `json:"code"`
+	Message string         `json:"message"`
+	Total   int            `json:"total"`
+	Data    []zoomeyeAsset `json:"data"`
+}
+
+func (z *ZoomEye) MakeSearchSyntax(syntax map[SyntaxType]string, condition SyntaxType, checkMod SyntaxType, value string) string {
+	return fmt.Sprintf("%s%s\"%s\"", syntax[checkMod], syntax[condition], value)
+}
+
+func (z *ZoomEye) GetSyntaxMap() (syntax map[SyntaxType]string) {
+	syntax = make(map[SyntaxType]string)
+	syntax[And] = "&&"
+	syntax[Or] = "||"
+	syntax[Equal] = "="
+	syntax[Not] = "!="
+	syntax[After] = "after="
+	syntax[Title] = "title"
+	syntax[Body] = "http.body"
+
+	return
+}
+
+func (z *ZoomEye) GetQueryString(domain string, config OnlineAPIConfig, filterKeyword map[string]struct{}) (query string) {
+	if config.SearchByKeyWord {
+		query = config.Target
+	} else {
+		if utils.CheckIP(domain) {
+			query = fmt.Sprintf("ip=\"%s\"", domain)
+		} else if utils.CheckIPOrSubnet(domain) {
+			query = fmt.Sprintf("cidr=\"%s\"", domain)
+		} else {
+			query = fmt.Sprintf("domain=\"%s\"", domain)
+		}
+	}
+	if words := z.getFilterTitleKeyword(filterKeyword); len(words) > 0 {
+		query = fmt.Sprintf("(%s) && (%s)", query, words)
+	}
+	if len(config.SearchStartTime) > 0 {
+		query = fmt.Sprintf("(%s) && after=\"%s\"", query, config.SearchStartTime)
+	}
+	if config.IsIgnoreOutofChina {
+		query = fmt.Sprintf("(%s) && country=\"CN\" && subdivisions!=\"Hong Kong\"", query)
+	}
+	return
+}
+
+func (z *ZoomEye) getFilterTitleKeyword(filterKeyword map[string]struct{}) string {
+	var words []string
+	for k := range filterKeyword {
+		words = append(words, fmt.Sprintf("http.body!=\"%s\"", k))
+	}
+
+	return strings.Join(words, " && ")
+}
+
+func (z *ZoomEye) Run(query string, apiKey string, pageIndex int, pageSize int, config OnlineAPIConfig) (pageResult []onlineSearchResult, sizeTotal int, err error) {
+	data := zoomeyePostData{
+		QBase64:  base64.StdEncoding.EncodeToString([]byte(query)),
+		Page:     pageIndex,
+		PageSize: pageSize,
+		Fields:   "ip,port,domain,hostname,title,product,service,country.name,city.name",
+	}
+	jsonData, _ := json.Marshal(data)
+	request, err := http.NewRequest(http.MethodPost, "https://api.zoomeye.ai/v2/search", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("API-KEY", apiKey)
+	statusCode, content, err := doAPIRequest(request, config)
+	if err != nil {
+		return
+	}
+	return z.parseZoomEyeSearchResult(statusCode, content)
+}
+
+// parseZoomEyeSearchResult 解析查询返回的数据
+func (z *ZoomEye) parseZoomEyeSearchResult(statusCode int, content []byte) (result []onlineSearchResult, sizeTotal int, err error) {
+	var r ZoomEyeSearchResult
+	if err = json.Unmarshal(content, &r); err != nil && statusCode == http.StatusOK {
+		return
+	}
+	if statusCode == http.StatusForbidden && strings.Contains(strings.ToLower(r.Message), "credit") {
+		return nil, 0, fmt.Errorf("%w:ZoomEye Search Error:%s", ErrKeyExhausted, r.Message)
+	}
+	if err = checkStatusCode("ZoomEye", statusCode, r.Message); err != nil {
+		return
+	}
+	if r.Code != zoomeyeSuccessCode {
+		return nil, 0, errors.New(fmt.Sprintf("ZoomEye Search Error:%d %s", r.Code, r.Message))
+	}
+	sizeTotal = r.Total
+	for _, asset := range r.Data {
+		result = append(result, z.assetResult(asset))
+	}
+	return
+}
+
+// assetResult 将一条资产转换为查询结果
+func (z *ZoomEye) assetResult(asset zoomeyeAsset) onlineSearchResult {
+	fsr := onlineSearchResult{
+		IP:      asset.IP,
+		Port:    strconv.Itoa(asset.Port),
+		Domain:  asset.Domain,
+		Host:    asset.Hostname,
+		Server:  asset.Product,
+		Country: asset.CountryName,
+		City:    asset.CityName,
+	}
+	if fsr.Host == "" {
+		fsr.Host = asset.Domain
+	}
+	if len(asset.Title) > 0 {
+		fsr.Title = asset.Title[0]
+	}
+	return fsr
+}
+
+// ParseContentResult 导入查询返回的JSON数据或导出的JSON Lines格式文件
+func (z *ZoomEye) ParseContentResult(content []byte) (ipResult portscan.Result, domainResult domainscan.Result) {
+	var results []onlineSearchResult
+	var r ZoomEyeSearchResult
+	if err := json.Unmarshal(content, &r); err == nil && len(r.Data) > 0 {
+		for _, asset := range r.Data {
+			results = append(results, z.assetResult(asset))
+		}
+	} else {
+		results = parseJSONLines(content, func(line []byte) []onlineSearchResult {
+			var asset zoomeyeAsset
+			if err := json.Unmarshal(line, &asset); err != nil {
+				logging.RuntimeLog.Error(err)
+				return nil
+			}
+			return []onlineSearchResult{z.assetResult(asset)}
+		})
+	}
+	return makeContentResult(results, "zoomeye")
+}
@@ -0,0 +1,47 @@
+package onlineapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+const zoomeyeSearchContent = `{"code":60000,"message":"success","query":"title=\"Example\"","total":163,"data":[
+{"ip":"1.2.3.4","port":443,"domain":"example.com","hostname":"www.example.com","title":["Example","Example Domain"],"product":"nginx","service":"https","country.name":"United States","city.name":"Ashburn"},
+{"ip":"1.2.3.5","port":80,"domain":"","hostname":"","title":"Welcome","product":"Apache httpd","service":"http","country.name":"United States","city.name":"Ashburn"}]}`
+
+func TestZoomEye_ParseSearchResult(t *testing.T) {
+	z := &ZoomEye{}
+	result, sizeTotal, err := z.parseZoomEyeSearchResult(http.StatusOK, []byte(zoomeyeSearchContent))
+	if err != nil || sizeTotal != 163 || len(result) != 2 {
+		t.Fatalf("parse result:%v %d %d", err, sizeTotal, len(result))
+	}
+	if result[0].Host != "www.example.com" || result[0].Title != "Example" || result[1].Title != "Welcome" || result[1].Server != "Apache httpd" {
+		t.Errorf("result:%+v", result)
+	}
+	_, _, err = z.parseZoomEyeSearchResult(http.StatusUnauthorized, []byte(`{"code":60001,"message":"invalid api key"}`))
+	if KeyStatus(err) != KeyStatusInvalid {
+		t.Errorf("invalid key:%v", err)
+	}
+	_, _, err = z.parseZoomEyeSearchResult(http.StatusForbidden, []byte(`{"code":60002,"message":"credits insufficent"}`))
+	if KeyStatus(err) != KeyStatusExhausted {
+		t.Errorf("exhausted key:%v", err)
+	}
+	if _, _, err = z.parseZoomEyeSearchResult(http.StatusOK, []byte(`{"code":60010,"message":"query syntax error"}`)); err == nil {
+		t.Error("error code should fail")
+	}
+}
+
+func TestZoomEye_ParseContentResult(t *testing.T) {
+	z := &ZoomEye{}
+	ipResult, domainResult := z.ParseContentResult([]byte(zoomeyeSearchContent))
+	if len(ipResult.IPResult) != 2 || len(domainResult.DomainResult) != 1 {
+		t.Errorf("json:%d %d", len(ipResult.IPResult), len(domainResult.DomainResult))
+	}
+	jsonLines := `{"ip":"1.2.3.4","port":443,"hostname":"www.example.com","title":["Example"]}
+{"ip":"1.2.3.4","port":8443,"domain":"example.com"}
+`
+	ipResult, domainResult = z.ParseContentResult([]byte(jsonLines))
+	if len(ipResult.IPResult) != 1 || len(ipResult.IPResult["1.2.3.4"].Ports) != 2 || len(domainResult.DomainResult) != 2 {
+		t.Errorf("json lines:%d %d", len(ipResult.IPResult), len(domainResult.DomainResult))
+	}
+}
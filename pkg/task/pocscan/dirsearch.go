@@ -4,54 +4,131 @@ import (
 	"bufio"
 	"crypto/tls"
 	"fmt"
-	"github.com/evilsocket/brutemachine"
 	"github.com/evilsocket/dirsearch"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
-	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
+	"github.com/remeh/sizedwaitgroup"
+	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
+const (
+	dirsearchSource = "dirsearch"
+	// extPlaceholder 字典中需要替换为扩展名的占位符
+	extPlaceholder = "%EXT%"
+	// dirsearchMaxErrors 单个目标请求错误超过该数量后停止扫描
+	dirsearchMaxErrors = 20
+	// dirsearchBodyMaxSize 读取响应正文的最大长度，用于soft-404比较
+	dirsearchBodyMaxSize = 64 * 1024
+	// soft404Similarity 响应与soft-404特征的相似度超过该值则认为页面不存在
+	soft404Similarity = 0.9
+
+	defaultDirsearchWordlist = "dicc.txt"
+	defaultDirsearchThreads  = 8
+	defaultDirsearchTimeout  = 10
+)
+
+// Dirsearch 目录及文件扫描，每个目标使用独立的扫描状态，可在同一进程中并发执行
 type Dirsearch struct {
 	Config Config
-	Result []Result
+	// PathResult 发现的路径，关联到端口或域名保存
+	PathResult []PathResult
+
+	// Wordlist 字典文件，Extensions 替换字典中%EXT%的扩展名列表
+	Wordlist   string
+	Extensions []string
+	// Threads 每个目标的并发数，MaxDepth 递归扫描目录的深度，RateLimit 每个目标每秒的最大请求数（0为不限制）
+	Threads   int
+	MaxDepth  int
+	RateLimit int
+	Timeout   time.Duration
+	// Headers 每个请求附加的请求头（如认证信息），格式为"Name: Value"
+	Headers []string
 
-	// resultUrl 暂存dirsearch的结果
-	resultUrl []string
-	// resultMutex 协程互斥锁
-	resultMutex sync.RWMutex
+	resultMutex sync.Mutex
 	// blacklist
 	http403BlackList []string
 	http400BlackList []string
 }
 
-// DirsearcchResult represents the resul of each HEAD request to a given URL.
-type DirsearcchResult struct {
+// PathResult 目录扫描发现的一个路径
+type PathResult struct {
+	Target        string `json:"target"`
+	Port          int    `json:"port"`
+	Url           string `json:"url"`
+	Path          string `json:"path"`
+	Status        int    `json:"status"`
+	ContentLength int    `json:"contentLength"`
+	Location      string `json:"location"`
+	Source        string `json:"source"`
+	WorkspaceId   int    `json:"workspaceId"`
+	// Egress 扫描使用的代理出口IP，直接访问时为空
+	Egress string `json:"egress,omitempty"`
+}
+
+// dirsearchTarget 一个目标的扫描状态
+type dirsearchTarget struct {
+	d       *Dirsearch
+	base    string
+	host    string
+	port    int
+	client  *http.Client
+	proxy   *proxypool.Proxy
+	limiter *time.Ticker
+	// soft404 校准时不存在页面的响应特征
+	soft404 []*dirsearchResponse
+	errors  uint64
+	visited sync.Map
+	results []PathResult
+	mutex   sync.Mutex
+}
+
+// dirsearchResponse 一次请求的响应
+type dirsearchResponse struct {
 	url      string
+	word     string
 	status   int
+	length   int
 	location string
-	err      error
+	body     []byte
 }
 
-var (
-	m         *brutemachine.Machine
-	errorsInt = uint64(0)
-	base, ext string
-	wordlist  = filepath.Join(conf.GetRootPath(), "thirdparty/dict/dicc.txt")
-	consumers = 8
-	only200   = false
-	maxerrors = 20
-)
-
 // NewDirsearch 创建Dirsearch对象
 func NewDirsearch(config Config) *Dirsearch {
-	d := &Dirsearch{Config: config}
+	c := conf.GlobalWorkerConfig().Pocscan.Dirsearch
+	d := &Dirsearch{
+		Config:    config,
+		Wordlist:  filepath.Join(conf.GetRootPath(), "thirdparty/dict", c.Wordlist),
+		Threads:   c.Threads,
+		MaxDepth:  c.MaxDepth,
+		RateLimit: c.RateLimit,
+		Timeout:   time.Duration(c.Timeout) * time.Second,
+		Headers:   append(append([]string{}, c.Headers...), config.Headers...),
+	}
+	if c.Wordlist == "" {
+		d.Wordlist = filepath.Join(conf.GetRootPath(), "thirdparty/dict", defaultDirsearchWordlist)
+	}
+	if d.Threads <= 0 {
+		d.Threads = defaultDirsearchThreads
+	}
+	if d.Timeout <= 0 {
+		d.Timeout = defaultDirsearchTimeout * time.Second
+	}
+	for _, ext := range strings.Split(config.PocFile, ",") {
+		if ext = strings.TrimPrefix(strings.TrimSpace(ext), "."); ext != "" {
+			d.Extensions = append(d.Extensions, ext)
+		}
+	}
 	d.http403BlackList = d.readBlankList("403_blacklist.txt")
 	d.http400BlackList = d.readBlankList("400_blacklist.txt")
 
@@ -79,155 +156,320 @@ func (d *Dirsearch) readBlankList(file string) (blackList []string) {
 
 // Do 执行Dirsearch
 func (d *Dirsearch) Do() {
-	var urlsFormatted []string
-	urlsFormatted = checkAndFormatUrl(d.Config.Target, true)
-	for _, url := range urlsFormatted {
-		target := utils.ParseHost(url)
-		//清空上一次暂存的结果
-		d.resultUrl = []string{}
-		d.RunDirsearch(url)
-		//保存结果
-		if len(d.resultUrl) > 0 {
-			d.Result = append(d.Result, Result{
-				Target:      target,
-				Url:         url,
-				PocFile:     d.Config.PocFile,
-				Source:      "dirsearch",
-				Extra:       strings.Join(d.resultUrl, "\n"),
-				WorkspaceId: d.Config.WorkspaceId,
-			})
-		}
-	}
-}
-
-// RunDirsearch 对一个url执行Dirsearch
-func (d *Dirsearch) RunDirsearch(url string) {
-	if d.initDirsearch(url) == false {
+	words := d.loadWordlist()
+	if len(words) == 0 {
+		d.Config.log().Errorf("dirsearch wordlist is empty:%s", d.Wordlist)
 		return
 	}
-	m = brutemachine.New(consumers, wordlist, d.DoRequest, d.OnResult)
+	for _, url := range checkAndFormatUrl(d.Config.Target, true) {
+		results := d.RunDirsearch(url, words)
+		d.resultMutex.Lock()
+		d.PathResult = append(d.PathResult, results...)
+		d.resultMutex.Unlock()
+	}
+}
+
+// RunDirsearch 对一个url执行目录扫描，返回发现的路径
+func (d *Dirsearch) RunDirsearch(url string, words []string) []PathResult {
+	t := d.newTarget(url)
+	if t == nil {
+		return nil
+	}
+	if t.limiter != nil {
+		defer t.limiter.Stop()
+	}
+	defer t.client.CloseIdleConnections()
+	if _, err := t.request(""); err != nil {
+		logging.CLILog.Infof("check %s http fail,skip... ", url)
+		return nil
+	}
+	t.calibrate()
 	logging.CLILog.Infof("start dirsearch:%s", url)
-	if err := m.Start(); err != nil {
-		logging.CLILog.Error(err)
+	dirs := []string{"/"}
+	for depth := 0; depth <= d.MaxDepth && len(dirs) > 0; depth++ {
+		var nextDirs []string
+		for _, dir := range dirs {
+			nextDirs = append(nextDirs, t.scanDir(dir, words)...)
+		}
+		dirs = nextDirs
 	}
-	m.Wait()
-	printStats(url)
+	logging.CLILog.Infof("%s -> Errors:%d, Results:%d", url, atomic.LoadUint64(&t.errors), len(t.results))
+	return t.results
 }
 
-// DoRequest 执行一次请求
-func (d *Dirsearch) DoRequest(page string) interface{} {
-	url := strings.Replace(fmt.Sprintf("%s%s", base, page), "%EXT%", ext, -1)
+// loadWordlist 读取字典，并将%EXT%替换为各扩展名（未指定扩展名时忽略含%EXT%的条目）
+func (d *Dirsearch) loadWordlist() (words []string) {
+	inputFile, err := os.Open(d.Wordlist)
+	if err != nil {
+		d.Config.log().Error(err)
+		return
+	}
+	defer inputFile.Close()
+	exists := make(map[string]struct{})
+	scanner := bufio.NewScanner(inputFile)
+	for scanner.Scan() {
+		word := strings.TrimPrefix(strings.TrimSpace(scanner.Text()), "/")
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		var expanded []string
+		if strings.Contains(word, extPlaceholder) {
+			for _, ext := range d.Extensions {
+				expanded = append(expanded, strings.ReplaceAll(word, extPlaceholder, ext))
+			}
+		} else {
+			expanded = []string{word}
+		}
+		for _, w := range expanded {
+			if _, ok := exists[w]; !ok {
+				exists[w] = struct{}{}
+				words = append(words, w)
+			}
+		}
+	}
+	return
+}
 
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+// newTarget 创建一个目标的扫描状态
+func (d *Dirsearch) newTarget(targetUrl string) *dirsearchTarget {
+	u, err := url.Parse(strings.TrimSuffix(targetUrl, "/"))
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	t := &dirsearchTarget{
+		d:    d,
+		base: fmt.Sprintf("%s://%s%s", u.Scheme, u.Host, u.Path),
+		host: u.Hostname(),
+	}
+	if t.port, err = strconv.Atoi(u.Port()); err != nil {
+		if u.Scheme == "https" {
+			t.port = 443
+		} else {
+			t.port = 80
+		}
 	}
 	// Do not verify certificates, do not follow redirects.
-	client := &http.Client{
-		Transport: tr,
+	transport := &http.Transport{
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
+		MaxIdleConnsPerHost: d.Threads,
+	}
+	t.proxy = proxypool.Get(proxypool.TaskDirsearch)
+	proxypool.SetTransport(transport, t.proxy)
+	t.client = &http.Client{
+		Transport: transport,
+		Timeout:   d.Timeout,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
-		}}
-
-	// Create HEAD request with random user agent.
-	req, err := http.NewRequest("HEAD", url, nil)
-	if err != nil {
-		logging.RuntimeLog.Error(err)
-		return nil
+		},
 	}
-	req.Header.Set("User-Agent", dirsearch.GetRandomUserAgent())
+	if d.RateLimit > 0 {
+		t.limiter = time.NewTicker(time.Second / time.Duration(d.RateLimit))
+	}
+	return t
+}
 
-	if resp, err := client.Do(req); err == nil {
-		resp.Body.Close()
-		if resp.StatusCode == 200 || !only200 {
-			return DirsearcchResult{url, resp.StatusCode, resp.Header.Get("Location"), nil}
+// calibrate 请求随机的不存在路径，记录soft-404的响应特征
+func (t *dirsearchTarget) calibrate() {
+	probes := []string{randomPath(12), randomPath(12) + "/", "." + randomPath(8)}
+	for _, ext := range t.d.Extensions {
+		probes = append(probes, fmt.Sprintf("%s.%s", randomPath(12), ext))
+	}
+	for _, probe := range probes {
+		resp, err := t.request(probe)
+		if err != nil || resp.status == http.StatusNotFound {
+			continue
 		}
-	} else {
-		atomic.AddUint64(&errorsInt, 1)
+		t.soft404 = append(t.soft404, resp)
 	}
-	client.CloseIdleConnections()
+}
 
-	return nil
+// scanDir 扫描一个目录，返回需要递归扫描的子目录
+func (t *dirsearchTarget) scanDir(dir string, words []string) (subDirs []string) {
+	var subDirsMutex sync.Mutex
+	swg := sizedwaitgroup.New(t.d.Threads)
+	for _, word := range words {
+		if atomic.LoadUint64(&t.errors) >= dirsearchMaxErrors {
+			logging.RuntimeLog.Warningf("%s too many errors,stop dirsearch", t.base)
+			break
+		}
+		path := dir + word
+		if _, loaded := t.visited.LoadOrStore(path, struct{}{}); loaded {
+			continue
+		}
+		swg.Add()
+		go func(path string) {
+			defer swg.Done()
+			resp, err := t.request(strings.TrimPrefix(path, "/"))
+			if err != nil {
+				atomic.AddUint64(&t.errors, 1)
+				return
+			}
+			if !t.isFound(resp) {
+				return
+			}
+			t.onResult(path, resp)
+			if t.isDir(path, resp) {
+				subDirsMutex.Lock()
+				subDirs = append(subDirs, strings.TrimSuffix(path, "/")+"/")
+				subDirsMutex.Unlock()
+			}
+		}(path)
+	}
+	swg.Wait()
+	return
 }
 
-// OnResult 请求结果处理
-func (d *Dirsearch) OnResult(res interface{}) {
-	result, ok := res.(DirsearcchResult)
-	if !ok {
-		logging.CLILog.Error("Error while converting result.")
-		return
+// request 执行一次请求，读取有限长度的正文
+func (t *dirsearchTarget) request(path string) (*dirsearchResponse, error) {
+	if t.limiter != nil {
+		<-t.limiter.C
+	}
+	reqUrl := fmt.Sprintf("%s/%s", t.base, path)
+	req, err := http.NewRequest(http.MethodGet, reqUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", dirsearch.GetRandomUserAgent())
+	for _, header := range t.d.Headers {
+		if k, v, ok := strings.Cut(header, ":"); ok && strings.TrimSpace(k) != "" {
+			req.Header.Set(strings.TrimSpace(k), strings.TrimSpace(v))
+		}
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, dirsearchBodyMaxSize))
+	length := int(resp.ContentLength)
+	if length < 0 {
+		length = len(body)
+	}
+	return &dirsearchResponse{
+		url:      reqUrl,
+		word:     path,
+		status:   resp.StatusCode,
+		length:   length,
+		location: resp.Header.Get("Location"),
+		body:     body,
+	}, nil
+}
 
+// isFound 判断响应是否为存在的路径
+func (t *dirsearchTarget) isFound(resp *dirsearchResponse) bool {
 	switch {
-	// error not due to 404 response
-	case result.err != nil && result.status != 404:
-		logging.CLILog.Infof("[???] %s : %v", result.url, result.err)
-	// 2xx
-	case result.status >= 200 && result.status < 300:
-		logging.CLILog.Infof("[%d] %s", result.status, result.url)
-		d.resultMutex.Lock()
-		d.resultUrl = append(d.resultUrl, fmt.Sprintf("[%d] %s", result.status, result.url))
-		d.resultMutex.Unlock()
-	// 3xx
-	case !only200 && result.status >= 300 && result.status < 400:
-		logging.CLILog.Infof("[%d] %s -> %s", result.status, result.url, result.location)
-		d.resultMutex.Lock()
-		d.resultUrl = append(d.resultUrl, fmt.Sprintf("[%d] %s -> %s", result.status, result.url, result.location))
-		d.resultMutex.Unlock()
-	// 4xx
-	case result.status >= 400 && result.status < 500 && result.status != 404:
-		// 401、403
-		if result.status > 400 && checkBlackList(result.url, d.http403BlackList) == false && checkBlackList(result.url, d.http400BlackList) == false {
-			logging.CLILog.Infof("[%d] %s", result.status, result.url)
-			d.resultMutex.Lock()
-			d.resultUrl = append(d.resultUrl, fmt.Sprintf("[%d] %s", result.status, result.url))
-			d.resultMutex.Unlock()
-		}
-	// 5xx
-	case result.status >= 500 && result.status < 600:
-		logging.CLILog.Infof("[%d] %s", result.status, result.url)
-	}
-}
-
-// Do some initialization.
-// NOTE: We can't call this in the 'init' function otherwise
-// flags are gonna be mandatory for unit test modules.
-func (d *Dirsearch) initDirsearch(url string) bool {
-	base = url
-	ext = d.Config.PocFile
-	if d.checkHttp(url) == false {
-		logging.CLILog.Infof("check %s http fail,skip... ", url)
+	case resp.status == http.StatusNotFound:
+		return false
+	// 401、403
+	case resp.status > 400 && resp.status < 500:
+		if checkBlackList(resp.url, t.d.http403BlackList) || checkBlackList(resp.url, t.d.http400BlackList) {
+			return false
+		}
+	case resp.status < 200 || resp.status >= 400:
 		return false
 	}
+	for _, s := range t.soft404 {
+		if isSoft404(s, resp) {
+			return false
+		}
+	}
 	return true
 }
 
-// checkHttp 检查指定url的http请求是否正常
-func (d *Dirsearch) checkHttp(url string) bool {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+// isDir 判断路径是否为目录
+func (t *dirsearchTarget) isDir(path string, resp *dirsearchResponse) bool {
+	if strings.HasSuffix(path, "/") {
+		return resp.status < 300 || resp.status == http.StatusForbidden
 	}
-	// Do not verify certificates, do not follow redirects.
-	client := &http.Client{
-		Transport: tr,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		}}
-	// Create HEAD request with random user agent.
-	req, _ := http.NewRequest("HEAD", url, nil)
-	req.Header.Set("User-Agent", dirsearch.GetRandomUserAgent())
+	if resp.status >= 300 && resp.status < 400 {
+		return strings.HasSuffix(strings.SplitN(resp.location, "?", 2)[0], path+"/")
+	}
+	return false
+}
 
-	if _, err := client.Do(req); err == nil {
-		return true
+func (t *dirsearchTarget) onResult(path string, resp *dirsearchResponse) {
+	if resp.location != "" {
+		logging.CLILog.Infof("[%d] %s -> %s", resp.status, resp.url, resp.location)
 	} else {
+		logging.CLILog.Infof("[%d] %s", resp.status, resp.url)
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	var egress string
+	if t.proxy != nil {
+		egress = t.proxy.Egress
+	}
+	t.results = append(t.results, PathResult{
+		Target:        t.host,
+		Port:          t.port,
+		Url:           resp.url,
+		Path:          path,
+		Status:        resp.status,
+		ContentLength: resp.length,
+		Location:      resp.location,
+		Source:        dirsearchSource,
+		WorkspaceId:   t.d.Config.WorkspaceId,
+		Egress:        egress,
+	})
+}
+
+// isSoft404 比较响应与soft-404特征：状态码一致、跳转地址一致，且正文（去除请求路径后）相似
+func isSoft404(s, resp *dirsearchResponse) bool {
+	if s.status != resp.status {
 		return false
 	}
+	if s.location != "" || resp.location != "" {
+		return normalizeByWord(s.location, s.word) == normalizeByWord(resp.location, resp.word)
+	}
+	return similarity(normalizeByWord(string(s.body), s.word), normalizeByWord(string(resp.body), resp.word)) >= soft404Similarity
+}
+
+// normalizeByWord 去除内容中回显的请求路径
+func normalizeByWord(content, word string) string {
+	word = strings.Trim(word, "/")
+	if word == "" {
+		return content
+	}
+	content = strings.ReplaceAll(content, url.PathEscape(word), "")
+	return strings.ReplaceAll(content, word, "")
 }
 
-// printStats Print some stats
-func printStats(url string) {
-	m.UpdateStats()
-	logging.CLILog.Infof("%s -> Requests:%d, Errors:%d, Results:%d, Time:%fs,Req/s: %f",
-		url, m.Stats.Execs, errorsInt, m.Stats.Results, m.Stats.Total.Seconds(), m.Stats.Eps)
+// similarity 按词计算两个内容的Jaccard相似度
+func similarity(a, b string) float64 {
+	tokenize := func(s string) map[string]int {
+		tokens := make(map[string]int)
+		for _, w := range strings.FieldsFunc(s, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r > 127)
+		}) {
+			tokens[w]++
+		}
+		return tokens
+	}
+	ta, tb := tokenize(a), tokenize(b)
+	if len(ta) == 0 && len(tb) == 0 {
+		return 1
+	}
+	var inter, union int
+	for w, ca := range ta {
+		cb := tb[w]
+		inter += min(ca, cb)
+		union += max(ca, cb)
+	}
+	for w, cb := range tb {
+		if _, ok := ta[w]; !ok {
+			union += cb
+		}
+	}
+	return float64(inter) / float64(union)
+}
+
+func randomPath(n int) string {
+	letters := "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = letters[rand.Intn(len(letters))]
+	}
+	return string(b)
 }
 
 // checkBlackList 检查是否是黑名单
@@ -1,15 +1,137 @@
 package pocscan
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
-func TestDirSearch(t *testing.T){
+func TestDirSearch(t *testing.T) {
 
 	d := NewDirsearch(Config{
-		Target: "127.0.0.1:8000,172.16.80.130",
+		Target:  "127.0.0.1:8000,172.16.80.130",
 		PocFile: "php",
 	})
 	d.Do()
-	t.Log(d.Result)
+	t.Log(d.PathResult)
+}
+
+// newTestDirsearchServer 模拟soft-404（不存在的页面返回200并回显路径）且需要认证的站点
+func newTestDirsearchServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer nemo" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/", "/admin/":
+			fmt.Fprint(w, "<html><body>index of site</body></html>")
+		case "/admin":
+			http.Redirect(w, r, "/admin/", http.StatusMovedPermanently)
+		case "/admin/login.php":
+			fmt.Fprint(w, "<html><body><form>username password login</form></body></html>")
+		case "/secret":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			fmt.Fprintf(w, "<html><body>Sorry, the page %s you requested was not found on this server.</body></html>", r.URL.Path)
+		}
+	}))
+}
+
+func newTestDirsearch(t *testing.T, target string) *Dirsearch {
+	wordlist := filepath.Join(t.TempDir(), "wordlist.txt")
+	os.WriteFile(wordlist, []byte("admin\nlogin.%EXT%\nsecret\nbackup\nindex.%EXT%\n#comment\n"), 0644)
+	return &Dirsearch{
+		Config:     Config{Target: target, WorkspaceId: 1},
+		Wordlist:   wordlist,
+		Extensions: []string{"php"},
+		Threads:    4,
+		MaxDepth:   1,
+		Timeout:    5 * time.Second,
+		Headers:    []string{"Authorization: Bearer nemo"},
+	}
+}
+
+func TestDirsearch_RunDirsearch(t *testing.T) {
+	ts := newTestDirsearchServer()
+	defer ts.Close()
+
+	d := newTestDirsearch(t, ts.URL)
+	results := d.RunDirsearch(ts.URL, d.loadWordlist())
+	var paths []string
+	for _, r := range results {
+		paths = append(paths, fmt.Sprintf("%d %s", r.Status, r.Path))
+		if r.Source != dirsearchSource || r.WorkspaceId != 1 || r.Target != "127.0.0.1" {
+			t.Errorf("invalid result:%v", r)
+		}
+	}
+	sort.Strings(paths)
+	expected := "200 /admin/login.php,301 /admin,403 /secret"
+	if strings.Join(paths, ",") != expected {
+		t.Errorf("got %v,expected %s", paths, expected)
+	}
+
+	// 不带认证头时全部为401
+	d.Headers = nil
+	if results = d.RunDirsearch(ts.URL, d.loadWordlist()); len(results) != 0 {
+		t.Errorf("should not found without auth header:%v", results)
+	}
+}
+
+func TestDirsearch_Concurrent(t *testing.T) {
+	ts := newTestDirsearchServer()
+	defer ts.Close()
+
+	var wg sync.WaitGroup
+	counts := make([]int, 4)
+	for i := range counts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			d := newTestDirsearch(t, strings.TrimPrefix(ts.URL, "http://"))
+			d.MaxDepth = 0
+			d.Do()
+			counts[i] = len(d.PathResult)
+		}(i)
+	}
+	wg.Wait()
+	for _, c := range counts {
+		if c != 2 {
+			t.Errorf("invalid result count:%v", counts)
+			break
+		}
+	}
+}
+
+func TestDirsearch_RateLimit(t *testing.T) {
+	ts := newTestDirsearchServer()
+	defer ts.Close()
+
+	d := newTestDirsearch(t, ts.URL)
+	d.MaxDepth = 0
+	d.RateLimit = 20
+	start := time.Now()
+	d.RunDirsearch(ts.URL, d.loadWordlist())
+	// 1次检查+4次校准+5个字典条目
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("rate limit not work:%v", elapsed)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	a := normalizeByWord("<p>page /abc123 not found</p>", "abc123")
+	b := normalizeByWord("<p>page /admin not found</p>", "admin")
+	if similarity(a, b) < soft404Similarity {
+		t.Errorf("should be similar:%s %s", a, b)
+	}
+	if similarity(a, "<form>username password login</form>") >= soft404Similarity {
+		t.Error("should not be similar")
+	}
 }
@@ -9,7 +9,6 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"io"
-	"math/rand"
 	"net/http"
 	"strings"
 	"time"
@@ -19,7 +18,6 @@ type Goby struct {
 	Config        Config
 	Result        []Result
 	AssertContent []byte
-	notice        chan int
 }
 
 // goby服务端部署：
@@ -194,12 +192,11 @@ const (
 	APIGetAsset         = "/api/v1/assetSearch"
 	APIGetVulnerability = "/api/v1/vulnerabilitySearch"
 	APIProgress         = "/api/v1/getProgress"
+	APIDeleteTask       = "/api/v1/deleteTask"
 	// SleepDelayTimeSecond 任务执行出错时的休眠间隔
 	SleepDelayTimeSecond = 10
 	// CheckProgressTimeSecond 检查任务执行结果的时间
 	CheckProgressTimeSecond = 10
-	// StartScanRandWaitTimeSecond 同时多个任务开始时，goby可能冲突会导致返回相同的taskid
-	StartScanRandWaitTimeSecond = 10
 )
 
 // NewGoby 创建goby对象
@@ -216,7 +213,7 @@ func (g *Goby) Do() {
 	*/
 	if len(conf.GlobalWorkerConfig().Pocscan.Goby.API) <= 0 {
 		logging.CLILog.Warning("no goby api set")
-		logging.RuntimeLog.Warning("no goby api set")
+		g.Config.log().Warning("no goby api set")
 		return
 	}
 	var urlsFormatted []string
@@ -226,19 +223,21 @@ func (g *Goby) Do() {
 	taskId, api, err := g.StartScan(urlsFormatted)
 	if err != nil {
 		logging.CLILog.Error(err)
-		logging.RuntimeLog.Error(err)
+		g.Config.log().Error(err)
 		return
 	}
+	// 获取结果后清理goby中的任务
+	defer g.DeleteTask(api, taskId)
 	err = g.GetVulnerability(api, taskId)
 	if err != nil {
 		logging.CLILog.Error(err)
-		logging.RuntimeLog.Error(err)
+		g.Config.log().Error(err)
 		return
 	}
 	g.AssertContent, err = g.GetAsset(api, taskId)
 	if err != nil {
 		logging.CLILog.Error(err)
-		logging.RuntimeLog.Error(err)
+		g.Config.log().Error(err)
 		return
 	}
 }
@@ -249,7 +248,7 @@ func (g *Goby) GetTaskList(api string) (err error) {
 	respBody, err = g.postData("GET", fmt.Sprintf("%s%s", api, APITaskList), nil)
 	if err != nil {
 		logging.CLILog.Error(err)
-		logging.RuntimeLog.Error(err)
+		g.Config.log().Error(err)
 		return
 	}
 	//fmt.Println(string(respBody))
@@ -259,7 +258,7 @@ func (g *Goby) GetTaskList(api string) (err error) {
 	return
 }
 
-// StartScan 执行一次扫描，并等待扫描结束后返回
+// StartScan 从goby实例池中选择负载最小的实例执行一次扫描，并等待扫描结束后返回；实例在扫描中掉线则切换到其它实例重新扫描
 func (g *Goby) StartScan(ips []string) (taskId string, api string, err error) {
 	reqBody := GobyStartScanRequest{}
 	reqBody.Asset.Ips = ips
@@ -269,52 +268,84 @@ func (g *Goby) StartScan(ips []string) (taskId string, api string, err error) {
 	reqBody.Options.HostListMode = true
 	reqBody.Options.DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/84.0.4147.125 Safari/537.36"
 	dataBytes, _ := json.Marshal(reqBody)
-	var respBody []byte
-	for {
-		//从多个api接口中，选择一个可用的接口执行，如果接口不可用或失败则回一直等待
-		for _, apiPath := range conf.GlobalWorkerConfig().Pocscan.Goby.API {
-			// 随机阻塞，避免goby的任务冲突导致taskid相同
-			time.Sleep(time.Duration(rand.Intn(StartScanRandWaitTimeSecond)) * time.Second)
-			// 当前使用的api
-			api = apiPath
-			respBody, err = g.postData("POST", fmt.Sprintf("%s%s", api, APIStartScan), dataBytes)
-			// 连接失败、验证失败
-			if err != nil {
-				logging.CLILog.Error(err)
-				logging.RuntimeLog.Error(err)
-				//延迟后选择下一个接口执行
-				time.Sleep(SleepDelayTimeSecond * time.Second)
-				continue
-			}
-			// 读取json内容
-			var result GobyStartScanResponse
-			err = json.Unmarshal(respBody, &result)
-			if err != nil {
-				logging.CLILog.Error(err)
-				logging.RuntimeLog.Error(err)
-				time.Sleep(SleepDelayTimeSecond * time.Second)
-				continue
+
+	pool := GetGobyPool()
+	exclude := make(map[string]struct{})
+	for failover := 0; failover <= GobyMaxFailoverNumber; {
+		//选择一个可用的实例执行，如果没有可用的实例则一直等待
+		inst, errAcquire := pool.Acquire(exclude)
+		if errAcquire != nil {
+			if errors.Is(errAcquire, errGobyNoAPI) {
+				return "", "", errAcquire
 			}
-			// goby正在执行扫描任务，当前接口不可用
-			//{"statusCode":500,"messages":"task launch failed, instance already running","data":null}
-			if result.StatusCode == 500 {
+			time.Sleep(gobyRetryInterval)
+			continue
+		}
+		api = inst.api
+		var busy bool
+		taskId, busy, err = g.startScanOnInstance(inst, dataBytes)
+		if err != nil {
+			logging.CLILog.Error(err)
+			g.Config.log().Error(err)
+			if busy {
+				// 实例正在执行其它任务，不标记为不可用
 				logging.CLILog.Infof("goby api:%s is busy", api)
-				err = errors.New(result.Messages)
-				time.Sleep(3 * SleepDelayTimeSecond * time.Second)
-				continue
-			}
-			// 任务成功执行，等待执行完成
-			taskId = result.Data.TaskId
-			logging.CLILog.Infof("goby scan task:%s,ips:%s started", taskId, strings.Join(ips, ","))
-			g.notice = make(chan int, 0)
-			go g.tickListen(api, taskId)
-			for {
-				select {
-				case <-g.notice:
-					return
-				}
+				pool.MarkBusy(inst)
+				pool.Release(inst, nil)
+			} else {
+				pool.Release(inst, err)
 			}
+			time.Sleep(gobyRetryInterval)
+			continue
 		}
+		// 任务成功执行，等待执行完成
+		logging.CLILog.Infof("goby scan task:%s,ips:%s started on %s", taskId, strings.Join(ips, ","), api)
+		err = g.tickListen(api, taskId)
+		pool.Release(inst, err)
+		if err == nil {
+			return
+		}
+		logging.CLILog.Warningf("goby api:%s lost when scanning task:%s,failover to other api", api, taskId)
+		g.Config.log().Warningf("goby api:%s lost when scanning task:%s,failover to other api", api, taskId)
+		exclude[api] = struct{}{}
+		failover++
+	}
+	return "", "", fmt.Errorf("goby scan fail after %d failover:%v", GobyMaxFailoverNumber, err)
+}
+
+// startScanOnInstance 在指定的实例上启动扫描任务；busy表示实例正在执行其它任务
+func (g *Goby) startScanOnInstance(inst *gobyInstance, dataBytes []byte) (taskId string, busy bool, err error) {
+	// 同一实例串行启动，避免goby的任务冲突导致taskid相同
+	inst.startMutex.Lock()
+	defer inst.startMutex.Unlock()
+
+	respBody, err := g.postData("POST", fmt.Sprintf("%s%s", inst.api, APIStartScan), dataBytes)
+	// 连接失败、验证失败
+	if err != nil {
+		return
+	}
+	var result GobyStartScanResponse
+	if err = json.Unmarshal(respBody, &result); err != nil {
+		return
+	}
+	// goby正在执行扫描任务，当前接口不可用
+	//{"statusCode":500,"messages":"task launch failed, instance already running","data":null}
+	if result.StatusCode == 500 {
+		return "", true, errors.New(result.Messages)
+	}
+	if result.Data.TaskId == "" {
+		return "", false, fmt.Errorf("goby api:%s start scan fail:%s", inst.api, result.Messages)
+	}
+	return result.Data.TaskId, false, nil
+}
+
+// DeleteTask 删除goby中已完成的任务，避免任务数据的累积
+func (g *Goby) DeleteTask(api string, taskId string) (err error) {
+	req := GobyProgessRequest{Taskid: taskId}
+	dataBytes, _ := json.Marshal(req)
+	_, err = g.postData("POST", fmt.Sprintf("%s%s", api, APIDeleteTask), dataBytes)
+	if err != nil {
+		g.Config.log().Warningf("goby delete task:%s fail:%v", taskId, err)
 	}
 	return
 }
@@ -329,7 +360,7 @@ func (g *Goby) GetAsset(api string, taskId string) (content []byte, err error) {
 	content, err = g.postData("POST", fmt.Sprintf("%s%s", api, APIGetAsset), dataBytes)
 	if err != nil {
 		logging.CLILog.Error(err)
-		logging.RuntimeLog.Error(err)
+		g.Config.log().Error(err)
 		return
 	}
 	return
@@ -348,7 +379,7 @@ func (g *Goby) GetVulnerability(api string, taskId string) (err error) {
 	respBody, err = g.postData("POST", fmt.Sprintf("%s%s", api, APIGetVulnerability), dataBytes)
 	if err != nil {
 		logging.CLILog.Error(err)
-		logging.RuntimeLog.Error(err)
+		g.Config.log().Error(err)
 		return
 	}
 	err = g.parseVulnerabilityResult(respBody)
@@ -360,7 +391,7 @@ func (g *Goby) postData(method string, apiUrl string, data []byte) (body []byte,
 	var req *http.Request
 	req, err = http.NewRequest(method, apiUrl, bytes.NewBuffer(data))
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		g.Config.log().Error(err)
 		return
 	}
 	apiAuth := base64.URLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", conf.GlobalWorkerConfig().Pocscan.Goby.AuthUser, conf.GlobalWorkerConfig().Pocscan.Goby.AuthPass)))
@@ -370,40 +401,47 @@ func (g *Goby) postData(method string, apiUrl string, data []byte) (body []byte,
 	var resp *http.Response
 	resp, err = client.Do(req)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		g.Config.log().Error(err)
 		return
 	}
 	defer resp.Body.Close()
 	body, err = io.ReadAll(resp.Body)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		g.Config.log().Error(err)
 		return
 	}
 	// 检查是否是验证错误
 	if resp.StatusCode == http.StatusUnauthorized || string(body) == "Not authorized" {
 		err = errors.New("not authorized")
-		logging.RuntimeLog.Warning(err)
+		g.Config.log().Warning(err)
 	}
 	return
 }
 
-// tickListen 定时器，监测任务状态
-func (g *Goby) tickListen(api string, taskId string) {
-	var progress int
-	timer := time.NewTicker(CheckProgressTimeSecond * time.Second)
+// tickListen 定时器，监测任务状态直到任务完成；连续多次获取进度失败则认为实例已掉线
+func (g *Goby) tickListen(api string, taskId string) (err error) {
+	var progress, failedNumber int
+	timer := time.NewTicker(gobyCheckProgressInterval)
 	defer timer.Stop()
 	for {
 		select {
 		case <-timer.C:
-			pNow, _ := g.checkGobyTaskProgress(api, taskId)
+			pNow, errCheck := g.checkGobyTaskProgress(api, taskId)
+			if errCheck != nil {
+				failedNumber++
+				if failedNumber >= GobyMaxProgressFailedNumber {
+					return errCheck
+				}
+				continue
+			}
+			failedNumber = 0
 			if pNow-progress >= 10 {
 				logging.CLILog.Infof("goby scan task:%s,progress:%d%% ", taskId, pNow)
 				progress = pNow
 			}
 			if progress >= 100 {
 				logging.CLILog.Infof("goby scan task:%s finish", taskId)
-				g.notice <- 1
-				return
+				return nil
 			}
 		}
 	}
@@ -417,14 +455,19 @@ func (g *Goby) checkGobyTaskProgress(api string, taskId string) (progress int, e
 	respBody, err = g.postData("POST", fmt.Sprintf("%s%s", api, APIProgress), dataBytes)
 	if err != nil {
 		logging.CLILog.Error(err)
-		logging.RuntimeLog.Error(err)
+		g.Config.log().Error(err)
 		return
 	}
 	var result GobyProgressResponse
 	err = json.Unmarshal(respBody, &result)
 	if err != nil {
 		logging.CLILog.Error(err)
-		logging.RuntimeLog.Error(err)
+		g.Config.log().Error(err)
+		return
+	}
+	// 任务不存在等错误（如实例重启）
+	if result.StatusCode != 0 && result.StatusCode != http.StatusOK {
+		err = fmt.Errorf("goby get task:%s progress fail:%s", taskId, result.Messages)
 		return
 	}
 	// state为1，正在执行中；state为2：执行结束（goby不会更新这个进度）
@@ -442,7 +485,7 @@ func (g *Goby) parseAssertResult(content []byte) (err error) {
 	var result GobyAssetSearchResponse
 	err = json.Unmarshal(content, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		g.Config.log().Error(err)
 		return
 	}
 	for _, ipAsset := range result.Data.Ips {
@@ -459,7 +502,7 @@ func (g *Goby) parseVulnerabilityResult(content []byte) (err error) {
 	var result GobyVulnerabilityResponse
 	err = json.Unmarshal(content, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		g.Config.log().Error(err)
 		return
 	}
 	for _, vul := range result.Data.Lists {
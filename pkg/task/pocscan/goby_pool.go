@@ -0,0 +1,203 @@
+package pocscan
+
+import (
+	"errors"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/task/ampq"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// GobyDefaultMaxConcurrency goby同时只能执行一个扫描任务
+	GobyDefaultMaxConcurrency = 1
+	// GobyDefaultHealthCheckIntervalSecond 健康检查的间隔
+	GobyDefaultHealthCheckIntervalSecond = 60
+	// GobyMaxProgressFailedNumber 连续获取进度失败的次数，超过后认为实例已掉线
+	GobyMaxProgressFailedNumber = 3
+	// GobyMaxFailoverNumber 扫描过程中实例掉线后，切换到其它实例重新扫描的最大次数
+	GobyMaxFailoverNumber = 3
+)
+
+var (
+	// 以下时间间隔可在测试中缩短
+	gobyRetryInterval         = SleepDelayTimeSecond * time.Second
+	gobyCheckProgressInterval = CheckProgressTimeSecond * time.Second
+
+	gobyPool = &GobyPool{}
+
+	errGobyNoAPI = errors.New("no goby api set")
+)
+
+// gobyInstance 一个goby服务端实例
+type gobyInstance struct {
+	api            string
+	maxConcurrency int
+	healthy        bool
+	running        int
+	taskNumber     int
+	lastCheckTime  time.Time
+	lastError      string
+	// busyUntil goby返回实例正在执行任务（可能被其它程序占用）时，在此之前不再分配
+	busyUntil time.Time
+	// startMutex 同一实例串行启动任务，避免goby返回相同的taskid
+	startMutex sync.Mutex
+}
+
+// GobyPool 管理多个goby服务端实例：健康检查、并发限制及最小负载选择
+type GobyPool struct {
+	sync.Mutex
+	instances []*gobyInstance
+}
+
+// GetGobyPool 获取worker全局的goby实例池
+func GetGobyPool() *GobyPool {
+	return gobyPool
+}
+
+// GobyPoolStatus 获取goby实例池的状态，用于worker的心跳
+func GobyPoolStatus() []ampq.GobyInstanceStatus {
+	return gobyPool.Status()
+}
+
+// syncConfig 根据配置文件同步实例列表，保留已有实例的运行状态
+func (p *GobyPool) syncConfig() {
+	gobyConfig := conf.GlobalWorkerConfig().Pocscan.Goby
+	maxConcurrency := gobyConfig.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = GobyDefaultMaxConcurrency
+	}
+	var instances []*gobyInstance
+	for _, api := range gobyConfig.API {
+		api = strings.TrimSuffix(strings.TrimSpace(api), "/")
+		if api == "" {
+			continue
+		}
+		var inst *gobyInstance
+		for _, v := range p.instances {
+			if v.api == api {
+				inst = v
+				break
+			}
+		}
+		if inst == nil {
+			inst = &gobyInstance{api: api}
+		}
+		inst.maxConcurrency = maxConcurrency
+		instances = append(instances, inst)
+	}
+	p.instances = instances
+}
+
+// healthCheckInterval 健康检查的间隔
+func (p *GobyPool) healthCheckInterval() time.Duration {
+	interval := conf.GlobalWorkerConfig().Pocscan.Goby.HealthCheckInterval
+	if interval <= 0 {
+		interval = GobyDefaultHealthCheckIntervalSecond
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// CheckHealth 对超过检查间隔的实例执行一次健康检查
+func (p *GobyPool) CheckHealth(force bool) {
+	p.Lock()
+	p.syncConfig()
+	interval := p.healthCheckInterval()
+	var checkList []*gobyInstance
+	for _, inst := range p.instances {
+		if force || time.Now().Sub(inst.lastCheckTime) >= interval {
+			checkList = append(checkList, inst)
+		}
+	}
+	p.Unlock()
+
+	for _, inst := range checkList {
+		g := Goby{}
+		err := g.GetTaskList(inst.api)
+		p.Lock()
+		inst.lastCheckTime = time.Now()
+		inst.healthy = err == nil
+		if err != nil {
+			inst.lastError = err.Error()
+		} else {
+			inst.lastError = ""
+		}
+		p.Unlock()
+	}
+}
+
+// Acquire 从健康且未达到并发上限的实例中选择负载最小的一个；exclude为本次扫描已失败的实例
+func (p *GobyPool) Acquire(exclude map[string]struct{}) (inst *gobyInstance, err error) {
+	p.CheckHealth(false)
+
+	p.Lock()
+	defer p.Unlock()
+	if len(p.instances) == 0 {
+		return nil, errGobyNoAPI
+	}
+	// 所有实例都已失败过，则允许重新使用恢复健康的实例
+	excludeAll := true
+	for _, v := range p.instances {
+		if _, ok := exclude[v.api]; !ok {
+			excludeAll = false
+			break
+		}
+	}
+	for _, v := range p.instances {
+		if _, ok := exclude[v.api]; ok && !excludeAll {
+			continue
+		}
+		if !v.healthy || v.running >= v.maxConcurrency || time.Now().Before(v.busyUntil) {
+			continue
+		}
+		if inst == nil || v.running < inst.running || (v.running == inst.running && v.taskNumber < inst.taskNumber) {
+			inst = v
+		}
+	}
+	if inst == nil {
+		return nil, errors.New("no available goby api")
+	}
+	inst.running++
+	inst.taskNumber++
+	return inst, nil
+}
+
+// Release 释放实例；err不为空表示实例在扫描中出错
+func (p *GobyPool) Release(inst *gobyInstance, err error) {
+	p.Lock()
+	defer p.Unlock()
+	if inst.running > 0 {
+		inst.running--
+	}
+	if err != nil {
+		inst.healthy = false
+		inst.lastError = err.Error()
+		inst.lastCheckTime = time.Now()
+	}
+}
+
+// MarkBusy 实例正在执行其它任务，延迟一段时间后再分配
+func (p *GobyPool) MarkBusy(inst *gobyInstance) {
+	p.Lock()
+	defer p.Unlock()
+	inst.busyUntil = time.Now().Add(3 * gobyRetryInterval)
+}
+
+// Status 获取所有实例的状态
+func (p *GobyPool) Status() (status []ampq.GobyInstanceStatus) {
+	p.Lock()
+	defer p.Unlock()
+	for _, v := range p.instances {
+		status = append(status, ampq.GobyInstanceStatus{
+			API:            v.api,
+			Healthy:        v.healthy,
+			Running:        v.running,
+			MaxConcurrency: v.maxConcurrency,
+			TaskNumber:     v.taskNumber,
+			LastCheckTime:  v.lastCheckTime,
+			LastError:      v.lastError,
+		})
+	}
+	return
+}
@@ -0,0 +1,175 @@
+package pocscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGobyServer 模拟goby-cmd的api服务
+type fakeGobyServer struct {
+	sync.Mutex
+	server *httptest.Server
+	// down 为true时模拟实例掉线
+	down bool
+	// dropAfterStart 任务启动后实例掉线
+	dropAfterStart bool
+	taskNumber     int
+	deletedTask    []string
+}
+
+func newFakeGobyServer() *fakeGobyServer {
+	f := &fakeGobyServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc(APITaskList, func(w http.ResponseWriter, r *http.Request) {
+		f.writeJSON(w, map[string]interface{}{"statusCode": 200, "messages": "", "data": nil})
+	})
+	mux.HandleFunc(APIStartScan, func(w http.ResponseWriter, r *http.Request) {
+		f.Lock()
+		f.taskNumber++
+		taskId := fmt.Sprintf("%s-task-%d", f.server.Listener.Addr().String(), f.taskNumber)
+		f.Unlock()
+		f.writeJSON(w, map[string]interface{}{"statusCode": 200, "messages": "", "data": map[string]string{"taskId": taskId}})
+		f.Lock()
+		if f.dropAfterStart {
+			f.down = true
+		}
+		f.Unlock()
+	})
+	mux.HandleFunc(APIProgress, func(w http.ResponseWriter, r *http.Request) {
+		f.writeJSON(w, map[string]interface{}{"statusCode": 200, "messages": "", "data": map[string]int{"progress": 100, "state": 2}})
+	})
+	mux.HandleFunc(APIGetVulnerability, func(w http.ResponseWriter, r *http.Request) {
+		var resp GobyVulnerabilityResponse
+		resp.StatusCode = 200
+		resp.Data.Lists = append(resp.Data.Lists, struct {
+			Name  string `json:"name"`
+			Nums  int    `json:"nums"`
+			Lists []struct {
+				Hostinfo string `json:"hostinfo"`
+				Name     string `json:"name"`
+				Filename string `json:"filename"`
+				Level    string `json:"level,omitempty"`
+				Vulurl   string `json:"vulurl,omitempty"`
+				Keymemo  string `json:"keymemo,omitempty"`
+				Hasexp   bool   `json:"hasexp,omitempty"`
+			} `json:"lists"`
+		}{Name: "fake-vul", Nums: 1})
+		resp.Data.Lists[0].Lists = append(resp.Data.Lists[0].Lists, struct {
+			Hostinfo string `json:"hostinfo"`
+			Name     string `json:"name"`
+			Filename string `json:"filename"`
+			Level    string `json:"level,omitempty"`
+			Vulurl   string `json:"vulurl,omitempty"`
+			Keymemo  string `json:"keymemo,omitempty"`
+			Hasexp   bool   `json:"hasexp,omitempty"`
+		}{Hostinfo: "127.0.0.1:8080", Name: "fake-vul"})
+		f.writeJSON(w, resp)
+	})
+	mux.HandleFunc(APIGetAsset, func(w http.ResponseWriter, r *http.Request) {
+		f.writeJSON(w, map[string]interface{}{"statusCode": 200, "messages": "", "data": map[string]interface{}{"ips": []interface{}{}}})
+	})
+	mux.HandleFunc(APIDeleteTask, func(w http.ResponseWriter, r *http.Request) {
+		var req GobyProgessRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		f.Lock()
+		f.deletedTask = append(f.deletedTask, req.Taskid)
+		f.Unlock()
+		f.writeJSON(w, map[string]interface{}{"statusCode": 200, "messages": "", "data": nil})
+	})
+	f.server = httptest.NewServer(mux)
+	return f
+}
+
+func (f *fakeGobyServer) writeJSON(w http.ResponseWriter, v interface{}) {
+	f.Lock()
+	down := f.down
+	f.Unlock()
+	if down {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("bad gateway"))
+		return
+	}
+	json.NewEncoder(w).Encode(v)
+}
+
+// setupGobyPool 使用模拟的goby服务重置实例池
+func setupGobyPool(apis ...string) {
+	conf.WorkerDefaultConfigFile = "../../../conf/worker.yml"
+	conf.GlobalWorkerConfig().Pocscan.Goby.API = apis
+	conf.GlobalWorkerConfig().Pocscan.Goby.MaxConcurrency = 1
+	conf.GlobalWorkerConfig().Pocscan.Goby.HealthCheckInterval = 3600
+	gobyPool = &GobyPool{}
+	gobyRetryInterval = 10 * time.Millisecond
+	gobyCheckProgressInterval = 10 * time.Millisecond
+}
+
+func TestGobyPool_Acquire(t *testing.T) {
+	s1 := newFakeGobyServer()
+	defer s1.server.Close()
+	s2 := newFakeGobyServer()
+	defer s2.server.Close()
+	s3 := newFakeGobyServer()
+	s3.server.Close()
+	setupGobyPool(s1.server.URL, s2.server.URL, s3.server.URL)
+
+	pool := GetGobyPool()
+	inst1, err := pool.Acquire(nil)
+	if err != nil || inst1.api != s1.server.URL {
+		t.Fatalf("acquire first:%v,%v", inst1, err)
+	}
+	inst2, err := pool.Acquire(nil)
+	if err != nil || inst2.api != s2.server.URL {
+		t.Fatalf("acquire second:%v,%v", inst2, err)
+	}
+	// 已达到并发上限，且第三个实例不可用
+	if _, err = pool.Acquire(nil); err == nil {
+		t.Fatal("acquire should fail when all instances are busy or down")
+	}
+	pool.Release(inst1, nil)
+	inst, err := pool.Acquire(nil)
+	if err != nil || inst.api != s1.server.URL {
+		t.Fatalf("acquire after release:%v,%v", inst, err)
+	}
+	for _, s := range pool.Status() {
+		t.Log(s)
+		if s.API == s3.server.URL && s.Healthy {
+			t.Error("closed instance should be unhealthy")
+		}
+	}
+}
+
+func TestGoby_DoFailover(t *testing.T) {
+	s1 := newFakeGobyServer()
+	defer s1.server.Close()
+	s1.dropAfterStart = true
+	s2 := newFakeGobyServer()
+	defer s2.server.Close()
+	setupGobyPool(s1.server.URL, s2.server.URL)
+
+	g := NewGoby(Config{Target: "127.0.0.1:8080", WorkspaceId: 1})
+	g.Do()
+	if len(g.Result) != 1 || g.Result[0].Url != "127.0.0.1:8080" {
+		t.Fatalf("unexpected result:%v", g.Result)
+	}
+	if s1.taskNumber != 1 || len(s1.deletedTask) != 0 {
+		t.Errorf("scan should be started on the first instance and then failover")
+	}
+	if len(s2.deletedTask) != 1 {
+		t.Errorf("finished task should be deleted:%v", s2.deletedTask)
+	}
+	for _, s := range GetGobyPool().Status() {
+		t.Log(s)
+		if s.API == s1.server.URL && s.Healthy {
+			t.Error("dropped instance should be unhealthy")
+		}
+		if s.Running != 0 {
+			t.Errorf("instance %s should be released", s.API)
+		}
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"github.com/tidwall/pretty"
@@ -25,6 +27,8 @@ import (
 type Nuclei struct {
 	Config Config
 	Result []Result
+	// proxy 扫描使用的代理，为nil时直接访问
+	proxy *proxypool.Proxy
 }
 
 func NewNuclei(config Config) *Nuclei {
@@ -45,7 +49,7 @@ func (n *Nuclei) Do() {
 	}
 	err := os.WriteFile(inputTargetFile, []byte(strings.Join(urlsFormatted, "\n")), 0666)
 	if err != nil {
-		logging.RuntimeLog.Error(err.Error())
+		n.Config.log().Error(err.Error())
 		return
 	}
 	cmdBin := filepath.Join(conf.GetAbsRootPath(), "thirdparty/nuclei", utils.GetThirdpartyBinNameByPlatform(utils.Nuclei))
@@ -64,49 +68,92 @@ func (n *Nuclei) Do() {
 		"-c", fmt.Sprintf("%d", nucleiConcurrencyThreadNumber[conf.WorkerPerformanceMode]),
 		"-bs", fmt.Sprintf("%d", nucleiConcurrencyThreadNumber[conf.WorkerPerformanceMode]),
 		"-rl", fmt.Sprintf("%d", nucleiConcurrencyThreadNumber[conf.WorkerPerformanceMode]*6),
-		"-t", filepath.Join(conf.GetAbsRootPath(), conf.GlobalWorkerConfig().Pocscan.Nuclei.PocPath, n.Config.PocFile),
 		"-j", "-o", resultTempFile, "-l", inputTargetFile,
 	)
+	templateArgs, err := n.templateArgs()
+	if err != nil {
+		n.Config.log().Error(err)
+		return
+	}
+	cmdArgs = append(cmdArgs, templateArgs...)
+	if n.proxy = proxypool.Get(proxypool.TaskNuclei); n.proxy != nil {
+		cmdArgs = append(cmdArgs, "-proxy", n.proxy.URL)
+	}
 	cmd := exec.Command(cmdBin, cmdArgs...)
 	var stderr bytes.Buffer
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = &stderr
 	err = cmd.Run()
+	metrics.ObserveToolExit("nuclei", err)
 	if err != nil {
-		logging.RuntimeLog.Error(err, stderr)
+		n.Config.log().Error(err, stderr)
 		logging.CLILog.Error(err, stderr)
 		return
 	}
 	n.parseNucleiResult(resultTempFile)
 }
 
+// templateArgs 模板的选择参数：模板文件或目录、工作流，以及标签和严重程度的筛选
+func (n *Nuclei) templateArgs() (args []string, err error) {
+	pocBase := NucleiPocBase()
+	if n.Config.Workflow != "" {
+		workflow, err := nucleiTemplatePath(pocBase, n.Config.Workflow)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "-w", workflow)
+	} else {
+		// 多个模板文件或目录以“,”分隔
+		var templates []string
+		for _, f := range strings.Split(n.Config.PocFile, ",") {
+			template, err := nucleiTemplatePath(pocBase, strings.TrimSpace(f))
+			if err != nil {
+				return nil, err
+			}
+			templates = append(templates, template)
+		}
+		args = append(args, "-t", strings.Join(templates, ","))
+	}
+	if len(n.Config.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(n.Config.Tags, ","))
+	}
+	if len(n.Config.Severity) > 0 {
+		args = append(args, "-severity", strings.Join(n.Config.Severity, ","))
+	}
+	return args, nil
+}
+
 // parseNucleiContentResult 解析nuclei的运行结果
 func (n *Nuclei) parseNucleiContentResult(content []byte) {
 	var xr nucleiJSONResult
 	err := json.Unmarshal(content, &xr)
 	if err != nil {
-		logging.RuntimeLog.Error(err.Error())
+		n.Config.log().Error(err.Error())
 		return
 	}
 	host := utils.ParseHost(xr.Host)
 	if host == "" {
 		return
 	}
-	n.Result = append(n.Result, Result{
+	r := Result{
 		Target:      host,
 		Url:         xr.Host,
 		PocFile:     xr.TemplateID,
 		Source:      "nuclei",
 		Extra:       string(pretty.Pretty(content)),
 		WorkspaceId: n.Config.WorkspaceId,
-	})
+	}
+	if n.proxy != nil {
+		r.Egress = n.proxy.Egress
+	}
+	n.Result = append(n.Result, r)
 }
 
 // parseNucleiResult 解析nuclei的运行结果
 func (n *Nuclei) parseNucleiResult(outputTempFile string) {
 	inputFile, err := os.Open(outputTempFile)
 	if err != nil {
-		logging.RuntimeLog.Errorf("could not read nuclei result: %s", err)
+		n.Config.log().Errorf("could not read nuclei result: %s", err)
 		return
 	}
 	defer inputFile.Close()
@@ -118,7 +165,7 @@ func (n *Nuclei) parseNucleiResult(outputTempFile string) {
 
 // LoadPocFile 加载poc文件列表
 func (n *Nuclei) LoadPocFile() (pocs []string) {
-	pocBase := filepath.Join(conf.GetRootPath(), conf.GlobalWorkerConfig().Pocscan.Nuclei.PocPath)
+	pocBase := NucleiPocBase()
 	//统一路径为“/”
 	if runtime.GOOS == "windows" {
 		pocBase = strings.ReplaceAll(pocBase, "\\", "/")
@@ -126,7 +173,7 @@ func (n *Nuclei) LoadPocFile() (pocs []string) {
 	err := filepath.Walk(pocBase,
 		func(path string, info os.FileInfo, err error) error {
 			if err != nil {
-				logging.RuntimeLog.Error(err)
+				n.Config.log().Error(err)
 				return err
 			}
 			//统一路径为“/”
@@ -148,7 +195,7 @@ func (n *Nuclei) LoadPocFile() (pocs []string) {
 			return nil
 		})
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		n.Config.log().Error(err)
 	}
 	sort.Strings(pocs)
 	return
@@ -197,7 +244,8 @@ func checkAndFormatUrl(targets string, checkHttpProtocol bool) (results []string
 			continue
 		}
 		var url string
-		if utils.CheckDomain(host) {
+		if utils.CheckDomain(host) || strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+			// 域名及完整的URL（如URL资产）保留原始的路径与参数
 			url = target
 		} else {
 			_, host, port = utils.ParseHostUrl(target)
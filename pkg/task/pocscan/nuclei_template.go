@@ -0,0 +1,340 @@
+package pocscan
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// NucleiTemplate nuclei模板的元数据
+type NucleiTemplate struct {
+	Id       string   `json:"id"`
+	Name     string   `json:"name"`
+	Path     string   `json:"path"`
+	Tags     []string `json:"tags"`
+	Severity string   `json:"severity"`
+	Author   []string `json:"author"`
+	Protocol string   `json:"protocol"`
+}
+
+// NucleiTemplateFilter 模板的筛选条件
+type NucleiTemplateFilter struct {
+	Tags     []string
+	Severity []string
+	Protocol string
+	Keyword  string
+}
+
+// NucleiTemplateIndex 模板的元数据索引
+type NucleiTemplateIndex struct {
+	sync.Mutex
+	loaded    bool
+	templates []NucleiTemplate
+}
+
+const (
+	// NucleiProtocolWorkflow 工作流模板
+	NucleiProtocolWorkflow = "workflow"
+)
+
+var (
+	nucleiTemplateIndex = &NucleiTemplateIndex{}
+
+	// nucleiSeverity 合法的严重程度
+	nucleiSeverity = map[string]struct{}{"info": {}, "low": {}, "medium": {}, "high": {}, "critical": {}, "unknown": {}}
+	// nucleiProtocol 模板的协议字段，requests为旧版本的http
+	nucleiProtocol = map[string]string{
+		"http": "http", "requests": "http", "dns": "dns", "network": "network", "tcp": "network",
+		"file": "file", "headless": "headless", "ssl": "ssl", "websocket": "websocket", "whois": "whois",
+		"code": "code", "javascript": "javascript", "workflows": NucleiProtocolWorkflow,
+	}
+	// nucleiTemplateId 模板id只允许字母、数字及-_
+	nucleiTemplateId = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+	// nucleiGenericTags 通用的分类标签，不用于根据指纹匹配
+	nucleiGenericTags = map[string]struct{}{
+		"cve": {}, "cnvd": {}, "edb": {}, "packetstorm": {}, "kev": {}, "vuln": {}, "tech": {}, "detect": {}, "misc": {},
+		"panel": {}, "login": {}, "exposure": {}, "config": {}, "file": {}, "http": {}, "network": {}, "dns": {}, "ssl": {},
+		"rce": {}, "sqli": {}, "xss": {}, "lfi": {}, "ssrf": {}, "default-login": {}, "unauth": {}, "intrusive": {},
+		"fuzz": {}, "oast": {}, "osint": {}, "token": {}, "api": {}, "web": {}, "server": {}, "admin": {},
+	}
+	nucleiFingerprintSplit = regexp.MustCompile(`[^a-z0-9-]+`)
+)
+
+// nucleiTemplateYaml 模板中需要解析的字段
+type nucleiTemplateYaml struct {
+	Id   string `yaml:"id"`
+	Info struct {
+		Name     string      `yaml:"name"`
+		Author   interface{} `yaml:"author"`
+		Severity string      `yaml:"severity"`
+		Tags     interface{} `yaml:"tags"`
+	} `yaml:"info"`
+}
+
+// NucleiPocBase 获取nuclei模板目录的绝对路径，建立索引与执行扫描使用相同的路径
+func NucleiPocBase() string {
+	return filepath.Join(conf.GetAbsRootPath(), conf.GlobalWorkerConfig().Pocscan.Nuclei.PocPath)
+}
+
+// nucleiTemplatePath 获取模板或工作流的路径，不允许通过“..”等方式跳出模板目录
+func nucleiTemplatePath(pocBase, name string) (string, error) {
+	path := filepath.Join(pocBase, name)
+	rel, err := filepath.Rel(pocBase, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(name) {
+		return "", fmt.Errorf("invalid template path:%s", name)
+	}
+	return path, nil
+}
+
+// GetNucleiTemplateIndex 获取模板索引
+func GetNucleiTemplateIndex() *NucleiTemplateIndex {
+	return nucleiTemplateIndex
+}
+
+// ParseNucleiTemplate 解析并校验模板内容
+func ParseNucleiTemplate(content []byte) (t NucleiTemplate, err error) {
+	var ty nucleiTemplateYaml
+	if err = yaml.Unmarshal(content, &ty); err != nil {
+		return
+	}
+	var fields map[string]interface{}
+	if err = yaml.Unmarshal(content, &fields); err != nil {
+		return
+	}
+	if !nucleiTemplateId.MatchString(ty.Id) {
+		return t, fmt.Errorf("invalid template id:%s", ty.Id)
+	}
+	if strings.TrimSpace(ty.Info.Name) == "" {
+		return t, errors.New("template info name is empty")
+	}
+	t = NucleiTemplate{
+		Id:       ty.Id,
+		Name:     strings.TrimSpace(ty.Info.Name),
+		Tags:     splitNucleiList(ty.Info.Tags),
+		Severity: strings.ToLower(strings.TrimSpace(ty.Info.Severity)),
+		Author:   splitNucleiList(ty.Info.Author),
+	}
+	for k := range fields {
+		if protocol, ok := nucleiProtocol[k]; ok {
+			t.Protocol = protocol
+			break
+		}
+	}
+	if t.Protocol == "" {
+		return t, errors.New("template has no protocol request")
+	}
+	// 工作流模板可以不指定严重程度
+	if t.Protocol != NucleiProtocolWorkflow || t.Severity != "" {
+		if _, ok := nucleiSeverity[t.Severity]; !ok {
+			return t, fmt.Errorf("invalid template severity:%s", t.Severity)
+		}
+	}
+	return t, nil
+}
+
+// splitNucleiList 标签、作者可以是“,”分隔的字符串或列表
+func splitNucleiList(value interface{}) (result []string) {
+	var values []string
+	switch v := value.(type) {
+	case string:
+		values = strings.Split(v, ",")
+	case []interface{}:
+		for _, item := range v {
+			values = append(values, fmt.Sprintf("%v", item))
+		}
+	}
+	for _, v := range values {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			result = append(result, v)
+		}
+	}
+	return
+}
+
+// loadNucleiTemplates 遍历目录，解析所有的模板
+func loadNucleiTemplates(pocBase string) (templates []NucleiTemplate) {
+	err := filepath.Walk(pocBase, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// 忽略.开头的隐藏目录
+		if strings.HasPrefix(info.Name(), ".") && path != pocBase {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".yaml") {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		t, err := ParseNucleiTemplate(content)
+		if err != nil {
+			return nil
+		}
+		t.Path, _ = filepath.Rel(pocBase, path)
+		t.Path = filepath.ToSlash(t.Path)
+		templates = append(templates, t)
+		return nil
+	})
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Path < templates[j].Path
+	})
+	return
+}
+
+// Refresh 重新建立模板索引
+func (idx *NucleiTemplateIndex) Refresh() {
+	pocBase := NucleiPocBase()
+	templates := loadNucleiTemplates(pocBase)
+
+	idx.Lock()
+	defer idx.Unlock()
+	idx.templates = templates
+	idx.loaded = true
+}
+
+// Templates 获取所有模板，第一次使用时建立索引
+func (idx *NucleiTemplateIndex) Templates() []NucleiTemplate {
+	idx.Lock()
+	loaded := idx.loaded
+	idx.Unlock()
+	if !loaded {
+		idx.Refresh()
+	}
+	idx.Lock()
+	defer idx.Unlock()
+	return idx.templates
+}
+
+// Filter 根据条件筛选模板
+func (idx *NucleiTemplateIndex) Filter(filter NucleiTemplateFilter) (results []NucleiTemplate) {
+	keyword := strings.ToLower(filter.Keyword)
+	for _, t := range idx.Templates() {
+		if filter.Protocol != "" && t.Protocol != filter.Protocol {
+			continue
+		}
+		if len(filter.Severity) > 0 && !containsAny([]string{t.Severity}, filter.Severity) {
+			continue
+		}
+		if len(filter.Tags) > 0 && !containsAny(t.Tags, filter.Tags) {
+			continue
+		}
+		if keyword != "" && !strings.Contains(strings.ToLower(t.Id), keyword) && !strings.Contains(strings.ToLower(t.Name), keyword) && !strings.Contains(strings.ToLower(t.Path), keyword) {
+			continue
+		}
+		results = append(results, t)
+	}
+	return
+}
+
+// Tags 获取所有模板的标签
+func (idx *NucleiTemplateIndex) Tags() (tags []string) {
+	tagSet := make(map[string]struct{})
+	for _, t := range idx.Templates() {
+		for _, tag := range t.Tags {
+			tagSet[tag] = struct{}{}
+		}
+	}
+	for tag := range tagSet {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return
+}
+
+// MatchTechnology 根据指纹信息（如Apache-Tomcat、nginx/1.18.0）匹配模板中存在的技术标签
+func (idx *NucleiTemplateIndex) MatchTechnology(fingerprints []string) (tags []string) {
+	tagSet := make(map[string]struct{})
+	for _, tag := range idx.Tags() {
+		if _, ok := nucleiGenericTags[tag]; !ok {
+			tagSet[tag] = struct{}{}
+		}
+	}
+	matched := make(map[string]struct{})
+	for _, fingerprint := range fingerprints {
+		fingerprint = strings.ToLower(strings.TrimSpace(fingerprint))
+		if fingerprint == "" {
+			continue
+		}
+		candidates := []string{strings.ReplaceAll(fingerprint, " ", "-")}
+		for _, word := range nucleiFingerprintSplit.Split(fingerprint, -1) {
+			candidates = append(candidates, word)
+			candidates = append(candidates, strings.Split(word, "-")...)
+		}
+		for _, c := range candidates {
+			if _, ok := tagSet[c]; ok && len(c) > 2 {
+				matched[c] = struct{}{}
+			}
+		}
+	}
+	for tag := range matched {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return
+}
+
+// containsAny 两个列表是否有相同的元素
+func containsAny(values []string, wants []string) bool {
+	for _, v := range values {
+		for _, w := range wants {
+			if strings.EqualFold(v, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SaveNucleiTemplate 校验并保存上传的模板，保存后通过文件同步分发到worker
+func SaveNucleiTemplate(fileName string, content []byte) (t NucleiTemplate, err error) {
+	fileName = filepath.Base(fileName)
+	if ext := filepath.Ext(fileName); ext != ".yaml" && ext != ".yml" {
+		return t, errors.New("invalid file type")
+	}
+	// nuclei只加载.yaml后缀的模板
+	fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName)) + ".yaml"
+	if t, err = ParseNucleiTemplate(content); err != nil {
+		return
+	}
+	t.Path = fileName
+	for _, v := range GetNucleiTemplateIndex().Templates() {
+		if v.Id == t.Id && v.Path != t.Path {
+			return t, fmt.Errorf("template id %s already exists in %s", t.Id, v.Path)
+		}
+	}
+	pocBase := NucleiPocBase()
+	// 先写入临时文件再重命名，避免文件同步时同步到不完整的模板
+	tempFile, err := os.CreateTemp(pocBase, ".upload-*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err = tempFile.Write(content); err != nil {
+		tempFile.Close()
+		return
+	}
+	if err = tempFile.Close(); err != nil {
+		return
+	}
+	if err = os.Rename(tempFile.Name(), filepath.Join(pocBase, fileName)); err != nil {
+		return
+	}
+	GetNucleiTemplateIndex().Refresh()
+	return t, nil
+}
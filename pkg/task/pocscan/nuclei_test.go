@@ -1,12 +1,14 @@
 package pocscan
 
 import (
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"strings"
 	"testing"
 )
 
 func TestNuclei_Do(t *testing.T) {
 	config := Config{
-		// v3起不需要指定scheme了；使用http://xxxx会导致checkAndFormatUrl不正确解析
+		// v3起不需要指定scheme了
 		Target: "http://127.0.0.1:7001,127.0.0.1:8000",
 		//PocFile: "http/cves/2020/CVE-2020-2551.yaml",
 		PocFile: "http/technologies/springboot-actuator.yaml",
@@ -23,3 +25,82 @@ func TestNuclei_LoadPocFile(t *testing.T) {
 	pocs := n.LoadPocFile()
 	t.Log(pocs)
 }
+
+func TestParseNucleiTemplate(t *testing.T) {
+	content := []byte(`id: tomcat-manager-default
+info:
+  name: Apache Tomcat Manager Default Login
+  author: pdteam,righettod
+  severity: high
+  tags: [tomcat, apache, default-login]
+http:
+  - method: GET
+    path:
+      - "{{BaseURL}}/manager/html"
+`)
+	tp, err := ParseNucleiTemplate(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tp.Id != "tomcat-manager-default" || tp.Severity != "high" || tp.Protocol != "http" || len(tp.Tags) != 3 || len(tp.Author) != 2 {
+		t.Errorf("unexpected template:%v", tp)
+	}
+	workflow := []byte(`id: tomcat-workflow
+info:
+  name: Tomcat Security Checks
+  author: pdteam
+workflows:
+  - template: http/technologies/tomcat-detect.yaml
+`)
+	if tp, err = ParseNucleiTemplate(workflow); err != nil || tp.Protocol != NucleiProtocolWorkflow {
+		t.Errorf("unexpected workflow:%v,%v", tp, err)
+	}
+	invalid := map[string]string{
+		"no id":       "info:\n  name: test\n  severity: low\nhttp: []\n",
+		"bad sev":     "id: test\ninfo:\n  name: test\n  severity: urgent\nhttp: []\n",
+		"no protocol": "id: test\ninfo:\n  name: test\n  severity: low\n",
+		"not yaml":    "id: [test\n",
+	}
+	for name, c := range invalid {
+		if _, err = ParseNucleiTemplate([]byte(c)); err == nil {
+			t.Errorf("%s should be invalid", name)
+		}
+	}
+}
+
+func TestNucleiTemplateIndex_MatchTechnology(t *testing.T) {
+	idx := &NucleiTemplateIndex{loaded: true, templates: []NucleiTemplate{
+		{Id: "a", Tags: []string{"tomcat", "apache", "cve"}, Severity: "high", Protocol: "http"},
+		{Id: "b", Tags: []string{"nginx", "exposure"}, Severity: "info", Protocol: "http"},
+		{Id: "c", Tags: []string{"weblogic"}, Severity: "critical", Protocol: "network"},
+	}}
+	tags := idx.MatchTechnology([]string{"Apache-Tomcat", "nginx/1.18.0", "Login Page"})
+	if strings.Join(tags, ",") != "apache,nginx,tomcat" {
+		t.Errorf("unexpected tags:%v", tags)
+	}
+	if r := idx.Filter(NucleiTemplateFilter{Severity: []string{"critical", "high"}}); len(r) != 2 {
+		t.Errorf("unexpected filter by severity:%v", r)
+	}
+	if r := idx.Filter(NucleiTemplateFilter{Tags: []string{"nginx"}, Protocol: "http"}); len(r) != 1 || r[0].Id != "b" {
+		t.Errorf("unexpected filter by tags:%v", r)
+	}
+}
+
+func TestNuclei_templateArgs(t *testing.T) {
+	conf.WorkerDefaultConfigFile = "../../../conf/worker.yml"
+	n := NewNuclei(Config{PocFile: "http/cves", Tags: []string{"tomcat"}, Severity: []string{"critical", "high"}})
+	templateArgs, err := n.templateArgs()
+	args := strings.Join(templateArgs, " ")
+	if err != nil || !strings.Contains(args, "-t ") || !strings.HasSuffix(args, "http/cves -tags tomcat -severity critical,high") {
+		t.Errorf("unexpected args:%s", args)
+	}
+	n = NewNuclei(Config{Workflow: "workflows/tomcat-workflow.yaml"})
+	if templateArgs, err = n.templateArgs(); err != nil || !strings.HasPrefix(strings.Join(templateArgs, " "), "-w ") {
+		t.Errorf("unexpected args:%v", templateArgs)
+	}
+	for _, c := range []Config{{PocFile: "http/cves,../../../etc/passwd"}, {Workflow: "../workflow.yaml"}, {PocFile: "/etc"}} {
+		if _, err = NewNuclei(c).templateArgs(); err == nil {
+			t.Errorf("template path escaped:%v", c)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package pocscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// FingerprintPocFile 指纹与POC对应关系的配置文件
+	FingerprintPocFile = "thirdparty/custom/fingerprint_poc.json"
+)
+
+// FingerprintPocRule 一条指纹与POC的对应规则：指纹关键词匹配后，使用对应的POC集合
+type FingerprintPocRule struct {
+	Name string `json:"name"`
+	// Fingerprint 指纹关键词，不区分大小写，只要目标的任一指纹包含关键词即匹配
+	Fingerprint []string `json:"fingerprint,omitempty"`
+	// Xray 内置POC的名称（支持xray的模糊匹配）；自定义POC时为文件名
+	Xray []string `json:"xray,omitempty"`
+	// Nuclei 模板文件或目录，NucleiTags 模板的标签
+	Nuclei     []string `json:"nuclei,omitempty"`
+	NucleiTags []string `json:"nucleiTags,omitempty"`
+	// XrayPocV1 xraypocv1的POC文件名
+	XrayPocV1 []string `json:"xraypocv1,omitempty"`
+}
+
+// FingerprintPocMap 指纹与POC的对应关系；没有匹配任何规则的目标使用Fallback
+type FingerprintPocMap struct {
+	Rules    []FingerprintPocRule `json:"rules"`
+	Fallback FingerprintPocRule   `json:"fallback"`
+}
+
+// PocSet 目标匹配得到的POC集合
+type PocSet struct {
+	// Matched 匹配的规则名称，为空表示使用的是Fallback
+	Matched    []string
+	Xray       []string
+	Nuclei     []string
+	NucleiTags []string
+	XrayPocV1  []string
+}
+
+// ParseFingerprintPocMap 解析并校验对应关系的配置内容
+func ParseFingerprintPocMap(content []byte) (m *FingerprintPocMap, err error) {
+	m = &FingerprintPocMap{}
+	if err = json.Unmarshal(content, m); err != nil {
+		return nil, err
+	}
+	for i, r := range m.Rules {
+		if strings.TrimSpace(r.Name) == "" {
+			return nil, fmt.Errorf("rule %d has no name", i)
+		}
+		if len(r.Fingerprint) == 0 {
+			return nil, fmt.Errorf("rule %s has no fingerprint", r.Name)
+		}
+		for _, pocs := range [][]string{r.Xray, r.Nuclei, r.XrayPocV1} {
+			for _, poc := range pocs {
+				if strings.Contains(poc, "..") || strings.Contains(poc, "\\") || strings.Contains(poc, ",") {
+					return nil, fmt.Errorf("rule %s has invalid poc:%s", r.Name, poc)
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// LoadFingerprintPocMap 加载对应关系的配置文件
+func LoadFingerprintPocMap() (m *FingerprintPocMap, err error) {
+	content, err := os.ReadFile(filepath.Join(conf.GetRootPath(), FingerprintPocFile))
+	if err != nil {
+		return nil, err
+	}
+	if m, err = ParseFingerprintPocMap(content); err != nil {
+		return nil, fmt.Errorf("invalid %s:%v", FingerprintPocFile, err)
+	}
+	return m, nil
+}
+
+// Match 根据目标的指纹匹配规则，合并所有匹配规则的POC集合；没有匹配时返回Fallback
+func (m *FingerprintPocMap) Match(fingerprints []string) (s PocSet) {
+	var lowerFingerprints []string
+	for _, f := range fingerprints {
+		if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+			lowerFingerprints = append(lowerFingerprints, f)
+		}
+	}
+	for _, r := range m.Rules {
+		if !matchFingerprintKeyword(lowerFingerprints, r.Fingerprint) {
+			continue
+		}
+		s.Matched = append(s.Matched, r.Name)
+		s.Xray = append(s.Xray, r.Xray...)
+		s.Nuclei = append(s.Nuclei, r.Nuclei...)
+		s.NucleiTags = append(s.NucleiTags, r.NucleiTags...)
+		s.XrayPocV1 = append(s.XrayPocV1, r.XrayPocV1...)
+	}
+	if len(s.Matched) == 0 {
+		s.Xray = m.Fallback.Xray
+		s.Nuclei = m.Fallback.Nuclei
+		s.NucleiTags = m.Fallback.NucleiTags
+		s.XrayPocV1 = m.Fallback.XrayPocV1
+	}
+	s.Xray = uniqueSortedList(s.Xray)
+	s.Nuclei = uniqueSortedList(s.Nuclei)
+	s.NucleiTags = uniqueSortedList(s.NucleiTags)
+	s.XrayPocV1 = uniqueSortedList(s.XrayPocV1)
+	return
+}
+
+// matchFingerprintKeyword 指纹中是否包含任一关键词
+func matchFingerprintKeyword(fingerprints []string, keywords []string) bool {
+	for _, k := range keywords {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" {
+			continue
+		}
+		for _, f := range fingerprints {
+			if strings.Contains(f, k) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// uniqueSortedList 去重并排序，使相同的POC集合可以合并为一个任务
+func uniqueSortedList(list []string) (result []string) {
+	set := make(map[string]struct{})
+	for _, v := range list {
+		if v = strings.TrimSpace(v); v != "" {
+			set[v] = struct{}{}
+		}
+	}
+	for v := range set {
+		result = append(result, v)
+	}
+	sort.Strings(result)
+	return
+}
+
+// ParseFingerprintAttr 从指纹识别的属性中提取指纹：fingerprint、server，以及httpx识别的technologies
+func ParseFingerprintAttr(tag string, content string) (fingerprints []string) {
+	switch tag {
+	case "fingerprint", "server":
+		fingerprints = append(fingerprints, content)
+	case "httpx":
+		var httpxResult struct {
+			Technologies []string `json:"technologies"`
+		}
+		if err := json.Unmarshal([]byte(content), &httpxResult); err != nil {
+			logging.RuntimeLog.Debugf("parse httpx technologies fail:%v", err)
+			return
+		}
+		fingerprints = append(fingerprints, httpxResult.Technologies...)
+	}
+	return
+}
@@ -0,0 +1,83 @@
+package pocscan
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFingerprintPocMap(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("../../..", FingerprintPocFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := ParseFingerprintPocMap(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(len(m.Rules))
+
+	s := m.Match([]string{"Apache-Coyote/1.1", "Tomcat"})
+	t.Log(s)
+	if len(s.Matched) != 1 || s.Matched[0] != "Apache-Tomcat" {
+		t.Errorf("tomcat should be matched:%v", s.Matched)
+	}
+	s = m.Match([]string{"unknown-app"})
+	t.Log(s)
+	if len(s.Matched) != 0 || strings.Join(s.NucleiTags, ",") != strings.Join(uniqueSortedList(m.Fallback.NucleiTags), ",") {
+		t.Errorf("unknown fingerprint should use fallback:%v", s)
+	}
+}
+
+func TestFingerprintPocMap_Match(t *testing.T) {
+	content := `{"rules":[
+{"name":"tomcat","fingerprint":["tomcat"],"xray":["*tomcat*"],"nucleiTags":["tomcat"]},
+{"name":"shiro","fingerprint":["Shiro"],"xray":["*shiro*"],"xraypocv1":["shiro-key.yml"]},
+{"name":"java","fingerprint":["tomcat","java"],"nucleiTags":["java","tomcat"]}],
+"fallback":{"nuclei":["http/exposures/"]}}`
+	m, err := ParseFingerprintPocMap([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := m.Match([]string{"Apache Tomcat/8.5", "shiro"})
+	t.Log(s)
+	if strings.Join(s.Matched, ",") != "tomcat,shiro,java" {
+		t.Errorf("unexpected matched rules:%v", s.Matched)
+	}
+	if strings.Join(s.Xray, ",") != "*shiro*,*tomcat*" || strings.Join(s.NucleiTags, ",") != "java,tomcat" || strings.Join(s.XrayPocV1, ",") != "shiro-key.yml" || len(s.Nuclei) != 0 {
+		t.Errorf("unexpected poc set:%v", s)
+	}
+	s = m.Match(nil)
+	if len(s.Matched) != 0 || strings.Join(s.Nuclei, ",") != "http/exposures/" || len(s.Xray) != 0 {
+		t.Errorf("unexpected fallback poc set:%v", s)
+	}
+}
+
+func TestParseFingerprintPocMap(t *testing.T) {
+	for _, content := range []string{
+		`{"rules":[{"name":"","fingerprint":["tomcat"]}]}`,
+		`{"rules":[{"name":"tomcat"}]}`,
+		`{"rules":[{"name":"tomcat","fingerprint":["tomcat"],"xraypocv1":["../poc.yml"]}]}`,
+		`{"rules":[{"name":"tomcat","fingerprint":["tomcat"],"xray":["a,b"]}]}`,
+		`{"rules":`,
+	} {
+		if _, err := ParseFingerprintPocMap([]byte(content)); err == nil {
+			t.Errorf("invalid content should be rejected:%s", content)
+		}
+	}
+}
+
+func TestParseFingerprintAttr(t *testing.T) {
+	httpx := `{"url":"http://127.0.0.1:8000","webserver":"SimpleHTTP/0.6 Python/3.9.13","technologies":["Python:3.9.13","SimpleHTTP:0.6"]}`
+	fingerprints := ParseFingerprintAttr("httpx", httpx)
+	if strings.Join(fingerprints, ",") != "Python:3.9.13,SimpleHTTP:0.6" {
+		t.Errorf("unexpected httpx technologies:%v", fingerprints)
+	}
+	if f := ParseFingerprintAttr("server", "nginx"); len(f) != 1 || f[0] != "nginx" {
+		t.Errorf("unexpected server fingerprint:%v", f)
+	}
+	if f := ParseFingerprintAttr("title", "Welcome to nginx"); len(f) != 0 {
+		t.Errorf("title should be ignored:%v", f)
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/db"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
+	"github.com/hanc00l/nemo_go/pkg/task/urlscan"
 	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/sirupsen/logrus"
 	"strings"
 	"sync"
 	"time"
@@ -22,11 +25,24 @@ var (
 )
 
 type Config struct {
-	Target           string `json:"target"`
-	PocFile          string `json:"pocFile"`
-	CmdBin           string `json:"cmdBin"`
-	IsLoadOpenedPort bool   `json:"loadOpenedPort"`
-	WorkspaceId      int    `json:"workspaceId"`
+	Target           string   `json:"target"`
+	PocFile          string   `json:"pocFile"`
+	CmdBin           string   `json:"cmdBin"`
+	IsLoadOpenedPort bool     `json:"loadOpenedPort"`
+	WorkspaceId      int      `json:"workspaceId"`
+	Headers          []string `json:"headers,omitempty"`
+	// nuclei模板的筛选条件及工作流
+	Tags     []string `json:"tags,omitempty"`
+	Severity []string `json:"severity,omitempty"`
+	Workflow string   `json:"workflow,omitempty"`
+	// 任务ID，用于关联任务日志
+	TaskId     string `json:"-"`
+	MainTaskId string `json:"-"`
+}
+
+// log 任务的日志
+func (c *Config) log() *logrus.Entry {
+	return logging.NewTaskLog(c.TaskId, c.MainTaskId, c.WorkspaceId)
 }
 
 type Result struct {
@@ -36,6 +52,8 @@ type Result struct {
 	Source      string `json:"source"`
 	Extra       string `json:"extra"`
 	WorkspaceId int    `json:"workspaceId"`
+	// Egress 验证使用的代理出口IP，直接访问时为空
+	Egress string `json:"egress,omitempty"`
 }
 
 type xrayJSONResult struct {
@@ -214,6 +232,9 @@ func SaveResult(result []Result) string {
 		if len(r.Extra) > 2000 {
 			extra = r.Extra[:2000] + "..."
 		}
+		if r.Egress != "" {
+			extra = fmt.Sprintf("egress:%s\n\n%s", r.Egress, extra)
+		}
 		vul := db.Vulnerability{
 			Target:      target,
 			Url:         r.Url,
@@ -236,3 +257,71 @@ func SaveResult(result []Result) string {
 	}
 	return sb.String()
 }
+
+// SavePathResult 保存目录扫描发现的路径，按目标关联到IP的端口或域名
+func SavePathResult(result []PathResult) string {
+	var resultCount, newPath int
+	var urlResult []urlscan.UrlResult
+	for _, r := range result {
+		urlResult = append(urlResult, urlscan.UrlResult{
+			Url:         r.Url,
+			Method:      "GET",
+			Status:      r.Status,
+			Source:      urlscan.SourceDirsearch,
+			WorkspaceId: r.WorkspaceId,
+		})
+		var ok, isNew bool
+		if utils.CheckIP(r.Target) {
+			ok, isNew = saveIpPath(r)
+		} else {
+			ok, isNew = saveDomainPath(r)
+		}
+		if ok {
+			resultCount++
+			if isNew {
+				newPath++
+			}
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("path:%d", resultCount))
+	if newPath > 0 {
+		sb.WriteString(fmt.Sprintf(",pathNew:%d", newPath))
+	}
+	// 同时作为URL资产保存
+	sb.WriteString(",")
+	sb.WriteString(urlscan.SaveResult(urlResult))
+	return sb.String()
+}
+
+// saveIpPath 保存IP端口的路径，IP或端口不存在时不保存
+func saveIpPath(r PathResult) (success bool, isNew bool) {
+	ip := db.Ip{IpName: r.Target, WorkspaceId: r.WorkspaceId}
+	if !ip.GetByIp() {
+		return
+	}
+	port := db.Port{IpId: ip.Id, PortNum: r.Port}
+	if !port.GetByIPPort() {
+		return
+	}
+	path := db.IpPath{RelatedId: port.Id, Path: r.Path, Url: r.Url, Status: r.Status, ContentLength: r.ContentLength, Location: r.Location, Source: r.Source}
+	if r.Egress != "" {
+		portAttr := db.PortAttr{RelatedId: port.Id, Source: r.Source, Tag: proxypool.EgressTag, Content: r.Egress}
+		portAttr.SaveOrUpdate()
+	}
+	return path.SaveOrUpdate()
+}
+
+// saveDomainPath 保存域名的路径，域名不存在时不保存
+func saveDomainPath(r PathResult) (success bool, isNew bool) {
+	domain := db.Domain{DomainName: r.Target, WorkspaceId: r.WorkspaceId}
+	if !domain.GetByDomain() {
+		return
+	}
+	path := db.DomainPath{RelatedId: domain.Id, Port: r.Port, Path: r.Path, Url: r.Url, Status: r.Status, ContentLength: r.ContentLength, Location: r.Location, Source: r.Source}
+	if r.Egress != "" {
+		domainAttr := db.DomainAttr{RelatedId: domain.Id, Source: r.Source, Tag: proxypool.EgressTag, Content: r.Egress}
+		domainAttr.SaveOrUpdate()
+	}
+	return path.SaveOrUpdate()
+}
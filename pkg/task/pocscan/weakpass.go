@@ -0,0 +1,123 @@
+package pocscan
+
+import (
+	"context"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/custom"
+	"github.com/hanc00l/nemo_go/pkg/weakpass"
+	"github.com/remeh/sizedwaitgroup"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	weakPassSource         = "weakpass"
+	weakPassDictPath       = "thirdparty/dict/weakpass"
+	defaultWeakPassThreads = 10
+	defaultWeakPassTimeout = 5
+)
+
+// WeakPass 常见服务的弱口令验证：同一主机的服务串行验证，主机之间并发
+type WeakPass struct {
+	Config Config
+	Result []Result
+	// DictPath 字典目录
+	DictPath string
+	Options  weakpass.Options
+	// Threads 并发验证的主机数
+	Threads int
+
+	resultMutex sync.Mutex
+}
+
+// NewWeakPass 创建弱口令验证对象
+func NewWeakPass(config Config) *WeakPass {
+	c := conf.GlobalWorkerConfig().Pocscan.WeakPass
+	w := &WeakPass{
+		Config:   config,
+		DictPath: filepath.Join(conf.GetRootPath(), weakPassDictPath),
+		Threads:  c.Threads,
+		Options: weakpass.Options{
+			Timeout:       time.Duration(c.Timeout) * time.Second,
+			Interval:      time.Duration(c.Interval) * time.Millisecond,
+			MaxAttempts:   c.MaxAttempts,
+			StopOnSuccess: c.StopOnSuccess,
+		},
+	}
+	if w.Threads <= 0 {
+		w.Threads = defaultWeakPassThreads
+	}
+	if w.Options.Timeout <= 0 {
+		w.Options.Timeout = defaultWeakPassTimeout * time.Second
+	}
+	return w
+}
+
+// Do 执行弱口令验证，目标格式为service://ip:port
+func (w *WeakPass) Do() {
+	hosts := w.groupTargetByHost()
+	if len(hosts) == 0 {
+		return
+	}
+	scanner := weakpass.NewScanner(weakpass.NewDictionary(w.DictPath), w.Options)
+	swg := sizedwaitgroup.New(w.Threads)
+	for _, targets := range hosts {
+		swg.Add()
+		go func(targets []weakpass.Target) {
+			defer swg.Done()
+			w.saveResult(scanner.ScanHost(context.Background(), targets))
+		}(targets)
+	}
+	swg.Wait()
+}
+
+// groupTargetByHost 解析目标并进行黑名单检查，按主机分组
+func (w *WeakPass) groupTargetByHost() (hosts map[string][]weakpass.Target) {
+	hosts = make(map[string][]weakpass.Target)
+	btc := custom.NewBlackTargetCheck(custom.CheckAll)
+	for _, t := range strings.Split(w.Config.Target, ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		target, err := weakpass.ParseTarget(t)
+		if err != nil {
+			w.Config.log().Warningf("invalid weakpass target:%v", err)
+			continue
+		}
+		if btc.CheckBlack(target.Host) {
+			w.Config.log().Warningf("%s is in blacklist,skip...", t)
+			continue
+		}
+		hosts[target.Host] = append(hosts[target.Host], target)
+	}
+	return
+}
+
+// saveResult 保存验证成功的结果，Extra中记录凭据
+func (w *WeakPass) saveResult(results []weakpass.Result) {
+	w.resultMutex.Lock()
+	defer w.resultMutex.Unlock()
+	for _, r := range results {
+		extra := fmt.Sprintf("username:%s\npassword:%s", r.Credential.Username, r.Credential.Password)
+		if r.Unauthorized {
+			extra = "unauthorized"
+		}
+		pocFile := fmt.Sprintf("%s-%s", weakPassSource, r.Target.Service)
+		if r.Target.Panel != "" {
+			pocFile = fmt.Sprintf("%s-%s", weakPassSource, r.Target.Panel)
+		}
+		logging.CLILog.Infof("%s %s found", r.Target, pocFile)
+		w.Result = append(w.Result, Result{
+			Target:      r.Target.Host,
+			Url:         r.Target.String(),
+			PocFile:     pocFile,
+			Source:      weakPassSource,
+			Extra:       extra,
+			WorkspaceId: w.Config.WorkspaceId,
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"os"
 	"os/exec"
@@ -37,7 +38,7 @@ func (x *Xray) Do() {
 	}
 	err := os.WriteFile(inputTargetFile, []byte(strings.Join(urlsFormatted, "\n")), 0666)
 	if err != nil {
-		logging.RuntimeLog.Error(err.Error())
+		x.Config.log().Error(err.Error())
 		return
 	}
 	cmdBin := filepath.Join(conf.GetAbsRootPath(), "thirdparty/xray", utils.GetThirdpartyBinNameByPlatform(utils.Xray))
@@ -53,7 +54,7 @@ func (x *Xray) Do() {
 	}
 	// check poc file name
 	if strings.Contains(pocFile, "..") || strings.Contains(pocFile, "/") || strings.Contains(pocFile, "\\") {
-		logging.RuntimeLog.Warningf("invalid poc file:%s", pocFile)
+		x.Config.log().Warningf("invalid poc file:%s", pocFile)
 		return
 	}
 	// format xray cmdline
@@ -67,17 +68,17 @@ func (x *Xray) Do() {
 		)
 	}
 	if pocType == "custom" {
-		if pocType != "" {
-			cmdArgs = append(
-				cmdArgs, "--plugins", "phantasm", "--poc",
-				filepath.Join(conf.GetAbsRootPath(), conf.GlobalWorkerConfig().Pocscan.Xray.PocPath, x.Config.PocFile),
-			)
-		} else {
-			cmdArgs = append(
-				cmdArgs, "--plugins", "phantasm", "--poc",
-				filepath.Join(conf.GetAbsRootPath(), conf.GlobalWorkerConfig().Pocscan.Xray.PocPath, "*"),
-			)
+		if pocFile == "" {
+			pocFile = "*"
 		}
+		// 多个poc文件以“,”分隔
+		var pocFiles []string
+		for _, f := range strings.Split(pocFile, ",") {
+			pocFiles = append(pocFiles, filepath.Join(conf.GetAbsRootPath(), conf.GlobalWorkerConfig().Pocscan.Xray.PocPath, strings.TrimSpace(f)))
+		}
+		cmdArgs = append(
+			cmdArgs, "--plugins", "phantasm", "--poc", strings.Join(pocFiles, ","),
+		)
 	}
 	cmd := exec.Command(cmdBin, cmdArgs...)
 	//Fix:必须指定绝对路径，才能正确读取到配置文件
@@ -86,8 +87,9 @@ func (x *Xray) Do() {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = &stderr
 	err = cmd.Run()
+	metrics.ObserveToolExit("xray", err)
 	if err != nil {
-		logging.RuntimeLog.Error(err, stderr)
+		x.Config.log().Error(err, stderr)
 		logging.CLILog.Error(err, stderr)
 		return
 	}
@@ -104,7 +106,7 @@ func (x *Xray) parseXrayResult(outputTempFile string) {
 	var xr []xrayJSONResult
 	err = json.Unmarshal(content, &xr)
 	if err != nil {
-		logging.RuntimeLog.Error(err.Error())
+		x.Config.log().Error(err.Error())
 		return
 	}
 	for _, r := range xr {
@@ -141,7 +143,7 @@ func (x *Xray) LoadPocFile() (pocs []string) {
 func (x *Xray) LoadDefaultPocFile() (pocs []string) {
 	inputFile, err := os.Open(filepath.Join(conf.GetRootPath(), "thirdparty/xray", "poc.list"))
 	if err != nil {
-		logging.RuntimeLog.Errorf("could not read poc.list: %s", err)
+		x.Config.log().Errorf("could not read poc.list: %s", err)
 		return
 	}
 	defer inputFile.Close()
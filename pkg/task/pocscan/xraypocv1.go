@@ -0,0 +1,162 @@
+package pocscan
+
+import (
+	"context"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/proxypool"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/hanc00l/nemo_go/pkg/xraypocv1"
+	"github.com/remeh/sizedwaitgroup"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	xrayPocV1Source         = "xraypocv1"
+	defaultXrayPocV1Threads = 20
+	defaultXrayPocV1Timeout = 10
+)
+
+// XrayPocV1 使用进程内的xraypocv1引擎执行xray格式的yaml POC
+type XrayPocV1 struct {
+	Config Config
+	Result []Result
+	// PocPath POC文件所在目录，默认为worker配置中的pocPath
+	PocPath string
+	// Threads 并发数，Timeout 每个请求的超时时间（秒），Proxy 请求使用的代理
+	// 配置文件未指定Proxy时使用代理池为xraypocv1分配的代理，Egress为其出口IP
+	Threads int
+	Timeout int
+	Proxy   string
+	Egress  string
+
+	resultMutex sync.Mutex
+}
+
+// NewXrayPocV1 创建xraypocv1对象
+func NewXrayPocV1(config Config) *XrayPocV1 {
+	c := conf.GlobalWorkerConfig().Pocscan.XrayPocV1
+	x := &XrayPocV1{
+		Config:  config,
+		PocPath: filepath.Join(conf.GetRootPath(), c.PocPath),
+		Threads: c.Threads,
+		Timeout: c.Timeout,
+		Proxy:   c.Proxy,
+	}
+	if x.Threads <= 0 {
+		x.Threads = defaultXrayPocV1Threads
+	}
+	if x.Timeout <= 0 {
+		x.Timeout = defaultXrayPocV1Timeout
+	}
+	if x.Proxy == "" {
+		if proxy := proxypool.Get(proxypool.TaskXrayPocV1); proxy != nil {
+			x.Proxy, x.Egress = proxy.URL, proxy.Egress
+		}
+	}
+	return x
+}
+
+// Do 对目标执行POC验证
+func (x *XrayPocV1) Do() {
+	var urlsFormatted []string
+	//没有需要检测的端口,直接返回
+	if urlsFormatted = checkAndFormatUrl(x.Config.Target, true); len(urlsFormatted) == 0 {
+		return
+	}
+	pocs := x.loadPocs()
+	if len(pocs) == 0 {
+		x.Config.log().Warningf("no xraypocv1 poc loaded:%s", x.Config.PocFile)
+		return
+	}
+	engine, err := xraypocv1.NewPocEngine(x.Proxy, time.Duration(x.Timeout)*time.Second)
+	if err != nil {
+		x.Config.log().Error(err)
+		logging.CLILog.Error(err)
+		return
+	}
+	swg := sizedwaitgroup.New(x.Threads)
+	for _, url := range urlsFormatted {
+		for pocFile, pocBody := range pocs {
+			swg.Add()
+			go func(url, pocFile string, pocBody []byte) {
+				defer swg.Done()
+				x.RunPoc(engine, url, pocFile, pocBody)
+			}(url, pocFile, pocBody)
+		}
+	}
+	swg.Wait()
+}
+
+// RunPoc 对一个url执行一个POC，验证成功时保存结果
+func (x *XrayPocV1) RunPoc(engine *xraypocv1.PocEngine, url string, pocFile string, pocBody []byte) {
+	isVul, pocName, evidence, err := engine.Execute(context.Background(), url, pocBody)
+	if err != nil {
+		x.Config.log().Debugf("%s execute poc %s fail:%v", url, pocFile, err)
+		return
+	}
+	if !isVul {
+		return
+	}
+	logging.CLILog.Infof("%s %s is vulnerable", url, pocName)
+	x.resultMutex.Lock()
+	defer x.resultMutex.Unlock()
+	x.Result = append(x.Result, Result{
+		Target:      utils.ParseHost(url),
+		Url:         url,
+		PocFile:     pocName,
+		Source:      xrayPocV1Source,
+		Extra:       fmt.Sprintf("%s\n\n%s", evidence.Request, evidence.Response),
+		WorkspaceId: x.Config.WorkspaceId,
+		Egress:      x.Egress,
+	})
+}
+
+// loadPocs 读取POC文件内容，PocFile为空时加载目录下的全部POC
+func (x *XrayPocV1) loadPocs() (pocs map[string][]byte) {
+	pocs = make(map[string][]byte)
+	var files []string
+	if x.Config.PocFile == "" {
+		files = x.LoadPocFile()
+	} else {
+		for _, pocFile := range strings.Split(x.Config.PocFile, ",") {
+			pocFile = strings.TrimSpace(pocFile)
+			// check poc file name
+			if pocFile == "" || strings.Contains(pocFile, "..") || strings.Contains(pocFile, "/") || strings.Contains(pocFile, "\\") {
+				x.Config.log().Warningf("invalid poc file:%s", pocFile)
+				continue
+			}
+			files = append(files, pocFile)
+		}
+	}
+	for _, pocFile := range files {
+		content, err := os.ReadFile(filepath.Join(x.PocPath, pocFile))
+		if err != nil {
+			x.Config.log().Errorf("read poc file %s fail:%v", pocFile, err)
+			continue
+		}
+		if _, err = xraypocv1.LoadPoc(content); err != nil {
+			x.Config.log().Warningf("invalid poc file %s:%v", pocFile, err)
+			continue
+		}
+		pocs[pocFile] = content
+	}
+	return
+}
+
+// LoadPocFile 加载poc文件列表
+func (x *XrayPocV1) LoadPocFile() (pocs []string) {
+	for _, ext := range []string{"*.yml", "*.yaml"} {
+		files, _ := filepath.Glob(filepath.Join(x.PocPath, ext))
+		for _, file := range files {
+			_, pocFile := filepath.Split(file)
+			pocs = append(pocs, pocFile)
+		}
+	}
+	return
+}
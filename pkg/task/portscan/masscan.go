@@ -3,6 +3,7 @@ package portscan
 import (
 	"bytes"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	gonmap "github.com/lair-framework/go-nmap"
@@ -40,14 +41,14 @@ func (m *Masscan) Do() {
 	for _, target := range strings.Split(m.Config.Target, ",") {
 		t := strings.TrimSpace(target)
 		if btc.CheckBlack(t) {
-			logging.RuntimeLog.Warningf("%s is in blacklist,skip...", t)
+			m.Config.log().Warningf("%s is in blacklist,skip...", t)
 			continue
 		}
 		targets = append(targets, t)
 	}
 	err := os.WriteFile(inputTargetFile, []byte(strings.Join(targets, "\n")), 0666)
 	if err != nil {
-		logging.RuntimeLog.Error(err.Error())
+		m.Config.log().Error(err.Error())
 		return
 	}
 	var cmdArgs []string
@@ -78,8 +79,9 @@ func (m *Masscan) Do() {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = &stderr
 	err = cmd.Run()
+	metrics.ObserveToolExit("masscan", err)
 	if err != nil {
-		logging.RuntimeLog.Error(err, stderr)
+		m.Config.log().Error(err, stderr)
 		logging.CLILog.Error(err, stderr)
 		return
 	}
@@ -91,7 +93,7 @@ func (m *Masscan) Do() {
 func (m *Masscan) parsResult(outputTempFile string) {
 	content, err := os.ReadFile(outputTempFile)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		m.Config.log().Error(err)
 		return
 	}
 
@@ -106,7 +108,7 @@ func (m *Masscan) parsResult(outputTempFile string) {
 			ip := strings.TrimSpace(data[3])
 			portNumber, err := strconv.Atoi(data[2])
 			if err != nil {
-				logging.RuntimeLog.Error(err)
+				m.Config.log().Error(err)
 				continue
 			}
 			if !m.Result.HasIP(ip) {
@@ -117,7 +119,7 @@ func (m *Masscan) parsResult(outputTempFile string) {
 			}
 			service := s.FindService(portNumber, ip)
 			m.Result.SetPortAttr(ip, portNumber, PortAttrResult{
-				Source:  "portscan",
+				Source:  "masscan",
 				Tag:     "service",
 				Content: service,
 			})
@@ -159,7 +161,7 @@ func (m *Masscan) ParseContentResult(content []byte) (result Result) {
 				}
 				service := s.FindService(port.PortId, ip)
 				result.SetPortAttr(ip, port.PortId, PortAttrResult{
-					Source:  "portscan",
+					Source:  "masscan",
 					Tag:     "service",
 					Content: service,
 				})
@@ -3,6 +3,7 @@ package portscan
 import (
 	"bytes"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"os"
 	"os/exec"
@@ -37,7 +38,7 @@ func (nmap *Nmap) Do() {
 	for _, target := range strings.Split(nmap.Config.Target, ",") {
 		t := strings.TrimSpace(target)
 		if btc.CheckBlack(t) {
-			logging.RuntimeLog.Warningf("%s is in blacklist,skip...", t)
+			nmap.Config.log().Warningf("%s is in blacklist,skip...", t)
 			continue
 		}
 		if utils.CheckIPV4(t) || utils.CheckIPV4Subnet(t) {
@@ -64,7 +65,7 @@ func (nmap *Nmap) RunNmap(targets []string, ipv6 bool) {
 
 	err := os.WriteFile(inputTargetFile, []byte(strings.Join(targets, "\n")), 0666)
 	if err != nil {
-		logging.RuntimeLog.Error(err.Error())
+		nmap.Config.log().Error(err.Error())
 		return
 	}
 
@@ -94,8 +95,9 @@ func (nmap *Nmap) RunNmap(targets []string, ipv6 bool) {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = &stderr
 	err = cmd.Run()
+	metrics.ObserveToolExit("nmap", err)
 	if err != nil {
-		logging.RuntimeLog.Error(err, stderr)
+		nmap.Config.log().Error(err, stderr)
 		logging.CLILog.Error(err, stderr)
 		return
 	}
@@ -106,7 +108,7 @@ func (nmap *Nmap) RunNmap(targets []string, ipv6 bool) {
 func (nmap *Nmap) parseResult(outputTempFile string) {
 	content, err := os.ReadFile(outputTempFile)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		nmap.Config.log().Error(err)
 		return
 	}
 	result := nmap.ParseContentResult(content)
@@ -149,14 +151,14 @@ func (nmap *Nmap) ParseContentResult(content []byte) (result Result) {
 					service = s.FindService(port.PortId, ip)
 				}
 				result.SetPortAttr(ip, port.PortId, PortAttrResult{
-					Source:  "portscan",
+					Source:  "nmap",
 					Tag:     "service",
 					Content: service,
 				})
 				banner := strings.Join([]string{port.Service.Product, port.Service.Version}, " ")
 				if strings.TrimSpace(banner) != "" {
 					result.SetPortAttr(ip, port.PortId, PortAttrResult{
-						Source:  "portscan",
+						Source:  "nmap",
 						Tag:     "banner",
 						Content: banner,
 					})
@@ -3,8 +3,10 @@ package portscan
 import (
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/fulltext"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
+	"github.com/sirupsen/logrus"
 	"strings"
 	"sync"
 )
@@ -31,6 +33,14 @@ type Config struct {
 	IsLoadOpenedPort bool   `json:"loadOpenedPort"`
 	IsPortscan       bool   `json:"isPortscan"`
 	WorkspaceId      int    `json:"workspaceId"`
+	// 任务ID，用于关联任务日志
+	TaskId     string `json:"-"`
+	MainTaskId string `json:"-"`
+}
+
+// log 任务的日志
+func (c *Config) log() *logrus.Entry {
+	return logging.NewTaskLog(c.TaskId, c.MainTaskId, c.WorkspaceId)
 }
 
 // PortAttrResult 端口属性结果
@@ -159,6 +169,7 @@ func (r *Result) SetPortHttpInfo(ip string, port int, result HttpResult) {
 func (r *Result) SaveResult(config Config) string {
 	var resultIPCount, resultPortCount int
 	var newIP, newPort int
+	var docs []fulltext.Document
 	blackIP := custom.NewBlackTargetCheck(custom.CheckIP)
 	for ipName, ipResult := range r.IPResult {
 		if blackIP.CheckBlack(ipName) {
@@ -212,7 +223,9 @@ func (r *Result) SaveResult(config Config) string {
 				} else {
 					portAttr.Content = portAttrResult.Content
 				}
-				portAttr.SaveOrUpdate()
+				if portAttr.SaveOrUpdate() {
+					docs = append(docs, fulltext.NewPortAttrDocument(ipName, portNumber, portAttr))
+				}
 			}
 			//save http info
 			for _, httpInfoResult := range portResult.HttpInfo {
@@ -226,10 +239,13 @@ func (r *Result) SaveResult(config Config) string {
 				} else {
 					httpInfo.Content = httpInfoResult.Content
 				}
-				httpInfo.SaveOrUpdate()
+				if httpInfo.SaveOrUpdate() {
+					docs = append(docs, fulltext.NewIpHttpDocument(ipName, portNumber, httpInfo))
+				}
 			}
 		}
 	}
+	fulltext.IndexWorkspace(config.WorkspaceId, docs)
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("ip:%d", resultIPCount))
 	if newIP > 0 {
@@ -1,5 +1,7 @@
 package runner
 
+import "github.com/hanc00l/nemo_go/pkg/task/onlineapi"
+
 type PortscanRequestParam struct {
 	Target             string `form:"target"`
 	IsPortScan         bool   `form:"portscan"`
@@ -7,6 +9,11 @@ type PortscanRequestParam struct {
 	IsFofa             bool   `form:"fofasearch"`
 	IsQuake            bool   `form:"quakesearch"`
 	IsHunter           bool   `form:"huntersearch"`
+	IsShodan           bool   `form:"shodansearch"`
+	IsCensys           bool   `form:"censyssearch"`
+	IsZoomEye          bool   `form:"zoomeyesearch"`
+	IsNetlas           bool   `form:"netlassearch"`
+	IsBinaryEdge       bool   `form:"binaryedgesearch"`
 	Port               string `form:"port"`
 	Rate               int    `form:"rate"`
 	NmapTech           string `form:"nmap_tech"`
@@ -25,6 +32,7 @@ type PortscanRequestParam struct {
 	IsLoadOpenedPort   bool   `form:"load_opened_port"`
 	IsIgnoreOutofChina bool   `form:"ignoreoutofchina"`
 	IsIgnoreCDN        bool   `form:"ignorecdn"`
+	WorkerLabels       string `form:"worker_labels"`
 }
 
 type DomainscanRequestParam struct {
@@ -32,6 +40,8 @@ type DomainscanRequestParam struct {
 	OrgId              int    `form:"org_id"`
 	IsSubfinder        bool   `form:"subfinder"`
 	IsSubdomainBrute   bool   `form:"subdomainbrute"`
+	IsSubdomainPassive bool   `form:"subdomainpassive"`
+	IsSubdomainAlter   bool   `form:"subdomainalteration"`
 	IsFldDomain        bool   `form:"fld_domain"`
 	IsHttpx            bool   `form:"httpx"`
 	IsIPPortscan       bool   `form:"portscan"`
@@ -40,9 +50,15 @@ type DomainscanRequestParam struct {
 	IsFofa             bool   `form:"fofasearch"`
 	IsQuake            bool   `form:"quakesearch"`
 	IsHunter           bool   `form:"huntersearch"`
+	IsShodan           bool   `form:"shodansearch"`
+	IsCensys           bool   `form:"censyssearch"`
+	IsZoomEye          bool   `form:"zoomeyesearch"`
+	IsNetlas           bool   `form:"netlassearch"`
+	IsBinaryEdge       bool   `form:"binaryedgesearch"`
 	IsScreenshot       bool   `form:"screenshot"`
 	IsICPQuery         bool   `form:"icpquery"`
 	IsWhoisQuery       bool   `form:"whoisquery"`
+	IsDNSRecord        bool   `form:"dnsrecord"`
 	IsFingerprintHub   bool   `form:"fingerprinthub"`
 	IsIconHash         bool   `form:"iconhash"`
 	TaskMode           int    `form:"taskmode"`
@@ -52,6 +68,7 @@ type DomainscanRequestParam struct {
 	TaskCronComment    string `form:"croncomment" json:"-"`
 	IsIgnoreOutofChina bool   `form:"ignoreoutofchina"`
 	IsIgnoreCDN        bool   `form:"ignorecdn"`
+	WorkerLabels       string `form:"worker_labels"`
 }
 
 type PocscanRequestParam struct {
@@ -60,13 +77,22 @@ type PocscanRequestParam struct {
 	XrayPocFile      string `form:"xray_poc_file"`
 	IsNucleiVerify   bool   `form:"nucleiverify"`
 	NucleiPocFile    string `form:"nuclei_poc_file"`
+	NucleiTags       string `form:"nuclei_tags"`
+	NucleiSeverity   string `form:"nuclei_severity"`
+	NucleiWorkflow   string `form:"nuclei_workflow"`
+	IsNucleiAutoTags bool   `form:"nuclei_auto_tags"`
 	IsGobyVerify     bool   `form:"gobyverify"`
+	IsXrayPocV1      bool   `form:"xraypocv1verify"`
+	XrayPocV1File    string `form:"xraypocv1_poc_file"`
+	IsWeakPass       bool   `form:"weakpassverify"`
 	IsDirsearch      bool   `form:"dirsearch"`
 	DirsearchExtName string `form:"ext"`
+	DirsearchHeader  string `form:"dirsearch_header"`
 	IsLoadOpenedPort bool   `form:"load_opened_port"`
 	IsTaskCron       bool   `form:"taskcron" json:"-"`
 	TaskCronRule     string `form:"cronrule" json:"-"`
 	TaskCronComment  string `form:"croncomment" json:"-"`
+	WorkerLabels     string `form:"worker_labels"`
 }
 
 type XScanRequestParam struct {
@@ -84,9 +110,13 @@ type XScanRequestParam struct {
 	IsNucleiPocscan bool   `form:"nucleipoc"`
 	NucleiPocFile   string `form:"nucleipocfile"`
 	IsGobyPocscan   bool   `form:"gobypoc"`
+	IsXrayPocV1     bool   `form:"xraypocv1"`
+	XrayPocV1File   string `form:"xraypocv1file"`
+	IsPocMap        bool   `form:"pocmap"`
 	IsTaskCron      bool   `form:"taskcron" json:"-"`
 	TaskCronRule    string `form:"cronrule" json:"-"`
 	TaskCronComment string `form:"croncomment" json:"-"`
+	WorkerLabels    string `form:"worker_labels"`
 }
 
 type taskKeySearchParam struct {
@@ -98,3 +128,41 @@ type taskKeySearchParam struct {
 	Count        int    `json:"count"`
 	IsCN         bool   `json:"is_CN"`
 }
+
+// OnlineAPIs 选择的在线资产搜索引擎
+func (req PortscanRequestParam) OnlineAPIs() []string {
+	return selectOnlineAPIs(map[string]bool{
+		"fofa":       req.IsFofa,
+		"hunter":     req.IsHunter,
+		"quake":      req.IsQuake,
+		"shodan":     req.IsShodan,
+		"censys":     req.IsCensys,
+		"zoomeye":    req.IsZoomEye,
+		"netlas":     req.IsNetlas,
+		"binaryedge": req.IsBinaryEdge,
+	})
+}
+
+// OnlineAPIs 选择的在线资产搜索引擎
+func (req DomainscanRequestParam) OnlineAPIs() []string {
+	return selectOnlineAPIs(map[string]bool{
+		"fofa":       req.IsFofa,
+		"hunter":     req.IsHunter,
+		"quake":      req.IsQuake,
+		"shodan":     req.IsShodan,
+		"censys":     req.IsCensys,
+		"zoomeye":    req.IsZoomEye,
+		"netlas":     req.IsNetlas,
+		"binaryedge": req.IsBinaryEdge,
+	})
+}
+
+// selectOnlineAPIs 按onlineapi.SearchEngines的顺序返回选择的在线资产搜索引擎
+func selectOnlineAPIs(selected map[string]bool) (apis []string) {
+	for _, api := range onlineapi.SearchEngines {
+		if selected[api] {
+			apis = append(apis, api)
+		}
+	}
+	return
+}
@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +11,8 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/notify"
 	"github.com/hanc00l/nemo_go/pkg/task/ampq"
+	"github.com/hanc00l/nemo_go/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"strings"
 	"time"
 )
@@ -153,8 +156,13 @@ func processCreatedTask() (err error) {
 			VulResult:    make(map[string]map[string]interface{}),
 		}
 		comm.MainTaskResultMutex.Unlock()
-		// 启动任务执行
-		if err = runMainTask(t.TaskName, t.TaskId, t.KwArgs, t.WorkspaceId); err != nil {
+		// 启动任务执行，maintask的trace上下文在任务完成前用于关联全部子任务
+		ctx, span := tracing.Start(context.Background(), "maintask."+t.TaskName,
+			attribute.String("main_task_id", t.TaskId), attribute.Int("workspace_id", t.WorkspaceId))
+		tracing.SetTaskContext(t.TaskId, ctx)
+		err = runMainTask(t.TaskName, t.TaskId, t.KwArgs, t.WorkspaceId)
+		tracing.End(span, err)
+		if err != nil {
 			logging.RuntimeLog.Error(err)
 			return err
 		}
@@ -215,6 +223,7 @@ func processStartedTask() (err error) {
 		message := formatNotifyMessage(taskId)
 		go notify.Send(message)
 		delete(comm.MainTaskResult, taskId)
+		tracing.DeleteTaskContext(taskId)
 	}
 	comm.MainTaskResultMutex.Unlock()
 	return
@@ -250,7 +259,8 @@ func checkRunTask(taskId string) (createdTask, startedTask, totalTask int) {
 	searchMapRun["main_id"] = taskId
 	runTasks, _ := taskRun.Gets(searchMapRun, -1, -1)
 	for _, t := range runTasks {
-		if t.State == ampq.CREATED {
+		// RETRY：worker不满足任务要求而拒绝执行，任务仍在等待执行
+		if t.State == ampq.CREATED || t.State == ampq.RETRY {
 			createdTask++
 		} else if t.State == ampq.STARTED {
 			startedTask++
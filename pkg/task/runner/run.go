@@ -2,6 +2,7 @@ package runner
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/db"
@@ -14,6 +15,7 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/task/serverapi"
 	"github.com/hanc00l/nemo_go/pkg/task/workerapi"
 	"github.com/hanc00l/nemo_go/pkg/utils"
+	"sort"
 	"strings"
 	"time"
 )
@@ -44,23 +46,9 @@ func StartPortScanTask(req PortscanRequestParam, mainTaskId string, workspaceId
 					return
 				}
 			}
-			// FOFA
-			if req.IsFofa {
-				if taskId, err = doOnlineAPISearch(workspaceId, mainTaskId, "fofa", t, &req.OrgId, req.IsIPLocation, req.IsHttpx, req.IsFingerprintHub, req.IsScreenshot, req.IsIconHash, req.IsIgnoreCDN, req.IsIgnoreOutofChina); err != nil {
-					logging.RuntimeLog.Error(err)
-					return
-				}
-			}
-			// Quake
-			if req.IsQuake {
-				if taskId, err = doOnlineAPISearch(workspaceId, mainTaskId, "quake", t, &req.OrgId, req.IsIPLocation, req.IsHttpx, req.IsFingerprintHub, req.IsScreenshot, req.IsIconHash, req.IsIgnoreCDN, req.IsIgnoreOutofChina); err != nil {
-					logging.RuntimeLog.Error(err)
-					return
-				}
-			}
-			// Hunter
-			if req.IsHunter {
-				if taskId, err = doOnlineAPISearch(workspaceId, mainTaskId, "hunter", t, &req.OrgId, req.IsIPLocation, req.IsHttpx, req.IsFingerprintHub, req.IsScreenshot, req.IsIconHash, req.IsIgnoreCDN, req.IsIgnoreOutofChina); err != nil {
+			// 在线资产搜索
+			for _, apiName := range req.OnlineAPIs() {
+				if taskId, err = doOnlineAPISearch(workspaceId, mainTaskId, apiName, t, &req.OrgId, req.IsIPLocation, req.IsHttpx, req.IsFingerprintHub, req.IsScreenshot, req.IsIconHash, req.IsIgnoreCDN, req.IsIgnoreOutofChina); err != nil {
 					logging.RuntimeLog.Error(err)
 					return
 				}
@@ -107,57 +95,88 @@ func StartDomainScanTask(req DomainscanRequestParam, mainTaskId string, workspac
 	for _, t := range targets {
 		// 每个获取子域名的方式采用独立任务，以提高速度
 		var taskStarted bool
+		// DNS记录枚举及检查只在第一个子任务中执行，避免同一根域名重复查询
+		isDNSRecord := req.IsDNSRecord
 		if req.IsSubfinder {
 			subConfig := req
 			subConfig.IsSubdomainBrute = false
 			subConfig.IsCrawler = false
+			subConfig.IsSubdomainPassive = false
+			subConfig.IsSubdomainAlter = false
+			subConfig.IsDNSRecord = isDNSRecord
 			if taskId, err = doDomainscan(workspaceId, mainTaskId, t, subConfig, "subfinder"); err != nil {
 				logging.RuntimeLog.Error(err)
 				return
 			}
 			taskStarted = true
+			isDNSRecord = false
 		}
 		if req.IsSubdomainBrute {
 			subConfig := req
 			subConfig.IsSubfinder = false
 			subConfig.IsCrawler = false
+			subConfig.IsSubdomainPassive = false
+			subConfig.IsSubdomainAlter = false
+			subConfig.IsDNSRecord = isDNSRecord
 			if taskId, err = doDomainscan(workspaceId, mainTaskId, t, subConfig, "subdomainbrute"); err != nil {
 				logging.RuntimeLog.Error(err)
 				return
 			}
 			taskStarted = true
+			isDNSRecord = false
 		}
 		if req.IsCrawler {
 			subConfig := req
 			subConfig.IsSubfinder = false
 			subConfig.IsSubdomainBrute = false
+			subConfig.IsSubdomainPassive = false
+			subConfig.IsSubdomainAlter = false
+			subConfig.IsDNSRecord = isDNSRecord
 			if taskId, err = doDomainscan(workspaceId, mainTaskId, t, subConfig, "subdomaincrawler"); err != nil {
 				logging.RuntimeLog.Error(err)
 				return
 			}
 			taskStarted = true
+			isDNSRecord = false
 		}
-		// 如果没有子域名任务，则至少启动一个域名解析任务
-		if !taskStarted {
-			if taskId, err = doDomainscan(workspaceId, mainTaskId, t, req, "domainscan"); err != nil {
+		if req.IsSubdomainPassive {
+			subConfig := req
+			subConfig.IsSubfinder = false
+			subConfig.IsSubdomainBrute = false
+			subConfig.IsCrawler = false
+			subConfig.IsSubdomainAlter = false
+			subConfig.IsDNSRecord = isDNSRecord
+			if taskId, err = doDomainscan(workspaceId, mainTaskId, t, subConfig, "subdomainpassive"); err != nil {
 				logging.RuntimeLog.Error(err)
 				return
 			}
+			taskStarted = true
+			isDNSRecord = false
 		}
-		if req.IsFofa {
-			if taskId, err = doOnlineAPISearch(workspaceId, mainTaskId, "fofa", t, &req.OrgId, true, req.IsHttpx, req.IsFingerprintHub, req.IsScreenshot, req.IsIconHash, req.IsIgnoreCDN, req.IsIgnoreOutofChina); err != nil {
+		// 子域名变换：使用subdomainbrute任务的变换模式
+		if req.IsSubdomainAlter {
+			subConfig := req
+			subConfig.IsSubfinder = false
+			subConfig.IsSubdomainBrute = true
+			subConfig.IsCrawler = false
+			subConfig.IsSubdomainPassive = false
+			subConfig.IsDNSRecord = isDNSRecord
+			if taskId, err = doDomainscan(workspaceId, mainTaskId, t, subConfig, "subdomainbrute"); err != nil {
 				logging.RuntimeLog.Error(err)
 				return
 			}
+			taskStarted = true
+			isDNSRecord = false
 		}
-		if req.IsQuake {
-			if taskId, err = doOnlineAPISearch(workspaceId, mainTaskId, "quake", t, &req.OrgId, true, req.IsHttpx, req.IsFingerprintHub, req.IsScreenshot, req.IsIconHash, req.IsIgnoreCDN, req.IsIgnoreOutofChina); err != nil {
+		// 如果没有子域名任务，则至少启动一个域名解析任务
+		if !taskStarted {
+			if taskId, err = doDomainscan(workspaceId, mainTaskId, t, req, "domainscan"); err != nil {
 				logging.RuntimeLog.Error(err)
 				return
 			}
 		}
-		if req.IsHunter {
-			if taskId, err = doOnlineAPISearch(workspaceId, mainTaskId, "hunter", t, &req.OrgId, true, req.IsHttpx, req.IsFingerprintHub, req.IsScreenshot, req.IsIconHash, req.IsIgnoreCDN, req.IsIgnoreOutofChina); err != nil {
+		for _, apiName := range req.OnlineAPIs() {
+			if taskId, err = doOnlineAPISearch(workspaceId, mainTaskId, apiName, t, &req.OrgId, true, req.IsHttpx, req.IsFingerprintHub, req.IsScreenshot, req.IsIconHash, req.IsIgnoreCDN, req.IsIgnoreOutofChina); err != nil {
 				logging.RuntimeLog.Error(err)
 				return
 			}
@@ -195,17 +214,19 @@ func StartPocScanTask(req PocscanRequestParam, mainTaskId string, workspaceId in
 			return
 		}
 	}
-	if req.IsNucleiVerify && req.NucleiPocFile != "" {
-		config := pocscan.Config{Target: strings.Join(targetList, ","), PocFile: req.NucleiPocFile, CmdBin: "nuclei", IsLoadOpenedPort: req.IsLoadOpenedPort, WorkspaceId: workspaceId}
-		configJSON, _ := json.Marshal(config)
-		taskId, err = serverapi.NewRunTask("nuclei", string(configJSON), mainTaskId, "")
-		if err != nil {
+	if req.IsNucleiVerify {
+		if taskId, err = startNucleiTask(req, targetList, mainTaskId, workspaceId); err != nil {
 			logging.RuntimeLog.Error(err)
 			return
 		}
 	}
 	if req.IsDirsearch && req.DirsearchExtName != "" {
 		config := pocscan.Config{Target: strings.Join(targetList, ","), PocFile: req.DirsearchExtName, CmdBin: "dirsearch", IsLoadOpenedPort: req.IsLoadOpenedPort, WorkspaceId: workspaceId}
+		for _, header := range strings.Split(req.DirsearchHeader, "\n") {
+			if header = strings.TrimSpace(header); header != "" {
+				config.Headers = append(config.Headers, header)
+			}
+		}
 		configJSON, _ := json.Marshal(config)
 		taskId, err = serverapi.NewRunTask("dirsearch", string(configJSON), mainTaskId, "")
 		if err != nil {
@@ -222,9 +243,115 @@ func StartPocScanTask(req PocscanRequestParam, mainTaskId string, workspaceId in
 			return
 		}
 	}
+	if req.IsXrayPocV1 {
+		config := pocscan.Config{Target: strings.Join(targetList, ","), PocFile: req.XrayPocV1File, CmdBin: "xraypocv1", IsLoadOpenedPort: req.IsLoadOpenedPort, WorkspaceId: workspaceId}
+		configJSON, _ := json.Marshal(config)
+		taskId, err = serverapi.NewRunTask("xraypocv1", string(configJSON), mainTaskId, "")
+		if err != nil {
+			logging.RuntimeLog.Error(err)
+			return
+		}
+	}
+	if req.IsWeakPass {
+		config := pocscan.Config{Target: strings.Join(targetList, ","), CmdBin: "weakpass", WorkspaceId: workspaceId}
+		configJSON, _ := json.Marshal(config)
+		taskId, err = serverapi.NewRunTask("weakpass", string(configJSON), mainTaskId, "")
+		if err != nil {
+			logging.RuntimeLog.Error(err)
+			return
+		}
+	}
 	return taskId, nil
 }
 
+// startNucleiTask nuclei任务：通过模板文件、工作流、标签及严重程度选择模板；
+// 自动选择时根据目标已有的指纹匹配模板的技术标签，匹配到相同标签的目标合并为一个任务
+func startNucleiTask(req PocscanRequestParam, targetList []string, mainTaskId string, workspaceId int) (taskId string, err error) {
+	isManualSelect := req.NucleiPocFile != "" || req.NucleiWorkflow != "" || utils.MergeCommaString(req.NucleiTags, req.NucleiSeverity) != ""
+	targetGroup := make(map[string][]string)
+	if req.IsNucleiAutoTags {
+		index := pocscan.GetNucleiTemplateIndex()
+		for _, t := range targetList {
+			tags := index.MatchTechnology(getTargetFingerprint(t, workspaceId))
+			if len(tags) == 0 && !isManualSelect {
+				logging.RuntimeLog.Infof("no nuclei template matched for %s,skip...", t)
+				continue
+			}
+			key := strings.Join(tags, ",")
+			targetGroup[key] = append(targetGroup[key], t)
+		}
+	} else if isManualSelect {
+		targetGroup[""] = targetList
+	}
+	var tagsList []string
+	for tags := range targetGroup {
+		tagsList = append(tagsList, tags)
+	}
+	sort.Strings(tagsList)
+	for _, tags := range tagsList {
+		config := pocscan.Config{
+			Target:           strings.Join(targetGroup[tags], ","),
+			PocFile:          req.NucleiPocFile,
+			CmdBin:           "nuclei",
+			IsLoadOpenedPort: req.IsLoadOpenedPort,
+			WorkspaceId:      workspaceId,
+			Tags:             splitCommaString(utils.MergeCommaString(req.NucleiTags, tags)),
+			Severity:         splitCommaString(utils.MergeCommaString(req.NucleiSeverity)),
+			Workflow:         req.NucleiWorkflow,
+		}
+		configJSON, _ := json.Marshal(config)
+		taskId, err = serverapi.NewRunTask("nuclei", string(configJSON), mainTaskId, "")
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// getTargetFingerprint 获取目标（IP、IP:端口、域名或URL）已保存的指纹、server及httpx识别的technologies
+func getTargetFingerprint(target string, workspaceId int) (fingerprints []string) {
+	host := utils.ParseHost(target)
+	// 未指定端口时获取所有端口的指纹
+	var port int
+	if strings.Contains(target, "://") || strings.Count(target, ":") == 1 {
+		_, port = utils.ParseHostPort(target)
+	}
+	if utils.CheckIP(host) {
+		ip := db.Ip{IpName: host, WorkspaceId: workspaceId}
+		if !ip.GetByIp() {
+			return
+		}
+		p := db.Port{IpId: ip.Id}
+		for _, portRow := range p.GetsByIPId() {
+			if port > 0 && portRow.PortNum != port {
+				continue
+			}
+			pa := db.PortAttr{RelatedId: portRow.Id}
+			for _, attr := range pa.GetsByRelatedId() {
+				fingerprints = append(fingerprints, pocscan.ParseFingerprintAttr(attr.Tag, attr.Content)...)
+			}
+		}
+	} else {
+		domain := db.Domain{DomainName: host, WorkspaceId: workspaceId}
+		if !domain.GetByDomain() {
+			return
+		}
+		da := db.DomainAttr{RelatedId: domain.Id}
+		for _, attr := range da.GetsByRelatedId() {
+			fingerprints = append(fingerprints, pocscan.ParseFingerprintAttr(attr.Tag, attr.Content)...)
+		}
+	}
+	return
+}
+
+// splitCommaString 将以逗号分隔的字符串转换为列表
+func splitCommaString(s string) (list []string) {
+	if s != "" {
+		list = strings.Split(s, ",")
+	}
+	return
+}
+
 // StartXOnlineAPIKeywordTask xscan任务，根据API的语法查询资产
 func StartXOnlineAPIKeywordTask(req XScanRequestParam, mainTaskId string, workspaceId int) (taskId string, err error) {
 	config := workerapi.XScanConfig{
@@ -235,6 +362,9 @@ func StartXOnlineAPIKeywordTask(req XScanRequestParam, mainTaskId string, worksp
 		IsNucleiPoc:   req.IsNucleiPocscan,
 		NucleiPocFile: req.NucleiPocFile,
 		IsGobyPoc:     req.IsGobyPocscan,
+		IsXrayPocV1:   req.IsXrayPocV1,
+		XrayPocV1File: req.XrayPocV1File,
+		IsPocMap:      req.IsPocMap,
 		WorkspaceId:   workspaceId,
 	}
 	// config.OrgId 为int，默认为0
@@ -244,13 +374,8 @@ func StartXOnlineAPIKeywordTask(req XScanRequestParam, mainTaskId string, worksp
 	}
 	// 生成查询语法
 	//keywords := makeSearchTaskConfig(req)
-	switch req.OnlineAPIEngine {
-	case "xfofa":
-		config.IsFofa = true
-	case "xhunter":
-		config.IsHunter = true
-	case "xquake":
-		config.IsQuake = true
+	if !config.SetOnlineAPI(req.OnlineAPIEngine) {
+		return "", errors.New(fmt.Sprintf("invalid onlineapi engine:%s", req.OnlineAPIEngine))
 	}
 	config.OnlineAPIKeyword = req.Target
 	config.OnlineAPISearchLimit = conf.GlobalWorkerConfig().API.SearchLimitCount
@@ -274,6 +399,9 @@ func StartXOnlineAPIKeywordCustomTask(req XScanRequestParam, mainTaskId string,
 		IsNucleiPoc:   req.IsNucleiPocscan,
 		NucleiPocFile: req.NucleiPocFile,
 		IsGobyPoc:     req.IsGobyPocscan,
+		IsXrayPocV1:   req.IsXrayPocV1,
+		XrayPocV1File: req.XrayPocV1File,
+		IsPocMap:      req.IsPocMap,
 		WorkspaceId:   workspaceId,
 	}
 	// config.OrgId 为int，默认为0
@@ -284,24 +412,10 @@ func StartXOnlineAPIKeywordCustomTask(req XScanRequestParam, mainTaskId string,
 	configTaskRuns := makeSearchTaskConfig(config)
 	for _, configRun := range configTaskRuns {
 		configJSONRun, _ := json.Marshal(configRun)
-		if configRun.IsFofa {
-			taskId, err = serverapi.NewRunTask("xfofa", string(configJSONRun), mainTaskId, "")
-			if err != nil {
-				logging.RuntimeLog.Errorf("start xfofa task fail:%s", err.Error())
-				return "", err
-			}
-		}
-		if configRun.IsHunter {
-			taskId, err = serverapi.NewRunTask("xhunter", string(configJSONRun), mainTaskId, "")
-			if err != nil {
-				logging.RuntimeLog.Errorf("start xhunter task fail:%s", err.Error())
-				return "", err
-			}
-		}
-		if configRun.IsQuake {
-			taskId, err = serverapi.NewRunTask("xquake", string(configJSONRun), mainTaskId, "")
+		for _, apiName := range configRun.OnlineAPIs() {
+			taskId, err = serverapi.NewRunTask("x"+apiName, string(configJSONRun), mainTaskId, "")
 			if err != nil {
-				logging.RuntimeLog.Errorf("start xquake task fail:%s", err.Error())
+				logging.RuntimeLog.Errorf("start x%s task fail:%s", apiName, err.Error())
 				return "", err
 			}
 		}
@@ -320,6 +434,9 @@ func StartXDomainScanTask(req XScanRequestParam, mainTaskId string, workspaceId
 		IsNucleiPoc:   req.IsNucleiPocscan,
 		NucleiPocFile: req.NucleiPocFile,
 		IsGobyPoc:     req.IsGobyPocscan,
+		IsXrayPocV1:   req.IsXrayPocV1,
+		XrayPocV1File: req.XrayPocV1File,
+		IsPocMap:      req.IsPocMap,
 		//
 		WorkspaceId: workspaceId,
 	}
@@ -385,36 +502,17 @@ func StartXDomainScanTask(req XScanRequestParam, mainTaskId string, workspaceId
 				return "", err
 			}
 		}
-		// 是否进行在线资产平台的查询（包括fofa、quake、hunter）：
+		// 是否进行在线资产平台的查询（配置文件中启用的在线资产搜索引擎）：
 		if req.IsOnlineAPI {
-			if conf.GlobalWorkerConfig().OnlineAPI.IsFofa {
-				configRun := config
-				configRun.OnlineAPITarget = target
-				configRun.IsFofa = true
-				configJSONRun, _ := json.Marshal(configRun)
-				taskId, err = serverapi.NewRunTask("xfofa", string(configJSONRun), mainTaskId, "")
-				if err != nil {
-					logging.RuntimeLog.Errorf("start xonlineapi fail:%s", err.Error())
-					return "", err
-				}
-			}
-			if conf.GlobalWorkerConfig().OnlineAPI.IsHunter {
-				configRun := config
-				configRun.OnlineAPITarget = target
-				configRun.IsHunter = true
-				configJSONRun, _ := json.Marshal(configRun)
-				taskId, err = serverapi.NewRunTask("xhunter", string(configJSONRun), mainTaskId, "")
-				if err != nil {
-					logging.RuntimeLog.Errorf("start xonlineapi fail:%s", err.Error())
-					return "", err
+			for _, apiName := range onlineapi.SearchEngines {
+				if !conf.GlobalWorkerConfig().OnlineAPI.IsEnabled(apiName) {
+					continue
 				}
-			}
-			if conf.GlobalWorkerConfig().OnlineAPI.IsQuake {
 				configRun := config
 				configRun.OnlineAPITarget = target
-				configRun.IsQuake = true
+				configRun.SetOnlineAPI(apiName)
 				configJSONRun, _ := json.Marshal(configRun)
-				taskId, err = serverapi.NewRunTask("xquake", string(configJSONRun), mainTaskId, "")
+				taskId, err = serverapi.NewRunTask("x"+apiName, string(configJSONRun), mainTaskId, "")
 				if err != nil {
 					logging.RuntimeLog.Errorf("start xonlineapi fail:%s", err.Error())
 					return "", err
@@ -436,6 +534,9 @@ func StartXPortScanTask(req XScanRequestParam, mainTaskId string, workspaceId in
 		XrayPocFile:   req.XrayPocFile,
 		IsNucleiPoc:   req.IsNucleiPocscan,
 		IsGobyPoc:     req.IsGobyPocscan,
+		IsXrayPocV1:   req.IsXrayPocV1,
+		XrayPocV1File: req.XrayPocV1File,
+		IsPocMap:      req.IsPocMap,
 		NucleiPocFile: req.NucleiPocFile,
 		WorkspaceId:   workspaceId,
 	}
@@ -462,36 +563,17 @@ func StartXPortScanTask(req XScanRequestParam, mainTaskId string, workspaceId in
 			logging.RuntimeLog.Errorf("start xportscan fail:%s", err.Error())
 			return "", err
 		}
-		// 是否进行在线资产平台的查询（包括fofa、quake、hunter）：
+		// 是否进行在线资产平台的查询（配置文件中启用的在线资产搜索引擎）：
 		if req.IsOnlineAPI {
-			if conf.GlobalWorkerConfig().OnlineAPI.IsFofa {
-				configRunAPI := config
-				configRunAPI.OnlineAPITarget = target
-				configRunAPI.IsFofa = true
-				configJSONRun, _ := json.Marshal(configRunAPI)
-				taskId, err = serverapi.NewRunTask("xfofa", string(configJSONRun), mainTaskId, "")
-				if err != nil {
-					logging.RuntimeLog.Errorf("start xonlineapi fail:%s", err.Error())
-					return "", err
-				}
-			}
-			if conf.GlobalWorkerConfig().OnlineAPI.IsHunter {
-				configRunAPI := config
-				configRunAPI.OnlineAPITarget = target
-				configRunAPI.IsHunter = true
-				configJSONRun, _ := json.Marshal(configRunAPI)
-				taskId, err = serverapi.NewRunTask("xhunter", string(configJSONRun), mainTaskId, "")
-				if err != nil {
-					logging.RuntimeLog.Errorf("start xonlineapi fail:%s", err.Error())
-					return "", err
+			for _, apiName := range onlineapi.SearchEngines {
+				if !conf.GlobalWorkerConfig().OnlineAPI.IsEnabled(apiName) {
+					continue
 				}
-			}
-			if conf.GlobalWorkerConfig().OnlineAPI.IsQuake {
 				configRunAPI := config
 				configRunAPI.OnlineAPITarget = target
-				configRunAPI.IsQuake = true
+				configRunAPI.SetOnlineAPI(apiName)
 				configJSONRun, _ := json.Marshal(configRunAPI)
-				taskId, err = serverapi.NewRunTask("xquake", string(configJSONRun), mainTaskId, "")
+				taskId, err = serverapi.NewRunTask("x"+apiName, string(configJSONRun), mainTaskId, "")
 				if err != nil {
 					logging.RuntimeLog.Errorf("start xonlineapi fail:%s", err.Error())
 					return "", err
@@ -516,6 +598,9 @@ func StartXOrgScanTask(req XScanRequestParam, mainTaskId string, workspaceId int
 		IsNucleiPoc:   req.IsNucleiPocscan,
 		NucleiPocFile: req.NucleiPocFile,
 		IsGobyPoc:     req.IsGobyPocscan,
+		IsXrayPocV1:   req.IsXrayPocV1,
+		XrayPocV1File: req.XrayPocV1File,
+		IsPocMap:      req.IsPocMap,
 		//
 		WorkspaceId: workspaceId,
 	}
@@ -634,6 +719,8 @@ func doDomainscan(workspaceId int, mainTaskId string, target string, req Domains
 		IsSubDomainFinder:  req.IsSubfinder,
 		IsSubDomainBrute:   req.IsSubdomainBrute,
 		IsCrawler:          req.IsCrawler,
+		IsSubDomainPassive: req.IsSubdomainPassive,
+		IsDNSRecord:        req.IsDNSRecord,
 		IsHttpx:            req.IsHttpx,
 		IsIPPortScan:       req.IsIPPortscan,
 		IsIPSubnetPortScan: req.IsSubnetPortscan,
@@ -643,6 +730,9 @@ func doDomainscan(workspaceId int, mainTaskId string, target string, req Domains
 		PortTaskMode:       req.PortTaskMode,
 		WorkspaceId:        workspaceId,
 	}
+	if req.IsSubdomainAlter {
+		config.SubDomainBruteMode = domainscan.BruteModeAlteration
+	}
 	// config.OrgId 为int，默认为0
 	// db.Organization.OrgId为指针，默认nil
 	if *config.OrgId == 0 {
@@ -661,7 +751,7 @@ func doDomainscan(workspaceId int, mainTaskId string, target string, req Domains
 	return taskId, nil
 }
 
-// doOnlineAPISearch Fofa,hunter,quaker的查询
+// doOnlineAPISearch 在线资产搜索引擎的查询
 func doOnlineAPISearch(workspaceId int, mainTaskId string, apiName string, target string, orgId *int, isIplocation, isHttp, isFingerprintHub, isScreenshot, isIconHash, isIgnoreCDN, isIgnorOutofChina bool) (taskId string, err error) {
 	config := onlineapi.OnlineAPIConfig{
 		Target:             target,
@@ -953,17 +1043,11 @@ func makeSearchTaskConfig(config workerapi.XScanConfig) (configs []workerapi.XSc
 		// 根据API生成任务
 		engines := strings.Split(row.Engine, ",")
 		for _, api := range engines {
-			var engineInterface onlineapi.Engine
 			configRun := config
-			if api == "xfofa" {
-				configRun.IsFofa = true
-				engineInterface = new(onlineapi.FOFA)
-			} else if api == "xhunter" {
-				configRun.IsHunter = true
-				engineInterface = new(onlineapi.Hunter)
-			} else if api == "xquake" {
-				configRun.IsQuake = true
-				engineInterface = new(onlineapi.Quake)
+			engineInterface := onlineapi.NewEngine(strings.TrimPrefix(api, "x"))
+			if engineInterface == nil || !configRun.SetOnlineAPI(api) {
+				logging.RuntimeLog.Warningf("invalid onlineapi engine:%s", api)
+				continue
 			}
 			configRun.OnlineAPIKeyword = makeSearchKeyword(engineInterface, api, row.CheckMod, row.KeyWord, row.ExcludeWords, row.SearchTime)
 			configRun.OnlineAPISearchLimit = row.Count
@@ -1,6 +1,8 @@
 package serverapi
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/RichardKnop/machinery/v2/tasks"
@@ -8,11 +10,22 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/db"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/ampq"
+	"github.com/hanc00l/nemo_go/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"time"
 )
 
-// NewRunTask 创建一个新执行任务
+// SelectWorkerTopic 根据任务要求选择worker的专属队列，由server在启动时设置；返回空时使用任务的原始队列
+var SelectWorkerTopic func(topicName string, requirement ampq.TaskRequirement) string
+
+// NewRunTask 创建一个新执行任务，使用maintask的trace上下文
 func NewRunTask(taskName, configJSON, mainTaskId, lastRunTaskId string) (taskId string, err error) {
+	return NewRunTaskWithContext(tracing.TaskContext(mainTaskId), taskName, configJSON, mainTaskId, lastRunTaskId)
+}
+
+// NewRunTaskWithContext 创建一个新执行任务，trace上下文通过任务的消息头传递到worker
+func NewRunTaskWithContext(ctx context.Context, taskName, configJSON, mainTaskId, lastRunTaskId string) (taskId string, err error) {
 	dbMTask := db.TaskMain{TaskId: mainTaskId}
 	if dbMTask.GetByTaskId() == false {
 		msg := fmt.Sprintf("maintask %s not exist", mainTaskId)
@@ -31,10 +44,24 @@ func NewRunTask(taskName, configJSON, mainTaskId, lastRunTaskId string) (taskId
 		logging.RuntimeLog.Error(msg)
 		return "", errors.New(msg)
 	}
-	server := ampq.GetServerTaskAMPQServer(topicName)
+	// 任务对worker有要求时，优先分配到满足要求的worker的专属队列
+	requirement := ampq.GetTaskRequirement(taskName, configJSON, getMainTaskWorkerLabels(dbMTask.KwArgs))
+	routeTopicName := topicName
+	if SelectWorkerTopic != nil && !requirement.IsEmpty() {
+		if workerTopic := SelectWorkerTopic(topicName, requirement); workerTopic != "" {
+			routeTopicName = workerTopic
+		}
+	}
+	server := ampq.GetServerTaskAMPQServer(routeTopicName)
 	// 延迟5秒后执行：如果不延迟，有可能任务在完成数据库之前执行，从而导致task not exist错误
 	eta := time.Now().Add(time.Second * 5)
 	taskId = uuid.New().String()
+	ctx, span := tracing.StartWithKind(ctx, "send."+taskName, trace.SpanKindProducer,
+		attribute.String("task_id", taskId), attribute.String("main_task_id", mainTaskId), attribute.String("topic", routeTopicName))
+	defer func() { tracing.End(span, err) }()
+	headers := tracing.HeadersCarrier{}
+	tracing.Inject(ctx, headers)
+	ampq.SetTaskRequirementHeaders(tasks.Headers(headers), requirement, topicName)
 	workerTask := tasks.Signature{
 		Name: taskName,
 		UUID: taskId,
@@ -45,7 +72,8 @@ func NewRunTask(taskName, configJSON, mainTaskId, lastRunTaskId string) (taskId
 			{Name: "configJSON", Type: "string", Value: configJSON},
 		},
 		//RoutingKey：分发到不同功能的worker队列
-		RoutingKey: ampq.GetRoutingKeyByTopic(topicName),
+		RoutingKey: ampq.GetRoutingKeyByTopic(routeTopicName),
+		Headers:    tasks.Headers(headers),
 	}
 	_, err = server.SendTask(&workerTask)
 	if err != nil {
@@ -57,6 +85,15 @@ func NewRunTask(taskName, configJSON, mainTaskId, lastRunTaskId string) (taskId
 	return taskId, nil
 }
 
+// getMainTaskWorkerLabels 获取主任务参数中指定的worker标签
+func getMainTaskWorkerLabels(kwArgs string) string {
+	var args struct {
+		WorkerLabels string
+	}
+	json.Unmarshal([]byte(kwArgs), &args)
+	return args.WorkerLabels
+}
+
 // RevokeUnexcusedTask 取消一个未开始执行的任务
 func RevokeUnexcusedTask(taskId string) (isRevoked bool, err error) {
 	task := &db.TaskRun{TaskId: taskId}
@@ -64,8 +101,8 @@ func RevokeUnexcusedTask(taskId string) (isRevoked bool, err error) {
 		logging.RuntimeLog.Warningf("task not exists when revoked:%s", taskId)
 		return false, errors.New("task not exists")
 	}
-	//检查状态，只有CREATED状态的才能取消
-	if task.State == ampq.CREATED {
+	//检查状态，只有CREATED及被worker拒绝执行（RETRY）状态的才能取消
+	if task.State == ampq.CREATED || task.State == ampq.RETRY {
 		updateRevokedTask(taskId)
 		logging.RuntimeLog.Infof("task revoked:%s", taskId)
 		return true, nil
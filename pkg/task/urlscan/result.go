@@ -0,0 +1,129 @@
+package urlscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/db"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// urlMaxLength 超过数据库字段长度的URL不保存
+	urlMaxLength = 1000
+)
+
+const (
+	SourceCrawler   = "crawler"
+	SourceHttpx     = "httpx"
+	SourceDirsearch = "dirsearch"
+)
+
+// UrlResult 发现的一条URL（endpoint）
+type UrlResult struct {
+	Url         string `json:"url"`
+	Method      string `json:"method"`
+	PostData    string `json:"postData,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Status      int    `json:"status,omitempty"`
+	Source      string `json:"source"`
+	WorkspaceId int    `json:"workspaceId"`
+}
+
+// ToUrl 将结果规范化为URL资产：去除query与fragment，参数只保留参数名
+func (r UrlResult) ToUrl() (u db.Url, ok bool) {
+	p, err := url.Parse(strings.TrimSpace(r.Url))
+	if err != nil || p.Hostname() == "" || len(r.Url) > urlMaxLength {
+		return
+	}
+	scheme := strings.ToLower(p.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return
+	}
+	port, _ := strconv.Atoi(p.Port())
+	if port == 0 {
+		if scheme == "https" {
+			port = 443
+		} else {
+			port = 80
+		}
+	}
+	path := p.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	method := strings.ToUpper(r.Method)
+	if method == "" {
+		method = "GET"
+	}
+	u = db.Url{
+		Url:         fmt.Sprintf("%s://%s%s", scheme, strings.ToLower(p.Host), path),
+		Host:        strings.ToLower(p.Hostname()),
+		Port:        port,
+		Method:      method,
+		Path:        path,
+		Params:      strings.Join(parseParams(p.RawQuery, r.PostData, r.ContentType), ","),
+		ContentType: r.ContentType,
+		Status:      r.Status,
+		Source:      r.Source,
+		WorkspaceId: r.WorkspaceId,
+	}
+	return u, true
+}
+
+// parseParams 获取query及POST数据（表单或JSON）中的参数名
+func parseParams(query, postData, contentType string) (params []string) {
+	paramSet := make(map[string]struct{})
+	if values, err := url.ParseQuery(query); err == nil {
+		for k := range values {
+			paramSet[k] = struct{}{}
+		}
+	}
+	postData = strings.TrimSpace(postData)
+	if strings.Contains(contentType, "json") || strings.HasPrefix(postData, "{") {
+		var values map[string]interface{}
+		if err := json.Unmarshal([]byte(postData), &values); err == nil {
+			for k := range values {
+				paramSet[k] = struct{}{}
+			}
+		}
+	} else if postData != "" && !strings.Contains(contentType, "multipart") {
+		if values, err := url.ParseQuery(postData); err == nil {
+			for k := range values {
+				paramSet[k] = struct{}{}
+			}
+		}
+	}
+	for k := range paramSet {
+		if k != "" {
+			params = append(params, k)
+		}
+	}
+	sort.Strings(params)
+	return
+}
+
+// SaveResult 保存URL资产结果
+func SaveResult(result []UrlResult) string {
+	var resultCount, newUrl int
+	for _, r := range result {
+		u, ok := r.ToUrl()
+		if !ok || u.WorkspaceId <= 0 {
+			continue
+		}
+		if success, isNew := u.SaveOrUpdate(); success {
+			resultCount++
+			if isNew {
+				newUrl++
+			}
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("url:%d", resultCount))
+	if newUrl > 0 {
+		sb.WriteString(fmt.Sprintf(",urlNew:%d", newUrl))
+	}
+	return sb.String()
+}
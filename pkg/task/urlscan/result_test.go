@@ -0,0 +1,37 @@
+package urlscan
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUrlResult_ToUrl(t *testing.T) {
+	u, ok := UrlResult{
+		Url:         "HTTP://WWW.Example.com/api/login?redirect=/&id=1#top",
+		Method:      "post",
+		PostData:    "username=admin&password=123",
+		ContentType: "application/x-www-form-urlencoded",
+		Source:      SourceCrawler,
+		WorkspaceId: 1,
+	}.ToUrl()
+	if !ok {
+		t.Fatal("parse url fail")
+	}
+	if u.Url != "http://www.example.com/api/login" || u.Host != "www.example.com" || u.Port != 80 || u.Path != "/api/login" || u.Method != "POST" {
+		t.Errorf("invalid url:%+v", u)
+	}
+	if u.Params != "id,password,redirect,username" {
+		t.Errorf("invalid params:%s", u.Params)
+	}
+
+	u, ok = UrlResult{Url: "https://127.0.0.1:8443", PostData: `{"name":"nemo","page":1}`, Source: SourceHttpx}.ToUrl()
+	if !ok || u.Url != "https://127.0.0.1:8443/" || u.Port != 8443 || u.Method != "GET" || u.Params != "name,page" {
+		t.Errorf("invalid url:%+v", u)
+	}
+
+	for _, invalid := range []string{"", "ftp://127.0.0.1/", "127.0.0.1:80", "http://" + strings.Repeat("a", urlMaxLength)} {
+		if _, ok = (UrlResult{Url: invalid}).ToUrl(); ok {
+			t.Errorf("should be invalid:%s", invalid)
+		}
+	}
+}
@@ -1,14 +1,20 @@
 package workerapi
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/RichardKnop/machinery/v2/log"
 	"github.com/hanc00l/nemo_go/pkg/comm"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
 	"github.com/hanc00l/nemo_go/pkg/task/ampq"
+	"github.com/hanc00l/nemo_go/pkg/tracing"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"sync"
 	"time"
 
 	"github.com/RichardKnop/machinery/v2/backends/result"
@@ -17,6 +23,12 @@ import (
 
 var WStatus ampq.WorkerStatus
 
+// taskStartTime 任务开始执行的时间，用于统计任务的执行时长
+var taskStartTime sync.Map
+
+// taskSpan 任务执行的span，在任务完成时结束
+var taskSpan sync.Map
+
 // taskMaps 定义work执行的任务；在添加了对应的任务后，在ampq/api.go中指定任务对应的队列映射：taskTopicDefineMap
 var taskMaps = map[string]interface{}{
 	"portscan":          PortScan,
@@ -25,14 +37,22 @@ var taskMaps = map[string]interface{}{
 	"subfinder":         DomainScan,
 	"subdomainbrute":    DomainScan,
 	"subdomaincrawler":  DomainScan,
+	"subdomainpassive":  DomainScan,
 	"iplocation":        IPLocation,
 	"fofa":              Fofa,
 	"quake":             Quake,
 	"hunter":            Hunter,
+	"shodan":            Shodan,
+	"censys":            Censys,
+	"zoomeye":           ZoomEye,
+	"netlas":            Netlas,
+	"binaryedge":        BinaryEdge,
 	"xray":              PocScan,
 	"dirsearch":         PocScan,
 	"nuclei":            PocScan,
 	"goby":              PocScan,
+	"xraypocv1":         PocScan,
+	"weakpass":          PocScan,
 	"icpquery":          ICPQuery,
 	"whoisquery":        WhoisQuery,
 	"fingerprint":       Fingerprint,
@@ -41,6 +61,11 @@ var taskMaps = map[string]interface{}{
 	"xfofa":             XOnlineAPI,
 	"xquake":            XOnlineAPI,
 	"xhunter":           XOnlineAPI,
+	"xshodan":           XOnlineAPI,
+	"xcensys":           XOnlineAPI,
+	"xzoomeye":          XOnlineAPI,
+	"xnetlas":           XOnlineAPI,
+	"xbinaryedge":       XOnlineAPI,
 	"xdomainscan":       XDomainscan,
 	"xsubfinder":        XDomainscan,
 	"xsubdomainbrute":   XDomainscan,
@@ -49,6 +74,7 @@ var taskMaps = map[string]interface{}{
 	"xxray":             XXray,
 	"xnuclei":           XNuclei,
 	"xgoby":             XGoby,
+	"xxraypocv1":        XXrayPocV1,
 	"xorgscan":          XOrganization,
 	//test:
 	"test": TaskTest,
@@ -87,7 +113,7 @@ func ParseConfig(configJSON string, config interface{}) (err error) {
 // StartWorker 启动worker
 func StartWorker(topicName string, concurrency int) error {
 	server := ampq.GetWorkerAMPQServer(topicName, concurrency)
-	err := server.RegisterTasks(taskMaps)
+	err := server.RegisterTasks(getRegisteredTasks())
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 		return err
@@ -109,10 +135,19 @@ func StartWorker(topicName string, concurrency int) error {
 
 // postTaskHandler 任务完成时处理工作
 func postTaskHandler(signature *tasks.Signature) {
+	var state string
+	defer func() { endTaskSpan(signature.UUID, state) }()
 	//更新任务执行state和worker
 	WStatus.Lock()
 	WStatus.TaskStartedNumber--
 	WStatus.Unlock()
+	//worker拒绝执行的任务：任务已退回到队列，等待其它worker执行
+	if reason, ok := rejectedTask.LoadAndDelete(signature.UUID); ok {
+		state = ampq.RETRY
+		observeTaskFinished(signature, state)
+		UpdateTaskStatus(signature.UUID, state, WStatus.WorkerName, RejectedTask(reason.(string)))
+		return
+	}
 
 	//log.INFO.Println("I am an end of task handler for:", signature.Name)
 	server := ampq.GetWorkerAMPQServer(ampq.GetTopicByMQRoutingKey(signature.RoutingKey), 3)
@@ -123,11 +158,53 @@ func postTaskHandler(signature *tasks.Signature) {
 	//检查REVOKED的任务
 	if err := json.Unmarshal([]byte(tasks.HumanReadableResults(rr)), &tr); err == nil {
 		if tr.Status == ampq.REVOKED {
-			UpdateTaskStatus(signature.UUID, ampq.REVOKED, WStatus.WorkerName, tasks.HumanReadableResults(rr))
+			state = ampq.REVOKED
+			observeTaskFinished(signature, state)
+			UpdateTaskStatus(signature.UUID, state, WStatus.WorkerName, tasks.HumanReadableResults(rr))
 			return
 		}
 	}
-	UpdateTaskStatus(signature.UUID, r.GetState().State, WStatus.WorkerName, tasks.HumanReadableResults(rr))
+	state = r.GetState().State
+	observeTaskFinished(signature, state)
+	UpdateTaskStatus(signature.UUID, state, WStatus.WorkerName, tasks.HumanReadableResults(rr))
+}
+
+// observeTaskFinished 记录任务完成的状态及执行时长
+func observeTaskFinished(signature *tasks.Signature, state string) {
+	metrics.TaskStateTotal.WithLabelValues(signature.Name, state).Inc()
+	if startTime, ok := taskStartTime.LoadAndDelete(signature.UUID); ok {
+		metrics.TaskDuration.WithLabelValues(signature.Name, state).Observe(time.Since(startTime.(time.Time)).Seconds())
+	}
+}
+
+// startTaskSpan 从任务的消息头中读取server的trace上下文，创建任务执行的span
+func startTaskSpan(signature *tasks.Signature) context.Context {
+	ctx := tracing.Extract(context.Background(), tracing.HeadersCarrier(signature.Headers))
+	ctx, span := tracing.StartWithKind(ctx, "task."+signature.Name, trace.SpanKindConsumer,
+		attribute.String("task_id", signature.UUID), attribute.String("worker", WStatus.WorkerName))
+	taskSpan.Store(signature.UUID, span)
+	tracing.SetTaskContext(signature.UUID, ctx)
+	return ctx
+}
+
+// endTaskSpan 结束任务执行的span
+func endTaskSpan(taskId string, state string) {
+	tracing.DeleteTaskContext(taskId)
+	if v, ok := taskSpan.LoadAndDelete(taskId); ok {
+		span := v.(trace.Span)
+		span.SetAttributes(attribute.String("state", state))
+		var err error
+		if state == ampq.FAILURE {
+			err = errors.New("task failure")
+		}
+		tracing.End(span, err)
+	}
+}
+
+// toolSpan 在任务的trace中创建外部工具执行的span
+func toolSpan(taskId string, tool string) trace.Span {
+	_, span := tracing.Start(tracing.TaskContext(taskId), "tool."+tool, attribute.String("tool", tool))
+	return span
 }
 
 // preTaskHandler 任务开始前的处理工作
@@ -137,9 +214,12 @@ func preTaskHandler(signature *tasks.Signature) {
 	WStatus.TaskExecutedNumber++
 	WStatus.TaskStartedNumber++
 	WStatus.Unlock()
+	taskStartTime.Store(signature.UUID, time.Now())
+	metrics.TaskStateTotal.WithLabelValues(signature.Name, ampq.STARTED).Inc()
+	ctx := startTaskSpan(signature)
 
 	var taskStatus comm.TaskStatusArgs
-	if err := comm.CallXClient("CheckTask", &signature.UUID, &taskStatus); err != nil {
+	if err := comm.CallXClientWithContext(ctx, "CheckTask", &signature.UUID, &taskStatus); err != nil {
 		return
 	}
 	if !taskStatus.IsExist {
@@ -157,18 +237,18 @@ func preTaskHandler(signature *tasks.Signature) {
 func CheckTaskStatus(taskId string) (ok bool, result string, err error) {
 	var taskStatus comm.TaskStatusArgs
 	if err = comm.CallXClient("CheckTask", &taskId, &taskStatus); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, "").Error(err)
 		return false, FailedTask(err.Error()), err
 	}
 	if !taskStatus.IsExist {
-		logging.RuntimeLog.Warningf("task not exists: %s", taskId)
+		taskLog(taskId, "").Warningf("task not exists: %s", taskId)
 		return false, FailedTask("task not exist"), errors.New("task not exist")
 	}
 	if taskStatus.IsRevoked {
 		return false, RevokedTask(""), nil
 	}
 	if taskStatus.IsFinished {
-		logging.RuntimeLog.Warningf("task has finished: %s", taskId)
+		taskLog(taskId, "").Warningf("task has finished: %s", taskId)
 		return false, SucceedTask(""), errors.New("task has finished")
 	}
 	return true, "", nil
@@ -183,13 +263,18 @@ func UpdateTaskStatus(taskId string, state string, worker string, result string)
 		Result: result,
 	}
 	var updateStatus bool
-	if err := comm.CallXClient("UpdateTask", &taskStatus, &updateStatus); err != nil {
-		logging.RuntimeLog.Error(err)
+	if err := comm.CallXClientWithContext(tracing.TaskContext(taskId), "UpdateTask", &taskStatus, &updateStatus); err != nil {
+		taskLog(taskId, "").Error(err)
 		return false
 	}
 	return updateStatus
 }
 
+// taskLog 任务的日志，记录任务及主任务的ID；workspace由server根据任务补充
+func taskLog(taskId, mainTaskId string) *logrus.Entry {
+	return logging.NewTaskLog(taskId, mainTaskId, 0)
+}
+
 // TaskTest 测试任务
 func TaskTest(taskId, r string) (string, error) {
 	fmt.Println(taskId)
@@ -4,8 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/comm"
-	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/tracing"
 	"strings"
 )
 
@@ -19,18 +19,19 @@ func BatchScan(taskId, mainTaskId, configJSON string) (result string, err error)
 	}
 	config := portscan.Config{}
 	if err = ParseConfig(configJSON, &config); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	// 提取两个阶段的port
 	ports := strings.Split(config.Port, "|")
 	if len(ports) != 2 || strings.TrimSpace(ports[0]) == "" || strings.TrimSpace(ports[1]) == "" {
-		logging.RuntimeLog.Warning("ports error")
+		taskLog(taskId, mainTaskId).Warning("ports error")
 		return FailedTask("ports error"), errors.New("ports error:" + config.Port)
 	}
 	var resultPortScan *portscan.Result
 	// 存活探测扫描
 	config.Port = ports[0]
+	span := toolSpan(taskId, config.CmdBin)
 	if config.CmdBin == "nmap" {
 		nmap := portscan.NewNmap(config)
 		nmap.Do()
@@ -40,11 +41,13 @@ func BatchScan(taskId, mainTaskId, configJSON string) (result string, err error)
 		mascan.Do()
 		resultPortScan = &mascan.Result
 	}
+	span.End()
 	// 详细端口扫描
 	ipSubnetList := getResultIPSubnetList(resultPortScan)
 	if ipSubnetList != "" {
 		config.Port = ports[1]
 		config.Target = ipSubnetList
+		span = toolSpan(taskId, config.CmdBin)
 		if config.CmdBin == "nmap" {
 			nmap := portscan.NewNmap(config)
 			nmap.Do()
@@ -54,6 +57,7 @@ func BatchScan(taskId, mainTaskId, configJSON string) (result string, err error)
 			mascan.Do()
 			resultPortScan = &mascan.Result
 		}
+		span.End()
 		// IP位置
 		if config.IsIpLocation {
 			doLocation(resultPortScan)
@@ -66,9 +70,9 @@ func BatchScan(taskId, mainTaskId, configJSON string) (result string, err error)
 		IPConfig:   &config,
 		IPResult:   resultPortScan.IPResult,
 	}
-	err = comm.CallXClient("SaveScanResult", &resultArgs, &result)
+	err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "SaveScanResult", &resultArgs, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	//指纹识别任务
@@ -80,7 +84,7 @@ func BatchScan(taskId, mainTaskId, configJSON string) (result string, err error)
 		WorkspaceId:      config.WorkspaceId,
 	})
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 
@@ -0,0 +1,147 @@
+package workerapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/RichardKnop/machinery/v2/tasks"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/task/ampq"
+	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/mem"
+	"golang.org/x/net/icmp"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	// rejectedTaskRetryDelay worker拒绝执行的任务重新进入队列的延迟时间
+	rejectedTaskRetryDelay = 30 * time.Second
+	// maxTaskRejectedTimes 任务被拒绝执行的最大次数，超过后任务失败，避免没有满足要求的worker时任务一直在队列中
+	maxTaskRejectedTimes = 20
+)
+
+// rejectedTask worker拒绝执行的任务及原因
+var rejectedTask sync.Map
+
+// thirdpartyTools 在thirdparty目录中的外部工具
+var thirdpartyTools = map[string]utils.BinShortName{
+	ampq.ToolHttpx:        utils.Httpx,
+	ampq.ToolSubfinder:    utils.Subfinder,
+	ampq.ToolMassdns:      utils.MassDns,
+	ampq.ToolXray:         utils.Xray,
+	ampq.ToolNuclei:       utils.Nuclei,
+	ampq.ToolObserverWard: utils.ObserverWard,
+}
+
+// thirdpartyToolDir 外部工具在thirdparty中的目录
+var thirdpartyToolDir = map[string]string{
+	ampq.ToolHttpx:        "httpx",
+	ampq.ToolSubfinder:    "subfinder",
+	ampq.ToolMassdns:      "massdns",
+	ampq.ToolXray:         "xray",
+	ampq.ToolNuclei:       "nuclei",
+	ampq.ToolObserverWard: "fingerprinthub",
+}
+
+// DetectCapability 检测worker已安装的工具及运行环境，labels为worker启动时指定的标签
+func DetectCapability(labels map[string]string) (c ampq.WorkerCapability) {
+	for tool, binName := range thirdpartyTools {
+		binPath := filepath.Join(conf.GetRootPath(), "thirdparty", thirdpartyToolDir[tool], utils.GetThirdpartyBinNameByPlatform(binName))
+		if utils.CheckFileExist(binPath) {
+			c.Tools = append(c.Tools, tool)
+		}
+	}
+	for _, tool := range []string{ampq.ToolNmap, ampq.ToolMasscan} {
+		if _, err := exec.LookPath(tool); err == nil {
+			c.Tools = append(c.Tools, tool)
+		}
+	}
+	c.Chrome = domainscan.FindExecPath() != ""
+	c.RawSocket = checkRawSocket()
+	if cpuNumber, err := cpu.Counts(true); err == nil {
+		c.CPU = cpuNumber
+	}
+	if memInfo, err := mem.VirtualMemory(); err == nil {
+		c.Memory = memInfo.Total / 1024 / 1024
+	}
+	c.Labels = map[string]string{
+		"os":   runtime.GOOS,
+		"arch": runtime.GOARCH,
+	}
+	for k, v := range labels {
+		c.Labels[k] = v
+	}
+	return
+}
+
+// checkRawSocket 检查是否有权限使用原始套接字（masscan及nmap的SYN扫描需要）
+func checkRawSocket() bool {
+	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// withRequirementCheck 执行任务前检查worker是否满足任务的要求，不满足时拒绝执行，任务退回到原始队列等待其它worker执行
+func withRequirementCheck(taskFunc func(taskId, mainTaskId, configJSON string) (string, error)) func(ctx context.Context, taskId, mainTaskId, configJSON string) (string, error) {
+	return func(ctx context.Context, taskId, mainTaskId, configJSON string) (string, error) {
+		signature := tasks.SignatureFromContext(ctx)
+		if signature == nil {
+			return taskFunc(taskId, mainTaskId, configJSON)
+		}
+		requirement, topicName, ok := ampq.GetTaskRequirementFromHeaders(signature.Headers)
+		if !ok {
+			return taskFunc(taskId, mainTaskId, configJSON)
+		}
+		WStatus.Lock()
+		satisfied, reason := WStatus.Capability.Satisfy(requirement)
+		WStatus.Unlock()
+		if satisfied {
+			return taskFunc(taskId, mainTaskId, configJSON)
+		}
+		// 已取消或已完成的任务不需要再退回到队列
+		if ok, result, err := CheckTaskStatus(taskId); !ok {
+			return result, err
+		}
+		if times := ampq.IncTaskRejectedTimes(signature.Headers); times > maxTaskRejectedTimes {
+			err := fmt.Errorf("no worker satisfies the requirement after rejected %d times, last reject by %s: %s", maxTaskRejectedTimes, WStatus.WorkerName, reason)
+			taskLog(taskId, mainTaskId).Error(err)
+			return FailedTask(err.Error()), err
+		}
+		taskLog(taskId, mainTaskId).Warningf("reject task %s: %s", signature.Name, reason)
+		rejectedTask.Store(taskId, reason)
+		// 退回到任务的原始队列，由其它满足要求的worker执行
+		if topicName != "" {
+			signature.RoutingKey = ampq.GetRoutingKeyByTopic(topicName)
+		}
+		return "", tasks.NewErrRetryTaskLater(fmt.Sprintf("reject by %s: %s", WStatus.WorkerName, reason), rejectedTaskRetryDelay)
+	}
+}
+
+// RejectedTask 任务被worker拒绝执行的状态和原因
+func RejectedTask(msg string) string {
+	r := ampq.TaskResult{Status: ampq.RETRY, Msg: msg}
+	js, _ := json.Marshal(r)
+	return string(js)
+}
+
+// getRegisteredTasks 获取worker注册的任务，任务执行前检查worker是否满足任务的要求
+func getRegisteredTasks() map[string]interface{} {
+	registeredTasks := make(map[string]interface{})
+	for name, taskFunc := range taskMaps {
+		if f, ok := taskFunc.(func(taskId, mainTaskId, configJSON string) (string, error)); ok {
+			registeredTasks[name] = withRequirementCheck(f)
+		} else {
+			registeredTasks[name] = taskFunc
+		}
+	}
+	return registeredTasks
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/tracing"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"net/netip"
 	"strings"
@@ -23,10 +24,13 @@ func DomainScan(taskId, mainTaskId, configJSON string) (result string, err error
 
 	config := domainscan.Config{}
 	if err = ParseConfig(configJSON, &config); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
+	config.TaskId, config.MainTaskId = taskId, mainTaskId
+	span := toolSpan(taskId, "domainscan")
 	resultDomainScan := doDomainScan(config)
+	span.End()
 	// 如果有端口扫描的选项
 	if config.IsIPPortScan || config.IsIPSubnetPortScan {
 		doPortScanByDomainscan(taskId, mainTaskId, config, resultDomainScan)
@@ -37,10 +41,11 @@ func DomainScan(taskId, mainTaskId, configJSON string) (result string, err error
 		MainTaskId:   mainTaskId,
 		DomainConfig: &config,
 		DomainResult: resultDomainScan.DomainResult,
+		UrlResult:    resultDomainScan.UrlResult,
 	}
-	err = comm.CallXClient("SaveScanResult", &resultArgs, &result)
+	err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "SaveScanResult", &resultArgs, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	_, err = NewFingerprintTask(taskId, mainTaskId, nil, resultDomainScan, FingerprintTaskConfig{
@@ -51,7 +56,7 @@ func DomainScan(taskId, mainTaskId, configJSON string) (result string, err error
 		WorkspaceId:      config.WorkspaceId,
 	})
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	return SucceedTask(result), nil
@@ -68,6 +73,9 @@ func doDomainScan(config domainscan.Config) (resultDomainScan *domainscan.Result
 	// 子域名爆破
 	if config.IsSubDomainBrute {
 		massdns := domainscan.NewMassdns(config)
+		if config.SubDomainBruteMode == domainscan.BruteModeAlteration {
+			massdns.KnownSubdomains = loadKnownSubdomain(config)
+		}
 		massdns.Do()
 		resultDomainScan = &massdns.Result
 	}
@@ -77,9 +85,15 @@ func doDomainScan(config domainscan.Config) (resultDomainScan *domainscan.Result
 		crawler.Do()
 		resultDomainScan = &crawler.Result
 	}
+	// 内置被动数据源
+	if config.IsSubDomainPassive {
+		passive := domainscan.NewPassive(config)
+		passive.Do()
+		resultDomainScan = &passive.Result
+	}
 	// 域名解析
 	resolve := domainscan.NewResolve(config)
-	if !config.IsSubDomainFinder && !config.IsSubDomainBrute && !config.IsCrawler {
+	if !config.IsSubDomainFinder && !config.IsSubDomainBrute && !config.IsCrawler && !config.IsSubDomainPassive {
 		// 对config中Target进行域名解析
 		resolve.Do()
 		resultDomainScan = &resolve.Result
@@ -92,10 +106,31 @@ func doDomainScan(config domainscan.Config) (resultDomainScan *domainscan.Result
 	checkDomainResolveResult(resultDomainScan)
 	// 对域名结果中同一个IP对应太多进行过滤
 	domainscan.FilterDomainHasTooMuchIP(resultDomainScan)
+	// DNS记录枚举及检查
+	if config.IsDNSRecord {
+		dnsRecord := domainscan.NewDNSRecord(config)
+		dnsRecord.Result.DomainResult = resultDomainScan.DomainResult
+		dnsRecord.Do()
+	}
 
 	return resultDomainScan
 }
 
+// loadKnownSubdomain 从server获取根域名在工作空间中已知的子域名
+func loadKnownSubdomain(config domainscan.Config) (knownSubdomains map[string][]string) {
+	args := comm.LoadKnownSubdomainArgs{WorkspaceId: config.WorkspaceId}
+	for _, line := range strings.Split(config.Target, ",") {
+		if domain := strings.TrimSpace(line); domain != "" && !utils.CheckIPOrSubnet(domain) {
+			args.Target = append(args.Target, domain)
+		}
+	}
+	if err := comm.CallXClient("LoadKnownSubdomain", &args, &knownSubdomains); err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+	}
+	return
+}
+
 // doPortScanByDomainscan 对IP进行端口扫描
 func doPortScanByDomainscan(taskId, mainTaskId string, config domainscan.Config, resultDomainScan *domainscan.Result) {
 	ipResult, ipSubnetResult := getResultIPList(resultDomainScan)
@@ -142,9 +177,9 @@ func doPortScanByDomainscan(taskId, mainTaskId string, config domainscan.Config,
 				ConfigJSON:    string(configPortScanJSON),
 			}
 			var result string
-			err := comm.CallXClient("NewTask", &newTaskArgs, &result)
+			err := comm.CallXClientWithContext(tracing.TaskContext(taskId), "NewTask", &newTaskArgs, &result)
 			if err != nil {
-				logging.RuntimeLog.Error("Start Portscan task fail:", err)
+				taskLog(taskId, mainTaskId).Error("Start Portscan task fail:", err)
 				logging.CLILog.Error("Start Portscan task fail:", err)
 			} else {
 				logging.CLILog.Info("Start Portscan task...")
@@ -220,7 +255,7 @@ func isHaveResolveRecord(domainAttrs *[]domainscan.DomainAttrResult) bool {
 		return false
 	}
 	for _, dar := range *domainAttrs {
-		if dar.Tag == "A" || dar.Tag == "AAAA" || dar.Tag == "CNAME" {
+		if dar.Tag == "A" || dar.Tag == "AAAA" || dar.Tag == "CNAME" || dar.Tag == domainscan.WildcardAttrTag {
 			return true
 		}
 	}
@@ -7,6 +7,7 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/tracing"
 	"strings"
 )
 
@@ -33,13 +34,13 @@ func Fingerprint(taskId, mainTaskId, configJSON string) (result string, err erro
 	}
 	config := FingerprintTaskConfig{}
 	if err = ParseConfig(configJSON, &config); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	//
 	_, _, result, err = doFingerPrintAndSave(taskId, mainTaskId, config)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 
@@ -72,8 +73,12 @@ func doFingerPrintAndSave(taskId string, mainTaskId string, config FingerprintTa
 			IsFingerprintHub: config.IsFingerprintHub,
 			IsIconHash:       config.IsIconHash,
 			WorkspaceId:      config.WorkspaceId,
+			TaskId:           taskId,
+			MainTaskId:       mainTaskId,
 		}
+		span := toolSpan(taskId, "fingerprint")
 		doIPFingerPrint(portscanConfig, resultPortScan)
+		span.End()
 		resultArgs.IPConfig = &portscanConfig
 		resultArgs.IPResult = resultPortScan.IPResult
 	}
@@ -86,7 +91,7 @@ func doFingerPrintAndSave(taskId string, mainTaskId string, config FingerprintTa
 		}
 		err = comm.CallXClient("LoadDomainOpenedPort", &args, &domainPort)
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 		}
 		for domain := range config.DomainTargetMap {
 			resultDomainScan.SetDomain(domain)
@@ -96,20 +101,25 @@ func doFingerPrintAndSave(taskId string, mainTaskId string, config FingerprintTa
 			IsFingerprintHub: config.IsFingerprintHub,
 			IsIconHash:       config.IsIconHash,
 			WorkspaceId:      config.WorkspaceId,
+			TaskId:           taskId,
+			MainTaskId:       mainTaskId,
 		}
+		span := toolSpan(taskId, "fingerprint")
 		doDomainFingerPrint(domainscanConfig, resultDomainScan, domainPort)
+		span.End()
 		resultArgs.DomainConfig = &domainscanConfig
 		resultArgs.DomainResult = resultDomainScan.DomainResult
 	}
 	// 保存结果
-	err = comm.CallXClient("SaveScanResult", &resultArgs, &result)
+	err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "SaveScanResult", &resultArgs, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return
 	}
 	// screenshot任务
 	if config.IsScreenshot {
-		resultScreenshot := doScreenshotAndSave(config.WorkspaceId, mainTaskId, resultPortScan, resultDomainScan, domainPort, config.IsHttpx)
+		task := fingerprint.TaskInfo{TaskId: taskId, MainTaskId: mainTaskId, WorkspaceId: config.WorkspaceId}
+		resultScreenshot := doScreenshotAndSave(task, resultPortScan, resultDomainScan, domainPort, config.IsHttpx)
 		result = strings.Join([]string{result, resultScreenshot}, ",")
 	}
 
@@ -118,46 +128,53 @@ func doFingerPrintAndSave(taskId string, mainTaskId string, config FingerprintTa
 
 // doIPFingerPrint 对 IP结果进行指纹识别
 func doIPFingerPrint(config portscan.Config, resultPortScan *portscan.Result) {
+	task := fingerprint.TaskInfo{TaskId: config.TaskId, MainTaskId: config.MainTaskId, WorkspaceId: config.WorkspaceId}
 	if config.IsHttpx {
 		httpx := fingerprint.NewHttpxFinger()
+		httpx.TaskInfo = task
 		httpx.ResultPortScan = resultPortScan
 		httpx.DoHttpxAndFingerPrint()
 	}
 	if config.IsFingerprintHub {
 		fp := fingerprint.NewFingerprintHub()
+		fp.TaskInfo = task
 		fp.ResultPortScan = resultPortScan
 		fp.OptimizationMode = config.IsHttpx
 		fp.Do()
 	}
 	if config.IsIconHash {
-		doIconHashAndSave(config.WorkspaceId, resultPortScan, nil, nil, config.IsHttpx)
+		doIconHashAndSave(task, resultPortScan, nil, nil, config.IsHttpx)
 	}
 }
 
 // doDomainFingerPrint 对域名结果进行指纹识别
 func doDomainFingerPrint(config domainscan.Config, resultDomainScan *domainscan.Result, domainPort map[string]map[int]struct{}) {
+	task := fingerprint.TaskInfo{TaskId: config.TaskId, MainTaskId: config.MainTaskId, WorkspaceId: config.WorkspaceId}
 	// 指纹识别
 	if config.IsHttpx {
 		httpx := fingerprint.NewHttpxFinger()
+		httpx.TaskInfo = task
 		httpx.ResultDomainScan = resultDomainScan
 		httpx.DomainTargetPort = domainPort
 		httpx.DoHttpxAndFingerPrint()
 	}
 	if config.IsFingerprintHub {
 		fp := fingerprint.NewFingerprintHub()
+		fp.TaskInfo = task
 		fp.OptimizationMode = config.IsHttpx
 		fp.ResultDomainScan = resultDomainScan
 		fp.DomainTargetPort = domainPort
 		fp.Do()
 	}
 	if config.IsIconHash {
-		doIconHashAndSave(config.WorkspaceId, nil, resultDomainScan, domainPort, config.IsHttpx)
+		doIconHashAndSave(task, nil, resultDomainScan, domainPort, config.IsHttpx)
 	}
 }
 
 // doScreenshotAndSave 执行Screenshot并保存
-func doScreenshotAndSave(workspaceId int, mainTaskId string, resultIPScan *portscan.Result, resultDomainScan *domainscan.Result, domainPort map[string]map[int]struct{}, isHttpx bool) (result string) {
+func doScreenshotAndSave(task fingerprint.TaskInfo, resultIPScan *portscan.Result, resultDomainScan *domainscan.Result, domainPort map[string]map[int]struct{}, isHttpx bool) (result string) {
 	ss := fingerprint.NewScreenShot()
+	ss.TaskInfo = task
 	ss.OptimizationMode = isHttpx
 	if resultIPScan != nil {
 		ss.ResultPortScan = resultIPScan
@@ -168,21 +185,22 @@ func doScreenshotAndSave(workspaceId int, mainTaskId string, resultIPScan *ports
 	}
 	ss.Do()
 	args := comm.ScreenshotResultArgs{
-		MainTaskId:  mainTaskId,
+		MainTaskId:  task.MainTaskId,
 		FileInfo:    ss.LoadResult(),
-		WorkspaceId: workspaceId,
+		WorkspaceId: task.WorkspaceId,
 	}
 	err := comm.CallXClient("SaveScreenshotResult", &args, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(task.TaskId, task.MainTaskId).Error(err)
 		return err.Error()
 	}
 	return
 }
 
 // doIconHashAndSave 获取icon，并将icon image保存到服务端
-func doIconHashAndSave(workspaceId int, resultIPScan *portscan.Result, resultDomainScan *domainscan.Result, domainPort map[string]map[int]struct{}, isHttpx bool) (result string) {
+func doIconHashAndSave(task fingerprint.TaskInfo, resultIPScan *portscan.Result, resultDomainScan *domainscan.Result, domainPort map[string]map[int]struct{}, isHttpx bool) (result string) {
 	hash := fingerprint.NewIconHash()
+	hash.TaskInfo = task
 	hash.OptimizationMode = isHttpx
 	if resultIPScan != nil {
 		hash.ResultPortScan = resultIPScan
@@ -197,12 +215,12 @@ func doIconHashAndSave(workspaceId int, resultIPScan *portscan.Result, resultDom
 		return ""
 	}
 	args := comm.IconHashResultArgs{
-		WorkspaceId:  workspaceId,
+		WorkspaceId:  task.WorkspaceId,
 		IconHashInfo: hash.IconHashInfoResult.Result,
 	}
 	err := comm.CallXClient("SaveIconImageResult", &args, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(task.TaskId, task.MainTaskId).Error(err)
 		return err.Error()
 	}
 	return
@@ -277,7 +295,7 @@ func MakeSubTaskTarget(portScanResult *portscan.Result, domainScanResult *domain
 func sendTask(taskId string, mainTaskId string, config interface{}, taskName string) (result string, err error) {
 	configMarshal, err := json.Marshal(config)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return
 	}
 	newTaskArgs := comm.NewTaskArgs{
@@ -286,9 +304,9 @@ func sendTask(taskId string, mainTaskId string, config interface{}, taskName str
 		TaskName:      taskName,
 		ConfigJSON:    string(configMarshal),
 	}
-	err = comm.CallXClient("NewTask", &newTaskArgs, &result)
+	err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "NewTask", &newTaskArgs, &result)
 	if err != nil {
-		logging.RuntimeLog.Errorf("start task:%s fail:%v", taskName, err)
+		taskLog(taskId, mainTaskId).Errorf("start task:%s fail:%v", taskName, err)
 		logging.CLILog.Errorf("start task:%s fail:%v", taskName, err)
 	}
 	return
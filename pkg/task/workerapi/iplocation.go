@@ -2,9 +2,9 @@ package workerapi
 
 import (
 	"github.com/hanc00l/nemo_go/pkg/comm"
-	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/tracing"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"strings"
 )
@@ -18,7 +18,7 @@ func IPLocation(taskId, mainTaskId, configJSON string) (result string, err error
 
 	config := custom.Config{}
 	if err = ParseConfig(configJSON, &config); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 
@@ -46,9 +46,9 @@ func IPLocation(taskId, mainTaskId, configJSON string) (result string, err error
 		IPConfig:   &portscan.Config{OrgId: config.OrgId},
 		IPResult:   resultPortScan.IPResult,
 	}
-	err = comm.CallXClient("SaveScanResult", &resultArgs, &result)
+	err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "SaveScanResult", &resultArgs, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	return SucceedTask(result), nil
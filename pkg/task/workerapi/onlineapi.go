@@ -9,12 +9,21 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/onlineapi"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/tracing"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
+// onlineAPIKeyProvider 通过RPC从server的key池租用在线API的key
+type onlineAPIKeyProvider struct{}
+
+// InitOnlineAPIKeyProvider 在线API查询使用server的key池，在worker启动时设置一次
+func InitOnlineAPIKeyProvider() {
+	onlineapi.SetKeyProvider(onlineAPIKeyProvider{})
+}
+
 // Fofa Fofa任务
 func Fofa(taskId, mainTaskId, configJSON string) (result string, err error) {
 	return doOnlineAPI(taskId, mainTaskId, configJSON, "fofa")
@@ -30,7 +39,32 @@ func Hunter(taskId, mainTaskId, configJSON string) (result string, err error) {
 	return doOnlineAPI(taskId, mainTaskId, configJSON, "hunter")
 }
 
-// doOnlineAPI 执行fofa、hunter及quake的资产搜索任务
+// Shodan Shodan任务
+func Shodan(taskId, mainTaskId, configJSON string) (result string, err error) {
+	return doOnlineAPI(taskId, mainTaskId, configJSON, "shodan")
+}
+
+// Censys Censys任务
+func Censys(taskId, mainTaskId, configJSON string) (result string, err error) {
+	return doOnlineAPI(taskId, mainTaskId, configJSON, "censys")
+}
+
+// ZoomEye ZoomEye任务
+func ZoomEye(taskId, mainTaskId, configJSON string) (result string, err error) {
+	return doOnlineAPI(taskId, mainTaskId, configJSON, "zoomeye")
+}
+
+// Netlas Netlas任务
+func Netlas(taskId, mainTaskId, configJSON string) (result string, err error) {
+	return doOnlineAPI(taskId, mainTaskId, configJSON, "netlas")
+}
+
+// BinaryEdge BinaryEdge任务
+func BinaryEdge(taskId, mainTaskId, configJSON string) (result string, err error) {
+	return doOnlineAPI(taskId, mainTaskId, configJSON, "binaryedge")
+}
+
+// doOnlineAPI 执行在线资产搜索引擎的资产搜索任务
 func doOnlineAPI(taskId string, mainTaskId string, configJSON string, apiName string) (result string, err error) {
 	// 检查任务状态
 	var ok bool
@@ -40,9 +74,10 @@ func doOnlineAPI(taskId string, mainTaskId string, configJSON string, apiName st
 	// 解析任务参数
 	config := onlineapi.OnlineAPIConfig{}
 	if err = ParseConfig(configJSON, &config); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
+	config.TaskId, config.MainTaskId = taskId, mainTaskId
 	//执行任务
 	var ipResult *portscan.Result
 	var domainResult *domainscan.Result
@@ -60,17 +95,19 @@ func doOnlineAPI(taskId string, mainTaskId string, configJSON string, apiName st
 		WorkspaceId:      config.WorkspaceId,
 	})
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 
 	return SucceedTask(result), nil
 }
 
-// doOnlineAPIAndSave 执行fofa、hunter及quake的资产搜索，并保存结果
+// doOnlineAPIAndSave 执行在线资产搜索引擎的资产搜索，并保存结果
 func doOnlineAPIAndSave(taskId string, mainTaskId string, apiName string, config onlineapi.OnlineAPIConfig) (ipResult *portscan.Result, domainResult *domainscan.Result, result string, err error) {
 	s := onlineapi.NewOnlineAPISearch(config, apiName)
+	span := toolSpan(taskId, apiName)
 	s.Do()
+	span.End()
 	ipResult = &s.IpResult
 	domainResult = &s.DomainResult
 	portscan.FilterIPHasTooMuchPort(ipResult, true)
@@ -88,13 +125,31 @@ func doOnlineAPIAndSave(taskId string, mainTaskId string, apiName string, config
 		IPResult:     ipResult.IPResult,
 		DomainResult: domainResult.DomainResult,
 	}
-	err = comm.CallXClient("SaveScanResult", &args, &result)
+	err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "SaveScanResult", &args, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 	}
 	return
 }
 
+// Lease 租用一个key
+func (onlineAPIKeyProvider) Lease(engine string, workspaceId int, excludeIds []int) (*onlineapi.APIKeyLease, error) {
+	args := comm.LeaseAPIKeyArgs{Engine: engine, WorkspaceId: workspaceId, ExcludeIds: excludeIds}
+	var lease onlineapi.APIKeyLease
+	if err := comm.CallXClient("LeaseOnlineAPIKey", &args, &lease); err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+// Report 报告key的使用结果
+func (onlineAPIKeyProvider) Report(report onlineapi.APIKeyReport) {
+	var result string
+	if err := comm.CallXClient("ReportOnlineAPIKey", &report, &result); err != nil {
+		logging.RuntimeLog.Error(err)
+	}
+}
+
 // ICPQuery ICP备案查询任务
 func ICPQuery(taskId, mainTaskId, configJSON string) (result string, err error) {
 	var ok bool
@@ -110,9 +165,9 @@ func ICPQuery(taskId, mainTaskId, configJSON string) (result string, err error)
 	icp := onlineapi.NewICPQuery(config)
 	icp.Do()
 	// 保存结果
-	err = comm.CallXClient("SaveICPResult", &icp.QueriedICPInfo, &result)
+	err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "SaveICPResult", &icp.QueriedICPInfo, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 
@@ -134,9 +189,9 @@ func WhoisQuery(taskId, mainTaskId, configJSON string) (result string, err error
 	whois := onlineapi.NewWhois(config)
 	whois.Do()
 	// 保存结果
-	err = comm.CallXClient("SaveWhoisResult", &whois.QueriedWhoisInfo, &result)
+	err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "SaveWhoisResult", &whois.QueriedWhoisInfo, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 
@@ -169,7 +224,7 @@ func checkIgnoreResult(portScanResult *portscan.Result, domainScanResult *domain
 				for port := range ipInfo.Ports {
 					portInfo := ipInfo.Ports[port]
 					for _, attr := range portInfo.PortAttrs {
-						if (attr.Source == "fofa" || attr.Source == "hunter" || attr.Source == "quake") && attr.Tag == "title" {
+						if onlineapi.IsSource(attr.Source) && attr.Tag == "title" {
 							if len(attr.Content) > 100 {
 								needDelete = true
 								break
@@ -3,9 +3,15 @@ package workerapi
 import (
 	"github.com/hanc00l/nemo_go/pkg/comm"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/oob"
 	"github.com/hanc00l/nemo_go/pkg/task/pocscan"
+	"github.com/hanc00l/nemo_go/pkg/tracing"
+	"github.com/hanc00l/nemo_go/pkg/xraypocv1"
 )
 
+// oobReversePlatform 通过RPC使用server的反连平台
+type oobReversePlatform struct{}
+
 // PocScan 漏洞验证任务
 func PocScan(taskId, mainTaskId, configJSON string) (result string, err error) {
 	var ok bool
@@ -14,12 +20,25 @@ func PocScan(taskId, mainTaskId, configJSON string) (result string, err error) {
 	}
 	config := pocscan.Config{}
 	if err = ParseConfig(configJSON, &config); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
+	config.TaskId, config.MainTaskId = taskId, mainTaskId
 	//读取资产开放端口
 	var resultIPPorts string
-	if config.IsLoadOpenedPort {
+	if config.CmdBin == "weakpass" {
+		// 弱口令验证只针对资产中已识别的服务
+		args := comm.LoadIPOpenedPortArgs{
+			WorkspaceId: config.WorkspaceId,
+			Target:      config.Target,
+		}
+		err = comm.CallXClient("LoadServicePort", &args, &resultIPPorts)
+		if err != nil {
+			taskLog(taskId, mainTaskId).Error(err)
+			return FailedTask(err.Error()), err
+		}
+		config.Target = resultIPPorts
+	} else if config.IsLoadOpenedPort {
 		args := comm.LoadIPOpenedPortArgs{
 			WorkspaceId: config.WorkspaceId,
 			Target:      config.Target,
@@ -28,10 +47,12 @@ func PocScan(taskId, mainTaskId, configJSON string) (result string, err error) {
 		if err == nil {
 			config.Target = resultIPPorts
 		} else {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 		}
 	}
 	var scanResult []pocscan.Result
+	var pathResult []pocscan.PathResult
+	span := toolSpan(taskId, config.CmdBin)
 	if config.CmdBin == "xray" {
 		x := pocscan.NewXray(config)
 		x.Do()
@@ -39,7 +60,7 @@ func PocScan(taskId, mainTaskId, configJSON string) (result string, err error) {
 	} else if config.CmdBin == "dirsearch" {
 		d := pocscan.NewDirsearch(config)
 		d.Do()
-		scanResult = d.Result
+		pathResult = d.PathResult
 	} else if config.CmdBin == "nuclei" {
 		n := pocscan.NewNuclei(config)
 		n.Do()
@@ -48,18 +69,45 @@ func PocScan(taskId, mainTaskId, configJSON string) (result string, err error) {
 		g := pocscan.NewGoby(config)
 		g.Do()
 		scanResult = g.Result
+	} else if config.CmdBin == "xraypocv1" {
+		xraypocv1.SetReversePlatform(oobReversePlatform{})
+		x := pocscan.NewXrayPocV1(config)
+		x.Do()
+		scanResult = x.Result
+	} else if config.CmdBin == "weakpass" {
+		w := pocscan.NewWeakPass(config)
+		w.Do()
+		scanResult = w.Result
 	}
+	span.End()
 	// 保存结果
 	resultArgs := comm.ScanResultArgs{
 		TaskID:              taskId,
 		MainTaskId:          mainTaskId,
 		VulnerabilityResult: scanResult,
+		PathResult:          pathResult,
 	}
-	err = comm.CallXClient("SaveVulnerabilityResult", &resultArgs, &result)
+	err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "SaveVulnerabilityResult", &resultArgs, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 
 	return SucceedTask(result), nil
 }
+
+// NewSubdomain 分配一个唯一的子域名
+func (oobReversePlatform) NewSubdomain() (domain string, err error) {
+	err = comm.CallXClient("NewOOBSubdomain", new(string), &domain)
+	return
+}
+
+// Check 检查子域名是否有交互记录
+func (oobReversePlatform) Check(domain string) bool {
+	var interactions []oob.Interaction
+	if err := comm.CallXClient("LookupOOBInteraction", &domain, &interactions); err != nil {
+		logging.RuntimeLog.Error(err)
+		return false
+	}
+	return len(interactions) > 0
+}
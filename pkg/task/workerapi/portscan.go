@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/comm"
 	"github.com/hanc00l/nemo_go/pkg/conf"
-	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/tracing"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"github.com/remeh/sizedwaitgroup"
 	"strconv"
@@ -27,9 +27,10 @@ func PortScan(taskId, mainTaskId, configJSON string) (result string, err error)
 	}
 	config := portscan.Config{}
 	if err = ParseConfig(configJSON, &config); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
+	config.TaskId, config.MainTaskId = taskId, mainTaskId
 	var resultPortScan *portscan.Result
 	resultPortScan, result, err = doPortScanAndSave(taskId, mainTaskId, config)
 	//指纹识别任务
@@ -49,6 +50,7 @@ func PortScan(taskId, mainTaskId, configJSON string) (result string, err error)
 func doPortScanAndSave(taskId string, mainTaskId string, config portscan.Config) (resultPortScan *portscan.Result, result string, err error) {
 	//端口扫描：
 	if config.IsPortscan {
+		span := toolSpan(taskId, config.CmdBin)
 		if config.CmdBin == "masnmap" {
 			resultPortScan = doMasscanPlusNmap(config)
 		} else if config.CmdBin == "nmap" {
@@ -60,6 +62,7 @@ func doPortScanAndSave(taskId string, mainTaskId string, config portscan.Config)
 			masscan.Do()
 			resultPortScan = &masscan.Result
 		}
+		span.End()
 	} else {
 		resultPortScan.IPResult = make(map[string]*portscan.IPResult)
 	}
@@ -74,9 +77,9 @@ func doPortScanAndSave(taskId string, mainTaskId string, config portscan.Config)
 		IPConfig:   &config,
 		IPResult:   resultPortScan.IPResult,
 	}
-	err = comm.CallXClient("SaveScanResult", &resultArgs, &result)
+	err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "SaveScanResult", &resultArgs, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 	}
 	// 读取目标的数据库中已保存的开放端口
 	var resultIPPorts string
@@ -107,7 +110,7 @@ func doPortScanAndSave(taskId string, mainTaskId string, config portscan.Config)
 				}
 			}
 		} else {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 		}
 	}
 	return
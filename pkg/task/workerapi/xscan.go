@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/comm"
 	"github.com/hanc00l/nemo_go/pkg/conf"
-	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/onlineapi"
 	"github.com/hanc00l/nemo_go/pkg/task/pocscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/tracing"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"github.com/remeh/sizedwaitgroup"
 	"strings"
@@ -29,9 +29,14 @@ type XScanConfig struct {
 	OnlineAPIKeyword     string `json:"onlineapiKeyword,omitempty"`
 	OnlineAPISearchLimit int    `json:"onlineapiSearchLimit,omitempty"`
 	// xonlineapi 任务需要区分是哪一个api
-	IsFofa   bool `json:"fofa,omitempty"`
-	IsHunter bool `json:"hunter,omitempty"`
-	IsQuake  bool `json:"quake,omitempty"`
+	IsFofa       bool `json:"fofa,omitempty"`
+	IsHunter     bool `json:"hunter,omitempty"`
+	IsQuake      bool `json:"quake,omitempty"`
+	IsShodan     bool `json:"shodan,omitempty"`
+	IsCensys     bool `json:"censys,omitempty"`
+	IsZoomEye    bool `json:"zoomeye,omitempty"`
+	IsNetlas     bool `json:"netlas,omitempty"`
+	IsBinaryEdge bool `json:"binaryedge,omitempty"`
 	// portscan
 	IPPort       map[string][]int  `json:"ipport,omitempty"`       //IP:PORT列表
 	IPPortString map[string]string `json:"ipportstring,omitempty"` //格式为ip列表，port可以为多种形式，如"80,443,8000-9000"、"--top-port 100"
@@ -46,10 +51,61 @@ type XScanConfig struct {
 	IsXrayPoc   bool   `json:"xraypoc,omitempty"`
 	XrayPocFile string `json:"xraypocfile,omitempty"`
 	// nucleipoc
-	IsNucleiPoc   bool   `json:"nucleipoc,omitempty"`
-	NucleiPocFile string `json:"nucleipocfile,omitempty"`
+	IsNucleiPoc   bool     `json:"nucleipoc,omitempty"`
+	NucleiPocFile string   `json:"nucleipocfile,omitempty"`
+	NucleiTags    []string `json:"nucleitags,omitempty"`
 	// gobypoc
 	IsGobyPoc bool `json:"gobypoc,omitempty"`
+	// xraypocv1
+	IsXrayPocV1   bool   `json:"xraypocv1,omitempty"`
+	XrayPocV1File string `json:"xraypocv1file,omitempty"`
+	// pocmap：根据指纹识别的结果，只对目标使用指纹对应的POC
+	IsPocMap bool `json:"pocmap,omitempty"`
+}
+
+// OnlineAPIs xonlineapi任务选择的在线资产搜索引擎
+func (c *XScanConfig) OnlineAPIs() (apis []string) {
+	selected := map[string]bool{
+		"fofa":       c.IsFofa,
+		"hunter":     c.IsHunter,
+		"quake":      c.IsQuake,
+		"shodan":     c.IsShodan,
+		"censys":     c.IsCensys,
+		"zoomeye":    c.IsZoomEye,
+		"netlas":     c.IsNetlas,
+		"binaryedge": c.IsBinaryEdge,
+	}
+	for _, api := range onlineapi.SearchEngines {
+		if selected[api] {
+			apis = append(apis, api)
+		}
+	}
+	return
+}
+
+// SetOnlineAPI 选择一个在线资产搜索引擎，apiName可以为任务名称（如xfofa）
+func (c *XScanConfig) SetOnlineAPI(apiName string) bool {
+	switch strings.TrimPrefix(apiName, "x") {
+	case "fofa":
+		c.IsFofa = true
+	case "hunter":
+		c.IsHunter = true
+	case "quake":
+		c.IsQuake = true
+	case "shodan":
+		c.IsShodan = true
+	case "censys":
+		c.IsCensys = true
+	case "zoomeye":
+		c.IsZoomEye = true
+	case "netlas":
+		c.IsNetlas = true
+	case "binaryedge":
+		c.IsBinaryEdge = true
+	default:
+		return false
+	}
+	return true
 }
 
 type XScan struct {
@@ -92,12 +148,12 @@ func XOrganization(taskId, mainTaskId, configJSON string) (result string, err er
 	// 解析任务参数
 	config := XScanConfig{}
 	if err = ParseConfig(configJSON, &config); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	// 执行任务
 	if config.OrgId == nil || *config.OrgId == 0 {
-		logging.RuntimeLog.Error("no org id")
+		taskLog(taskId, mainTaskId).Error("no org id")
 		return FailedTask("no org id"), errors.New("no org id")
 	}
 	scan := NewXScan(config)
@@ -107,7 +163,7 @@ func XOrganization(taskId, mainTaskId, configJSON string) (result string, err er
 	if scan.Config.IsOrgIP {
 		err = comm.CallXClient("LoadIpByOrgId", *config.OrgId, &scan.ResultIP.IPResult)
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return FailedTask("load org ip fail"), err
 		}
 		result = fmt.Sprintf("ip:%d", len(scan.ResultIP.IPResult))
@@ -115,7 +171,7 @@ func XOrganization(taskId, mainTaskId, configJSON string) (result string, err er
 	if scan.Config.IsOrgDomain {
 		err = comm.CallXClient("LoadDomainByOrgId", *config.OrgId, &scan.ResultDomain.DomainResult)
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return FailedTask("load org domain fail"), err
 
 		}
@@ -139,14 +195,14 @@ func XOrganization(taskId, mainTaskId, configJSON string) (result string, err er
 			_, err = scan.NewPortScan(taskId, mainTaskId, ipPortMap, nil)
 		}
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return FailedTask(err.Error()), err
 		}
 	}
 	// 域名任务只执行解析不进行子域名任务
 	_, err = scan.NewDomainScan(taskId, mainTaskId, domainMap, false, false)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 
@@ -163,27 +219,27 @@ func XOnlineAPI(taskId, mainTaskId, configJSON string) (result string, err error
 	// 解析任务参数
 	config := XScanConfig{}
 	if err = ParseConfig(configJSON, &config); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	// 执行任务
 	scan := NewXScan(config)
 	result, err = scan.OnlineAPISearch(taskId, mainTaskId)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	// 执行portscan与domainscan
 	ipPortMap, domainMap := MakeSubTaskTarget(scan.ResultIP, scan.ResultDomain)
 	_, err = scan.NewPortScan(taskId, mainTaskId, ipPortMap, nil)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	//域名任务只执行解析不进行子域名任务
 	_, err = scan.NewDomainScan(taskId, mainTaskId, domainMap, false, false)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 
@@ -200,21 +256,21 @@ func XPortScan(taskId, mainTaskId, configJSON string) (result string, err error)
 	// 解析任务参数
 	config := XScanConfig{}
 	if err = ParseConfig(configJSON, &config); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	// 执行任务
 	scan := NewXScan(config)
 	result, err = scan.Portscan(taskId, mainTaskId)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	// 启动指纹识别任务：
 	if config.IsFingerprint {
 		_, err = scan.NewFingerprintScan(taskId, mainTaskId)
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return FailedTask(err.Error()), err
 		}
 	}
@@ -232,21 +288,21 @@ func XDomainscan(taskId, mainTaskId, configJSON string) (result string, err erro
 	// 解析任务参数
 	config := XScanConfig{}
 	if err = ParseConfig(configJSON, &config); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	// 执行任务
 	scan := NewXScan(config)
 	result, err = scan.Domainscan(taskId, mainTaskId)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	// 启动指纹识别任务：
 	if config.IsFingerprint {
 		_, err = scan.NewFingerprintScan(taskId, mainTaskId)
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return FailedTask(err.Error()), err
 		}
 	}
@@ -263,21 +319,21 @@ func XFingerPrint(taskId, mainTaskId, configJSON string) (result string, err err
 	// 解析任务参数
 	config := XScanConfig{}
 	if err = ParseConfig(configJSON, &config); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	// 执行任务
 	scan := NewXScan(config)
 	result, err = scan.FingerPrint(taskId, mainTaskId)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	// 启动XrayPoc任务
 	if config.IsXrayPoc {
 		_, err = scan.NewXrayScan(taskId, mainTaskId)
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return FailedTask(err.Error()), err
 		}
 	}
@@ -286,7 +342,7 @@ func XFingerPrint(taskId, mainTaskId, configJSON string) (result string, err err
 	if config.IsNucleiPoc {
 		_, err = scan.NewNucleiScan(taskId, mainTaskId)
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return FailedTask(err.Error()), err
 		}
 	}
@@ -295,7 +351,16 @@ func XFingerPrint(taskId, mainTaskId, configJSON string) (result string, err err
 	if config.IsGobyPoc {
 		_, err = scan.NewGobyScan(taskId, mainTaskId)
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
+			return FailedTask(err.Error()), err
+		}
+	}
+
+	// 启动XrayPocV1任务
+	if config.IsXrayPocV1 {
+		_, err = scan.NewXrayPocV1Scan(taskId, mainTaskId)
+		if err != nil {
+			taskLog(taskId, mainTaskId).Error(err)
 			return FailedTask(err.Error()), err
 		}
 	}
@@ -312,14 +377,14 @@ func XXray(taskId, mainTaskId, configJSON string) (result string, err error) {
 	// 解析任务参数
 	config := XScanConfig{}
 	if err = ParseConfig(configJSON, &config); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	// 执行任务
 	scan := NewXScan(config)
 	result, err = scan.XrayScan(taskId, mainTaskId)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	return SucceedTask(result), nil
@@ -335,14 +400,37 @@ func XNuclei(taskId, mainTaskId, configJSON string) (result string, err error) {
 	// 解析任务参数
 	config := XScanConfig{}
 	if err = ParseConfig(configJSON, &config); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	// 执行任务
 	scan := NewXScan(config)
 	result, err = scan.NucleiScan(taskId, mainTaskId)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
+		return FailedTask(err.Error()), err
+	}
+	return SucceedTask(result), nil
+}
+
+// XXrayPocV1 xraypocv1扫描任务（进程内的xraypocv1引擎）
+func XXrayPocV1(taskId, mainTaskId, configJSON string) (result string, err error) {
+	// 检查任务状态
+	var ok bool
+	if ok, result, err = CheckTaskStatus(taskId); !ok {
+		return result, err
+	}
+	// 解析任务参数
+	config := XScanConfig{}
+	if err = ParseConfig(configJSON, &config); err != nil {
+		taskLog(taskId, mainTaskId).Error(err)
+		return FailedTask(err.Error()), err
+	}
+	// 执行任务
+	scan := NewXScan(config)
+	result, err = scan.XrayPocV1Scan(taskId, mainTaskId)
+	if err != nil {
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	return SucceedTask(result), nil
@@ -358,14 +446,14 @@ func XGoby(taskId, mainTaskId, configJSON string) (result string, err error) {
 	// 解析任务参数
 	config := XScanConfig{}
 	if err = ParseConfig(configJSON, &config); err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	// 执行任务
 	scan := NewXScan(config)
 	result, err = scan.GobyScan(taskId, mainTaskId)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 		return FailedTask(err.Error()), err
 	}
 	return SucceedTask(result), nil
@@ -386,7 +474,10 @@ func (x *XScan) Portscan(taskId string, mainTaskId string) (result string, err e
 		CmdBin:       conf.GlobalWorkerConfig().Portscan.Cmdbin,
 		IsIpLocation: true,
 		WorkspaceId:  x.Config.WorkspaceId,
+		TaskId:       taskId,
+		MainTaskId:   mainTaskId,
 	}
+	span := toolSpan(taskId, config.CmdBin)
 	if len(x.Config.IPPortString) > 0 {
 		for ip, ports := range x.Config.IPPortString {
 			if len(ports) <= 0 {
@@ -419,6 +510,7 @@ func (x *XScan) Portscan(taskId string, mainTaskId string) (result string, err e
 		}
 	}
 	swg.Wait()
+	span.End()
 	// 保存结果
 	resultArgs := comm.ScanResultArgs{
 		TaskID:     taskId,
@@ -426,9 +518,9 @@ func (x *XScan) Portscan(taskId string, mainTaskId string) (result string, err e
 		IPConfig:   &portscan.Config{OrgId: config.OrgId, WorkspaceId: config.WorkspaceId},
 		IPResult:   x.ResultIP.IPResult,
 	}
-	err = comm.CallXClient("SaveScanResult", &resultArgs, &result)
+	err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "SaveScanResult", &resultArgs, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 	}
 	return
 }
@@ -475,6 +567,7 @@ func (x *XScan) doDomainscan(swg *sizedwaitgroup.SizedWaitGroup, config domainsc
 	for k, v := range result.DomainResult {
 		x.ResultDomain.DomainResult[k] = v
 	}
+	x.ResultDomain.UrlResult = append(x.ResultDomain.UrlResult, result.UrlResult...)
 	x.ResultDomain.Unlock()
 }
 
@@ -482,6 +575,8 @@ func (x *XScan) doDomainscan(swg *sizedwaitgroup.SizedWaitGroup, config domainsc
 func (x *XScan) doXrayscan(swg *sizedwaitgroup.SizedWaitGroup, config pocscan.Config) {
 	defer swg.Done()
 
+	span := toolSpan(config.TaskId, "xray")
+	defer span.End()
 	xray := pocscan.NewXray(config)
 	xray.Do()
 	//合并结果
@@ -494,6 +589,8 @@ func (x *XScan) doXrayscan(swg *sizedwaitgroup.SizedWaitGroup, config pocscan.Co
 func (x *XScan) doNucleiScan(swg *sizedwaitgroup.SizedWaitGroup, config pocscan.Config) {
 	defer swg.Done()
 
+	span := toolSpan(config.TaskId, "nuclei")
+	defer span.End()
 	nuclei := pocscan.NewNuclei(config)
 	nuclei.Do()
 	//合并结果
@@ -506,6 +603,8 @@ func (x *XScan) doNucleiScan(swg *sizedwaitgroup.SizedWaitGroup, config pocscan.
 func (x *XScan) doGobyScan(swg *sizedwaitgroup.SizedWaitGroup, config pocscan.Config) {
 	defer swg.Done()
 
+	span := toolSpan(config.TaskId, "goby")
+	defer span.End()
 	goby := pocscan.NewGoby(config)
 	goby.Do()
 	//合并结果
@@ -514,6 +613,20 @@ func (x *XScan) doGobyScan(swg *sizedwaitgroup.SizedWaitGroup, config pocscan.Co
 	x.vulMutex.Unlock()
 }
 
+// doXrayPocV1Scan 调用一次xraypocv1
+func (x *XScan) doXrayPocV1Scan(swg *sizedwaitgroup.SizedWaitGroup, config pocscan.Config) {
+	defer swg.Done()
+
+	span := toolSpan(config.TaskId, "xraypocv1")
+	defer span.End()
+	xrayPocV1 := pocscan.NewXrayPocV1(config)
+	xrayPocV1.Do()
+	//合并结果
+	x.vulMutex.Lock()
+	x.ResultVul = append(x.ResultVul, xrayPocV1.Result...)
+	x.vulMutex.Unlock()
+}
+
 // OnlineAPISearch 执行fofa搜索任务
 func (x *XScan) OnlineAPISearch(taskId string, mainTaskId string) (result string, err error) {
 	conf.GlobalWorkerConfig().ReloadConfig()
@@ -525,6 +638,8 @@ func (x *XScan) OnlineAPISearch(taskId string, mainTaskId string) (result string
 		IsIgnoreCDN:        conf.GlobalWorkerConfig().Domainscan.IsIgnoreCDN,
 		IsIgnoreOutofChina: conf.GlobalWorkerConfig().Domainscan.IsIgnoreOutofChina,
 		WorkspaceId:        x.Config.WorkspaceId,
+		TaskId:             taskId,
+		MainTaskId:         mainTaskId,
 	}
 	if x.Config.IsFingerprint {
 		config.IsHttpx = conf.GlobalWorkerConfig().Fingerprint.IsHttpx
@@ -534,7 +649,7 @@ func (x *XScan) OnlineAPISearch(taskId string, mainTaskId string) (result string
 	}
 	//fofa任务支持两种模式：
 	//一种是关键词，需设置SearchByKeyWord为true，只支持fofa
-	//另一种是ip/domain，同时支持fofa、quake、hunter
+	//另一种是ip/domain，同时支持fofa、quake、hunter、shodan、censys、zoomeye、netlas及binaryedge
 	if len(x.Config.OnlineAPIKeyword) > 0 {
 		config.SearchByKeyWord = true
 		config.Target = x.Config.OnlineAPIKeyword
@@ -543,14 +658,8 @@ func (x *XScan) OnlineAPISearch(taskId string, mainTaskId string) (result string
 	} else if len(x.Config.OnlineAPITarget) > 0 {
 		config.Target = x.Config.OnlineAPITarget
 	}
-	if x.Config.IsFofa {
-		x.ResultIP, x.ResultDomain, result, err = doOnlineAPIAndSave(taskId, mainTaskId, "fofa", config)
-	}
-	if x.Config.IsQuake {
-		x.ResultIP, x.ResultDomain, result, err = doOnlineAPIAndSave(taskId, mainTaskId, "quake", config)
-	}
-	if x.Config.IsHunter {
-		x.ResultIP, x.ResultDomain, result, err = doOnlineAPIAndSave(taskId, mainTaskId, "hunter", config)
+	for _, apiName := range x.Config.OnlineAPIs() {
+		x.ResultIP, x.ResultDomain, result, err = doOnlineAPIAndSave(taskId, mainTaskId, apiName, config)
 	}
 	return
 }
@@ -574,7 +683,10 @@ func (x *XScan) Domainscan(taskId string, mainTaskId string) (result string, err
 		IsIPPortScan:       conf.GlobalWorkerConfig().Domainscan.IsPortScan,
 
 		WorkspaceId: x.Config.WorkspaceId,
+		TaskId:      taskId,
+		MainTaskId:  mainTaskId,
 	}
+	span := toolSpan(taskId, "domainscan")
 	for domain := range x.Config.Domain {
 		runConfig := config
 		runConfig.Target = domain
@@ -582,6 +694,7 @@ func (x *XScan) Domainscan(taskId string, mainTaskId string) (result string, err
 		go x.doDomainscan(&swg, runConfig)
 	}
 	swg.Wait()
+	span.End()
 	// 如果有端口扫描的选项
 	if config.IsIPPortScan || config.IsIPSubnetPortScan {
 		doPortScanByDomainscan(taskId, mainTaskId, config, x.ResultDomain)
@@ -592,9 +705,10 @@ func (x *XScan) Domainscan(taskId string, mainTaskId string) (result string, err
 		MainTaskId:   mainTaskId,
 		DomainConfig: &domainscan.Config{OrgId: config.OrgId, WorkspaceId: x.Config.WorkspaceId},
 		DomainResult: x.ResultDomain.DomainResult,
+		UrlResult:    x.ResultDomain.UrlResult,
 	}
-	if err = comm.CallXClient("SaveScanResult", &resultArgs, &result); err != nil {
-		logging.RuntimeLog.Error(err)
+	if err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "SaveScanResult", &resultArgs, &result); err != nil {
+		taskLog(taskId, mainTaskId).Error(err)
 	}
 	return
 }
@@ -609,6 +723,9 @@ func (x *XScan) NewPortScan(taskId, mainTaskId string, ipPortMap []map[string][]
 		IsNucleiPoc:   x.Config.IsNucleiPoc,
 		NucleiPocFile: x.Config.NucleiPocFile,
 		IsGobyPoc:     x.Config.IsGobyPoc,
+		IsXrayPocV1:   x.Config.IsXrayPocV1,
+		XrayPocV1File: x.Config.XrayPocV1File,
+		IsPocMap:      x.Config.IsPocMap,
 		WorkspaceId:   x.Config.WorkspaceId,
 	}
 	for _, t := range ipPortMap {
@@ -616,7 +733,7 @@ func (x *XScan) NewPortScan(taskId, mainTaskId string, ipPortMap []map[string][]
 		configRun.IPPort = t
 		result, err = sendTask(taskId, mainTaskId, configRun, "xportscan")
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return
 		}
 	}
@@ -625,7 +742,7 @@ func (x *XScan) NewPortScan(taskId, mainTaskId string, ipPortMap []map[string][]
 		configRun.IPPortString = t
 		result, err = sendTask(taskId, mainTaskId, configRun, "xportscan")
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return
 		}
 	}
@@ -636,12 +753,12 @@ func (x *XScan) NewPortScan(taskId, mainTaskId string, ipPortMap []map[string][]
 func (x *XScan) NewICPQuery(taskId, mainTaskId string, target string) (result string, err error) {
 	config := onlineapi.ICPQueryConfig{Target: target}
 	if err != nil {
-		logging.RuntimeLog.Errorf("start icpquery fail:%s", err.Error())
+		taskLog(taskId, mainTaskId).Errorf("start icpquery fail:%s", err.Error())
 		return "", err
 	}
 	result, err = sendTask(taskId, mainTaskId, config, "icpquery")
 	if err != nil {
-		logging.RuntimeLog.Errorf("start icpquery fail:%s", err.Error())
+		taskLog(taskId, mainTaskId).Errorf("start icpquery fail:%s", err.Error())
 		return "", err
 	}
 	return result, nil
@@ -651,12 +768,12 @@ func (x *XScan) NewICPQuery(taskId, mainTaskId string, target string) (result st
 func (x *XScan) NewWhoisQuery(taskId, mainTaskId string, target string) (result string, err error) {
 	config := onlineapi.WhoisQueryConfig{Target: target}
 	if err != nil {
-		logging.RuntimeLog.Errorf("start whoisquery fail:%s", err.Error())
+		taskLog(taskId, mainTaskId).Errorf("start whoisquery fail:%s", err.Error())
 		return "", err
 	}
 	result, err = sendTask(taskId, mainTaskId, config, "whoisquery")
 	if err != nil {
-		logging.RuntimeLog.Errorf("start whoisquery fail:%s", err.Error())
+		taskLog(taskId, mainTaskId).Errorf("start whoisquery fail:%s", err.Error())
 		return "", err
 	}
 	return result, nil
@@ -674,6 +791,9 @@ func (x *XScan) NewDomainScan(taskId, mainTaskId string, domainMap []map[string]
 		IsNucleiPoc:       x.Config.IsNucleiPoc,
 		NucleiPocFile:     x.Config.NucleiPocFile,
 		IsGobyPoc:         x.Config.IsGobyPoc,
+		IsXrayPocV1:       x.Config.IsXrayPocV1,
+		XrayPocV1File:     x.Config.XrayPocV1File,
+		IsPocMap:          x.Config.IsPocMap,
 		WorkspaceId:       x.Config.WorkspaceId,
 	}
 	for _, t := range domainMap {
@@ -681,7 +801,7 @@ func (x *XScan) NewDomainScan(taskId, mainTaskId string, domainMap []map[string]
 		configRun.Domain = t
 		result, err = sendTask(taskId, mainTaskId, configRun, "xdomainscan")
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return
 		}
 	}
@@ -718,6 +838,9 @@ func (x *XScan) NewFingerprintScan(taskId, mainTaskId string) (result string, er
 		IsNucleiPoc:   x.Config.IsNucleiPoc,
 		NucleiPocFile: x.Config.NucleiPocFile,
 		IsGobyPoc:     x.Config.IsGobyPoc,
+		IsXrayPocV1:   x.Config.IsXrayPocV1,
+		XrayPocV1File: x.Config.XrayPocV1File,
+		IsPocMap:      x.Config.IsPocMap,
 		WorkspaceId:   x.Config.WorkspaceId,
 	}
 	//拆分子任务
@@ -728,7 +851,7 @@ func (x *XScan) NewFingerprintScan(taskId, mainTaskId string) (result string, er
 		newConfig.IPPort = t
 		result, err = sendTask(taskId, mainTaskId, newConfig, "xfingerprint")
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return
 		}
 	}
@@ -737,7 +860,7 @@ func (x *XScan) NewFingerprintScan(taskId, mainTaskId string) (result string, er
 		newConfig.Domain = t
 		result, err = sendTask(taskId, mainTaskId, newConfig, "xfingerprint")
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return
 		}
 	}
@@ -746,13 +869,21 @@ func (x *XScan) NewFingerprintScan(taskId, mainTaskId string) (result string, er
 
 // NewNucleiScan 生成Nuclei任务
 func (x *XScan) NewNucleiScan(taskId, mainTaskId string) (result string, err error) {
+	if x.Config.IsPocMap {
+		return x.newPocMapScan(taskId, mainTaskId, "xnuclei", func(s pocscan.PocSet) (XScanConfig, bool) {
+			if len(s.Nuclei) == 0 && len(s.NucleiTags) == 0 {
+				return XScanConfig{}, false
+			}
+			return XScanConfig{IsNucleiPoc: true, NucleiPocFile: strings.Join(s.Nuclei, ","), NucleiTags: s.NucleiTags}, true
+		})
+	}
 	//拆分子任务
 	ipTarget, domainTarget := MakeSubTaskTarget(x.ResultIP, x.ResultDomain)
 	for _, t := range ipTarget {
 		newConfig := XScanConfig{IPPort: t, IsNucleiPoc: true, NucleiPocFile: x.Config.NucleiPocFile, WorkspaceId: x.Config.WorkspaceId}
 		result, err = sendTask(taskId, mainTaskId, newConfig, "xnuclei")
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return
 		}
 	}
@@ -760,7 +891,7 @@ func (x *XScan) NewNucleiScan(taskId, mainTaskId string) (result string, err err
 		newConfig := XScanConfig{Domain: t, IsNucleiPoc: true, NucleiPocFile: x.Config.NucleiPocFile, WorkspaceId: x.Config.WorkspaceId}
 		result, err = sendTask(taskId, mainTaskId, newConfig, "xnuclei")
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return
 		}
 	}
@@ -775,7 +906,7 @@ func (x *XScan) NewGobyScan(taskId, mainTaskId string) (result string, err error
 		newConfig := XScanConfig{IPPort: t, IsGobyPoc: true, WorkspaceId: x.Config.WorkspaceId}
 		result, err = sendTask(taskId, mainTaskId, newConfig, "xgoby")
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return
 		}
 	}
@@ -783,7 +914,7 @@ func (x *XScan) NewGobyScan(taskId, mainTaskId string) (result string, err error
 		newConfig := XScanConfig{Domain: t, IsGobyPoc: true, WorkspaceId: x.Config.WorkspaceId}
 		result, err = sendTask(taskId, mainTaskId, newConfig, "xgoby")
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return
 		}
 	}
@@ -793,8 +924,8 @@ func (x *XScan) NewGobyScan(taskId, mainTaskId string) (result string, err error
 // NucleiScan 调用执行Nuclei扫描任务
 func (x *XScan) NucleiScan(taskId string, mainTaskId string) (result string, err error) {
 	// 生成扫描参数
-	config := pocscan.Config{PocFile: x.Config.NucleiPocFile, WorkspaceId: x.Config.WorkspaceId}
-	if x.Config.NucleiPocFile == "" {
+	config := pocscan.Config{PocFile: x.Config.NucleiPocFile, Tags: x.Config.NucleiTags, WorkspaceId: x.Config.WorkspaceId, TaskId: taskId, MainTaskId: mainTaskId}
+	if x.Config.NucleiPocFile == "" && len(x.Config.NucleiTags) == 0 {
 		config.PocFile = "*"
 	}
 	swg := sizedwaitgroup.New(xrayscanMaxThreadNum[conf.WorkerPerformanceMode])
@@ -823,9 +954,9 @@ func (x *XScan) NucleiScan(taskId string, mainTaskId string) (result string, err
 		MainTaskId:          mainTaskId,
 		VulnerabilityResult: x.ResultVul,
 	}
-	err = comm.CallXClient("SaveVulnerabilityResult", &resultArgs, &result)
+	err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "SaveVulnerabilityResult", &resultArgs, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 	}
 	return
 }
@@ -833,7 +964,7 @@ func (x *XScan) NucleiScan(taskId string, mainTaskId string) (result string, err
 // GobyScan 调用执行goby扫描任务
 func (x *XScan) GobyScan(taskId string, mainTaskId string) (result string, err error) {
 	// 生成扫描参数
-	config := pocscan.Config{WorkspaceId: x.Config.WorkspaceId}
+	config := pocscan.Config{WorkspaceId: x.Config.WorkspaceId, TaskId: taskId, MainTaskId: mainTaskId}
 	// goby支持通过,分隔的多个目标
 	swg := sizedwaitgroup.New(xrayscanMaxThreadNum[conf.WorkerPerformanceMode])
 	if len(x.Config.IPPort) > 0 {
@@ -865,22 +996,31 @@ func (x *XScan) GobyScan(taskId string, mainTaskId string) (result string, err e
 		MainTaskId:          mainTaskId,
 		VulnerabilityResult: x.ResultVul,
 	}
-	err = comm.CallXClient("SaveVulnerabilityResult", &resultArgs, &result)
+	err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "SaveVulnerabilityResult", &resultArgs, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
 	}
 	return
 }
 
 // NewXrayScan 生成xraypoc任务
 func (x *XScan) NewXrayScan(taskId, mainTaskId string) (result string, err error) {
+	if x.Config.IsPocMap {
+		// 对应关系中的xray为内置的poc
+		return x.newPocMapScan(taskId, mainTaskId, "xxray", func(s pocscan.PocSet) (XScanConfig, bool) {
+			if len(s.Xray) == 0 {
+				return XScanConfig{}, false
+			}
+			return XScanConfig{IsXrayPoc: true, XrayPocFile: "default|" + strings.Join(s.Xray, ",")}, true
+		})
+	}
 	//拆分子任务
 	ipTarget, domainTarget := MakeSubTaskTarget(x.ResultIP, x.ResultDomain)
 	for _, t := range ipTarget {
 		newConfig := XScanConfig{IPPort: t, IsXrayPoc: true, XrayPocFile: x.Config.XrayPocFile, WorkspaceId: x.Config.WorkspaceId}
 		result, err = sendTask(taskId, mainTaskId, newConfig, "xxray")
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return
 		}
 	}
@@ -888,7 +1028,7 @@ func (x *XScan) NewXrayScan(taskId, mainTaskId string) (result string, err error
 		newConfig := XScanConfig{Domain: t, IsXrayPoc: true, XrayPocFile: x.Config.XrayPocFile, WorkspaceId: x.Config.WorkspaceId}
 		result, err = sendTask(taskId, mainTaskId, newConfig, "xxray")
 		if err != nil {
-			logging.RuntimeLog.Error(err)
+			taskLog(taskId, mainTaskId).Error(err)
 			return
 		}
 	}
@@ -898,7 +1038,7 @@ func (x *XScan) NewXrayScan(taskId, mainTaskId string) (result string, err error
 // XrayScan 调用执行xray扫描任务
 func (x *XScan) XrayScan(taskId string, mainTaskId string) (result string, err error) {
 	// 生成扫描参数
-	config := pocscan.Config{PocFile: x.Config.XrayPocFile, WorkspaceId: x.Config.WorkspaceId}
+	config := pocscan.Config{PocFile: x.Config.XrayPocFile, WorkspaceId: x.Config.WorkspaceId, TaskId: taskId, MainTaskId: mainTaskId}
 	if x.Config.XrayPocFile == "" {
 		config.PocFile = "*"
 	}
@@ -928,9 +1068,164 @@ func (x *XScan) XrayScan(taskId string, mainTaskId string) (result string, err e
 		MainTaskId:          mainTaskId,
 		VulnerabilityResult: x.ResultVul,
 	}
-	err = comm.CallXClient("SaveVulnerabilityResult", &resultArgs, &result)
+	err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "SaveVulnerabilityResult", &resultArgs, &result)
+	if err != nil {
+		taskLog(taskId, mainTaskId).Error(err)
+	}
+	return
+}
+
+// NewXrayPocV1Scan 生成xraypocv1任务
+func (x *XScan) NewXrayPocV1Scan(taskId, mainTaskId string) (result string, err error) {
+	if x.Config.IsPocMap {
+		return x.newPocMapScan(taskId, mainTaskId, "xxraypocv1", func(s pocscan.PocSet) (XScanConfig, bool) {
+			if len(s.XrayPocV1) == 0 {
+				return XScanConfig{}, false
+			}
+			return XScanConfig{IsXrayPocV1: true, XrayPocV1File: strings.Join(s.XrayPocV1, ",")}, true
+		})
+	}
+	//拆分子任务
+	ipTarget, domainTarget := MakeSubTaskTarget(x.ResultIP, x.ResultDomain)
+	for _, t := range ipTarget {
+		newConfig := XScanConfig{IPPort: t, IsXrayPocV1: true, XrayPocV1File: x.Config.XrayPocV1File, WorkspaceId: x.Config.WorkspaceId}
+		result, err = sendTask(taskId, mainTaskId, newConfig, "xxraypocv1")
+		if err != nil {
+			taskLog(taskId, mainTaskId).Error(err)
+			return
+		}
+	}
+	for _, t := range domainTarget {
+		newConfig := XScanConfig{Domain: t, IsXrayPocV1: true, XrayPocV1File: x.Config.XrayPocV1File, WorkspaceId: x.Config.WorkspaceId}
+		result, err = sendTask(taskId, mainTaskId, newConfig, "xxraypocv1")
+		if err != nil {
+			taskLog(taskId, mainTaskId).Error(err)
+			return
+		}
+	}
+	return
+}
+
+// XrayPocV1Scan 调用执行xraypocv1扫描任务
+func (x *XScan) XrayPocV1Scan(taskId string, mainTaskId string) (result string, err error) {
+	// 生成扫描参数，PocFile为空时使用全部的poc
+	config := pocscan.Config{PocFile: x.Config.XrayPocV1File, WorkspaceId: x.Config.WorkspaceId, TaskId: taskId, MainTaskId: mainTaskId}
+	swg := sizedwaitgroup.New(xrayscanMaxThreadNum[conf.WorkerPerformanceMode])
+	if len(x.Config.IPPort) > 0 {
+		for ip, ports := range x.Config.IPPort {
+			for _, port := range ports {
+				runConfig := config
+				runConfig.Target = utils.FormatHostUrl("", ip, port)
+				swg.Add()
+				go x.doXrayPocV1Scan(&swg, runConfig)
+			}
+		}
+	}
+	if len(x.Config.Domain) > 0 {
+		for domain := range x.Config.Domain {
+			runConfig := config
+			runConfig.Target = domain
+			swg.Add()
+			go x.doXrayPocV1Scan(&swg, runConfig)
+		}
+	}
+	swg.Wait()
+	// 保存结果
+	resultArgs := comm.ScanResultArgs{
+		TaskID:              taskId,
+		MainTaskId:          mainTaskId,
+		VulnerabilityResult: x.ResultVul,
+	}
+	err = comm.CallXClientWithContext(tracing.TaskContext(taskId), "SaveVulnerabilityResult", &resultArgs, &result)
 	if err != nil {
-		logging.RuntimeLog.Error(err)
+		taskLog(taskId, mainTaskId).Error(err)
+	}
+	return
+}
+
+// pocMapTarget 使用相同POC集合的目标
+type pocMapTarget struct {
+	config       XScanConfig
+	resultIP     *portscan.Result
+	resultDomain *domainscan.Result
+}
+
+// newPocMapScan 根据指纹与POC的对应关系生成poc任务：按目标匹配的POC集合分组，相同POC集合的目标合并后再拆分子任务；
+// pocConfig返回POC集合对应的任务参数，返回false表示该POC集合中没有当前扫描方式的POC，不对目标进行扫描
+func (x *XScan) newPocMapScan(taskId, mainTaskId, taskName string, pocConfig func(pocscan.PocSet) (XScanConfig, bool)) (result string, err error) {
+	pocMap, err := pocscan.LoadFingerprintPocMap()
+	if err != nil {
+		return "", err
+	}
+	targets := make(map[string]*pocMapTarget)
+	getTarget := func(fingerprints []string) *pocMapTarget {
+		config, ok := pocConfig(pocMap.Match(fingerprints))
+		if !ok {
+			return nil
+		}
+		key := fmt.Sprintf("%s|%s|%s|%s", config.XrayPocFile, config.NucleiPocFile, strings.Join(config.NucleiTags, ","), config.XrayPocV1File)
+		if _, exist := targets[key]; !exist {
+			targets[key] = &pocMapTarget{
+				config:       config,
+				resultIP:     &portscan.Result{IPResult: make(map[string]*portscan.IPResult)},
+				resultDomain: &domainscan.Result{DomainResult: make(map[string]*domainscan.DomainResult)},
+			}
+		}
+		return targets[key]
+	}
+	if x.ResultIP != nil {
+		for ip, ipr := range x.ResultIP.IPResult {
+			for port, pr := range ipr.Ports {
+				var fingerprints []string
+				for _, attr := range pr.PortAttrs {
+					fingerprints = append(fingerprints, pocscan.ParseFingerprintAttr(attr.Tag, attr.Content)...)
+				}
+				t := getTarget(fingerprints)
+				if t == nil {
+					continue
+				}
+				if !t.resultIP.HasIP(ip) {
+					t.resultIP.SetIP(ip)
+				}
+				t.resultIP.SetPort(ip, port)
+			}
+		}
+	}
+	if x.ResultDomain != nil {
+		for domain, dr := range x.ResultDomain.DomainResult {
+			var fingerprints []string
+			for _, attr := range dr.DomainAttrs {
+				fingerprints = append(fingerprints, pocscan.ParseFingerprintAttr(attr.Tag, attr.Content)...)
+			}
+			t := getTarget(fingerprints)
+			if t == nil {
+				continue
+			}
+			t.resultDomain.SetDomain(domain)
+		}
+	}
+	for _, t := range targets {
+		ipTarget, domainTarget := MakeSubTaskTarget(t.resultIP, t.resultDomain)
+		for _, ipPort := range ipTarget {
+			newConfig := t.config
+			newConfig.IPPort = ipPort
+			newConfig.WorkspaceId = x.Config.WorkspaceId
+			result, err = sendTask(taskId, mainTaskId, newConfig, taskName)
+			if err != nil {
+				taskLog(taskId, mainTaskId).Error(err)
+				return
+			}
+		}
+		for _, domain := range domainTarget {
+			newConfig := t.config
+			newConfig.Domain = domain
+			newConfig.WorkspaceId = x.Config.WorkspaceId
+			result, err = sendTask(taskId, mainTaskId, newConfig, taskName)
+			if err != nil {
+				taskLog(taskId, mainTaskId).Error(err)
+				return
+			}
+		}
 	}
 	return
 }
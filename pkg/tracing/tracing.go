@@ -0,0 +1,160 @@
+package tracing
+
+import (
+	"context"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"sync"
+)
+
+const tracerName = "github.com/hanc00l/nemo_go"
+
+// taskContext 任务ID对应的trace上下文，用于同一任务中的各个环节关联到同一个trace
+var taskContext sync.Map
+
+func init() {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+}
+
+// Init 根据配置启用OTLP/HTTP导出；未启用时使用默认的空实现，返回的shutdown用于退出时导出剩余的span
+func Init(serviceName string, config conf.Tracing) (shutdown func(context.Context) error, err error) {
+	shutdown = func(context.Context) error { return nil }
+	if !config.Enabled {
+		return
+	}
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Endpoint)}
+	if config.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		return
+	}
+	sampler := sdktrace.AlwaysSample()
+	if config.SampleRatio > 0 && config.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(config.SampleRatio)
+	}
+	tp := newTracerProvider(serviceName, sdktrace.WithBatcher(exporter), sdktrace.WithSampler(sdktrace.ParentBased(sampler)))
+	return tp.Shutdown, nil
+}
+
+// InitWithExporter 使用指定的exporter（如测试用的tracetest.InMemoryExporter）同步导出全部span
+func InitWithExporter(serviceName string, exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	return newTracerProvider(serviceName, sdktrace.WithSyncer(exporter), sdktrace.WithSampler(sdktrace.AlwaysSample()))
+}
+
+func newTracerProvider(serviceName string, options ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	options = append(options, sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))))
+	tp := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(tp)
+	return tp
+}
+
+// Start 创建一个span
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartWithKind 创建一个指定类型（如producer、consumer、client、server）的span
+func StartWithKind(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// End 结束span，err不为空时记录错误
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// SetTaskContext 保存任务的trace上下文
+func SetTaskContext(taskId string, ctx context.Context) {
+	if taskId == "" || ctx == nil {
+		return
+	}
+	taskContext.Store(taskId, ctx)
+}
+
+// TaskContext 获取任务的trace上下文，不存在时返回context.Background()
+func TaskContext(taskId string) context.Context {
+	if ctx, ok := taskContext.Load(taskId); ok {
+		return ctx.(context.Context)
+	}
+	return context.Background()
+}
+
+// DeleteTaskContext 删除任务的trace上下文
+func DeleteTaskContext(taskId string) {
+	taskContext.Delete(taskId)
+}
+
+// HeadersCarrier 任务消息头（machinery的tasks.Headers）的trace上下文载体
+type HeadersCarrier map[string]interface{}
+
+func (c HeadersCarrier) Get(key string) string {
+	if v, ok := c[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func (c HeadersCarrier) Set(key string, value string) {
+	c[key] = value
+}
+
+func (c HeadersCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Inject 将trace上下文写入到载体（任务消息头或RPC的metadata）中
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+// Extract 从载体中读取trace上下文
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// InjectMap 将trace上下文写入到map（RPC的metadata）中，ctx中没有span时返回nil
+func InjectMap(ctx context.Context) map[string]string {
+	if !HasSpan(ctx) {
+		return nil
+	}
+	m := make(map[string]string)
+	Inject(ctx, propagation.MapCarrier(m))
+	return m
+}
+
+// ExtractMap 从map（RPC的metadata）中读取trace上下文
+func ExtractMap(ctx context.Context, m map[string]string) context.Context {
+	return Extract(ctx, propagation.MapCarrier(m))
+}
+
+// HasSpan ctx中是否有有效的span
+func HasSpan(ctx context.Context) bool {
+	return ctx != nil && trace.SpanContextFromContext(ctx).IsValid()
+}
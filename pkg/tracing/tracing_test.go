@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+// TestPropagation 模拟maintask -> 任务消息头 -> worker -> RPC metadata -> server保存结果的链路
+func TestPropagation(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := InitWithExporter("nemo-test", exporter)
+	defer tp.Shutdown(context.Background())
+
+	// server：maintask及发送任务
+	mainCtx, mainSpan := Start(context.Background(), "maintask.portscan")
+	SetTaskContext("main-1", mainCtx)
+	sendCtx, sendSpan := StartWithKind(TaskContext("main-1"), "send.portscan", trace.SpanKindProducer)
+	headers := HeadersCarrier{}
+	Inject(sendCtx, headers)
+	sendSpan.End()
+	mainSpan.End()
+	// 任务消息头经过JSON序列化后传递到worker
+	content, _ := json.Marshal(headers)
+	var received map[string]interface{}
+	if err := json.Unmarshal(content, &received); err != nil {
+		t.Fatal(err)
+	}
+
+	// worker：执行任务并通过RPC保存结果
+	taskCtx, taskSpan := StartWithKind(Extract(context.Background(), HeadersCarrier(received)), "task.portscan", trace.SpanKindConsumer)
+	SetTaskContext("task-1", taskCtx)
+	rpcCtx, rpcSpan := StartWithKind(TaskContext("task-1"), "rpc.SaveScanResult", trace.SpanKindClient)
+	metadata := InjectMap(rpcCtx)
+
+	// server：从RPC metadata中读取trace上下文并保存结果
+	serverCtx, serverSpan := StartWithKind(ExtractMap(context.Background(), metadata), "rpc.SaveScanResult", trace.SpanKindServer)
+	_, dbSpan := Start(serverCtx, "db.SaveIPResult")
+	End(dbSpan, errors.New("save fail"))
+	serverSpan.End()
+	rpcSpan.End()
+	taskSpan.End()
+	DeleteTaskContext("task-1")
+	DeleteTaskContext("main-1")
+
+	spans := exporter.GetSpans()
+	if len(spans) != 6 {
+		t.Fatalf("expected 6 spans, got %d", len(spans))
+	}
+	traceId := mainSpan.SpanContext().TraceID()
+	parent := make(map[string]string)
+	for _, s := range spans {
+		if s.SpanContext.TraceID() != traceId {
+			t.Errorf("span %s not in the maintask trace", s.Name)
+		}
+		parent[s.Name+"/"+s.SpanKind.String()] = s.Parent.SpanID().String()
+	}
+	if parent["task.portscan/consumer"] != sendSpan.SpanContext().SpanID().String() {
+		t.Error("task span should be child of send span")
+	}
+	if parent["rpc.SaveScanResult/server"] != rpcSpan.SpanContext().SpanID().String() {
+		t.Error("rpc server span should be child of rpc client span")
+	}
+	for _, s := range spans {
+		if s.Name == "db.SaveIPResult" && s.Status.Code != codes.Error {
+			t.Error("db span should record error")
+		}
+	}
+	if HasSpan(TaskContext("task-1")) {
+		t.Error("task context should be deleted")
+	}
+}
+
+func TestInjectMapWithoutSpan(t *testing.T) {
+	if m := InjectMap(context.Background()); m != nil {
+		t.Errorf("expected nil metadata, got %v", m)
+	}
+	if HasSpan(ExtractMap(context.Background(), nil)) {
+		t.Error("expected no span")
+	}
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"archive/zip"
+	"bytes"
 	"fmt"
 	"github.com/disintegration/imaging"
 	"github.com/hanc00l/nemo_go/pkg/logging"
@@ -149,6 +150,20 @@ func ReSizePicture(srcFile, dstFile string, width, height int) bool {
 	return true
 }
 
+// ReSizePictureContent 对图片内容尺寸缩放，返回png格式的图片内容
+func ReSizePictureContent(content []byte, width, height int) ([]byte, error) {
+	src, err := imaging.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	dst := imaging.Resize(src, width, height, imaging.CatmullRom)
+	var buf bytes.Buffer
+	if err = imaging.Encode(&buf, dst, imaging.PNG); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 type BinShortName string
 
 const (
@@ -123,3 +123,18 @@ func MergeMapStringInt(dstMap map[string]int, srcMap map[string]int) {
 	}
 	return
 }
+
+// MergeCommaString 合并以逗号分隔的字符串并去重排序
+func MergeCommaString(values ...string) string {
+	setMap := make(map[string]struct{})
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				setMap[s] = struct{}{}
+			}
+		}
+	}
+	list := SetToSlice(setMap)
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -162,6 +163,11 @@ func Socks5Dailer(forward *net.Dialer) (proxy.Dialer, error) {
 
 // GetProtocol 检测URL协议
 func GetProtocol(host string, Timeout int64) (protocol string) {
+	return GetProtocolByDialer(host, Timeout, nil)
+}
+
+// GetProtocolByDialer 通过指定的dialer（如代理）检测URL协议，dialer为nil时直接连接
+func GetProtocolByDialer(host string, Timeout int64, dialer proxy.Dialer) (protocol string) {
 	protocol = "http"
 	//如果端口是80或443,跳过Protocol判断
 	//if strings.HasSuffix(host, ":80") || !strings.Contains(host, ":") {
@@ -172,7 +178,13 @@ func GetProtocol(host string, Timeout int64) (protocol string) {
 		return
 	}
 
-	socksconn, err := WrapperTcpWithTimeout("tcp", host, time.Duration(Timeout)*time.Second)
+	var socksconn net.Conn
+	var err error
+	if dialer == nil {
+		socksconn, err = WrapperTcpWithTimeout("tcp", host, time.Duration(Timeout)*time.Second)
+	} else {
+		socksconn, err = dialWithTimeout(dialer, "tcp", host, time.Duration(Timeout)*time.Second)
+	}
 	if err != nil {
 		return
 	}
@@ -195,6 +207,16 @@ func GetProtocol(host string, Timeout int64) (protocol string) {
 	return protocol
 }
 
+// dialWithTimeout 使用dialer建立连接，dialer支持context时使用超时
+func dialWithTimeout(dialer proxy.Dialer, network, address string, timeout time.Duration) (net.Conn, error) {
+	if d, ok := dialer.(proxy.ContextDialer); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return d.DialContext(ctx, network, address)
+	}
+	return dialer.Dial(network, address)
+}
+
 // ParseHostUrl ipv4/v6地址格式识别的通用函数，用于识别ipv4,ipv4:port,ipv6,[ipv6],[ipv6]:port的形式
 func ParseHostUrl(u string) (isIpv6 bool, ip string, port int) {
 	// ipv4
@@ -0,0 +1,257 @@
+package weakpass
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveStub 启动一个本地的TCP服务，每个连接由handler处理
+func serveStub(t *testing.T, service string, handler func(conn net.Conn)) Target {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handler(conn)
+			}()
+		}
+	}()
+	port := ln.Addr().(*net.TCPAddr).Port
+	return Target{Service: service, Host: "127.0.0.1", Port: port}
+}
+
+// checkCredentials 验证一组凭据，返回各自的结果
+func checkCredentials(t *testing.T, checker Checker, target Target, creds map[Credential]bool) {
+	for cred, expected := range creds {
+		ok, err := checker.Check(context.Background(), target, cred, 3*time.Second)
+		if err != nil {
+			t.Errorf("%s check %v fail:%v", target, cred, err)
+			continue
+		}
+		if ok != expected {
+			t.Errorf("%s check %v should be %v", target, cred, expected)
+		}
+	}
+}
+
+func TestFtpChecker(t *testing.T) {
+	target := serveStub(t, "ftp", func(conn net.Conn) {
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 stub ftp")
+		var user string
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd, arg, _ := strings.Cut(line, " ")
+			switch cmd {
+			case "USER":
+				user = arg
+				tp.PrintfLine("331 password required")
+			case "PASS":
+				if (user == "ftp" && arg == "ftp123") || user == "anonymous" {
+					tp.PrintfLine("230 login successful")
+				} else {
+					tp.PrintfLine("530 login incorrect")
+				}
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				return
+			}
+		}
+	})
+	checkCredentials(t, ftpChecker{}, target, map[Credential]bool{
+		{"ftp", "ftp123"}: true,
+		{"ftp", "123456"}: false,
+		{"", ""}:          true,
+	})
+}
+
+func TestRedisChecker(t *testing.T) {
+	target := serveStub(t, "redis", func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		var args []string
+		line, _ := reader.ReadString('\n')
+		n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		for i := 0; i < n; i++ {
+			reader.ReadString('\n')
+			arg, _ := reader.ReadString('\n')
+			args = append(args, strings.TrimSpace(arg))
+		}
+		switch {
+		case args[0] == "PING":
+			io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+		case args[0] == "AUTH" && args[len(args)-1] == "foobared":
+			io.WriteString(conn, "+OK\r\n")
+		default:
+			io.WriteString(conn, "-WRONGPASS invalid username-password pair or user is disabled.\r\n")
+		}
+	})
+	checkCredentials(t, redisChecker{}, target, map[Credential]bool{
+		{"", ""}:              false,
+		{"", "foobared"}:      true,
+		{"default", "123456"}: false,
+		{"admin", "foobared"}: true,
+	})
+}
+
+func TestSshChecker(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if c.User() == "root" && string(password) == "toor" {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("password rejected for %s", c.User())
+		},
+	}
+	config.AddHostKey(signer)
+	target := serveStub(t, "ssh", func(conn net.Conn) {
+		_, chans, reqs, err := ssh.NewServerConn(conn, config)
+		if err != nil {
+			return
+		}
+		go ssh.DiscardRequests(reqs)
+		for ch := range chans {
+			ch.Reject(ssh.Prohibited, "stub")
+		}
+	})
+	checkCredentials(t, sshChecker{}, target, map[Credential]bool{
+		{"root", "toor"}:   true,
+		{"root", "123456"}: false,
+		{"admin", "toor"}:  false,
+	})
+}
+
+func TestHttpChecker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/manager/html" {
+			http.NotFound(w, r)
+			return
+		}
+		if user, pass, ok := r.BasicAuth(); ok && user == "tomcat" && pass == "s3cret" {
+			io.WriteString(w, "Tomcat Web Application Manager")
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="Tomcat Manager Application"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	panel := HTTPPanel{Name: "tomcat-manager"}
+	panel.Probe.Status = http.StatusUnauthorized
+	panel.Probe.Keyword = "Tomcat Manager"
+	panel.Login.Method = "basic"
+	panel.Login.Path = "/manager/html"
+	panel.Login.Status = []int{http.StatusOK}
+	checker := &httpChecker{Panels: []HTTPPanel{panel}}
+
+	addr := server.Listener.Addr().(*net.TCPAddr)
+	targets := checker.Probe(context.Background(), Target{Service: "http", Host: "127.0.0.1", Port: addr.Port}, 3*time.Second)
+	if len(targets) != 1 || targets[0].Panel != "tomcat-manager" || targets[0].DictName() != "tomcat-manager" {
+		t.Fatalf("tomcat manager should be probed:%v", targets)
+	}
+	checkCredentials(t, checker, targets[0], map[Credential]bool{
+		{"tomcat", "s3cret"}: true,
+		{"tomcat", "tomcat"}: false,
+	})
+}
+
+func TestPostgresChecker(t *testing.T) {
+	salt := []byte{1, 2, 3, 4}
+	target := serveStub(t, "postgresql", func(conn net.Conn) {
+		header := make([]byte, 4)
+		io.ReadFull(conn, header)
+		startup := make([]byte, binary.BigEndian.Uint32(header)-4)
+		io.ReadFull(conn, startup)
+		params := strings.Split(string(startup[4:]), "\x00")
+		user := params[1]
+		writePostgresMessage(conn, 'R', append(binary.BigEndian.AppendUint32(nil, postgresAuthMD5), salt...))
+
+		msgType, body, err := readPostgresMessage(conn)
+		if err != nil || msgType != 'p' {
+			return
+		}
+		if strings.TrimRight(string(body), "\x00") == postgresMD5Password(user, "postgres", salt) {
+			writePostgresMessage(conn, 'R', binary.BigEndian.AppendUint32(nil, postgresAuthOK))
+			return
+		}
+		writePostgresMessage(conn, 'E', []byte("SFATAL\x00C28P01\x00Mpassword authentication failed\x00\x00"))
+	})
+	checkCredentials(t, postgresChecker{}, target, map[Credential]bool{
+		{"postgres", "postgres"}: true,
+		{"postgres", "123456"}:   false,
+	})
+}
+
+func TestMssqlChecker(t *testing.T) {
+	target := serveStub(t, "mssql", func(conn net.Conn) {
+		if _, err := readTDSMessage(conn); err != nil {
+			return
+		}
+		// 不支持加密，LOGIN7以明文发送
+		writeTDSPacket(conn, 0x04, []byte{0x01, 0, 6, 0, 1, 0xff, tdsEncryptNotSup})
+		login, err := readTDSMessage(conn)
+		if err != nil {
+			return
+		}
+		userOffset, userLength := binary.LittleEndian.Uint16(login[40:]), binary.LittleEndian.Uint16(login[42:])
+		passOffset, passLength := binary.LittleEndian.Uint16(login[44:]), binary.LittleEndian.Uint16(login[46:])
+		user := string(login[userOffset : userOffset+userLength*2])
+		pass := string(login[passOffset : passOffset+passLength*2])
+		if user == string(ntlmString("sa")) && pass == string(tdsPassword("sa@123")) {
+			writeTDSPacket(conn, 0x04, []byte{tdsTokenLoginAck, 1, 0, 0})
+			return
+		}
+		token := []byte{tdsTokenError, 6, 0}
+		token = binary.LittleEndian.AppendUint32(token, mssqlLoginFailed)
+		writeTDSPacket(conn, 0x04, append(token, 1, 14))
+	})
+	checkCredentials(t, mssqlChecker{}, target, map[Credential]bool{
+		{"sa", "sa@123"}: true,
+		{"sa", "123456"}: false,
+	})
+}
+
+// RFC 7677 SCRAM-SHA-256的测试数据
+func TestScramClient(t *testing.T) {
+	s := &scramClient{password: "pencil", clientNonce: "rOprNGfwEbeRWgbNEkqO"}
+	s.clientFirstBare = "n=user,r=" + s.clientNonce
+	final, err := s.clientFinal("r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if final != "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ=" {
+		t.Errorf("unexpected client final message:%s", final)
+	}
+	if _, err = s.clientFinal("r=other,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"); err == nil {
+		t.Errorf("server nonce should start with client nonce")
+	}
+}
@@ -0,0 +1,78 @@
+package weakpass
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// UserPlaceholder 密码字典中表示用户名的占位符
+	UserPlaceholder = "{user}"
+	// EmptyValue 字典中表示空用户名或空密码；空用户名只验证一次未授权访问
+	EmptyValue = "{empty}"
+)
+
+// Dictionary 弱口令字典：
+// user_<服务>.txt 用户名（文件不存在时使用空用户名验证所有密码，用于redis等只有密码的服务）；
+// password.txt 通用密码，password_<服务>.txt 服务专用的密码（优先尝试）
+type Dictionary struct {
+	Path string
+}
+
+// NewDictionary 创建字典对象
+func NewDictionary(path string) *Dictionary {
+	return &Dictionary{Path: path}
+}
+
+// Credentials 得到服务需要验证的凭据列表，按用户名分组依次排列
+func (d *Dictionary) Credentials(dictName string) (creds []Credential) {
+	users := readDictFile(filepath.Join(d.Path, "user_"+dictName+".txt"))
+	if len(users) == 0 {
+		users = []string{""}
+	}
+	passwords := readDictFile(filepath.Join(d.Path, "password_"+dictName+".txt"))
+	passwords = append(passwords, readDictFile(filepath.Join(d.Path, "password.txt"))...)
+
+	for _, user := range users {
+		if user == EmptyValue {
+			creds = append(creds, Credential{})
+			continue
+		}
+		exist := make(map[string]struct{})
+		for _, p := range passwords {
+			switch p {
+			case EmptyValue:
+				p = ""
+			default:
+				p = strings.ReplaceAll(p, UserPlaceholder, user)
+			}
+			if _, ok := exist[p]; ok {
+				continue
+			}
+			exist[p] = struct{}{}
+			creds = append(creds, Credential{Username: user, Password: p})
+		}
+	}
+	return
+}
+
+// readDictFile 读取字典文件，忽略空行及#开头的注释
+func readDictFile(fileName string) (lines []string) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return
+}
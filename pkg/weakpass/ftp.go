@@ -0,0 +1,61 @@
+package weakpass
+
+import (
+	"context"
+	"fmt"
+	"net/textproto"
+	"time"
+)
+
+type ftpChecker struct{}
+
+// Check 使用USER/PASS命令认证，230为成功，530为失败；空用户名时验证匿名登录
+func (ftpChecker) Check(ctx context.Context, target Target, cred Credential, timeout time.Duration) (bool, error) {
+	conn, err := dialTimeout(ctx, target, timeout)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	if _, _, err = tp.ReadResponse(220); err != nil {
+		return false, err
+	}
+	user, password := cred.Username, cred.Password
+	if user == "" {
+		user, password = "anonymous", "anonymous@"
+	}
+	if err = tp.PrintfLine("USER %s", user); err != nil {
+		return false, err
+	}
+	code, _, err := tp.ReadResponse(0)
+	switch {
+	case code == 0:
+		return false, err
+	case code == 230:
+		// 无需密码
+		return true, nil
+	case code == 530:
+		return false, nil
+	case code != 331:
+		return false, fmt.Errorf("unexpected ftp reply:%d", code)
+	}
+	if err = tp.PrintfLine("PASS %s", password); err != nil {
+		return false, err
+	}
+	code, msg, err := tp.ReadResponse(0)
+	if code == 0 {
+		return false, err
+	}
+	switch code {
+	case 230:
+		tp.PrintfLine("QUIT")
+		return true, nil
+	case 530:
+		return false, nil
+	case 421:
+		// 连接数或登录失败次数过多，服务端主动断开
+		return false, fmt.Errorf("ftp service not available:%s", msg)
+	}
+	return false, fmt.Errorf("unexpected ftp reply:%d %s", code, msg)
+}
@@ -0,0 +1,162 @@
+package weakpass
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// HTTPPanelFile 管理后台的定义文件，位于字典目录中
+const HTTPPanelFile = "http_panel.yml"
+
+// HTTPPanel 一个需要验证的HTTP管理后台
+type HTTPPanel struct {
+	Name string `yaml:"name"`
+	// Probe 判断是否存在该后台：请求Path，状态码为Status且响应头或内容包含Keyword
+	Probe struct {
+		Path    string `yaml:"path"`
+		Status  int    `yaml:"status"`
+		Keyword string `yaml:"keyword"`
+	} `yaml:"probe"`
+	// Login 登录方式：Method为basic时使用HTTP Basic认证，否则提交Body表单（{user}、{pass}为占位符）；
+	// 状态码在Status中、且响应头及内容不包含Failure时为成功
+	Login struct {
+		Method  string `yaml:"method"`
+		Path    string `yaml:"path"`
+		Body    string `yaml:"body"`
+		Status  []int  `yaml:"status"`
+		Failure string `yaml:"failure"`
+	} `yaml:"login"`
+}
+
+// LoadHTTPPanels 加载管理后台的定义
+func LoadHTTPPanels(fileName string) (panels []HTTPPanel, err error) {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(content, &panels); err != nil {
+		return nil, err
+	}
+	for _, p := range panels {
+		if p.Name == "" || p.Login.Path == "" || len(p.Login.Status) == 0 {
+			return nil, fmt.Errorf("invalid http panel:%s", p.Name)
+		}
+	}
+	return panels, nil
+}
+
+type httpChecker struct {
+	Panels []HTTPPanel
+}
+
+// Probe 探测服务上存在的管理后台
+func (h *httpChecker) Probe(ctx context.Context, target Target, timeout time.Duration) (targets []Target) {
+	client := newHTTPClient(timeout)
+	for _, p := range h.Panels {
+		path := p.Probe.Path
+		if path == "" {
+			path = p.Login.Path
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.Service+"://"+target.Address()+path, nil)
+		if err != nil {
+			continue
+		}
+		status, content, err := doHTTPRequest(client, req)
+		if err != nil {
+			continue
+		}
+		if (p.Probe.Status == 0 || status == p.Probe.Status) && strings.Contains(content, p.Probe.Keyword) {
+			t := target
+			t.Panel = p.Name
+			targets = append(targets, t)
+		}
+	}
+	return
+}
+
+// Check 登录管理后台；返回429时认为来源已被限制
+func (h *httpChecker) Check(ctx context.Context, target Target, cred Credential, timeout time.Duration) (bool, error) {
+	var panel *HTTPPanel
+	for i := range h.Panels {
+		if h.Panels[i].Name == target.Panel {
+			panel = &h.Panels[i]
+			break
+		}
+	}
+	if panel == nil {
+		return false, fmt.Errorf("unknown http panel:%s", target.Panel)
+	}
+	address := target.Service + "://" + target.Address() + panel.Login.Path
+	var req *http.Request
+	var err error
+	if strings.EqualFold(panel.Login.Method, "basic") {
+		if req, err = http.NewRequestWithContext(ctx, http.MethodGet, address, nil); err == nil {
+			req.SetBasicAuth(cred.Username, cred.Password)
+		}
+	} else {
+		body := strings.NewReplacer("{user}", url.QueryEscape(cred.Username), "{pass}", url.QueryEscape(cred.Password)).Replace(panel.Login.Body)
+		if req, err = http.NewRequestWithContext(ctx, strings.ToUpper(panel.Login.Method), address, strings.NewReader(body)); err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	status, content, err := doHTTPRequest(newHTTPClient(timeout), req)
+	if err != nil {
+		return false, err
+	}
+	if status == http.StatusTooManyRequests {
+		return false, ErrLockout
+	}
+	for _, s := range panel.Login.Status {
+		if status == s {
+			return panel.Login.Failure == "" || !strings.Contains(content, panel.Login.Failure), nil
+		}
+	}
+	return false, nil
+}
+
+// newHTTPClient 不跟随跳转（登录结果通常由跳转地址判断），不校验证书
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// doHTTPRequest 返回状态码，以及用于匹配关键词的响应头和内容
+func doHTTPRequest(client *http.Client, req *http.Request) (status int, content string, err error) {
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36")
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	var sb strings.Builder
+	for k, v := range resp.Header {
+		sb.WriteString(k + ": " + strings.Join(v, ",") + "\n")
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, "", err
+	}
+	sb.Write(body)
+	return resp.StatusCode, sb.String(), nil
+}
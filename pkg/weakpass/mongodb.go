@@ -0,0 +1,53 @@
+package weakpass
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver"
+	"time"
+)
+
+const (
+	mongoUnauthorized         = 13
+	mongoAuthenticationFailed = 18
+)
+
+type mongodbChecker struct{}
+
+// Check 空用户名时验证未授权访问（能否列出数据库），否则在admin库使用默认机制认证
+func (mongodbChecker) Check(ctx context.Context, target Target, cred Credential, timeout time.Duration) (bool, error) {
+	opts := options.Client().
+		SetHosts([]string{target.Address()}).
+		SetDirect(true).
+		SetConnectTimeout(timeout).
+		SetServerSelectionTimeout(timeout).
+		SetSocketTimeout(timeout)
+	if cred.Username != "" {
+		opts.SetAuth(options.Credential{AuthSource: "admin", Username: cred.Username, Password: cred.Password})
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	client, err := mongo.Connect(checkCtx, opts)
+	if err != nil {
+		return false, err
+	}
+	defer client.Disconnect(context.Background())
+
+	err = client.Database("admin").RunCommand(checkCtx, bson.D{{Key: "listDatabases", Value: 1}, {Key: "nameOnly", Value: true}}).Err()
+	if err == nil {
+		return true, nil
+	}
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Code == mongoUnauthorized || cmdErr.Code == mongoAuthenticationFailed) {
+		return false, nil
+	}
+	// 认证失败时驱动返回的是握手阶段的连接错误，其中包含服务端的错误码
+	var driverErr driver.Error
+	if errors.As(err, &driverErr) && (driverErr.Code == mongoUnauthorized || driverErr.Code == mongoAuthenticationFailed) {
+		return false, nil
+	}
+	return false, err
+}
@@ -0,0 +1,262 @@
+package weakpass
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	tdsPrelogin = 0x12
+	tdsLogin7   = 0x10
+
+	tdsEncryptOff    = 0x00
+	tdsEncryptNotSup = 0x02
+
+	tdsTokenError     = 0xaa
+	tdsTokenInfo      = 0xab
+	tdsTokenLoginAck  = 0xad
+	tdsTokenEnvChange = 0xe3
+
+	mssqlLoginFailed        = 18456
+	mssqlAccountDisabled    = 18470
+	mssqlAccountLockedOut   = 18486
+	mssqlPasswordExpired    = 18487
+	mssqlPasswordMustChange = 18488
+)
+
+type mssqlChecker struct{}
+
+// Check 按TDS协议发送PRELOGIN及LOGIN7进行SQL Server身份认证；服务端支持加密时LOGIN7在TLS中发送
+func (mssqlChecker) Check(ctx context.Context, target Target, cred Credential, timeout time.Duration) (bool, error) {
+	conn, err := dialTimeout(ctx, target, timeout)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// PRELOGIN：VERSION及ENCRYPTION两个选项，请求只加密登录过程
+	prelogin := []byte{0x00, 0, 11, 0, 6, 0x01, 0, 17, 0, 1, 0xff, 0, 0, 0, 0, 0, 0, tdsEncryptOff}
+	if err = writeTDSPacket(conn, tdsPrelogin, prelogin); err != nil {
+		return false, err
+	}
+	response, err := readTDSMessage(conn)
+	if err != nil {
+		return false, err
+	}
+	encryption, err := tdsPreloginEncryption(response)
+	if err != nil {
+		return false, err
+	}
+
+	login := tdsLogin7Message(cred)
+	var reply []byte
+	if encryption == tdsEncryptNotSup {
+		if err = writeTDSPacket(conn, tdsLogin7, login); err != nil {
+			return false, err
+		}
+		reply, err = readTDSMessage(conn)
+	} else {
+		handshakeConn := &tdsHandshakeConn{Conn: conn, handshake: true}
+		tlsConn := tls.Client(handshakeConn, &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS10})
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			return false, err
+		}
+		if err = handshakeConn.finishHandshake(); err != nil {
+			return false, err
+		}
+		if err = writeTDSPacket(tlsConn, tdsLogin7, login); err != nil {
+			return false, err
+		}
+		// 只加密登录过程时，登录的响应是明文
+		if encryption == tdsEncryptOff {
+			reply, err = readTDSMessage(conn)
+		} else {
+			reply, err = readTDSMessage(tlsConn)
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return tdsLoginResult(reply)
+}
+
+// tdsPreloginEncryption 从PRELOGIN响应中得到服务端的加密选项
+func tdsPreloginEncryption(data []byte) (byte, error) {
+	for i := 0; i+5 <= len(data) && data[i] != 0xff; i += 5 {
+		if data[i] != 0x01 {
+			continue
+		}
+		offset := int(binary.BigEndian.Uint16(data[i+1:]))
+		if offset < len(data) {
+			return data[offset], nil
+		}
+	}
+	return 0, errors.New("invalid tds prelogin response")
+}
+
+// tdsLogin7Message LOGIN7消息：94字节的固定部分，之后为各变长字段
+func tdsLogin7Message(cred Credential) []byte {
+	fields := [][]byte{
+		ntlmString("nemo"),         // HostName
+		ntlmString(cred.Username),  // UserName
+		tdsPassword(cred.Password), // Password
+		ntlmString("nemo"),         // AppName
+		nil,                        // ServerName
+		nil,                        // Extension
+		ntlmString("nemo"),         // CltIntName
+		nil,                        // Language
+		nil,                        // Database
+	}
+	msg := make([]byte, 94)
+	binary.LittleEndian.PutUint32(msg[4:], 0x74000004)
+	binary.LittleEndian.PutUint32(msg[8:], 4096)
+	binary.LittleEndian.PutUint32(msg[12:], 7)
+	msg[24] = 0xe0
+	msg[25] = 0x03
+	offset := 94
+	for i, f := range fields {
+		binary.LittleEndian.PutUint16(msg[36+i*4:], uint16(offset))
+		binary.LittleEndian.PutUint16(msg[38+i*4:], uint16(len(f)/2))
+		offset += len(f)
+	}
+	// ClientID之后的SSPI、AtchDBFile及ChangePassword为空
+	for _, pos := range []int{78, 82, 86} {
+		binary.LittleEndian.PutUint16(msg[pos:], uint16(offset))
+	}
+	for _, f := range fields {
+		msg = append(msg, f...)
+	}
+	binary.LittleEndian.PutUint32(msg, uint32(len(msg)))
+	return msg
+}
+
+// tdsPassword LOGIN7中的密码：UTF-16LE编码后每个字节交换高低4位再与0xA5异或
+func tdsPassword(password string) []byte {
+	b := ntlmString(password)
+	for i := range b {
+		b[i] = (b[i]<<4 | b[i]>>4) ^ 0xa5
+	}
+	return b
+}
+
+// tdsLoginResult 解析登录响应的token：LOGINACK为成功，ERROR根据错误号判断
+func tdsLoginResult(data []byte) (bool, error) {
+	for len(data) >= 3 {
+		token := data[0]
+		length := int(binary.LittleEndian.Uint16(data[1:]))
+		if len(data) < 3+length {
+			break
+		}
+		switch token {
+		case tdsTokenLoginAck:
+			return true, nil
+		case tdsTokenError:
+			if length < 4 {
+				break
+			}
+			switch number := binary.LittleEndian.Uint32(data[3:]); number {
+			case mssqlLoginFailed, mssqlAccountDisabled:
+				return false, nil
+			case mssqlPasswordExpired, mssqlPasswordMustChange:
+				return true, nil
+			case mssqlAccountLockedOut:
+				return false, ErrLockout
+			default:
+				return false, fmt.Errorf("mssql error:%d", number)
+			}
+		case tdsTokenInfo, tdsTokenEnvChange:
+		default:
+			return false, fmt.Errorf("unexpected tds token:0x%02x", token)
+		}
+		data = data[3+length:]
+	}
+	return false, errors.New("invalid tds login response")
+}
+
+// writeTDSPacket 发送一个TDS包
+func writeTDSPacket(w io.Writer, packetType byte, data []byte) error {
+	header := []byte{packetType, 0x01, 0, 0, 0, 0, 1, 0}
+	binary.BigEndian.PutUint16(header[2:], uint16(len(data)+8))
+	_, err := w.Write(append(header, data...))
+	return err
+}
+
+// readTDSMessage 读取一个完整的TDS消息（直到EOM标志的包）
+func readTDSMessage(r io.Reader) (data []byte, err error) {
+	header := make([]byte, 8)
+	for {
+		if _, err = io.ReadFull(r, header); err != nil {
+			return
+		}
+		length := int(binary.BigEndian.Uint16(header[2:]))
+		if length < 8 {
+			return nil, errors.New("invalid tds packet")
+		}
+		body := make([]byte, length-8)
+		if _, err = io.ReadFull(r, body); err != nil {
+			return
+		}
+		data = append(data, body...)
+		if header[1]&0x01 != 0 {
+			return
+		}
+	}
+}
+
+// tdsHandshakeConn TLS握手的数据需要封装在PRELOGIN包中传输，握手完成后直接传输TLS数据
+type tdsHandshakeConn struct {
+	net.Conn
+	handshake bool
+	readBuf   []byte
+	writeBuf  []byte
+}
+
+// flush 一次握手的数据合并为一个PRELOGIN包发送
+func (c *tdsHandshakeConn) flush() error {
+	if len(c.writeBuf) == 0 {
+		return nil
+	}
+	err := writeTDSPacket(c.Conn, tdsPrelogin, c.writeBuf)
+	c.writeBuf = nil
+	return err
+}
+
+// finishHandshake 发送握手最后的数据，之后不再封装
+func (c *tdsHandshakeConn) finishHandshake() error {
+	err := c.flush()
+	c.handshake = false
+	return err
+}
+
+func (c *tdsHandshakeConn) Read(b []byte) (int, error) {
+	if !c.handshake {
+		return c.Conn.Read(b)
+	}
+	if err := c.flush(); err != nil {
+		return 0, err
+	}
+	if len(c.readBuf) == 0 {
+		data, err := readTDSMessage(c.Conn)
+		if err != nil {
+			return 0, err
+		}
+		c.readBuf = data
+	}
+	n := copy(b, c.readBuf)
+	c.readBuf = c.readBuf[n:]
+	return n, nil
+}
+
+func (c *tdsHandshakeConn) Write(b []byte) (int, error) {
+	if !c.handshake {
+		return c.Conn.Write(b)
+	}
+	c.writeBuf = append(c.writeBuf, b...)
+	return len(b), nil
+}
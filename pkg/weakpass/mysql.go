@@ -0,0 +1,54 @@
+package weakpass
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"time"
+)
+
+const (
+	mysqlAccessDenied = 1045
+	mysqlHostBlocked  = 1129
+)
+
+type mysqlChecker struct{}
+
+// Check 连接并认证，1045为认证失败，1129为来源IP因连接错误过多被锁定
+func (mysqlChecker) Check(ctx context.Context, target Target, cred Credential, timeout time.Duration) (bool, error) {
+	config := mysql.NewConfig()
+	config.User = cred.Username
+	config.Passwd = cred.Password
+	config.Net = "tcp"
+	config.Addr = target.Address()
+	config.Timeout = timeout
+	config.ReadTimeout = timeout
+	config.WriteTimeout = timeout
+	config.AllowNativePasswords = true
+	config.AllowCleartextPasswords = true
+	connector, err := mysql.NewConnector(config)
+	if err != nil {
+		return false, err
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err = db.PingContext(pingCtx)
+	if err == nil {
+		return true, nil
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlAccessDenied:
+			return false, nil
+		case mysqlHostBlocked:
+			return false, fmt.Errorf("%w:%s", ErrLockout, mysqlErr.Message)
+		}
+	}
+	return false, err
+}
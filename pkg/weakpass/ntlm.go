@@ -0,0 +1,248 @@
+package weakpass
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"encoding/binary"
+	"errors"
+	"golang.org/x/crypto/md4"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// NTLM的协商标志（MS-NLMP 2.2.2.5）
+const (
+	ntlmNegotiateUnicode                 = 0x00000001
+	ntlmRequestTarget                    = 0x00000004
+	ntlmNegotiateSign                    = 0x00000010
+	ntlmNegotiateSeal                    = 0x00000020
+	ntlmNegotiateNTLM                    = 0x00000200
+	ntlmNegotiateAlwaysSign              = 0x00008000
+	ntlmNegotiateExtendedSessionSecurity = 0x00080000
+	ntlmNegotiateTargetInfo              = 0x00800000
+	ntlmNegotiate128                     = 0x20000000
+	ntlmNegotiateKeyExch                 = 0x40000000
+	ntlmNegotiate56                      = 0x80000000
+
+	ntlmAvEOL       = 0
+	ntlmAvTimestamp = 7
+)
+
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+// ntlmClient NTLMv2认证的客户端，支持CredSSP需要的消息加密（seal）
+type ntlmClient struct {
+	domain   string
+	user     string
+	password string
+	flags    uint32
+
+	// 以下为随机值，测试时可指定
+	clientChallenge  []byte
+	randomSessionKey []byte
+	timestamp        []byte
+
+	exportedSessionKey []byte
+	clientSigningKey   []byte
+	clientSealing      *rc4.Cipher
+	seqNum             uint32
+}
+
+// newNTLMClient 创建NTLM客户端，用户名可以是DOMAIN\user格式
+func newNTLMClient(cred Credential) *ntlmClient {
+	c := &ntlmClient{
+		user:     cred.Username,
+		password: cred.Password,
+		flags: ntlmNegotiateUnicode | ntlmRequestTarget | ntlmNegotiateSign | ntlmNegotiateSeal | ntlmNegotiateNTLM |
+			ntlmNegotiateAlwaysSign | ntlmNegotiateExtendedSessionSecurity | ntlmNegotiateTargetInfo |
+			ntlmNegotiate128 | ntlmNegotiateKeyExch | ntlmNegotiate56,
+	}
+	if domain, user, found := strings.Cut(cred.Username, "\\"); found {
+		c.domain, c.user = domain, user
+	}
+	return c
+}
+
+// negotiate NEGOTIATE_MESSAGE
+func (c *ntlmClient) negotiate() []byte {
+	msg := make([]byte, 32)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 1)
+	binary.LittleEndian.PutUint32(msg[12:], c.flags)
+	return msg
+}
+
+// authenticate 根据CHALLENGE_MESSAGE生成AUTHENTICATE_MESSAGE
+func (c *ntlmClient) authenticate(challenge []byte) ([]byte, error) {
+	if len(challenge) < 48 || !bytes.Equal(challenge[:8], ntlmSignature) || binary.LittleEndian.Uint32(challenge[8:]) != 2 {
+		return nil, errors.New("invalid ntlm challenge message")
+	}
+	c.flags &= binary.LittleEndian.Uint32(challenge[20:])
+	serverChallenge := challenge[24:32]
+	targetInfo, ok := ntlmField(challenge, 40)
+	if !ok {
+		return nil, errors.New("invalid ntlm target info")
+	}
+	if c.clientChallenge == nil {
+		c.clientChallenge = randomBytes(8)
+	}
+	if c.timestamp == nil {
+		if c.timestamp = ntlmAvPair(targetInfo, ntlmAvTimestamp); c.timestamp == nil {
+			c.timestamp = ntlmFileTime(time.Now())
+		}
+	}
+
+	ntowf := ntowfv2(c.user, c.password, c.domain)
+	_, ntResponse, sessionBaseKey := ntlmv2Response(ntowf, serverChallenge, c.clientChallenge, c.timestamp, targetInfo)
+	// 服务端提供了时间戳时，LmChallengeResponse为全0
+	lmResponse := make([]byte, 24)
+	if ntlmAvPair(targetInfo, ntlmAvTimestamp) == nil {
+		lmResponse = append(hmacMD5(ntowf, serverChallenge, c.clientChallenge), c.clientChallenge...)
+	}
+	var encryptedSessionKey []byte
+	c.exportedSessionKey = sessionBaseKey
+	if c.flags&ntlmNegotiateKeyExch != 0 {
+		if c.randomSessionKey == nil {
+			c.randomSessionKey = randomBytes(16)
+		}
+		c.exportedSessionKey = c.randomSessionKey
+		encryptedSessionKey = rc4Crypt(sessionBaseKey, c.randomSessionKey)
+	}
+	c.initSealing()
+
+	payloads := [][]byte{lmResponse, ntResponse, ntlmString(c.domain), ntlmString(c.user), nil, encryptedSessionKey}
+	msg := make([]byte, 64)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 3)
+	for i, p := range payloads {
+		field := 12 + i*8
+		binary.LittleEndian.PutUint16(msg[field:], uint16(len(p)))
+		binary.LittleEndian.PutUint16(msg[field+2:], uint16(len(p)))
+		binary.LittleEndian.PutUint32(msg[field+4:], uint32(len(msg)))
+		msg = append(msg, p...)
+	}
+	binary.LittleEndian.PutUint32(msg[60:], c.flags)
+	return msg, nil
+}
+
+// initSealing 根据会话密钥生成客户端的签名及加密密钥
+func (c *ntlmClient) initSealing() {
+	sealKey := c.exportedSessionKey
+	switch {
+	case c.flags&ntlmNegotiate128 != 0:
+	case c.flags&ntlmNegotiate56 != 0:
+		sealKey = sealKey[:7]
+	default:
+		sealKey = sealKey[:5]
+	}
+	signKey := md5.Sum(append(append([]byte{}, c.exportedSessionKey...), "session key to client-to-server signing key magic constant\x00"...))
+	sealMD5 := md5.Sum(append(append([]byte{}, sealKey...), "session key to client-to-server sealing key magic constant\x00"...))
+	c.clientSigningKey = signKey[:]
+	c.clientSealing, _ = rc4.NewCipher(sealMD5[:])
+	c.seqNum = 0
+}
+
+// seal 加密消息，返回签名（16字节）及密文
+func (c *ntlmClient) seal(message []byte) []byte {
+	sealed := make([]byte, len(message))
+	c.clientSealing.XORKeyStream(sealed, message)
+
+	seq := binary.LittleEndian.AppendUint32(nil, c.seqNum)
+	checksum := hmacMD5(c.clientSigningKey, seq, message)[:8]
+	if c.flags&ntlmNegotiateKeyExch != 0 {
+		c.clientSealing.XORKeyStream(checksum, checksum)
+	}
+	signature := binary.LittleEndian.AppendUint32(nil, 1)
+	signature = append(append(signature, checksum...), seq...)
+	c.seqNum++
+	return append(signature, sealed...)
+}
+
+// ntowfv2 NTOWFv2(Passwd, User, UserDom)
+func ntowfv2(user, password, domain string) []byte {
+	h := md4.New()
+	h.Write(ntlmString(password))
+	return hmacMD5(h.Sum(nil), ntlmString(strings.ToUpper(user)+domain))
+}
+
+// ntlmv2Response 计算NTProofStr、NtChallengeResponse及SessionBaseKey
+func ntlmv2Response(ntowf, serverChallenge, clientChallenge, timestamp, targetInfo []byte) (ntProof, response, sessionBaseKey []byte) {
+	var temp []byte
+	temp = append(temp, 1, 1, 0, 0, 0, 0, 0, 0)
+	temp = append(temp, timestamp...)
+	temp = append(temp, clientChallenge...)
+	temp = append(temp, 0, 0, 0, 0)
+	temp = append(temp, targetInfo...)
+	temp = append(temp, 0, 0, 0, 0)
+
+	ntProof = hmacMD5(ntowf, serverChallenge, temp)
+	return ntProof, append(append([]byte{}, ntProof...), temp...), hmacMD5(ntowf, ntProof)
+}
+
+// ntlmField 读取消息中的变长字段（长度、最大长度及偏移）
+func ntlmField(msg []byte, offset int) ([]byte, bool) {
+	if len(msg) < offset+8 {
+		return nil, false
+	}
+	length := int(binary.LittleEndian.Uint16(msg[offset:]))
+	start := int(binary.LittleEndian.Uint32(msg[offset+4:]))
+	if start+length > len(msg) {
+		return nil, false
+	}
+	return msg[start : start+length], true
+}
+
+// ntlmAvPair 查找TargetInfo中指定类型的AV_PAIR
+func ntlmAvPair(targetInfo []byte, avId uint16) []byte {
+	for len(targetInfo) >= 4 {
+		id := binary.LittleEndian.Uint16(targetInfo)
+		length := int(binary.LittleEndian.Uint16(targetInfo[2:]))
+		if id == ntlmAvEOL || len(targetInfo) < 4+length {
+			break
+		}
+		if id == avId {
+			return targetInfo[4 : 4+length]
+		}
+		targetInfo = targetInfo[4+length:]
+	}
+	return nil
+}
+
+// ntlmString UTF-16LE编码
+func ntlmString(s string) []byte {
+	var b []byte
+	for _, r := range utf16.Encode([]rune(s)) {
+		b = binary.LittleEndian.AppendUint16(b, r)
+	}
+	return b
+}
+
+// ntlmFileTime Windows的FILETIME格式：自1601-01-01以来的100纳秒数
+func ntlmFileTime(t time.Time) []byte {
+	return binary.LittleEndian.AppendUint64(nil, uint64(t.UnixNano()/100+116444736000000000))
+}
+
+func hmacMD5(key []byte, data ...[]byte) []byte {
+	h := hmac.New(md5.New, key)
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+func rc4Crypt(key, data []byte) []byte {
+	cipher, _ := rc4.NewCipher(key)
+	result := make([]byte, len(data))
+	cipher.XORKeyStream(result, data)
+	return result
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
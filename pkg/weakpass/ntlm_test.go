@@ -0,0 +1,59 @@
+package weakpass
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+// MS-NLMP 4.2.4 NTLMv2认证的测试数据
+func TestNTLMClient_Authenticate(t *testing.T) {
+	targetInfo := append(append([]byte{2, 0, 12, 0}, ntlmString("Domain")...), append([]byte{1, 0, 12, 0}, ntlmString("Server")...)...)
+	targetInfo = append(targetInfo, 0, 0, 0, 0)
+	challenge := make([]byte, 48)
+	copy(challenge, ntlmSignature)
+	binary.LittleEndian.PutUint32(challenge[8:], 2)
+	binary.LittleEndian.PutUint32(challenge[20:], 0xe28a8233)
+	copy(challenge[24:], []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef})
+	binary.LittleEndian.PutUint16(challenge[40:], uint16(len(targetInfo)))
+	binary.LittleEndian.PutUint16(challenge[42:], uint16(len(targetInfo)))
+	binary.LittleEndian.PutUint32(challenge[44:], uint32(len(challenge)))
+	challenge = append(challenge, targetInfo...)
+
+	c := newNTLMClient(Credential{Username: "Domain\\User", Password: "Password"})
+	c.clientChallenge = bytes.Repeat([]byte{0xaa}, 8)
+	c.randomSessionKey = bytes.Repeat([]byte{0x55}, 16)
+	c.timestamp = make([]byte, 8)
+	msg, err := c.authenticate(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := hex.EncodeToString(ntowfv2("User", "Password", "Domain")); v != "0c868a403bfd7a93a3001ef22ef02e3f" {
+		t.Errorf("unexpected NTOWFv2:%s", v)
+	}
+	lm, _ := ntlmField(msg, 12)
+	if v := hex.EncodeToString(lm); v != "86c35097ac9cec102554764a57cccc19aaaaaaaaaaaaaaaa" {
+		t.Errorf("unexpected LMv2 response:%s", v)
+	}
+	nt, _ := ntlmField(msg, 20)
+	if v := hex.EncodeToString(nt[:16]); v != "68cd0ab851e51c96aabc927bebef6a1c" {
+		t.Errorf("unexpected NTProofStr:%s", v)
+	}
+	key, _ := ntlmField(msg, 52)
+	if v := hex.EncodeToString(key); v != "c5dad2544fc9799094ce1ce90bc9d03e" {
+		t.Errorf("unexpected encrypted session key:%s", v)
+	}
+	user, _ := ntlmField(msg, 36)
+	if !bytes.Equal(user, ntlmString("User")) {
+		t.Errorf("unexpected user name:%x", user)
+	}
+
+	sealed := c.seal(ntlmString("Plaintext"))
+	if v := hex.EncodeToString(sealed[16:]); v != "54e50165bf1936dc996020c1811b0f06fb5f" {
+		t.Errorf("unexpected sealed data:%s", v)
+	}
+	if v := hex.EncodeToString(sealed[:16]); v != "010000007fb38ec5c55d497600000000" {
+		t.Errorf("unexpected signature:%s", v)
+	}
+}
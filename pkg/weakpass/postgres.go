@@ -0,0 +1,222 @@
+package weakpass
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"golang.org/x/crypto/pbkdf2"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	postgresProtocolVersion = 196608
+	postgresAuthOK          = 0
+	postgresAuthCleartext   = 3
+	postgresAuthMD5         = 5
+	postgresAuthSASL        = 10
+	postgresAuthSASLCont    = 11
+	postgresAuthSASLFinal   = 12
+)
+
+type postgresChecker struct{}
+
+// Check 按PostgreSQL协议认证，支持cleartext、md5及SCRAM-SHA-256
+func (postgresChecker) Check(ctx context.Context, target Target, cred Credential, timeout time.Duration) (bool, error) {
+	conn, err := dialTimeout(ctx, target, timeout)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	var startup []byte
+	startup = binary.BigEndian.AppendUint32(startup, postgresProtocolVersion)
+	for _, s := range []string{"user", cred.Username, "database", "postgres", ""} {
+		startup = append(append(startup, s...), 0)
+	}
+	if err = writePostgresMessage(conn, 0, startup); err != nil {
+		return false, err
+	}
+	var scram *scramClient
+	for {
+		msgType, body, err := readPostgresMessage(conn)
+		if err != nil {
+			return false, err
+		}
+		switch msgType {
+		case 'E':
+			return postgresError(body)
+		case 'R':
+		default:
+			return false, fmt.Errorf("unexpected postgres message:%c", msgType)
+		}
+		if len(body) < 4 {
+			return false, fmt.Errorf("invalid postgres auth message")
+		}
+		switch binary.BigEndian.Uint32(body) {
+		case postgresAuthOK:
+			return true, nil
+		case postgresAuthCleartext:
+			err = writePostgresMessage(conn, 'p', append([]byte(cred.Password), 0))
+		case postgresAuthMD5:
+			if len(body) < 8 {
+				return false, fmt.Errorf("invalid postgres md5 salt")
+			}
+			err = writePostgresMessage(conn, 'p', append([]byte(postgresMD5Password(cred.Username, cred.Password, body[4:8])), 0))
+		case postgresAuthSASL:
+			if !bytes.Contains(body[4:], []byte("SCRAM-SHA-256\x00")) {
+				return false, fmt.Errorf("unsupported postgres sasl mechanism")
+			}
+			scram = newScramClient(cred.Password)
+			first := scram.clientFirst()
+			var msg []byte
+			msg = append(append(msg, "SCRAM-SHA-256"...), 0)
+			msg = binary.BigEndian.AppendUint32(msg, uint32(len(first)))
+			msg = append(msg, first...)
+			err = writePostgresMessage(conn, 'p', msg)
+		case postgresAuthSASLCont:
+			if scram == nil {
+				return false, fmt.Errorf("unexpected postgres sasl continue")
+			}
+			final, scramErr := scram.clientFinal(string(body[4:]))
+			if scramErr != nil {
+				return false, scramErr
+			}
+			err = writePostgresMessage(conn, 'p', []byte(final))
+		case postgresAuthSASLFinal:
+			// 等待AuthenticationOk
+		default:
+			return false, fmt.Errorf("unsupported postgres auth method:%d", binary.BigEndian.Uint32(body))
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+// writePostgresMessage 发送消息：类型（启动消息没有类型）、长度及内容
+func writePostgresMessage(conn net.Conn, msgType byte, body []byte) error {
+	var msg []byte
+	if msgType != 0 {
+		msg = append(msg, msgType)
+	}
+	msg = binary.BigEndian.AppendUint32(msg, uint32(len(body)+4))
+	_, err := conn.Write(append(msg, body...))
+	return err
+}
+
+// readPostgresMessage 读取一个消息
+func readPostgresMessage(conn net.Conn) (msgType byte, body []byte, err error) {
+	header := make([]byte, 5)
+	if _, err = io.ReadFull(conn, header); err != nil {
+		return
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length < 4 || length > 1<<16 {
+		return 0, nil, fmt.Errorf("invalid postgres message length:%d", length)
+	}
+	body = make([]byte, length-4)
+	_, err = io.ReadFull(conn, body)
+	return header[0], body, err
+}
+
+// postgresError 根据错误码判断认证结果：28P01密码错误，3D000数据库不存在（认证已通过）
+func postgresError(body []byte) (bool, error) {
+	fields := make(map[byte]string)
+	for len(body) > 1 {
+		end := bytes.IndexByte(body[1:], 0)
+		if end < 0 {
+			break
+		}
+		fields[body[0]] = string(body[1 : end+1])
+		body = body[end+2:]
+	}
+	switch fields['C'] {
+	case "28P01":
+		return false, nil
+	case "28000":
+		// trust认证时用户不存在
+		if strings.Contains(fields['M'], "does not exist") {
+			return false, nil
+		}
+	case "3D000":
+		return true, nil
+	}
+	return false, fmt.Errorf("postgres error %s:%s", fields['C'], fields['M'])
+}
+
+// postgresMD5Password md5认证：md5(md5(password+user)+salt)
+func postgresMD5Password(user, password string, salt []byte) string {
+	inner := md5.Sum([]byte(password + user))
+	outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), salt...))
+	return "md5" + hex.EncodeToString(outer[:])
+}
+
+// scramClient SCRAM-SHA-256认证（RFC 5802、RFC 7677）
+type scramClient struct {
+	password        string
+	clientNonce     string
+	clientFirstBare string
+}
+
+func newScramClient(password string) *scramClient {
+	nonce := make([]byte, 18)
+	rand.Read(nonce)
+	return &scramClient{password: password, clientNonce: base64.RawStdEncoding.EncodeToString(nonce)}
+}
+
+// clientFirst 客户端的第一个消息；PostgreSQL忽略其中的用户名
+func (s *scramClient) clientFirst() string {
+	s.clientFirstBare = "n=,r=" + s.clientNonce
+	return "n,," + s.clientFirstBare
+}
+
+// clientFinal 根据服务端返回的nonce、salt及迭代次数计算proof
+func (s *scramClient) clientFinal(serverFirst string) (string, error) {
+	var nonce, salt string
+	var iterations int
+	for _, attr := range strings.Split(serverFirst, ",") {
+		if len(attr) < 2 || attr[1] != '=' {
+			continue
+		}
+		switch attr[0] {
+		case 'r':
+			nonce = attr[2:]
+		case 's':
+			salt = attr[2:]
+		case 'i':
+			iterations, _ = strconv.Atoi(attr[2:])
+		}
+	}
+	saltBytes, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil || !strings.HasPrefix(nonce, s.clientNonce) || iterations <= 0 {
+		return "", fmt.Errorf("invalid scram server message:%s", serverFirst)
+	}
+	saltedPassword := pbkdf2.Key([]byte(s.password), saltBytes, iterations, sha256.Size, sha256.New)
+	clientKey := hmacSHA256(saltedPassword, []byte("Client Key"))
+	storedKey := sha256.Sum256(clientKey)
+	clientFinalWithoutProof := "c=biws,r=" + nonce
+	authMessage := s.clientFirstBare + "," + serverFirst + "," + clientFinalWithoutProof
+	signature := hmacSHA256(storedKey[:], []byte(authMessage))
+	proof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		proof[i] = clientKey[i] ^ signature[i]
+	}
+	return clientFinalWithoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
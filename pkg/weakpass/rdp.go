@@ -0,0 +1,206 @@
+package weakpass
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+)
+
+const (
+	rdpProtocolSSL    = 0x00000001
+	rdpProtocolHybrid = 0x00000002
+
+	rdpNegResponse = 0x02
+	rdpNegFailure  = 0x03
+
+	credsspVersion = 6
+)
+
+// tsRequest CredSSP的TSRequest（MS-CSSP 2.2.1）
+type tsRequest struct {
+	Version     int           `asn1:"explicit,tag:0"`
+	NegoTokens  []tsNegoToken `asn1:"explicit,optional,tag:1"`
+	AuthInfo    []byte        `asn1:"explicit,optional,tag:2"`
+	PubKeyAuth  []byte        `asn1:"explicit,optional,tag:3"`
+	ErrorCode   int64         `asn1:"explicit,optional,tag:4"`
+	ClientNonce []byte        `asn1:"explicit,optional,tag:5"`
+}
+
+type tsNegoToken struct {
+	Token []byte `asn1:"explicit,tag:0"`
+}
+
+type rdpChecker struct{}
+
+// Check 通过NLA（CredSSP）进行NTLMv2认证：服务端返回pubKeyAuth即认证成功；服务端不支持NLA时无法验证
+func (rdpChecker) Check(ctx context.Context, target Target, cred Credential, timeout time.Duration) (bool, error) {
+	conn, err := dialTimeout(ctx, target, timeout)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if err = rdpNegotiate(conn); err != nil {
+		return false, err
+	}
+	tlsConn := tls.Client(conn, &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS10})
+	if err = tlsConn.HandshakeContext(ctx); err != nil {
+		return false, err
+	}
+	publicKey, err := rdpSubjectPublicKey(tlsConn)
+	if err != nil {
+		return false, err
+	}
+
+	ntlm := newNTLMClient(cred)
+	if err = writeTSRequest(tlsConn, tsRequest{Version: credsspVersion, NegoTokens: []tsNegoToken{{ntlm.negotiate()}}}); err != nil {
+		return false, err
+	}
+	challenge, err := readTSRequest(tlsConn)
+	if err != nil {
+		return false, err
+	}
+	if len(challenge.NegoTokens) == 0 {
+		return tsErrorResult(challenge)
+	}
+	authenticate, err := ntlm.authenticate(challenge.NegoTokens[0].Token)
+	if err != nil {
+		return false, err
+	}
+	// 版本5以上使用clientNonce及公钥的哈希，之前的版本直接加密公钥
+	request := tsRequest{Version: credsspVersion, NegoTokens: []tsNegoToken{{authenticate}}}
+	if challenge.Version >= 5 {
+		request.ClientNonce = randomBytes(32)
+		h := sha256.New()
+		h.Write([]byte("CredSSP Client-To-Server Binding Hash\x00"))
+		h.Write(request.ClientNonce)
+		h.Write(publicKey)
+		request.PubKeyAuth = ntlm.seal(h.Sum(nil))
+	} else {
+		request.Version = challenge.Version
+		request.PubKeyAuth = ntlm.seal(publicKey)
+	}
+	if err = writeTSRequest(tlsConn, request); err != nil {
+		return false, err
+	}
+	response, err := readTSRequest(tlsConn)
+	if err != nil {
+		// 早期版本的服务端认证失败时直接断开连接
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) {
+			return false, nil
+		}
+		return false, err
+	}
+	if len(response.PubKeyAuth) > 0 {
+		return true, nil
+	}
+	return tsErrorResult(response)
+}
+
+// rdpNegotiate 发送X.224连接请求，要求使用NLA
+func rdpNegotiate(conn net.Conn) error {
+	negReq := []byte{0x01, 0x00, 0x08, 0x00}
+	negReq = binary.LittleEndian.AppendUint32(negReq, rdpProtocolSSL|rdpProtocolHybrid)
+	x224 := append([]byte{byte(6 + len(negReq)), 0xe0, 0, 0, 0, 0, 0}, negReq...)
+	tpkt := binary.BigEndian.AppendUint16([]byte{0x03, 0x00}, uint16(4+len(x224)))
+	if _, err := conn.Write(append(tpkt, x224...)); err != nil {
+		return err
+	}
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	length := int(binary.BigEndian.Uint16(header[2:]))
+	if header[0] != 0x03 || length < 4 {
+		return errors.New("not a rdp service")
+	}
+	body := make([]byte, length-4)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return err
+	}
+	// X.224 Connection Confirm（7字节）后为RDP_NEG_RSP或RDP_NEG_FAILURE
+	if len(body) < 7+8 || body[1] != 0xd0 {
+		return errors.New("rdp service does not support nla")
+	}
+	neg := body[7:]
+	switch {
+	case neg[0] == rdpNegFailure:
+		return fmt.Errorf("rdp negotiation failure:%d", binary.LittleEndian.Uint32(neg[4:]))
+	case neg[0] != rdpNegResponse || binary.LittleEndian.Uint32(neg[4:])&rdpProtocolHybrid == 0:
+		return errors.New("rdp service does not support nla")
+	}
+	return nil
+}
+
+// rdpSubjectPublicKey 服务端证书的SubjectPublicKey，用于pubKeyAuth
+func rdpSubjectPublicKey(conn *tls.Conn) ([]byte, error) {
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, errors.New("no rdp server certificate")
+	}
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(certs[0].RawSubjectPublicKeyInfo, &info); err != nil {
+		return nil, err
+	}
+	return info.PublicKey.Bytes, nil
+}
+
+// tsErrorResult 根据TSRequest的errorCode判断认证结果
+func tsErrorResult(request tsRequest) (bool, error) {
+	if request.ErrorCode == 0 {
+		return false, errors.New("unexpected credssp response")
+	}
+	return ntStatusResult(uint32(request.ErrorCode))
+}
+
+func writeTSRequest(conn net.Conn, request tsRequest) error {
+	data, err := asn1.Marshal(request)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(data)
+	return err
+}
+
+// readTSRequest 读取一个DER编码的TSRequest
+func readTSRequest(conn net.Conn) (request tsRequest, err error) {
+	header := make([]byte, 2)
+	if _, err = io.ReadFull(conn, header); err != nil {
+		return
+	}
+	data := header
+	length := int(header[1])
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 3 {
+			return request, errors.New("invalid credssp message length")
+		}
+		lengthBytes := make([]byte, n)
+		if _, err = io.ReadFull(conn, lengthBytes); err != nil {
+			return
+		}
+		data = append(data, lengthBytes...)
+		length = 0
+		for _, b := range lengthBytes {
+			length = length<<8 | int(b)
+		}
+	}
+	body := make([]byte, length)
+	if _, err = io.ReadFull(conn, body); err != nil {
+		return
+	}
+	_, err = asn1.Unmarshal(append(data, body...), &request)
+	return
+}
@@ -0,0 +1,61 @@
+package weakpass
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type redisChecker struct{}
+
+// Check 空密码时使用PING验证未授权访问，否则使用AUTH认证（redis 6以上支持用户名）
+func (redisChecker) Check(ctx context.Context, target Target, cred Credential, timeout time.Duration) (bool, error) {
+	conn, err := dialTimeout(ctx, target, timeout)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	var args []string
+	switch {
+	case cred.Username == "" && cred.Password == "":
+		args = []string{"PING"}
+	case cred.Username == "" || cred.Username == "default":
+		args = []string{"AUTH", cred.Password}
+	default:
+		args = []string{"AUTH", cred.Username, cred.Password}
+	}
+	if _, err = conn.Write(redisCommand(args...)); err != nil {
+		return false, err
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	reply = strings.TrimSpace(reply)
+	switch {
+	case strings.HasPrefix(reply, "+"):
+		return true, nil
+	case strings.HasPrefix(reply, "-NOAUTH"), strings.HasPrefix(reply, "-WRONGPASS"), strings.HasPrefix(reply, "-ERR invalid password"):
+		return false, nil
+	case strings.HasPrefix(reply, "-ERR") && strings.Contains(reply, "without any password configured"):
+		// 未设置密码时AUTH返回错误，由空密码的PING验证未授权访问
+		return false, nil
+	case strings.HasPrefix(reply, "-DENIED"):
+		// protected mode，不允许外部访问
+		return false, fmt.Errorf("redis denied:%s", reply)
+	}
+	return false, fmt.Errorf("unexpected redis reply:%s", reply)
+}
+
+// redisCommand 按RESP协议编码命令
+func redisCommand(args ...string) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return []byte(sb.String())
+}
@@ -0,0 +1,142 @@
+package weakpass
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"time"
+)
+
+const (
+	defaultTimeout   = 5 * time.Second
+	defaultMaxErrors = 3
+)
+
+// Options 验证的参数
+type Options struct {
+	// Timeout 每次验证的超时时间
+	Timeout time.Duration
+	// Interval 同一主机两次验证之间的间隔，避免触发账号锁定策略
+	Interval time.Duration
+	// MaxAttempts 每个用户名最多尝试的密码数量，0为不限制
+	MaxAttempts int
+	// MaxErrors 连续出现网络或协议错误的次数，达到后放弃该服务
+	MaxErrors int
+	// StopOnSuccess 服务验证成功一组凭据后即停止
+	StopOnSuccess bool
+}
+
+// Result 验证成功的结果
+type Result struct {
+	Target     Target
+	Credential Credential
+	// Unauthorized 无需认证即可访问（空用户名及空密码）
+	Unauthorized bool
+}
+
+// Scanner 弱口令验证
+type Scanner struct {
+	Options Options
+	Dict    *Dictionary
+
+	checkers map[string]Checker
+}
+
+// NewScanner 创建验证对象，HTTP管理后台的定义从字典目录中加载
+func NewScanner(dict *Dictionary, options Options) *Scanner {
+	if options.Timeout <= 0 {
+		options.Timeout = defaultTimeout
+	}
+	if options.MaxErrors <= 0 {
+		options.MaxErrors = defaultMaxErrors
+	}
+	s := &Scanner{Options: options, Dict: dict, checkers: make(map[string]Checker)}
+	for service, checker := range checkers {
+		s.checkers[service] = checker
+	}
+	if panels, err := LoadHTTPPanels(filepath.Join(dict.Path, HTTPPanelFile)); err == nil {
+		s.checkers["http"] = &httpChecker{Panels: panels}
+		s.checkers["https"] = &httpChecker{Panels: panels}
+	}
+	return s
+}
+
+// ScanHost 依次验证同一主机上的服务；主机返回锁定时放弃其余的服务
+func (s *Scanner) ScanHost(ctx context.Context, targets []Target) (results []Result) {
+	for _, target := range targets {
+		checker, ok := s.checkers[target.Service]
+		if !ok {
+			continue
+		}
+		probed := []Target{target}
+		if prober, ok := checker.(Prober); ok {
+			probed = prober.Probe(ctx, target, s.Options.Timeout)
+		}
+		for _, t := range probed {
+			r, err := s.scanTarget(ctx, checker, t)
+			results = append(results, r...)
+			if errors.Is(err, ErrLockout) || ctx.Err() != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// scanTarget 使用字典验证一个服务
+func (s *Scanner) scanTarget(ctx context.Context, checker Checker, target Target) (results []Result, err error) {
+	attempts := make(map[string]int)
+	succeeded := make(map[string]bool)
+	var errorCount int
+	for _, cred := range s.Dict.Credentials(target.DictName()) {
+		if succeeded[cred.Username] {
+			continue
+		}
+		if s.Options.MaxAttempts > 0 && attempts[cred.Username] >= s.Options.MaxAttempts {
+			continue
+		}
+		if !s.wait(ctx, attempts) {
+			return results, ctx.Err()
+		}
+		attempts[cred.Username]++
+		ok, checkErr := checker.Check(ctx, target, cred, s.Options.Timeout)
+		if checkErr != nil {
+			if errors.Is(checkErr, ErrLockout) {
+				return results, checkErr
+			}
+			if errorCount++; errorCount >= s.Options.MaxErrors {
+				return results, checkErr
+			}
+			continue
+		}
+		errorCount = 0
+		if !ok {
+			continue
+		}
+		succeeded[cred.Username] = true
+		results = append(results, Result{
+			Target:       target,
+			Credential:   cred,
+			Unauthorized: cred.Username == "" && cred.Password == "",
+		})
+		if s.Options.StopOnSuccess {
+			return
+		}
+	}
+	return
+}
+
+// wait 在两次验证之间等待设定的间隔，任务取消时返回false
+func (s *Scanner) wait(ctx context.Context, attempts map[string]int) bool {
+	if s.Options.Interval <= 0 || len(attempts) == 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(s.Options.Interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
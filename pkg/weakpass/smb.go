@@ -0,0 +1,188 @@
+package weakpass
+
+import (
+	"bytes"
+	"context"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	smb2Negotiate    = 0
+	smb2SessionSetup = 1
+
+	smb2SessionFlagIsGuest = 0x0001
+	smb2SessionFlagIsNull  = 0x0002
+)
+
+// NTSTATUS
+const (
+	statusSuccess                = 0x00000000
+	statusMoreProcessingRequired = 0xC0000016
+	statusLogonFailure           = 0xC000006D
+	statusAccountRestriction     = 0xC000006E
+	statusPasswordExpired        = 0xC0000071
+	statusAccountDisabled        = 0xC0000072
+	statusAccountExpired         = 0xC0000193
+	statusPasswordMustChange     = 0xC0000224
+	statusAccountLockedOut       = 0xC0000234
+)
+
+var (
+	spnegoOID = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 2}
+	ntlmOID   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 2, 10}
+)
+
+type smbChecker struct{}
+
+// Check 使用SMB2的SESSION_SETUP进行NTLMv2认证；guest会话不认为是认证成功
+func (smbChecker) Check(ctx context.Context, target Target, cred Credential, timeout time.Duration) (bool, error) {
+	conn, err := dialTimeout(ctx, target, timeout)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	s := &smb2Conn{conn: conn}
+	// 协商SMB 2.0.2至3.0.2，不需要SMB 3.1.1的协商上下文
+	negotiate := make([]byte, 36)
+	binary.LittleEndian.PutUint16(negotiate, 36)
+	binary.LittleEndian.PutUint16(negotiate[2:], 4)
+	binary.LittleEndian.PutUint16(negotiate[4:], 1)
+	copy(negotiate[12:28], randomBytes(16))
+	for _, dialect := range []uint16{0x0202, 0x0210, 0x0300, 0x0302} {
+		negotiate = binary.LittleEndian.AppendUint16(negotiate, dialect)
+	}
+	if status, _, err := s.request(smb2Negotiate, negotiate); err != nil {
+		return false, err
+	} else if status != statusSuccess {
+		return false, fmt.Errorf("smb2 negotiate fail:0x%08x", status)
+	}
+
+	ntlm := newNTLMClient(cred)
+	status, body, err := s.request(smb2SessionSetup, smb2SessionSetupRequest(spnegoInit(ntlm.negotiate())))
+	if err != nil {
+		return false, err
+	}
+	if status != statusMoreProcessingRequired {
+		return false, fmt.Errorf("smb2 session setup fail:0x%08x", status)
+	}
+	challenge := extractNTLMMessage(body)
+	if challenge == nil {
+		return false, errors.New("no ntlm challenge in smb2 response")
+	}
+	authenticate, err := ntlm.authenticate(challenge)
+	if err != nil {
+		return false, err
+	}
+	status, body, err = s.request(smb2SessionSetup, smb2SessionSetupRequest(spnegoResponse(authenticate)))
+	if err != nil {
+		return false, err
+	}
+	if status == statusSuccess {
+		if len(body) < 4 {
+			return false, errors.New("invalid smb2 session setup response")
+		}
+		flags := binary.LittleEndian.Uint16(body[2:])
+		return flags&(smb2SessionFlagIsGuest|smb2SessionFlagIsNull) == 0, nil
+	}
+	return ntStatusResult(status)
+}
+
+// ntStatusResult 根据NTSTATUS判断认证结果：密码过期或需要修改时，密码本身是正确的
+func ntStatusResult(status uint32) (bool, error) {
+	switch status {
+	case statusPasswordExpired, statusPasswordMustChange:
+		return true, nil
+	case statusLogonFailure, statusAccountRestriction, statusAccountDisabled, statusAccountExpired:
+		return false, nil
+	case statusAccountLockedOut:
+		return false, ErrLockout
+	}
+	return false, fmt.Errorf("authentication fail:0x%08x", status)
+}
+
+// smb2Conn SMB2的请求及响应
+type smb2Conn struct {
+	conn      net.Conn
+	messageId uint64
+	sessionId uint64
+}
+
+// request 发送请求，返回响应的状态及内容
+func (s *smb2Conn) request(command uint16, body []byte) (status uint32, response []byte, err error) {
+	header := make([]byte, 64)
+	copy(header, "\xfeSMB")
+	binary.LittleEndian.PutUint16(header[4:], 64)
+	binary.LittleEndian.PutUint16(header[12:], command)
+	binary.LittleEndian.PutUint16(header[14:], 1)
+	binary.LittleEndian.PutUint64(header[24:], s.messageId)
+	binary.LittleEndian.PutUint64(header[40:], s.sessionId)
+	s.messageId++
+
+	packet := append(header, body...)
+	netbios := binary.BigEndian.AppendUint32(nil, uint32(len(packet)))
+	if _, err = s.conn.Write(append(netbios, packet...)); err != nil {
+		return
+	}
+	if _, err = io.ReadFull(s.conn, netbios); err != nil {
+		return
+	}
+	length := binary.BigEndian.Uint32(netbios) & 0xffffff
+	if length < 64 {
+		return 0, nil, errors.New("invalid smb2 response")
+	}
+	packet = make([]byte, length)
+	if _, err = io.ReadFull(s.conn, packet); err != nil {
+		return
+	}
+	if !bytes.Equal(packet[:4], []byte("\xfeSMB")) {
+		return 0, nil, errors.New("not a smb2 response")
+	}
+	s.sessionId = binary.LittleEndian.Uint64(packet[40:])
+	return binary.LittleEndian.Uint32(packet[8:]), packet[64:], nil
+}
+
+// smb2SessionSetupRequest SESSION_SETUP请求，安全缓冲区紧接在固定部分之后
+func smb2SessionSetupRequest(securityBuffer []byte) []byte {
+	body := make([]byte, 24)
+	binary.LittleEndian.PutUint16(body, 25)
+	body[3] = 1
+	binary.LittleEndian.PutUint16(body[12:], 64+24)
+	binary.LittleEndian.PutUint16(body[14:], uint16(len(securityBuffer)))
+	return append(body, securityBuffer...)
+}
+
+// spnegoInit 将NTLM的NEGOTIATE_MESSAGE封装为SPNEGO的NegTokenInit
+func spnegoInit(token []byte) []byte {
+	init, _ := asn1.Marshal(struct {
+		MechTypes []asn1.ObjectIdentifier `asn1:"explicit,tag:0"`
+		MechToken []byte                  `asn1:"explicit,tag:2"`
+	}{[]asn1.ObjectIdentifier{ntlmOID}, token})
+	negTokenInit, _ := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: init})
+	oid, _ := asn1.Marshal(spnegoOID)
+	gss, _ := asn1.Marshal(asn1.RawValue{Class: asn1.ClassApplication, Tag: 0, IsCompound: true, Bytes: append(oid, negTokenInit...)})
+	return gss
+}
+
+// spnegoResponse 将NTLM的AUTHENTICATE_MESSAGE封装为SPNEGO的NegTokenResp
+func spnegoResponse(token []byte) []byte {
+	resp, _ := asn1.Marshal(struct {
+		ResponseToken []byte `asn1:"explicit,tag:2"`
+	}{token})
+	negTokenResp, _ := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: resp})
+	return negTokenResp
+}
+
+// extractNTLMMessage 从SPNEGO的响应中提取NTLM消息
+func extractNTLMMessage(data []byte) []byte {
+	if i := bytes.Index(data, ntlmSignature); i >= 0 {
+		return data[i:]
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package weakpass
+
+import (
+	"context"
+	"golang.org/x/crypto/ssh"
+	"net"
+	"strings"
+	"time"
+)
+
+type sshChecker struct{}
+
+// Check 使用password及keyboard-interactive方式认证
+func (sshChecker) Check(ctx context.Context, target Target, cred Credential, timeout time.Duration) (bool, error) {
+	conn, err := dialTimeout(ctx, target, timeout)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	config := &ssh.ClientConfig{
+		User: cred.Username,
+		Auth: []ssh.AuthMethod{
+			ssh.Password(cred.Password),
+			ssh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = cred.Password
+				}
+				return answers, nil
+			}),
+		},
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error { return nil },
+		Timeout:         timeout,
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, target.Address(), config)
+	if err != nil {
+		// 认证失败的错误为：ssh: handshake failed: ssh: unable to authenticate...
+		if strings.Contains(err.Error(), "unable to authenticate") {
+			return false, nil
+		}
+		return false, err
+	}
+	client := ssh.NewClient(c, chans, reqs)
+	client.Close()
+	return true, nil
+}
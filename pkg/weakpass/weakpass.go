@@ -0,0 +1,144 @@
+package weakpass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Credential 一组待验证的用户名与密码
+type Credential struct {
+	Username string
+	Password string
+}
+
+// Target 一个待验证的服务
+type Target struct {
+	Service string
+	Host    string
+	Port    int
+	// Panel HTTP管理后台的名称，由探测得到
+	Panel string
+}
+
+// Checker 验证服务的一组凭据：认证失败时返回(false,nil)，网络或协议错误时返回error，账号或来源被锁定时返回ErrLockout
+type Checker interface {
+	Check(ctx context.Context, target Target, cred Credential, timeout time.Duration) (bool, error)
+}
+
+// Prober 需要先探测才能确定验证方式的服务（如HTTP管理后台），返回实际需要验证的目标
+type Prober interface {
+	Probe(ctx context.Context, target Target, timeout time.Duration) []Target
+}
+
+var (
+	// ErrLockout 账号或来源IP已被锁定，继续尝试可能导致更多账号被锁定
+	ErrLockout = errors.New("account or host locked out")
+
+	checkers = map[string]Checker{
+		"ssh":        sshChecker{},
+		"ftp":        ftpChecker{},
+		"mysql":      mysqlChecker{},
+		"postgresql": postgresChecker{},
+		"mssql":      mssqlChecker{},
+		"redis":      redisChecker{},
+		"mongodb":    mongodbChecker{},
+		"smb":        smbChecker{},
+		"rdp":        rdpChecker{},
+		"http":       &httpChecker{},
+		"https":      &httpChecker{},
+	}
+	// serviceAlias nmap识别的服务名称与支持的服务的对应关系
+	serviceAlias = map[string]string{
+		"ssh": "ssh", "ftp": "ftp", "mysql": "mysql", "postgresql": "postgresql", "postgres": "postgresql",
+		"ms-sql-s": "mssql", "mssql": "mssql", "redis": "redis", "mongodb": "mongodb", "mongod": "mongodb",
+		"microsoft-ds": "smb", "smb": "smb", "ms-wbt-server": "rdp", "rdp": "rdp",
+		"http": "http", "http-proxy": "http", "http-alt": "http", "https": "https", "https-alt": "https", "ssl/http": "https",
+	}
+	// servicePort 没有服务名称时根据默认端口确定服务
+	servicePort = map[int]string{
+		21: "ftp", 22: "ssh", 445: "smb", 1433: "mssql", 3306: "mysql", 3389: "rdp", 5432: "postgresql", 6379: "redis", 27017: "mongodb",
+	}
+)
+
+// Address 服务的地址
+func (t Target) Address() string {
+	return net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+}
+
+// String 服务的URI，如ssh://127.0.0.1:22
+func (t Target) String() string {
+	return fmt.Sprintf("%s://%s", t.Service, t.Address())
+}
+
+// DictName 使用的字典名称：HTTP管理后台使用后台名称，其它使用服务名称
+func (t Target) DictName() string {
+	if t.Panel != "" {
+		return t.Panel
+	}
+	return t.Service
+}
+
+// ParseTarget 解析服务的URI
+func ParseTarget(uri string) (t Target, err error) {
+	service, address, found := strings.Cut(strings.TrimSpace(uri), "://")
+	if !found {
+		return t, fmt.Errorf("invalid target:%s", uri)
+	}
+	host, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		return t, err
+	}
+	port, err := strconv.Atoi(portString)
+	if err != nil || port <= 0 || port > 65535 {
+		return t, fmt.Errorf("invalid port:%s", uri)
+	}
+	t = Target{Service: strings.ToLower(service), Host: host, Port: port}
+	if _, ok := checkers[t.Service]; !ok {
+		return t, fmt.Errorf("unsupported service:%s", t.Service)
+	}
+	return t, nil
+}
+
+// ServiceName 根据nmap识别的服务名称或端口，得到支持的服务名称，不支持时返回空
+func ServiceName(service string, port int) string {
+	service = strings.ToLower(strings.TrimSpace(service))
+	// 如nmap的ssl/https、tcpwrapped等
+	if name, ok := serviceAlias[service]; ok {
+		return name
+	}
+	if strings.HasPrefix(service, "ssl/") || strings.HasSuffix(service, "https") {
+		if name, ok := serviceAlias[strings.TrimPrefix(service, "ssl/")]; ok && name == "http" {
+			return "https"
+		}
+	}
+	if service == "" || service == "unknown" || service == "tcpwrapped" {
+		return servicePort[port]
+	}
+	return ""
+}
+
+// Services 支持的服务
+func Services() (services []string) {
+	for s := range checkers {
+		services = append(services, s)
+	}
+	sort.Strings(services)
+	return
+}
+
+// dialTimeout 建立TCP连接，并设置整个会话的超时时间
+func dialTimeout(ctx context.Context, target Target, timeout time.Duration) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", target.Address())
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	return conn, nil
+}
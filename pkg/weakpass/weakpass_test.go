@@ -0,0 +1,157 @@
+package weakpass
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseTarget(t *testing.T) {
+	target, err := ParseTarget("SSH://127.0.0.1:2222")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Service != "ssh" || target.Host != "127.0.0.1" || target.Port != 2222 || target.String() != "ssh://127.0.0.1:2222" {
+		t.Errorf("unexpected target:%v", target)
+	}
+	for _, s := range []string{"127.0.0.1:22", "telnet://127.0.0.1:23", "ssh://127.0.0.1:0", "ssh://127.0.0.1"} {
+		if _, err = ParseTarget(s); err == nil {
+			t.Errorf("invalid target should be rejected:%s", s)
+		}
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	for _, c := range []struct {
+		service string
+		port    int
+		name    string
+	}{
+		{"ms-sql-s", 1433, "mssql"},
+		{"microsoft-ds", 445, "smb"},
+		{"ms-wbt-server", 3389, "rdp"},
+		{"ssl/http", 8443, "https"},
+		{"", 6379, "redis"},
+		{"unknown", 5432, "postgresql"},
+		{"telnet", 23, ""},
+		{"", 8888, ""},
+	} {
+		if name := ServiceName(c.service, c.port); name != c.name {
+			t.Errorf("%s:%d should be %s, got %s", c.service, c.port, c.name, name)
+		}
+	}
+}
+
+func TestDictionary_Credentials(t *testing.T) {
+	path := t.TempDir()
+	os.WriteFile(filepath.Join(path, "user_ssh.txt"), []byte("root\n# comment\n\nadmin\n"), 0644)
+	os.WriteFile(filepath.Join(path, "password_ssh.txt"), []byte("toor\n"), 0644)
+	os.WriteFile(filepath.Join(path, "password.txt"), []byte("{user}\n{empty}\ntoor\n{user}123\n"), 0644)
+
+	creds := NewDictionary(path).Credentials("ssh")
+	expected := []Credential{
+		{"root", "toor"}, {"root", "root"}, {"root", ""}, {"root", "root123"},
+		{"admin", "toor"}, {"admin", "admin"}, {"admin", ""}, {"admin", "admin123"},
+	}
+	if len(creds) != len(expected) {
+		t.Fatalf("unexpected credentials:%v", creds)
+	}
+	for i := range creds {
+		if creds[i] != expected[i] {
+			t.Errorf("unexpected credential %d:%v", i, creds[i])
+		}
+	}
+	// 没有用户名字典的服务只验证密码，{user}替换后与空密码重复
+	creds = NewDictionary(path).Credentials("redis")
+	if len(creds) != 3 || creds[0] != (Credential{}) || creds[1].Password != "toor" || creds[2].Password != "123" {
+		t.Errorf("unexpected redis credentials:%v", creds)
+	}
+	// 空用户名只验证一次未授权访问
+	os.WriteFile(filepath.Join(path, "user_mongodb.txt"), []byte("{empty}\nadmin\n"), 0644)
+	creds = NewDictionary(path).Credentials("mongodb")
+	if len(creds) != 5 || creds[0] != (Credential{}) || creds[1].Username != "admin" {
+		t.Errorf("unexpected mongodb credentials:%v", creds)
+	}
+}
+
+// fakeChecker 按预设的结果验证
+type fakeChecker struct {
+	valid    map[Credential]bool
+	errs     map[Credential]error
+	attempts []Credential
+}
+
+func (f *fakeChecker) Check(ctx context.Context, target Target, cred Credential, timeout time.Duration) (bool, error) {
+	f.attempts = append(f.attempts, cred)
+	return f.valid[cred], f.errs[cred]
+}
+
+func newFakeScanner(t *testing.T, checker Checker, options Options) *Scanner {
+	path := t.TempDir()
+	os.WriteFile(filepath.Join(path, "user_fake.txt"), []byte("root\nadmin\n"), 0644)
+	os.WriteFile(filepath.Join(path, "password.txt"), []byte("123456\n{user}\npassword\n"), 0644)
+	s := NewScanner(NewDictionary(path), options)
+	s.checkers["fake"] = checker
+	return s
+}
+
+func TestScanner_ScanHost(t *testing.T) {
+	target := Target{Service: "fake", Host: "127.0.0.1", Port: 1}
+
+	f := &fakeChecker{valid: map[Credential]bool{{"root", "root"}: true, {"admin", "password"}: true}}
+	results := newFakeScanner(t, f, Options{}).ScanHost(context.Background(), []Target{target})
+	if len(results) != 2 || results[0].Credential != (Credential{"root", "root"}) || results[1].Credential != (Credential{"admin", "password"}) {
+		t.Errorf("unexpected results:%v", results)
+	}
+	// root验证成功后不再尝试root的其它密码
+	if len(f.attempts) != 5 {
+		t.Errorf("unexpected attempts:%v", f.attempts)
+	}
+
+	f = &fakeChecker{valid: map[Credential]bool{{"root", "root"}: true, {"admin", "password"}: true}}
+	results = newFakeScanner(t, f, Options{StopOnSuccess: true}).ScanHost(context.Background(), []Target{target})
+	if len(results) != 1 || len(f.attempts) != 2 {
+		t.Errorf("should stop on success:%v %v", results, f.attempts)
+	}
+
+	f = &fakeChecker{}
+	newFakeScanner(t, f, Options{MaxAttempts: 1}).ScanHost(context.Background(), []Target{target})
+	if len(f.attempts) != 2 {
+		t.Errorf("should limit attempts per user:%v", f.attempts)
+	}
+
+	// 被锁定时放弃主机的其它服务
+	f = &fakeChecker{errs: map[Credential]error{{"root", "123456"}: ErrLockout}}
+	newFakeScanner(t, f, Options{}).ScanHost(context.Background(), []Target{target, target})
+	if len(f.attempts) != 1 {
+		t.Errorf("should abort host on lockout:%v", f.attempts)
+	}
+
+	// 连续出错后放弃该服务
+	connErr := errors.New("connection refused")
+	f = &fakeChecker{errs: map[Credential]error{{"root", "123456"}: connErr, {"root", "root"}: connErr, {"root", "password"}: connErr}}
+	newFakeScanner(t, f, Options{}).ScanHost(context.Background(), []Target{target})
+	if len(f.attempts) != 3 {
+		t.Errorf("should abort target after errors:%v", f.attempts)
+	}
+
+	f = &fakeChecker{}
+	start := time.Now()
+	newFakeScanner(t, f, Options{Interval: 20 * time.Millisecond, MaxAttempts: 1}).ScanHost(context.Background(), []Target{target})
+	if time.Since(start) < 20*time.Millisecond {
+		t.Errorf("should wait interval between attempts")
+	}
+}
+
+func TestLoadHTTPPanels(t *testing.T) {
+	panels, err := LoadHTTPPanels(filepath.Join("../../thirdparty/dict/weakpass", HTTPPanelFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(panels) == 0 || panels[0].Name != "tomcat-manager" || len(panels[0].Login.Status) == 0 {
+		t.Errorf("unexpected panels:%v", panels)
+	}
+}
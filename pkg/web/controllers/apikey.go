@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/apikeypool"
+	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/onlineapi"
+	"time"
+)
+
+type APIKeyController struct {
+	BaseController
+}
+
+// APIKeyData key池中的key及使用量，key已脱敏
+type APIKeyData struct {
+	Id              int    `json:"id"`
+	Engine          string `json:"engine"`
+	Key             string `json:"key"`
+	Enabled         bool   `json:"enabled"`
+	Status          string `json:"status"`
+	Quota           int    `json:"quota"`
+	LastError       string `json:"last_error"`
+	CooldownTime    string `json:"cooldown_datetime"`
+	CheckTime       string `json:"check_datetime"`
+	LeaseTime       string `json:"lease_datetime"`
+	WorkspaceQuery  int    `json:"workspace_query"`
+	WorkspaceResult int    `json:"workspace_result"`
+	TotalQuery      int    `json:"total_query"`
+	TotalError      int    `json:"total_error"`
+	IsCoolingDown   bool   `json:"cooling_down"`
+}
+
+// ListAction key池中的全部key及当前workspace的使用量
+func (c *APIKeyController) ListAction() {
+	defer c.ServeJSON()
+	if c.CheckMultiAccessRequest([]RequestRole{SuperAdmin, Admin}, false) == false {
+		c.FailedStatus("当前用户权限不允许！")
+		return
+	}
+
+	usageDb := db.APIKeyUsage{}
+	workspaceUsage := make(map[int]db.APIKeyUsageSum)
+	if workspaceId := c.GetCurrentWorkspace(); workspaceId > 0 {
+		workspaceUsage = usageDb.SumByKey(workspaceId)
+	}
+	totalUsage := usageDb.SumByKey(0)
+	now := time.Now()
+	keyDb := db.APIKey{Engine: c.GetString("engine")}
+	result := make([]APIKeyData, 0)
+	for _, row := range keyDb.Gets() {
+		data := APIKeyData{
+			Id:              row.Id,
+			Engine:          row.Engine,
+			Key:             onlineapi.DesensitizeKey(row.APIKey),
+			Enabled:         row.Enabled,
+			Status:          row.Status,
+			Quota:           row.Quota,
+			LastError:       row.LastError,
+			CooldownTime:    formatNullDateTime(row.CooldownDatetime),
+			CheckTime:       formatNullDateTime(row.CheckDatetime),
+			LeaseTime:       formatNullDateTime(row.LeaseDatetime),
+			WorkspaceQuery:  workspaceUsage[row.Id].QueryCount,
+			WorkspaceResult: workspaceUsage[row.Id].ResultCount,
+			TotalQuery:      totalUsage[row.Id].QueryCount,
+			TotalError:      totalUsage[row.Id].ErrorCount,
+			IsCoolingDown:   row.CooldownDatetime != nil && now.Before(*row.CooldownDatetime),
+		}
+		result = append(result, data)
+	}
+	c.Data["json"] = result
+}
+
+// AddAction 添加以,分隔的多个key
+func (c *APIKeyController) AddAction() {
+	defer c.ServeJSON()
+	if c.CheckMultiAccessRequest([]RequestRole{SuperAdmin, Admin}, false) == false {
+		c.FailedStatus("当前用户权限不允许！")
+		return
+	}
+
+	engine := c.GetString("engine")
+	if !isPoolEngine(engine) {
+		c.FailedStatus("不支持的API！")
+		return
+	}
+	count := apikeypool.GetPool().Import(engine, c.GetString("keys"))
+	c.SucceededStatus(fmt.Sprintf("添加key：%d", count))
+}
+
+// EnableAction 启用或禁用一个key，启用时清除无效及冷却的状态
+func (c *APIKeyController) EnableAction() {
+	defer c.ServeJSON()
+	if c.CheckMultiAccessRequest([]RequestRole{SuperAdmin, Admin}, false) == false {
+		c.FailedStatus("当前用户权限不允许！")
+		return
+	}
+
+	k, ok := c.getAPIKey()
+	if !ok {
+		return
+	}
+	enabled, _ := c.GetBool("enabled")
+	updateMap := map[string]interface{}{"enabled": enabled}
+	if enabled {
+		updateMap["status"] = onlineapi.KeyStatusUnknown
+		updateMap["cooldown_datetime"] = nil
+	}
+	c.MakeStatusResponse(k.Update(updateMap))
+}
+
+// DeleteAction 删除一个key及其使用量
+func (c *APIKeyController) DeleteAction() {
+	defer c.ServeJSON()
+	if c.CheckMultiAccessRequest([]RequestRole{SuperAdmin, Admin}, false) == false {
+		c.FailedStatus("当前用户权限不允许！")
+		return
+	}
+
+	k, ok := c.getAPIKey()
+	if !ok {
+		return
+	}
+	c.MakeStatusResponse(k.Delete())
+}
+
+// CheckAction 立即查询一个key（id为0时全部key）的剩余额度
+func (c *APIKeyController) CheckAction() {
+	defer c.ServeJSON()
+	if c.CheckMultiAccessRequest([]RequestRole{SuperAdmin, Admin}, false) == false {
+		c.FailedStatus("当前用户权限不允许！")
+		return
+	}
+
+	if id, _ := c.GetInt("id"); id == 0 {
+		apikeypool.GetPool().CheckQuota(true)
+		c.SucceededStatus("查询完成")
+		return
+	}
+	k, ok := c.getAPIKey()
+	if !ok {
+		return
+	}
+	apikeypool.GetPool().CheckKey(k)
+	c.SucceededStatus("查询完成")
+}
+
+// getAPIKey 根据请求的id获取key
+func (c *APIKeyController) getAPIKey() (k db.APIKey, ok bool) {
+	id, err := c.GetInt("id")
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		c.FailedStatus(err.Error())
+		return
+	}
+	k = db.APIKey{Id: id}
+	if !k.Get() {
+		c.FailedStatus("key不存在！")
+		return
+	}
+	return k, true
+}
+
+// isPoolEngine 是否为使用key池的在线API
+func isPoolEngine(engine string) bool {
+	for _, e := range apikeypool.Engines {
+		if e == engine {
+			return true
+		}
+	}
+	return false
+}
+
+// formatNullDateTime 格式化可为空的时间
+func formatNullDateTime(dt *time.Time) string {
+	if dt == nil {
+		return ""
+	}
+	return FormatDateTime(*dt)
+}
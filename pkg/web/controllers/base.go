@@ -57,6 +57,7 @@ type DataTableResponseData struct {
 	RecordsTotal    int           `json:"recordsTotal"`
 	RecordsFiltered int           `json:"recordsFiltered"`
 	Data            []interface{} `json:"data"`
+	Error           string        `json:"error,omitempty"`
 }
 
 // OnlineUserInfo 在线用户
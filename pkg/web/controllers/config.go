@@ -3,15 +3,20 @@ package controllers
 import (
 	"encoding/base64"
 	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/apikeypool"
 	"github.com/hanc00l/nemo_go/pkg/comm"
 	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/db"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/notify"
 	"github.com/hanc00l/nemo_go/pkg/task/ampq"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
+	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
 	"github.com/hanc00l/nemo_go/pkg/task/onlineapi"
+	"github.com/hanc00l/nemo_go/pkg/task/pocscan"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"github.com/remeh/sizedwaitgroup"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
@@ -37,6 +42,7 @@ const (
 	TaskWorkspace          string = "task_workspace"
 	FOFAFilterKeyword      string = "fofa_filter_keyword"
 	FOFAFilterKeywordLocal string = "fofa_filter_keyword_local"
+	FingerprintPoc         string = "fingerprint_poc"
 )
 
 type DefaultConfig struct {
@@ -60,12 +66,22 @@ type DefaultConfig struct {
 	IsFofa           bool   `json:"fofa" form:"fofa"`
 	IsQuake          bool   `json:"quake" form:"quake"`
 	IsHunter         bool   `json:"hunter" form:"hunter"`
+	IsShodan         bool   `json:"shodan" form:"shodan"`
+	IsCensys         bool   `json:"censys" form:"censys"`
+	IsZoomEye        bool   `json:"zoomeye" form:"zoomeye"`
+	IsNetlas         bool   `json:"netlas" form:"netlas"`
+	IsBinaryEdge     bool   `json:"binaryedge" form:"binaryedge"`
 	ServerChanToken  string `json:"serverchan" form:"serverchan"`
 	DingTalkToken    string `json:"dingtalk" form:"dingtalk"`
 	FeishuToken      string `json:"feishu" form:"feishu"`
 	FofaToken        string `json:"fofatoken" form:"fofatoken"`
 	HunterToken      string `json:"huntertoken" form:"huntertoken"`
 	QuakeToken       string `json:"quaketoken" form:"quaketoken"`
+	ShodanToken      string `json:"shodantoken" form:"shodantoken"`
+	CensysToken      string `json:"censystoken" form:"censystoken"`
+	ZoomEyeToken     string `json:"zoomeyetoken" form:"zoomeyetoken"`
+	NetlasToken      string `json:"netlastoken" form:"netlastoken"`
+	BinaryEdgeToken  string `json:"binaryedgetoken" form:"binaryedgetoken"`
 	ChinazToken      string `json:"chinaztoken" form:"chinaztoken"`
 	SearchPageSize   int    `json:"pagesize" form:"pagesize"`
 	SearchLimitCount int    `json:"limitcount" form:"limitcount"`
@@ -74,11 +90,14 @@ type DefaultConfig struct {
 	IsSubDomainFinder  bool   `json:"subfinder" form:"subfinder"`
 	IsSubDomainBrute   bool   `json:"subdomainbrute" form:"subdomainbrute"`
 	IsSubDomainCrawler bool   `json:"subdomaincrawler" form:"subdomaincrawler"`
+	IsSubDomainPassive bool   `json:"subdomainpassive" form:"subdomainpassive"`
+	IsSubDomainAlter   bool   `json:"subdomainalteration" form:"subdomainalteration"`
 	IsIgnoreCDN        bool   `json:"ignorecdn" form:"ignorecdn"`
 	IsIgnoreOutofChina bool   `json:"ignoreoutofchina" form:"ignoreoutofchina"`
 	IsPortscan         bool   `json:"portscan" form:"portscan"`
 	IsWhois            bool   `json:"whois" form:"whois"`
 	IsICP              bool   `json:"icp" form:"icp"`
+	IsDNSRecord        bool   `json:"dnsrecord" form:"dnsrecord"`
 }
 
 func (c *ConfigController) IndexAction() {
@@ -128,24 +147,37 @@ func (c *ConfigController) LoadDefaultConfigAction() {
 		DingTalkToken:   notifyToken["dingtalk"].Token,
 		FeishuToken:     notifyToken["feishu"].Token,
 		//
-		FofaToken:   apiConfig.Fofa.Key,
-		HunterToken: apiConfig.Hunter.Key,
-		QuakeToken:  apiConfig.Quake.Key,
-		ChinazToken: apiConfig.ICP.Key,
+		FofaToken:       apiConfig.Fofa.Key,
+		HunterToken:     apiConfig.Hunter.Key,
+		QuakeToken:      apiConfig.Quake.Key,
+		ShodanToken:     apiConfig.Shodan.Key,
+		CensysToken:     apiConfig.Censys.Key,
+		ZoomEyeToken:    apiConfig.ZoomEye.Key,
+		NetlasToken:     apiConfig.Netlas.Key,
+		BinaryEdgeToken: apiConfig.BinaryEdge.Key,
+		ChinazToken:     apiConfig.ICP.Key,
 		//
 		Wordlist:           domainscan.Wordlist,
 		IsSubDomainFinder:  domainscan.IsSubDomainFinder,
 		IsSubDomainBrute:   domainscan.IsSubDomainBrute,
 		IsSubDomainCrawler: domainscan.IsSubdomainCrawler,
+		IsSubDomainPassive: domainscan.IsSubDomainPassive,
+		IsSubDomainAlter:   domainscan.IsSubDomainAlteration,
 		IsIgnoreCDN:        domainscan.IsIgnoreCDN,
 		IsIgnoreOutofChina: domainscan.IsIgnoreOutofChina,
 		IsPortscan:         domainscan.IsPortScan,
 		IsWhois:            domainscan.IsWhois,
 		IsICP:              domainscan.IsICP,
+		IsDNSRecord:        domainscan.IsDNSRecord,
 		//onlineAPI:
 		IsFofa:           onlineapi.IsFofa,
 		IsHunter:         onlineapi.IsHunter,
 		IsQuake:          onlineapi.IsQuake,
+		IsShodan:         onlineapi.IsShodan,
+		IsCensys:         onlineapi.IsCensys,
+		IsZoomEye:        onlineapi.IsZoomEye,
+		IsNetlas:         onlineapi.IsNetlas,
+		IsBinaryEdge:     onlineapi.IsBinaryEdge,
 		SearchPageSize:   apiConfig.SearchPageSize,
 		SearchLimitCount: apiConfig.SearchLimitCount,
 	}
@@ -239,6 +271,13 @@ func (c *ConfigController) SaveCustomConfigAction() {
 		c.FailedStatus("错误的类型")
 		return
 	}
+	// 指纹与POC的对应关系保存前先校验格式
+	if customType == FingerprintPoc {
+		if _, err := pocscan.ParseFingerprintPocMap([]byte(customContent)); err != nil {
+			c.FailedStatus(err.Error())
+			return
+		}
+	}
 	err := os.WriteFile(filepath.Join(conf.GetRootPath(), "thirdparty", customFile), []byte(customContent), 0666)
 	if err != nil {
 		c.FailedStatus(err.Error())
@@ -350,9 +389,19 @@ func (c *ConfigController) SaveAPITokenAction() {
 	conf.GlobalWorkerConfig().OnlineAPI.IsFofa = data.IsFofa
 	conf.GlobalWorkerConfig().OnlineAPI.IsQuake = data.IsQuake
 	conf.GlobalWorkerConfig().OnlineAPI.IsHunter = data.IsHunter
+	conf.GlobalWorkerConfig().OnlineAPI.IsShodan = data.IsShodan
+	conf.GlobalWorkerConfig().OnlineAPI.IsCensys = data.IsCensys
+	conf.GlobalWorkerConfig().OnlineAPI.IsZoomEye = data.IsZoomEye
+	conf.GlobalWorkerConfig().OnlineAPI.IsNetlas = data.IsNetlas
+	conf.GlobalWorkerConfig().OnlineAPI.IsBinaryEdge = data.IsBinaryEdge
 	conf.GlobalWorkerConfig().API.Fofa.Key = data.FofaToken
 	conf.GlobalWorkerConfig().API.Hunter.Key = data.HunterToken
 	conf.GlobalWorkerConfig().API.Quake.Key = data.QuakeToken
+	conf.GlobalWorkerConfig().API.Shodan.Key = data.ShodanToken
+	conf.GlobalWorkerConfig().API.Censys.Key = data.CensysToken
+	conf.GlobalWorkerConfig().API.ZoomEye.Key = data.ZoomEyeToken
+	conf.GlobalWorkerConfig().API.Netlas.Key = data.NetlasToken
+	conf.GlobalWorkerConfig().API.BinaryEdge.Key = data.BinaryEdgeToken
 	conf.GlobalWorkerConfig().API.ICP.Key = data.ChinazToken
 	conf.GlobalWorkerConfig().API.SearchLimitCount = data.SearchLimitCount
 	conf.GlobalWorkerConfig().API.SearchPageSize = data.SearchPageSize
@@ -362,6 +411,10 @@ func (c *ConfigController) SaveAPITokenAction() {
 		c.FailedStatus(err.Error())
 		return
 	}
+	// 新的key同时导入到key池，worker从key池租用key
+	for engine, keys := range onlineapi.ConfigKeys(conf.GlobalWorkerConfig().API) {
+		apikeypool.GetPool().Import(engine, keys)
+	}
 	c.SucceededStatus("保存配置成功")
 }
 
@@ -388,17 +441,12 @@ func (c *ConfigController) TestOnlineAPIKeyAction() {
 	}()
 
 	apiKeys := conf.GlobalWorkerConfig().API
-	if len(apiKeys.Fofa.Key) > 0 {
-		swg.Add()
-		go testOnineAPI("fofa", &swg, msgChan)
-	}
-	if len(apiKeys.Hunter.Key) > 0 {
-		swg.Add()
-		go testOnineAPI("hunter", &swg, msgChan)
-	}
-	if len(apiKeys.Quake.Key) > 0 {
-		swg.Add()
-		go testOnineAPI("quake", &swg, msgChan)
+	for _, engine := range apikeypool.Engines {
+		keyDb := db.APIKey{Engine: engine}
+		if keyDb.Count() > 0 {
+			swg.Add()
+			go testOnineAPI(engine, &swg, msgChan)
+		}
 	}
 	if len(apiKeys.ICP.Key) > 0 {
 		swg.Add()
@@ -454,6 +502,51 @@ func (c *ConfigController) SaveFingerprintAction() {
 	c.SucceededStatus("保存配置成功")
 }
 
+// ReFingerprintAction 在后台对当前工作空间已保存的http响应重新进行指纹匹配
+func (c *ConfigController) ReFingerprintAction() {
+	defer c.ServeJSON()
+	if c.CheckMultiAccessRequest([]RequestRole{SuperAdmin, Admin}, false) == false {
+		c.FailedStatus("当前用户权限不允许！")
+		return
+	}
+	workspaceId := c.GetCurrentWorkspace()
+	if workspaceId <= 0 {
+		c.FailedStatus("未选择当前的工作空间！")
+		return
+	}
+	if err := fingerprint.StartReFingerprint(workspaceId); err != nil {
+		logging.RuntimeLog.Error(err)
+		c.FailedStatus("当前工作空间正在重新匹配指纹，请稍后再试！")
+		return
+	}
+	c.SucceededStatus("已开始在后台重新匹配指纹")
+}
+
+// ReFingerprintStatusAction 获取当前工作空间重新匹配指纹的状态
+func (c *ConfigController) ReFingerprintStatusAction() {
+	defer c.ServeJSON()
+	if c.CheckMultiAccessRequest([]RequestRole{SuperAdmin, Admin}, false) == false {
+		c.FailedStatus("当前用户权限不允许！")
+		return
+	}
+	status, ok := fingerprint.GetReFingerprintStatus(c.GetCurrentWorkspace())
+	if !ok {
+		c.FailedStatus("当前工作空间没有执行重新匹配指纹！")
+		return
+	}
+	if status.Running {
+		c.Data["json"] = StatusResponseData{Status: "running", Msg: fmt.Sprintf("开始时间：%s", status.StartTime.Format("2006-01-02 15:04:05"))}
+		return
+	}
+	if status.Error != "" {
+		c.FailedStatus(status.Error)
+		return
+	}
+	r := status.Result
+	c.SucceededStatus(fmt.Sprintf("端口：%d，变化：%d；域名：%d，变化：%d；新增指纹：%d，删除指纹：%d",
+		r.PortTotal, r.PortChanged, r.DomainTotal, r.DomainChanged, r.AttrAdded, r.AttrRemoved))
+}
+
 // SaveDomainscanAction 保存默认域名任务的设置
 func (c *ConfigController) SaveDomainscanAction() {
 	defer c.ServeJSON()
@@ -478,11 +571,14 @@ func (c *ConfigController) SaveDomainscanAction() {
 	conf.GlobalWorkerConfig().Domainscan.IsSubDomainFinder = data.IsSubDomainFinder
 	conf.GlobalWorkerConfig().Domainscan.IsSubDomainBrute = data.IsSubDomainBrute
 	conf.GlobalWorkerConfig().Domainscan.IsSubdomainCrawler = data.IsSubDomainCrawler
+	conf.GlobalWorkerConfig().Domainscan.IsSubDomainPassive = data.IsSubDomainPassive
+	conf.GlobalWorkerConfig().Domainscan.IsSubDomainAlteration = data.IsSubDomainAlter
 	conf.GlobalWorkerConfig().Domainscan.IsIgnoreCDN = data.IsIgnoreCDN
 	conf.GlobalWorkerConfig().Domainscan.IsIgnoreOutofChina = data.IsIgnoreOutofChina
 	conf.GlobalWorkerConfig().Domainscan.IsPortScan = data.IsPortscan
 	conf.GlobalWorkerConfig().Domainscan.IsICP = data.IsICP
 	conf.GlobalWorkerConfig().Domainscan.IsWhois = data.IsWhois
+	conf.GlobalWorkerConfig().Domainscan.IsDNSRecord = data.IsDNSRecord
 	err = conf.GlobalWorkerConfig().WriteConfig()
 	if err != nil {
 		logging.RuntimeLog.Error("save config file error:", err)
@@ -559,12 +655,25 @@ func (c *ConfigController) UploadPocAction() {
 		c.FailedStatus("invalid file type!")
 		return
 	}
+	// nuclei模板校验通过后保存，并更新模板索引
+	if pocType == "nuclei" {
+		content, err := io.ReadAll(f)
+		if err != nil {
+			c.FailedStatus(err.Error())
+			return
+		}
+		if _, err = pocscan.SaveNucleiTemplate(h.Filename, content); err != nil {
+			logging.RuntimeLog.Warningf("save nuclei template %s fail:%v", h.Filename, err)
+			c.FailedStatus(err.Error())
+			return
+		}
+		c.SucceededStatus("上传成功")
+		return
+	}
 	// 保存到poc目录下
 	var pocSavedPathName string
 	if pocType == "xray" {
 		pocSavedPathName = filepath.Join(conf.GetRootPath(), conf.GlobalWorkerConfig().Pocscan.Xray.PocPath, h.Filename)
-	} else if pocType == "nuclei" {
-		pocSavedPathName = filepath.Join(conf.GetRootPath(), conf.GlobalWorkerConfig().Pocscan.Nuclei.PocPath, h.Filename)
 	}
 	if pocSavedPathName == "" {
 		logging.RuntimeLog.Error("get poc save path from config file error")
@@ -611,6 +720,8 @@ func getCustomFilename(customType string) (customFile string) {
 		customFile = "custom/onlineapi_filter_keyword.txt"
 	case FOFAFilterKeywordLocal:
 		customFile = "custom/onlineapi_filter_keyword_local.txt"
+	case FingerprintPoc:
+		customFile = "custom/fingerprint_poc.json"
 	}
 
 	return
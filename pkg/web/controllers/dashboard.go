@@ -5,10 +5,12 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/comm"
 	"github.com/hanc00l/nemo_go/pkg/db"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/metrics"
 	"github.com/hanc00l/nemo_go/pkg/task/ampq"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/runner"
 	"github.com/hanc00l/nemo_go/pkg/utils"
+	"sort"
 	"strings"
 	"time"
 )
@@ -25,16 +27,18 @@ type DashboardStatisticData struct {
 }
 
 type WorkerStatusData struct {
-	Index                    int    `json:"index"`
-	WorkName                 string `json:"worker_name"`
-	WorkerTopic              string `json:"worker_topic"`
-	CreateTime               string `json:"create_time"`
-	UpdateTime               string `json:"update_time"`
-	TaskExecutedNumber       int    `json:"task_number"`
-	TaskStartedNumber        int    `json:"started_number"`
-	EnableManualReloadFlag   bool   `json:"enable_manual_reload_flag"`
-	EnableManualFileSyncFlag bool   `json:"enable_manual_file_sync_flag"`
-	HeartColor               string `json:"heart_color"`
+	Index                    int                       `json:"index"`
+	WorkName                 string                    `json:"worker_name"`
+	WorkerTopic              string                    `json:"worker_topic"`
+	CreateTime               string                    `json:"create_time"`
+	UpdateTime               string                    `json:"update_time"`
+	TaskExecutedNumber       int                       `json:"task_number"`
+	TaskStartedNumber        int                       `json:"started_number"`
+	EnableManualReloadFlag   bool                      `json:"enable_manual_reload_flag"`
+	EnableManualFileSyncFlag bool                      `json:"enable_manual_file_sync_flag"`
+	HeartColor               string                    `json:"heart_color"`
+	GobyPool                 []ampq.GobyInstanceStatus `json:"goby_pool"`
+	Capability               ampq.WorkerCapability     `json:"capability"`
 }
 
 type TaskInfoData struct {
@@ -47,6 +51,19 @@ type StartedTaskInfo struct {
 	TaskStarting string `json:"task_starting"`
 }
 
+type TaskStateMetricData struct {
+	TaskName string `json:"task_name"`
+	Started  int    `json:"started"`
+	Success  int    `json:"success"`
+	Failure  int    `json:"failure"`
+	Revoked  int    `json:"revoked"`
+}
+
+type DashboardMetricsData struct {
+	QueueDepth map[string]int        `json:"queue_depth"`
+	TaskState  []TaskStateMetricData `json:"task_state"`
+}
+
 type OnlineUserInfoData struct {
 	Index        int    `json:"index"`
 	IP           string `json:"ip"`
@@ -136,6 +153,46 @@ func (c *DashboardController) GetStartedTaskInfoAction() {
 	c.Data["json"] = tis
 }
 
+// GetMetricsAction 获取任务队列及任务状态的指标（与/metrics接口使用相同的计数）
+func (c *DashboardController) GetMetricsAction() {
+	defer c.ServeJSON()
+
+	data := DashboardMetricsData{QueueDepth: make(map[string]int)}
+	for topic, v := range metrics.Values("nemo_task_queue_depth") {
+		data.QueueDepth[topic] = int(v)
+	}
+	taskStateMap := make(map[string]*TaskStateMetricData)
+	for key, v := range metrics.Values("nemo_task_state_total") {
+		// key为"name,state"
+		nameAndState := strings.SplitN(key, ",", 2)
+		if len(nameAndState) != 2 {
+			continue
+		}
+		ts, ok := taskStateMap[nameAndState[0]]
+		if !ok {
+			ts = &TaskStateMetricData{TaskName: nameAndState[0]}
+			taskStateMap[nameAndState[0]] = ts
+		}
+		switch nameAndState[1] {
+		case ampq.STARTED:
+			ts.Started = int(v)
+		case ampq.SUCCESS:
+			ts.Success = int(v)
+		case ampq.FAILURE:
+			ts.Failure = int(v)
+		case ampq.REVOKED:
+			ts.Revoked = int(v)
+		}
+	}
+	for _, ts := range taskStateMap {
+		data.TaskState = append(data.TaskState, *ts)
+	}
+	sort.Slice(data.TaskState, func(i, j int) bool {
+		return data.TaskState[i].TaskName < data.TaskState[j].TaskName
+	})
+	c.Data["json"] = data
+}
+
 // WorkerAliveListAction 获取worker数据，用于dashboard列表显示
 func (c *DashboardController) WorkerAliveListAction() {
 	defer c.ServeJSON()
@@ -165,6 +222,8 @@ func (c *DashboardController) WorkerAliveListAction() {
 			TaskExecutedNumber: v.TaskExecutedNumber,
 			TaskStartedNumber:  v.TaskStartedNumber,
 			HeartColor:         "green",
+			GobyPool:           v.GobyPool,
+			Capability:         v.Capability,
 		}
 		workerHeartDt := time.Now().Sub(v.UpdateTime).Minutes()
 		daemonHeartDt := time.Now().Sub(v.WorkerDaemonUpdateTime).Minutes()
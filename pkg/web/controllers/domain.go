@@ -7,16 +7,16 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
-	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/fulltext"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/storage"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
 	"github.com/hanc00l/nemo_go/pkg/task/onlineapi"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"net/http"
-	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -43,63 +43,70 @@ type domainRequestParam struct {
 	SelectNoResolvedIP bool   `form:"select_no_ip"`
 	OrderByDate        bool   `form:"select_order_by_date"`
 	DomainHttp         string `form:"domain_http"`
+	Query              string `form:"query"`
 }
 
 // DomainListData datable显示的每一行数据
 type DomainListData struct {
-	Id             int            `json:"id"`
-	Index          int            `json:"index"`
-	FldDomain      string         `json:"fld_domain"`
-	Domain         string         `json:"domain"`
-	IP             []string       `json:"ip"`
-	Port           []int          `json:"port"`
-	StatusCode     []string       `json:"statuscode"`
-	Title          map[string]int `json:"title"`
-	Banner         map[string]int `json:"banner"`
-	ColorTag       string         `json:"color_tag"`
-	MemoContent    string         `json:"memo_content"`
-	Vulnerability  string         `json:"vulnerability"`
-	HoneyPot       string         `json:"honeypot"`
-	ScreenshotFile []string       `json:"screenshot"`
-	DomainCDN      string         `json:"domaincdn"`
-	DomainCNAME    string         `json:"domaincname"`
-	IsIPCDN        bool           `json:"ipcdn"`
-	IconImage      []string       `json:"iconimage"`
-	WorkspaceId    int            `json:"workspace"`
-	WorkspaceGUID  string         `json:"workspace_guid"`
-	PinIndex       int            `json:"pinindex"`
+	Id             int               `json:"id"`
+	Index          int               `json:"index"`
+	FldDomain      string            `json:"fld_domain"`
+	Domain         string            `json:"domain"`
+	IP             []string          `json:"ip"`
+	Port           []int             `json:"port"`
+	StatusCode     []string          `json:"statuscode"`
+	Title          map[string]int    `json:"title"`
+	Banner         map[string]int    `json:"banner"`
+	ValueSource    map[string]string `json:"value_source"`
+	EngineOnlyPort []int             `json:"engine_only_port"`
+	ColorTag       string            `json:"color_tag"`
+	MemoContent    string            `json:"memo_content"`
+	Vulnerability  string            `json:"vulnerability"`
+	HoneyPot       string            `json:"honeypot"`
+	ScreenshotFile []string          `json:"screenshot"`
+	DomainCDN      string            `json:"domaincdn"`
+	DomainCNAME    string            `json:"domaincname"`
+	IsIPCDN        bool              `json:"ipcdn"`
+	IconImage      []string          `json:"iconimage"`
+	WorkspaceId    int               `json:"workspace"`
+	WorkspaceGUID  string            `json:"workspace_guid"`
+	PinIndex       int               `json:"pinindex"`
 }
 
 // DomainInfo domain详细数据聚合
 type DomainInfo struct {
-	Id            int
-	Domain        string
-	Organization  string
-	IP            []string
-	Port          []int
-	PortAttr      []PortAttrInfo
-	Finger        []string
-	StatusCode    []string
-	Title         map[string]int
-	Banner        map[string]int
-	TitleString   string
-	BannerString  string
-	ColorTag      string
-	Memo          string
-	Vulnerability []VulnerabilityInfo
-	CreateTime    string
-	UpdateTime    string
-	Screenshot    []ScreenshotFileInfo
-	DomainAttr    []DomainAttrInfo
-	DisableFofa   bool
-	IconHashes    []IconHashWithFofa
-	TlsData       []string
-	DomainCDN     string
-	DomainCNAME   string
-	Workspace     string
-	WorkspaceGUID string
-	PinIndex      string
-	Source        []string
+	Id              int
+	Domain          string
+	Organization    string
+	IP              []string
+	Port            []int
+	PortAttr        []PortAttrInfo
+	PortReconcile   []PortReconcileInfo
+	ValueSource     map[string]string
+	EngineOnlyPorts []int
+	Finger          []string
+	StatusCode      []string
+	Title           map[string]int
+	Banner          map[string]int
+	TitleString     string
+	BannerString    string
+	ColorTag        string
+	Memo            string
+	Vulnerability   []VulnerabilityInfo
+	Path            []PathInfo
+	CreateTime      string
+	UpdateTime      string
+	Screenshot      []ScreenshotFileInfo
+	DomainAttr      []DomainAttrInfo
+	DisableFofa     bool
+	IconHashes      []IconHashWithFofa
+	TlsData         []string
+	DomainCDN       string
+	DomainCNAME     string
+	Workspace       string
+	WorkspaceGUID   string
+	PinIndex        string
+	Source          []string
 }
 
 // DomainAttrInfo domain属性
@@ -182,6 +189,12 @@ func (c *DomainController) ListAction() {
 			c.setSessionData("session_org_id", fmt.Sprintf("%d", req.OrgId))
 		}
 	}
+	if req.Query != "" {
+		if err = db.ValidateQuery(db.QueryCategoryDomain, req.Query); err != nil {
+			c.Data["json"] = DataTableResponseData{Draw: req.Draw, Data: make([]interface{}, 0), Error: err.Error()}
+			return
+		}
+	}
 	resp := c.getDomainListData(req)
 	c.Data["json"] = resp
 }
@@ -207,6 +220,13 @@ func (c *DomainController) InfoAction() {
 					domainInfo.PortAttr[i].TableBackgroundSet = tableBackgroundSet
 				}
 			}
+			tableBackgroundSet := false
+			for i := range domainInfo.PortReconcile {
+				if domainInfo.PortReconcile[i].IP != "" && domainInfo.PortReconcile[i].Port != "" {
+					tableBackgroundSet = !tableBackgroundSet
+				}
+				domainInfo.PortReconcile[i].TableBackgroundSet = tableBackgroundSet
+			}
 		}
 	}
 	domainInfo.DisableFofa = disableFofa
@@ -259,7 +279,11 @@ func (c *DomainController) DeleteDomainAttrAction() {
 		return
 	}
 	domainAttr := db.DomainAttr{Id: id}
-	c.MakeStatusResponse(domainAttr.Delete())
+	success := domainAttr.Delete()
+	if success {
+		fulltext.DeleteDocuments(c.GetCurrentWorkspace(), []string{fulltext.DomainAttrDocumentID(&domainAttr)})
+	}
+	c.MakeStatusResponse(success)
 }
 
 // DeleteDomainOnlineAPIAttrAction 删除fofa等属性
@@ -277,10 +301,19 @@ func (c *DomainController) DeleteDomainOnlineAPIAttrAction() {
 		c.MakeStatusResponse(false)
 		return
 	}
-	for _, source := range []string{"fofa", "hunter", "quake", "0zone"} {
+	// 删除前获取属性的ID，用于删除全文索引中的文档
+	var deletedIds []string
+	domainAttrDb := db.DomainAttr{RelatedId: id}
+	for _, da := range domainAttrDb.GetsByRelatedId() {
+		if onlineapi.IsSource(da.Source) {
+			deletedIds = append(deletedIds, fulltext.DomainAttrDocumentID(&da))
+		}
+	}
+	for _, source := range append(onlineapi.SearchEngines, "0zone") {
 		domainAttr := db.DomainAttr{RelatedId: id, Source: source}
 		c.MakeStatusResponse(domainAttr.DeleteByRelatedIDAndSource())
 	}
+	fulltext.DeleteDocuments(c.GetCurrentWorkspace(), deletedIds)
 }
 
 // ExportMemoAction 导出备忘录信息
@@ -518,6 +551,9 @@ func (c *DomainController) getSearchMap(req domainRequestParam) (searchMap map[s
 	if req.DomainHttp != "" {
 		searchMap["domain_http"] = req.DomainHttp
 	}
+	if req.Query != "" {
+		searchMap["query"] = req.Query
+	}
 	return
 }
 
@@ -576,6 +612,8 @@ func (c *DomainController) getDomainListData(req domainRequestParam) (resp DataT
 		domainData.ColorTag = domainInfo.ColorTag
 		domainData.Title = domainInfo.Title
 		domainData.Banner = domainInfo.Banner
+		domainData.ValueSource = domainInfo.ValueSource
+		domainData.EngineOnlyPort = domainInfo.EngineOnlyPorts
 		domainData.StatusCode = domainInfo.StatusCode
 		domainData.Port = domainInfo.Port
 		domainData.ScreenshotFile = ss.LoadScreenshotFile(domainData.WorkspaceGUID, domainRow.DomainName)
@@ -656,6 +694,9 @@ func getDomainInfo(domain *db.Domain, portInfoCacheMap map[int]PortInfo, disable
 		}
 	}
 	portSet := make(map[int]struct{})
+	// 端口是否在任一IP上经过主动探测
+	portVerified := make(map[int]bool)
+	r.ValueSource = make(map[string]string)
 	//域名的属性
 	domainAttrInfo := getDomainAttrFullInfo(r.WorkspaceGUID, domain.Id, disableFofa, disableBanner)
 	//遍历域名关联的每一个IP，获取port,title,banner和PortAttrInfo
@@ -668,16 +709,36 @@ func getDomainInfo(domain *db.Domain, portInfoCacheMap map[int]PortInfo, disable
 			portInfoCacheMap[ip.Id] = getPortInfo(r.WorkspaceGUID, ipName, ip.Id, disableFofa, disableBanner)
 		}
 		pi := portInfoCacheMap[ip.Id]
+		engineOnlyPorts := make(map[int]struct{})
+		for _, portNumber := range pi.EngineOnlyPorts {
+			engineOnlyPorts[portNumber] = struct{}{}
+		}
 		for _, portNumber := range pi.PortNumbers {
 			if _, ok := portSet[portNumber]; !ok {
 				portSet[portNumber] = struct{}{}
 			}
+			if _, ok := engineOnlyPorts[portNumber]; !ok {
+				portVerified[portNumber] = true
+			} else if _, ok = portVerified[portNumber]; !ok {
+				portVerified[portNumber] = false
+			}
 		}
 		utils.MergeMapStringInt(domainAttrInfo.TitleSet, pi.TitleSet)
 		utils.MergeMapStringInt(domainAttrInfo.BannerSet, pi.BannerSet)
+		for value, source := range pi.ValueSource {
+			if _, ok := r.ValueSource[value]; !ok {
+				r.ValueSource[value] = fmt.Sprintf("%s:%s", ipName, source)
+			}
+		}
 		r.PortAttr = append(r.PortAttr, pi.PortAttr...)
+		r.PortReconcile = append(r.PortReconcile, pi.PortReconcile...)
 	}
 	r.Port = utils.SetToSliceInt(portSet)
+	for _, portNumber := range r.Port {
+		if !portVerified[portNumber] {
+			r.EngineOnlyPorts = append(r.EngineOnlyPorts, portNumber)
+		}
+	}
 	r.IP = utils.SetToSlice(domainAttrInfo.IP)
 	r.Title = domainAttrInfo.TitleSet
 	r.Banner = domainAttrInfo.BannerSet
@@ -723,6 +784,18 @@ func getDomainInfo(domain *db.Domain, portInfoCacheMap map[int]PortInfo, disable
 			UpdateTime: FormatDateTime(v.UpdateDatetime),
 		})
 	}
+	// path
+	domainPath := db.DomainPath{RelatedId: domain.Id}
+	for _, v := range domainPath.GetsByRelatedId() {
+		r.Path = append(r.Path, PathInfo{
+			Url:           v.Url,
+			Status:        v.Status,
+			ContentLength: v.ContentLength,
+			Location:      v.Location,
+			Source:        v.Source,
+			UpdateTime:    FormatDateTime(v.UpdateDatetime),
+		})
+	}
 	//
 	r.TlsData = utils.SetToSlice(domainAttrInfo.TlsData)
 	r.DomainCDN = domainAttrInfo.DomainCDN
@@ -769,10 +842,10 @@ func getDomainAttrFullInfo(workspaceGUID string, id int, disableFofa, disableBan
 	domainAttr := db.DomainAttr{RelatedId: id}
 	domainAttrData := domainAttr.GetsByRelatedId()
 	for _, da := range domainAttrData {
-		if disableFofa && (da.Source == "fofa" || da.Source == "quake" || da.Source == "hunter" || da.Source == "0zone") {
+		if disableFofa && onlineapi.IsSource(da.Source) {
 			continue
 		}
-		if da.Source == "fofa" || da.Source == "quake" || da.Source == "hunter" || da.Source == "0zone" {
+		if onlineapi.IsSource(da.Source) {
 			fofaInfo[da.Tag] = da.Content
 		}
 		if da.Tag == "A" || da.Tag == "AAAA" {
@@ -810,7 +883,7 @@ func getDomainAttrFullInfo(workspaceGUID string, id int, disableFofa, disableBan
 					})
 				}
 			}
-		} else if da.Tag == "httpx" {
+		} else if da.Tag == "httpx" || da.Tag == domainscan.WildcardAttrTag || da.Source == domainscan.DNSRecordSource {
 			r.DomainAttr = append(r.DomainAttr, DomainAttrInfo{
 				Id:         da.Id,
 				Tag:        da.Tag,
@@ -833,7 +906,7 @@ func getDomainAttrFullInfo(workspaceGUID string, id int, disableFofa, disableBan
 				fileSuffix := utils.GetFaviconSuffixUrl(strings.TrimSpace(hashAndUrls[1]))
 				if fileSuffix != "" {
 					imageFile := fmt.Sprintf("%s.%s", utils.MD5(hash), fileSuffix)
-					if utils.CheckFileExist(filepath.Join(conf.GlobalServerConfig().Web.WebFiles, workspaceGUID, "iconimage", imageFile)) {
+					if storage.GetStorage().Exists(storage.Key(workspaceGUID, "iconimage", imageFile)) {
 						if _, ok := r.IconImageSet[hash]; !ok {
 							r.IconImageSet[hash] = imageFile
 						}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/fulltext"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"strings"
+)
+
+type FulltextController struct {
+	BaseController
+}
+
+// fulltextRequestParam 全文检索的请求参数
+type fulltextRequestParam struct {
+	DatableRequestParam
+	Query    string `form:"query"`
+	Category string `form:"category"`
+}
+
+// FulltextHitData 一条全文检索结果，Highlights为已转义的html片段，匹配的内容由<mark>标记
+type FulltextHitData struct {
+	Category    string   `json:"category"`
+	Target      string   `json:"target"`
+	Port        int      `json:"port"`
+	Type        string   `json:"type"`
+	Source      string   `json:"source"`
+	Tag         string   `json:"tag"`
+	Score       float64  `json:"score"`
+	Highlights  []string `json:"highlights"`
+	UpdateTime  string   `json:"update_datetime"`
+	WorkspaceId int      `json:"workspace"`
+}
+
+func (c *FulltextController) IndexAction() {
+	c.Layout = "base.html"
+	c.TplName = "fulltext-search.html"
+}
+
+// SearchAction 在当前workspace的全文索引中检索HTTP内容、Banner及属性
+func (c *FulltextController) SearchAction() {
+	defer c.ServeJSON()
+
+	req := fulltextRequestParam{}
+	err := c.ParseForm(&req)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+	}
+	c.validateRequestParam(&req)
+	c.Data["json"] = c.getSearchData(req)
+}
+
+// validateRequestParam 校验请求的参数
+func (c *FulltextController) validateRequestParam(req *fulltextRequestParam) {
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Length <= 0 || req.Length > fulltext.MaxSearchSize {
+		req.Length = 20
+	}
+	if req.Start < 0 {
+		req.Start = 0
+	}
+	if req.Category != fulltext.CategoryIP && req.Category != fulltext.CategoryDomain {
+		req.Category = ""
+	}
+}
+
+// getSearchData 获取检索结果
+func (c *FulltextController) getSearchData(req fulltextRequestParam) (resp DataTableResponseData) {
+	resp.Draw = req.Draw
+	resp.Data = make([]interface{}, 0)
+	if req.Query == "" {
+		return
+	}
+	workspaceId := c.GetCurrentWorkspace()
+	if workspaceId <= 0 {
+		resp.Error = "未选择当前的workspace！"
+		return
+	}
+	result, err := fulltext.SearchWorkspace(workspaceId, fulltext.SearchRequest{
+		Query:    req.Query,
+		Category: req.Category,
+		From:     req.Start,
+		Size:     req.Length,
+	})
+	if err != nil {
+		resp.Error = err.Error()
+		return
+	}
+	for _, hit := range result.Hits {
+		resp.Data = append(resp.Data, FulltextHitData{
+			Category:    hit.Category,
+			Target:      hit.Target,
+			Port:        hit.Port,
+			Type:        hit.Type,
+			Source:      hit.Source,
+			Tag:         hit.Tag,
+			Score:       hit.Score,
+			Highlights:  hit.Highlights,
+			UpdateTime:  FormatDateTime(hit.UpdateTime),
+			WorkspaceId: workspaceId,
+		})
+	}
+	resp.RecordsTotal = int(result.Total)
+	resp.RecordsFiltered = int(result.Total)
+	return
+}
@@ -8,7 +8,10 @@ import (
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/fulltext"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/reconcile"
+	"github.com/hanc00l/nemo_go/pkg/storage"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
@@ -18,7 +21,6 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"net/http"
 	"path"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -49,29 +51,32 @@ type ipRequestParam struct {
 	SelectNoOpenedPort    bool   `form:"select_no_openedport"`
 	OrderByDate           bool   `form:"select_order_by_date"`
 	IpHttp                string `form:"ip_http"`
+	Query                 string `form:"query"`
 }
 
 // IPListData 列表中每一行显示的IP数据
 type IPListData struct {
-	Id             int            `json:"id"`
-	Index          int            `json:"index"`
-	IP             string         `json:"ip"`
-	IPFormatted    string         `json:"ipf"`
-	Location       string         `json:"location"`
-	Port           []string       `json:"port"`
-	Title          map[string]int `json:"title"`
-	Banner         map[string]int `json:"banner"`
-	ColorTag       string         `json:"color_tag"`
-	MemoContent    string         `json:"memo_content"`
-	Vulnerability  string         `json:"vulnerability"`
-	HoneyPot       string         `json:"honeypot"`
-	ScreenshotFile []string       `json:"screenshot"`
-	CloudName      string         `json:"cloudname"`
-	IsCDN          bool           `json:"cdn"`
-	IconImage      []string       `json:"iconimage"`
-	WorkspaceId    int            `json:"workspace"`
-	WorkspaceGUID  string         `json:"workspace_guid"`
-	PinIndex       int            `json:"pinindex"`
+	Id             int               `json:"id"`
+	Index          int               `json:"index"`
+	IP             string            `json:"ip"`
+	IPFormatted    string            `json:"ipf"`
+	Location       string            `json:"location"`
+	Port           []string          `json:"port"`
+	Title          map[string]int    `json:"title"`
+	Banner         map[string]int    `json:"banner"`
+	ValueSource    map[string]string `json:"value_source"`
+	EngineOnlyPort []int             `json:"engine_only_port"`
+	ColorTag       string            `json:"color_tag"`
+	MemoContent    string            `json:"memo_content"`
+	Vulnerability  string            `json:"vulnerability"`
+	HoneyPot       string            `json:"honeypot"`
+	ScreenshotFile []string          `json:"screenshot"`
+	CloudName      string            `json:"cloudname"`
+	IsCDN          bool              `json:"cdn"`
+	IconImage      []string          `json:"iconimage"`
+	WorkspaceId    int               `json:"workspace"`
+	WorkspaceGUID  string            `json:"workspace_guid"`
+	PinIndex       int               `json:"pinindex"`
 }
 
 type IconHashWithFofa struct {
@@ -82,31 +87,35 @@ type IconHashWithFofa struct {
 
 // IPInfo IP的详细数据的集合
 type IPInfo struct {
-	Id            int
-	IP            string
-	IPFormatted   string
-	Organization  string
-	Status        string
-	Location      string
-	Port          []int
-	Title         map[string]int
-	Banner        map[string]int
-	TitleString   string
-	BannerString  string
-	PortAttr      []PortAttrInfo
-	Domain        []string
-	ColorTag      string
-	Memo          string
-	Vulnerability []VulnerabilityInfo
-	CreateTime    string
-	UpdateTime    string
-	Screenshot    []ScreenshotFileInfo
-	DisableFofa   bool
-	IconHashes    []IconHashWithFofa
-	TlsData       []string
-	Workspace     string
-	WorkspaceGUID string
-	PinIndex      string
+	Id              int
+	IP              string
+	IPFormatted     string
+	Organization    string
+	Status          string
+	Location        string
+	Port            []int
+	Title           map[string]int
+	Banner          map[string]int
+	TitleString     string
+	BannerString    string
+	PortAttr        []PortAttrInfo
+	PortReconcile   []PortReconcileInfo
+	ValueSource     map[string]string
+	EngineOnlyPorts []int
+	Domain          []string
+	ColorTag        string
+	Memo            string
+	Vulnerability   []VulnerabilityInfo
+	Path            []PathInfo
+	CreateTime      string
+	UpdateTime      string
+	Screenshot      []ScreenshotFileInfo
+	DisableFofa     bool
+	IconHashes      []IconHashWithFofa
+	TlsData         []string
+	Workspace       string
+	WorkspaceGUID   string
+	PinIndex        string
 }
 
 // PortAttrInfo 每一个端口的详细数据
@@ -125,6 +134,27 @@ type PortAttrInfo struct {
 	TableBackgroundSet bool
 }
 
+// PortReconcileInfo 端口属性合并后的一行数据，第一行显示IP和端口
+type PortReconcileInfo struct {
+	IP                 string
+	IPFormatted        string
+	Port               string
+	Verified           bool
+	Tag                string
+	Value              string
+	Confidence         string
+	Sources            []PortReconcileSource
+	TableBackgroundSet bool
+}
+
+// PortReconcileSource 合并属性的一个来源的原始值
+type PortReconcileSource struct {
+	Source     string
+	Content    string
+	Score      string
+	UpdateTime string
+}
+
 // ScreenshotFileInfo screenshot文件
 type ScreenshotFileInfo struct {
 	ScreenShotFile          string
@@ -139,6 +169,9 @@ type PortInfo struct {
 	TitleSet         map[string]int
 	BannerSet        map[string]int
 	PortAttr         []PortAttrInfo
+	PortReconcile    []PortReconcileInfo
+	ValueSource      map[string]string
+	EngineOnlyPorts  []int
 	IconHashImageSet map[string]string
 	TlsDataSet       map[string]struct{}
 }
@@ -195,6 +228,12 @@ func (c *IPController) ListAction() {
 			c.setSessionData("session_org_id", fmt.Sprintf("%d", req.OrgId))
 		}
 	}
+	if req.Query != "" {
+		if err = db.ValidateQuery(db.QueryCategoryIP, req.Query); err != nil {
+			c.Data["json"] = DataTableResponseData{Draw: req.Draw, Data: make([]interface{}, 0), Error: err.Error()}
+			return
+		}
+	}
 	resp := c.GetIPListData(req)
 	c.Data["json"] = resp
 }
@@ -219,6 +258,13 @@ func (c *IPController) InfoAction() {
 					ipInfo.PortAttr[i].TableBackgroundSet = tableBackgroundSet
 				}
 			}
+			tableBackgroundSet := false
+			for i := range ipInfo.PortReconcile {
+				if ipInfo.PortReconcile[i].IP != "" && ipInfo.PortReconcile[i].Port != "" {
+					tableBackgroundSet = !tableBackgroundSet
+				}
+				ipInfo.PortReconcile[i].TableBackgroundSet = tableBackgroundSet
+			}
 		}
 	}
 	if c.IsServerAPI {
@@ -276,7 +322,11 @@ func (c *IPController) DeletePortAttrAction() {
 		return
 	}
 	portAttr := db.PortAttr{Id: id}
-	c.MakeStatusResponse(portAttr.Delete())
+	success := portAttr.Delete()
+	if success {
+		fulltext.DeleteDocuments(c.GetCurrentWorkspace(), []string{fulltext.PortAttrDocumentID(&portAttr)})
+	}
+	c.MakeStatusResponse(success)
 }
 
 // StatisticsAction IP的统计信息
@@ -519,7 +569,7 @@ func (c *IPController) ImportPortscanResultAction() {
 		portscan.FilterIPHasTooMuchPort(&i.IpResult, false)
 		resultIpPort := i.IpResult.SaveResult(config)
 		result = fmt.Sprintf("%s", resultIpPort)
-	} else if bin == "0zone" || bin == "fofa" || bin == "hunter" {
+	} else if bin == "0zone" || bin == "fofa" || bin == "hunter" || bin == "shodan" || bin == "censys" || bin == "zoomeye" || bin == "netlas" || bin == "binaryedge" {
 		s := onlineapi.NewOnlineAPISearch(onlineapi.OnlineAPIConfig{}, bin)
 		s.ParseContentResult(fileContent)
 		portscan.FilterIPHasTooMuchPort(&s.IpResult, true)
@@ -588,6 +638,9 @@ func (c *IPController) getSearchMap(req ipRequestParam) (searchMap map[string]in
 	if req.IpHttp != "" {
 		searchMap["ip_http"] = req.IpHttp
 	}
+	if req.Query != "" {
+		searchMap["query"] = req.Query
+	}
 	return searchMap
 }
 
@@ -627,6 +680,8 @@ func (c *IPController) GetIPListData(req ipRequestParam) (resp DataTableResponse
 		ipData.MemoContent = ipInfo.Memo
 		ipData.Title = ipInfo.Title
 		ipData.Banner = ipInfo.Banner
+		ipData.ValueSource = ipInfo.ValueSource
+		ipData.EngineOnlyPort = ipInfo.EngineOnlyPorts
 		ipData.WorkspaceId = ipRow.WorkspaceId
 		if _, ok := workspaceCacheMap[ipRow.WorkspaceId]; !ok {
 			workspace := db.Workspace{Id: ipRow.WorkspaceId}
@@ -688,7 +743,9 @@ func getPortInfo(workspaceGUID string, ip string, ipId int, disableFofa, disable
 	r.TitleSet = make(map[string]int)
 	r.TlsDataSet = make(map[string]struct{})
 	r.IconHashImageSet = make(map[string]string)
+	r.ValueSource = make(map[string]string)
 
+	reconciler := reconcile.NewReconciler(conf.GlobalServerConfig().Reconcile)
 	port := db.Port{IpId: ipId}
 	portData := port.GetsByIPId()
 	for _, pd := range portData {
@@ -701,10 +758,12 @@ func getPortInfo(workspaceGUID string, ip string, ipId int, disableFofa, disable
 		portAttr := db.PortAttr{RelatedId: pd.Id}
 		portAttrData := portAttr.GetsByRelatedId()
 		FirstRow := true
+		var records []reconcile.Record
 		for _, pad := range portAttrData {
-			if disableFofa && (pad.Source == "fofa" || pad.Source == "quake" || pad.Source == "hunter" || pad.Source == "0zone") {
+			if disableFofa && onlineapi.IsSource(pad.Source) {
 				continue
 			}
+			records = append(records, reconcile.Record{Source: pad.Source, Tag: pad.Tag, Content: pad.Content, UpdateTime: pad.UpdateDatetime})
 			pai := PortAttrInfo{}
 			pai.Id = pad.Id
 			pai.PortId = pd.Id
@@ -726,16 +785,8 @@ func getPortInfo(workspaceGUID string, ip string, ipId int, disableFofa, disable
 				pai.FofaLink = fmt.Sprintf("https://fofa.info/result?qbase64=%s", base64.URLEncoding.EncodeToString([]byte(fofaSearch)))
 			}
 			r.PortAttr = append(r.PortAttr, pai)
-			if pad.Tag == "title" {
-				if _, ok := r.TitleSet[pad.Content]; !ok {
-					r.TitleSet[pad.Content] = 1
-				} else {
-					r.TitleSet[pad.Content]++
-				}
-			} else if pad.Tag == "banner" || pad.Tag == "server" || pad.Tag == "tag" || pad.Tag == "fingerprint" {
-				if pad.Tag == "banner" && disableBanner {
-					continue
-				}
+			// title、banner及server使用合并后的值
+			if pad.Tag == "tag" || pad.Tag == "fingerprint" {
 				if isUnusefulBanner(pad.Content) { //pad.Content == "unknown" || pad.Content == "" {
 					continue
 				}
@@ -753,7 +804,7 @@ func getPortInfo(workspaceGUID string, ip string, ipId int, disableFofa, disable
 					fileSuffix := utils.GetFaviconSuffixUrl(strings.TrimSpace(hashAndUrls[1]))
 					if fileSuffix != "" {
 						imageFile := fmt.Sprintf("%s.%s", utils.MD5(hash), fileSuffix)
-						if utils.CheckFileExist(filepath.Join(conf.GlobalServerConfig().Web.WebFiles, workspaceGUID, "iconimage", imageFile)) {
+						if storage.GetStorage().Exists(storage.Key(workspaceGUID, "iconimage", imageFile)) {
 							if _, ok := r.IconHashImageSet[hash]; !ok {
 								r.IconHashImageSet[hash] = imageFile
 							}
@@ -787,11 +838,73 @@ func getPortInfo(workspaceGUID string, ip string, ipId int, disableFofa, disable
 				httpPortAttr.Port = fmt.Sprintf("%d", pd.PortNum)
 			}
 			r.PortAttr = append(r.PortAttr, httpPortAttr)
+			records = append(records, reconcile.Record{Source: httpInfo.Source, Tag: "http_header", UpdateTime: httpInfo.UpdateDatetime})
 		}
+		rp := reconciler.Reconcile(records)
+		if !rp.Verified && len(portAttrData) > 0 {
+			r.EngineOnlyPorts = append(r.EngineOnlyPorts, pd.PortNum)
+		}
+		r.setReconciledPort(ip, pd.PortNum, rp, disableBanner)
 	}
 	return
 }
 
+// setReconciledPort 保存端口合并后的属性：title、server及banner使用合并后的值，值的来源用于列表中的提示
+func (r *PortInfo) setReconciledPort(ip string, portNumber int, rp reconcile.Port, disableBanner bool) {
+	firstRow := true
+	for _, tag := range reconcile.Fields {
+		field, ok := rp.Fields[tag]
+		if !ok {
+			continue
+		}
+		pri := PortReconcileInfo{
+			Verified:   rp.Verified,
+			Tag:        field.Tag,
+			Value:      field.Value,
+			Confidence: fmt.Sprintf("%.2f", field.Confidence),
+		}
+		if firstRow {
+			firstRow = false
+			pri.IP = ip
+			pri.IPFormatted = utils.FormatHostUrl("", ip, 0)
+			pri.Port = fmt.Sprintf("%d", portNumber)
+		}
+		var sources []string
+		for _, sv := range field.Sources {
+			prs := PortReconcileSource{
+				Source:     sv.Source,
+				Content:    sv.Content,
+				Score:      fmt.Sprintf("%.2f", sv.Score),
+				UpdateTime: FormatDateTime(sv.UpdateTime),
+			}
+			pri.Sources = append(pri.Sources, prs)
+			sources = append(sources, fmt.Sprintf("%s[%s]:%s（%s）", prs.Source, prs.Score, prs.Content, prs.UpdateTime))
+		}
+		r.PortReconcile = append(r.PortReconcile, pri)
+
+		if tag == "title" {
+			r.TitleSet[field.Value]++
+		} else if tag == "server" || (tag == "banner" && !disableBanner) {
+			if isUnusefulBanner(field.Value) {
+				continue
+			}
+			r.BannerSet[field.Value]++
+		} else {
+			continue
+		}
+		if _, ok := r.ValueSource[field.Value]; !ok {
+			r.ValueSource[field.Value] = fmt.Sprintf("%d/%s 置信度:%s\n%s", portNumber, tag, pri.Confidence, strings.Join(sources, "\n"))
+		}
+	}
+	if firstRow && !rp.Verified && len(rp.Sources) > 0 {
+		r.PortReconcile = append(r.PortReconcile, PortReconcileInfo{
+			IP:          ip,
+			IPFormatted: utils.FormatHostUrl("", ip, 0),
+			Port:        fmt.Sprintf("%d", portNumber),
+		})
+	}
+}
+
 // getIPInfo 获取一个IP的信息集合
 func getIPInfo(ip *db.Ip, getReleatedDomain, disableFofa, disableBanner bool) (r IPInfo) {
 	r.IP = ip.IpName
@@ -830,6 +943,9 @@ func getIPInfo(ip *db.Ip, getReleatedDomain, disableFofa, disableBanner bool) (r
 	// port
 	portInfo := getPortInfo(r.WorkspaceGUID, ip.IpName, ip.Id, disableFofa, disableBanner)
 	r.PortAttr = portInfo.PortAttr
+	r.PortReconcile = portInfo.PortReconcile
+	r.ValueSource = portInfo.ValueSource
+	r.EngineOnlyPorts = portInfo.EngineOnlyPorts
 	r.Title = portInfo.TitleSet
 	r.Banner = portInfo.BannerSet
 	r.TitleString = strings.Join(utils.SetToSliceStringInt(portInfo.TitleSet), ", ")
@@ -857,6 +973,21 @@ func getIPInfo(ip *db.Ip, getReleatedDomain, disableFofa, disableBanner bool) (r
 			UpdateTime: FormatDateTime(v.UpdateDatetime),
 		})
 	}
+	// path
+	port := db.Port{IpId: ip.Id}
+	for _, p := range port.GetsByIPId() {
+		ipPath := db.IpPath{RelatedId: p.Id}
+		for _, v := range ipPath.GetsByRelatedId() {
+			r.Path = append(r.Path, PathInfo{
+				Url:           v.Url,
+				Status:        v.Status,
+				ContentLength: v.ContentLength,
+				Location:      v.Location,
+				Source:        v.Source,
+				UpdateTime:    FormatDateTime(v.UpdateDatetime),
+			})
+		}
+	}
 	for hash, image := range portInfo.IconHashImageSet {
 		r.IconHashes = append(r.IconHashes, IconHashWithFofa{
 			IconHash:  hash,
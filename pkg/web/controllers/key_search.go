@@ -3,6 +3,7 @@ package controllers
 import (
 	"github.com/hanc00l/nemo_go/pkg/db"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/onlineapi"
 	"strings"
 )
 
@@ -19,6 +20,11 @@ type keyWordInitRequestParam struct {
 	AddFOFA         bool   `form:"fofa"`
 	AddHunter       bool   `form:"hunter"`
 	AddQuake        bool   `form:"quake"`
+	AddShodan       bool   `form:"shodan"`
+	AddCensys       bool   `form:"censys"`
+	AddZoomEye      bool   `form:"zoomeye"`
+	AddNetlas       bool   `form:"netlas"`
+	AddBinaryEdge   bool   `form:"binaryedge"`
 }
 
 type keySearchRequestParam struct {
@@ -53,6 +59,11 @@ type KeyWordInfo struct {
 	IsFofa         bool   `json:"fofa"`
 	IsHunter       bool   `json:"hunter"`
 	IsQuake        bool   `json:"quake"`
+	IsShodan       bool   `json:"shodan"`
+	IsCensys       bool   `json:"censys"`
+	IsZoomEye      bool   `json:"zoomeye"`
+	IsNetlas       bool   `json:"netlas"`
+	IsBinaryEdge   bool   `json:"binaryedge"`
 	SearchTime     string `json:"search_time"`
 	ExcludeWords   string `json:"exclude_words"`
 	CheckMod       string `json:"check_mod"`
@@ -100,18 +111,29 @@ func (c *KeySearchController) AddSaveAction() {
 	kw.CheckMod = keyWordData.AddCheckMod
 	kw.Count = keyWordData.AddCount
 	kw.WorkspaceId = workspaceId
-	var engines []string
-	if keyWordData.AddFOFA {
-		engines = append(engines, "xfofa")
-	}
-	if keyWordData.AddHunter {
-		engines = append(engines, "xhunter")
+	kw.Engine = keyWordData.engines()
+	c.MakeStatusResponse(kw.Add())
+}
+
+// engines 选择的在线API，以,分隔的xscan任务名称
+func (p keyWordInitRequestParam) engines() string {
+	selected := map[string]bool{
+		"fofa":       p.AddFOFA,
+		"hunter":     p.AddHunter,
+		"quake":      p.AddQuake,
+		"shodan":     p.AddShodan,
+		"censys":     p.AddCensys,
+		"zoomeye":    p.AddZoomEye,
+		"netlas":     p.AddNetlas,
+		"binaryedge": p.AddBinaryEdge,
 	}
-	if keyWordData.AddQuake {
-		engines = append(engines, "xquake")
+	var engines []string
+	for _, api := range onlineapi.SearchEngines {
+		if selected[api] {
+			engines = append(engines, "x"+api)
+		}
 	}
-	kw.Engine = strings.Join(engines, ",")
-	c.MakeStatusResponse(kw.Add())
+	return strings.Join(engines, ",")
 }
 
 // validateRequestParam 校验请求的参数
@@ -169,6 +191,16 @@ func (c *KeySearchController) GetAction() {
 				kwi.IsHunter = true
 			case "quake", "xquake":
 				kwi.IsQuake = true
+			case "shodan", "xshodan":
+				kwi.IsShodan = true
+			case "censys", "xcensys":
+				kwi.IsCensys = true
+			case "zoomeye", "xzoomeye":
+				kwi.IsZoomEye = true
+			case "netlas", "xnetlas":
+				kwi.IsNetlas = true
+			case "binaryedge", "xbinaryedge":
+				kwi.IsBinaryEdge = true
 			}
 		}
 		c.Data["json"] = kwi
@@ -208,17 +240,7 @@ func (c *KeySearchController) UpdateAction() {
 	updateMap["exclude_words"] = kwi.AddExcludeWords
 	updateMap["check_mod"] = kwi.AddCheckMod
 	updateMap["count"] = kwi.AddCount
-	var engines []string
-	if kwi.AddFOFA {
-		engines = append(engines, "xfofa")
-	}
-	if kwi.AddHunter {
-		engines = append(engines, "xhunter")
-	}
-	if kwi.AddQuake {
-		engines = append(engines, "xquake")
-	}
-	updateMap["engine"] = strings.Join(engines, ",")
+	updateMap["engine"] = kwi.engines()
 	c.MakeStatusResponse(kw.Update(updateMap))
 }
 
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"strings"
+)
+
+type QueryController struct {
+	BaseController
+}
+
+// savedQueryRequestParam 保存查询语句的请求参数
+type savedQueryRequestParam struct {
+	Name     string `form:"name"`
+	Category string `form:"category"`
+	Query    string `form:"query"`
+}
+
+// SavedQueryData 保存的查询语句
+type SavedQueryData struct {
+	Id         int    `json:"id"`
+	Name       string `json:"name"`
+	Category   string `json:"category"`
+	Query      string `json:"query"`
+	UpdateTime string `json:"update_datetime"`
+}
+
+// QueryFieldsData 类别可用的查询字段
+type QueryFieldsData struct {
+	Category string   `json:"category"`
+	Fields   []string `json:"fields"`
+}
+
+// ListAction 当前workspace中指定类别的查询语句
+func (c *QueryController) ListAction() {
+	defer c.ServeJSON()
+
+	var result []SavedQueryData
+	workspaceId := c.GetCurrentWorkspace()
+	if workspaceId > 0 {
+		q := db.SavedQuery{WorkspaceId: workspaceId, Category: c.GetString("category")}
+		for _, row := range q.Gets() {
+			result = append(result, SavedQueryData{
+				Id:         row.Id,
+				Name:       row.Name,
+				Category:   row.Category,
+				Query:      row.Query,
+				UpdateTime: FormatDateTime(row.UpdateDatetime),
+			})
+		}
+	}
+	if result == nil {
+		result = make([]SavedQueryData, 0)
+	}
+	c.Data["json"] = result
+}
+
+// FieldsAction 类别可用的查询字段
+func (c *QueryController) FieldsAction() {
+	defer c.ServeJSON()
+
+	category := c.GetString("category")
+	c.Data["json"] = QueryFieldsData{Category: category, Fields: db.GetQueryFields(category)}
+}
+
+// SaveAction 保存查询语句，同名的查询语句将被覆盖
+func (c *QueryController) SaveAction() {
+	defer c.ServeJSON()
+	if c.CheckMultiAccessRequest([]RequestRole{SuperAdmin, Admin}, false) == false {
+		c.FailedStatus("当前用户权限不允许！")
+		return
+	}
+
+	req := savedQueryRequestParam{}
+	err := c.ParseForm(&req)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+		c.FailedStatus(err.Error())
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Name == "" || len(req.Name) > 100 {
+		c.FailedStatus("查询名称为空或过长！")
+		return
+	}
+	if err = db.ValidateQuery(req.Category, req.Query); err != nil {
+		c.FailedStatus(err.Error())
+		return
+	}
+	workspaceId := c.GetCurrentWorkspace()
+	if workspaceId <= 0 {
+		c.FailedStatus("未选择当前的workspace！")
+		return
+	}
+	q := db.SavedQuery{Name: req.Name, Category: req.Category, Query: req.Query, WorkspaceId: workspaceId}
+	success, _ := q.SaveOrUpdate()
+	c.MakeStatusResponse(success)
+}
+
+// DeleteAction 删除一条查询语句
+func (c *QueryController) DeleteAction() {
+	defer c.ServeJSON()
+	if c.CheckMultiAccessRequest([]RequestRole{SuperAdmin, Admin}, false) == false {
+		c.FailedStatus("当前用户权限不允许！")
+		return
+	}
+
+	id, err := c.GetInt("id")
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+		c.FailedStatus(err.Error())
+		return
+	}
+	q := db.SavedQuery{Id: id}
+	if !q.Get() || q.WorkspaceId != c.GetCurrentWorkspace() {
+		c.FailedStatus("查询语句不存在！")
+		return
+	}
+	c.MakeStatusResponse(q.Delete())
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/db"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"strings"
 )
 
 type RuntimeLogController struct {
@@ -17,6 +18,7 @@ type runtimeLogRequestParam struct {
 	Func      string `form:"log_func"`
 	Level     int    `form:"log_level"`
 	Message   string `form:"log_message"`
+	TaskId    string `form:"log_task"`
 	DateDelta int    `form:"date_delta"`
 }
 
@@ -28,6 +30,8 @@ type RuntimeLogData struct {
 	Func       string `json:"func"`
 	Level      string `json:"level"`
 	Message    string `json:"message"`
+	TaskId     string `json:"task_id"`
+	MainTaskId string `json:"main_task_id"`
 	CreateTime string `json:"create_datetime"`
 	UpdateTime string `json:"update_datetime"`
 }
@@ -39,6 +43,9 @@ type RuntimeLogInfo struct {
 	Func       string
 	Level      string
 	Message    string
+	TaskId     string
+	MainTaskId string
+	Workspace  string
 	CreateTime string
 	UpdateTime string
 }
@@ -144,6 +151,9 @@ func (c *RuntimeLogController) getSearchMap(req runtimeLogRequestParam) (searchM
 	if req.Message != "" {
 		searchMap["message"] = req.Message
 	}
+	if req.TaskId != "" {
+		searchMap["task_id"] = strings.TrimSpace(req.TaskId)
+	}
 	if req.Level > 0 {
 		searchMap["level_int"] = req.Level
 	}
@@ -160,17 +170,7 @@ func (c *RuntimeLogController) getRuntimeLogListData(req runtimeLogRequestParam)
 	startPage := req.Start/req.Length + 1
 	results, total := rtlog.Gets(searchMap, startPage, req.Length)
 	for i, logRow := range results {
-		v := RuntimeLogData{}
-		v.Id = logRow.Id
-		v.Index = req.Start + i + 1
-		v.Source = logRow.Source
-		v.File = logRow.File
-		v.Func = logRow.Func
-		v.Level = logRow.Level
-		v.Message = logRow.Message
-		v.CreateTime = FormatDateTime(logRow.CreateDatetime)
-		v.UpdateTime = FormatDateTime(logRow.UpdateDatetime)
-		resp.Data = append(resp.Data, v)
+		resp.Data = append(resp.Data, newRuntimeLogData(req.Start+i+1, &logRow))
 	}
 	resp.Draw = req.Draw
 	resp.RecordsTotal = total
@@ -181,6 +181,23 @@ func (c *RuntimeLogController) getRuntimeLogListData(req runtimeLogRequestParam)
 	return
 }
 
+// newRuntimeLogData 生成列表显示的一条日志
+func newRuntimeLogData(index int, logRow *db.RuntimeLog) RuntimeLogData {
+	return RuntimeLogData{
+		Id:         logRow.Id,
+		Index:      index,
+		Source:     logRow.Source,
+		File:       logRow.File,
+		Func:       logRow.Func,
+		Level:      logRow.Level,
+		Message:    logRow.Message,
+		TaskId:     logRow.TaskId,
+		MainTaskId: logRow.MainTaskId,
+		CreateTime: FormatDateTime(logRow.CreateDatetime),
+		UpdateTime: FormatDateTime(logRow.UpdateDatetime),
+	}
+}
+
 // getRuntimeLogInfo 获取一个详情
 func getRuntimeLogInfo(id int) (r RuntimeLogInfo) {
 	rtlog := db.RuntimeLog{Id: id}
@@ -193,6 +210,14 @@ func getRuntimeLogInfo(id int) (r RuntimeLogInfo) {
 	r.Func = rtlog.Func
 	r.Level = rtlog.Level
 	r.Message = rtlog.Message
+	r.TaskId = rtlog.TaskId
+	r.MainTaskId = rtlog.MainTaskId
+	if rtlog.WorkspaceId > 0 {
+		workspace := db.Workspace{Id: rtlog.WorkspaceId}
+		if workspace.Get() {
+			r.Workspace = workspace.WorkspaceName
+		}
+	}
 	r.CreateTime = FormatDateTime(rtlog.CreateDatetime)
 	r.UpdateTime = FormatDateTime(rtlog.UpdateDatetime)
 
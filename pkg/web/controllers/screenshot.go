@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"sort"
+)
+
+type ScreenshotController struct {
+	BaseController
+}
+
+// screenshotRequestParam 请求参数
+type screenshotRequestParam struct {
+	DatableRequestParam
+	Domain    string `form:"domain"`
+	Title     string `form:"title"`
+	Content   string `form:"content"`
+	DateDelta int    `form:"date_delta"`
+	Distance  int    `form:"distance"`
+}
+
+// ScreenshotGroupData 视觉相似的一组截图
+type ScreenshotGroupData struct {
+	Index int                  `json:"index"`
+	PHash string               `json:"phash"`
+	Title string               `json:"title"`
+	Count int                  `json:"count"`
+	Items []ScreenshotItemData `json:"items"`
+}
+
+// ScreenshotItemData 一个截图
+type ScreenshotItemData struct {
+	Domain        string `json:"domain"`
+	IsIP          bool   `json:"is_ip"`
+	Port          int    `json:"port"`
+	Url           string `json:"url"`
+	Title         string `json:"title"`
+	PHash         string `json:"phash"`
+	File          string `json:"file"`
+	ThumbnailFile string `json:"thumbnail"`
+	UpdateTime    string `json:"update_datetime"`
+	WorkspaceId   int    `json:"workspace"`
+}
+
+func (c *ScreenshotController) IndexAction() {
+	c.Layout = "base.html"
+	c.TplName = "screenshot-gallery.html"
+}
+
+// GalleryAction 按视觉相似度分组的截图列表，相同页面数量多的分组在前
+func (c *ScreenshotController) GalleryAction() {
+	defer c.ServeJSON()
+
+	req := screenshotRequestParam{}
+	err := c.ParseForm(&req)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+	}
+	c.validateRequestParam(&req)
+	c.Data["json"] = c.getGalleryData(req)
+}
+
+// validateRequestParam 校验请求的参数
+func (c *ScreenshotController) validateRequestParam(req *screenshotRequestParam) {
+	if req.Length <= 0 {
+		req.Length = 20
+	}
+	if req.Start < 0 {
+		req.Start = 0
+	}
+	if req.Distance <= 0 || req.Distance > 64 {
+		req.Distance = fingerprint.PHashSimilarDistance
+	}
+}
+
+// getSearchMap 根据查询参数生成查询条件
+func (c *ScreenshotController) getSearchMap(req screenshotRequestParam) (searchMap map[string]interface{}) {
+	searchMap = make(map[string]interface{})
+
+	workspaceId := c.GetCurrentWorkspace()
+	if workspaceId > 0 {
+		searchMap["workspace_id"] = workspaceId
+	}
+	if req.Domain != "" {
+		searchMap["domain"] = req.Domain
+	}
+	if req.Title != "" {
+		searchMap["title"] = req.Title
+	}
+	if req.Content != "" {
+		searchMap["content"] = req.Content
+	}
+	if req.DateDelta > 0 {
+		searchMap["date_delta"] = req.DateDelta
+	}
+	return
+}
+
+// getGalleryData 获取分组显示的数据
+func (c *ScreenshotController) getGalleryData(req screenshotRequestParam) (resp DataTableResponseData) {
+	s := db.Screenshot{}
+	results := s.Gets(c.getSearchMap(req))
+	hashes := make([]string, len(results))
+	for i := range results {
+		hashes[i] = results[i].PHash
+	}
+	groups := fingerprint.GroupBySimilarity(hashes, req.Distance)
+	sort.SliceStable(groups, func(i, j int) bool {
+		return len(groups[i]) > len(groups[j])
+	})
+
+	workspaceGUID := make(map[int]string)
+	for i := req.Start; i < len(groups) && i < req.Start+req.Length; i++ {
+		leader := results[groups[i][0]]
+		groupData := ScreenshotGroupData{
+			Index: i + 1,
+			PHash: leader.PHash,
+			Title: leader.Title,
+			Count: len(groups[i]),
+		}
+		for _, index := range groups[i] {
+			groupData.Items = append(groupData.Items, c.makeItemData(results[index], workspaceGUID))
+		}
+		resp.Data = append(resp.Data, groupData)
+	}
+	resp.Draw = req.Draw
+	resp.RecordsTotal = len(groups)
+	resp.RecordsFiltered = len(groups)
+	if resp.Data == nil {
+		resp.Data = make([]interface{}, 0)
+	}
+	return
+}
+
+// makeItemData 生成一个截图的显示数据
+func (c *ScreenshotController) makeItemData(s db.Screenshot, workspaceGUID map[int]string) ScreenshotItemData {
+	guid, ok := workspaceGUID[s.WorkspaceId]
+	if !ok {
+		workspace := db.Workspace{Id: s.WorkspaceId}
+		if workspace.Get() {
+			guid = workspace.WorkspaceGUID
+		}
+		workspaceGUID[s.WorkspaceId] = guid
+	}
+	url := s.FinalUrl
+	if url == "" {
+		url = utils.FormatHostUrl(s.Protocol, s.Domain, s.Port)
+	}
+	return ScreenshotItemData{
+		Domain:        s.Domain,
+		IsIP:          utils.CheckIP(s.Domain),
+		Port:          s.Port,
+		Url:           url,
+		Title:         s.Title,
+		PHash:         s.PHash,
+		File:          fmt.Sprintf("/webfiles/%s/screenshot/%s/%d_%s.png", guid, s.Domain, s.Port, s.Protocol),
+		ThumbnailFile: fmt.Sprintf("/webfiles/%s/screenshot/%s/%d_%s_thumbnail.png", guid, s.Domain, s.Port, s.Protocol),
+		UpdateTime:    FormatDateTime(s.UpdateDatetime),
+		WorkspaceId:   s.WorkspaceId,
+	}
+}
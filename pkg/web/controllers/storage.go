@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/storage"
+	"net/http"
+	"path/filepath"
+)
+
+type StorageController struct {
+	BaseController
+}
+
+// FileAction 非本地存储时读取/webfiles下的截图、icon等文件；存储中不存在的文件（如任务结果）从本地webfiles目录读取
+func (c *StorageController) FileAction() {
+	key := c.Ctx.Input.Param(":splat")
+	if err := storage.CheckKey(key); err != nil {
+		c.Ctx.ResponseWriter.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	content, err := storage.GetStorage().Get(key)
+	if err != nil {
+		if !storage.IsNotExist(err) {
+			logging.RuntimeLog.Errorf("get file %s fail:%v", key, err)
+			c.Ctx.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		http.ServeFile(c.Ctx.ResponseWriter, c.Ctx.Request, filepath.Join(conf.GlobalServerConfig().Web.WebFiles, filepath.FromSlash(key)))
+		return
+	}
+	c.Ctx.Output.Header("Content-Type", storage.ContentType(key))
+	c.Ctx.Output.Body(content)
+}
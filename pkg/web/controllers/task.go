@@ -31,6 +31,12 @@ type taskRequestParam struct {
 	RunTaskState string `form:"runtask_state"`
 }
 
+// taskLogRequestParam 任务日志的请求参数
+type taskLogRequestParam struct {
+	DatableRequestParam
+	TaskId string `form:"task_id"`
+}
+
 type taskCronRequestParam struct {
 	DatableRequestParam
 	Name   string `form:"task_name"`
@@ -184,6 +190,25 @@ func (c *TaskController) InfoMainAction() {
 	}
 }
 
+// LogAction 任务的运行日志，主任务包括其全部子任务的日志
+func (c *TaskController) LogAction() {
+	defer c.ServeJSON()
+
+	req := taskLogRequestParam{}
+	err := c.ParseForm(&req)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+	}
+	if req.Length <= 0 {
+		req.Length = 50
+	}
+	if req.Start < 0 {
+		req.Start = 0
+	}
+	c.Data["json"] = c.getTaskLogData(req)
+}
+
 // InfoCronAction 显示一个任务的详情
 func (c *TaskController) InfoCronAction() {
 	var taskInfo TaskCronInfo
@@ -594,6 +619,9 @@ func (c *TaskController) StartXScanTaskAction() {
 	if !reqByForm.IsNucleiPocscan {
 		reqByForm.NucleiPocFile = ""
 	}
+	if !reqByForm.IsXrayPocV1 {
+		reqByForm.XrayPocV1File = ""
+	}
 	var targets []string
 	if c.IsServerAPI {
 		// webapi方式：多个目标以“,”分隔，并且每一个目标单独生成一个任务
@@ -933,6 +961,38 @@ func (c *TaskController) getTaskInfo(taskId string) (r TaskInfo) {
 	return
 }
 
+// getTaskLogData 获取任务的日志；任务须属于当前的workspace
+func (c *TaskController) getTaskLogData(req taskLogRequestParam) (resp DataTableResponseData) {
+	resp.Draw = req.Draw
+	resp.Data = make([]interface{}, 0)
+	if req.TaskId == "" {
+		return
+	}
+	var taskWorkspaceId int
+	taskMain := db.TaskMain{TaskId: req.TaskId}
+	taskRun := db.TaskRun{TaskId: req.TaskId}
+	if taskMain.GetByTaskId() {
+		taskWorkspaceId = taskMain.WorkspaceId
+	} else if taskRun.GetByTaskId() {
+		taskWorkspaceId = taskRun.WorkspaceId
+	} else {
+		resp.Error = "任务不存在！"
+		return
+	}
+	if workspaceId := c.GetCurrentWorkspace(); workspaceId > 0 && workspaceId != taskWorkspaceId {
+		resp.Error = "任务不属于当前的workspace！"
+		return
+	}
+	rtlog := db.RuntimeLog{}
+	results, total := rtlog.Gets(map[string]interface{}{"task_id": req.TaskId}, req.Start/req.Length+1, req.Length)
+	for i, logRow := range results {
+		resp.Data = append(resp.Data, newRuntimeLogData(req.Start+i+1, &logRow))
+	}
+	resp.RecordsTotal = total
+	resp.RecordsFiltered = total
+	return
+}
+
 // getTaskCronInfo 获取一个任务的详情
 func (c *TaskController) getTaskCronInfo(taskId string) (r TaskCronInfo) {
 	task := db.TaskCron{TaskId: taskId}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type UrlController struct {
+	BaseController
+}
+
+// urlRequestParam 请求参数
+type urlRequestParam struct {
+	DatableRequestParam
+	Url         string `form:"url"`
+	Host        string `form:"host"`
+	Method      string `form:"method"`
+	Params      string `form:"params"`
+	ContentType string `form:"content_type"`
+	Status      int    `form:"status"`
+	Source      string `form:"source"`
+	DateDelta   int    `form:"date_delta"`
+}
+
+type UrlData struct {
+	Id          int    `json:"id"`
+	Index       int    `json:"index"`
+	Url         string `json:"url"`
+	Host        string `json:"host"`
+	Port        int    `json:"port"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Params      string `json:"params"`
+	ContentType string `json:"content_type"`
+	Status      int    `json:"status"`
+	Source      string `json:"source"`
+	CreateTime  string `json:"create_datetime"`
+	UpdateTime  string `json:"update_datetime"`
+	WorkspaceId int    `json:"workspace"`
+}
+
+func (c *UrlController) IndexAction() {
+	c.Layout = "base.html"
+	c.TplName = "url-list.html"
+}
+
+// ListAction URL资产列表的数据
+func (c *UrlController) ListAction() {
+	defer c.ServeJSON()
+
+	req := urlRequestParam{}
+	err := c.ParseForm(&req)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+	}
+	c.validateRequestParam(&req)
+	c.Data["json"] = c.getUrlListData(req)
+}
+
+// DeleteAction 删除一个记录
+func (c *UrlController) DeleteAction() {
+	defer c.ServeJSON()
+	if c.CheckMultiAccessRequest([]RequestRole{SuperAdmin, Admin}, false) == false {
+		c.FailedStatus("当前用户权限不允许！")
+		return
+	}
+
+	id, err := c.GetInt("id")
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+		c.FailedStatus(err.Error())
+		return
+	}
+	u := db.Url{Id: id}
+	c.MakeStatusResponse(u.Delete())
+}
+
+// ExportAction 导出URL资产
+func (c *UrlController) ExportAction() {
+	req := urlRequestParam{}
+	err := c.ParseForm(&req)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+		return
+	}
+	u := db.Url{}
+	results, _ := u.Gets(c.getSearchMap(req), -1, -1)
+	content := c.writeToCSVData(results)
+	rw := c.Ctx.ResponseWriter
+	rw.Header().Set("Content-Disposition", "attachment; filename=url-result.csv")
+	rw.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	rw.WriteHeader(http.StatusOK)
+
+	http.ServeContent(rw, c.Ctx.Request, "url-result.csv", time.Now(), bytes.NewReader(content))
+}
+
+// validateRequestParam 校验请求的参数
+func (c *UrlController) validateRequestParam(req *urlRequestParam) {
+	if req.Length <= 0 {
+		req.Length = 50
+	}
+	if req.Start < 0 {
+		req.Start = 0
+	}
+}
+
+// getSearchMap 根据查询参数生成查询条件
+func (c *UrlController) getSearchMap(req urlRequestParam) (searchMap map[string]interface{}) {
+	searchMap = make(map[string]interface{})
+
+	workspaceId := c.GetCurrentWorkspace()
+	if workspaceId > 0 {
+		searchMap["workspace_id"] = workspaceId
+	}
+	if req.Url != "" {
+		searchMap["url"] = req.Url
+	}
+	if req.Host != "" {
+		searchMap["host"] = req.Host
+	}
+	if req.Method != "" {
+		searchMap["method"] = req.Method
+	}
+	if req.Params != "" {
+		searchMap["params"] = req.Params
+	}
+	if req.ContentType != "" {
+		searchMap["content_type"] = req.ContentType
+	}
+	if req.Status > 0 {
+		searchMap["status"] = req.Status
+	}
+	if req.Source != "" {
+		searchMap["source"] = req.Source
+	}
+	if req.DateDelta > 0 {
+		searchMap["date_delta"] = req.DateDelta
+	}
+	return
+}
+
+// getUrlListData 获取列显示的数据
+func (c *UrlController) getUrlListData(req urlRequestParam) (resp DataTableResponseData) {
+	u := db.Url{}
+	searchMap := c.getSearchMap(req)
+	startPage := req.Start/req.Length + 1
+	results, total := u.Gets(searchMap, startPage, req.Length)
+	for i, urlRow := range results {
+		resp.Data = append(resp.Data, UrlData{
+			Id:          urlRow.Id,
+			Index:       req.Start + i + 1,
+			Url:         urlRow.Url,
+			Host:        urlRow.Host,
+			Port:        urlRow.Port,
+			Method:      urlRow.Method,
+			Path:        urlRow.Path,
+			Params:      urlRow.Params,
+			ContentType: urlRow.ContentType,
+			Status:      urlRow.Status,
+			Source:      urlRow.Source,
+			CreateTime:  FormatDateTime(urlRow.CreateDatetime),
+			UpdateTime:  FormatDateTime(urlRow.UpdateDatetime),
+			WorkspaceId: urlRow.WorkspaceId,
+		})
+	}
+	resp.Draw = req.Draw
+	resp.RecordsTotal = total
+	resp.RecordsFiltered = total
+	if resp.Data == nil {
+		resp.Data = make([]interface{}, 0)
+	}
+	return
+}
+
+// writeToCSVData 输出为csv格式
+func (c *UrlController) writeToCSVData(results []db.Url) []byte {
+	var buf bytes.Buffer
+	bufWrite := bufio.NewWriter(&buf)
+	csvWriter := csv.NewWriter(bufWrite)
+	csvWriter.Write([]string{"index", "url", "method", "params", "content-type", "status", "source", "first-seen", "last-seen"})
+	for i, v := range results {
+		csvWriter.Write([]string{
+			strconv.Itoa(i + 1),
+			v.Url,
+			v.Method,
+			v.Params,
+			v.ContentType,
+			strconv.Itoa(v.Status),
+			v.Source,
+			FormatDateTime(v.CreateDatetime),
+			FormatDateTime(v.UpdateDatetime),
+		})
+	}
+	csvWriter.Flush()
+	bufWrite.Flush()
+	return buf.Bytes()
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/db"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/pocscan"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"strings"
 )
 
 type VulController struct {
@@ -18,6 +20,16 @@ type vulRequestParam struct {
 	Target    string `form:"vul_target"`
 	PocFile   string `form:"vul_poc_file"`
 	DateDelta int    `form:"date_delta"`
+	Query     string `form:"query"`
+}
+
+// nucleiTemplateRequestParam nuclei模板的查询参数
+type nucleiTemplateRequestParam struct {
+	Tags     string `form:"tags"`
+	Severity string `form:"severity"`
+	Protocol string `form:"protocol"`
+	Keyword  string `form:"keyword"`
+	Refresh  bool   `form:"refresh"`
 }
 
 type VulnerabilityData struct {
@@ -44,6 +56,16 @@ type VulnerabilityInfo struct {
 	Workspace  string
 }
 
+// PathInfo 目录扫描发现的路径
+type PathInfo struct {
+	Url           string
+	Status        int
+	ContentLength int
+	Location      string
+	Source        string
+	UpdateTime    string
+}
+
 func (c *VulController) IndexAction() {
 	c.Layout = "base.html"
 	c.TplName = "vulnerability-list.html"
@@ -60,6 +82,12 @@ func (c *VulController) ListAction() {
 		logging.CLILog.Error(err)
 	}
 	c.validateRequestParam(&req)
+	if req.Query != "" {
+		if err = db.ValidateQuery(db.QueryCategoryVulnerability, req.Query); err != nil {
+			c.Data["json"] = DataTableResponseData{Draw: req.Draw, Data: make([]interface{}, 0), Error: err.Error()}
+			return
+		}
+	}
 	resp := c.getVulnerabilityListData(req)
 	c.Data["json"] = resp
 }
@@ -124,6 +152,46 @@ func (c *VulController) LoadNucleiPocFileAction() {
 	c.ServeJSON()
 }
 
+// LoadNucleiTemplateAction 根据标签、严重程度、协议等条件获取Nuclei模板的元数据
+func (c *VulController) LoadNucleiTemplateAction() {
+	defer c.ServeJSON()
+
+	req := nucleiTemplateRequestParam{}
+	if err := c.ParseForm(&req); err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+	}
+	index := pocscan.GetNucleiTemplateIndex()
+	if req.Refresh {
+		index.Refresh()
+	}
+	filter := pocscan.NucleiTemplateFilter{Protocol: req.Protocol, Keyword: req.Keyword}
+	if tags := utils.MergeCommaString(req.Tags); tags != "" {
+		filter.Tags = strings.Split(tags, ",")
+	}
+	if severity := utils.MergeCommaString(req.Severity); severity != "" {
+		filter.Severity = strings.Split(severity, ",")
+	}
+	templates := index.Filter(filter)
+	if templates == nil {
+		templates = make([]pocscan.NucleiTemplate, 0)
+	}
+	c.Data["json"] = templates
+}
+
+// LoadNucleiTagsAction 获取Nuclei模板的所有标签
+func (c *VulController) LoadNucleiTagsAction() {
+	c.Data["json"] = pocscan.GetNucleiTemplateIndex().Tags()
+	c.ServeJSON()
+}
+
+// LoadXrayPocV1PocFileAction 获取内置xraypocv1引擎的pocfile列表
+func (c *VulController) LoadXrayPocV1PocFileAction() {
+	x := pocscan.NewXrayPocV1(pocscan.Config{})
+	c.Data["json"] = x.LoadPocFile()
+	c.ServeJSON()
+}
+
 // validateRequestParam 校验请求的参数
 func (c *VulController) validateRequestParam(req *vulRequestParam) {
 	if req.Length <= 0 {
@@ -154,6 +222,9 @@ func (c *VulController) getSearchMap(req vulRequestParam) (searchMap map[string]
 	if req.DateDelta > 0 {
 		searchMap["date_delta"] = req.DateDelta
 	}
+	if req.Query != "" {
+		searchMap["query"] = req.Query
+	}
 	return
 }
 
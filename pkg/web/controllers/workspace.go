@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/fulltext"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/storage"
 	"os"
 	"path/filepath"
 )
@@ -313,6 +315,12 @@ func (c *WorkspaceController) DeleteAction() {
 	if workspace.Get() {
 		domainPath := filepath.Join(conf.GlobalServerConfig().Web.WebFiles, workspace.WorkspaceGUID)
 		os.RemoveAll(domainPath)
+		if !storage.IsLocal() && workspace.WorkspaceGUID != "" {
+			if err = storage.GetStorage().DeletePrefix(workspace.WorkspaceGUID); err != nil {
+				logging.RuntimeLog.Errorf("delete workspace storage files fail:%v", err)
+			}
+		}
+		fulltext.DeleteWorkspace(workspace.WorkspaceGUID)
 		c.MakeStatusResponse(workspace.Delete())
 	}
 	c.MakeStatusResponse(false)
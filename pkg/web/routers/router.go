@@ -20,10 +20,17 @@ func init() {
 	web.CtrlPost("/config-save-taskslice", (*controllers.ConfigController).SaveTaskSliceNumberAction)
 	web.CtrlPost("/config-save-portscan", (*controllers.ConfigController).SavePortscanAction)
 	web.CtrlPost("/config-save-fingerprint", (*controllers.ConfigController).SaveFingerprintAction)
+	web.CtrlPost("/config-refingerprint", (*controllers.ConfigController).ReFingerprintAction)
+	web.CtrlPost("/config-refingerprint-status", (*controllers.ConfigController).ReFingerprintStatusAction)
 	web.CtrlPost("/config-upload-poc", (*controllers.ConfigController).UploadPocAction)
 	web.CtrlPost("/config-save-notify", (*controllers.ConfigController).SaveTaskNotifyAction)
 	web.CtrlPost("/config-save-api", (*controllers.ConfigController).SaveAPITokenAction)
 	web.CtrlPost("/config-test-api", (*controllers.ConfigController).TestOnlineAPIKeyAction)
+	web.CtrlPost("/config-apikey-list", (*controllers.APIKeyController).ListAction)
+	web.CtrlPost("/config-apikey-add", (*controllers.APIKeyController).AddAction)
+	web.CtrlPost("/config-apikey-enable", (*controllers.APIKeyController).EnableAction)
+	web.CtrlPost("/config-apikey-delete", (*controllers.APIKeyController).DeleteAction)
+	web.CtrlPost("/config-apikey-check", (*controllers.APIKeyController).CheckAction)
 	web.CtrlPost("/config-test-notify", (*controllers.ConfigController).TestTaskNotifyAction)
 	web.CtrlPost("/config-save-domainscan", (*controllers.ConfigController).SaveDomainscanAction)
 	web.CtrlPost("/custom-save-taskworkspace", (*controllers.ConfigController).SaveCustomTaskWorkspaceConfigAction)
@@ -34,6 +41,7 @@ func init() {
 	web.CtrlPost("/worker-list", (*controllers.DashboardController).WorkerAliveListAction)
 	web.CtrlPost("/onlineuser-list", (*controllers.DashboardController).OnlineUserListAction)
 	web.CtrlPost("/dashboard-task-started-info", (*controllers.DashboardController).GetStartedTaskInfoAction)
+	web.CtrlPost("/dashboard-metrics", (*controllers.DashboardController).GetMetricsAction)
 	web.CtrlPost("/worker-reload", (*controllers.DashboardController).ManualReloadWorkerAction)
 	web.CtrlPost("/worker-filesync", (*controllers.DashboardController).ManualWorkerFileSyncAction)
 
@@ -75,6 +83,27 @@ func init() {
 	web.CtrlPost("/vulnerability-delete", (*controllers.VulController).DeleteAction)
 	web.CtrlPost("/vulnerability-load-xray-pocfile", (*controllers.VulController).LoadXrayPocFileAction)
 	web.CtrlPost("/vulnerability-load-nuclei-pocfile", (*controllers.VulController).LoadNucleiPocFileAction)
+	web.CtrlPost("/vulnerability-load-nuclei-template", (*controllers.VulController).LoadNucleiTemplateAction)
+	web.CtrlPost("/vulnerability-load-nuclei-tags", (*controllers.VulController).LoadNucleiTagsAction)
+	web.CtrlPost("/vulnerability-load-xraypocv1-pocfile", (*controllers.VulController).LoadXrayPocV1PocFileAction)
+
+	web.CtrlGet("/url-list", (*controllers.UrlController).IndexAction)
+	web.CtrlPost("/url-list", (*controllers.UrlController).ListAction)
+	web.CtrlPost("/url-delete", (*controllers.UrlController).DeleteAction)
+	web.CtrlGet("/url-export", (*controllers.UrlController).ExportAction)
+
+	web.CtrlGet("/screenshot-gallery", (*controllers.ScreenshotController).IndexAction)
+	web.CtrlPost("/screenshot-gallery", (*controllers.ScreenshotController).GalleryAction)
+
+	web.CtrlGet("/fulltext-search", (*controllers.FulltextController).IndexAction)
+	web.CtrlPost("/fulltext-search", (*controllers.FulltextController).SearchAction)
+
+	web.CtrlGet("/webfiles/*", (*controllers.StorageController).FileAction)
+
+	web.CtrlPost("/query-list", (*controllers.QueryController).ListAction)
+	web.CtrlPost("/query-fields", (*controllers.QueryController).FieldsAction)
+	web.CtrlPost("/query-save", (*controllers.QueryController).SaveAction)
+	web.CtrlPost("/query-delete", (*controllers.QueryController).DeleteAction)
 
 	web.CtrlGet("/org-list", (*controllers.OrganizationController).IndexAction)
 	web.CtrlPost("/org-list", (*controllers.OrganizationController).ListAction)
@@ -98,6 +127,7 @@ func init() {
 	web.CtrlPost("/task-start-xscan", (*controllers.TaskController).StartXScanTaskAction)
 	web.CtrlGet("/task-info-main", (*controllers.TaskController).InfoMainAction)
 	web.CtrlPost("/task-delete-main", (*controllers.TaskController).DeleteMainAction)
+	web.CtrlPost("/task-log", (*controllers.TaskController).LogAction)
 
 	web.CtrlGet("/task-cron-list", (*controllers.TaskController).IndexCronAction)
 	web.CtrlPost("/task-cron-list", (*controllers.TaskController).ListCronAction)
@@ -63,9 +63,14 @@ func (c *ConfigController) LoadDefaultConfig() {
 		IsWhois:            domainscan.IsWhois,
 		IsICP:              domainscan.IsICP,
 		//onlineAPI:
-		IsFofa:   onlineapi.IsFofa,
-		IsHunter: onlineapi.IsHunter,
-		IsQuake:  onlineapi.IsQuake,
+		IsFofa:       onlineapi.IsFofa,
+		IsHunter:     onlineapi.IsHunter,
+		IsQuake:      onlineapi.IsQuake,
+		IsShodan:     onlineapi.IsShodan,
+		IsCensys:     onlineapi.IsCensys,
+		IsZoomEye:    onlineapi.IsZoomEye,
+		IsNetlas:     onlineapi.IsNetlas,
+		IsBinaryEdge: onlineapi.IsBinaryEdge,
 		// task
 		IpSliceNumber:   task.IpSliceNumber,
 		PortSliceNumber: task.PortSliceNumber,
@@ -103,6 +108,11 @@ func (c *ConfigController) LoadDefaultConfig() {
 // @Param fofa				formData bool true "是否执行fofa"
 // @Param hunter			formData bool true "是否执行hunter"
 // @Param quake				formData bool true "是否执行quake"
+// @Param shodan			formData bool true "是否执行shodan"
+// @Param censys			formData bool true "是否执行censys"
+// @Param zoomeye			formData bool true "是否执行zoomeye"
+// @Param netlas			formData bool true "是否执行netlas"
+// @Param binaryedge		formData bool true "是否执行binaryedge"
 // @Success 200 {object} models.WorkspaceDataTableResponseData
 // @router /save-default [post]
 func (c *ConfigController) SaveDefaultConfig() {
@@ -166,6 +176,11 @@ func (c *ConfigController) SaveDefaultConfig() {
 	conf.GlobalWorkerConfig().OnlineAPI.IsFofa = data.IsFofa
 	conf.GlobalWorkerConfig().OnlineAPI.IsQuake = data.IsQuake
 	conf.GlobalWorkerConfig().OnlineAPI.IsHunter = data.IsHunter
+	conf.GlobalWorkerConfig().OnlineAPI.IsShodan = data.IsShodan
+	conf.GlobalWorkerConfig().OnlineAPI.IsCensys = data.IsCensys
+	conf.GlobalWorkerConfig().OnlineAPI.IsZoomEye = data.IsZoomEye
+	conf.GlobalWorkerConfig().OnlineAPI.IsNetlas = data.IsNetlas
+	conf.GlobalWorkerConfig().OnlineAPI.IsBinaryEdge = data.IsBinaryEdge
 	err = conf.GlobalWorkerConfig().WriteConfig()
 	if err != nil {
 		c.FailedStatus(err.Error())
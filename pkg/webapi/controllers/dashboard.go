@@ -16,6 +16,16 @@ func (c *DashboardController) GetStatisticData() {
 	c.GetStatisticDataAction()
 }
 
+// @Title GetMetrics
+// @Description 获取任务队列及任务状态的指标
+// @Param authorization		header string true "token"
+// @Success 200 {object} models.DashboardMetricsData
+// @router /metrics [post]
+func (c *DashboardController) GetMetrics() {
+	c.IsServerAPI = true
+	c.GetMetricsAction()
+}
+
 // @Title GetTaskInfo
 // @Description 获取任务数据
 // @Param authorization		header string true "token"
@@ -24,6 +24,7 @@ type DomainController struct {
 // @Param select_no_ip 		formData bool false "选择没有解析IP的资产"
 // @Param select_order_by_date 	formData bool false "IP按更新日期排序"
 // @Param domain_http 			formData string false "http协议中的属性"
+// @Param query 			formData string false "查询语句，如port=443 && title=login"
 // @Success 200 {object} models.DomainDataTableResponseData
 // @router /list [post]
 func (c *DomainController) List() {
@@ -0,0 +1,21 @@
+package controllers
+
+import ctrl "github.com/hanc00l/nemo_go/pkg/web/controllers"
+
+type FulltextController struct {
+	ctrl.FulltextController
+}
+
+// @Title Search
+// @Description 在当前workspace的全文索引中检索HTTP内容、Banner及属性，返回按相关度排序的结果及高亮片段
+// @Param authorization		header string true "token"
+// @Param start 			formData int true "查询的起始行数"
+// @Param length 			formData int true "返回的行数（最多100）"
+// @Param query 			formData string true "检索语句，如rememberMe tag:header"
+// @Param category 			formData string false "类别：ip、domain，为空时不限"
+// @Success 200 {object} models.FulltextSearchResponseData
+// @router /search [post]
+func (c *FulltextController) Search() {
+	c.IsServerAPI = true
+	c.SearchAction()
+}
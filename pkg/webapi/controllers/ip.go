@@ -31,6 +31,7 @@ type IPController struct {
 // @Param select_no_openedport 	formData bool false "选择没有开放端口的IP"
 // @Param select_order_by_date 	formData bool false "IP按更新日期排序"
 // @Param ip_http 			formData string false "http协议中的属性"
+// @Param query 			formData string false "查询语句，如port=443 && title=login"
 // @Success 200 {object} models.IPDataTableResponseData
 // @router /list [post]
 func (c *IPController) List() {
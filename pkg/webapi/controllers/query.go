@@ -0,0 +1,53 @@
+package controllers
+
+import ctrl "github.com/hanc00l/nemo_go/pkg/web/controllers"
+
+type QueryController struct {
+	ctrl.QueryController
+}
+
+// @Title List
+// @Description 当前workspace中保存的查询语句
+// @Param authorization		header string true "token"
+// @Param category 			formData string true "查询的类别：ip、domain、vulnerability"
+// @Success 200 {object} models.SavedQueryListData
+// @router /list [post]
+func (c *QueryController) List() {
+	c.IsServerAPI = true
+	c.ListAction()
+}
+
+// @Title Fields
+// @Description 查询语句中类别可用的字段
+// @Param authorization		header string true "token"
+// @Param category 			formData string true "查询的类别：ip、domain、vulnerability"
+// @Success 200 {object} models.QueryFieldsData
+// @router /fields [post]
+func (c *QueryController) Fields() {
+	c.IsServerAPI = true
+	c.FieldsAction()
+}
+
+// @Title Save
+// @Description 保存查询语句，同名的查询语句将被覆盖
+// @Param authorization		header string true "token"
+// @Param name 				formData string true "查询名称"
+// @Param category 			formData string true "查询的类别：ip、domain、vulnerability"
+// @Param query 			formData string true "查询语句"
+// @Success 200 {object} models.StatusResponseData
+// @router /save [post]
+func (c *QueryController) Save() {
+	c.IsServerAPI = true
+	c.SaveAction()
+}
+
+// @Title Delete
+// @Description 删除保存的查询语句
+// @Param authorization		header string true "token"
+// @Param id 				formData int true "id"
+// @Success 200 {object} models.StatusResponseData
+// @router /delete [post]
+func (c *QueryController) Delete() {
+	c.IsServerAPI = true
+	c.DeleteAction()
+}
@@ -0,0 +1,24 @@
+package controllers
+
+import ctrl "github.com/hanc00l/nemo_go/pkg/web/controllers"
+
+type ScreenshotController struct {
+	ctrl.ScreenshotController
+}
+
+// @Title Gallery
+// @Description 根据指定筛选条件，查询按视觉相似度（感知哈希）分组的截图
+// @Param authorization		header string true "token"
+// @Param start 			formData int true "查询的分组的起始行数"
+// @Param length 			formData int true "返回分组的数量"
+// @Param domain 			formData string false "IP或域名"
+// @Param title 			formData string false "页面标题"
+// @Param content 			formData string false "页面文本"
+// @Param distance 			formData int false "感知哈希的最大汉明距离（默认为8）"
+// @Param date_delta 		formData int false "时间间隔"
+// @Success 200 {object} models.ScreenshotGalleryResponseData
+// @router /gallery [post]
+func (c *ScreenshotController) Gallery() {
+	c.IsServerAPI = true
+	c.GalleryAction()
+}
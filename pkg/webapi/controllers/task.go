@@ -66,6 +66,19 @@ func (c *TaskController) InfoRunTask() {
 	c.InfoAction()
 }
 
+// @Title ListTaskLog
+// @Description 任务的运行日志，主任务包括其全部子任务的日志
+// @Param authorization		header string true "token"
+// @Param start 			formData int true "查询起始行数"
+// @Param length 			formData int true "返回指定的数量"
+// @Param task_id 			formData string true "任务或主任务ID"
+// @Success 200 {object} models.TaskLogResponseData
+// @router /log [post]
+func (c *TaskController) ListTaskLog() {
+	c.IsServerAPI = true
+	c.LogAction()
+}
+
 // @Title InfoCronTask
 // @Description 显示一个CronTask任务的详情
 // @Param authorization		header string true "token"
@@ -195,9 +208,13 @@ type XScanRequestParam struct {
 // @Param xraypocfile 	formData string false "xraypoc使用的pocfile，格式为\"poc类型|poc文件名\"；poc类型为default或custom，poc文件名可为空（全部poc）或xray支持的模糊匹配方式"
 // @Param nucleipoc 	formData bool false "是否要执行nuclei扫描"
 // @Param nucleipocfile formData string false "nucleipoc使用的pocfile"
+// @Param xraypocv1 	formData bool false "是否要执行xraypocv1扫描"
+// @Param xraypocv1file formData string false "xraypocv1使用的pocfile，多个以,分隔，为空时使用全部poc"
+// @Param pocmap 		formData bool false "是否根据指纹识别的结果，只使用指纹对应的poc（thirdparty/custom/fingerprint_poc.json）"
 // @Param taskcron 		formData bool false "是否为计划任务"
 // @Param cronrule 		formData string false "计划任务的规则"
 // @Param croncomment 	formData string false "计划任务的名称"
+// @Param worker_labels formData string false "任务只分配到具有全部指定标签的worker，格式为“标签=值”，多个标签以,分隔"
 // @Success 200 {object} models.StatusResponseData
 // @router /xscan [post]
 func (c *TaskController) StartXScanTask() {
@@ -0,0 +1,56 @@
+package controllers
+
+import ctrl "github.com/hanc00l/nemo_go/pkg/web/controllers"
+
+type UrlController struct {
+	ctrl.UrlController
+}
+
+// @Title List
+// @Description 根据指定筛选条件，查询URL资产的数据
+// @Param authorization		header string true "token"
+// @Param start 			formData int true "查询的资产的起始行数"
+// @Param length 			formData int true "返回资产指定的数量"
+// @Param url 				formData string false "URL"
+// @Param host 				formData string false "IP或域名"
+// @Param method 			formData string false "请求方法"
+// @Param params 			formData string false "参数名"
+// @Param content_type 		formData string false "Content-Type"
+// @Param status 			formData int false "状态码"
+// @Param source 			formData string false "来源（crawler、httpx、dirsearch）"
+// @Param date_delta 		formData int false "时间间隔"
+// @Success 200 {object} models.UrlDataTableResponseData
+// @router /list [post]
+func (c *UrlController) List() {
+	c.IsServerAPI = true
+	c.ListAction()
+}
+
+// @Title DeleteUrl
+// @Description 删除一个URL资产
+// @Param authorization	header string true "token"
+// @Param id 			formData int true "id"
+// @Success 200 {object} models.StatusResponseData
+// @router /delete [post]
+func (c *UrlController) DeleteUrl() {
+	c.IsServerAPI = true
+	c.DeleteAction()
+}
+
+// @Title Export
+// @Description 根据指定筛选条件，导出URL资产（csv格式）
+// @Param authorization		header string true "token"
+// @Param url 				formData string false "URL"
+// @Param host 				formData string false "IP或域名"
+// @Param method 			formData string false "请求方法"
+// @Param params 			formData string false "参数名"
+// @Param content_type 		formData string false "Content-Type"
+// @Param status 			formData int false "状态码"
+// @Param source 			formData string false "来源（crawler、httpx、dirsearch）"
+// @Param date_delta 		formData int false "时间间隔"
+// @Success 200 {string} csv
+// @router /export [post]
+func (c *UrlController) Export() {
+	c.IsServerAPI = true
+	c.ExportAction()
+}
@@ -15,6 +15,7 @@ type VulController struct {
 // @Param vul_target 		formData string false "漏洞目标"
 // @Param vul_poc_file 		formData string false "漏洞的poc"
 // @Param date_delta 		formData int false "时间间隔"
+// @Param query 			formData string false "查询语句，如port=443 && title=login"
 // @Success 200 {object} models.VulDataTableResponseData
 // @router /list [post]
 func (c *VulController) List() {
@@ -64,3 +65,28 @@ func (c *VulController) LoadNucleiPocFile() {
 	c.IsServerAPI = true
 	c.LoadNucleiPocFileAction()
 }
+
+// @Title LoadNucleiTemplate
+// @Description 根据标签、严重程度、协议等条件获取Nuclei模板的元数据
+// @Param authorization	header string true "token"
+// @Param tags 			formData string false "标签，多个以,分隔"
+// @Param severity 		formData string false "严重程度（info、low、medium、high、critical），多个以,分隔"
+// @Param protocol 		formData string false "协议（http、network、dns、workflow等）"
+// @Param keyword 		formData string false "模板id、名称或路径的关键字"
+// @Param refresh 		formData bool false "是否重新建立模板索引"
+// @Success 200 {object} models.NucleiTemplateList
+// @router /nuclei/template [post]
+func (c *VulController) LoadNucleiTemplate() {
+	c.IsServerAPI = true
+	c.LoadNucleiTemplateAction()
+}
+
+// @Title LoadNucleiTags
+// @Description 获取Nuclei模板的所有标签
+// @Param authorization	header string true "token"
+// @Success 200 {object} models.PocFileList
+// @router /nuclei/tags [post]
+func (c *VulController) LoadNucleiTags() {
+	c.IsServerAPI = true
+	c.LoadNucleiTagsAction()
+}
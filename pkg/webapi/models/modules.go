@@ -10,23 +10,25 @@ type StatusResponseData struct {
 
 // IPListData IP列表显示数据
 type IPListData struct {
-	Id             int      `json:"id"`
-	Index          int      `json:"index"`
-	IP             string   `json:"ip"`
-	Location       string   `json:"location"`
-	Port           []string `json:"port"`
-	Title          string   `json:"title"`
-	Banner         string   `json:"banner"`
-	ColorTag       string   `json:"color_tag"`
-	MemoContent    string   `json:"memo_content"`
-	Vulnerability  string   `json:"vulnerability"`
-	HoneyPot       string   `json:"honeypot"`
-	ScreenshotFile []string `json:"screenshot"`
-	IsCDN          bool     `json:"cdn"`
-	IconImage      []string `json:"iconimage"`
-	WorkspaceId    int      `json:"workspace"`
-	WorkspaceGUID  string   `json:"workspace_guid"`
-	PinIndex       int      `json:"pinindex"`
+	Id             int               `json:"id"`
+	Index          int               `json:"index"`
+	IP             string            `json:"ip"`
+	Location       string            `json:"location"`
+	Port           []string          `json:"port"`
+	Title          string            `json:"title"`
+	Banner         string            `json:"banner"`
+	ValueSource    map[string]string `json:"value_source"`
+	EngineOnlyPort []int             `json:"engine_only_port"`
+	ColorTag       string            `json:"color_tag"`
+	MemoContent    string            `json:"memo_content"`
+	Vulnerability  string            `json:"vulnerability"`
+	HoneyPot       string            `json:"honeypot"`
+	ScreenshotFile []string          `json:"screenshot"`
+	IsCDN          bool              `json:"cdn"`
+	IconImage      []string          `json:"iconimage"`
+	WorkspaceId    int               `json:"workspace"`
+	WorkspaceGUID  string            `json:"workspace_guid"`
+	PinIndex       int               `json:"pinindex"`
 }
 
 // IPDataTableResponseData IP列表的返回数据
@@ -52,6 +54,25 @@ type PortAttrInfo struct {
 	TableBackgroundSet bool
 }
 
+// PortReconcileInfo 端口属性合并后的一行数据
+type PortReconcileInfo struct {
+	IP         string
+	Port       string
+	Verified   bool
+	Tag        string
+	Value      string
+	Confidence string
+	Sources    []PortReconcileSource
+}
+
+// PortReconcileSource 合并属性的一个来源的原始值
+type PortReconcileSource struct {
+	Source     string
+	Content    string
+	Score      string
+	UpdateTime string
+}
+
 // ScreenshotFileInfo screenshot文件
 type ScreenshotFileInfo struct {
 	ScreenShotFile          string
@@ -101,6 +122,7 @@ type IPInfo struct {
 	Title         []string
 	Banner        []string
 	PortAttr      []PortAttrInfo
+	PortReconcile []PortReconcileInfo
 	Domain        []string
 	ColorTag      string
 	Memo          string
@@ -143,6 +165,30 @@ type TaskDataTableResponseData struct {
 	Data            []TaskListData `json:"data"`
 }
 
+// TaskLogData 任务的一条日志
+type TaskLogData struct {
+	Id         int    `json:"id"`
+	Index      int    `json:"index"`
+	Source     string `json:"source"`
+	File       string `json:"file"`
+	Func       string `json:"func"`
+	Level      string `json:"level"`
+	Message    string `json:"message"`
+	TaskId     string `json:"task_id"`
+	MainTaskId string `json:"main_task_id"`
+	CreateTime string `json:"create_datetime"`
+	UpdateTime string `json:"update_datetime"`
+}
+
+// TaskLogResponseData 任务日志的返回数据
+type TaskLogResponseData struct {
+	Draw            int           `json:"draw"`
+	RecordsTotal    int           `json:"recordsTotal"`
+	RecordsFiltered int           `json:"recordsFiltered"`
+	Data            []TaskLogData `json:"data"`
+	Error           string        `json:"error"`
+}
+
 type TaskCronListData struct {
 	Id          int    `json:"id"`
 	Index       int    `json:"index"`
@@ -285,6 +331,112 @@ type VulDataTableResponseData struct {
 
 type PocFileList []string
 
+type NucleiTemplateData struct {
+	Id       string   `json:"id"`
+	Name     string   `json:"name"`
+	Path     string   `json:"path"`
+	Tags     []string `json:"tags"`
+	Severity string   `json:"severity"`
+	Author   []string `json:"author"`
+	Protocol string   `json:"protocol"`
+}
+
+type NucleiTemplateList []NucleiTemplateData
+
+type UrlData struct {
+	Id          int    `json:"id"`
+	Index       int    `json:"index"`
+	Url         string `json:"url"`
+	Host        string `json:"host"`
+	Port        int    `json:"port"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Params      string `json:"params"`
+	ContentType string `json:"content_type"`
+	Status      int    `json:"status"`
+	Source      string `json:"source"`
+	CreateTime  string `json:"create_datetime"`
+	UpdateTime  string `json:"update_datetime"`
+	WorkspaceId int    `json:"workspace"`
+}
+
+// UrlDataTableResponseData URL资产的列表返回数据
+type UrlDataTableResponseData struct {
+	Draw            int       `json:"draw"`
+	RecordsTotal    int       `json:"recordsTotal"`
+	RecordsFiltered int       `json:"recordsFiltered"`
+	Data            []UrlData `json:"data"`
+}
+
+type ScreenshotItemData struct {
+	Domain        string `json:"domain"`
+	IsIP          bool   `json:"is_ip"`
+	Port          int    `json:"port"`
+	Url           string `json:"url"`
+	Title         string `json:"title"`
+	PHash         string `json:"phash"`
+	File          string `json:"file"`
+	ThumbnailFile string `json:"thumbnail"`
+	UpdateTime    string `json:"update_datetime"`
+	WorkspaceId   int    `json:"workspace"`
+}
+
+type ScreenshotGroupData struct {
+	Index int                  `json:"index"`
+	PHash string               `json:"phash"`
+	Title string               `json:"title"`
+	Count int                  `json:"count"`
+	Items []ScreenshotItemData `json:"items"`
+}
+
+// ScreenshotGalleryResponseData 按相似度分组的截图返回数据
+type ScreenshotGalleryResponseData struct {
+	Draw            int                   `json:"draw"`
+	RecordsTotal    int                   `json:"recordsTotal"`
+	RecordsFiltered int                   `json:"recordsFiltered"`
+	Data            []ScreenshotGroupData `json:"data"`
+}
+
+// FulltextHitData 一条全文检索结果，Highlights为已转义的html片段，匹配的内容由<mark>标记
+type FulltextHitData struct {
+	Category    string   `json:"category"`
+	Target      string   `json:"target"`
+	Port        int      `json:"port"`
+	Type        string   `json:"type"`
+	Source      string   `json:"source"`
+	Tag         string   `json:"tag"`
+	Score       float64  `json:"score"`
+	Highlights  []string `json:"highlights"`
+	UpdateTime  string   `json:"update_datetime"`
+	WorkspaceId int      `json:"workspace"`
+}
+
+// FulltextSearchResponseData 全文检索的返回数据
+type FulltextSearchResponseData struct {
+	Draw            int               `json:"draw"`
+	RecordsTotal    int               `json:"recordsTotal"`
+	RecordsFiltered int               `json:"recordsFiltered"`
+	Data            []FulltextHitData `json:"data"`
+	Error           string            `json:"error"`
+}
+
+// SavedQueryData 保存的查询语句
+type SavedQueryData struct {
+	Id         int    `json:"id"`
+	Name       string `json:"name"`
+	Category   string `json:"category"`
+	Query      string `json:"query"`
+	UpdateTime string `json:"update_datetime"`
+}
+
+type SavedQueryListData []SavedQueryData
+
+// QueryFieldsData 查询语句中类别可用的字段
+type QueryFieldsData struct {
+	Category string   `json:"category"`
+	Fields   []string `json:"fields"`
+}
+
 type OrganizationData struct {
 	Id             int    `json:"id" form:"id"`
 	Index          int    `json:"index" form:"-"`
@@ -388,9 +540,14 @@ type DefaultConfigData struct {
 	IsFingerprintHub bool `json:"fingerprinthub"`
 	IsIconHash       bool `json:"iconhash"`
 	// onlineapi
-	IsFofa   bool `json:"fofa"`
-	IsQuake  bool `json:"quake"`
-	IsHunter bool `json:"hunter"`
+	IsFofa       bool `json:"fofa"`
+	IsQuake      bool `json:"quake"`
+	IsHunter     bool `json:"hunter"`
+	IsShodan     bool `json:"shodan"`
+	IsCensys     bool `json:"censys"`
+	IsZoomEye    bool `json:"zoomeye"`
+	IsNetlas     bool `json:"netlas"`
+	IsBinaryEdge bool `json:"binaryedge"`
 	// task
 	IpSliceNumber   int `json:"ipslicenumber"`
 	PortSliceNumber int `json:"portslicenumber"`
@@ -420,6 +577,19 @@ type TaskInfoData struct {
 	TaskInfo string `json:"task_info"`
 }
 
+type TaskStateMetricData struct {
+	TaskName string `json:"task_name"`
+	Started  int    `json:"started"`
+	Success  int    `json:"success"`
+	Failure  int    `json:"failure"`
+	Revoked  int    `json:"revoked"`
+}
+
+type DashboardMetricsData struct {
+	QueueDepth map[string]int        `json:"queue_depth"`
+	TaskState  []TaskStateMetricData `json:"task_state"`
+}
+
 // OnlineUserDataTableResponseData DataTable列表的返回数据
 type OnlineUserDataTableResponseData struct {
 	Draw            int              `json:"draw"`
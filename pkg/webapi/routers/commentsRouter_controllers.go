@@ -34,6 +34,15 @@ func init() {
             Filters: nil,
             Params: nil})
 
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:DashboardController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:DashboardController"],
+        beego.ControllerComments{
+            Method: "GetMetrics",
+            Router: `/metrics`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
     beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:DashboardController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:DashboardController"],
         beego.ControllerComments{
             Method: "GetStatisticData",
@@ -178,6 +187,15 @@ func init() {
             Filters: nil,
             Params: nil})
 
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:FulltextController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:FulltextController"],
+        beego.ControllerComments{
+            Method: "Search",
+            Router: `/search`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
     beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:IPController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:IPController"],
         beego.ControllerComments{
             Method: "MarkColor",
@@ -340,6 +358,51 @@ func init() {
             Filters: nil,
             Params: nil})
 
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:QueryController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:QueryController"],
+        beego.ControllerComments{
+            Method: "Delete",
+            Router: `/delete`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:QueryController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:QueryController"],
+        beego.ControllerComments{
+            Method: "Fields",
+            Router: `/fields`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:QueryController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:QueryController"],
+        beego.ControllerComments{
+            Method: "List",
+            Router: `/list`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:QueryController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:QueryController"],
+        beego.ControllerComments{
+            Method: "Save",
+            Router: `/save`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:ScreenshotController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:ScreenshotController"],
+        beego.ControllerComments{
+            Method: "Gallery",
+            Router: `/gallery`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
     beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:TaskController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:TaskController"],
         beego.ControllerComments{
             Method: "DeleteBatchTask",
@@ -403,6 +466,15 @@ func init() {
             Filters: nil,
             Params: nil})
 
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:TaskController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:TaskController"],
+        beego.ControllerComments{
+            Method: "ListTaskLog",
+            Router: `/log`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
     beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:TaskController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:TaskController"],
         beego.ControllerComments{
             Method: "DeleteMainTask",
@@ -538,6 +610,33 @@ func init() {
             Filters: nil,
             Params: nil})
 
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:UrlController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:UrlController"],
+        beego.ControllerComments{
+            Method: "DeleteUrl",
+            Router: `/delete`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:UrlController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:UrlController"],
+        beego.ControllerComments{
+            Method: "Export",
+            Router: `/export`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:UrlController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:UrlController"],
+        beego.ControllerComments{
+            Method: "List",
+            Router: `/list`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
     beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:VulController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:VulController"],
         beego.ControllerComments{
             Method: "DeleteVul",
@@ -574,6 +673,24 @@ func init() {
             Filters: nil,
             Params: nil})
 
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:VulController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:VulController"],
+        beego.ControllerComments{
+            Method: "LoadNucleiTags",
+            Router: `/nuclei/tags`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:VulController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:VulController"],
+        beego.ControllerComments{
+            Method: "LoadNucleiTemplate",
+            Router: `/nuclei/template`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
     beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:VulController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:VulController"],
         beego.ControllerComments{
             Method: "LoadXrayPocFile",
@@ -34,11 +34,31 @@ func init() {
 				&controllers.VulController{},
 			),
 		),
+		beego.NSNamespace("/url",
+			beego.NSInclude(
+				&controllers.UrlController{},
+			),
+		),
+		beego.NSNamespace("/fulltext",
+			beego.NSInclude(
+				&controllers.FulltextController{},
+			),
+		),
+		beego.NSNamespace("/screenshot",
+			beego.NSInclude(
+				&controllers.ScreenshotController{},
+			),
+		),
 		beego.NSNamespace("/org",
 			beego.NSInclude(
 				&controllers.OrganizationController{},
 			),
 		),
+		beego.NSNamespace("/query",
+			beego.NSInclude(
+				&controllers.QueryController{},
+			),
+		),
 		beego.NSNamespace("/task",
 			beego.NSInclude(
 				&controllers.TaskController{},
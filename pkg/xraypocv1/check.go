@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"github.com/google/cel-go/cel"
 	"gopkg.in/yaml.v3"
-	"math/rand"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -13,11 +12,6 @@ import (
 	"time"
 )
 
-var (
-	ceyeApi    = "a78a1cb49d91fe09e01876078d1868b2"
-	ceyeDomain = "7wtusr.ceye.io"
-)
-
 type Task struct {
 	Req *http.Request
 	Poc *Poc
@@ -94,7 +88,7 @@ func executePoc(oReq *http.Request, p *Poc) (bool, error, string) {
 				variableMap[k] = expression
 				continue
 			}
-			switch value := out.Value().(type) {
+			switch value := messageV1(out.Value()).(type) {
 			case *UrlType:
 				variableMap[k] = UrlTypeToString(value)
 			case int64:
@@ -184,7 +178,7 @@ func executePoc(oReq *http.Request, p *Poc) (bool, error, string) {
 		req.Url.Path = strings.ReplaceAll(req.Url.Path, " ", "%20")
 		req.Url.Path = strings.ReplaceAll(req.Url.Path, "+", "%20")
 
-		newRequest, err := http.NewRequest(rule.Method, fmt.Sprintf("%s://%s%s", req.Url.Scheme, req.Url.Host, req.Url.Path), strings.NewReader(rule.Body))
+		newRequest, err := http.NewRequestWithContext(oReq.Context(), rule.Method, fmt.Sprintf("%s://%s%s", req.Url.Scheme, req.Url.Host, req.Url.Path), strings.NewReader(rule.Body))
 		if err != nil {
 			return false, err
 		}
@@ -275,15 +269,18 @@ func doSearch(re string, body string) map[string]string {
 }
 
 func newReverse() *Reverse {
-	letters := "1234567890abcdefghijklmnopqrstuvwxyz-"
-	randSource := rand.New(rand.NewSource(time.Now().Unix()))
-	sub := RandomStr(randSource, letters, 8)
-	if true {
-		//默认不开启dns解析
+	if reversePlatform == nil {
+		//未设置反连平台时不开启反连
+		return &Reverse{}
+	}
+	domain, err := reversePlatform.NewSubdomain()
+	if err != nil || domain == "" {
+		return &Reverse{}
+	}
+	u, err := url.Parse(fmt.Sprintf("http://%s", domain))
+	if err != nil {
 		return &Reverse{}
 	}
-	urlStr := fmt.Sprintf("http://%s.%s", sub, ceyeDomain)
-	u, _ := url.Parse(urlStr)
 	return &Reverse{
 		Url:                ParseUrl(u),
 		Domain:             u.Hostname(),
@@ -615,7 +612,7 @@ func clustersend(oReq *http.Request, variableMap map[string]interface{}, req *Re
 	req.Url.Path = strings.ReplaceAll(req.Url.Path, " ", "%20")
 	req.Url.Path = strings.ReplaceAll(req.Url.Path, "+", "%20")
 
-	newRequest, _ := http.NewRequest(rule.Method, fmt.Sprintf("%s://%s%s", req.Url.Scheme, req.Url.Host, req.Url.Path), strings.NewReader(rule.Body))
+	newRequest, _ := http.NewRequestWithContext(oReq.Context(), rule.Method, fmt.Sprintf("%s://%s%s", req.Url.Scheme, req.Url.Host, req.Url.Path), strings.NewReader(rule.Body))
 	newRequest.Header = oReq.Header.Clone()
 	for k, v := range rule.Headers {
 		newRequest.Header.Set(k, v)
@@ -703,7 +700,7 @@ func evalset(env *cel.Env, variableMap map[string]interface{}) {
 				variableMap[k] = expression
 				continue
 			}
-			switch value := out.Value().(type) {
+			switch value := messageV1(out.Value()).(type) {
 			case *UrlType:
 				variableMap[k] = UrlTypeToString(value)
 			case int64:
@@ -16,7 +16,13 @@ var (
 	keepAlive        = 5 * time.Second
 )
 
-func InitHttpClient(ThreadsNum int, DownProxy string, Timeout time.Duration) error {
+func InitHttpClient(ThreadsNum int, DownProxy string, Timeout time.Duration) (err error) {
+	Client, ClientNoRedirect, err = newHttpClient(DownProxy, Timeout)
+	return
+}
+
+// newHttpClient 创建http请求的client（跟随跳转及不跟随跳转），共用同一个Transport
+func newHttpClient(DownProxy string, Timeout time.Duration) (client *http.Client, clientNoRedirect *http.Client, err error) {
 	dialer := &net.Dialer{
 		Timeout:   dialTimout,
 		KeepAlive: keepAlive,
@@ -40,19 +46,19 @@ func InitHttpClient(ThreadsNum int, DownProxy string, Timeout time.Duration) err
 		}
 		u, err := url.Parse(DownProxy)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 		tr.Proxy = http.ProxyURL(u)
 	}
 
-	Client = &http.Client{
+	client = &http.Client{
 		Transport: tr,
 		Timeout:   Timeout,
 	}
-	ClientNoRedirect = &http.Client{
+	clientNoRedirect = &http.Client{
 		Transport:     tr,
 		Timeout:       Timeout,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse },
 	}
-	return nil
+	return client, clientNoRedirect, nil
 }
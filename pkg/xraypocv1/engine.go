@@ -0,0 +1,121 @@
+package xraypocv1
+
+import (
+	"context"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"net/http/httputil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// evidenceBodyMaxSize 证据中保留的响应正文最大长度
+const evidenceBodyMaxSize = 4096
+
+// Evidence 验证成功时最后一次的请求与响应
+type Evidence struct {
+	Request  string
+	Response string
+}
+
+// PocEngine 进程内的xray格式POC执行引擎，每个引擎使用独立的http client（超时、代理）
+type PocEngine struct {
+	client           *http.Client
+	clientNoRedirect *http.Client
+}
+
+type sessionContextKey struct{}
+
+// session 一次POC执行的上下文，记录最后一次的请求与响应
+type session struct {
+	sync.Mutex
+	client           *http.Client
+	clientNoRedirect *http.Client
+	evidence         Evidence
+}
+
+// NewPocEngine 创建POC执行引擎，proxy为空时不使用代理，timeout为每个请求的超时时间
+func NewPocEngine(proxy string, timeout time.Duration) (*PocEngine, error) {
+	client, clientNoRedirect, err := newHttpClient(proxy, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &PocEngine{client: client, clientNoRedirect: clientNoRedirect}, nil
+}
+
+// LoadPoc 解析POC内容
+func LoadPoc(pocBody []byte) (*Poc, error) {
+	poc := &Poc{}
+	if err := yaml.Unmarshal(pocBody, poc); err != nil {
+		return nil, err
+	}
+	if poc.Name == "" || (len(poc.Rules) == 0 && len(poc.Groups) == 0) {
+		return nil, fmt.Errorf("invalid poc")
+	}
+	return poc, nil
+}
+
+// Execute 对目标url执行一个POC，验证成功时返回POC名称及证据
+// POC在执行过程中会修改规则内容，因此每次执行都重新解析POC
+func (e *PocEngine) Execute(ctx context.Context, target string, pocBody []byte) (isVul bool, pocName string, evidence Evidence, err error) {
+	poc, err := LoadPoc(pocBody)
+	if err != nil {
+		return
+	}
+	s := &session{client: e.client, clientNoRedirect: e.clientNoRedirect}
+	req, err := http.NewRequestWithContext(context.WithValue(ctx, sessionContextKey{}, s), http.MethodGet, target, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("User-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/104.0.0.0 Safari/537.36")
+	isVul, err, _ = executePoc(req, poc)
+	if isVul {
+		s.Lock()
+		evidence = s.evidence
+		s.Unlock()
+	}
+	return isVul, poc.Name, evidence, err
+}
+
+func sessionFromContext(ctx context.Context) *session {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(sessionContextKey{}).(*session)
+	return s
+}
+
+func (s *session) dumpRequest(req *http.Request) {
+	dump, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		dump = []byte(fmt.Sprintf("%s %s", req.Method, req.URL.String()))
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.evidence = Evidence{Request: string(dump)}
+}
+
+func (s *session) dumpResponse(resp *Response) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("HTTP/1.1 %d %s\r\n", resp.Status, http.StatusText(int(resp.Status))))
+	var keys []string
+	for k := range resp.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf("%s: %s\r\n", k, resp.Headers[k]))
+	}
+	sb.WriteString("\r\n")
+	if len(resp.Body) > evidenceBodyMaxSize {
+		sb.Write(resp.Body[:evidenceBodyMaxSize])
+	} else {
+		sb.Write(resp.Body)
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.evidence.Response = sb.String()
+}
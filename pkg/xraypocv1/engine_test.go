@@ -0,0 +1,156 @@
+package xraypocv1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testEnginePoc = `name: poc-yaml-test-marker
+rules:
+  - method: POST
+    path: /marker
+    headers:
+      Content-Type: application/x-www-form-urlencoded
+    body: id=1
+    expression: response.status == 200 && response.body.bcontains(b"nemo-marker")
+`
+
+func newTestTarget() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/marker":
+			w.Header().Set("X-Test", "1")
+			fmt.Fprint(w, "hello nemo-marker")
+		case "/slow":
+			time.Sleep(2 * time.Second)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestPocEngine_Execute(t *testing.T) {
+	ts := newTestTarget()
+	defer ts.Close()
+
+	e, err := NewPocEngine("", 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	isVul, name, evidence, err := e.Execute(context.Background(), ts.URL, []byte(testEnginePoc))
+	if err != nil || !isVul || name != "poc-yaml-test-marker" {
+		t.Fatalf("execute fail:%v %v %s", err, isVul, name)
+	}
+	if !strings.HasPrefix(evidence.Request, "POST /marker") || !strings.Contains(evidence.Request, "id=1") {
+		t.Errorf("invalid request evidence:%s", evidence.Request)
+	}
+	if !strings.HasPrefix(evidence.Response, "HTTP/1.1 200 OK") || !strings.Contains(evidence.Response, "nemo-marker") {
+		t.Errorf("invalid response evidence:%s", evidence.Response)
+	}
+
+	isVul, _, _, _ = e.Execute(context.Background(), ts.URL, []byte(strings.ReplaceAll(testEnginePoc, "/marker", "/none")))
+	if isVul {
+		t.Error("should not be vulnerable")
+	}
+}
+
+func TestPocEngine_Timeout(t *testing.T) {
+	ts := newTestTarget()
+	defer ts.Close()
+
+	e, err := NewPocEngine("", 500*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	isVul, _, _, _ := e.Execute(context.Background(), ts.URL, []byte(strings.ReplaceAll(testEnginePoc, "/marker", "/slow")))
+	if isVul || time.Since(start) > 1500*time.Millisecond {
+		t.Errorf("request should be timeout:%v %v", isVul, time.Since(start))
+	}
+}
+
+func TestPocEngine_Proxy(t *testing.T) {
+	var proxied bool
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.IsAbs() && r.URL.Host == "nemo.test"
+		fmt.Fprint(w, "nemo-marker")
+	}))
+	defer proxy.Close()
+
+	e, err := NewPocEngine(proxy.URL, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	isVul, _, _, err := e.Execute(context.Background(), "http://nemo.test", []byte(testEnginePoc))
+	if err != nil || !isVul || !proxied {
+		t.Errorf("request should be proxied:%v %v %v", err, isVul, proxied)
+	}
+	if _, err = NewPocEngine("://invalid", time.Second); err == nil {
+		t.Error("invalid proxy should fail")
+	}
+}
+
+// testReversePlatform 模拟反连平台，目标访问过的子域名视为有交互
+type testReversePlatform struct {
+	sync.Mutex
+	hits map[string]bool
+}
+
+func (p *testReversePlatform) NewSubdomain() (string, error) {
+	return "abcdef.oob.test", nil
+}
+
+func (p *testReversePlatform) Check(domain string) bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.hits[domain]
+}
+
+func TestPocEngine_Reverse(t *testing.T) {
+	p := &testReversePlatform{hits: make(map[string]bool)}
+	SetReversePlatform(p)
+	defer SetReversePlatform(nil)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.Lock()
+		defer p.Unlock()
+		if u, err := url.Parse(r.URL.Query().Get("u")); err == nil {
+			p.hits[u.Hostname()] = true
+		}
+	}))
+	defer ts.Close()
+
+	e, err := NewPocEngine("", 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poc := `name: poc-yaml-test-reverse
+set:
+  reverse: newReverse()
+  reverseURL: reverse.url
+rules:
+  - method: GET
+    path: /ping?u={{reverseURL}}
+    expression: reverse.wait(1)
+`
+	isVul, _, evidence, err := e.Execute(context.Background(), ts.URL, []byte(poc))
+	if err != nil || !isVul {
+		t.Fatalf("reverse check fail:%v %v", err, isVul)
+	}
+	if !strings.Contains(evidence.Request, "abcdef.oob.test") {
+		t.Errorf("invalid request evidence:%s", evidence.Request)
+	}
+
+	SetReversePlatform(nil)
+	isVul, _, _, _ = e.Execute(context.Background(), ts.URL, []byte(poc))
+	if isVul {
+		t.Error("should not be vulnerable without reverse platform")
+	}
+}
@@ -7,12 +7,14 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"github.com/golang/protobuf/proto"
 	"github.com/google/cel-go/cel"
 	"github.com/google/cel-go/checker/decls"
 	"github.com/google/cel-go/common/types"
 	"github.com/google/cel-go/common/types/ref"
 	"github.com/google/cel-go/interpreter/functions"
 	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"io"
 	"io/ioutil"
 	"math/rand"
@@ -41,7 +43,16 @@ func Evaluate(env *cel.Env, expression string, params map[string]interface{}) (r
 		return nil, err
 	}
 
-	out, _, err := prg.Eval(params)
+	// http.pb.go中为旧版protobuf的结构，cel需要转换为新版的proto.Message，结果使用messageV1转换回来
+	vars := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		if m, ok := v.(proto.Message); ok {
+			vars[k] = proto.MessageV2(m)
+		} else {
+			vars[k] = v
+		}
+	}
+	out, _, err := prg.Eval(vars)
 	if err != nil {
 		//fmt.Printf("Evaluation error: %v", err)
 		return nil, err
@@ -96,10 +107,10 @@ func NewEnvOption() CustomLib {
 	c.envOptions = []cel.EnvOption{
 		cel.Container("lib"),
 		cel.Types(
-			&UrlType{},
-			&Request{},
-			&Response{},
-			&Reverse{},
+			proto.MessageV2(&UrlType{}),
+			proto.MessageV2(&Request{}),
+			proto.MessageV2(&Response{}),
+			proto.MessageV2(&Reverse{}),
 		),
 		cel.Declarations(
 			decls.NewIdent("request", decls.NewObjectType("lib.Request"), nil),
@@ -402,7 +413,7 @@ func NewEnvOption() CustomLib {
 			&functions.Overload{
 				Operator: "reverse_wait_int",
 				Binary: func(lhs ref.Val, rhs ref.Val) ref.Val {
-					reverse, ok := lhs.Value().(*Reverse)
+					reverse, ok := messageV1(lhs.Value()).(*Reverse)
 					if !ok {
 						return types.ValOrErr(lhs, "unexpected type '%v' passed to 'wait'", lhs.Type())
 					}
@@ -539,24 +550,29 @@ func randomUppercase(n int) string {
 	return RandomStr(randSource, lowercase, n)
 }
 
+// reverseCheck 在timeout秒内轮询反连平台，检查子域名是否有交互记录
 func reverseCheck(r *Reverse, timeout int64) bool {
-	if ceyeApi == "" || r.Domain == "" {
+	if reversePlatform == nil || r.Domain == "" {
 		return false
 	}
-	time.Sleep(time.Second * time.Duration(timeout))
-	sub := strings.Split(r.Domain, ".")[0]
-	urlStr := fmt.Sprintf("http://api.ceye.io/v1/records?token=%s&type=dns&filter=%s", ceyeApi, sub)
-	fmt.Println(urlStr)
-	req, _ := http.NewRequest("GET", urlStr, nil)
-	resp, err := DoRequest(req, false)
-	if err != nil {
-		return false
+	deadline := time.Now().Add(time.Second * time.Duration(timeout))
+	for {
+		if reversePlatform.Check(r.Domain) {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(reverseCheckInterval)
 	}
+}
 
-	if !bytes.Contains(resp.Body, []byte(`"data": []`)) && bytes.Contains(resp.Body, []byte(`"message": "OK"`)) { // api返回结果不为空
-		return true
+// messageV1 将cel返回的proto.Message转换为http.pb.go中的结构
+func messageV1(v interface{}) interface{} {
+	if m, ok := v.(protoreflect.ProtoMessage); ok {
+		return proto.MessageV1(m)
 	}
-	return false
+	return v
 }
 
 func RandomStr(randSource *rand.Rand, letterBytes string, n int) string {
@@ -590,12 +606,19 @@ func DoRequest(req *http.Request, redirect bool) (*Response, error) {
 		}
 	}
 
+	client, clientNoRedirect := Client, ClientNoRedirect
+	// 由PocEngine执行时使用独立的client，并记录请求与响应
+	s := sessionFromContext(req.Context())
+	if s != nil {
+		client, clientNoRedirect = s.client, s.clientNoRedirect
+		s.dumpRequest(req)
+	}
 	var oResp *http.Response
 	var err error
 	if redirect {
-		oResp, err = Client.Do(req)
+		oResp, err = client.Do(req)
 	} else {
-		oResp, err = ClientNoRedirect.Do(req)
+		oResp, err = clientNoRedirect.Do(req)
 	}
 	if err != nil {
 		return nil, err
@@ -605,6 +628,9 @@ func DoRequest(req *http.Request, redirect bool) (*Response, error) {
 	if err != nil {
 		return nil, err
 	}
+	if s != nil {
+		s.dumpResponse(resp)
+	}
 	return resp, err
 }
 
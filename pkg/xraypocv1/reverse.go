@@ -0,0 +1,19 @@
+package xraypocv1
+
+import "time"
+
+// reverseCheckInterval 检查反连结果的轮询间隔
+const reverseCheckInterval = time.Second
+
+// ReversePlatform 反连平台，为每次POC执行分配唯一的子域名并查询是否有交互
+type ReversePlatform interface {
+	NewSubdomain() (domain string, err error)
+	Check(domain string) bool
+}
+
+var reversePlatform ReversePlatform
+
+// SetReversePlatform 设置POC中newReverse()使用的反连平台，为nil时不开启反连
+func SetReversePlatform(p ReversePlatform) {
+	reversePlatform = p
+}
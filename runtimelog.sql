@@ -30,9 +30,14 @@ CREATE TABLE `runtimelog` (
   `level` varchar(20) NOT NULL,
   `level_int` int(11) NOT NULL,
   `message` varchar(1000) NOT NULL,
+  `task_id` varchar(40) NOT NULL DEFAULT '',
+  `main_task_id` varchar(40) NOT NULL DEFAULT '',
+  `workspace_id` int(11) NOT NULL DEFAULT '0',
   `create_datetime` datetime NOT NULL,
   `update_datetime` datetime NOT NULL,
-  PRIMARY KEY (`id`)
+  PRIMARY KEY (`id`),
+  KEY `index_runtimelog_task_id` (`task_id`),
+  KEY `index_runtimelog_main_task_id` (`main_task_id`)
 ) ENGINE=InnoDB AUTO_INCREMENT=164 DEFAULT CHARSET=utf8mb4;
 /*!40101 SET character_set_client = @saved_cs_client */;
 /*!40103 SET TIME_ZONE=@OLD_TIME_ZONE */;
@@ -138,9 +138,56 @@ function load_pocfile_list(xray = true, nuclei = true, xray_type = "default") {
                 }
             }
         });
+        load_nuclei_template_option();
     }
 }
 
+/**
+ * 加载nuclei模板的标签及workflow列表
+ */
+function load_nuclei_template_option() {
+    $.post("/vulnerability-load-nuclei-tags", {}, function (data, e) {
+        if (e === "success") {
+            $("#datalist_nuclei_tags").empty();
+            for (let i = 0; i < data.length; i++) {
+                $("#datalist_nuclei_tags").append("<option value='" + data[i] + "'>" + data[i] + "</option>")
+            }
+        }
+    });
+    $.post("/vulnerability-load-nuclei-template", {"protocol": "workflow"}, function (data, e) {
+        if (e === "success") {
+            $("#datalist_nuclei_workflow").empty();
+            for (let i = 0; i < data.length; i++) {
+                $("#datalist_nuclei_workflow").append("<option value='" + data[i]['path'] + "'>" + data[i]['name'] + "</option>")
+            }
+        }
+    });
+}
+
+/**
+ * nuclei是否选择了模板（poc文件、标签、严重程度、workflow或根据指纹自动选择）
+ */
+function check_nuclei_template_selected() {
+    return $('#input_nuclei_poc_file').val() != '' || $('#input_nuclei_tags').val() != '' || $('#select_nuclei_severity').val() != ''
+        || $('#input_nuclei_workflow').val() != '' || $('#checkbox_nuclei_auto_tags').is(":checked");
+}
+
+/**
+ * 加载内置xraypocv1引擎的poc文件列表
+ */
+function load_xraypocv1_pocfile_list() {
+    $.post("/vulnerability-load-xraypocv1-pocfile", {}, function (data, e) {
+        if (e === "success") {
+            $("#datalist_xraypocv1_poc_file").empty();
+            $("#datalist_xraypocv1_poc_file_xscan").empty();
+            for (let i = 0; i < data.length; i++) {
+                $("#datalist_xraypocv1_poc_file").append("<option value='" + data[i] + "'>" + data[i] + "</option>")
+                $("#datalist_xraypocv1_poc_file_xscan").append("<option value='" + data[i] + "'>" + data[i] + "</option>")
+            }
+        }
+    });
+}
+
 /**
  * 获取任务状态
  */
@@ -164,6 +211,41 @@ function html2Escape(sHtml) {
     return output;
 }
 
+/**
+ * 显示合并后的title或banner，鼠标悬停时显示该值的各个来源及置信度
+ * @param values 值及出现的次数
+ * @param value_source 值的来源
+ * @returns {string}
+ */
+function format_reconciled_values(values, value_source) {
+    let str = '';
+    let length = 0;
+    for (let key of Object.keys(values)) {
+        if (length >= 200) {
+            str += '......';
+            break;
+        }
+        if (str !== '') str += ',';
+        let source = (value_source && value_source[key]) ? value_source[key] : '';
+        str += '<span title="' + html2Escape(source) + '">' + encodeHtml(key.substr(0, 200 - length)) + '</span>';
+        length += key.length;
+    }
+    return str;
+}
+
+/**
+ * 只由在线资产平台获取、未经主动探测的端口的标记
+ * @param row
+ * @param port
+ * @returns {string}
+ */
+function engine_only_port_mark(row, port) {
+    if (row['engine_only_port'] && row['engine_only_port'].indexOf(Number(port)) >= 0) {
+        return '<sup class="text-muted" title="仅由在线资产平台获取，未经主动探测">?</sup>';
+    }
+    return '';
+}
+
 /**
  * 获取选中的Tab索引号
  * 0: portscan
@@ -1,6 +1,7 @@
 $(function () {
     //$('#btnsiderbar').click();
     load_config();
+    load_apikey();
     load_custom('task_workspace', $('#text_task_workspace'));
     $("#buttonSaveNmap").click(function () {
         $.post("/config-save-portscan",
@@ -50,6 +51,33 @@ $(function () {
                 }
             });
     });
+    $("#buttonReFingerprint").click(function () {
+        swal({
+                title: "确定要重新匹配指纹吗?",
+                text: "将使用当前的指纹规则对工作空间中已保存的HTTP响应重新匹配，不会发送任何扫描请求",
+                type: "warning",
+                showCancelButton: true,
+                confirmButtonColor: "#DD6B55",
+                confirmButtonText: "确认",
+                cancelButtonText: "取消",
+                closeOnConfirm: false
+            },
+            function () {
+                $.post("/config-refingerprint", {}, function (data, e) {
+                    if (e === "success" && data['status'] == 'success') {
+                        swal({
+                            title: "已开始匹配！",
+                            text: data['msg'],
+                            type: "info",
+                            showConfirmButton: false,
+                        });
+                        setTimeout(pollReFingerprintStatus, 3000);
+                    } else {
+                        swal('Warning', data['msg'], 'error');
+                    }
+                });
+            });
+    });
     $("#buttonSaveTaskSlice").click(function () {
         if ($('#input_ipslicenumber').val() === '' || $('#input_portslicenumber').val() === '') {
             swal('Warning', "请输入数量", 'error');
@@ -119,9 +147,19 @@ $(function () {
                 "fofa": $('#checkbox_fofa').is(":checked"),
                 "hunter": $('#checkbox_hunter').is(":checked"),
                 "quake": $('#checkbox_quake').is(":checked"),
+                "shodan": $('#checkbox_shodan').is(":checked"),
+                "censys": $('#checkbox_censys').is(":checked"),
+                "zoomeye": $('#checkbox_zoomeye').is(":checked"),
+                "netlas": $('#checkbox_netlas').is(":checked"),
+                "binaryedge": $('#checkbox_binaryedge').is(":checked"),
                 "fofatoken": $('#input_fofa_token').val(),
                 "huntertoken": $('#input_hunter_token').val(),
                 "quaketoken": $('#input_quake_token').val(),
+                "shodantoken": $('#input_shodan_token').val(),
+                "censystoken": $('#input_censys_token').val(),
+                "zoomeyetoken": $('#input_zoomeye_token').val(),
+                "netlastoken": $('#input_netlas_token').val(),
+                "binaryedgetoken": $('#input_binaryedge_token').val(),
                 "chinaztoken": $('#input_chinaz_token').val(),
                 "pagesize": $('#input_pagesize').val(),
                 "limitcount": $('#input_limitcount').val(),
@@ -157,14 +195,43 @@ $(function () {
             }
         });
     });
+    $("#buttonAddAPIKey").click(function () {
+        $.post("/config-apikey-add",
+            {
+                "engine": $('#select_apikey_engine').val(),
+                "keys": $('#input_apikey_keys').val(),
+            }, function (data, e) {
+                if (e === "success" && data['status'] == 'success') {
+                    $('#input_apikey_keys').val('');
+                    load_apikey();
+                    swal({
+                        title: "添加成功！",
+                        text: data['msg'],
+                        type: "success",
+                        confirmButtonText: "确定",
+                        confirmButtonColor: "#41b883",
+                        closeOnConfirm: true,
+                        timer: 3000
+                    });
+                } else {
+                    swal('Warning', data['msg'], 'error');
+                }
+            });
+    });
+    $("#buttonCheckAPIKey").click(function () {
+        check_apikey(0);
+    });
     $("#buttonSaveDomainscan").click(function () {
         $.post("/config-save-domainscan",
             {
                 "subfinder": $('#checkbox_subfinder').is(":checked"),
                 "subdomainbrute": $('#checkbox_subdomainbrute').is(":checked"),
                 "subdomaincrawler": $('#checkbox_subdomaincrawler').is(":checked"),
+                "subdomainpassive": $('#checkbox_subdomainpassive').is(":checked"),
+                "subdomainalteration": $('#checkbox_subdomainalteration').is(":checked"),
                 "icp": $('#checkbox_icp').is(":checked"),
                 "whois": $('#checkbox_whois').is(":checked"),
+                "dnsrecord": $('#checkbox_dnsrecord').is(":checked"),
                 "portscan": $('#checkbox_portscan').is(":checked"),
                 "ignorecdn": $('#checkbox_ignorecdn').is(":checked"),
                 "ignoreoutofchina": $('#checkbox_ignoreoutofchina').is(":checked"),
@@ -249,13 +316,21 @@ function load_config() {
         $('#input_fofa_token').val(data['fofatoken']);
         $('#input_hunter_token').val(data['huntertoken']);
         $('#input_quake_token').val(data['quaketoken']);
+        $('#input_shodan_token').val(data['shodantoken']);
+        $('#input_censys_token').val(data['censystoken']);
+        $('#input_zoomeye_token').val(data['zoomeyetoken']);
+        $('#input_netlas_token').val(data['netlastoken']);
+        $('#input_binaryedge_token').val(data['binaryedgetoken']);
         $('#input_chinaz_token').val(data['chinaztoken']);
 
         $('#checkbox_subfinder').prop("checked", data['subfinder']);
         $('#checkbox_subdomainbrute').prop("checked", data['subdomainbrute']);
         $('#checkbox_subdomaincrawler').prop("checked", data['subdomaincrawler']);
+        $('#checkbox_subdomainpassive').prop("checked", data['subdomainpassive']);
+        $('#checkbox_subdomainalteration').prop("checked", data['subdomainalteration']);
         $('#checkbox_icp').prop("checked", data['icp']);
         $('#checkbox_whois').prop("checked", data['whois']);
+        $('#checkbox_dnsrecord').prop("checked", data['dnsrecord']);
         $('#checkbox_ignorecdn').prop("checked", data['ignorecdn']);
         $('#checkbox_ignoreoutofchina').prop("checked", data['ignoreoutofchina']);
         $('#checkbox_portscan').prop("checked", data['portscan']);
@@ -264,6 +339,11 @@ function load_config() {
         $('#checkbox_fofa').prop("checked", data['fofa']);
         $('#checkbox_hunter').prop("checked", data['hunter']);
         $('#checkbox_quake').prop("checked", data['quake']);
+        $('#checkbox_shodan').prop("checked", data['shodan']);
+        $('#checkbox_censys').prop("checked", data['censys']);
+        $('#checkbox_zoomeye').prop("checked", data['zoomeye']);
+        $('#checkbox_netlas').prop("checked", data['netlas']);
+        $('#checkbox_binaryedge').prop("checked", data['binaryedge']);
         $('#input_pagesize').val(data['pagesize']);
         $('#input_limitcount').val(data['limitcount']);
     });
@@ -307,3 +387,132 @@ function save_custom(type, content, url = "/custom-save") {
             }
         });
 }
+
+/**
+ * 加载Key池中的key及使用量
+ */
+function load_apikey() {
+    if ($('#apikey_table').length === 0) {
+        return;
+    }
+    $.post("/config-apikey-list", {}, function (data) {
+        let tbody = $('#apikey_table tbody');
+        tbody.empty();
+        if (!Array.isArray(data)) {
+            return;
+        }
+        const statusColor = {
+            "ok": "green",
+            "unknown": "gray",
+            "ratelimited": "orange",
+            "exhausted": "red",
+            "invalid": "red"
+        };
+        for (const row of data) {
+            let status = '<span style="color:' + (statusColor[row['status']] || "gray") + '">' + html2Escape(row['status']) + '</span>';
+            if (!row['enabled']) {
+                status = '<span style="color:gray">disabled</span>';
+            } else if (row['cooling_down']) {
+                status += '<br>' + html2Escape(row['cooldown_datetime']);
+            }
+            let tr = $('<tr></tr>');
+            tr.attr('title', row['last_error'] ? row['last_error'] : '检查时间：' + row['check_datetime'] + '，租用时间：' + row['lease_datetime']);
+            tr.append('<td>' + html2Escape(row['engine']) + '</td>');
+            tr.append('<td>' + html2Escape(row['key']) + '</td>');
+            tr.append('<td>' + status + '</td>');
+            tr.append('<td>' + (row['quota'] < 0 ? '-' : row['quota']) + '</td>');
+            tr.append('<td>' + row['workspace_query'] + '/' + row['workspace_result'] + '</td>');
+            tr.append('<td>' + row['total_query'] + '/' + row['total_error'] + '</td>');
+            let op = '<a href="javascript:check_apikey(' + row['id'] + ')" title="查询额度"><i class="fa fa-refresh"></i></a>&nbsp;';
+            if (row['enabled']) {
+                op += '<a href="javascript:enable_apikey(' + row['id'] + ',false)" title="禁用"><i class="fa fa-pause"></i></a>&nbsp;';
+            } else {
+                op += '<a href="javascript:enable_apikey(' + row['id'] + ',true)" title="启用"><i class="fa fa-play"></i></a>&nbsp;';
+            }
+            op += '<a href="javascript:delete_apikey(' + row['id'] + ')" title="删除"><i class="fa fa-trash"></i></a>';
+            tr.append('<td>' + op + '</td>');
+            tbody.append(tr);
+        }
+    });
+}
+
+/**
+ * 查询key的剩余额度，id为0时查询全部key
+ * @param id
+ */
+function check_apikey(id) {
+    $.post("/config-apikey-check", {"id": id}, function (data, e) {
+        if (e === "success" && data['status'] == 'success') {
+            load_apikey();
+        } else {
+            swal('Warning', data['msg'], 'error');
+        }
+    });
+}
+
+/**
+ * 启用或禁用key
+ * @param id
+ * @param enabled
+ */
+function enable_apikey(id, enabled) {
+    $.post("/config-apikey-enable", {"id": id, "enabled": enabled}, function (data, e) {
+        if (e === "success" && data['status'] == 'success') {
+            load_apikey();
+        } else {
+            swal('Warning', data['msg'], 'error');
+        }
+    });
+}
+
+/**
+ * 删除key
+ * @param id
+ */
+function delete_apikey(id) {
+    swal({
+            title: "确定要删除key吗?",
+            text: "key的使用记录将同时删除",
+            type: "warning",
+            showCancelButton: true,
+            confirmButtonColor: "#DD6B55",
+            confirmButtonText: "确认",
+            cancelButtonText: "取消",
+            closeOnConfirm: true
+        },
+        function () {
+            $.post("/config-apikey-delete", {"id": id}, function (data, e) {
+                if (e === "success" && data['status'] == 'success') {
+                    load_apikey();
+                } else {
+                    swal('Warning', data['msg'], 'error');
+                }
+            });
+        });
+}
+
+function html2Escape(sHtml) {
+    return sHtml.replace(/[<>&"]/g, function (c) {
+        return {'<': '&lt;', '>': '&gt;', '&': '&amp;', '"': '&quot;'}[c];
+    });
+}
+
+//轮询重新匹配指纹的状态，直到后台任务结束
+function pollReFingerprintStatus() {
+    $.post("/config-refingerprint-status", {}, function (data, e) {
+        if (e === "success" && data['status'] == 'running') {
+            setTimeout(pollReFingerprintStatus, 3000);
+        } else if (e === "success" && data['status'] == 'success') {
+            swal({
+                title: "匹配完成！",
+                text: data['msg'],
+                type: "success",
+                confirmButtonText: "确定",
+                confirmButtonColor: "#41b883",
+                closeOnConfirm: true,
+            });
+        } else {
+            swal('Warning', data['msg'], 'error');
+        }
+    });
+}
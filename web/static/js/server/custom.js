@@ -15,6 +15,7 @@ $(function () {
         load_custom($('#select_black_filename').val(), $('#text_black_domain_ip'));
     });
     load_custom('task_workspace', $('#text_task_workspace'));
+    load_custom('fingerprint_poc', $('#text_fingerprint_poc'));
     load_custom('fofa_filter_keyword', $('#text_fofa_filter_keyword'));
     $('#select_fofa_filter_keyword_type').change(function () {
         load_custom($('#select_fofa_filter_keyword_type').val(), $('#text_fofa_filter_keyword'));
@@ -37,6 +38,9 @@ $(function () {
     $("#buttonSaveFOFAFilterKeyword").click(function () {
         save_custom($("#select_fofa_filter_keyword_type").val(), $('#text_fofa_filter_keyword').val())
     });
+    $("#buttonSaveFingerprintPoc").click(function () {
+        save_custom("fingerprint_poc", $('#text_fingerprint_poc').val())
+    });
     $("#buttonUploadPoc").click(function () {
         let formData = new FormData();
         formData.append('type', $('#select_poc_type').val());
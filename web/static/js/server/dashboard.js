@@ -72,9 +72,9 @@ $(function () {
             },
             columns: [
                 {data: "index", title: "序号", width: "5%"},
-                {data: "worker_name", title: "Worker", width: "25%"},
-                {data: "worker_topic", title: "任务模式", width: "20%"},
-                {data: 'create_time', title: '启动时间', width: '15%',},
+                {data: "worker_name", title: "Worker", width: "15%"},
+                {data: "worker_topic", title: "任务模式", width: "15%"},
+                {data: 'create_time', title: '启动时间', width: '10%',},
                 {
                     data: 'update_time', title: '心跳时间', width: '10%',
                     render: function (data, type, row, meta) {
@@ -91,6 +91,40 @@ $(function () {
                         return row["started_number"] + "/" + row["task_number"];
                     }
                 },
+                {
+                    data: "goby_pool", title: "<span title='正在执行/并发上限'>Goby</span>", width: '10%',
+                    render: function (data, type, row, meta) {
+                        let str = "";
+                        if (data == null) return str;
+                        for (let i = 0; i < data.length; i++) {
+                            let title = data[i]["api"];
+                            if (data[i]["last_error"]) title += " " + data[i]["last_error"];
+                            str += '<span class="badge ' + (data[i]["healthy"] ? 'badge-success' : 'badge-danger') + '" title="' + $('<div>').text(title).html().replace(/"/g, '&quot;') + '">' + data[i]["running"] + "/" + data[i]["max_concurrency"] + '</span>&nbsp;';
+                        }
+                        return str;
+                    }
+                },
+                {
+                    data: "capability", title: "<span title='已安装的工具、运行环境及标签'>能力</span>", width: '15%',
+                    render: function (data, type, row, meta) {
+                        let str = "";
+                        if (data == null) return str;
+                        let title = "CPU:" + data["cpu"] + " 内存:" + data["memory"] + "MB";
+                        if (data["labels"]) {
+                            for (let k in data["labels"]) title += "\n" + k + "=" + data["labels"][k];
+                        }
+                        str += '<span title="' + $('<div>').text(title).html().replace(/"/g, '&quot;') + '">';
+                        if (data["tools"]) {
+                            for (let i = 0; i < data["tools"].length; i++) {
+                                str += '<span class="badge badge-info">' + $('<div>').text(data["tools"][i]).html() + '</span>&nbsp;';
+                            }
+                        }
+                        if (data["chrome"]) str += '<span class="badge badge-success">chrome</span>&nbsp;';
+                        if (data["raw_socket"]) str += '<span class="badge badge-warning">raw</span>&nbsp;';
+                        str += '</span>';
+                        return str;
+                    }
+                },
                 {
                     title: "操作", width: '10%',
                     render: function (data, type, row, meta) {
@@ -129,6 +163,7 @@ $(function () {
         }
     );//end datatable
     get_count_data();
+    get_metrics_data();
     get_user_workspace_list();
     $('#select_workspace').change(function () {
         change_user_workspace();
@@ -136,6 +171,7 @@ $(function () {
     //定时刷新页面
     setInterval(function () {
         get_count_data();
+        get_metrics_data();
         onlineuser_table.ajax.reload();
         worker_table.ajax.reload();
         vulnerability_table.ajax.reload();
@@ -154,6 +190,24 @@ function get_count_data() {
     });
 }
 
+// 获取任务队列及任务状态的指标
+function get_metrics_data() {
+    $.post("/dashboard-metrics", function (data) {
+        let queue = "";
+        for (let topic in data['queue_depth']) {
+            queue += '<span class="badge badge-info">' + $('<div>').text(topic).html() + ": " + data['queue_depth'][topic] + '</span>&nbsp;';
+        }
+        $("#queue_depth").html(queue);
+        let tbody = $("#task-metrics-table tbody");
+        tbody.empty();
+        if (data['task_state'] == null) return;
+        for (let i = 0; i < data['task_state'].length; i++) {
+            let ts = data['task_state'][i];
+            tbody.append("<tr><td>" + $('<div>').text(ts['task_name']).html() + "</td><td>" + ts['started'] + "</td><td>" + ts['success'] + "</td><td>" + ts['failure'] + "</td><td>" + ts['revoked'] + "</td></tr>");
+        }
+    });
+}
+
 //  获取用户的工作空间
 function get_user_workspace_list() {
     document.getElementById('li_workspace').style.visibility = 'visible';
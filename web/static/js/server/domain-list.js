@@ -1,5 +1,6 @@
 $(function () {
     load_domainscan_config();
+    init_saved_query("domain", "#domain_table");
     //搜索任务
     $("#search").click(function () {
         $("#hidden_org_id").val($("#select_org_id_search").val())
@@ -13,6 +14,7 @@ $(function () {
         });
         $('#text_target').val(checkIP.join("\n"));
         $('#newTask').modal('toggle');
+        load_xraypocv1_pocfile_list();
     });
     //XSCAN窗口
     $("#create_xscan_task").click(function () {
@@ -23,6 +25,7 @@ $(function () {
         $('#text_target_xscan').val(checkIP.join("\n"));
         $('#newXScan').modal('toggle');
         load_pocfile_list();
+        load_xraypocv1_pocfile_list();
     });
     $("#block_domain").click(function () {
         swal({
@@ -81,20 +84,29 @@ $(function () {
                     'fofasearch': $('#checkbox_fofasearch').is(":checked"),
                     'quakesearch': $('#checkbox_quakesearch').is(":checked"),
                     'huntersearch': $('#checkbox_huntersearch').is(":checked"),
+                    'shodansearch': $('#checkbox_shodansearch').is(":checked"),
+                    'censyssearch': $('#checkbox_censyssearch').is(":checked"),
+                    'zoomeyesearch': $('#checkbox_zoomeyesearch').is(":checked"),
+                    'netlassearch': $('#checkbox_netlassearch').is(":checked"),
+                    'binaryedgesearch': $('#checkbox_binaryedgesearch').is(":checked"),
                     'networkscan': $('#checkbox_networkscan').is(":checked"),
                     'taskmode': $('#select_taskmode').val(),
                     'porttaskmode': $('#select_porttaskmode').val(),
                     'subfinder': $('#checkbox_subfinder').is(":checked"),
                     'crawler': $('#checkbox_crawler').is(":checked"),
+                    'subdomainpassive': $('#checkbox_subdomainpassive').is(":checked"),
+                    'subdomainalteration': $('#checkbox_subdomainalteration').is(":checked"),
                     'httpx': $('#checkbox_httpx').is(":checked"),
                     'screenshot': $('#checkbox_screenshot').is(":checked"),
                     'icpquery': $('#checkbox_icpquery').is(":checked"),
                     'whoisquery': $('#checkbox_whoisquery').is(":checked"),
+                    'dnsrecord': $('#checkbox_dnsrecord').is(":checked"),
                     'fingerprinthub': $('#checkbox_fingerprinthub').is(":checked"),
                     'iconhash': $('#checkbox_iconhash').is(":checked"),
                     'taskcron': $('#checkbox_cron_task').is(":checked"),
                     'cronrule': cron_rule,
                     'croncomment': $('#input_cron_comment').val(),
+                    'worker_labels': $('#input_worker_labels').val(),
                     'ignoreoutofchina': $('#checkbox_ignorecdn_outofchina').is(":checked"),
                     'ignorecdn': $('#checkbox_ignorecdn_outofchina').is(":checked"),
                 }, function (data, e) {
@@ -115,13 +127,13 @@ $(function () {
                     }
                 });
         } else {
-            if ($('#checkbox_xray').is(":checked") == false && $('#checkbox_dirsearch').is(":checked") == false && $('#checkbox_nuclei').is(":checked") == false && $('#checkbox_goby').is(":checked") == false) {
+            if ($('#checkbox_xray').is(":checked") == false && $('#checkbox_dirsearch').is(":checked") == false && $('#checkbox_nuclei').is(":checked") == false && $('#checkbox_goby').is(":checked") == false && $('#checkbox_xraypocv1').is(":checked") == false) {
                 swal('Warning', '请选择要使用的验证工具！', 'error');
                 return;
             }
             if ($('#checkbox_nuclei').is(":checked")) {
-                if ($('#input_nuclei_poc_file').val() == '') {
-                    swal('Warning', '请选择poc file', 'error');
+                if (!check_nuclei_template_selected()) {
+                    swal('Warning', '请选择poc file、标签、严重程度或workflow', 'error');
                     return;
                 }
             }
@@ -137,13 +149,21 @@ $(function () {
                 'xray_poc_file': $('#select_poc_type').val() + '|' + $('#input_xray_poc_file').val(),
                 'nucleiverify': $('#checkbox_nuclei').is(":checked"),
                 'nuclei_poc_file': $('#input_nuclei_poc_file').val(),
+                'nuclei_tags': $('#input_nuclei_tags').val(),
+                'nuclei_severity': $('#select_nuclei_severity').val(),
+                'nuclei_workflow': $('#input_nuclei_workflow').val(),
+                'nuclei_auto_tags': $('#checkbox_nuclei_auto_tags').is(":checked"),
                 'gobyverify': $('#checkbox_goby').is(":checked"),
+                'xraypocv1verify': $('#checkbox_xraypocv1').is(":checked"),
+                'xraypocv1_poc_file': $('#input_xraypocv1_poc_file').val(),
                 'dirsearch': $('#checkbox_dirsearch').is(":checked"),
                 'ext': $('#input_dirsearch_ext').val(),
+                'dirsearch_header': $('#input_dirsearch_header').val(),
                 'load_opened_port': false,
                 'taskcron': $('#checkbox_cron_task').is(":checked"),
                 'cronrule': cron_rule,
                 'croncomment': $('#input_cron_comment').val(),
+                'worker_labels': $('#input_worker_labels').val(),
             }, function (data, e) {
                 if (e === "success" && data['status'] == 'success') {
                     swal({
@@ -214,12 +234,16 @@ $(function () {
         formData.append("nucleipoc", $('#checkbox_nucleipoc_xscan').is(":checked"));
         formData.append("nucleipocfile", $('#input_nuclei_poc_file_xscan').val());
         formData.append("gobypoc", $('#checkbox_gobypoc_xscan').is(":checked"));
+        formData.append("xraypocv1", $('#checkbox_xraypocv1_xscan').is(":checked"));
+        formData.append("xraypocv1file", $('#input_xraypocv1_poc_file_xscan').val());
+        formData.append("pocmap", $('#checkbox_pocmap_xscan').is(":checked"));
 
         formData.append("taskcron", $('#checkbox_cron_task_xscan').is(":checked"));
         formData.append("cronrule", cron_rule);
         formData.append("croncomment", $('#input_cron_comment_xscan').val());
+        formData.append("worker_labels", $('#input_worker_labels_xscan').val());
 
-        if ((formData.get("xraypoc") === "true" || formData.get("nucleipoc") === "true" || formData.get("gobypoc") === "true") && formData.get("fingerprint") === "false") {
+        if ((formData.get("xraypoc") === "true" || formData.get("nucleipoc") === "true" || formData.get("gobypoc") === "true" || formData.get("xraypocv1") === "true") && formData.get("fingerprint") === "false") {
             swal('Warning', '漏洞扫描需要开启指纹扫描步骤选项', 'error');
             return;
         }
@@ -335,6 +359,7 @@ $(function () {
                         'content': $('#content').val(),
                         'select_order_by_date': $('#checkbox_select_order_by_date').is(":checked"),
                         "domain_http": $('#http_content').val(),
+                        "query": $('#query').val(),
                     });
                 }
             },
@@ -417,6 +442,7 @@ $(function () {
                                 } else {
                                     strData += '<a href="http://' + row["domain"] + ":" + port + '"  target="_blank">' + port + '</a>';
                                 }
+                                strData += engine_only_port_mark(row, port);
                                 pre_link = "&nbsp;"
                             }
                             strData += "]";
@@ -435,22 +461,9 @@ $(function () {
                         }
                         if (icons !== "") icons += "<br>";
 
-                        let title_array = [];
-                        for (let key of Object.keys(row['title'])) {
-                            title_array.push(key)
-                        }
-                        let titles_all = title_array.toString();
-                        let title = encodeHtml(titles_all.substr(0, 200));
-                        if (titles_all.length > 200) title += '......';
+                        let title = format_reconciled_values(row['title'], row['value_source']);
                         if (title !== "") title += "<br>";
-
-                        let banner_array = [];
-                        for (let key of Object.keys(row['banner'])) {
-                            banner_array.push(key)
-                        }
-                        let banner_all = banner_array.toString();
-                        let banner = encodeHtml(banner_all.substr(0, 200));
-                        if (banner_all.length > 200) banner += '......';
+                        let banner = format_reconciled_values(row['banner'], row['value_source']);
                         return '<div style="width:100%;white-space:normal;word-wrap:break-word;word-break:break-all;">' + icons + title + banner + '</div>';
                     }
                 },
@@ -532,6 +545,7 @@ function get_export_options() {
     url += "&content=" + encodeURI($('#content').val());
     url += "&create_date_delta=" + encodeURI($('#create_date_delta').val());
     url += "&domain_http=" + encodeURI($('#http_content').val());
+    url += "&query=" + encodeURIComponent($('#query').val());
 
     return url;
 }
@@ -541,12 +555,20 @@ function load_domainscan_config() {
         $('#checkbox_subfinder').prop("checked", data['subfinder']);
         $('#checkbox_subdomainbrute').prop("checked", data['subdomainbrute']);
         $('#checkbox_crawler').prop("checked", data['subdomaincrawler']);
+        $('#checkbox_subdomainpassive').prop("checked", data['subdomainpassive']);
+        $('#checkbox_subdomainalteration').prop("checked", data['subdomainalteration']);
         //onlineapi
         $('#checkbox_fofasearch').prop("checked", data['fofa']);
         $('#checkbox_huntersearch').prop("checked", data['hunter']);
+        $('#checkbox_shodansearch').prop("checked", data['shodan']);
+        $('#checkbox_censyssearch').prop("checked", data['censys']);
+        $('#checkbox_zoomeyesearch').prop("checked", data['zoomeye']);
+        $('#checkbox_netlassearch').prop("checked", data['netlas']);
+        $('#checkbox_binaryedgesearch').prop("checked", data['binaryedge']);
         $('#checkbox_quakesearch').prop("checked", data['quake']);
         $('#checkbox_icpquery').prop("checked", data['icp']);
         $('#checkbox_whoisquery').prop("checked", data['whois']);
+        $('#checkbox_dnsrecord').prop("checked", data['dnsrecord']);
         $('#checkbox_ignorecdn_outofchina').prop("checked", data['ignorecdn']);
 
         $('#checkbox_portscan').prop("checked", data['portscan']);
@@ -0,0 +1,99 @@
+const searchPageSize = 20;
+let searchStart = 0;
+let searchTotal = 0;
+
+$(function () {
+    //搜索
+    $("#search").click(function () {
+        searchStart = 0;
+        load_search_result();
+    });
+    $("#query").keydown(function (e) {
+        if (e.keyCode === 13) {
+            searchStart = 0;
+            load_search_result();
+        }
+    });
+    $("#prev_page").click(function () {
+        if (searchStart >= searchPageSize) {
+            searchStart -= searchPageSize;
+            load_search_result();
+        }
+    });
+    $("#next_page").click(function () {
+        if (searchStart + searchPageSize < searchTotal) {
+            searchStart += searchPageSize;
+            load_search_result();
+        }
+    });
+    update_page_info();
+});
+
+/**
+ * 加载全文检索的结果
+ */
+function load_search_result() {
+    if ($('#query').val().trim() === "") {
+        swal('Warning', "请输入检索的内容！", 'error');
+        return;
+    }
+    $.post("/fulltext-search", {
+        "start": searchStart,
+        "length": searchPageSize,
+        "query": $('#query').val(),
+        "category": $('#category').val()
+    }, function (data, e) {
+        if (e !== "success") {
+            swal('Warning', "检索失败！", 'error');
+            return;
+        }
+        $("#search_result").empty();
+        if (data['error']) {
+            searchTotal = 0;
+            update_page_info();
+            swal('Warning', data['error'], 'error');
+            return;
+        }
+        searchTotal = data['recordsTotal'];
+        for (let i = 0; i < data['data'].length; i++) {
+            $("#search_result").append(render_hit(data['data'][i]));
+        }
+        update_page_info();
+    });
+}
+
+/**
+ * 更新分页信息
+ */
+function update_page_info() {
+    let end = Math.min(searchStart + searchPageSize, searchTotal);
+    $("#search_info").html("共<b>" + searchTotal + "</b>条结果，当前显示" + (searchTotal > 0 ? searchStart + 1 : 0) + "到" + end + "条");
+    $("#prev_page").prop("disabled", searchStart === 0);
+    $("#next_page").prop("disabled", end >= searchTotal);
+}
+
+/**
+ * 生成一条检索结果的显示内容，highlights已由服务端进行html转义
+ */
+function render_hit(hit) {
+    let info = hit['category'] === 'ip' ? 'ip-info?workspace=' + hit['workspace'] + '&&ip=' + encodeURIComponent(hit['target']) : 'domain-info?workspace=' + hit['workspace'] + '&&domain=' + encodeURIComponent(hit['target']);
+    let target = html2Escape(hit['target']);
+    if (hit['port'] > 0) {
+        target += ':' + hit['port'];
+    }
+    let strData = '<div class="tile"><h5 class="tile-title"><a href="' + info + '" target="_blank">' + target + '</a>';
+    strData += ' <span class="badge badge-info">' + html2Escape(hit['type']) + '</span>';
+    strData += ' <span class="badge badge-secondary">' + html2Escape(hit['tag']) + '</span>';
+    strData += ' <small class="text-muted">' + html2Escape(hit['source']) + ' ' + hit['update_datetime'] + '</small></h5><div class="tile-body">';
+    for (let i = 0; i < hit['highlights'].length; i++) {
+        strData += '<pre style="white-space: pre-wrap;word-break: break-all;">' + hit['highlights'][i] + '</pre>';
+    }
+    strData += '</div></div>';
+    return strData;
+}
+
+function html2Escape(sHtml) {
+    return sHtml.replace(/[<>&"]/g, function (c) {
+        return {'<': '&lt;', '>': '&gt;', '&': '&amp;', '"': '&quot;'}[c];
+    });
+}
@@ -1,5 +1,6 @@
 $(function () {
     load_portscan_config();
+    init_saved_query("ip", "#ip_table");
     //搜索
     $("#search").click(function () {
         $("#hidden_org_id").val($("#select_org_id_search").val())
@@ -16,6 +17,7 @@ $(function () {
         });
         $('#text_target').val(checkIP.join("\n"));
         $('#newTask').modal('toggle');
+        load_xraypocv1_pocfile_list();
     });
     //XSCAN窗口
     $("#create_xscan_task").click(function () {
@@ -26,6 +28,7 @@ $(function () {
         $('#text_target_xscan').val(checkIP.join("\n"));
         $('#newXScan').modal('toggle');
         load_pocfile_list();
+        load_xraypocv1_pocfile_list();
     });
     //导入本地扫描结果窗口
     $("#import_portscan").click(function () {
@@ -129,6 +132,11 @@ $(function () {
                     'fofasearch': $('#checkbox_fofasearch').is(":checked"),
                     'quakesearch': $('#checkbox_quakesearch').is(":checked"),
                     'huntersearch': $('#checkbox_huntersearch').is(":checked"),
+                    'shodansearch': $('#checkbox_shodansearch').is(":checked"),
+                    'censyssearch': $('#checkbox_censyssearch').is(":checked"),
+                    'zoomeyesearch': $('#checkbox_zoomeyesearch').is(":checked"),
+                    'netlassearch': $('#checkbox_netlassearch').is(":checked"),
+                    'binaryedgesearch': $('#checkbox_binaryedgesearch').is(":checked"),
                     'taskmode': $('#select_taskmode').val(),
                     'httpx': $('#checkbox_httpx').is(":checked"),
                     'exclude': exclude_ip,
@@ -138,6 +146,7 @@ $(function () {
                     'taskcron': $('#checkbox_cron_task').is(":checked"),
                     'cronrule': cron_rule,
                     'croncomment': $('#input_cron_comment').val(),
+                    'worker_labels': $('#input_worker_labels').val(),
                     'load_opened_port': $('#checkbox_ip_load_opened_port').is(":checked"),
                     'ignoreoutofchina': $('#checkbox_ignorecdn_outofchina').is(":checked"),
                     'ignorecdn': $('#checkbox_ignorecdn_outofchina').is(":checked"),
@@ -160,13 +169,13 @@ $(function () {
                 });
         }
         if (getCurrentTabIndex('#nav_tabs') == 1) {
-            if ($('#checkbox_xray').is(":checked") == false && $('#checkbox_dirsearch').is(":checked") == false && $('#checkbox_nuclei').is(":checked") == false && $('#checkbox_goby').is(":checked") == false) {
+            if ($('#checkbox_xray').is(":checked") == false && $('#checkbox_dirsearch').is(":checked") == false && $('#checkbox_nuclei').is(":checked") == false && $('#checkbox_goby').is(":checked") == false && $('#checkbox_xraypocv1').is(":checked") == false && $('#checkbox_weakpass').is(":checked") == false) {
                 swal('Warning', '请选择要使用的验证工具！', 'error');
                 return;
             }
             if ($('#checkbox_nuclei').is(":checked")) {
-                if ($('#input_nuclei_poc_file').val() == '') {
-                    swal('Warning', '请选择poc file', 'error');
+                if (!check_nuclei_template_selected()) {
+                    swal('Warning', '请选择poc file、标签、严重程度或workflow', 'error');
                     return;
                 }
             }
@@ -183,13 +192,22 @@ $(function () {
                     'xray_poc_file': $('#select_poc_type') + "|" + $('#input_xray_poc_file').val(),
                     'nucleiverify': $('#checkbox_nuclei').is(":checked"),
                     'nuclei_poc_file': $('#input_nuclei_poc_file').val(),
+                    'nuclei_tags': $('#input_nuclei_tags').val(),
+                    'nuclei_severity': $('#select_nuclei_severity').val(),
+                    'nuclei_workflow': $('#input_nuclei_workflow').val(),
+                    'nuclei_auto_tags': $('#checkbox_nuclei_auto_tags').is(":checked"),
                     'gobyverify': $('#checkbox_goby').is(":checked"),
+                    'xraypocv1verify': $('#checkbox_xraypocv1').is(":checked"),
+                    'xraypocv1_poc_file': $('#input_xraypocv1_poc_file').val(),
+                    'weakpassverify': $('#checkbox_weakpass').is(":checked"),
                     'dirsearch': $('#checkbox_dirsearch').is(":checked"),
                     'ext': $('#input_dirsearch_ext').val(),
+                    'dirsearch_header': $('#input_dirsearch_header').val(),
                     'load_opened_port': $('#checkbox_load_opened_port').is(":checked"),
                     'taskcron': $('#checkbox_cron_task').is(":checked"),
                     'cronrule': cron_rule,
                     'croncomment': $('#input_cron_comment').val(),
+                    'worker_labels': $('#input_worker_labels').val(),
                 }, function (data, e) {
                     if (e === "success" && data['status'] == 'success') {
                         swal({
@@ -230,6 +248,11 @@ $(function () {
                     'fofasearch': false,
                     'quakesearch': false,
                     'huntersearch': false,
+                    'shodansearch': false,
+                    'censyssearch': false,
+                    'zoomeyesearch': false,
+                    'netlassearch': false,
+                    'binaryedgesearch': false,
                     'taskmode': $('#select_batchscan_taskmode').val(),
                     'httpx': $('#checkbox_batchscan_httpx').is(":checked"),
                     'exclude': exclude_ip,
@@ -239,6 +262,7 @@ $(function () {
                     'taskcron': $('#checkbox_cron_task').is(":checked"),
                     'cronrule': cron_rule,
                     'croncomment': $('#input_cron_comment').val(),
+                    'worker_labels': $('#input_worker_labels').val(),
                 }, function (data, e) {
                     if (e === "success" && data['status'] == 'success') {
                         swal({
@@ -314,12 +338,16 @@ $(function () {
         formData.append("nucleipoc", $('#checkbox_nucleipoc_xscan').is(":checked"));
         formData.append("nucleipocfile", $('#input_nuclei_poc_file_xscan').val());
         formData.append("gobypoc", $('#checkbox_gobypoc_xscan').is(":checked"));
+        formData.append("xraypocv1", $('#checkbox_xraypocv1_xscan').is(":checked"));
+        formData.append("xraypocv1file", $('#input_xraypocv1_poc_file_xscan').val());
+        formData.append("pocmap", $('#checkbox_pocmap_xscan').is(":checked"));
 
         formData.append("taskcron", $('#checkbox_cron_task_xscan').is(":checked"));
         formData.append("cronrule", cron_rule);
         formData.append("croncomment", $('#input_cron_comment_xscan').val());
+        formData.append("worker_labels", $('#input_worker_labels_xscan').val());
 
-        if ((formData.get("nucleipoc") === "true" || formData.get("gobypoc") === "true" || formData.get("xraypoc") === "true") && formData.get("fingerprint") === "false") {
+        if ((formData.get("nucleipoc") === "true" || formData.get("gobypoc") === "true" || formData.get("xraypoc") === "true" || formData.get("xraypocv1") === "true") && formData.get("fingerprint") === "false") {
             swal('Warning', '漏洞扫描需要开启指纹扫描步骤选项', 'error');
             return;
         }
@@ -461,6 +489,7 @@ $(function () {
                         'select_no_openedport': $('#checkbox_select_no_openedport').is(":checked"),
                         'select_order_by_date': $('#checkbox_select_order_by_date').is(":checked"),
                         "ip_http": $('#http_content').val(),
+                        "query": $('#query').val(),
                     });
                 }
             },
@@ -532,6 +561,7 @@ $(function () {
                             else strData += 'http';
                             // 快速链接地址
                             strData += '://' + row['ipf'] + ':' + port + '" target="_blank">' + port + '</a>';
+                            strData += engine_only_port_mark(row, port);
                             // 端口状态
                             if (status !== port) strData += "[" + status;
 
@@ -549,22 +579,9 @@ $(function () {
                             icons += '<img src=/webfiles/' + row['workspace_guid'] + '/iconimage/' + row['iconimage'][i] + ' width="24px" height="24px"/>&nbsp;';
                         }
                         if (icons !== "") icons += "<br>";
-                        let title_array = [];
-                        for (let key of Object.keys(row['title'])) {
-                            title_array.push(key)
-                        }
-                        let titles_all = title_array.toString();
-                        let title = encodeHtml(titles_all.substr(0, 200));
-                        if (titles_all.length > 200) title += '......';
+                        let title = format_reconciled_values(row['title'], row['value_source']);
                         if (title !== "") title += "<br>";
-
-                        let banner_array = [];
-                        for (let key of Object.keys(row['banner'])) {
-                            banner_array.push(key)
-                        }
-                        let banner_all = banner_array.toString();
-                        let banner = encodeHtml(banner_all.substr(0, 200));
-                        if (banner_all.length > 200) banner += '......';
+                        let banner = format_reconciled_values(row['banner'], row['value_source']);
                         return '<div style="width:100%;white-space:normal;word-wrap:break-word;word-break:break-all;">' + icons + title + banner + '</div>';
                     }
                 },
@@ -751,6 +768,7 @@ function get_export_options() {
     url += '&disable_fofa=' + encodeURI($('#checkbox_disable_fofa').is(":checked"));
     url += '&create_date_delta=' + encodeURI($('#create_date_delta').val());
     url += '&ip_http=' + encodeURI($('#http_content').val());
+    url += '&query=' + encodeURIComponent($('#query').val());
     url += '&select_order_by_date=' + encodeURI($('#checkbox_select_order_by_date').is(":checked"));
 
     return url;
@@ -772,6 +790,11 @@ function load_portscan_config() {
         // onlineapi
         $('#checkbox_fofasearch').prop("checked", data['fofa']);
         $('#checkbox_huntersearch').prop("checked", data['hunter']);
+        $('#checkbox_shodansearch').prop("checked", data['shodan']);
+        $('#checkbox_censyssearch').prop("checked", data['censys']);
+        $('#checkbox_zoomeyesearch').prop("checked", data['zoomeye']);
+        $('#checkbox_netlassearch').prop("checked", data['netlas']);
+        $('#checkbox_binaryedgesearch').prop("checked", data['binaryedge']);
         $('#checkbox_quakesearch').prop("checked", data['quake']);
         $('#checkbox_ignorecdn_outofchina').prop("checked", data['ignorecdn']);
         //fingerprint
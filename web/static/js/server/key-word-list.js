@@ -165,6 +165,7 @@ $("#create_key_word").click(function () {
 $("#create_key_word_task").click(function () {
     $('#new_key_word_task').modal('toggle');
     load_pocfile_list(true, false, "default")
+    load_xraypocv1_pocfile_list()
 });
 
 $('#select_poc_type_xscan').change(function () {
@@ -194,12 +195,16 @@ $("#start_xscan_task").click(function () {
     formData.append("nucleipoc", $('#checkbox_nucleipoc_xscan').is(":checked"));
     formData.append("nucleipocfile", $('#input_nuclei_poc_file_xscan').val());
     formData.append("gobypoc", $('#checkbox_gobypoc_xscan').is(":checked"));
+    formData.append("xraypocv1", $('#checkbox_xraypocv1_xscan').is(":checked"));
+    formData.append("xraypocv1file", $('#input_xraypocv1_poc_file_xscan').val());
+    formData.append("pocmap", $('#checkbox_pocmap_xscan').is(":checked"));
 
     formData.append("taskcron", $('#checkbox_cron_task_xscan').is(":checked"));
     formData.append("cronrule", cron_rule);
     formData.append("croncomment", $('#input_cron_comment_xscan').val());
+    formData.append("worker_labels", $('#input_worker_labels_xscan').val());
 
-    if ((formData.get("nucleipoc") === "true" || formData.get("gobypoc") === "true" || formData.get("xraypoc") === "true") && formData.get("fingerprint") === "false") {
+    if ((formData.get("nucleipoc") === "true" || formData.get("gobypoc") === "true" || formData.get("xraypoc") === "true" || formData.get("xraypocv1") === "true") && formData.get("fingerprint") === "false") {
         swal('Warning', '漏洞扫描需要开启指纹扫描步骤选项', 'error');
         return;
     }
@@ -253,6 +258,11 @@ $("#start_add_key_search_word").click(function () {
     formData.append("fofa", $('#checkbox_fofasearch').is(":checked"));
     formData.append("hunter", $('#checkbox_huntersearch').is(":checked"));
     formData.append("quake", $('#checkbox_quakesearch').is(":checked"));
+    formData.append("shodan", $('#checkbox_shodansearch').is(":checked"));
+    formData.append("censys", $('#checkbox_censyssearch').is(":checked"));
+    formData.append("zoomeye", $('#checkbox_zoomeyesearch').is(":checked"));
+    formData.append("netlas", $('#checkbox_netlassearch').is(":checked"));
+    formData.append("binaryedge", $('#checkbox_binaryedgesearch').is(":checked"));
 
     $.ajax({
         url: url,
@@ -302,6 +312,11 @@ function edit_key_word(id) {
                     $('#checkbox_fofasearch').prop("checked", data['fofa']);
                     $('#checkbox_huntersearch').prop("checked", data['hunter']);
                     $('#checkbox_quakesearch').prop("checked", data['quake']);
+                    $('#checkbox_shodansearch').prop("checked", data['shodan']);
+                    $('#checkbox_censyssearch').prop("checked", data['censys']);
+                    $('#checkbox_zoomeyesearch').prop("checked", data['zoomeye']);
+                    $('#checkbox_netlassearch').prop("checked", data['netlas']);
+                    $('#checkbox_binaryedgesearch').prop("checked", data['binaryedge']);
                 }
             }
         });
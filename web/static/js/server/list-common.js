@@ -138,4 +138,102 @@ let getIPv6CSubnet = function (ipv6) {
     let ipv6CSubnet = ipv6Arr[0] + ":" + ipv6Arr[1] + ":" + ipv6Arr[2] + ":" + ipv6Arr[3] + ":" + ipv6Arr[4] + ":" + ipv6Arr[5] + ":" + ipv6Arr[6] + ":" + "0000"
     let ipv6CSubnetComp = compressIPv6(ipv6CSubnet)
     return ipv6CSubnetComp + "/120"
-}
\ No newline at end of file
+}
+/**
+ * 查询语句及保存的查询
+ * @param category 查询的类别：ip、domain、vulnerability
+ * @param table DataTable的选择器
+ */
+function init_saved_query(category, table) {
+    load_saved_query(category);
+    $.post("/query-fields", {"category": category}, function (data) {
+        $('#query').attr("title", "可用字段：" + data["fields"].join(", "));
+    });
+    $('#query').keydown(function (e) {
+        if (e.keyCode === 13) {
+            e.preventDefault();
+            $(table).DataTable().draw(true);
+        }
+    });
+    $('#select_saved_query').change(function () {
+        let q = $(this).find("option:selected").data("query");
+        if (q === undefined) return;
+        $('#query').val(q);
+        $(table).DataTable().draw(true);
+    });
+    $('#save_query').click(function () {
+        if ($('#query').val() === "") {
+            swal('Warning', '请输入查询语句！', 'error');
+            return;
+        }
+        swal({
+                title: "保存查询",
+                text: "查询名称（同名的查询将被覆盖）：",
+                type: "input",
+                showCancelButton: true,
+                confirmButtonText: "确认",
+                cancelButtonText: "取消",
+                closeOnConfirm: false,
+                inputValue: $('#select_saved_query').find("option:selected").data("name") || ""
+            },
+            function (name) {
+                if (name === false) return;
+                if (name === "") {
+                    swal.showInputError("请输入查询名称！");
+                    return;
+                }
+                $.post("/query-save", {
+                    "category": category,
+                    "name": name,
+                    "query": $('#query').val()
+                }, function (data) {
+                    if (data['status'] === 'success') {
+                        swal({title: "保存成功！", type: "success", timer: 1000, showConfirmButton: false});
+                        load_saved_query(category);
+                    } else {
+                        swal('Warning', "保存失败！" + data['msg'], 'error');
+                    }
+                });
+            });
+    });
+    $('#delete_query').click(function () {
+        let id = $('#select_saved_query').val();
+        if (!id) {
+            swal('Warning', '请选择要删除的查询！', 'error');
+            return;
+        }
+        swal({
+                title: "确定要删除保存的查询吗?",
+                type: "warning",
+                showCancelButton: true,
+                confirmButtonColor: "#DD6B55",
+                confirmButtonText: "确认",
+                cancelButtonText: "取消",
+                closeOnConfirm: true
+            },
+            function () {
+                $.post("/query-delete", {"id": id}, function (data) {
+                    if (data['status'] === 'success') {
+                        load_saved_query(category);
+                    } else {
+                        swal('Warning', "删除失败！" + data['msg'], 'error');
+                    }
+                });
+            });
+    });
+}
+
+/**
+ * 加载保存的查询
+ * @param category 查询的类别
+ */
+function load_saved_query(category) {
+    $.post("/query-list", {"category": category}, function (data) {
+        let select = $('#select_saved_query');
+        select.empty();
+        select.append($('<option>').val("").text("--保存的查询--"));
+        for (let i = 0; i < data.length; i++) {
+            select.append($('<option>').val(data[i]['id']).text(data[i]['name']).attr("title", data[i]['query']).data("query", data[i]['query']).data("name", data[i]['name']));
+        }
+    });
+}
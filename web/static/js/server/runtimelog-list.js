@@ -19,6 +19,7 @@ $(function () {
                         "log_func": $('#log_func').val(),
                         "log_level": $('#log_level').val(),
                         "log_message": $('#log_message').val(),
+                        "log_task": $('#log_task').val(),
                         "date_delta": $('#date_delta').val()
                     });
                 }
@@ -62,6 +63,9 @@ $(function () {
                         let msgShow = data.substr(0, 200);
                         if (data.length > 200) msgShow += '......';
                         strData += msgShow;
+                        if (row['task_id'] !== "") {
+                            strData += '<br><a href="/task-info-run?task_id=' + row['task_id'] + '" target="_blank"><span class="badge badge-secondary">' + row['task_id'] + '</span></a>';
+                        }
                         strData += '</div>'
                         return strData;
                     }
@@ -199,6 +203,7 @@ function batch_delete_runtimelog() {
                     "log_func": $('#log_func').val(),
                     "log_level": $('#log_level').val(),
                     "log_message": $('#log_message').val(),
+                    "log_task": $('#log_task').val(),
                     "date_delta": $('#date_delta').val(),
                 }, function (data, e) {
                     if (e === "success") {
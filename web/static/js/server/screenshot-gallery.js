@@ -0,0 +1,96 @@
+const galleryPageSize = 20;
+let galleryStart = 0;
+let galleryTotal = 0;
+
+$(function () {
+    $('.imgPreview').click(function () {
+        $('.imgPreview').hide();
+    });
+    //搜索
+    $("#search").click(function () {
+        galleryStart = 0;
+        load_gallery();
+    });
+    $("#prev_page").click(function () {
+        if (galleryStart >= galleryPageSize) {
+            galleryStart -= galleryPageSize;
+            load_gallery();
+        }
+    });
+    $("#next_page").click(function () {
+        if (galleryStart + galleryPageSize < galleryTotal) {
+            galleryStart += galleryPageSize;
+            load_gallery();
+        }
+    });
+    load_gallery();
+});
+
+/**
+ * 查询条件
+ */
+function get_search_options() {
+    return {
+        "domain": $('#domain').val(),
+        "title": $('#title').val(),
+        "content": $('#content').val(),
+        "distance": $('#distance').val(),
+        "date_delta": $('#date_delta').val()
+    };
+}
+
+/**
+ * 加载按相似度分组的截图
+ */
+function load_gallery() {
+    $.post("/screenshot-gallery", $.extend({
+        "start": galleryStart,
+        "length": galleryPageSize
+    }, get_search_options()), function (data, e) {
+        if (e !== "success") {
+            swal('Warning', "加载截图失败！", 'error');
+            return;
+        }
+        galleryTotal = data['recordsTotal'];
+        $("#gallery").empty();
+        for (let i = 0; i < data['data'].length; i++) {
+            $("#gallery").append(render_group(data['data'][i]));
+        }
+        let end = Math.min(galleryStart + galleryPageSize, galleryTotal);
+        $("#gallery_info").html("共<b>" + galleryTotal + "</b>组相似页面，当前显示" + (galleryTotal > 0 ? galleryStart + 1 : 0) + "到" + end + "组");
+        $("#prev_page").prop("disabled", galleryStart === 0);
+        $("#next_page").prop("disabled", end >= galleryTotal);
+    });
+}
+
+/**
+ * 生成一组截图的显示内容
+ */
+function render_group(group) {
+    let title = group['title'] ? html2Escape(group['title']) : '<i>--无标题--</i>';
+    let strData = '<div class="tile"><h5 class="tile-title">#' + group['index'] + ' ' + title;
+    strData += ' <span class="badge badge-info">' + group['count'] + '</span>';
+    strData += ' <small class="text-muted">' + group['phash'] + '</small></h5><div class="tile-body row">';
+    for (let i = 0; i < group['items'].length; i++) {
+        let item = group['items'][i];
+        let info = item['is_ip'] ? 'ip-info?workspace=' + item['workspace'] + '&&ip=' + item['domain'] : 'domain-info?workspace=' + item['workspace'] + '&&domain=' + item['domain'];
+        strData += '<div class="col-md-2 text-center" style="margin-bottom: 10px;">';
+        strData += '<img src="' + item['thumbnail'] + '" class="img" width="120" loading="lazy" onclick=show_bigpic("' + item['file'] + '") title="' + html2Escape(item['title']) + '"/>';
+        strData += '<div style="word-break: break-all;"><a href="' + info + '" target="_blank">' + item['domain'] + ':' + item['port'] + '</a></div>';
+        strData += '<div style="word-break: break-all;"><small><a href="' + encodeURI(item['url']) + '" target="_blank">' + html2Escape(item['url']) + '</a></small></div>';
+        strData += '</div>';
+    }
+    strData += '</div></div>';
+    return strData;
+}
+
+function show_bigpic(src) {
+    $('.imgPreview img').attr('src', src);
+    $('.imgPreview').show();
+}
+
+function html2Escape(sHtml) {
+    return sHtml.replace(/[<>&"]/g, function (c) {
+        return {'<': '&lt;', '>': '&gt;', '&': '&amp;', '"': '&quot;'}[c];
+    });
+}
@@ -0,0 +1,72 @@
+$(function () {
+    $('#task_log_table').DataTable(
+        {
+            "paging": true,
+            "serverSide": true,
+            "autowidth": false,
+            "sort": false,
+            "pagingType": "full_numbers",//分页样式
+            'iDisplayLength': 20,
+            "dom": '<i><t><"bottom"lp>',
+            "ajax": {
+                "url": "/task-log",
+                "type": "post",
+                "data": function (d) {
+                    for (let key in d) {
+                        if (key.indexOf("columns") === 0 || key.indexOf("order") === 0 || key.indexOf("search") === 0) {
+                            delete d[key];
+                        }
+                    }
+                    return $.extend({}, d, {
+                        "task_id": $('#task_log_table').attr('data-task-id')
+                    });
+                }
+            },
+            columns: [
+                {
+                    data: "index", title: "序号", width: "5%"
+                },
+                {
+                    data: "source", title: "Source", width: "15%",
+                    render: function (data, type, row, meta) {
+                        return '<div style="width:100%;white-space:normal;word-wrap:break-word;word-break:break-all;">' + html2Escape(data) + '</div>';
+                    }
+                },
+                {
+                    data: "func", title: "Func", width: "15%",
+                    render: function (data, type, row, meta) {
+                        return '<div style="width:100%;white-space:normal;word-wrap:break-word;word-break:break-all;">' + html2Escape(data) + '</div>';
+                    }
+                },
+                {
+                    data: 'message', title: 'Message', width: '45%',
+                    render: function (data, type, row, meta) {
+                        return '<div style="width:100%;white-space:normal;word-wrap:break-word;word-break:break-all;">' + html2Escape(data) + '</div>';
+                    }
+                },
+                {
+                    data: 'level', title: 'Level', width: '5%',
+                    render: function (data, type, row, meta) {
+                        if (data === "warning") {
+                            return '<span class="text-warning">' + data + '</span>';
+                        } else if (data === "error" || data === "fatal") {
+                            return '<span class="text-danger">' + data + '</span>';
+                        } else return data;
+                    }
+                },
+                {
+                    data: 'update_datetime', title: '时间', width: '15%'
+                }
+            ],
+            infoCallback: function (settings, start, end, max, total, pre) {
+                return "共<b>" + total + "</b>条日志，当前显示" + start + "到" + end + "记录";
+            }
+        }
+    );//end datatable
+});
+
+function html2Escape(sHtml) {
+    return sHtml.replace(/[<>&"]/g, function (c) {
+        return {'<': '&lt;', '>': '&gt;', '&': '&amp;', '"': '&quot;'}[c];
+    });
+}
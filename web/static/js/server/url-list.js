@@ -0,0 +1,291 @@
+$(function () {
+    $('#url_table').DataTable(
+        {
+            "paging": true,
+            "serverSide": true,
+            "autowidth": false,
+            "sort": false,
+            "pagingType": "full_numbers",//分页样式
+            'iDisplayLength': 50,
+            "dom": '<i><t><"bottom"lp>',
+            "ajax": {
+                "url": "/url-list",
+                "type": "post",
+                "data": function (d) {
+                    init_dataTables_defaultParam(d);
+                    return $.extend({}, d, get_search_options());
+                }
+            },
+            columns: [
+                {
+                    data: "id",
+                    width: "5%",
+                    className: "dt-body-center",
+                    title: '<input  type="checkbox" class="checkall" />',
+                    "render": function (data, type, row) {
+                        return '<input type="checkbox" class="checkchild" value="' + row['id'] + '|' + encodeURI(row['url']) + '"/>';
+                    }
+                },
+                {
+                    data: "index",
+                    title: "序号",
+                    width: "5%"
+                },
+                {data: "method", title: "Method", width: "6%"},
+                {
+                    data: "url",
+                    title: "URL",
+                    width: "30%",
+                    render: function (data, type, row, meta) {
+                        return '<a href="' + encodeURI(data) + '" target="_blank">' + html2Escape(data) + '</a>';
+                    }
+                },
+                {
+                    data: "params", title: "参数", width: "15%",
+                    render: function (data, type, row, meta) {
+                        return html2Escape(data);
+                    }
+                },
+                {data: "content_type", title: "Content-Type", width: "10%"},
+                {
+                    data: "status", title: "状态码", width: "6%",
+                    render: function (data, type, row, meta) {
+                        return data > 0 ? data : '';
+                    }
+                },
+                {data: "source", title: "来源", width: "8%"},
+                {data: "update_datetime", title: "更新时间", width: "10%"},
+                {
+                    title: "操作",
+                    width: "5%",
+                    "render": function (data, type, row, meta) {
+                        return "<a class=\"btn btn-sm btn-danger\" href=javascript:delete_url(\"" + row["id"] + "\") role=\"button\" title=\"Delete\"><i class=\"fa fa-trash-o\"></i></a>";
+                    }
+                }
+            ],
+            infoCallback: function (settings, start, end, max, total, pre) {
+                return "共<b>" + total + "</b>条记录，当前显示" + start + "到" + end + "记录";
+            }
+        }
+    );//end datatable
+    $(".checkall").click(function () {
+        var check = $(this).prop("checked");
+        $(".checkchild").prop("checked", check);
+    });
+    //搜索
+    $("#search").click(function () {
+        $("#url_table").DataTable().draw(true);
+    });
+    //导出
+    $("#url_export").click(function () {
+        window.open('url-export?' + $.param(get_search_options()));
+    });
+    //批量删除
+    $("#batch_delete").click(function () {
+        batch_delete('#url_table', '/url-delete');
+    });
+    //对选择的URL进行漏洞验证
+    $("#new_pocscan_task").click(function () {
+        let targets = [];
+        $('#url_table').DataTable().$('input[type=checkbox]:checked').each(function (i) {
+            targets.push(decodeURI($(this).val().split("|")[1]));
+        });
+        if (targets.length === 0) {
+            swal('Warning', '请选择要验证的URL！', 'error');
+            return;
+        }
+        $('#text_target').val(targets.join("\n"));
+        load_pocfile_list(true, true, $('#select_poc_type').val());
+        $('#newPocscanTask').modal('toggle');
+    });
+    $("#select_poc_type").change(function () {
+        load_pocfile_list(true, false, $('#select_poc_type').val());
+    });
+    $("#start_task").click(function () {
+        if ($('#checkbox_xray').is(":checked") == false && $('#checkbox_nuclei').is(":checked") == false) {
+            swal('Warning', '请选择要使用的验证工具！', 'error');
+            return;
+        }
+        if ($('#checkbox_nuclei').is(":checked") && !check_nuclei_template_selected()) {
+            swal('Warning', '请选择poc file、标签、严重程度或workflow', 'error');
+            return;
+        }
+        $.post("/task-start-vulnerability",
+            {
+                "target": $('#text_target').val(),
+                'xrayverify': $('#checkbox_xray').is(":checked"),
+                'xray_poc_file': $('#select_poc_type').val() + "|" + $('#input_xray_poc_file').val(),
+                'nucleiverify': $('#checkbox_nuclei').is(":checked"),
+                'nuclei_poc_file': $('#input_nuclei_poc_file').val(),
+                'nuclei_tags': $('#input_nuclei_tags').val(),
+                'nuclei_severity': $('#select_nuclei_severity').val(),
+                'nuclei_workflow': $('#input_nuclei_workflow').val(),
+                'nuclei_auto_tags': $('#checkbox_nuclei_auto_tags').is(":checked"),
+            }, function (data, e) {
+                if (e === "success" && data['status'] == 'success') {
+                    swal({
+                            title: "新建任务成功！",
+                            text: "TaskId:" + data['msg'],
+                            type: "success",
+                            confirmButtonText: "确定",
+                            confirmButtonColor: "#41b883",
+                            closeOnConfirm: true,
+                        },
+                        function () {
+                            $('#newPocscanTask').modal('hide');
+                        });
+                } else {
+                    swal('Warning', "添加任务失败! " + data['msg'], 'error');
+                }
+            });
+    });
+});
+
+/**
+ * 移除 dataTables默认参数，并设置分页值
+ * @param param
+ */
+function init_dataTables_defaultParam(param) {
+    for (var key in param) {
+        if (key.indexOf("columns") == 0 || key.indexOf("order") == 0 || key.indexOf("search") == 0) { //以columns开头的参数删除
+            delete param[key];
+        }
+    }
+    param.pageSize = param.length;
+    param.pageNum = (param.start / param.length) + 1;
+}
+
+/**
+ * 查询条件
+ */
+function get_search_options() {
+    return {
+        "url": $('#url').val(),
+        "host": $('#host').val(),
+        "method": $('#method').val(),
+        "params": $('#params').val(),
+        "content_type": $('#content_type').val(),
+        "status": $('#status').val(),
+        "source": $('#source').val(),
+        "date_delta": $('#date_delta').val()
+    };
+}
+
+/**
+ * 加载poc文件列表
+ */
+function load_pocfile_list(xray = true, nuclei = true, xray_type = "default") {
+    if (xray) {
+        $.post("/vulnerability-load-xray-pocfile", {"type": xray_type}, function (data, e) {
+            if (e === "success") {
+                $("#datalist_xray_poc_file").empty();
+                for (let i = 0; i < data.length; i++) {
+                    $("#datalist_xray_poc_file").append("<option value='" + data[i] + "'>" + data[i] + "</option>")
+                }
+            }
+        });
+    }
+    if (nuclei) {
+        $.post("/vulnerability-load-nuclei-pocfile", {}, function (data, e) {
+            if (e === "success") {
+                $("#datalist_nuclei_poc_file").empty();
+                for (let i = 0; i < data.length; i++) {
+                    $("#datalist_nuclei_poc_file").append("<option value='" + data[i] + "'>" + data[i] + "</option>")
+                }
+            }
+        });
+        load_nuclei_template_option();
+    }
+}
+
+/**
+ * 加载nuclei模板的标签及workflow列表
+ */
+function load_nuclei_template_option() {
+    $.post("/vulnerability-load-nuclei-tags", {}, function (data, e) {
+        if (e === "success") {
+            $("#datalist_nuclei_tags").empty();
+            for (let i = 0; i < data.length; i++) {
+                $("#datalist_nuclei_tags").append("<option value='" + data[i] + "'>" + data[i] + "</option>")
+            }
+        }
+    });
+    $.post("/vulnerability-load-nuclei-template", {"protocol": "workflow"}, function (data, e) {
+        if (e === "success") {
+            $("#datalist_nuclei_workflow").empty();
+            for (let i = 0; i < data.length; i++) {
+                $("#datalist_nuclei_workflow").append("<option value='" + data[i]['path'] + "'>" + data[i]['name'] + "</option>")
+            }
+        }
+    });
+}
+
+/**
+ * nuclei是否选择了模板（poc文件、标签、严重程度、workflow或根据指纹自动选择）
+ */
+function check_nuclei_template_selected() {
+    return $('#input_nuclei_poc_file').val() != '' || $('#input_nuclei_tags').val() != '' || $('#select_nuclei_severity').val() != ''
+        || $('#input_nuclei_workflow').val() != '' || $('#checkbox_nuclei_auto_tags').is(":checked");
+}
+
+/**
+ * 删除一个URL
+ * @param id
+ */
+function delete_url(id) {
+    swal({
+            title: "确定要删除?",
+            text: "删除当前URL！",
+            type: "warning",
+            showCancelButton: true,
+            confirmButtonColor: "#DD6B55",
+            confirmButtonText: "确认删除",
+            cancelButtonText: "取消",
+            closeOnConfirm: true
+        },
+        function () {
+            $.post("/url-delete",
+                {
+                    "id": id,
+                }, function (data, e) {
+                    if (e === "success") {
+                        $('#url_table').DataTable().draw(false);
+                    }
+                });
+        });
+}
+
+//批量删除
+function batch_delete(dataTableId, url) {
+    swal({
+            title: "确定要批量删除选定的目标?",
+            text: "该操作会删除所有选定的URL！",
+            type: "warning",
+            showCancelButton: true,
+            confirmButtonColor: "#DD6B55",
+            confirmButtonText: "确认删除",
+            cancelButtonText: "取消",
+            closeOnConfirm: true
+        },
+        function () {
+            $(dataTableId).DataTable().$('input[type=checkbox]:checked').each(function (i) {
+                let id = $(this).val().split("|")[0];
+                $.ajax({
+                    type: 'post',
+                    async: false,
+                    url: url + '?id=' + id,
+                    success: function (data) {
+                    },
+                    error: function (xhr, type) {
+                    }
+                });
+            });
+            $(dataTableId).DataTable().draw(false);
+        });
+}
+
+function html2Escape(sHtml) {
+    return sHtml.replace(/[<>&"]/g, function (c) {
+        return {'<': '&lt;', '>': '&gt;', '&': '&amp;', '"': '&quot;'}[c];
+    });
+}
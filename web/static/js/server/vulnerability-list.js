@@ -1,5 +1,6 @@
 $(function () {
     //$('#btnsiderbar').click();
+    init_saved_query("vulnerability", "#vulnerability_table");
     $('#vulnerability_table').DataTable(
         {
             "paging": true,
@@ -18,7 +19,8 @@ $(function () {
                         "vul_source": $('#vul_source').val(),
                         "vul_target": $('#vul_target').val(),
                         "vul_poc_file": $('#vul_poc_file').val(),
-                        "date_delta": $('#date_delta').val()
+                        "date_delta": $('#date_delta').val(),
+                        "query": $('#query').val()
                     });
                 }
             },